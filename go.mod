@@ -252,7 +252,12 @@ require (
 	gopkg.in/asn1-ber.v1 v1.0.0-20181015200546-f715ec2f112d // indirect
 )
 
-require cloud.google.com/go/kms v1.1.0
+require (
+	cloud.google.com/go/kms v1.1.0
+	github.com/eclipse/paho.mqtt.golang v1.3.5
+	github.com/miekg/pkcs11 v1.0.3
+	github.com/segmentio/kafka-go v0.4.28
+)
 
 require (
 	github.com/Azure/go-autorest/autorest/adal v0.9.15 // indirect
@@ -261,6 +266,7 @@ require (
 	github.com/imdario/mergo v0.3.12 // indirect
 	github.com/klauspost/compress v1.13.1 // indirect
 	github.com/opencontainers/image-spec v1.0.2 // indirect
+	github.com/pierrec/lz4 v2.6.0+incompatible // indirect
 	github.com/pierrec/lz4/v4 v4.1.8 // indirect
 	github.com/segmentio/asm v1.1.1 // indirect
 )
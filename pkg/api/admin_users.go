@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -118,6 +119,8 @@ func (hs *HTTPServer) AdminUpdateUserPermissions(c *models.ReqContext) response.
 
 		return response.Error(500, "Failed to update user permissions", err)
 	}
+	hs.recordAudit(c, audit.NewEntry(c, audit.ActionRoleUpdate, audit.ResourceUser, strconv.FormatInt(userID, 10)).
+		WithDetails("isGrafanaAdmin", form.IsGrafanaAdmin))
 
 	return response.Success("User permissions updated")
 }
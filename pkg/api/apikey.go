@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/components/apikeygen"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -33,6 +34,8 @@ func (hs *HTTPServer) GetAPIKeys(c *models.ReqContext) response.Response {
 			Name:       t.Name,
 			Role:       t.Role,
 			Expiration: expiration,
+			LastUsedAt: t.LastUsedAt,
+			LastUsedIP: t.LastUsedIP,
 		}
 	}
 
@@ -80,6 +83,10 @@ func (hs *HTTPServer) AddAPIKey(c *models.ReqContext) response.Response {
 		}
 	}
 
+	if len(cmd.Permissions) > 0 {
+		return response.Error(400, "Permissions are only supported by service account tokens", nil)
+	}
+
 	cmd.ServiceAccountId = nil // Security: API keys can't be added to SAs through this endpoint since we do not implement access checks here
 	cmd.OrgId = c.OrgId
 
@@ -98,6 +105,8 @@ func (hs *HTTPServer) AddAPIKey(c *models.ReqContext) response.Response {
 		}
 		return response.Error(500, "Failed to add API Key", err)
 	}
+	hs.recordAudit(c, audit.NewEntry(c, audit.ActionCreate, audit.ResourceAPIKey, strconv.FormatInt(cmd.Result.Id, 10)).
+		WithDetails("name", cmd.Result.Name, "role", cmd.Result.Role, "secondsToLive", cmd.SecondsToLive))
 
 	result := &dtos.NewApiKeyResult{
 		ID:   cmd.Result.Id,
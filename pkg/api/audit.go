@@ -0,0 +1,16 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
+)
+
+// recordAudit records an audit entry for an action of the signed in user of
+// the request. Entries are not recorded if no audit service is configured,
+// like in the tests of the HTTP API.
+func (hs *HTTPServer) recordAudit(c *models.ReqContext, entry *audit.Entry) {
+	if hs.AuditService == nil {
+		return
+	}
+	hs.AuditService.Record(c.Req.Context(), entry)
+}
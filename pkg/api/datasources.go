@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/adapters"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -155,6 +156,7 @@ func (hs *HTTPServer) DeleteDataSourceById(c *models.ReqContext) response.Respon
 	}
 
 	hs.Live.HandleDatasourceDelete(c.OrgId, ds.Uid)
+	hs.recordAudit(c, audit.NewEntry(c, audit.ActionDelete, audit.ResourceDatasource, ds.Uid).WithDetails("name", ds.Name, "type", ds.Type))
 
 	return response.Success("Data source deleted")
 }
@@ -215,6 +217,7 @@ func (hs *HTTPServer) DeleteDataSourceByUID(c *models.ReqContext) response.Respo
 	}
 
 	hs.Live.HandleDatasourceDelete(c.OrgId, ds.Uid)
+	hs.recordAudit(c, audit.NewEntry(c, audit.ActionDelete, audit.ResourceDatasource, ds.Uid).WithDetails("name", ds.Name, "type", ds.Type))
 
 	return response.JSON(200, util.DynMap{
 		"message": "Data source deleted",
@@ -249,6 +252,8 @@ func (hs *HTTPServer) DeleteDataSourceByName(c *models.ReqContext) response.Resp
 	}
 
 	hs.Live.HandleDatasourceDelete(c.OrgId, getCmd.Result.Uid)
+	hs.recordAudit(c, audit.NewEntry(c, audit.ActionDelete, audit.ResourceDatasource, getCmd.Result.Uid).
+		WithDetails("name", getCmd.Result.Name, "type", getCmd.Result.Type))
 
 	return response.JSON(200, util.DynMap{
 		"message": "Data source deleted",
@@ -286,6 +291,8 @@ func (hs *HTTPServer) AddDataSource(c *models.ReqContext) response.Response {
 
 		return response.Error(500, "Failed to add datasource", err)
 	}
+	hs.recordAudit(c, audit.NewEntry(c, audit.ActionCreate, audit.ResourceDatasource, cmd.Result.Uid).
+		WithDetails("name", cmd.Result.Name, "type", cmd.Result.Type))
 
 	ds := convertModelToDtos(cmd.Result)
 	return response.JSON(200, util.DynMap{
@@ -352,6 +359,8 @@ func (hs *HTTPServer) UpdateDataSource(c *models.ReqContext) response.Response {
 	datasourceDTO := convertModelToDtos(query.Result)
 
 	hs.Live.HandleDatasourceUpdate(c.OrgId, datasourceDTO.UID)
+	hs.recordAudit(c, audit.NewEntry(c, audit.ActionUpdate, audit.ResourceDatasource, datasourceDTO.UID).
+		WithDetails("name", datasourceDTO.Name, "type", datasourceDTO.Type, "version", datasourceDTO.Version))
 
 	return response.JSON(200, util.DynMap{
 		"message":    "Datasource updated",
@@ -476,9 +485,29 @@ func (hs *HTTPServer) CallDatasourceResource(c *models.ReqContext) {
 		return
 	}
 
+	hs.setOAuthPassThruHeaders(c, ds)
 	hs.callPluginResource(c, plugin.ID, ds.Uid)
 }
 
+// setOAuthPassThruHeaders replaces the authorization headers of the request with the OAuth
+// tokens of the user, like the data source proxy, if the data source forwards OAuth identity.
+func (hs *HTTPServer) setOAuthPassThruHeaders(c *models.ReqContext, ds *models.DataSource) {
+	if hs.DataProxy == nil || hs.DataProxy.OAuthTokenService == nil || !hs.DataProxy.OAuthTokenService.IsOAuthPassThruEnabled(ds) {
+		return
+	}
+
+	c.Req.Header.Del("Authorization")
+	c.Req.Header.Del("X-ID-Token")
+	if token := hs.DataProxy.OAuthTokenService.GetCurrentOAuthToken(c.Req.Context(), c.SignedInUser); token != nil {
+		c.Req.Header.Set("Authorization", fmt.Sprintf("%s %s", token.Type(), token.AccessToken))
+
+		idToken, ok := token.Extra("id_token").(string)
+		if ok && idToken != "" {
+			c.Req.Header.Set("X-ID-Token", idToken)
+		}
+	}
+}
+
 func convertModelToDtos(ds *models.DataSource) dtos.DataSource {
 	dto := dtos.DataSource{
 		Id:                ds.Id,
@@ -23,6 +23,8 @@ type LoginCommand struct {
 	User     string `json:"user" binding:"Required"`
 	Password string `json:"password" binding:"Required"`
 	Remember bool   `json:"remember"`
+	// Otp is the two-factor authentication code, or a recovery code.
+	Otp string `json:"otp"`
 }
 
 type CurrentUser struct {
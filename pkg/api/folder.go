@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/services/libraryelements"
@@ -77,6 +78,7 @@ func (hs *HTTPServer) CreateFolder(c *models.ReqContext) response.Response {
 	if err != nil {
 		return apierrors.ToFolderErrorResponse(err)
 	}
+	hs.recordAudit(c, audit.NewEntry(c, audit.ActionCreate, audit.ResourceFolder, folder.Uid).WithDetails("title", folder.Title))
 
 	if hs.Cfg.EditorsCanAdmin {
 		if err := s.MakeUserAdmin(c.Req.Context(), c.OrgId, c.SignedInUser.UserId, folder.Id, true); err != nil {
@@ -99,6 +101,8 @@ func (hs *HTTPServer) UpdateFolder(c *models.ReqContext) response.Response {
 	if err != nil {
 		return apierrors.ToFolderErrorResponse(err)
 	}
+	hs.recordAudit(c, audit.NewEntry(c, audit.ActionUpdate, audit.ResourceFolder, cmd.Result.Uid).
+		WithDetails("title", cmd.Result.Title, "version", cmd.Result.Version))
 
 	g := guardian.New(c.Req.Context(), cmd.Result.Id, c.OrgId, c.SignedInUser)
 	return response.JSON(200, hs.toFolderDto(c.Req.Context(), g, cmd.Result))
@@ -118,6 +122,7 @@ func (hs *HTTPServer) DeleteFolder(c *models.ReqContext) response.Response { //
 	if err != nil {
 		return apierrors.ToFolderErrorResponse(err)
 	}
+	hs.recordAudit(c, audit.NewEntry(c, audit.ActionDelete, audit.ResourceFolder, f.Uid).WithDetails("title", f.Title))
 
 	return response.JSON(200, util.DynMap{
 		"title":   f.Title,
@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/util"
@@ -131,6 +132,8 @@ func (hs *HTTPServer) UpdateFolderPermissions(c *models.ReqContext) response.Res
 
 		return response.Error(500, "Failed to create permission", err)
 	}
+	hs.recordAudit(c, audit.NewEntry(c, audit.ActionPermissionsUpdate, audit.ResourceFolder, folder.Uid).
+		WithDetails("title", folder.Title, "items", apiCmd.Items))
 
 	return response.JSON(200, util.DynMap{
 		"message": "Folder permissions updated",
@@ -16,7 +16,9 @@ import (
 	"github.com/grafana/grafana/pkg/middleware/cookies"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/totp"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -228,6 +230,12 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext) response.Response {
 
 	user = authQuery.User
 
+	recoveryCodes, totpResp := hs.verifySecondFactor(c, authQuery, cmd.Otp)
+	if totpResp != nil {
+		resp = totpResp
+		return resp
+	}
+
 	err = hs.loginUserWithUser(user, c)
 	if err != nil {
 		var createTokenErr *models.CreateTokenErr
@@ -242,6 +250,9 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext) response.Response {
 	result := map[string]interface{}{
 		"message": "Logged in",
 	}
+	if len(recoveryCodes) > 0 {
+		result["recoveryCodes"] = recoveryCodes
+	}
 
 	if redirectTo := c.GetCookie("redirect_to"); len(redirectTo) > 0 {
 		if err := hs.ValidateRedirectTo(redirectTo); err == nil {
@@ -257,6 +268,78 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext) response.Response {
 	return resp
 }
 
+// verifySecondFactor checks the code of users who enrolled a second factor,
+// and enrols the users the policy requires one for: without a code, the
+// response has the secret to add to an authenticator app, and signing in
+// again with a code of the app enables the second factor. It returns the
+// recovery codes of a new second factor.
+func (hs *HTTPServer) verifySecondFactor(c *models.ReqContext, query *models.LoginUserQuery, code string) ([]string, *response.NormalResponse) {
+	if !hs.Cfg.TOTPEnabled {
+		return nil, nil
+	}
+
+	ctx := c.Req.Context()
+	enabled, err := hs.TOTPService.IsEnabled(ctx, query.User.Id)
+	if err != nil {
+		return nil, response.Error(http.StatusInternalServerError, "Error while trying to authenticate user", err)
+	}
+
+	if enabled {
+		if code == "" {
+			return nil, response.JSON(http.StatusUnauthorized, util.DynMap{
+				"message":      "Two-factor authentication code required",
+				"totpRequired": true,
+			})
+		}
+		if err := hs.TOTPService.Verify(ctx, query.User.Id, code); err != nil {
+			return nil, hs.invalidSecondFactor(c, query, err, "totpRequired")
+		}
+		return nil, nil
+	}
+
+	required, err := hs.TOTPService.IsRequired(ctx, query.User)
+	if err != nil {
+		return nil, response.Error(http.StatusInternalServerError, "Error while trying to authenticate user", err)
+	}
+	if !required {
+		return nil, nil
+	}
+
+	if code == "" {
+		enrolment, err := hs.TOTPService.Enrol(ctx, query.User)
+		if err != nil {
+			return nil, response.Error(http.StatusInternalServerError, "Error while trying to authenticate user", err)
+		}
+		return nil, response.JSON(http.StatusUnauthorized, util.DynMap{
+			"message":       "Two-factor authentication enrolment required",
+			"totpEnrolment": enrolment,
+		})
+	}
+
+	recoveryCodes, err := hs.TOTPService.Confirm(ctx, query.User.Id, code)
+	if err != nil {
+		return nil, hs.invalidSecondFactor(c, query, err, "totpEnrolmentRequired")
+	}
+	return recoveryCodes, nil
+}
+
+func (hs *HTTPServer) invalidSecondFactor(c *models.ReqContext, query *models.LoginUserQuery, err error, flag string) *response.NormalResponse {
+	if !errors.Is(err, totp.ErrInvalidCode) && !errors.Is(err, totp.ErrNotEnrolled) {
+		return response.Error(http.StatusInternalServerError, "Error while trying to authenticate user", err)
+	}
+
+	if errors.Is(err, totp.ErrInvalidCode) {
+		if err := login.SaveInvalidLoginAttempt(c.Req.Context(), query); err != nil {
+			hs.log.Error("Failed to save invalid login attempt", "err", err)
+		}
+	}
+
+	return response.JSON(http.StatusUnauthorized, util.DynMap{
+		"message": "Invalid two-factor authentication code",
+		flag:      true,
+	})
+}
+
 func (hs *HTTPServer) loginUserWithUser(user *models.User, c *models.ReqContext) error {
 	if user == nil {
 		return errors.New("could not login user")
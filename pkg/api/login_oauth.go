@@ -276,9 +276,15 @@ func buildExternalUserInfo(token *oauth2.Token, userInfo *social.BasicUserInfo,
 		Email:      userInfo.Email,
 		OrgRoles:   map[int64]models.RoleType{},
 		Groups:     userInfo.Groups,
+		Teams:      userInfo.Teams,
 	}
 
-	if userInfo.Role != "" {
+	if len(userInfo.OrgRoles) > 0 {
+		// The organizations and roles are mapped with org_mapping
+		for orgID, role := range userInfo.OrgRoles {
+			extUser.OrgRoles[orgID] = role
+		}
+	} else if userInfo.Role != "" {
 		rt := models.RoleType(userInfo.Role)
 		if rt.IsValid() {
 			// The user will be assigned a role in either the auto-assigned organization or in the default one
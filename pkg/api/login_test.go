@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -27,6 +28,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/secrets/fakes"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/services/totp/totptest"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -703,6 +706,95 @@ func TestLoginPostRunLokingHook(t *testing.T) {
 	}
 }
 
+func TestLoginPostSecondFactor(t *testing.T) {
+	testUser := &models.User{Id: 42, Login: "admin"}
+	mockAuthenticateUserFunc(testUser, "grafana", nil)
+	t.Cleanup(resetAuthenticateUserFunc)
+
+	testCases := []struct {
+		desc           string
+		totp           *totptest.FakeService
+		body           string
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			desc:           "enrolled user without code",
+			totp:           &totptest.FakeService{Enabled: true, ValidCode: "123456"},
+			body:           `{"user":"admin","password":"admin"}`,
+			expectedStatus: 401,
+			expectedBody:   map[string]interface{}{"message": "Two-factor authentication code required", "totpRequired": true},
+		},
+		{
+			desc:           "enrolled user with invalid code",
+			totp:           &totptest.FakeService{Enabled: true, ValidCode: "123456"},
+			body:           `{"user":"admin","password":"admin","otp":"654321"}`,
+			expectedStatus: 401,
+			expectedBody:   map[string]interface{}{"message": "Invalid two-factor authentication code", "totpRequired": true},
+		},
+		{
+			desc:           "enrolled user with valid code",
+			totp:           &totptest.FakeService{Enabled: true, ValidCode: "123456"},
+			body:           `{"user":"admin","password":"admin","otp":"123456"}`,
+			expectedStatus: 200,
+			expectedBody:   map[string]interface{}{"message": "Logged in"},
+		},
+		{
+			desc:           "user without second factor",
+			totp:           &totptest.FakeService{},
+			body:           `{"user":"admin","password":"admin"}`,
+			expectedStatus: 200,
+			expectedBody:   map[string]interface{}{"message": "Logged in"},
+		},
+		{
+			desc:           "user required to enrol",
+			totp:           &totptest.FakeService{Required: true, Enrolment: &totp.Enrolment{Secret: "SECRET", URL: "otpauth://totp/Grafana:admin?secret=SECRET"}},
+			body:           `{"user":"admin","password":"admin"}`,
+			expectedStatus: 401,
+			expectedBody: map[string]interface{}{
+				"message":       "Two-factor authentication enrolment required",
+				"totpEnrolment": map[string]interface{}{"secret": "SECRET", "url": "otpauth://totp/Grafana:admin?secret=SECRET"},
+			},
+		},
+		{
+			desc:           "user required to enrol confirming a code",
+			totp:           &totptest.FakeService{Required: true, ValidCode: "123456", RecoveryCodes: []string{"aaaaa-bbbbb"}},
+			body:           `{"user":"admin","password":"admin","otp":"123456"}`,
+			expectedStatus: 200,
+			expectedBody:   map[string]interface{}{"message": "Logged in", "recoveryCodes": []interface{}{"aaaaa-bbbbb"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			sc := setupScenarioContext(t, "/login")
+			hs := &HTTPServer{
+				log:              log.New("test"),
+				Cfg:              setting.NewCfg(),
+				License:          &licensing.OSSLicensingService{},
+				AuthTokenService: auth.NewFakeUserAuthTokenService(),
+				HooksService:     &hooks.HooksService{},
+				TOTPService:      tc.totp,
+			}
+			hs.Cfg.TOTPEnabled = true
+			hs.Cfg.DisableBruteForceLoginProtection = true
+
+			sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+				c.Req.Header.Set("Content-Type", "application/json")
+				c.Req.Body = io.NopCloser(bytes.NewBufferString(tc.body))
+				return hs.LoginPost(c)
+			})
+			sc.m.Post(sc.url, sc.defaultHandler)
+			sc.fakeReqNoAssertions("POST", sc.url).exec()
+
+			assert.Equal(t, tc.expectedStatus, sc.resp.Code)
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &body))
+			assert.Equal(t, tc.expectedBody, body)
+		})
+	}
+}
+
 type mockSocialService struct {
 	oAuthInfo       *social.OAuthInfo
 	oAuthInfos      map[string]*social.OAuthInfo
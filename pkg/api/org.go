@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
@@ -117,7 +118,7 @@ func (hs *HTTPServer) UpdateCurrentOrg(c *models.ReqContext) response.Response {
 	if err := web.Bind(c.Req, &form); err != nil {
 		return response.Error(http.StatusBadRequest, "bad request data", err)
 	}
-	return hs.updateOrgHelper(c.Req.Context(), form, c.OrgId)
+	return hs.updateOrgHelper(c, form, c.OrgId)
 }
 
 // PUT /api/orgs/:orgId
@@ -130,17 +131,20 @@ func (hs *HTTPServer) UpdateOrg(c *models.ReqContext) response.Response {
 	if err != nil {
 		return response.Error(http.StatusBadRequest, "orgId is invalid", err)
 	}
-	return hs.updateOrgHelper(c.Req.Context(), form, orgId)
+	return hs.updateOrgHelper(c, form, orgId)
 }
 
-func (hs *HTTPServer) updateOrgHelper(ctx context.Context, form dtos.UpdateOrgForm, orgID int64) response.Response {
+func (hs *HTTPServer) updateOrgHelper(c *models.ReqContext, form dtos.UpdateOrgForm, orgID int64) response.Response {
 	cmd := models.UpdateOrgCommand{Name: form.Name, OrgId: orgID}
-	if err := hs.SQLStore.UpdateOrg(ctx, &cmd); err != nil {
+	if err := hs.SQLStore.UpdateOrg(c.Req.Context(), &cmd); err != nil {
 		if errors.Is(err, models.ErrOrgNameTaken) {
 			return response.Error(400, "Organization name taken", err)
 		}
 		return response.Error(500, "Failed to update organization", err)
 	}
+	entry := audit.NewEntry(c, audit.ActionUpdate, audit.ResourceOrg, strconv.FormatInt(orgID, 10)).WithDetails("name", form.Name)
+	entry.OrgId = orgID
+	hs.recordAudit(c, entry)
 
 	return response.Success("Organization updated")
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -235,7 +236,7 @@ func (hs *HTTPServer) UpdateOrgUserForCurrentOrg(c *models.ReqContext) response.
 	if err != nil {
 		return response.Error(http.StatusBadRequest, "userId is invalid", err)
 	}
-	return hs.updateOrgUserHelper(c.Req.Context(), cmd)
+	return hs.updateOrgUserHelper(c, cmd)
 }
 
 // PATCH /api/orgs/:orgId/users/:userId
@@ -253,19 +254,22 @@ func (hs *HTTPServer) UpdateOrgUser(c *models.ReqContext) response.Response {
 	if err != nil {
 		return response.Error(http.StatusBadRequest, "userId is invalid", err)
 	}
-	return hs.updateOrgUserHelper(c.Req.Context(), cmd)
+	return hs.updateOrgUserHelper(c, cmd)
 }
 
-func (hs *HTTPServer) updateOrgUserHelper(ctx context.Context, cmd models.UpdateOrgUserCommand) response.Response {
+func (hs *HTTPServer) updateOrgUserHelper(c *models.ReqContext, cmd models.UpdateOrgUserCommand) response.Response {
 	if !cmd.Role.IsValid() {
 		return response.Error(400, "Invalid role specified", nil)
 	}
-	if err := hs.SQLStore.UpdateOrgUser(ctx, &cmd); err != nil {
+	if err := hs.SQLStore.UpdateOrgUser(c.Req.Context(), &cmd); err != nil {
 		if errors.Is(err, models.ErrLastOrgAdmin) {
 			return response.Error(400, "Cannot change role so that there is no organization admin left", nil)
 		}
 		return response.Error(500, "Failed update org user", err)
 	}
+	entry := audit.NewEntry(c, audit.ActionRoleUpdate, audit.ResourceUser, strconv.FormatInt(cmd.UserId, 10)).WithDetails("role", cmd.Role)
+	entry.OrgId = cmd.OrgId
+	hs.recordAudit(c, entry)
 
 	return response.Success("Organization user updated")
 }
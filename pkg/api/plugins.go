@@ -24,6 +24,7 @@ import (
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/plugins/manager/installer"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	"github.com/grafana/grafana/pkg/util/proxyutil"
@@ -178,6 +179,13 @@ func (hs *HTTPServer) UpdatePluginSetting(c *models.ReqContext) response.Respons
 	if err := hs.SQLStore.UpdatePluginSetting(c.Req.Context(), &cmd); err != nil {
 		return response.Error(500, "Failed to update plugin setting", err)
 	}
+	secureFields := make([]string, 0, len(cmd.SecureJsonData))
+	for key := range cmd.SecureJsonData {
+		secureFields = append(secureFields, key)
+	}
+	sort.Strings(secureFields)
+	hs.recordAudit(c, audit.NewEntry(c, audit.ActionUpdate, audit.ResourcePluginSettings, pluginID).
+		WithDetails("enabled", cmd.Enabled, "pinned", cmd.Pinned, "secureFields", secureFields))
 
 	return response.Success("Plugin settings updated")
 }
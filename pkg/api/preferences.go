@@ -3,10 +3,12 @@ package api
 import (
 	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -95,5 +97,10 @@ func (hs *HTTPServer) UpdateOrgPreferences(c *models.ReqContext) response.Respon
 	if err := web.Bind(c.Req, &dtoCmd); err != nil {
 		return response.Error(http.StatusBadRequest, "bad request data", err)
 	}
-	return hs.updatePreferencesFor(c.Req.Context(), c.OrgId, 0, 0, &dtoCmd)
+	resp := hs.updatePreferencesFor(c.Req.Context(), c.OrgId, 0, 0, &dtoCmd)
+	if resp.Status() == http.StatusOK {
+		hs.recordAudit(c, audit.NewEntry(c, audit.ActionUpdate, audit.ResourcePreferences, strconv.FormatInt(c.OrgId, 10)).
+			WithDetails("theme", dtoCmd.Theme, "timezone", dtoCmd.Timezone, "weekStart", dtoCmd.WeekStart, "homeDashboardId", dtoCmd.HomeDashboardID))
+	}
+	return resp
 }
@@ -28,6 +28,27 @@ func (hs *HTTPServer) RevokeUserAuthToken(c *models.ReqContext) response.Respons
 	return hs.revokeUserAuthTokenInternal(c, c.UserId, cmd)
 }
 
+// POST /api/user/revoke-other-auth-tokens
+func (hs *HTTPServer) RevokeOtherUserAuthTokens(c *models.ReqContext) response.Response {
+	tokens, err := hs.AuthTokenService.GetUserTokens(c.Req.Context(), c.UserId)
+	if err != nil {
+		return response.Error(500, "Failed to get user auth tokens", err)
+	}
+
+	for _, token := range tokens {
+		if c.UserToken != nil && c.UserToken.Id == token.Id {
+			continue
+		}
+		if err := hs.AuthTokenService.RevokeToken(c.Req.Context(), token, false); err != nil && !errors.Is(err, models.ErrUserTokenNotFound) {
+			return response.Error(500, "Failed to revoke user auth token", err)
+		}
+	}
+
+	return response.JSON(200, util.DynMap{
+		"message": "User auth tokens revoked",
+	})
+}
+
 func (hs *HTTPServer) logoutUserFromAllDevicesInternal(ctx context.Context, userID int64) response.Response {
 	userQuery := models.GetUserByIdQuery{Id: userID}
 
@@ -92,6 +92,23 @@ func TestUserTokenAPIEndpoint(t *testing.T) {
 		}, mock)
 	})
 
+	t.Run("When revoking the other auth tokens of the current user", func(t *testing.T) {
+		token := &models.UserToken{Id: 2}
+		revokeOtherUserAuthTokensScenario(t, "Should revoke all tokens except the active one", token, func(sc *scenarioContext) {
+			sc.userAuthTokenService.GetUserTokensProvider = func(ctx context.Context, userId int64) ([]*models.UserToken, error) {
+				return []*models.UserToken{{Id: 1}, {Id: 2}, {Id: 3}}, nil
+			}
+			var revoked []int64
+			sc.userAuthTokenService.RevokeTokenProvider = func(ctx context.Context, token *models.UserToken, soft bool) error {
+				revoked = append(revoked, token.Id)
+				return nil
+			}
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+			assert.Equal(t, 200, sc.resp.Code)
+			assert.Equal(t, []int64{1, 3}, revoked)
+		})
+	})
+
 	t.Run("When gets auth tokens for a user", func(t *testing.T) {
 		currentToken := &models.UserToken{Id: 1}
 		mock := mockstore.NewSQLStoreMock()
@@ -265,6 +282,30 @@ func revokeUserAuthTokenInternalScenario(t *testing.T, desc string, cmd models.R
 	})
 }
 
+func revokeOtherUserAuthTokensScenario(t *testing.T, desc string, token *models.UserToken, fn scenarioFunc) {
+	t.Run(desc, func(t *testing.T) {
+		fakeAuthTokenService := auth.NewFakeUserAuthTokenService()
+
+		hs := HTTPServer{
+			Bus:              bus.GetBus(),
+			AuthTokenService: fakeAuthTokenService,
+		}
+
+		sc := setupScenarioContext(t, "/")
+		sc.userAuthTokenService = fakeAuthTokenService
+		sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+			sc.context = c
+			sc.context.UserId = testUserID
+			sc.context.OrgId = testOrgID
+			sc.context.UserToken = token
+
+			return hs.RevokeOtherUserAuthTokens(c)
+		})
+		sc.m.Post("/", sc.defaultHandler)
+		fn(sc)
+	})
+}
+
 func getUserAuthTokensInternalScenario(t *testing.T, desc string, token *models.UserToken, fn scenarioFunc, sqlStore sqlstore.Store) {
 	t.Run(desc, func(t *testing.T) {
 		t.Cleanup(bus.ClearBusHandlers)
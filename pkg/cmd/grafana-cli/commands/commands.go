@@ -163,6 +163,11 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:   "reset-user-totp",
+		Usage:  "reset-user-totp <user login or email>",
+		Action: runDbCommand(resetUserTOTPCommand),
+	},
 	{
 		Name:  "data-migration",
 		Usage: "Runs a script that migrates or cleanups data in your database",
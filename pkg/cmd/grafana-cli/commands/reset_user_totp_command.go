@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/services/totp/database"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// resetUserTOTPCommand removes the second factor of a user who lost their
+// authenticator app and recovery codes, so they can sign in with their
+// password and enrol again.
+func resetUserTOTPCommand(c utils.CommandLine, sqlStore *sqlstore.SQLStore) error {
+	loginOrEmail := c.Args().First()
+	if loginOrEmail == "" {
+		return fmt.Errorf("missing user login or email")
+	}
+
+	userQuery := models.GetUserByLoginQuery{LoginOrEmail: loginOrEmail}
+	if err := sqlStore.GetUserByLogin(context.Background(), &userQuery); err != nil {
+		return fmt.Errorf("could not read user from database. Error: %v", err)
+	}
+
+	if err := database.NewTOTPStore(sqlStore).Delete(context.Background(), userQuery.Result.Id); err != nil {
+		if errors.Is(err, totp.ErrNotEnrolled) {
+			return fmt.Errorf("user %s has not enrolled two-factor authentication", userQuery.Result.Login)
+		}
+		return errutil.Wrapf(err, "failed to reset two-factor authentication")
+	}
+
+	logger.Infof("\n")
+	logger.Infof("Two-factor authentication of %s reset successfully %s", userQuery.Result.Login, color.GreenString("✔"))
+
+	return nil
+}
@@ -85,7 +85,7 @@ func (s jsonSecret) reencrypt(secretsSrv *manager.SecretsService, sess *xorm.Ses
 			continue
 		}
 
-		decrypted, err := secretsSrv.DecryptJsonData(context.Background(), row.SecureJsonData)
+		decrypted, err := decryptJsonData(secretsSrv, row.SecureJsonData)
 		if err != nil {
 			return err
 		}
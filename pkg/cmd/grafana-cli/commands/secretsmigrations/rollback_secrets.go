@@ -96,7 +96,7 @@ func (s jsonSecret) rollback(
 			continue
 		}
 
-		decrypted, err := secretsSrv.DecryptJsonData(context.Background(), row.SecureJsonData)
+		decrypted, err := decryptJsonData(secretsSrv, row.SecureJsonData)
 		if err != nil {
 			return err
 		}
@@ -1,5 +1,11 @@
 package secretsmigrations
 
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
 type simpleSecret struct {
 	tableName       string
 	columnName      string
@@ -11,3 +17,17 @@ type jsonSecret struct {
 }
 
 type alertingSecret struct{}
+
+// decryptJsonData decrypts secure JSON data without resolving the references
+// to external secrets it may contain, so they are migrated as they are.
+func decryptJsonData(secretsSrv *manager.SecretsService, sjd map[string][]byte) (map[string]string, error) {
+	decrypted := make(map[string]string, len(sjd))
+	for key, data := range sjd {
+		decryptedData, err := secretsSrv.Decrypt(context.Background(), data)
+		if err != nil {
+			return nil, err
+		}
+		decrypted[key] = string(decryptedData)
+	}
+	return decrypted, nil
+}
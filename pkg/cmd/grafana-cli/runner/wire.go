@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/secrets"
 	secretsDatabase "github.com/grafana/grafana/pkg/services/secrets/database"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+	secretsReferences "github.com/grafana/grafana/pkg/services/secrets/references"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
@@ -36,6 +37,7 @@ var wireSet = wire.NewSet(
 	secretsDatabase.ProvideSecretsStore,
 	wire.Bind(new(secrets.Store), new(*secretsDatabase.SecretsStoreImpl)),
 	secretsManager.ProvideSecretsService,
+	secretsReferences.ProvideResolver,
 	wire.Bind(new(secrets.Service), new(*secretsManager.SecretsService)),
 	hooks.ProvideService,
 )
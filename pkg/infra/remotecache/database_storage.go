@@ -2,6 +2,9 @@ package remotecache
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -132,6 +135,83 @@ func (dc *databaseCache) Delete(ctx context.Context, key string) error {
 	})
 }
 
+// Incr increments a counter. The updates compare and swap the stored value, so
+// that concurrent increments from other instances are not lost.
+func (dc *databaseCache) Incr(ctx context.Context, key string, expire time.Duration) (int64, error) {
+	var expiresInSeconds int64
+	if expire != 0 {
+		expiresInSeconds = int64(expire) / int64(time.Second)
+	}
+
+	for i := 0; i < maxCounterRetries; i++ {
+		count, err := dc.incr(ctx, key, expiresInSeconds)
+		if !errors.Is(err, ErrCounterConflict) {
+			return count, err
+		}
+	}
+	return 0, ErrCounterConflict
+}
+
+func (dc *databaseCache) incr(ctx context.Context, key string, expires int64) (int64, error) {
+	var count int64
+	err := dc.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		item := CacheData{}
+		exist, err := session.Where("cache_key= ?", key).Get(&item)
+		if err != nil {
+			return err
+		}
+
+		now := getTime().Unix()
+		if !exist {
+			count = 1
+			sql := `INSERT INTO cache_data (cache_key,data,created_at,expires) VALUES(?,?,?,?)`
+			_, err := session.Exec(sql, key, counterData(count), now, expires)
+			if err != nil && (dc.SQLStore.Dialect.IsUniqueConstraintViolation(err) || dc.SQLStore.Dialect.IsDeadlock(err)) {
+				return ErrCounterConflict
+			}
+			return err
+		}
+
+		var sql string
+		var args []interface{}
+		if item.Expires > 0 && now-item.CreatedAt >= item.Expires {
+			// the counter expired, start over
+			count = 1
+			sql = `UPDATE cache_data SET data=?, created_at=?, expires=? WHERE cache_key=? AND data=? AND created_at=?`
+			args = []interface{}{counterData(count), now, expires, key, item.Data, item.CreatedAt}
+		} else {
+			current, err := strconv.ParseInt(string(item.Data), 10, 64)
+			if err != nil {
+				return fmt.Errorf("cache item %q is not a counter", key)
+			}
+			count = current + 1
+			sql = `UPDATE cache_data SET data=? WHERE cache_key=? AND data=?`
+			args = []interface{}{counterData(count), key, item.Data}
+		}
+
+		res, err := session.Exec(append([]interface{}{sql}, args...)...)
+		if err != nil {
+			if dc.SQLStore.Dialect.IsDeadlock(err) {
+				return ErrCounterConflict
+			}
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrCounterConflict
+		}
+		return nil
+	})
+	return count, err
+}
+
+func counterData(count int64) []byte {
+	return []byte(strconv.FormatInt(count, 10))
+}
+
 // CacheData is the struct representing the table in the database
 type CacheData struct {
 	CacheKey  string
@@ -74,3 +74,25 @@ func TestSecondSet(t *testing.T) {
 	err = db.Set(context.Background(), "killa-gorilla", obj, 0)
 	assert.Equal(t, err, nil)
 }
+
+func TestDatabaseStorageCounterExpiration(t *testing.T) {
+	db := &databaseCache{
+		SQLStore: sqlstore.InitTestDB(t),
+		log:      log.New("remotecache.database"),
+	}
+	t.Cleanup(func() { getTime = time.Now })
+
+	getTime = func() time.Time { return time.Now().Add(-time.Hour) }
+	count, err := db.Incr(context.Background(), "counter", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	count, err = db.Incr(context.Background(), "counter", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// the counter expired, so it starts over
+	getTime = time.Now
+	count, err = db.Incr(context.Background(), "counter", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
@@ -2,6 +2,7 @@ package remotecache
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
@@ -70,3 +71,33 @@ func (s *memcachedStorage) Get(ctx context.Context, key string) (interface{}, er
 func (s *memcachedStorage) Delete(ctx context.Context, key string) error {
 	return s.c.Delete(key)
 }
+
+// Incr increments a counter in the cache.
+func (s *memcachedStorage) Incr(ctx context.Context, key string, expires time.Duration) (int64, error) {
+	// memcached expirations are in seconds, don't let short ones round to "never"
+	expiresInSeconds := int64(expires) / int64(time.Second)
+	if expiresInSeconds < 1 {
+		expiresInSeconds = 1
+	}
+
+	for i := 0; i < maxCounterRetries; i++ {
+		count, err := s.c.Increment(key, 1)
+		if err == nil {
+			return int64(count), nil
+		}
+		if !errors.Is(err, memcache.ErrCacheMiss) {
+			return 0, err
+		}
+
+		// Add fails if another instance created the counter in the meantime,
+		// increment that one instead
+		err = s.c.Add(newItem(key, []byte("1"), int32(expiresInSeconds)))
+		if err == nil {
+			return 1, nil
+		}
+		if !errors.Is(err, memcache.ErrNotStored) {
+			return 0, err
+		}
+	}
+	return 0, ErrCounterConflict
+}
@@ -15,6 +15,16 @@ import (
 
 const redisCacheType = "redis"
 
+// incrScript sets the expiration of a counter when it's created, in the same
+// round trip so that a counter can't be left without expiration.
+var incrScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
 type redisStorage struct {
 	c *redis.Client
 }
@@ -117,3 +127,8 @@ func (s *redisStorage) Delete(ctx context.Context, key string) error {
 	cmd := s.c.Del(ctx, key)
 	return cmd.Err()
 }
+
+// Incr increments a counter in session.
+func (s *redisStorage) Incr(ctx context.Context, key string, expires time.Duration) (int64, error) {
+	return incrScript.Run(ctx, s.c, []string{key}, expires.Milliseconds()).Int64()
+}
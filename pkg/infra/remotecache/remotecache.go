@@ -21,7 +21,15 @@ var (
 	// ErrInvalidCacheType is returned if the type is invalid
 	ErrInvalidCacheType = errors.New("invalid remote cache name")
 
+	// ErrCounterConflict is returned if a counter couldn't be incremented
+	// because of concurrent updates
+	ErrCounterConflict = errors.New("counter was concurrently updated")
+
 	defaultMaxCacheExpiration = time.Hour * 24
+
+	// maxCounterRetries is how many times an increment is retried when it
+	// conflicts with another instance
+	maxCounterRetries = 3
 )
 
 const (
@@ -55,6 +63,11 @@ type CacheStorage interface {
 
 	// Delete object from cache
 	Delete(ctx context.Context, key string) error
+
+	// Incr atomically increments the counter stored at key and returns its new value.
+	// A missing counter is created with the `expire` expiration, which later increments
+	// don't extend. Counters are not gob encoded and can't be read with Get.
+	Incr(ctx context.Context, key string, expire time.Duration) (int64, error)
 }
 
 // RemoteCache allows Grafana to cache data outside its own process
@@ -84,6 +97,15 @@ func (ds *RemoteCache) Delete(ctx context.Context, key string) error {
 	return ds.client.Delete(ctx, key)
 }
 
+// Incr atomically increments the counter stored at key. if `expire` is set to zero it will default to 24h
+func (ds *RemoteCache) Incr(ctx context.Context, key string, expire time.Duration) (int64, error) {
+	if expire == 0 {
+		expire = defaultMaxCacheExpiration
+	}
+
+	return ds.client.Incr(ctx, key, expire)
+}
+
 // Run starts the backend processes for cache clients.
 func (ds *RemoteCache) Run(ctx context.Context) error {
 	// create new interface if more clients need GC jobs
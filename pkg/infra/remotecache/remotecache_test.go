@@ -51,6 +51,7 @@ func TestInvalidCacheTypeReturnsError(t *testing.T) {
 func runTestsForClient(t *testing.T, client CacheStorage) {
 	canPutGetAndDeleteCachedObjects(t, client)
 	canNotFetchExpiredItems(t, client)
+	canIncrementCounters(t, client)
 }
 
 func canPutGetAndDeleteCachedObjects(t *testing.T, client CacheStorage) {
@@ -74,6 +75,21 @@ func canPutGetAndDeleteCachedObjects(t *testing.T, client CacheStorage) {
 	assert.Equal(t, err, ErrCacheItemNotFound)
 }
 
+func canIncrementCounters(t *testing.T, client CacheStorage) {
+	for i := int64(1); i <= 3; i++ {
+		count, err := client.Incr(context.Background(), "counter1", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, i, count)
+	}
+
+	err := client.Delete(context.Background(), "counter1")
+	require.NoError(t, err)
+
+	count, err := client.Incr(context.Background(), "counter1", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
 func canNotFetchExpiredItems(t *testing.T, client CacheStorage) {
 	cacheableStruct := CacheableStruct{String: "hej", Int64: 2000}
 
@@ -13,6 +13,18 @@ var (
 	loginAttemptsWindow           = time.Minute * 5
 )
 
+// ValidateLoginAttempts returns ErrTooManyLoginAttempts if the user had too
+// many invalid login attempts recently, which includes invalid second factor
+// codes.
+func ValidateLoginAttempts(ctx context.Context, query *models.LoginUserQuery) error {
+	return validateLoginAttempts(ctx, query)
+}
+
+// SaveInvalidLoginAttempt records an invalid login attempt of the user.
+func SaveInvalidLoginAttempt(ctx context.Context, query *models.LoginUserQuery) error {
+	return saveInvalidLoginAttempt(ctx, query)
+}
+
 var validateLoginAttempts = func(ctx context.Context, query *models.LoginUserQuery) error {
 	if query.Cfg.DisableBruteForceLoginProtection {
 		return nil
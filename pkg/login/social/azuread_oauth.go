@@ -82,14 +82,27 @@ func (s *SocialAzureAD) UserInfo(client *http.Client, token *oauth2.Token) (*Bas
 		return nil, errMissingGroupMembership
 	}
 
-	return &BasicUserInfo{
+	userInfo := &BasicUserInfo{
 		Id:     claims.ID,
 		Name:   claims.Name,
 		Email:  email,
 		Login:  email,
 		Role:   string(role),
 		Groups: groups,
-	}, nil
+	}
+
+	// org_attribute_path is searched in the claims of the ID token
+	var rawClaims map[string]interface{}
+	if err := parsedToken.UnsafeClaimsWithoutVerification(&rawClaims); err != nil {
+		return nil, errutil.Wrapf(err, "error getting claims from id token")
+	}
+	rawJSON, err := json.Marshal(rawClaims)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "error encoding id token claims")
+	}
+	s.applyMappings(userInfo, rawJSON)
+
+	return userInfo, nil
 }
 
 func (s *SocialAzureAD) IsGroupMember(groups []string) bool {
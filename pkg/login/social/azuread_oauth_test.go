@@ -282,8 +282,12 @@ func TestSocialAzureAD_UserInfo(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			socialBase := tt.fields.SocialBase
+			if socialBase == nil {
+				socialBase = newSocialBase("azuread", &oauth2.Config{}, &OAuthInfo{})
+			}
 			s := &SocialAzureAD{
-				SocialBase:        tt.fields.SocialBase,
+				SocialBase:        socialBase,
 				allowedGroups:     tt.fields.allowedGroups,
 				autoAssignOrgRole: tt.fields.autoAssignOrgRole,
 			}
@@ -105,10 +105,12 @@ func (s *SocialGenericOAuth) UserInfo(client *http.Client, token *oauth2.Token)
 	apiData := s.extractFromAPI(client)
 
 	userInfo := &BasicUserInfo{}
+	var rawJSONs [][]byte
 	for _, data := range []*UserInfoJson{tokenData, apiData} {
 		if data == nil {
 			continue
 		}
+		rawJSONs = append(rawJSONs, data.rawJSON)
 
 		s.log.Debug("Processing external user info", "source", data.source, "data", data)
 
@@ -193,6 +195,8 @@ func (s *SocialGenericOAuth) UserInfo(client *http.Client, token *oauth2.Token)
 		return nil, errors.New("user not a member of one of the required organizations")
 	}
 
+	s.applyMappings(userInfo, rawJSONs...)
+
 	s.log.Debug("User info result", "result", userInfo)
 	return userInfo, nil
 }
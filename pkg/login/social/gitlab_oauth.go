@@ -133,6 +133,8 @@ func (s *SocialGitlab) UserInfo(client *http.Client, token *oauth2.Token) (*Basi
 		return nil, errMissingGroupMembership
 	}
 
+	s.applyMappings(userInfo, response.Body)
+
 	return userInfo, nil
 }
 
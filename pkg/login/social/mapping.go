@@ -0,0 +1,152 @@
+package social
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// mapping is an org_mapping or team_mapping entry, <value>:<org id>:<target>.
+// It applies to users with the value among their claims, or to all users when
+// the value is *.
+type mapping struct {
+	value  string
+	orgID  int64
+	target string
+}
+
+func (m mapping) matches(values []string) bool {
+	if m.value == "*" {
+		return true
+	}
+	for _, v := range values {
+		if v == m.value {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMappings parses mapping entries, skipping the invalid ones. The value
+// may contain colons, the org ID and the target are the last two parts.
+func parseMappings(logger log.Logger, setting string, entries []string, validTarget func(string) bool) []mapping {
+	mappings := make([]mapping, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) < 3 {
+			logger.Warn("Skipping invalid mapping, the format is <value>:<org id>:<target>", "setting", setting, "mapping", entry)
+			continue
+		}
+
+		value := strings.Join(parts[:len(parts)-2], ":")
+		orgID, err := strconv.ParseInt(parts[len(parts)-2], 10, 64)
+		target := parts[len(parts)-1]
+		if value == "" || err != nil || orgID <= 0 || !validTarget(target) {
+			logger.Warn("Skipping invalid mapping", "setting", setting, "mapping", entry)
+			continue
+		}
+
+		mappings = append(mappings, mapping{value: value, orgID: orgID, target: target})
+	}
+	return mappings
+}
+
+func isValidRole(role string) bool {
+	return models.RoleType(role).IsValid()
+}
+
+func isValidTeamID(id string) bool {
+	teamID, err := strconv.ParseInt(id, 10, 64)
+	return err == nil && teamID > 0
+}
+
+// applyMappings sets the organization roles and the teams of a user from the
+// org_mapping and team_mapping of the provider. Organizations are matched
+// against the result of org_attribute_path in the first user info JSON it
+// finds values in, or against the groups of the user without a path. Teams
+// are matched against the groups.
+func (s *SocialBase) applyMappings(userInfo *BasicUserInfo, rawJSONs ...[]byte) {
+	if len(s.orgMapping) > 0 {
+		values := userInfo.Groups
+		if s.orgAttributePath != "" {
+			values = nil
+			for _, rawJSON := range rawJSONs {
+				if values = s.extractOrgValues(rawJSON); len(values) > 0 {
+					break
+				}
+			}
+		}
+
+		userInfo.OrgRoles = mapOrgRoles(s.orgMapping, values)
+		if len(userInfo.OrgRoles) == 0 {
+			s.log.Debug("User matches no org_mapping, using the default organization assignment", "login", userInfo.Login)
+		}
+	}
+
+	if len(s.teamMapping) > 0 {
+		userInfo.Teams = mapTeams(s.teamMapping, userInfo.Groups)
+	}
+}
+
+func (s *SocialBase) extractOrgValues(rawJSON []byte) []string {
+	if len(rawJSON) == 0 {
+		return nil
+	}
+
+	val, err := s.searchJSONForAttr(s.orgAttributePath, rawJSON)
+	if err != nil {
+		s.log.Warn("Failed to search JSON for organizations", "error", err)
+		return nil
+	}
+
+	switch v := val.(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// mapOrgRoles returns the role of the user in each organization they're
+// mapped to. A user mapped to an organization more than once gets the highest
+// role.
+func mapOrgRoles(mappings []mapping, values []string) map[int64]models.RoleType {
+	orgRoles := map[int64]models.RoleType{}
+	for _, m := range mappings {
+		if !m.matches(values) {
+			continue
+		}
+		role := models.RoleType(m.target)
+		if current, ok := orgRoles[m.orgID]; !ok || role.Includes(current) {
+			orgRoles[m.orgID] = role
+		}
+	}
+	return orgRoles
+}
+
+// mapTeams returns the teams the user is a member of. The result is never nil,
+// an empty list removes the user from the teams they were synced to before.
+func mapTeams(mappings []mapping, groups []string) []models.ExternalTeam {
+	teams := []models.ExternalTeam{}
+	seen := map[int64]bool{}
+	for _, m := range mappings {
+		teamID, _ := strconv.ParseInt(m.target, 10, 64)
+		if seen[teamID] || !m.matches(groups) {
+			continue
+		}
+		seen[teamID] = true
+		teams = append(teams, models.ExternalTeam{OrgId: m.orgID, TeamId: teamID})
+	}
+	return teams
+}
@@ -0,0 +1,140 @@
+package social
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestParseMappings(t *testing.T) {
+	logger := newLogger("mapping_test", "debug")
+
+	mappings := parseMappings(logger, "org_mapping", []string{
+		"admins:1:Admin",
+		"cn=devs,ou=groups:2:Editor",
+		"*:3:Viewer",
+		"invalid",
+		"devs:org:Editor",
+		"devs:1:Owner",
+		":1:Viewer",
+	}, isValidRole)
+
+	assert.Equal(t, []mapping{
+		{value: "admins", orgID: 1, target: "Admin"},
+		{value: "cn=devs,ou=groups", orgID: 2, target: "Editor"},
+		{value: "*", orgID: 3, target: "Viewer"},
+	}, mappings)
+
+	teamMappings := parseMappings(logger, "team_mapping", []string{"devs:1:5", "devs:1:Developers"}, isValidTeamID)
+	assert.Equal(t, []mapping{{value: "devs", orgID: 1, target: "5"}}, teamMappings)
+}
+
+func TestMapOrgRoles(t *testing.T) {
+	mappings := []mapping{
+		{value: "viewers", orgID: 1, target: "Viewer"},
+		{value: "admins", orgID: 1, target: "Admin"},
+		{value: "editors", orgID: 1, target: "Editor"},
+		{value: "editors", orgID: 2, target: "Editor"},
+		{value: "*", orgID: 3, target: "Viewer"},
+	}
+
+	assert.Equal(t, map[int64]models.RoleType{
+		1: models.ROLE_ADMIN,
+		2: models.ROLE_EDITOR,
+		3: models.ROLE_VIEWER,
+	}, mapOrgRoles(mappings, []string{"viewers", "admins", "editors"}))
+
+	assert.Equal(t, map[int64]models.RoleType{
+		1: models.ROLE_VIEWER,
+		3: models.ROLE_VIEWER,
+	}, mapOrgRoles(mappings, []string{"viewers"}))
+
+	assert.Equal(t, map[int64]models.RoleType{3: models.ROLE_VIEWER}, mapOrgRoles(mappings, nil))
+}
+
+func TestMapTeams(t *testing.T) {
+	mappings := []mapping{
+		{value: "devs", orgID: 1, target: "5"},
+		{value: "ops", orgID: 1, target: "5"},
+		{value: "ops", orgID: 2, target: "7"},
+	}
+
+	assert.Equal(t, []models.ExternalTeam{{OrgId: 1, TeamId: 5}, {OrgId: 2, TeamId: 7}}, mapTeams(mappings, []string{"devs", "ops"}))
+	assert.Equal(t, []models.ExternalTeam{}, mapTeams(mappings, []string{"sales"}))
+}
+
+func TestUserInfoMapsOrganizationsAndTeams(t *testing.T) {
+	logger := newLogger("generic_oauth_test", "debug")
+	provider := SocialGenericOAuth{
+		SocialBase: &SocialBase{
+			log:         logger,
+			orgMapping:  parseMappings(logger, "org_mapping", []string{"acme:1:Editor", "acme/ops:2:Admin", "*:3:Viewer"}, isValidRole),
+			teamMapping: parseMappings(logger, "team_mapping", []string{"devs:1:5", "ops:2:7"}, isValidTeamID),
+		},
+		groupsAttributePath: "groups",
+	}
+
+	tests := []struct {
+		name             string
+		orgAttributePath string
+		responseBody     interface{}
+		expectedOrgRoles map[int64]models.RoleType
+		expectedTeams    []models.ExternalTeam
+	}{
+		{
+			name:             "Organizations are matched against the result of org_attribute_path",
+			orgAttributePath: "info.orgs",
+			responseBody: map[string]interface{}{
+				"groups": []string{"devs"},
+				"info":   map[string]interface{}{"orgs": []string{"acme", "acme/ops"}},
+			},
+			expectedOrgRoles: map[int64]models.RoleType{1: models.ROLE_EDITOR, 2: models.ROLE_ADMIN, 3: models.ROLE_VIEWER},
+			expectedTeams:    []models.ExternalTeam{{OrgId: 1, TeamId: 5}},
+		},
+		{
+			name:             "Organizations are matched against a single value",
+			orgAttributePath: "info.org",
+			responseBody: map[string]interface{}{
+				"info": map[string]interface{}{"org": "acme"},
+			},
+			expectedOrgRoles: map[int64]models.RoleType{1: models.ROLE_EDITOR, 3: models.ROLE_VIEWER},
+			expectedTeams:    []models.ExternalTeam{},
+		},
+		{
+			name: "Organizations are matched against the groups without org_attribute_path",
+			responseBody: map[string]interface{}{
+				"groups": []string{"acme", "ops"},
+			},
+			expectedOrgRoles: map[int64]models.RoleType{1: models.ROLE_EDITOR, 3: models.ROLE_VIEWER},
+			expectedTeams:    []models.ExternalTeam{{OrgId: 2, TeamId: 7}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider.orgAttributePath = test.orgAttributePath
+			body, err := json.Marshal(test.responseBody)
+			require.NoError(t, err)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, err := w.Write(body)
+				require.NoError(t, err)
+			}))
+			defer ts.Close()
+			provider.apiUrl = ts.URL
+
+			userInfo, err := provider.UserInfo(ts.Client(), &oauth2.Token{Expiry: time.Now()})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOrgRoles, userInfo.OrgRoles)
+			assert.Equal(t, test.expectedTeams, userInfo.Teams)
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"golang.org/x/oauth2"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -50,6 +51,9 @@ type OAuthInfo struct {
 	TlsClientCa            string
 	TlsSkipVerify          bool
 	UsePKCE                bool
+	OrgAttributePath       string
+	OrgMapping             []string
+	TeamMapping            []string
 }
 
 func ProvideService(cfg *setting.Cfg) *SocialService {
@@ -86,6 +90,9 @@ func ProvideService(cfg *setting.Cfg) *SocialService {
 			TlsClientCa:          sec.Key("tls_client_ca").String(),
 			TlsSkipVerify:        sec.Key("tls_skip_verify_insecure").MustBool(),
 			UsePKCE:              sec.Key("use_pkce").MustBool(),
+			OrgAttributePath:     sec.Key("org_attribute_path").String(),
+			OrgMapping:           util.SplitString(sec.Key("org_mapping").String()),
+			TeamMapping:          util.SplitString(sec.Key("team_mapping").String()),
 		}
 
 		// when empty_scopes parameter exists and is true, overwrite scope with empty value
@@ -208,13 +215,15 @@ func ProvideService(cfg *setting.Cfg) *SocialService {
 }
 
 type BasicUserInfo struct {
-	Id      string
-	Name    string
-	Email   string
-	Login   string
-	Company string
-	Role    string
-	Groups  []string
+	Id       string
+	Name     string
+	Email    string
+	Login    string
+	Company  string
+	Role     string
+	Groups   []string
+	OrgRoles map[int64]models.RoleType
+	Teams    []models.ExternalTeam
 }
 
 type SocialConnector interface {
@@ -231,9 +240,12 @@ type SocialConnector interface {
 
 type SocialBase struct {
 	*oauth2.Config
-	log            log.Logger
-	allowSignup    bool
-	allowedDomains []string
+	log              log.Logger
+	allowSignup      bool
+	allowedDomains   []string
+	orgAttributePath string
+	orgMapping       []mapping
+	teamMapping      []mapping
 }
 
 type Error struct {
@@ -266,10 +278,13 @@ func newSocialBase(name string, config *oauth2.Config, info *OAuthInfo) *SocialB
 	logger := log.New("oauth." + name)
 
 	return &SocialBase{
-		Config:         config,
-		log:            logger,
-		allowSignup:    info.AllowSignup,
-		allowedDomains: info.AllowedDomains,
+		Config:           config,
+		log:              logger,
+		allowSignup:      info.AllowSignup,
+		allowedDomains:   info.AllowedDomains,
+		orgAttributePath: info.OrgAttributePath,
+		orgMapping:       parseMappings(logger, "org_mapping", info.OrgMapping, isValidRole),
+		teamMapping:      parseMappings(logger, "team_mapping", info.TeamMapping, isValidTeamID),
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/login"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/totp/totptest"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/stretchr/testify/assert"
@@ -74,6 +75,69 @@ func TestMiddlewareBasicAuth(t *testing.T) {
 		assert.Equal(t, id, sc.context.UserId)
 	}, configure)
 
+	middlewareScenario(t, "Handles user with two-factor authentication", func(t *testing.T, sc *scenarioContext) {
+		const password = "MyPass"
+		const salt = "Salt"
+
+		login.Init()
+
+		bus.AddHandler("user-query", func(ctx context.Context, query *models.GetUserByLoginQuery) error {
+			encoded, err := util.EncodePassword(password, salt)
+			if err != nil {
+				return err
+			}
+			query.Result = &models.User{
+				Password: encoded,
+				Id:       id,
+				Salt:     salt,
+			}
+			return nil
+		})
+
+		sc.contextHandler.TOTPService = &totptest.FakeService{Enabled: true}
+
+		authHeader := util.GetBasicAuthHeader("myUser", password)
+		sc.fakeReq("GET", "/").withAuthorizationHeader(authHeader).exec()
+
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, "Basic authentication is not allowed for users with two-factor authentication", sc.respJson["message"])
+	}, func(cfg *setting.Cfg) {
+		configure(cfg)
+		cfg.TOTPEnabled = true
+	})
+
+	middlewareScenario(t, "Handles user required to use two-factor authentication", func(t *testing.T, sc *scenarioContext) {
+		const password = "MyPass"
+		const salt = "Salt"
+
+		login.Init()
+
+		bus.AddHandler("user-query", func(ctx context.Context, query *models.GetUserByLoginQuery) error {
+			encoded, err := util.EncodePassword(password, salt)
+			if err != nil {
+				return err
+			}
+			query.Result = &models.User{
+				Password: encoded,
+				Id:       id,
+				Salt:     salt,
+			}
+			return nil
+		})
+
+		// an admin who hasn't enrolled yet, with require_for_admins
+		sc.contextHandler.TOTPService = &totptest.FakeService{Required: true}
+
+		authHeader := util.GetBasicAuthHeader("myUser", password)
+		sc.fakeReq("GET", "/").withAuthorizationHeader(authHeader).exec()
+
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, "Basic authentication is not allowed for users required to use two-factor authentication", sc.respJson["message"])
+	}, func(cfg *setting.Cfg) {
+		configure(cfg)
+		cfg.TOTPEnabled = true
+	})
+
 	middlewareScenario(t, "Auth sequence", func(t *testing.T, sc *scenarioContext) {
 		const password = "MyPass"
 		const salt = "Salt"
@@ -195,3 +195,151 @@ func TestMiddlewareJWTAuth(t *testing.T) {
 		assert.Equal(t, contexthandler.InvalidJWT, sc.respJson["message"])
 	}, configure, configureUsernameClaim)
 }
+
+func TestMiddlewareJWTServiceAccountAuth(t *testing.T) {
+	const saID int64 = 7
+	const orgID int64 = 2
+	const clientID = "ci-pipeline"
+
+	token := "header.payload.signature"
+
+	configure := func(cfg *setting.Cfg) {
+		cfg.JWTAuthEnabled = true
+		cfg.JWTAuthServiceAccountsEnabled = true
+		cfg.JWTAuthServiceAccountClaim = "client_id"
+		cfg.JWTAuthServiceAccountAudience = []string{"grafana"}
+		cfg.JWTAuthServiceAccountScopes = []string{"grafana:api"}
+	}
+
+	handleLinkedServiceAccount := func(t *testing.T) {
+		bus.AddHandler("get-auth-info", func(ctx context.Context, query *models.GetAuthInfoQuery) error {
+			if query.AuthModule != models.AuthModuleJWTServiceAccount || query.AuthId != clientID {
+				return models.ErrUserNotFound
+			}
+			query.Result = &models.UserAuth{UserId: saID, AuthModule: query.AuthModule, AuthId: query.AuthId}
+			return nil
+		})
+		bus.AddHandler("get-sign-user", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+			if query.UserId != saID {
+				return models.ErrUserNotFound
+			}
+			query.Result = &models.SignedInUser{UserId: saID, OrgId: orgID, Login: "Service-Account-ci"}
+			return nil
+		})
+	}
+
+	middlewareScenario(t, "Valid bearer token of a linked client", func(t *testing.T, sc *scenarioContext) {
+		var verifiedToken string
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			verifiedToken = token
+			return models.JWTClaims{
+				"sub":       "workload",
+				"client_id": clientID,
+				"aud":       []interface{}{"other", "grafana"},
+				"scope":     "openid grafana:api",
+			}, nil
+		}
+		handleLinkedServiceAccount(t)
+
+		sc.fakeReq("GET", "/").withAuthorizationHeader("Bearer " + token).exec()
+		assert.Equal(t, token, verifiedToken)
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.True(t, sc.context.IsSignedIn)
+		assert.Equal(t, saID, sc.context.UserId)
+		assert.Equal(t, orgID, sc.context.OrgId)
+	}, configure)
+
+	middlewareScenario(t, "Valid bearer token with scp claim", func(t *testing.T, sc *scenarioContext) {
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{
+				"client_id": clientID,
+				"aud":       "grafana",
+				"scp":       []interface{}{"grafana:api"},
+			}, nil
+		}
+		handleLinkedServiceAccount(t)
+
+		sc.fakeReq("GET", "/").withAuthorizationHeader("Bearer " + token).exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, saID, sc.context.UserId)
+	}, configure)
+
+	middlewareScenario(t, "Bearer token for another audience", func(t *testing.T, sc *scenarioContext) {
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{
+				"client_id": clientID,
+				"aud":       "other",
+				"scope":     "grafana:api",
+			}, nil
+		}
+		handleLinkedServiceAccount(t)
+
+		sc.fakeReq("GET", "/").withAuthorizationHeader("Bearer " + token).exec()
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidJWT, sc.respJson["message"])
+	}, configure)
+
+	middlewareScenario(t, "Bearer token missing a required scope", func(t *testing.T, sc *scenarioContext) {
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{
+				"client_id": clientID,
+				"aud":       "grafana",
+				"scope":     "openid",
+			}, nil
+		}
+		handleLinkedServiceAccount(t)
+
+		sc.fakeReq("GET", "/").withAuthorizationHeader("Bearer " + token).exec()
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidJWT, sc.respJson["message"])
+	}, configure)
+
+	middlewareScenario(t, "Bearer token of a client not linked to a service account", func(t *testing.T, sc *scenarioContext) {
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{
+				"client_id": "unknown",
+				"aud":       "grafana",
+				"scope":     "grafana:api",
+			}, nil
+		}
+		handleLinkedServiceAccount(t)
+
+		sc.fakeReq("GET", "/").withAuthorizationHeader("Bearer " + token).exec()
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.ServiceAccountNotFound, sc.respJson["message"])
+	}, configure)
+
+	middlewareScenario(t, "Invalid bearer token", func(t *testing.T, sc *scenarioContext) {
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return nil, errors.New("token is invalid")
+		}
+
+		sc.fakeReq("GET", "/").withAuthorizationHeader("Bearer " + token).exec()
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidJWT, sc.respJson["message"])
+	}, configure)
+
+	middlewareScenario(t, "API keys are not verified as JWTs", func(t *testing.T, sc *scenarioContext) {
+		verified := false
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			verified = true
+			return nil, errors.New("token is invalid")
+		}
+
+		sc.fakeReq("GET", "/").withValidApiKey().exec()
+		assert.False(t, verified)
+	}, configure)
+
+	middlewareScenario(t, "Bearer tokens are ignored when service accounts are disabled", func(t *testing.T, sc *scenarioContext) {
+		verified := false
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			verified = true
+			return nil, errors.New("token is invalid")
+		}
+
+		sc.fakeReq("GET", "/").withAuthorizationHeader("Bearer " + token).exec()
+		assert.False(t, verified)
+	}, configure, func(cfg *setting.Cfg) {
+		cfg.JWTAuthServiceAccountsEnabled = false
+	})
+}
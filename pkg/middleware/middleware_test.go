@@ -25,8 +25,10 @@ import (
 	"github.com/grafana/grafana/pkg/services/auth"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
 	"github.com/grafana/grafana/pkg/services/contexthandler/authproxy"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/totp/totptest"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
@@ -197,6 +199,67 @@ func TestMiddlewareContext(t *testing.T) {
 		assert.Equal(t, "Expired API key", sc.respJson["message"])
 	})
 
+	middlewareScenario(t, "Valid API key records its last use", func(t *testing.T, sc *scenarioContext) {
+		keyhash, err := util.EncodePassword("v5nAwpMafFP6znaS4urhdWDLS5511M42", "asd")
+		require.NoError(t, err)
+		err = sc.sqlStore.AddAPIKey(context.Background(), &models.AddApiKeyCommand{OrgId: 1, Name: "asd", Role: models.ROLE_EDITOR, Key: keyhash})
+		require.NoError(t, err)
+
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyByNameQuery) error {
+			return sc.sqlStore.GetApiKeyByName(ctx, query)
+		})
+
+		sc.fakeReq("GET", "/").withValidApiKey().exec()
+		require.Equal(t, 200, sc.resp.Code)
+
+		query := models.GetApiKeyByNameQuery{KeyName: "asd", OrgId: 1}
+		require.NoError(t, sc.sqlStore.GetApiKeyByName(context.Background(), &query))
+		assert.NotNil(t, query.Result.LastUsedAt)
+	})
+
+	middlewareScenario(t, "Restricted service account token", func(t *testing.T, sc *scenarioContext) {
+		keyhash, err := util.EncodePassword("v5nAwpMafFP6znaS4urhdWDLS5511M42", "asd")
+		require.NoError(t, err)
+		var serviceAccountID int64 = 5
+		permissions := []models.ApiKeyPermission{{Action: "dashboards:read", Scope: "dashboards:uid:abc"}}
+
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetApiKeyByNameQuery) error {
+			query.Result = &models.ApiKey{OrgId: 12, Role: models.ROLE_VIEWER, Key: keyhash,
+				ServiceAccountId: &serviceAccountID, Permissions: permissions}
+			return nil
+		})
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{OrgId: 12, UserId: serviceAccountID, OrgRole: models.ROLE_EDITOR}
+			return nil
+		})
+
+		t.Run("should be restricted to the token permissions", func(t *testing.T) {
+			sc.cfg.IsFeatureToggleEnabled = func(key string) bool { return key == featuremgmt.FlagAccesscontrol }
+			sc.fakeReq("GET", "/").withValidApiKey().exec()
+
+			require.Equal(t, 200, sc.resp.Code)
+			assert.Equal(t, serviceAccountID, sc.context.UserId)
+			assert.Equal(t, permissions, sc.context.TokenPermissions)
+			assert.Equal(t, models.ROLE_EDITOR, sc.context.TokenOrgRole)
+		})
+
+		t.Run("should be denied by the legacy role checks", func(t *testing.T) {
+			sc.cfg.IsFeatureToggleEnabled = func(key string) bool { return key == featuremgmt.FlagAccesscontrol }
+			sc.m.Post("/api/dashboards/db", ReqEditorRole, sc.defaultHandler)
+			sc.fakeReq("POST", "/api/dashboards/db").withValidApiKey().exec()
+
+			assert.Equal(t, 403, sc.resp.Code)
+			assert.False(t, sc.context.HasRole(models.ROLE_VIEWER))
+		})
+
+		t.Run("should be rejected when access control is disabled", func(t *testing.T) {
+			sc.cfg.IsFeatureToggleEnabled = func(key string) bool { return false }
+			sc.fakeReq("GET", "/").withValidApiKey().exec()
+
+			assert.Equal(t, 401, sc.resp.Code)
+		})
+	})
+
 	middlewareScenario(t, "Non-expired auth token in cookie which is not being rotated", func(
 		t *testing.T, sc *scenarioContext) {
 		const userID int64 = 12
@@ -731,7 +794,7 @@ func getContextHandler(t *testing.T, cfg *setting.Cfg) *contexthandler.ContextHa
 	authJWTSvc := models.NewFakeJWTService()
 	tracer, err := tracing.InitializeTracerForTest()
 	require.NoError(t, err)
-	return contexthandler.ProvideService(cfg, userAuthTokenSvc, authJWTSvc, remoteCacheSvc, renderSvc, sqlStore, tracer)
+	return contexthandler.ProvideService(cfg, userAuthTokenSvc, authJWTSvc, remoteCacheSvc, renderSvc, sqlStore, tracer, totptest.NewFakeService(), &authproxy.HeaderVerifier{})
 }
 
 type fakeRenderService struct {
@@ -12,6 +12,14 @@ var (
 	ErrDuplicateApiKey         = errors.New("API key, organization ID and name must be unique")
 )
 
+// ApiKeyPermission restricts a service account token to a subset of the
+// permissions of its service account. An empty scope allows the action on all
+// the scopes the service account has it on.
+type ApiKeyPermission struct {
+	Action string `json:"action"`
+	Scope  string `json:"scope,omitempty"`
+}
+
 type ApiKey struct {
 	Id               int64
 	OrgId            int64
@@ -22,6 +30,18 @@ type ApiKey struct {
 	Updated          time.Time
 	Expires          *int64
 	ServiceAccountId *int64
+	LastUsedAt       *time.Time
+	LastUsedIP       string `xorm:"last_used_ip"`
+	// Permissions restrict a service account token, all the permissions of
+	// the service account are granted when empty.
+	Permissions      []ApiKeyPermission `xorm:"permissions"`
+	ExpiryNotifiedAt *time.Time
+}
+
+// IsRestricted returns true if the key is a service account token restricted
+// to a subset of the permissions of its service account.
+func (k *ApiKey) IsRestricted() bool {
+	return len(k.Permissions) > 0
 }
 
 // ---------------------
@@ -33,7 +53,9 @@ type AddApiKeyCommand struct {
 	Key              string   `json:"-"`
 	SecondsToLive    int64    `json:"secondsToLive"`
 	ServiceAccountId *int64   `json:"-"`
-	Result           *ApiKey  `json:"-"`
+	// Permissions are only supported by service account tokens.
+	Permissions []ApiKeyPermission `json:"permissions"`
+	Result      *ApiKey            `json:"-"`
 }
 
 type DeleteApiKeyCommand struct {
@@ -41,6 +63,14 @@ type DeleteApiKeyCommand struct {
 	OrgId int64 `json:"-"`
 }
 
+// UpdateApiKeyLastUsedCommand records when and from where an API key was last
+// used.
+type UpdateApiKeyLastUsedCommand struct {
+	Id     int64
+	IP     string
+	UsedAt time.Time
+}
+
 // ----------------------
 // QUERIES
 
@@ -69,4 +99,8 @@ type ApiKeyDTO struct {
 	Name       string     `json:"name"`
 	Role       RoleType   `json:"role"`
 	Expiration *time.Time `json:"expiration,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	LastUsedIP string     `json:"lastUsedIp,omitempty"`
+	// Permissions are only set for restricted service account tokens.
+	Permissions []ApiKeyPermission `json:"permissions,omitempty"`
 }
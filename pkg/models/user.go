@@ -185,6 +185,13 @@ type SignedInUser struct {
 	Teams          []int64
 	// Permissions grouped by orgID and actions
 	Permissions map[int64]map[string][]string
+	// TokenPermissions restrict the permissions of a service account
+	// authenticated with a restricted token.
+	TokenPermissions []ApiKeyPermission
+	// TokenOrgRole is the org role of a service account authenticated with a
+	// restricted token. Its OrgRole is left empty, so that the legacy role
+	// checks, which don't know about the token permissions, deny access.
+	TokenOrgRole RoleType
 }
 
 func (u *SignedInUser) ShouldUpdateLastSeenAt() bool {
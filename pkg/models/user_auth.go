@@ -9,6 +9,12 @@ import (
 
 const (
 	AuthModuleLDAP = "ldap"
+	// AuthModuleJWTServiceAccount links the JWT identity of a machine client
+	// to the service account it authenticates as.
+	AuthModuleJWTServiceAccount = "jwt_service_account"
+	// AuthModuleSCIM links a user to the ID assigned by the identity
+	// provider provisioning it through SCIM.
+	AuthModuleSCIM = "scim"
 )
 
 type UserAuth struct {
@@ -34,10 +40,17 @@ type ExternalUserInfo struct {
 	Name           string
 	Groups         []string
 	OrgRoles       map[int64]RoleType
-	IsGrafanaAdmin *bool // This is a pointer to know if we should sync this or not (nil = ignore sync)
+	Teams          []ExternalTeam // Teams synced from the external provider (nil = ignore sync)
+	IsGrafanaAdmin *bool          // This is a pointer to know if we should sync this or not (nil = ignore sync)
 	IsDisabled     bool
 }
 
+// ExternalTeam is a team an external provider makes the user a member of.
+type ExternalTeam struct {
+	OrgId  int64
+	TeamId int64
+}
+
 type LoginInfo struct {
 	AuthModule    string
 	User          *User
@@ -118,6 +118,31 @@ func GroupScopesByAction(permissions []*Permission) map[string][]string {
 	return m
 }
 
+// RestrictPermissions returns the permissions granted both by permissions and
+// by restrictions, as used for restricted service account tokens. A
+// restriction without scope keeps all the scopes of its action, otherwise the
+// narrower of the two scopes is kept.
+func RestrictPermissions(permissions []*Permission, restrictions []*Permission) []*Permission {
+	result := make([]*Permission, 0, len(permissions))
+	for _, p := range permissions {
+		for _, r := range restrictions {
+			if r.Action != p.Action {
+				continue
+			}
+			if r.Scope == "" || p.Scope == "" {
+				result = append(result, p)
+				continue
+			}
+			if ok, _ := match(p.Scope, r.Scope); ok {
+				result = append(result, &Permission{Action: p.Action, Scope: r.Scope})
+			} else if ok, _ := match(r.Scope, p.Scope); ok {
+				result = append(result, p)
+			}
+		}
+	}
+	return result
+}
+
 func ValidateScope(scope string) bool {
 	prefix, last := scope[:len(scope)-1], scope[len(scope)-1]
 	// verify that last char is either ':' or '/' if last character of scope is '*'
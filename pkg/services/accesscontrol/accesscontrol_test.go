@@ -7,6 +7,47 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func TestRestrictPermissions(t *testing.T) {
+	permissions := []*Permission{
+		{Action: "dashboards:read", Scope: "dashboards:*"},
+		{Action: "dashboards:write", Scope: "dashboards:uid:abc"},
+		{Action: "folders:read", Scope: "folders:*"},
+		{Action: "users:create"},
+	}
+
+	tests := []struct {
+		desc         string
+		restrictions []*Permission
+		expected     []*Permission
+	}{
+		{
+			desc:         "Should keep nothing without restrictions",
+			restrictions: []*Permission{},
+			expected:     []*Permission{},
+		},
+		{
+			desc:         "Should keep all the scopes of an action restricted without scope",
+			restrictions: []*Permission{{Action: "dashboards:read"}, {Action: "users:create"}},
+			expected:     []*Permission{{Action: "dashboards:read", Scope: "dashboards:*"}, {Action: "users:create"}},
+		},
+		{
+			desc:         "Should keep the narrower scope",
+			restrictions: []*Permission{{Action: "dashboards:read", Scope: "dashboards:uid:abc"}, {Action: "dashboards:write", Scope: "dashboards:*"}},
+			expected:     []*Permission{{Action: "dashboards:read", Scope: "dashboards:uid:abc"}, {Action: "dashboards:write", Scope: "dashboards:uid:abc"}},
+		},
+		{
+			desc:         "Should not grant actions or scopes the permissions don't have",
+			restrictions: []*Permission{{Action: "dashboards:write", Scope: "dashboards:uid:def"}, {Action: "teams:read"}},
+			expected:     []*Permission{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RestrictPermissions(permissions, tt.restrictions))
+		})
+	}
+}
+
 func TestGetResourcesMetadata(t *testing.T) {
 	tests := []struct {
 		desc         string
@@ -0,0 +1,475 @@
+package customroles
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+var (
+	roleUIDScope = accesscontrol.Scope("roles", "uid", accesscontrol.Parameter(":roleUID"))
+	userIDScope  = accesscontrol.Scope("users", "id", accesscontrol.Parameter(":userId"))
+)
+
+type api struct {
+	ac       accesscontrol.AccessControl
+	router   routing.RouteRegister
+	store    Store
+	sqlStore *sqlstore.SQLStore
+	audit    audit.Service
+}
+
+func newAPI(ac accesscontrol.AccessControl, router routing.RouteRegister, store Store, sqlStore *sqlstore.SQLStore, auditService audit.Service) *api {
+	return &api{ac: ac, router: router, store: store, sqlStore: sqlStore, audit: auditService}
+}
+
+func (a *api) registerEndpoints() {
+	auth := middleware.Middleware(a.ac)
+	disable := middleware.Disable(a.ac.IsDisabled())
+	a.router.Group("/api/access-control", func(r routing.RouteRegister) {
+		r.Get("/roles", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionRolesRead)), routing.Wrap(a.getRoles))
+		r.Post("/roles", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionRolesWrite)), routing.Wrap(a.createRole))
+		r.Get("/roles/:roleUID", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionRolesRead, roleUIDScope)), routing.Wrap(a.getRole))
+		r.Put("/roles/:roleUID", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionRolesWrite, roleUIDScope)), routing.Wrap(a.updateRole))
+		r.Delete("/roles/:roleUID", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionRolesDelete, roleUIDScope)), routing.Wrap(a.deleteRole))
+		r.Get("/roles/:roleUID/assignments", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionRolesRead, roleUIDScope)), routing.Wrap(a.getRoleAssignments))
+
+		r.Post("/users/:userId/roles", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionUsersRolesAdd, userIDScope)), routing.Wrap(a.addUserRole))
+		r.Delete("/users/:userId/roles/:roleUID", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionUsersRolesRemove, userIDScope)), routing.Wrap(a.removeUserRole))
+		r.Post("/teams/:teamId/roles", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionTeamsRolesAdd, accesscontrol.ScopeTeamsID)), routing.Wrap(a.addTeamRole))
+		r.Delete("/teams/:teamId/roles/:roleUID", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionTeamsRolesRemove, accesscontrol.ScopeTeamsID)), routing.Wrap(a.removeTeamRole))
+		r.Post("/builtin-roles", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionRolesBuiltInAdd)), routing.Wrap(a.addBuiltInRole))
+		r.Delete("/builtin-roles/:builtInRole/roles/:roleUID", auth(disable, accesscontrol.EvalPermission(accesscontrol.ActionRolesBuiltInRemove)), routing.Wrap(a.removeBuiltInRole))
+	})
+}
+
+func (a *api) getRoles(c *models.ReqContext) response.Response {
+	roles, err := a.store.GetRoles(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get roles", err)
+	}
+
+	// Only list the roles the user can read
+	filtered := make([]*accesscontrol.RoleDTO, 0, len(roles))
+	for _, role := range roles {
+		ok, err := a.ac.Evaluate(c.Req.Context(), c.SignedInUser, accesscontrol.EvalPermission(accesscontrol.ActionRolesRead, roleScope(role.UID)))
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to evaluate permissions", err)
+		}
+		if ok {
+			filtered = append(filtered, role)
+		}
+	}
+
+	return response.JSON(http.StatusOK, filtered)
+}
+
+func (a *api) getRole(c *models.ReqContext) response.Response {
+	role, err := a.store.GetRoleByUID(c.Req.Context(), c.OrgId, web.Params(c.Req)[":roleUID"])
+	if err != nil {
+		return roleErrorResponse(err, "Failed to get role")
+	}
+	return response.JSON(http.StatusOK, role)
+}
+
+func (a *api) createRole(c *models.ReqContext) response.Response {
+	cmd := accesscontrol.CreateRoleCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgId
+
+	if err := accesscontrol.ValidateCustomRole(cmd.Name, cmd.Permissions); err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if resp := a.checkEscalation(c, cmd.Global, cmd.Permissions); resp != nil {
+		return resp
+	}
+
+	role, err := a.store.CreateRole(c.Req.Context(), cmd)
+	if err != nil {
+		return roleErrorResponse(err, "Failed to create role")
+	}
+	a.recordAudit(c, audit.ActionCreate, role.UID, "name", role.Name, "global", role.Global())
+
+	return response.JSON(http.StatusCreated, role)
+}
+
+func (a *api) updateRole(c *models.ReqContext) response.Response {
+	cmd := accesscontrol.UpdateRoleCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgId
+	cmd.UID = web.Params(c.Req)[":roleUID"]
+
+	existing, err := a.store.GetRoleByUID(c.Req.Context(), c.OrgId, cmd.UID)
+	if err != nil {
+		return roleErrorResponse(err, "Failed to get role")
+	}
+
+	if err := accesscontrol.ValidateCustomRole(cmd.Name, cmd.Permissions); err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if resp := a.checkEscalation(c, existing.Global(), cmd.Permissions); resp != nil {
+		return resp
+	}
+
+	role, err := a.store.UpdateRole(c.Req.Context(), cmd)
+	if err != nil {
+		return roleErrorResponse(err, "Failed to update role")
+	}
+	a.recordAudit(c, audit.ActionUpdate, role.UID, "name", role.Name, "version", role.Version)
+
+	return response.JSON(http.StatusOK, role)
+}
+
+func (a *api) deleteRole(c *models.ReqContext) response.Response {
+	roleUID := web.Params(c.Req)[":roleUID"]
+	role, err := a.store.GetRoleByUID(c.Req.Context(), c.OrgId, roleUID)
+	if err != nil {
+		return roleErrorResponse(err, "Failed to get role")
+	}
+	if role.Global() && !c.IsGrafanaAdmin {
+		return response.Error(http.StatusForbidden, "Only server admins can delete global roles", nil)
+	}
+
+	// Without force, refuse to silently revoke the role from its assignees
+	if !c.QueryBool("force") {
+		assignments, err := a.store.GetRoleAssignments(c.Req.Context(), role.ID)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to get role assignments", err)
+		}
+		if assignments.Len() > 0 {
+			return response.Error(http.StatusBadRequest, "Role is assigned, set force to delete it with its assignments", accesscontrol.ErrRoleHasAssignments)
+		}
+	}
+
+	if err := a.store.DeleteRole(c.Req.Context(), c.OrgId, roleUID); err != nil {
+		return roleErrorResponse(err, "Failed to delete role")
+	}
+	a.recordAudit(c, audit.ActionDelete, role.UID, "name", role.Name)
+
+	return response.Success("Role deleted")
+}
+
+func (a *api) getRoleAssignments(c *models.ReqContext) response.Response {
+	role, err := a.store.GetRoleByUID(c.Req.Context(), c.OrgId, web.Params(c.Req)[":roleUID"])
+	if err != nil {
+		return roleErrorResponse(err, "Failed to get role")
+	}
+
+	assignments, err := a.store.GetRoleAssignments(c.Req.Context(), role.ID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get role assignments", err)
+	}
+
+	// Server admins see the assignments of every organization, other users
+	// only the assignments of their current organization and global ones.
+	if !c.IsGrafanaAdmin {
+		assignments = filterAssignments(assignments, c.OrgId)
+	}
+
+	return response.JSON(http.StatusOK, assignments)
+}
+
+type addRoleCommand struct {
+	RoleUID string `json:"roleUid" binding:"Required"`
+	// Global assigns the role in all organizations. Only available to server
+	// admins, for global roles assigned to users or built-in roles.
+	Global bool `json:"global"`
+}
+
+type addBuiltInRoleCommand struct {
+	addRoleCommand
+	BuiltInRole string `json:"builtInRole" binding:"Required"`
+}
+
+func (a *api) addUserRole(c *models.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":userId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "userId is invalid", err)
+	}
+	cmd := addRoleCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if err := a.sqlStore.GetUserProfile(c.Req.Context(), &models.GetUserProfileQuery{UserId: userID}); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return response.Error(http.StatusNotFound, "User not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get user", err)
+	}
+
+	role, orgID, resp := a.getAssignmentRole(c, cmd.RoleUID, cmd.Global)
+	if resp != nil {
+		return resp
+	}
+	if orgID != accesscontrol.GlobalOrgID {
+		isMember, err := a.isOrgMember(c.Req.Context(), orgID, userID)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to get user", err)
+		}
+		if !isMember {
+			return response.Error(http.StatusNotFound, "User not found", nil)
+		}
+	}
+	if resp := a.checkEscalation(c, cmd.Global, role.Permissions); resp != nil {
+		return resp
+	}
+
+	if err := a.store.AddUserRole(c.Req.Context(), orgID, userID, role.ID); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to add role to user", err)
+	}
+	a.recordAssignmentAudit(c, audit.ResourceUser, strconv.FormatInt(userID, 10), role, "add", orgID)
+
+	return response.Success("Role added to the user")
+}
+
+func (a *api) removeUserRole(c *models.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":userId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "userId is invalid", err)
+	}
+
+	role, orgID, resp := a.getAssignmentRole(c, web.Params(c.Req)[":roleUID"], c.QueryBool("global"))
+	if resp != nil {
+		return resp
+	}
+
+	if err := a.store.RemoveUserRole(c.Req.Context(), orgID, userID, role.ID); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to remove role from user", err)
+	}
+	a.recordAssignmentAudit(c, audit.ResourceUser, strconv.FormatInt(userID, 10), role, "remove", orgID)
+
+	return response.Success("Role removed from the user")
+}
+
+func (a *api) addTeamRole(c *models.ReqContext) response.Response {
+	teamID, err := strconv.ParseInt(web.Params(c.Req)[":teamId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "teamId is invalid", err)
+	}
+	cmd := addRoleCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if cmd.Global {
+		return response.Error(http.StatusBadRequest, "Roles cannot be assigned to teams globally", nil)
+	}
+
+	if err := a.sqlStore.GetTeamById(c.Req.Context(), &models.GetTeamByIdQuery{OrgId: c.OrgId, Id: teamID}); err != nil {
+		if errors.Is(err, models.ErrTeamNotFound) {
+			return response.Error(http.StatusNotFound, "Team not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get team", err)
+	}
+
+	role, orgID, resp := a.getAssignmentRole(c, cmd.RoleUID, false)
+	if resp != nil {
+		return resp
+	}
+	if resp := a.checkEscalation(c, false, role.Permissions); resp != nil {
+		return resp
+	}
+
+	if err := a.store.AddTeamRole(c.Req.Context(), orgID, teamID, role.ID); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to add role to team", err)
+	}
+	a.recordAssignmentAudit(c, audit.ResourceTeam, strconv.FormatInt(teamID, 10), role, "add", orgID)
+
+	return response.Success("Role added to the team")
+}
+
+func (a *api) removeTeamRole(c *models.ReqContext) response.Response {
+	teamID, err := strconv.ParseInt(web.Params(c.Req)[":teamId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "teamId is invalid", err)
+	}
+
+	role, orgID, resp := a.getAssignmentRole(c, web.Params(c.Req)[":roleUID"], false)
+	if resp != nil {
+		return resp
+	}
+
+	if err := a.store.RemoveTeamRole(c.Req.Context(), orgID, teamID, role.ID); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to remove role from team", err)
+	}
+	a.recordAssignmentAudit(c, audit.ResourceTeam, strconv.FormatInt(teamID, 10), role, "remove", orgID)
+
+	return response.Success("Role removed from the team")
+}
+
+func (a *api) addBuiltInRole(c *models.ReqContext) response.Response {
+	cmd := addBuiltInRoleCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if err := accesscontrol.ValidateBuiltInRoles([]string{cmd.BuiltInRole}); err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+
+	role, orgID, resp := a.getAssignmentRole(c, cmd.RoleUID, cmd.Global)
+	if resp != nil {
+		return resp
+	}
+	if resp := a.checkEscalation(c, cmd.Global, role.Permissions); resp != nil {
+		return resp
+	}
+
+	if err := a.store.AddBuiltInRole(c.Req.Context(), orgID, cmd.BuiltInRole, role.ID); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to add role to built-in role", err)
+	}
+	a.recordAssignmentAudit(c, audit.ResourceRole, role.UID, role, "add", orgID, "builtInRole", cmd.BuiltInRole)
+
+	return response.Success("Role added to the built-in role")
+}
+
+func (a *api) removeBuiltInRole(c *models.ReqContext) response.Response {
+	builtInRole := web.Params(c.Req)[":builtInRole"]
+	if err := accesscontrol.ValidateBuiltInRoles([]string{builtInRole}); err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+
+	role, orgID, resp := a.getAssignmentRole(c, web.Params(c.Req)[":roleUID"], c.QueryBool("global"))
+	if resp != nil {
+		return resp
+	}
+
+	if err := a.store.RemoveBuiltInRole(c.Req.Context(), orgID, builtInRole, role.ID); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to remove role from built-in role", err)
+	}
+	a.recordAssignmentAudit(c, audit.ResourceRole, role.UID, role, "remove", orgID, "builtInRole", builtInRole)
+
+	return response.Success("Role removed from the built-in role")
+}
+
+// isOrgMember returns true if the user belongs to the organization.
+func (a *api) isOrgMember(ctx context.Context, orgID, userID int64) (bool, error) {
+	var isMember bool
+	err := a.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		isMember, err = sess.Where("org_id = ? AND user_id = ?", orgID, userID).Exist(&models.OrgUser{})
+		return err
+	})
+	return isMember, err
+}
+
+// getAssignmentRole returns the role of an assignment and the organization of
+// the assignment. Global assignments are reserved to server admins.
+func (a *api) getAssignmentRole(c *models.ReqContext, roleUID string, global bool) (*accesscontrol.RoleDTO, int64, response.Response) {
+	role, err := a.store.GetRoleByUID(c.Req.Context(), c.OrgId, roleUID)
+	if err != nil {
+		return nil, 0, roleErrorResponse(err, "Failed to get role")
+	}
+
+	orgID := c.OrgId
+	if global {
+		if !role.Global() {
+			return nil, 0, response.Error(http.StatusBadRequest, "Only global roles can be assigned globally", nil)
+		}
+		if !c.IsGrafanaAdmin {
+			return nil, 0, response.Error(http.StatusForbidden, "Only server admins can manage global assignments", nil)
+		}
+		orgID = accesscontrol.GlobalOrgID
+	}
+
+	return role, orgID, nil
+}
+
+// checkEscalation prevents users from granting permissions they don't have
+// themselves. Global roles and assignments are reserved to server admins.
+func (a *api) checkEscalation(c *models.ReqContext, global bool, permissions []accesscontrol.Permission) response.Response {
+	if global && !c.IsGrafanaAdmin {
+		return response.Error(http.StatusForbidden, "Only server admins can manage global roles and assignments", nil)
+	}
+
+	for _, p := range permissions {
+		ok, err := a.hasPermission(c.Req.Context(), c.SignedInUser, p)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to evaluate permissions", err)
+		}
+		if !ok {
+			return response.Error(http.StatusForbidden, "Cannot grant a permission you do not have: "+p.Action+" "+p.Scope, nil)
+		}
+	}
+
+	return nil
+}
+
+func (a *api) hasPermission(ctx context.Context, user *models.SignedInUser, p accesscontrol.Permission) (bool, error) {
+	if p.Scope == "" {
+		return a.ac.Evaluate(ctx, user, accesscontrol.EvalPermission(p.Action))
+	}
+	return a.ac.Evaluate(ctx, user, accesscontrol.EvalPermission(p.Action, p.Scope))
+}
+
+func (a *api) recordAudit(c *models.ReqContext, action string, roleUID string, details ...interface{}) {
+	if a.audit == nil {
+		return
+	}
+	a.audit.Record(c.Req.Context(), audit.NewEntry(c, action, audit.ResourceRole, roleUID).WithDetails(details...))
+}
+
+func (a *api) recordAssignmentAudit(c *models.ReqContext, resourceType, resourceID string, role *accesscontrol.RoleDTO, operation string, orgID int64, details ...interface{}) {
+	if a.audit == nil {
+		return
+	}
+	details = append(details, "roleUid", role.UID, "operation", operation, "global", orgID == accesscontrol.GlobalOrgID)
+	a.audit.Record(c.Req.Context(), audit.NewEntry(c, audit.ActionRoleUpdate, resourceType, resourceID).WithDetails(details...))
+}
+
+func filterAssignments(assignments *accesscontrol.RoleAssignments, orgID int64) *accesscontrol.RoleAssignments {
+	inOrg := func(id int64) bool {
+		return id == orgID || id == accesscontrol.GlobalOrgID
+	}
+
+	filtered := &accesscontrol.RoleAssignments{
+		Users:        []accesscontrol.UserRoleAssignment{},
+		Teams:        []accesscontrol.TeamRoleAssignment{},
+		BuiltInRoles: []accesscontrol.BuiltInRoleRoleAssignment{},
+	}
+	for _, u := range assignments.Users {
+		if inOrg(u.OrgID) {
+			filtered.Users = append(filtered.Users, u)
+		}
+	}
+	for _, t := range assignments.Teams {
+		if inOrg(t.OrgID) {
+			filtered.Teams = append(filtered.Teams, t)
+		}
+	}
+	for _, b := range assignments.BuiltInRoles {
+		if inOrg(b.OrgID) {
+			filtered.BuiltInRoles = append(filtered.BuiltInRoles, b)
+		}
+	}
+	return filtered
+}
+
+func roleScope(uid string) string {
+	return accesscontrol.Scope("roles", "uid", uid)
+}
+
+func roleErrorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, accesscontrol.ErrRoleNotFound):
+		return response.Error(http.StatusNotFound, "Role not found", err)
+	case errors.Is(err, accesscontrol.ErrRoleAlreadyExists):
+		return response.Error(http.StatusConflict, err.Error(), err)
+	case errors.Is(err, accesscontrol.ErrVersionLE):
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
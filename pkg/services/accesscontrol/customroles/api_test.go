@@ -0,0 +1,217 @@
+package customroles
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/audit/audittest"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+var writerPermissions = []*accesscontrol.Permission{
+	{Action: accesscontrol.ActionRolesRead, Scope: accesscontrol.ScopeRolesAll},
+	{Action: accesscontrol.ActionRolesWrite, Scope: accesscontrol.ScopeRolesAll},
+	{Action: accesscontrol.ActionRolesDelete, Scope: accesscontrol.ScopeRolesAll},
+	{Action: accesscontrol.ActionUsersRolesAdd, Scope: accesscontrol.ScopeUsersAll},
+	{Action: accesscontrol.ActionRolesBuiltInAdd},
+	{Action: "datasources:read", Scope: "datasources:*"},
+}
+
+type testEnv struct {
+	server   *web.Mux
+	store    *database.AccessControlStore
+	sqlStore *sqlstore.SQLStore
+	audit    *audittest.FakeService
+}
+
+func setupTestEnv(t *testing.T, user *models.SignedInUser, permissions []*accesscontrol.Permission) *testEnv {
+	t.Helper()
+
+	sqlStore := sqlstore.InitTestDB(t)
+	store := database.ProvideService(sqlStore)
+	router := routing.NewRouteRegister()
+	auditService := audittest.NewFakeService()
+	cfg := setting.NewCfg()
+	cfg.ProvisioningPath = t.TempDir()
+
+	_, err := ProvideService(cfg, router, accesscontrolmock.New().WithPermissions(permissions), store, sqlStore, auditService)
+	require.NoError(t, err)
+
+	server := web.New()
+	server.UseMiddleware(web.Renderer(path.Join(setting.StaticRootPath, "views"), "[[", "]]"))
+	server.Use(func(c *web.Context) {
+		c.Map(&models.ReqContext{
+			Context:      c,
+			SignedInUser: user,
+			IsSignedIn:   true,
+			SkipCache:    true,
+			Logger:       log.New("test"),
+		})
+	})
+	router.Register(server)
+
+	return &testEnv{server: server, store: store, sqlStore: sqlStore, audit: auditService}
+}
+
+func (e *testEnv) request(t *testing.T, method, url string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	e.server.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestApi_CustomRoles(t *testing.T) {
+	env := setupTestEnv(t, &models.SignedInUser{OrgId: 1, UserId: 1}, writerPermissions)
+
+	t.Run("should create, update and delete a role", func(t *testing.T) {
+		recorder := env.request(t, http.MethodPost, "/api/access-control/roles", accesscontrol.CreateRoleCommand{
+			Name:        "custom:datasources:reader",
+			Permissions: []accesscontrol.Permission{{Action: "datasources:read", Scope: "datasources:id:1"}},
+		})
+		require.Equal(t, http.StatusCreated, recorder.Code)
+		var created accesscontrol.RoleDTO
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+		assert.Equal(t, int64(1), created.Version)
+		assert.Len(t, created.Permissions, 1)
+
+		recorder = env.request(t, http.MethodGet, "/api/access-control/roles", nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var roles []accesscontrol.RoleDTO
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &roles))
+		assert.Len(t, roles, 1)
+
+		recorder = env.request(t, http.MethodPut, "/api/access-control/roles/"+created.UID, accesscontrol.UpdateRoleCommand{
+			Name:        "custom:datasources:reader",
+			Permissions: []accesscontrol.Permission{{Action: "datasources:read", Scope: "datasources:*"}},
+		})
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var updated accesscontrol.RoleDTO
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &updated))
+		assert.Equal(t, int64(2), updated.Version)
+
+		recorder = env.request(t, http.MethodDelete, "/api/access-control/roles/"+created.UID, nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		recorder = env.request(t, http.MethodGet, "/api/access-control/roles/"+created.UID, nil)
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+
+		require.Len(t, env.audit.Entries, 3)
+		assert.Equal(t, audit.ActionCreate, env.audit.Entries[0].Action)
+		assert.Equal(t, audit.ResourceRole, env.audit.Entries[0].ResourceType)
+	})
+
+	t.Run("should not create a role with more permissions than the user", func(t *testing.T) {
+		recorder := env.request(t, http.MethodPost, "/api/access-control/roles", accesscontrol.CreateRoleCommand{
+			Name:        "custom:datasources:writer",
+			Permissions: []accesscontrol.Permission{{Action: "datasources:write", Scope: "datasources:*"}},
+		})
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("should not create a role with a reserved prefix", func(t *testing.T) {
+		recorder := env.request(t, http.MethodPost, "/api/access-control/roles", accesscontrol.CreateRoleCommand{
+			Name: "fixed:datasources:reader",
+		})
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("should not create a global role without being server admin", func(t *testing.T) {
+		recorder := env.request(t, http.MethodPost, "/api/access-control/roles", accesscontrol.CreateRoleCommand{
+			Name:   "custom:global",
+			Global: true,
+		})
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+}
+
+func TestApi_CustomRoleAssignments(t *testing.T) {
+	env := setupTestEnv(t, &models.SignedInUser{OrgId: 1, UserId: 1}, writerPermissions)
+	ctx := context.Background()
+
+	user, err := env.sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: "alice", OrgId: 1})
+	require.NoError(t, err)
+
+	allowed, err := env.store.CreateRole(ctx, accesscontrol.CreateRoleCommand{
+		OrgID:       1,
+		Name:        "custom:allowed",
+		Permissions: []accesscontrol.Permission{{Action: "datasources:read", Scope: "datasources:*"}},
+	})
+	require.NoError(t, err)
+	denied, err := env.store.CreateRole(ctx, accesscontrol.CreateRoleCommand{
+		OrgID:       1,
+		Name:        "custom:denied",
+		Permissions: []accesscontrol.Permission{{Action: "datasources:write", Scope: "datasources:*"}},
+	})
+	require.NoError(t, err)
+
+	recorder := env.request(t, http.MethodPost, "/api/access-control/users/"+strconv.FormatInt(user.Id, 10)+"/roles", map[string]interface{}{"roleUid": allowed.UID})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = env.request(t, http.MethodPost, "/api/access-control/users/"+strconv.FormatInt(user.Id, 10)+"/roles", map[string]interface{}{"roleUid": denied.UID})
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = env.request(t, http.MethodPost, "/api/access-control/users/"+strconv.FormatInt(user.Id, 10)+"/roles", map[string]interface{}{"roleUid": allowed.UID, "global": true})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = env.request(t, http.MethodPost, "/api/access-control/users/999/roles", map[string]interface{}{"roleUid": allowed.UID})
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Users of other organizations can't be assigned roles
+	other, err := env.sqlStore.CreateOrgWithMember("other", user.Id)
+	require.NoError(t, err)
+	bob, err := env.sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: "bob", OrgId: other.Id})
+	require.NoError(t, err)
+	recorder = env.request(t, http.MethodPost, "/api/access-control/users/"+strconv.FormatInt(bob.Id, 10)+"/roles", map[string]interface{}{"roleUid": allowed.UID})
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = env.request(t, http.MethodPost, "/api/access-control/builtin-roles", map[string]interface{}{"roleUid": allowed.UID, "builtInRole": "Viewer"})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = env.request(t, http.MethodPost, "/api/access-control/builtin-roles", map[string]interface{}{"roleUid": allowed.UID, "builtInRole": "Owner"})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = env.request(t, http.MethodGet, "/api/access-control/roles/"+allowed.UID+"/assignments", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var assignments accesscontrol.RoleAssignments
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &assignments))
+	assert.Equal(t, []accesscontrol.UserRoleAssignment{{OrgID: 1, UserID: user.Id, Login: "alice"}}, assignments.Users)
+	assert.Equal(t, []accesscontrol.BuiltInRoleRoleAssignment{{OrgID: 1, BuiltInRole: "Viewer"}}, assignments.BuiltInRoles)
+
+	// Removing assignments is not allowed without the remove actions
+	recorder = env.request(t, http.MethodDelete, "/api/access-control/users/"+strconv.FormatInt(user.Id, 10)+"/roles/"+allowed.UID, nil)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// Assigned roles are only deleted when forced
+	recorder = env.request(t, http.MethodDelete, "/api/access-control/roles/"+allowed.UID, nil)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = env.request(t, http.MethodDelete, "/api/access-control/roles/"+allowed.UID+"?force=true", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
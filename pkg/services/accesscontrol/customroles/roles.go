@@ -0,0 +1,70 @@
+package customroles
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+func registerRoles(ac accesscontrol.AccessControl) error {
+	reader := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:roles:reader",
+			DisplayName: "Custom roles reader",
+			Description: "Read custom roles and their assignments.",
+			Group:       "Access control",
+			Permissions: []accesscontrol.Permission{
+				{
+					Action: accesscontrol.ActionRolesRead,
+					Scope:  accesscontrol.ScopeRolesAll,
+				},
+			},
+		},
+		Grants: []string{string(models.ROLE_ADMIN)},
+	}
+
+	writer := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version:     1,
+			Name:        "fixed:roles:writer",
+			DisplayName: "Custom roles writer",
+			Description: "Create, update, delete and assign custom roles. Roles can only grant permissions the user already has.",
+			Group:       "Access control",
+			Permissions: accesscontrol.ConcatPermissions(reader.Role.Permissions, []accesscontrol.Permission{
+				{
+					Action: accesscontrol.ActionRolesWrite,
+					Scope:  accesscontrol.ScopeRolesAll,
+				},
+				{
+					Action: accesscontrol.ActionRolesDelete,
+					Scope:  accesscontrol.ScopeRolesAll,
+				},
+				{
+					Action: accesscontrol.ActionUsersRolesAdd,
+					Scope:  accesscontrol.ScopeUsersAll,
+				},
+				{
+					Action: accesscontrol.ActionUsersRolesRemove,
+					Scope:  accesscontrol.ScopeUsersAll,
+				},
+				{
+					Action: accesscontrol.ActionTeamsRolesAdd,
+					Scope:  accesscontrol.ScopeTeamsAll,
+				},
+				{
+					Action: accesscontrol.ActionTeamsRolesRemove,
+					Scope:  accesscontrol.ScopeTeamsAll,
+				},
+				{
+					Action: accesscontrol.ActionRolesBuiltInAdd,
+				},
+				{
+					Action: accesscontrol.ActionRolesBuiltInRemove,
+				},
+			}),
+		},
+		Grants: []string{string(models.ROLE_ADMIN)},
+	}
+
+	return ac.DeclareFixedRoles(reader, writer)
+}
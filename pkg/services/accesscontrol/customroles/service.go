@@ -0,0 +1,63 @@
+// Package customroles manages the custom roles of access control: roles
+// defined by operators through the HTTP API or provisioning, and assigned to
+// users, teams and built-in roles in addition to the fixed roles.
+package customroles
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/provisioning/roles"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type Store interface {
+	GetRoles(ctx context.Context, orgID int64) ([]*accesscontrol.RoleDTO, error)
+	GetRoleByUID(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error)
+	CreateRole(ctx context.Context, cmd accesscontrol.CreateRoleCommand) (*accesscontrol.RoleDTO, error)
+	UpdateRole(ctx context.Context, cmd accesscontrol.UpdateRoleCommand) (*accesscontrol.RoleDTO, error)
+	DeleteRole(ctx context.Context, orgID int64, uid string) error
+	GetRoleAssignments(ctx context.Context, roleID int64) (*accesscontrol.RoleAssignments, error)
+	AddUserRole(ctx context.Context, orgID, userID, roleID int64) error
+	RemoveUserRole(ctx context.Context, orgID, userID, roleID int64) error
+	AddTeamRole(ctx context.Context, orgID, teamID, roleID int64) error
+	RemoveTeamRole(ctx context.Context, orgID, teamID, roleID int64) error
+	AddBuiltInRole(ctx context.Context, orgID int64, builtInRole string, roleID int64) error
+	RemoveBuiltInRole(ctx context.Context, orgID int64, builtInRole string, roleID int64) error
+}
+
+// Service registers the custom roles API and provisions the custom roles
+// from the provisioning/access-control directory on startup.
+type Service struct{}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	routeRegister routing.RouteRegister,
+	ac accesscontrol.AccessControl,
+	store *database.AccessControlStore,
+	sqlStore *sqlstore.SQLStore,
+	auditService audit.Service,
+) (*Service, error) {
+	s := &Service{}
+	if ac.IsDisabled() {
+		return s, nil
+	}
+
+	if err := registerRoles(ac); err != nil {
+		return nil, err
+	}
+
+	if err := roles.Provision(context.Background(), filepath.Join(cfg.ProvisioningPath, "access-control"), store, sqlStore); err != nil {
+		return nil, fmt.Errorf("failed to provision custom roles: %w", err)
+	}
+
+	newAPI(ac, routeRegister, store, sqlStore, auditService).registerEndpoints()
+
+	return s, nil
+}
@@ -38,7 +38,7 @@ func (s *AccessControlStore) GetUserPermissions(ctx context.Context, query acces
 		` + filter
 
 		if query.Actions != nil {
-			q += " AND permission.action IN("
+			q += " AND (permission.action IN("
 			if len(query.Actions) > 0 {
 				q += "?" + strings.Repeat(",?", len(query.Actions)-1)
 			}
@@ -46,6 +46,11 @@ func (s *AccessControlStore) GetUserPermissions(ctx context.Context, query acces
 			for _, a := range query.Actions {
 				params = append(params, a)
 			}
+			if query.IncludeCustomRoles {
+				q += " OR (role.name NOT LIKE ? AND role.name NOT LIKE ?)"
+				params = append(params, accesscontrol.ManagedRolePrefix+"%", accesscontrol.FixedRolePrefix+"%")
+			}
+			q += ")"
 		}
 
 		if err := sess.SQL(q, params...).Find(&result); err != nil {
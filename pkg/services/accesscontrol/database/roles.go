@@ -0,0 +1,349 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// customRoleFilter excludes the fixed and managed roles, which are not
+// managed through the custom roles API.
+const customRoleFilter = "name NOT LIKE ? AND name NOT LIKE ?"
+
+func customRoleFilterArgs() []interface{} {
+	return []interface{}{accesscontrol.FixedRolePrefix + "%", accesscontrol.ManagedRolePrefix + "%"}
+}
+
+// GetRoles returns the custom roles of an organization and the global custom roles.
+func (s *AccessControlStore) GetRoles(ctx context.Context, orgID int64) ([]*accesscontrol.RoleDTO, error) {
+	var result []*accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var roles []*accesscontrol.Role
+		err := sess.Where("(org_id = ? OR org_id = ?)", orgID, accesscontrol.GlobalOrgID).
+			And(customRoleFilter, customRoleFilterArgs()...).
+			Asc("name").Find(&roles)
+		if err != nil {
+			return err
+		}
+
+		result = make([]*accesscontrol.RoleDTO, 0, len(roles))
+		for _, role := range roles {
+			dto, err := getRoleDTO(sess, role)
+			if err != nil {
+				return err
+			}
+			result = append(result, dto)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// GetRoleByUID returns a custom role of an organization, or a global custom role.
+func (s *AccessControlStore) GetRoleByUID(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error) {
+	var result *accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		role, err := getCustomRole(sess, "uid = ? AND (org_id = ? OR org_id = ?)", uid, orgID, accesscontrol.GlobalOrgID)
+		if err != nil {
+			return err
+		}
+		result, err = getRoleDTO(sess, role)
+		return err
+	})
+	return result, err
+}
+
+// GetRoleByName returns a custom role of an organization, or a global custom
+// role when orgID is accesscontrol.GlobalOrgID.
+func (s *AccessControlStore) GetRoleByName(ctx context.Context, orgID int64, name string) (*accesscontrol.RoleDTO, error) {
+	var result *accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		role, err := getCustomRole(sess, "name = ? AND org_id = ?", name, orgID)
+		if err != nil {
+			return err
+		}
+		result, err = getRoleDTO(sess, role)
+		return err
+	})
+	return result, err
+}
+
+func (s *AccessControlStore) CreateRole(ctx context.Context, cmd accesscontrol.CreateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	orgID := cmd.OrgID
+	if cmd.Global {
+		orgID = accesscontrol.GlobalOrgID
+	}
+
+	var result *accesscontrol.RoleDTO
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if exists, err := sess.Where("org_id = ? AND name = ?", orgID, cmd.Name).Exist(&accesscontrol.Role{}); err != nil {
+			return err
+		} else if exists {
+			return accesscontrol.ErrRoleAlreadyExists
+		}
+
+		uid := cmd.UID
+		if uid == "" {
+			var err error
+			if uid, err = generateNewRoleUID(sess, orgID); err != nil {
+				return err
+			}
+		} else if exists, err := sess.Where("uid = ?", uid).Exist(&accesscontrol.Role{}); err != nil {
+			return err
+		} else if exists {
+			return accesscontrol.ErrRoleAlreadyExists
+		}
+
+		version := cmd.Version
+		if version < 1 {
+			version = 1
+		}
+
+		role := &accesscontrol.Role{
+			OrgID:       orgID,
+			UID:         uid,
+			Version:     version,
+			Name:        cmd.Name,
+			DisplayName: cmd.DisplayName,
+			Description: cmd.Description,
+			Group:       cmd.Group,
+			Created:     time.Now(),
+			Updated:     time.Now(),
+		}
+		if _, err := sess.Insert(role); err != nil {
+			return err
+		}
+
+		if err := insertRolePermissions(sess, role.ID, cmd.Permissions); err != nil {
+			return err
+		}
+
+		var err error
+		result, err = getRoleDTO(sess, role)
+		return err
+	})
+	return result, err
+}
+
+func (s *AccessControlStore) UpdateRole(ctx context.Context, cmd accesscontrol.UpdateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	var result *accesscontrol.RoleDTO
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		role, err := getCustomRole(sess, "uid = ? AND (org_id = ? OR org_id = ?)", cmd.UID, cmd.OrgID, accesscontrol.GlobalOrgID)
+		if err != nil {
+			return err
+		}
+
+		version := cmd.Version
+		if version == 0 {
+			version = role.Version + 1
+		} else if version <= role.Version {
+			return accesscontrol.ErrVersionLE
+		}
+
+		if cmd.Name != role.Name {
+			if exists, err := sess.Where("org_id = ? AND name = ?", role.OrgID, cmd.Name).Exist(&accesscontrol.Role{}); err != nil {
+				return err
+			} else if exists {
+				return accesscontrol.ErrRoleAlreadyExists
+			}
+		}
+
+		role.Version = version
+		role.Name = cmd.Name
+		role.DisplayName = cmd.DisplayName
+		role.Description = cmd.Description
+		role.Group = cmd.Group
+		role.Updated = time.Now()
+		if _, err := sess.ID(role.ID).AllCols().Update(role); err != nil {
+			return err
+		}
+
+		if _, err := sess.Exec("DELETE FROM permission WHERE role_id = ?", role.ID); err != nil {
+			return err
+		}
+		if err := insertRolePermissions(sess, role.ID, cmd.Permissions); err != nil {
+			return err
+		}
+
+		result, err = getRoleDTO(sess, role)
+		return err
+	})
+	return result, err
+}
+
+// DeleteRole deletes a custom role, its permissions and its assignments.
+func (s *AccessControlStore) DeleteRole(ctx context.Context, orgID int64, uid string) error {
+	return s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		role, err := getCustomRole(sess, "uid = ? AND (org_id = ? OR org_id = ?)", uid, orgID, accesscontrol.GlobalOrgID)
+		if err != nil {
+			return err
+		}
+
+		deletes := []string{
+			"DELETE FROM permission WHERE role_id = ?",
+			"DELETE FROM user_role WHERE role_id = ?",
+			"DELETE FROM team_role WHERE role_id = ?",
+			"DELETE FROM builtin_role WHERE role_id = ?",
+			"DELETE FROM role WHERE id = ?",
+		}
+		for _, sql := range deletes {
+			if _, err := sess.Exec(sql, role.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddUserRole assigns a role to a user in an organization, or in all
+// organizations when orgID is accesscontrol.GlobalOrgID. Adding an existing
+// assignment does nothing.
+func (s *AccessControlStore) AddUserRole(ctx context.Context, orgID, userID, roleID int64) error {
+	return s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if exists, err := sess.Where("org_id = ? AND user_id = ? AND role_id = ?", orgID, userID, roleID).Exist(&accesscontrol.UserRole{}); err != nil || exists {
+			return err
+		}
+		_, err := sess.Insert(&accesscontrol.UserRole{OrgID: orgID, UserID: userID, RoleID: roleID, Created: time.Now()})
+		return err
+	})
+}
+
+func (s *AccessControlStore) RemoveUserRole(ctx context.Context, orgID, userID, roleID int64) error {
+	return s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec("DELETE FROM user_role WHERE org_id = ? AND user_id = ? AND role_id = ?", orgID, userID, roleID)
+		return err
+	})
+}
+
+// AddTeamRole assigns a role to a team. Adding an existing assignment does nothing.
+func (s *AccessControlStore) AddTeamRole(ctx context.Context, orgID, teamID, roleID int64) error {
+	return s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if exists, err := sess.Where("org_id = ? AND team_id = ? AND role_id = ?", orgID, teamID, roleID).Exist(&accesscontrol.TeamRole{}); err != nil || exists {
+			return err
+		}
+		_, err := sess.Insert(&accesscontrol.TeamRole{OrgID: orgID, TeamID: teamID, RoleID: roleID, Created: time.Now()})
+		return err
+	})
+}
+
+func (s *AccessControlStore) RemoveTeamRole(ctx context.Context, orgID, teamID, roleID int64) error {
+	return s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec("DELETE FROM team_role WHERE org_id = ? AND team_id = ? AND role_id = ?", orgID, teamID, roleID)
+		return err
+	})
+}
+
+// AddBuiltInRole assigns a role to a built-in role in an organization, or in
+// all organizations when orgID is accesscontrol.GlobalOrgID. Adding an
+// existing assignment does nothing.
+func (s *AccessControlStore) AddBuiltInRole(ctx context.Context, orgID int64, builtInRole string, roleID int64) error {
+	return s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if exists, err := sess.Table("builtin_role").Where("org_id = ? AND role = ? AND role_id = ?", orgID, builtInRole, roleID).Exist(); err != nil || exists {
+			return err
+		}
+		_, err := sess.Table("builtin_role").Insert(accesscontrol.BuiltinRole{
+			RoleID:  roleID,
+			OrgID:   orgID,
+			Role:    builtInRole,
+			Updated: time.Now(),
+			Created: time.Now(),
+		})
+		return err
+	})
+}
+
+func (s *AccessControlStore) RemoveBuiltInRole(ctx context.Context, orgID int64, builtInRole string, roleID int64) error {
+	return s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec("DELETE FROM builtin_role WHERE org_id = ? AND role = ? AND role_id = ?", orgID, builtInRole, roleID)
+		return err
+	})
+}
+
+// GetRoleAssignments returns the users, teams and built-in roles a role is assigned to.
+func (s *AccessControlStore) GetRoleAssignments(ctx context.Context, roleID int64) (*accesscontrol.RoleAssignments, error) {
+	result := &accesscontrol.RoleAssignments{
+		Users:        []accesscontrol.UserRoleAssignment{},
+		Teams:        []accesscontrol.TeamRoleAssignment{},
+		BuiltInRoles: []accesscontrol.BuiltInRoleRoleAssignment{},
+	}
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		err := sess.SQL(`SELECT ur.org_id, ur.user_id, u.login
+			FROM user_role AS ur
+			INNER JOIN `+s.sql.Dialect.Quote("user")+` AS u ON u.id = ur.user_id
+			WHERE ur.role_id = ?
+			ORDER BY ur.org_id, u.login`, roleID).Find(&result.Users)
+		if err != nil {
+			return err
+		}
+
+		err = sess.SQL(`SELECT tr.org_id, tr.team_id, t.name
+			FROM team_role AS tr
+			INNER JOIN team AS t ON t.id = tr.team_id
+			WHERE tr.role_id = ?
+			ORDER BY tr.org_id, t.name`, roleID).Find(&result.Teams)
+		if err != nil {
+			return err
+		}
+
+		return sess.SQL(`SELECT org_id, role FROM builtin_role WHERE role_id = ? ORDER BY org_id, role`, roleID).Find(&result.BuiltInRoles)
+	})
+	return result, err
+}
+
+func getCustomRole(sess *sqlstore.DBSession, where string, args ...interface{}) (*accesscontrol.Role, error) {
+	role := &accesscontrol.Role{}
+	has, err := sess.Where(where, args...).And(customRoleFilter, customRoleFilterArgs()...).Get(role)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, accesscontrol.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func getRoleDTO(sess *sqlstore.DBSession, role *accesscontrol.Role) (*accesscontrol.RoleDTO, error) {
+	permissions := make([]accesscontrol.Permission, 0)
+	if err := sess.Where("role_id = ?", role.ID).Asc("action", "scope").Find(&permissions); err != nil {
+		return nil, err
+	}
+
+	return &accesscontrol.RoleDTO{
+		ID:          role.ID,
+		OrgID:       role.OrgID,
+		UID:         role.UID,
+		Version:     role.Version,
+		Name:        role.Name,
+		DisplayName: role.DisplayName,
+		Description: role.Description,
+		Group:       role.Group,
+		Permissions: permissions,
+		Created:     role.Created,
+		Updated:     role.Updated,
+	}, nil
+}
+
+func insertRolePermissions(sess *sqlstore.DBSession, roleID int64, permissions []accesscontrol.Permission) error {
+	seen := make(map[accesscontrol.Permission]bool, len(permissions))
+	for _, p := range permissions {
+		key := p.OSSPermission()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		permission := &accesscontrol.Permission{
+			RoleID:  roleID,
+			Action:  p.Action,
+			Scope:   p.Scope,
+			Created: time.Now(),
+			Updated: time.Now(),
+		}
+		if _, err := sess.Insert(permission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/resourcepermissions/types"
+)
+
+func TestAccessControlStore_CustomRoles(t *testing.T) {
+	store, _ := setupTestEnv(t)
+	ctx := context.Background()
+
+	role, err := store.CreateRole(ctx, accesscontrol.CreateRoleCommand{
+		OrgID:       1,
+		Name:        "custom:datasources:reader",
+		Description: "Read data sources",
+		Permissions: []accesscontrol.Permission{
+			{Action: "datasources:read", Scope: "datasources:*"},
+			{Action: "datasources:read", Scope: "datasources:*"},
+			{Action: "datasources:query"},
+		},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, role.UID)
+	require.Equal(t, int64(1), role.Version)
+	require.Equal(t, int64(1), role.OrgID)
+	require.Len(t, role.Permissions, 2)
+
+	_, err = store.CreateRole(ctx, accesscontrol.CreateRoleCommand{OrgID: 1, Name: "custom:datasources:reader"})
+	require.ErrorIs(t, err, accesscontrol.ErrRoleAlreadyExists)
+
+	_, err = store.CreateRole(ctx, accesscontrol.CreateRoleCommand{OrgID: 2, UID: role.UID, Name: "other"})
+	require.ErrorIs(t, err, accesscontrol.ErrRoleAlreadyExists)
+
+	global, err := store.CreateRole(ctx, accesscontrol.CreateRoleCommand{OrgID: 2, Global: true, Name: "custom:global", Version: 3})
+	require.NoError(t, err)
+	require.True(t, global.Global())
+	require.Equal(t, int64(3), global.Version)
+
+	// Managed roles are not custom roles
+	_, err = store.SetUserResourcePermission(ctx, 1, accesscontrol.User{ID: 1}, types.SetResourcePermissionCommand{
+		Actions:    []string{"dashboards:write"},
+		Resource:   "dashboards",
+		ResourceID: "1",
+	}, nil)
+	require.NoError(t, err)
+
+	roles, err := store.GetRoles(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, roles, 2)
+
+	roles, err = store.GetRoles(ctx, 3)
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+
+	_, err = store.GetRoleByUID(ctx, 2, role.UID)
+	require.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+
+	found, err := store.GetRoleByName(ctx, 1, "custom:datasources:reader")
+	require.NoError(t, err)
+	require.Equal(t, role.UID, found.UID)
+
+	updated, err := store.UpdateRole(ctx, accesscontrol.UpdateRoleCommand{
+		OrgID:       1,
+		UID:         role.UID,
+		Name:        "custom:datasources:writer",
+		Permissions: []accesscontrol.Permission{{Action: "datasources:write", Scope: "datasources:*"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), updated.Version)
+	require.Equal(t, "custom:datasources:writer", updated.Name)
+	require.Len(t, updated.Permissions, 1)
+
+	_, err = store.UpdateRole(ctx, accesscontrol.UpdateRoleCommand{OrgID: 1, UID: role.UID, Name: "custom:datasources:writer", Version: 2})
+	require.ErrorIs(t, err, accesscontrol.ErrVersionLE)
+
+	_, err = store.UpdateRole(ctx, accesscontrol.UpdateRoleCommand{OrgID: 2, UID: role.UID, Name: "custom:datasources:writer"})
+	require.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+
+	require.NoError(t, store.DeleteRole(ctx, 1, role.UID))
+	_, err = store.GetRoleByUID(ctx, 1, role.UID)
+	require.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+}
+
+func TestAccessControlStore_CustomRoleAssignments(t *testing.T) {
+	store, sql := setupTestEnv(t)
+	ctx := context.Background()
+	user, team := createUserAndTeam(t, sql, 1)
+
+	userRole, err := store.CreateRole(ctx, accesscontrol.CreateRoleCommand{
+		OrgID:       1,
+		Name:        "custom:user",
+		Permissions: []accesscontrol.Permission{{Action: "datasources:read", Scope: "datasources:*"}},
+	})
+	require.NoError(t, err)
+	teamRole, err := store.CreateRole(ctx, accesscontrol.CreateRoleCommand{
+		OrgID:       1,
+		Name:        "custom:team",
+		Permissions: []accesscontrol.Permission{{Action: "dashboards:create", Scope: "folders:*"}},
+	})
+	require.NoError(t, err)
+	builtInRole, err := store.CreateRole(ctx, accesscontrol.CreateRoleCommand{
+		Global:      true,
+		Name:        "custom:builtin",
+		Permissions: []accesscontrol.Permission{{Action: "annotations:read"}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.AddUserRole(ctx, 1, user.Id, userRole.ID))
+	require.NoError(t, store.AddUserRole(ctx, 1, user.Id, userRole.ID))
+	require.NoError(t, store.AddTeamRole(ctx, 1, team.Id, teamRole.ID))
+	require.NoError(t, store.AddBuiltInRole(ctx, accesscontrol.GlobalOrgID, "Viewer", builtInRole.ID))
+	require.NoError(t, store.AddBuiltInRole(ctx, accesscontrol.GlobalOrgID, "Viewer", builtInRole.ID))
+
+	assignments, err := store.GetRoleAssignments(ctx, userRole.ID)
+	require.NoError(t, err)
+	require.Equal(t, []accesscontrol.UserRoleAssignment{{OrgID: 1, UserID: user.Id, Login: "user"}}, assignments.Users)
+	assignments, err = store.GetRoleAssignments(ctx, teamRole.ID)
+	require.NoError(t, err)
+	require.Equal(t, []accesscontrol.TeamRoleAssignment{{OrgID: 1, TeamID: team.Id, Name: "team"}}, assignments.Teams)
+	assignments, err = store.GetRoleAssignments(ctx, builtInRole.ID)
+	require.NoError(t, err)
+	require.Equal(t, []accesscontrol.BuiltInRoleRoleAssignment{{OrgID: 0, BuiltInRole: "Viewer"}}, assignments.BuiltInRoles)
+
+	query := accesscontrol.GetUserPermissionsQuery{
+		OrgID:   1,
+		UserID:  user.Id,
+		Roles:   []string{"Viewer"},
+		Actions: []string{"teams:read"},
+	}
+	permissions, err := store.GetUserPermissions(ctx, query)
+	require.NoError(t, err)
+	require.Len(t, permissions, 0)
+
+	query.IncludeCustomRoles = true
+	permissions, err = store.GetUserPermissions(ctx, query)
+	require.NoError(t, err)
+	require.Len(t, permissions, 3)
+
+	require.NoError(t, store.RemoveUserRole(ctx, 1, user.Id, userRole.ID))
+	require.NoError(t, store.RemoveTeamRole(ctx, 1, team.Id, teamRole.ID))
+	require.NoError(t, store.DeleteRole(ctx, 1, builtInRole.UID))
+	permissions, err = store.GetUserPermissions(ctx, query)
+	require.NoError(t, err)
+	require.Len(t, permissions, 0)
+}
@@ -6,4 +6,10 @@ var (
 	ErrFixedRolePrefixMissing = errors.New("fixed role should be prefixed with '" + FixedRolePrefix + "'")
 	ErrInvalidBuiltinRole     = errors.New("built-in role is not valid")
 	ErrInvalidScope           = errors.New("invalid scope")
+	ErrReservedRolePrefix     = errors.New("custom role should not be prefixed with '" + FixedRolePrefix + "' or '" + ManagedRolePrefix + "'")
+	ErrInvalidPermission      = errors.New("invalid permission")
+	ErrRoleNotFound           = errors.New("role not found")
+	ErrRoleAlreadyExists      = errors.New("role with the same name or uid already exists")
+	ErrVersionLE              = errors.New("the provided role version should be greater than the stored one")
+	ErrRoleHasAssignments     = errors.New("role is assigned to users, teams or built-in roles")
 )
@@ -36,6 +36,10 @@ func (r Role) IsFixed() bool {
 	return strings.HasPrefix(r.Name, FixedRolePrefix)
 }
 
+func (r Role) IsManaged() bool {
+	return strings.HasPrefix(r.Name, ManagedRolePrefix)
+}
+
 func (r Role) GetDisplayName() string {
 	if r.IsFixed() && r.DisplayName == "" {
 		r.DisplayName = fallbackDisplayName(r.Name)
@@ -95,6 +99,10 @@ func (r RoleDTO) IsFixed() bool {
 	return strings.HasPrefix(r.Name, FixedRolePrefix)
 }
 
+func (r RoleDTO) IsManaged() bool {
+	return strings.HasPrefix(r.Name, ManagedRolePrefix)
+}
+
 func (r RoleDTO) GetDisplayName() string {
 	if r.IsFixed() && r.DisplayName == "" {
 		r.DisplayName = fallbackDisplayName(r.Name)
@@ -180,6 +188,66 @@ type GetUserPermissionsQuery struct {
 	UserID  int64 `json:"userId"`
 	Roles   []string
 	Actions []string
+	// IncludeCustomRoles returns the permissions of custom roles regardless
+	// of the Actions filter, which then only applies to managed roles.
+	IncludeCustomRoles bool
+}
+
+// CreateRoleCommand creates a custom role, in an organization or globally
+// when OrgID is GlobalOrgID.
+type CreateRoleCommand struct {
+	OrgID       int64        `json:"-"`
+	UID         string       `json:"uid"`
+	Name        string       `json:"name" binding:"Required"`
+	DisplayName string       `json:"displayName"`
+	Description string       `json:"description"`
+	Group       string       `json:"group"`
+	Version     int64        `json:"version"`
+	Global      bool         `json:"global"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// UpdateRoleCommand replaces the attributes and permissions of a custom role.
+// The version is incremented when it is not set.
+type UpdateRoleCommand struct {
+	OrgID       int64        `json:"-"`
+	UID         string       `json:"-"`
+	Name        string       `json:"name" binding:"Required"`
+	DisplayName string       `json:"displayName"`
+	Description string       `json:"description"`
+	Group       string       `json:"group"`
+	Version     int64        `json:"version"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// RoleAssignments lists the users, teams and built-in roles a role is
+// assigned to.
+type RoleAssignments struct {
+	Users        []UserRoleAssignment        `json:"users"`
+	Teams        []TeamRoleAssignment        `json:"teams"`
+	BuiltInRoles []BuiltInRoleRoleAssignment `json:"builtInRoles"`
+}
+
+// Len returns the total number of assignments of a role.
+func (a *RoleAssignments) Len() int {
+	return len(a.Users) + len(a.Teams) + len(a.BuiltInRoles)
+}
+
+type UserRoleAssignment struct {
+	OrgID  int64  `json:"orgId" xorm:"org_id"`
+	UserID int64  `json:"userId" xorm:"user_id"`
+	Login  string `json:"login"`
+}
+
+type TeamRoleAssignment struct {
+	OrgID  int64  `json:"orgId" xorm:"org_id"`
+	TeamID int64  `json:"teamId" xorm:"team_id"`
+	Name   string `json:"name"`
+}
+
+type BuiltInRoleRoleAssignment struct {
+	OrgID       int64  `json:"orgId" xorm:"org_id"`
+	BuiltInRole string `json:"builtInRole" xorm:"role"`
 }
 
 // ScopeParams holds the parameters used to fill in scope templates
@@ -208,7 +276,7 @@ type ResourcePermission struct {
 }
 
 func (p *ResourcePermission) IsManaged() bool {
-	return strings.HasPrefix(p.RoleName, "managed:")
+	return strings.HasPrefix(p.RoleName, ManagedRolePrefix)
 }
 
 func (p *ResourcePermission) Contains(targetActions []string) bool {
@@ -321,6 +389,20 @@ const (
 
 	// Team related scopes
 	ScopeTeamsAll = "teams:*"
+
+	// Custom roles actions
+	ActionRolesRead          = "roles:read"
+	ActionRolesWrite         = "roles:write"
+	ActionRolesDelete        = "roles:delete"
+	ActionUsersRolesAdd      = "users.roles:add"
+	ActionUsersRolesRemove   = "users.roles:remove"
+	ActionTeamsRolesAdd      = "teams.roles:add"
+	ActionTeamsRolesRemove   = "teams.roles:remove"
+	ActionRolesBuiltInAdd    = "roles.builtin:add"
+	ActionRolesBuiltInRemove = "roles.builtin:remove"
+
+	// Custom roles scopes
+	ScopeRolesAll = "roles:*"
 )
 
 var (
@@ -332,6 +414,8 @@ const RoleGrafanaAdmin = "Grafana Admin"
 
 const FixedRolePrefix = "fixed:"
 
+const ManagedRolePrefix = "managed:"
+
 // LicensingPageReaderAccess defines permissions that grant access to the licensing and stats page
 var LicensingPageReaderAccess = EvalAny(
 	EvalPermission(ActionLicensingRead),
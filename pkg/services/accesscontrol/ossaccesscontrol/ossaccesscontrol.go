@@ -95,7 +95,8 @@ func (ac *OSSAccessControlService) GetUserRoles(ctx context.Context, user *model
 	return nil, errors.New("unsupported function") //OSS users will continue to use builtin roles via GetUserPermissions
 }
 
-// GetUserPermissions returns user permissions based on built-in roles
+// GetUserPermissions returns user permissions based on built-in roles, and
+// the custom roles assigned to the user, their teams and built-in roles
 func (ac *OSSAccessControlService) GetUserPermissions(ctx context.Context, user *models.SignedInUser) ([]*accesscontrol.Permission, error) {
 	timer := prometheus.NewTimer(metrics.MAccessPermissionsSummary)
 	defer timer.ObserveDuration()
@@ -107,6 +108,9 @@ func (ac *OSSAccessControlService) GetUserPermissions(ctx context.Context, user
 		UserID:  user.UserId,
 		Roles:   ac.GetUserBuiltInRoles(user),
 		Actions: resourceservices.TeamAdminActions,
+		// Managed roles are limited to team permissions in OSS, since the
+		// other resources still use the legacy permissions.
+		IncludeCustomRoles: true,
 	})
 	if err != nil {
 		return nil, err
@@ -124,7 +128,20 @@ func (ac *OSSAccessControlService) GetUserPermissions(ctx context.Context, user
 		resolved = append(resolved, p)
 	}
 
-	return resolved, nil
+	if len(user.TokenPermissions) == 0 {
+		return resolved, nil
+	}
+
+	// The service account authenticated with a restricted token
+	restrictions := make([]*accesscontrol.Permission, 0, len(user.TokenPermissions))
+	for _, p := range user.TokenPermissions {
+		scope, err := keywordMutator(ctx, p.Scope)
+		if err != nil {
+			return nil, err
+		}
+		restrictions = append(restrictions, &accesscontrol.Permission{Action: p.Action, Scope: scope})
+	}
+	return accesscontrol.RestrictPermissions(resolved, restrictions), nil
 }
 
 func (ac *OSSAccessControlService) getFixedPermissions(ctx context.Context, user *models.SignedInUser) []*accesscontrol.Permission {
@@ -148,8 +165,13 @@ func (ac *OSSAccessControlService) getFixedPermissions(ctx context.Context, user
 }
 
 func (ac *OSSAccessControlService) GetUserBuiltInRoles(user *models.SignedInUser) []string {
-	roles := []string{string(user.OrgRole)}
-	for _, role := range user.OrgRole.Children() {
+	orgRole := user.OrgRole
+	if user.TokenOrgRole != "" {
+		orgRole = user.TokenOrgRole
+	}
+
+	roles := []string{string(orgRole)}
+	for _, role := range orgRole.Children() {
 		roles = append(roles, string(role))
 	}
 	if user.IsGrafanaAdmin {
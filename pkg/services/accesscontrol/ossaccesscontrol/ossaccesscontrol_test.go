@@ -524,6 +524,18 @@ func TestOSSAccessControlService_GetUserPermissions(t *testing.T) {
 			wantPerm: accesscontrol.Permission{Action: "users:read", Scope: "users:id:2"},
 			wantErr:  false,
 		},
+		{
+			name: "Restrict to the token permissions",
+			user: func() models.SignedInUser {
+				user := testUser
+				user.TokenPermissions = []models.ApiKeyPermission{{Action: "users:read", Scope: "users:self"}}
+				user.TokenOrgRole, user.OrgRole = user.OrgRole, ""
+				return user
+			}(),
+			rawPerm:  accesscontrol.Permission{Action: "users:read", Scope: "users:*"},
+			wantPerm: accesscontrol.Permission{Action: "users:read", Scope: "users:id:2"},
+			wantErr:  false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
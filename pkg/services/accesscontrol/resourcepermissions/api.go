@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -136,6 +137,7 @@ func (a *api) setUserPermission(c *models.ReqContext) response.Response {
 	if err != nil {
 		return response.Error(http.StatusBadRequest, "failed to set user permission", err)
 	}
+	a.recordAudit(c, resourceID, "userId", userID, "permission", cmd.Permission)
 
 	return permissionSetResponse(cmd)
 }
@@ -156,6 +158,7 @@ func (a *api) setTeamPermission(c *models.ReqContext) response.Response {
 	if err != nil {
 		return response.Error(http.StatusBadRequest, "failed to set team permission", err)
 	}
+	a.recordAudit(c, resourceID, "teamId", teamID, "permission", cmd.Permission)
 
 	return permissionSetResponse(cmd)
 }
@@ -173,10 +176,21 @@ func (a *api) setBuiltinRolePermission(c *models.ReqContext) response.Response {
 	if err != nil {
 		return response.Error(http.StatusBadRequest, "failed to set role permission", err)
 	}
+	a.recordAudit(c, resourceID, "builtInRole", builtInRole, "permission", cmd.Permission)
 
 	return permissionSetResponse(cmd)
 }
 
+// recordAudit records a permission change on a resource. The resource type of
+// the entry is the resource of the service, like teams.
+func (a *api) recordAudit(c *models.ReqContext, resourceID string, details ...interface{}) {
+	if a.service.audit == nil {
+		return
+	}
+	entry := audit.NewEntry(c, audit.ActionPermissionsUpdate, a.service.options.Resource, resourceID).WithDetails(details...)
+	a.service.audit.Record(c.Req.Context(), entry)
+}
+
 func permissionSetResponse(cmd setPermissionCommand) response.Response {
 	message := "Permission updated"
 	if cmd.Permission == "" {
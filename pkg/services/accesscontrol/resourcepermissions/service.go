@@ -6,6 +6,7 @@ import (
 	"sort"
 
 	"github.com/grafana/grafana/pkg/services/accesscontrol/resourcepermissions/types"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 
 	"github.com/grafana/grafana/pkg/api/routing"
@@ -46,7 +47,7 @@ type Store interface {
 	GetResourcesPermissions(ctx context.Context, orgID int64, query types.GetResourcesPermissionsQuery) ([]accesscontrol.ResourcePermission, error)
 }
 
-func New(options Options, router routing.RouteRegister, ac accesscontrol.AccessControl, store Store, sqlStore *sqlstore.SQLStore) (*Service, error) {
+func New(options Options, router routing.RouteRegister, ac accesscontrol.AccessControl, store Store, sqlStore *sqlstore.SQLStore, auditService audit.Service) (*Service, error) {
 	var permissions []string
 	actionSet := make(map[string]struct{})
 	for permission, actions := range options.PermissionsToActions {
@@ -73,6 +74,7 @@ func New(options Options, router routing.RouteRegister, ac accesscontrol.AccessC
 		permissions: permissions,
 		actions:     actions,
 		sqlStore:    sqlStore,
+		audit:       auditService,
 	}
 
 	s.api = newApi(ac, router, s)
@@ -96,6 +98,7 @@ type Service struct {
 	permissions []string
 	actions     []string
 	sqlStore    *sqlstore.SQLStore
+	audit       audit.Service
 }
 
 func (s *Service) GetPermissions(ctx context.Context, orgID int64, resourceID string) ([]accesscontrol.ResourcePermission, error) {
@@ -257,6 +257,20 @@ func ValidateFixedRole(role RoleDTO) error {
 	return nil
 }
 
+// ValidateCustomRole errors when a custom role uses a name reserved for fixed
+// or managed roles, or has permissions without an action
+func ValidateCustomRole(name string, permissions []Permission) error {
+	if strings.HasPrefix(name, FixedRolePrefix) || strings.HasPrefix(name, ManagedRolePrefix) {
+		return ErrReservedRolePrefix
+	}
+	for _, p := range permissions {
+		if p.Action == "" {
+			return fmt.Errorf("%w: permission without action", ErrInvalidPermission)
+		}
+	}
+	return nil
+}
+
 // ValidateBuiltInRoles errors when a built-in role does not match expected pattern
 func ValidateBuiltInRoles(builtInRoles []string) error {
 	for _, br := range builtInRoles {
@@ -10,8 +10,10 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/secrets/references"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -304,6 +306,24 @@ func InitNotifier(model *models.AlertNotification, fn GetDecryptedValueFn, notif
 // the given key. If the key is not present, then it returns the fallback value.
 type GetDecryptedValueFn func(ctx context.Context, sjd map[string][]byte, key string, fallback string, secret string) string
 
+// newDecryptFn returns a GetDecryptedValueFn which also resolves references
+// to secrets stored outside of Grafana, like ${env:SLACK_TOKEN}.
+func newDecryptFn(encryptionService encryption.Internal, secretReferences *references.Resolver) GetDecryptedValueFn {
+	logger := log.New("alerting.notifier")
+	return func(ctx context.Context, sjd map[string][]byte, key string, fallback string, secret string) string {
+		if _, ok := sjd[key]; !ok {
+			return fallback
+		}
+		value := encryptionService.GetDecryptedValue(ctx, sjd, key, fallback, secret)
+		resolved, err := secretReferences.Resolve(ctx, value)
+		if err != nil {
+			logger.Error("Failed to resolve secure setting reference, using the fallback", "key", key, "error", err)
+			return fallback
+		}
+		return resolved
+	}
+}
+
 // NotifierFactory is a signature for creating notifiers.
 type NotifierFactory func(*models.AlertNotification, GetDecryptedValueFn, notifications.Service) (Notifier, error)
 
@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/secrets/references"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -15,15 +16,17 @@ type AlertNotificationService struct {
 	Bus                 bus.Bus
 	SQLStore            *sqlstore.SQLStore
 	EncryptionService   encryption.Internal
+	SecretReferences    *references.Resolver
 	NotificationService *notifications.NotificationService
 }
 
 func ProvideService(bus bus.Bus, store *sqlstore.SQLStore, encryptionService encryption.Internal,
-	notificationService *notifications.NotificationService) *AlertNotificationService {
+	secretReferences *references.Resolver, notificationService *notifications.NotificationService) *AlertNotificationService {
 	s := &AlertNotificationService{
 		Bus:                 bus,
 		SQLStore:            store,
 		EncryptionService:   encryptionService,
+		SecretReferences:    secretReferences,
 		NotificationService: notificationService,
 	}
 
@@ -156,7 +159,7 @@ func (s *AlertNotificationService) createNotifier(ctx context.Context, model *mo
 		return nil, err
 	}
 
-	notifier, err := InitNotifier(model, s.EncryptionService.GetDecryptedValue, s.NotificationService)
+	notifier, err := InitNotifier(model, newDecryptFn(s.EncryptionService, s.SecretReferences), s.NotificationService)
 	if err != nil {
 		logger.Error("Failed to create notifier", "error", err.Error())
 		return nil, err
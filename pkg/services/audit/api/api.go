@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
+)
+
+const (
+	defaultPerPage = 100
+	maxPerPage     = 1000
+)
+
+type AuditAPI struct {
+	service        audit.Service
+	RouterRegister routing.RouteRegister
+}
+
+func NewAuditAPI(service audit.Service, routerRegister routing.RouteRegister) *AuditAPI {
+	return &AuditAPI{
+		service:        service,
+		RouterRegister: routerRegister,
+	}
+}
+
+func (api *AuditAPI) RegisterAPIEndpoints() {
+	api.RouterRegister.Get("/api/admin/audit", middleware.ReqGrafanaAdmin, routing.Wrap(api.SearchEntries))
+}
+
+// SearchEntries returns the audit entries matching the query parameters, most
+// recent first. from and to are epoch timestamps in milliseconds.
+func (api *AuditAPI) SearchEntries(c *models.ReqContext) response.Response {
+	perPage := c.QueryInt("perpage")
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+	page := c.QueryInt("page")
+	if page < 1 {
+		page = 1
+	}
+
+	query := &audit.SearchQuery{
+		OrgId:        c.QueryInt64("orgId"),
+		UserId:       c.QueryInt64("userId"),
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resourceType"),
+		ResourceId:   c.Query("resourceId"),
+		Result:       c.Query("result"),
+		Page:         page,
+		PerPage:      perPage,
+	}
+	if from := c.QueryInt64("from"); from > 0 {
+		query.From = time.UnixMilli(from)
+	}
+	if to := c.QueryInt64("to"); to > 0 {
+		query.To = time.UnixMilli(to)
+	}
+
+	result, err := api.service.Search(c.Req.Context(), query)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to search audit entries", err)
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
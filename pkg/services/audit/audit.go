@@ -0,0 +1,136 @@
+// Package audit records who did what to which resource, for administrative
+// and security-relevant actions like changes to dashboards, data sources and
+// permissions, API key creation and logins.
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+type Service interface {
+	// Record stores an audit entry. Failing to record an entry is logged and
+	// never fails the action being audited.
+	Record(ctx context.Context, entry *Entry)
+	Search(ctx context.Context, query *SearchQuery) (*SearchResult, error)
+}
+
+type Store interface {
+	Insert(ctx context.Context, entry *Entry) error
+	Search(ctx context.Context, query *SearchQuery) (*SearchResult, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+}
+
+const (
+	ActionCreate            = "create"
+	ActionUpdate            = "update"
+	ActionDelete            = "delete"
+	ActionPermissionsUpdate = "permissions-update"
+	ActionRoleUpdate        = "role-update"
+	ActionLogin             = "login"
+	ActionTOTPEnable        = "totp-enable"
+	ActionTOTPDisable       = "totp-disable"
+)
+
+const (
+	ResourceDashboard           = "dashboard"
+	ResourceFolder              = "folder"
+	ResourceDatasource          = "datasource"
+	ResourceTeam                = "team"
+	ResourceAPIKey              = "api-key"
+	ResourceServiceAccountToken = "service-account-token"
+	ResourceServiceAccountJWT   = "service-account-jwt-identity"
+	ResourceUser                = "user"
+	ResourceOrg                 = "org"
+	ResourcePreferences         = "preferences"
+	ResourcePluginSettings      = "plugin-settings"
+	ResourceRole                = "role"
+)
+
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+type Entry struct {
+	Id           int64                  `json:"id"`
+	OrgId        int64                  `json:"orgId"`
+	UserId       int64                  `json:"userId"`
+	UserLogin    string                 `json:"userLogin"`
+	Action       string                 `json:"action"`
+	ResourceType string                 `json:"resourceType"`
+	ResourceId   string                 `json:"resourceId"`
+	Result       string                 `json:"result"`
+	IpAddress    string                 `json:"ipAddress"`
+	UserAgent    string                 `json:"userAgent"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+	Created      time.Time              `json:"created"`
+}
+
+func (Entry) TableName() string {
+	return "audit_entry"
+}
+
+// NewEntry returns a successful audit entry for an action of the signed in
+// user of a request.
+func NewEntry(c *models.ReqContext, action, resourceType, resourceId string) *Entry {
+	entry := &Entry{
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceId:   resourceId,
+		Result:       ResultSuccess,
+		IpAddress:    c.RemoteAddr(),
+		UserAgent:    c.Req.UserAgent(),
+	}
+	if c.SignedInUser != nil {
+		entry.OrgId = c.OrgId
+		entry.UserId = c.UserId
+		entry.UserLogin = c.Login
+	}
+	return entry
+}
+
+// WithDetails adds details to the entry, like the title of the resource or
+// the new value of a setting.
+func (e *Entry) WithDetails(keysAndValues ...interface{}) *Entry {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{}, len(keysAndValues)/2)
+	}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if key, ok := keysAndValues[i].(string); ok {
+			e.Details[key] = keysAndValues[i+1]
+		}
+	}
+	return e
+}
+
+// Failed marks the entry as a failed attempt.
+func (e *Entry) Failed(err error) *Entry {
+	e.Result = ResultFailure
+	if err != nil {
+		e.WithDetails("error", err.Error())
+	}
+	return e
+}
+
+type SearchQuery struct {
+	OrgId        int64
+	UserId       int64
+	Action       string
+	ResourceType string
+	ResourceId   string
+	Result       string
+	From         time.Time
+	To           time.Time
+	Page         int
+	PerPage      int
+}
+
+type SearchResult struct {
+	TotalCount int64    `json:"totalCount"`
+	Entries    []*Entry `json:"entries"`
+	Page       int      `json:"page"`
+	PerPage    int      `json:"perPage"`
+}
@@ -0,0 +1,37 @@
+package audittest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/services/audit"
+)
+
+var _ audit.Service = new(FakeService)
+
+// FakeService keeps the recorded audit entries in memory.
+type FakeService struct {
+	mu      sync.Mutex
+	Entries []*audit.Entry
+}
+
+func NewFakeService() *FakeService {
+	return &FakeService{}
+}
+
+func (s *FakeService) Record(_ context.Context, entry *audit.Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Entries = append(s.Entries, entry)
+}
+
+func (s *FakeService) Search(_ context.Context, query *audit.SearchQuery) (*audit.SearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &audit.SearchResult{
+		TotalCount: int64(len(s.Entries)),
+		Entries:    s.Entries,
+		Page:       query.Page,
+		PerPage:    query.PerPage,
+	}, nil
+}
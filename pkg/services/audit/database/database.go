@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+type AuditStoreImpl struct {
+	sqlStore *sqlstore.SQLStore
+}
+
+func NewAuditStore(store *sqlstore.SQLStore) *AuditStoreImpl {
+	return &AuditStoreImpl{
+		sqlStore: store,
+	}
+}
+
+func (s *AuditStoreImpl) Insert(ctx context.Context, entry *audit.Entry) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(entry)
+		return err
+	})
+}
+
+func (s *AuditStoreImpl) Search(ctx context.Context, query *audit.SearchQuery) (*audit.SearchResult, error) {
+	result := &audit.SearchResult{
+		Entries: make([]*audit.Entry, 0),
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	}
+
+	whereConditions := make([]string, 0)
+	whereParams := make([]interface{}, 0)
+	addCondition := func(condition string, param interface{}) {
+		whereConditions = append(whereConditions, condition)
+		whereParams = append(whereParams, param)
+	}
+
+	if query.OrgId > 0 {
+		addCondition("org_id = ?", query.OrgId)
+	}
+	if query.UserId > 0 {
+		addCondition("user_id = ?", query.UserId)
+	}
+	if query.Action != "" {
+		addCondition("action = ?", query.Action)
+	}
+	if query.ResourceType != "" {
+		addCondition("resource_type = ?", query.ResourceType)
+	}
+	if query.ResourceId != "" {
+		addCondition("resource_id = ?", query.ResourceId)
+	}
+	if query.Result != "" {
+		addCondition("result = ?", query.Result)
+	}
+	if !query.From.IsZero() {
+		addCondition("created >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		addCondition("created <= ?", query.To)
+	}
+
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if len(whereConditions) > 0 {
+			sess.Where(strings.Join(whereConditions, " AND "), whereParams...)
+		}
+		if query.PerPage > 0 {
+			offset := query.PerPage * (query.Page - 1)
+			sess.Limit(query.PerPage, offset)
+		}
+		sess.Desc("created", "id")
+		if err := sess.Find(&result.Entries); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if len(whereConditions) > 0 {
+			sess.Where(strings.Join(whereConditions, " AND "), whereParams...)
+		}
+		count, err := sess.Count(&audit.Entry{})
+		result.TotalCount = count
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *AuditStoreImpl) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	var affected int64
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM audit_entry WHERE created < ?", before)
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	return affected, err
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func setupTestStore(t *testing.T) *AuditStoreImpl {
+	t.Helper()
+	return NewAuditStore(sqlstore.InitTestDB(t))
+}
+
+func TestStore_Search(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+	created := time.Now().Truncate(time.Second)
+
+	entries := []*audit.Entry{
+		{OrgId: 1, UserId: 1, UserLogin: "admin", Action: audit.ActionCreate, ResourceType: audit.ResourceDashboard, ResourceId: "abc", Result: audit.ResultSuccess, Created: created.Add(-2 * time.Hour)},
+		{OrgId: 1, UserId: 2, UserLogin: "editor", Action: audit.ActionDelete, ResourceType: audit.ResourceDashboard, ResourceId: "abc", Result: audit.ResultSuccess, Created: created.Add(-time.Hour)},
+		{OrgId: 2, UserId: 2, UserLogin: "editor", Action: audit.ActionLogin, ResourceType: audit.ResourceUser, Result: audit.ResultFailure, Created: created,
+			Details: map[string]interface{}{"error": "invalid username or password"}},
+	}
+	for _, entry := range entries {
+		require.NoError(t, store.Insert(ctx, entry))
+		require.NotZero(t, entry.Id)
+	}
+
+	t.Run("returns the most recent entries first", func(t *testing.T) {
+		result, err := store.Search(ctx, &audit.SearchQuery{})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), result.TotalCount)
+		require.Len(t, result.Entries, 3)
+		require.Equal(t, audit.ActionLogin, result.Entries[0].Action)
+		require.Equal(t, "invalid username or password", result.Entries[0].Details["error"])
+		require.Equal(t, audit.ActionCreate, result.Entries[2].Action)
+	})
+
+	t.Run("filters entries", func(t *testing.T) {
+		result, err := store.Search(ctx, &audit.SearchQuery{OrgId: 1, ResourceType: audit.ResourceDashboard, ResourceId: "abc", UserId: 2})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), result.TotalCount)
+		require.Equal(t, audit.ActionDelete, result.Entries[0].Action)
+
+		result, err = store.Search(ctx, &audit.SearchQuery{Result: audit.ResultFailure})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), result.TotalCount)
+
+		result, err = store.Search(ctx, &audit.SearchQuery{From: created.Add(-90 * time.Minute), To: created.Add(-30 * time.Minute)})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), result.TotalCount)
+		require.Equal(t, "editor", result.Entries[0].UserLogin)
+	})
+
+	t.Run("paginates entries", func(t *testing.T) {
+		result, err := store.Search(ctx, &audit.SearchQuery{Page: 2, PerPage: 2})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), result.TotalCount)
+		require.Len(t, result.Entries, 1)
+		require.Equal(t, audit.ActionCreate, result.Entries[0].Action)
+	})
+}
+
+func TestStore_DeleteOlderThan(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, store.Insert(ctx, &audit.Entry{Action: audit.ActionLogin, Created: now.Add(-48 * time.Hour)}))
+	require.NoError(t, store.Insert(ctx, &audit.Entry{Action: audit.ActionLogin, Created: now}))
+
+	deleted, err := store.DeleteOlderThan(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	result, err := store.Search(ctx, &audit.SearchQuery{})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.TotalCount)
+}
@@ -0,0 +1,217 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/audit/api"
+	"github.com/grafana/grafana/pkg/services/audit/database"
+	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const cleanupInterval = time.Hour
+
+var (
+	entriesRecordedTotal *prometheus.CounterVec
+	recordFailuresTotal  prometheus.Counter
+)
+
+func init() {
+	entriesRecordedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "audit_entries_recorded_total",
+		Help:      "Number of audit entries recorded by result of the audited action",
+		Namespace: "grafana",
+	}, []string{"result"})
+
+	recordFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name:      "audit_record_failures_total",
+		Help:      "Number of audit entries Grafana failed to write to the database or the audit log file",
+		Namespace: "grafana",
+	})
+}
+
+// now is used to test the retention of audit entries.
+var now = time.Now
+
+type AuditService struct {
+	cfg        setting.AuditSettings
+	store      audit.Store
+	serverLock *serverlock.ServerLockService
+	file       *log.FileLogWriter
+	log        log.Logger
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sqlStore *sqlstore.SQLStore,
+	serverLock *serverlock.ServerLockService,
+	routeRegister routing.RouteRegister,
+	hooksService *hooks.HooksService,
+) (*AuditService, error) {
+	s := &AuditService{
+		cfg:        cfg.Audit,
+		store:      database.NewAuditStore(sqlStore),
+		serverLock: serverLock,
+		log:        log.New("audit"),
+	}
+
+	if !s.cfg.Enabled {
+		return s, nil
+	}
+
+	if s.cfg.LogPath != "" {
+		if err := os.MkdirAll(filepath.Dir(s.cfg.LogPath), 0750); err != nil {
+			return nil, err
+		}
+		s.file = log.NewFileWriter()
+		s.file.Filename = s.cfg.LogPath
+		s.file.Maxdays = s.cfg.LogMaxDays
+		if err := s.file.StartLogger(); err != nil {
+			return nil, err
+		}
+	}
+
+	hooksService.AddLoginHook(s.recordLogin)
+
+	auditAPI := api.NewAuditAPI(s, routeRegister)
+	auditAPI.RegisterAPIEndpoints()
+
+	return s, nil
+}
+
+// IsDisabled returns true if the audit log is not enabled, in which case the
+// retention of audit entries is not enforced either.
+func (s *AuditService) IsDisabled() bool {
+	return !s.cfg.Enabled
+}
+
+func (s *AuditService) Run(ctx context.Context) error {
+	if s.file != nil {
+		defer func() {
+			if err := s.file.Close(); err != nil {
+				s.log.Warn("Failed to close audit log file", "error", err)
+			}
+		}()
+	}
+
+	if s.cfg.Retention <= 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	s.cleanupWithLock(ctx)
+
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.cleanupWithLock(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *AuditService) cleanupWithLock(ctx context.Context) {
+	err := s.serverLock.LockAndExecute(ctx, "delete expired audit entries", cleanupInterval, func(ctx context.Context) {
+		if _, err := s.DeleteExpired(ctx); err != nil {
+			s.log.Error("Failed to delete expired audit entries", "error", err)
+		}
+	})
+	if err != nil {
+		s.log.Error("Failed to lock and execute deletion of expired audit entries", "error", err)
+	}
+}
+
+// DeleteExpired deletes the audit entries older than the configured
+// retention.
+func (s *AuditService) DeleteExpired(ctx context.Context) (int64, error) {
+	if s.cfg.Retention <= 0 {
+		return 0, nil
+	}
+	deleted, err := s.store.DeleteOlderThan(ctx, now().Add(-s.cfg.Retention))
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		s.log.Debug("Deleted expired audit entries", "count", deleted)
+	}
+	return deleted, nil
+}
+
+func (s *AuditService) Record(ctx context.Context, entry *audit.Entry) {
+	if !s.cfg.Enabled {
+		return
+	}
+	if entry.Created.IsZero() {
+		entry.Created = now()
+	}
+	entriesRecordedTotal.WithLabelValues(entry.Result).Inc()
+
+	if err := s.store.Insert(ctx, entry); err != nil {
+		recordFailuresTotal.Inc()
+		s.log.Error("Failed to store audit entry", "error", err, "action", entry.Action,
+			"resourceType", entry.ResourceType, "resourceId", entry.ResourceId)
+	}
+
+	// The entry is written to the file even if it could not be stored in
+	// the database, so that no action goes unaudited.
+	if s.file != nil {
+		if err := s.writeFile(entry); err != nil {
+			recordFailuresTotal.Inc()
+			s.log.Error("Failed to write audit entry to file", "error", err, "action", entry.Action,
+				"resourceType", entry.ResourceType, "resourceId", entry.ResourceId)
+		}
+	}
+}
+
+// recordLogin records successful and failed logins, with the login form and
+// with external authentication providers.
+func (s *AuditService) recordLogin(info *models.LoginInfo, c *models.ReqContext) {
+	entry := audit.NewEntry(c, audit.ActionLogin, audit.ResourceUser, "")
+	entry.OrgId, entry.UserId, entry.UserLogin = 0, 0, info.LoginUsername
+	if entry.UserLogin == "" {
+		entry.UserLogin = info.ExternalUser.Login
+	}
+	if info.User != nil {
+		entry.OrgId = info.User.OrgId
+		entry.UserId = info.User.Id
+		entry.UserLogin = info.User.Login
+		entry.ResourceId = strconv.FormatInt(info.User.Id, 10)
+	}
+	if info.AuthModule != "" {
+		entry.WithDetails("authModule", info.AuthModule)
+	}
+	if info.Error != nil || info.HTTPStatus >= 400 {
+		entry.Failed(info.Error)
+	}
+	s.Record(c.Req.Context(), entry)
+}
+
+func (s *AuditService) writeFile(entry *audit.Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *AuditService) Search(ctx context.Context, query *audit.SearchQuery) (*audit.SearchResult, error) {
+	return s.store.Search(ctx, query)
+}
@@ -0,0 +1,163 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func setupTestService(t *testing.T, auditCfg setting.AuditSettings) (*AuditService, *hooks.HooksService) {
+	t.Helper()
+	sqlStore := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.Audit = auditCfg
+	hooksService := hooks.ProvideService()
+	s, err := ProvideService(cfg, sqlStore, serverlock.ProvideService(sqlStore), routing.NewRouteRegister(), hooksService)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if s.file != nil {
+			_ = s.file.Close()
+		}
+	})
+	return s, hooksService
+}
+
+func testReqContext(user *models.SignedInUser) *models.ReqContext {
+	req := httptest.NewRequest("POST", "/api/dashboards/db", nil)
+	req.RemoteAddr = "10.0.0.1:34567"
+	req.Header.Set("User-Agent", "test-agent")
+	return &models.ReqContext{
+		Context:      &web.Context{Req: req},
+		SignedInUser: user,
+	}
+}
+
+func TestAuditService_Record(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit", "audit.log")
+	s, _ := setupTestService(t, setting.AuditSettings{Enabled: true, LogPath: logPath, LogMaxDays: 7})
+	ctx := context.Background()
+
+	c := testReqContext(&models.SignedInUser{OrgId: 1, UserId: 2, Login: "editor"})
+	s.Record(ctx, audit.NewEntry(c, audit.ActionCreate, audit.ResourceDashboard, "abc").WithDetails("title", "Production"))
+
+	result, err := s.Search(ctx, &audit.SearchQuery{})
+	require.NoError(t, err)
+	require.Len(t, result.Entries, 1)
+	entry := result.Entries[0]
+	require.Equal(t, int64(1), entry.OrgId)
+	require.Equal(t, int64(2), entry.UserId)
+	require.Equal(t, "editor", entry.UserLogin)
+	require.Equal(t, "10.0.0.1", entry.IpAddress)
+	require.Equal(t, "test-agent", entry.UserAgent)
+	require.Equal(t, audit.ResultSuccess, entry.Result)
+	require.Equal(t, "Production", entry.Details["title"])
+
+	content, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 1)
+	var logged audit.Entry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &logged))
+	require.Equal(t, entry.Id, logged.Id)
+	require.Equal(t, audit.ActionCreate, logged.Action)
+	require.Equal(t, "abc", logged.ResourceId)
+}
+
+func TestAuditService_Disabled(t *testing.T) {
+	s, hooksService := setupTestService(t, setting.AuditSettings{Enabled: false})
+	ctx := context.Background()
+
+	c := testReqContext(&models.SignedInUser{OrgId: 1, UserId: 2, Login: "editor"})
+	s.Record(ctx, audit.NewEntry(c, audit.ActionDelete, audit.ResourceDatasource, "abc"))
+	hooksService.RunLoginHook(&models.LoginInfo{LoginUsername: "admin", HTTPStatus: 200}, c)
+
+	require.True(t, s.IsDisabled())
+	result, err := s.Search(ctx, &audit.SearchQuery{})
+	require.NoError(t, err)
+	require.Empty(t, result.Entries)
+}
+
+func TestAuditService_Logins(t *testing.T) {
+	s, hooksService := setupTestService(t, setting.AuditSettings{Enabled: true})
+	ctx := context.Background()
+
+	c := testReqContext(&models.SignedInUser{})
+	hooksService.RunLoginHook(&models.LoginInfo{
+		AuthModule:    "ldap",
+		LoginUsername: "admin",
+		HTTPStatus:    401,
+		Error:         errors.New("invalid username or password"),
+	}, c)
+	hooksService.RunLoginHook(&models.LoginInfo{
+		AuthModule:   "oauth_github",
+		User:         &models.User{Id: 3, OrgId: 1, Login: "octocat"},
+		ExternalUser: models.ExternalUserInfo{Login: "octocat"},
+		HTTPStatus:   200,
+	}, c)
+
+	result, err := s.Search(ctx, &audit.SearchQuery{Action: audit.ActionLogin})
+	require.NoError(t, err)
+	require.Len(t, result.Entries, 2)
+
+	succeeded, failed := result.Entries[0], result.Entries[1]
+	if succeeded.Result != audit.ResultSuccess {
+		succeeded, failed = failed, succeeded
+	}
+
+	require.Equal(t, audit.ResultFailure, failed.Result)
+	require.Equal(t, "admin", failed.UserLogin)
+	require.Zero(t, failed.UserId)
+	require.Equal(t, "invalid username or password", failed.Details["error"])
+	require.Equal(t, "ldap", failed.Details["authModule"])
+
+	require.Equal(t, audit.ResultSuccess, succeeded.Result)
+	require.Equal(t, int64(3), succeeded.UserId)
+	require.Equal(t, "3", succeeded.ResourceId)
+	require.Equal(t, "octocat", succeeded.UserLogin)
+}
+
+func TestAuditService_DeleteExpired(t *testing.T) {
+	t.Cleanup(func() { now = time.Now })
+	current := time.Now()
+	now = func() time.Time { return current }
+
+	s, _ := setupTestService(t, setting.AuditSettings{Enabled: true, Retention: 24 * time.Hour})
+	ctx := context.Background()
+
+	c := testReqContext(&models.SignedInUser{OrgId: 1, UserId: 1, Login: "admin"})
+	s.Record(ctx, audit.NewEntry(c, audit.ActionUpdate, audit.ResourceOrg, "1"))
+
+	current = current.Add(12 * time.Hour)
+	s.Record(ctx, audit.NewEntry(c, audit.ActionUpdate, audit.ResourcePreferences, "1"))
+
+	deleted, err := s.DeleteExpired(ctx)
+	require.NoError(t, err)
+	require.Zero(t, deleted)
+
+	current = current.Add(13 * time.Hour)
+	deleted, err = s.DeleteExpired(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	result, err := s.Search(ctx, &audit.SearchQuery{})
+	require.NoError(t, err)
+	require.Len(t, result.Entries, 1)
+	require.Equal(t, audit.ResourcePreferences, result.Entries[0].ResourceType)
+}
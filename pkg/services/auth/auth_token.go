@@ -85,9 +85,11 @@ func (s *UserAuthTokenService) CreateToken(ctx context.Context, user *models.Use
 		AuthTokenSeen: false,
 	}
 
-	err = s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
-		_, err = dbSession.Insert(&userAuthToken)
-		return err
+	err = s.SQLStore.WithTransactionalDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		if _, err := dbSession.Insert(&userAuthToken); err != nil {
+			return err
+		}
+		return s.evictSessions(dbSession, user.Id, now)
 	})
 
 	if err != nil {
@@ -104,6 +106,38 @@ func (s *UserAuthTokenService) CreateToken(ctx context.Context, user *models.Use
 	return &userToken, err
 }
 
+// evictSessions revokes the oldest active tokens of the user beyond the
+// maximum number of concurrent sessions.
+func (s *UserAuthTokenService) evictSessions(dbSession *sqlstore.DBSession, userId int64, now int64) error {
+	if s.Cfg.LoginMaxConcurrentSessions <= 0 {
+		return nil
+	}
+
+	var tokens []*userAuthToken
+	err := dbSession.Where("user_id = ? AND created_at > ? AND rotated_at > ? AND revoked_at = 0",
+		userId,
+		s.createdAfterParam(),
+		s.rotatedAfterParam()).
+		Desc("created_at", "id").
+		Find(&tokens)
+	if err != nil {
+		return err
+	}
+
+	if len(tokens) <= s.Cfg.LoginMaxConcurrentSessions {
+		return nil
+	}
+
+	for _, token := range tokens[s.Cfg.LoginMaxConcurrentSessions:] {
+		if _, err := dbSession.Exec("UPDATE user_auth_token SET revoked_at = ? WHERE id = ?", now, token.Id); err != nil {
+			return err
+		}
+		s.log.Debug("user auth token revoked, maximum number of concurrent sessions reached", "tokenId", token.Id, "userId", userId, "clientIP", token.ClientIp, "userAgent", token.UserAgent)
+	}
+
+	return nil
+}
+
 func (s *UserAuthTokenService) LookupToken(ctx context.Context, unhashedToken string) (*models.UserToken, error) {
 	hashedToken := hashToken(unhashedToken)
 	var model userAuthToken
@@ -162,6 +162,33 @@ func TestUserAuthToken(t *testing.T) {
 		})
 	})
 
+	t.Run("When the maximum number of concurrent sessions is reached", func(t *testing.T) {
+		ctx := createTestContext(t)
+		ctx.tokenService.Cfg.LoginMaxConcurrentSessions = 2
+		user := &models.User{Id: int64(10)}
+
+		tokens := make([]*models.UserToken, 3)
+		for i := range tokens {
+			getTime = func() time.Time { return now.Add(time.Duration(i) * time.Minute) }
+			token, err := ctx.tokenService.CreateToken(context.Background(), user, net.ParseIP("192.168.10.11"), "some user agent")
+			require.Nil(t, err)
+			tokens[i] = token
+		}
+		getTime = func() time.Time { return now.Add(3 * time.Minute) }
+		defer func() { getTime = func() time.Time { return now } }()
+
+		t.Run("the oldest session is revoked", func(t *testing.T) {
+			_, err := ctx.tokenService.LookupToken(context.Background(), tokens[0].UnhashedToken)
+			var revokedErr *models.TokenRevokedError
+			require.ErrorAs(t, err, &revokedErr)
+
+			active, err := ctx.tokenService.GetUserTokens(context.Background(), user.Id)
+			require.Nil(t, err)
+			require.Len(t, active, 2)
+			require.ElementsMatch(t, []int64{tokens[1].Id, tokens[2].Id}, []int64{active[0].Id, active[1].Id})
+		})
+	})
+
 	t.Run("expires correctly", func(t *testing.T) {
 		ctx := createTestContext(t)
 		userToken, err := ctx.tokenService.CreateToken(context.Background(), user,
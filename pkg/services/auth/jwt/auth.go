@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/remotecache"
@@ -24,11 +25,34 @@ func ProvideService(cfg *setting.Cfg, remoteCache *remotecache.RemoteCache) (*Au
 	return s, nil
 }
 
+// NewVerifier returns a service verifying JSON Web Tokens with the keys and
+// the claim expectations of the given settings, rather than the ones of the
+// [auth.jwt] section.
+func NewVerifier(settings Settings, remoteCache *remotecache.RemoteCache, logger log.Logger) (*AuthService, error) {
+	s := &AuthService{
+		RemoteCache: remoteCache,
+		settings:    settings,
+		log:         logger,
+	}
+	if err := s.initVerification(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
 func newService(cfg *setting.Cfg, remoteCache *remotecache.RemoteCache) *AuthService {
 	return &AuthService{
 		Cfg:         cfg,
 		RemoteCache: remoteCache,
-		log:         log.New("auth.jwt"),
+		settings: Settings{
+			KeyFile:      cfg.JWTAuthKeyFile,
+			JWKSetFile:   cfg.JWTAuthJWKSetFile,
+			JWKSetURL:    cfg.JWTAuthJWKSetURL,
+			CacheTTL:     cfg.JWTAuthCacheTTL,
+			ExpectClaims: cfg.JWTAuthExpectClaims,
+		},
+		log: log.New("auth.jwt"),
 	}
 }
 
@@ -37,6 +61,10 @@ func (s *AuthService) init() error {
 		return nil
 	}
 
+	return s.initVerification()
+}
+
+func (s *AuthService) initVerification() error {
 	if err := s.initClaimExpectations(); err != nil {
 		return err
 	}
@@ -47,10 +75,22 @@ func (s *AuthService) init() error {
 	return nil
 }
 
+// Settings are the keys verifying the signature of tokens, which are read
+// from one of KeyFile, JWKSetFile or JWKSetURL, and the claims tokens are
+// expected to have.
+type Settings struct {
+	KeyFile      string
+	JWKSetFile   string
+	JWKSetURL    string
+	CacheTTL     time.Duration
+	ExpectClaims string
+}
+
 type AuthService struct {
 	Cfg         *setting.Cfg
 	RemoteCache *remotecache.RemoteCache
 
+	settings         Settings
 	keySet           keySet
 	log              log.Logger
 	expect           map[string]interface{}
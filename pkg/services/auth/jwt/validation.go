@@ -11,7 +11,7 @@ import (
 )
 
 func (s *AuthService) initClaimExpectations() error {
-	if err := json.Unmarshal([]byte(s.Cfg.JWTAuthExpectClaims), &s.expect); err != nil {
+	if err := json.Unmarshal([]byte(s.settings.ExpectClaims), &s.expect); err != nil {
 		return err
 	}
 
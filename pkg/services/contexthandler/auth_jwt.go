@@ -2,6 +2,8 @@ package contexthandler
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/login"
@@ -10,6 +12,123 @@ import (
 
 const InvalidJWT = "Invalid JWT"
 const UserNotFound = "User not found"
+const ServiceAccountNotFound = "Service account not found"
+
+// initContextWithJWTServiceAccount authenticates machine clients sending a JWT
+// issued by the configured identity provider as a bearer token. The value of
+// the service account claim, usually the client ID, must have been linked to
+// a service account.
+func (h *ContextHandler) initContextWithJWTServiceAccount(ctx *models.ReqContext) bool {
+	if !h.Cfg.JWTAuthEnabled || !h.Cfg.JWTAuthServiceAccountsEnabled {
+		return false
+	}
+
+	parts := strings.SplitN(ctx.Req.Header.Get("Authorization"), " ", 2)
+	// API keys are base64 encoded JSON documents, which never contain dots
+	if len(parts) != 2 || parts[0] != "Bearer" || strings.Count(parts[1], ".") != 2 {
+		return false
+	}
+
+	claims, err := h.JWTAuthService.Verify(ctx.Req.Context(), parts[1])
+	if err != nil {
+		ctx.Logger.Debug("Failed to verify service account JWT", "error", err)
+		ctx.JsonApiErr(401, InvalidJWT, err)
+		return true
+	}
+
+	subject, _ := claims[h.Cfg.JWTAuthServiceAccountClaim].(string)
+	if subject == "" {
+		ctx.Logger.Debug("Got a service account JWT without the configured claim", "claim", h.Cfg.JWTAuthServiceAccountClaim)
+		ctx.JsonApiErr(401, InvalidJWT, nil)
+		return true
+	}
+
+	if err := validateServiceAccountClaims(claims, h.Cfg.JWTAuthServiceAccountAudience, h.Cfg.JWTAuthServiceAccountScopes); err != nil {
+		ctx.Logger.Debug("Service account JWT rejected", "subject", subject, "error", err)
+		ctx.JsonApiErr(401, InvalidJWT, err)
+		return true
+	}
+
+	authQuery := &models.GetAuthInfoQuery{AuthModule: models.AuthModuleJWTServiceAccount, AuthId: subject}
+	if err := bus.Dispatch(ctx.Req.Context(), authQuery); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			ctx.Logger.Debug("No service account linked to JWT identity", "subject", subject)
+			ctx.JsonApiErr(401, ServiceAccountNotFound, login.ErrInvalidCredentials)
+		} else {
+			ctx.Logger.Error("Failed to get service account JWT identity", "error", err)
+			ctx.JsonApiErr(401, InvalidJWT, err)
+		}
+		return true
+	}
+
+	query := models.GetSignedInUserQuery{UserId: authQuery.Result.UserId}
+	if err := bus.Dispatch(ctx.Req.Context(), &query); err != nil {
+		ctx.Logger.Error("Failed to get service account linked to JWT identity", "subject", subject, "error", err)
+		ctx.JsonApiErr(401, ServiceAccountNotFound, err)
+		return true
+	}
+
+	ctx.SignedInUser = query.Result
+	ctx.IsSignedIn = true
+
+	return true
+}
+
+// validateServiceAccountClaims checks that a service account JWT was issued
+// for one of the given audiences, and grants all of the given scopes.
+func validateServiceAccountClaims(claims models.JWTClaims, audience, scopes []string) error {
+	if len(audience) > 0 {
+		found := false
+		for _, aud := range claimValues(claims["aud"]) {
+			if containsString(audience, aud) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("token audience must be one of %v", audience)
+		}
+	}
+
+	if len(scopes) > 0 {
+		// OAuth 2.0 access tokens carry a space separated scope claim, some
+		// identity providers use an scp claim instead
+		granted := claimValues(claims["scope"])
+		granted = append(granted, claimValues(claims["scp"])...)
+		for _, scope := range scopes {
+			if !containsString(granted, scope) {
+				return fmt.Errorf("token is missing the %q scope", scope)
+			}
+		}
+	}
+
+	return nil
+}
+
+func claimValues(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
 
 func (h *ContextHandler) initContextWithJWT(ctx *models.ReqContext, orgId int64) bool {
 	if !h.Cfg.JWTAuthEnabled || h.Cfg.JWTAuthHeaderName == "" {
@@ -54,6 +54,7 @@ type AuthProxy struct {
 	ctx         *models.ReqContext
 	orgID       int64
 	header      string
+	verifier    *HeaderVerifier
 }
 
 // Error auth proxy specific error
@@ -77,9 +78,10 @@ func (err Error) Error() string {
 
 // Options for the AuthProxy
 type Options struct {
-	RemoteCache *remotecache.RemoteCache
-	Ctx         *models.ReqContext
-	OrgID       int64
+	RemoteCache    *remotecache.RemoteCache
+	Ctx            *models.ReqContext
+	OrgID          int64
+	HeaderVerifier *HeaderVerifier
 }
 
 // New instance of the AuthProxy.
@@ -91,6 +93,7 @@ func New(cfg *setting.Cfg, options *Options) *AuthProxy {
 		ctx:         options.Ctx,
 		orgID:       options.OrgID,
 		header:      header,
+		verifier:    options.HeaderVerifier,
 	}
 }
 
@@ -0,0 +1,220 @@
+package authproxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth/jwt"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// now is used to test the max age of signatures
+var now = time.Now
+
+// HeaderVerifier holds the keys verifying the JWTs issued by the
+// authentication proxy, when the header verification is jwt.
+type HeaderVerifier struct {
+	jwt models.JWTService
+}
+
+func ProvideHeaderVerifier(cfg *setting.Cfg, remoteCache *remotecache.RemoteCache) (*HeaderVerifier, error) {
+	v := &HeaderVerifier{}
+	if !cfg.AuthProxyEnabled || cfg.AuthProxyHeaderVerification != "jwt" {
+		return v, nil
+	}
+
+	verifier, err := jwt.NewVerifier(jwt.Settings{
+		KeyFile:      cfg.AuthProxyJWTKeyFile,
+		JWKSetFile:   cfg.AuthProxyJWTJWKSetFile,
+		JWKSetURL:    cfg.AuthProxyJWTJWKSetURL,
+		CacheTTL:     cfg.AuthProxyJWTCacheTTL,
+		ExpectClaims: cfg.AuthProxyJWTExpectClaims,
+	}, remoteCache, log.New("auth.proxy.jwt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize the verification of auth proxy JWTs: %w", err)
+	}
+	v.jwt = verifier
+
+	return v, nil
+}
+
+// VerifyHeaders checks that the identity headers were set by the
+// authentication proxy, either with their signature or with a JWT issued by
+// the proxy, so that clients reaching Grafana directly can't spoof them.
+func (auth *AuthProxy) VerifyHeaders() error {
+	switch auth.cfg.AuthProxyHeaderVerification {
+	case "signature":
+		return auth.verifySignature()
+	case "jwt":
+		return auth.verifyJWT()
+	}
+
+	return nil
+}
+
+// verifySignature checks the signature header, "t=<unix time>,v1=<signature>".
+// The signature is the hex encoded HMAC-SHA256 of the signed payload with one
+// of the secrets. Several v1 signatures can be sent, which lets the proxy sign
+// with both the old and the new secret while they are rotated.
+func (auth *AuthProxy) verifySignature() error {
+	value := auth.ctx.Req.Header.Get(auth.cfg.AuthProxySignatureHeader)
+	if value == "" {
+		return newError("proxy authentication required", fmt.Errorf(
+			"request for user (%s) has no %s header", auth.header, auth.cfg.AuthProxySignatureHeader,
+		))
+	}
+
+	var timestamp string
+	var signatures [][]byte
+	for _, part := range strings.Split(value, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			if signature, err := hex.DecodeString(kv[1]); err == nil {
+				signatures = append(signatures, signature)
+			}
+		}
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return newError("proxy authentication required", errors.New("invalid signature header"))
+	}
+
+	age := now().Sub(time.Unix(signedAt, 0))
+	if age > auth.cfg.AuthProxySignatureMaxAge || age < -auth.cfg.AuthProxySignatureMaxAge {
+		return newError("proxy authentication required", fmt.Errorf(
+			"signature of the request for user (%s) has expired", auth.header,
+		))
+	}
+
+	payload := []byte(auth.signedPayload(timestamp))
+	for _, secret := range auth.cfg.AuthProxySignatureSecrets {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(payload)
+		expected := mac.Sum(nil)
+
+		for _, signature := range signatures {
+			if hmac.Equal(signature, expected) {
+				return nil
+			}
+		}
+	}
+
+	return newError("proxy authentication required", fmt.Errorf(
+		"signature of the request for user (%s) is invalid", auth.header,
+	))
+}
+
+// signedPayload returns what the proxy signs: the timestamp of the signature,
+// then a "<header name in lower case>:<value>" line for the main header and
+// each configured additional header, in the order Name, Email, Login, Groups
+// and Role. Headers missing from the request are signed with an empty value.
+func (auth *AuthProxy) signedPayload(timestamp string) string {
+	names := []string{auth.cfg.AuthProxyHeaderName}
+	for _, field := range supportedHeaderFields {
+		if h := auth.cfg.AuthProxyHeaders[field]; h != "" {
+			names = append(names, h)
+		}
+	}
+
+	var payload strings.Builder
+	payload.WriteString(timestamp + "\n")
+	for _, name := range names {
+		payload.WriteString(strings.ToLower(name) + ":" + auth.ctx.Req.Header.Get(name) + "\n")
+	}
+
+	return payload.String()
+}
+
+// verifyJWT checks the JWT issued by the proxy. Its identity claim must match
+// the main header, and each additional header must match the claim named
+// after its field in lower case, for example "email" for the Email header.
+func (auth *AuthProxy) verifyJWT() error {
+	if auth.verifier == nil || auth.verifier.jwt == nil {
+		return newError("proxy authentication required", errors.New("JWT verification is not initialized"))
+	}
+
+	token := auth.ctx.Req.Header.Get(auth.cfg.AuthProxyJWTHeader)
+	if token == "" {
+		return newError("proxy authentication required", fmt.Errorf(
+			"request for user (%s) has no %s header", auth.header, auth.cfg.AuthProxyJWTHeader,
+		))
+	}
+
+	claims, err := auth.verifier.jwt.Verify(auth.ctx.Req.Context(), token)
+	if err != nil {
+		return newError("proxy authentication required", fmt.Errorf("failed to verify JWT: %w", err))
+	}
+
+	identity, _ := claims[auth.cfg.AuthProxyJWTIdentityClaim].(string)
+	if identity == "" || identity != auth.header {
+		return newError("proxy authentication required", fmt.Errorf(
+			"JWT %q claim doesn't match the user (%s)", auth.cfg.AuthProxyJWTIdentityClaim, auth.header,
+		))
+	}
+
+	auth.headersIterator(func(field string, header string) {
+		if err == nil && !claimMatches(claims[strings.ToLower(field)], field, header) {
+			err = fmt.Errorf("JWT %q claim doesn't match the %s header", strings.ToLower(field), field)
+		}
+	})
+	if err != nil {
+		return newError("proxy authentication required", err)
+	}
+
+	return nil
+}
+
+func claimMatches(claim interface{}, field string, header string) bool {
+	if field != "Groups" {
+		value, ok := claim.(string)
+		return ok && value == header
+	}
+
+	var groups []string
+	switch claim := claim.(type) {
+	case string:
+		groups = util.SplitString(claim)
+	case []interface{}:
+		for _, group := range claim {
+			value, ok := group.(string)
+			if !ok {
+				return false
+			}
+			groups = append(groups, value)
+		}
+	default:
+		return false
+	}
+
+	headerGroups := util.SplitString(header)
+	if len(groups) != len(headerGroups) {
+		return false
+	}
+	sort.Strings(groups)
+	sort.Strings(headerGroups)
+	for i := range groups {
+		if groups[i] != headerGroups[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,152 @@
+package authproxy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func sign(secret string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyHeaders_signature(t *testing.T) {
+	signedAt := time.Date(2021, 11, 2, 13, 0, 0, 0, time.UTC)
+	now = func() time.Time { return signedAt.Add(time.Minute) }
+	t.Cleanup(func() { now = time.Now })
+
+	timestamp := fmt.Sprint(signedAt.Unix())
+	payload := timestamp + "\nx-killa:" + hdrName + "\nx-webauth-email:markelog@example.org\nx-webauth-groups:\n"
+
+	prepare := func(t *testing.T, signature string, cb func(*http.Request)) *AuthProxy {
+		return prepareMiddleware(t, nil, func(req *http.Request, cfg *setting.Cfg) {
+			cfg.AuthProxyHeaderVerification = "signature"
+			cfg.AuthProxySignatureHeader = "X-WEBAUTH-SIGNATURE"
+			cfg.AuthProxySignatureSecrets = []string{"new-secret", "old-secret"}
+			cfg.AuthProxySignatureMaxAge = 5 * time.Minute
+			cfg.AuthProxyHeaders = map[string]string{"Email": "X-WEBAUTH-EMAIL", "Groups": "X-WEBAUTH-GROUPS"}
+
+			req.Header.Set("X-WEBAUTH-EMAIL", "markelog@example.org")
+			if signature != "" {
+				req.Header.Set("X-WEBAUTH-SIGNATURE", signature)
+			}
+			if cb != nil {
+				cb(req)
+			}
+		})
+	}
+
+	t.Run("accepts a valid signature", func(t *testing.T) {
+		auth := prepare(t, "t="+timestamp+",v1="+sign("new-secret", payload), nil)
+		require.NoError(t, auth.VerifyHeaders())
+	})
+
+	t.Run("accepts a signature with any of the secrets", func(t *testing.T) {
+		auth := prepare(t, "t="+timestamp+",v1="+sign("unknown", payload)+",v1="+sign("old-secret", payload), nil)
+		require.NoError(t, auth.VerifyHeaders())
+	})
+
+	t.Run("rejects a request without signature", func(t *testing.T) {
+		auth := prepare(t, "", nil)
+		err := auth.VerifyHeaders()
+		require.Error(t, err)
+		assert.Equal(t, "proxy authentication required", err.Error())
+	})
+
+	t.Run("rejects a signature with an unknown secret", func(t *testing.T) {
+		auth := prepare(t, "t="+timestamp+",v1="+sign("unknown", payload), nil)
+		require.Error(t, auth.VerifyHeaders())
+	})
+
+	t.Run("rejects an expired signature", func(t *testing.T) {
+		old := fmt.Sprint(signedAt.Add(-10 * time.Minute).Unix())
+		oldPayload := old + "\nx-killa:" + hdrName + "\nx-webauth-email:markelog@example.org\nx-webauth-groups:\n"
+		auth := prepare(t, "t="+old+",v1="+sign("new-secret", oldPayload), nil)
+		require.Error(t, auth.VerifyHeaders())
+	})
+
+	t.Run("rejects a tampered header", func(t *testing.T) {
+		auth := prepare(t, "t="+timestamp+",v1="+sign("new-secret", payload), func(req *http.Request) {
+			req.Header.Set("X-WEBAUTH-GROUPS", "admins")
+		})
+		require.Error(t, auth.VerifyHeaders())
+	})
+}
+
+func TestVerifyHeaders_jwt(t *testing.T) {
+	prepare := func(t *testing.T, claims models.JWTClaims, cb func(*http.Request)) *AuthProxy {
+		auth := prepareMiddleware(t, nil, func(req *http.Request, cfg *setting.Cfg) {
+			cfg.AuthProxyHeaderVerification = "jwt"
+			cfg.AuthProxyJWTHeader = "X-WEBAUTH-JWT"
+			cfg.AuthProxyJWTIdentityClaim = "sub"
+			cfg.AuthProxyHeaders = map[string]string{"Email": "X-WEBAUTH-EMAIL", "Groups": "X-WEBAUTH-GROUPS"}
+
+			req.Header.Set("X-WEBAUTH-JWT", "token")
+			if cb != nil {
+				cb(req)
+			}
+		})
+
+		jwtService := models.NewFakeJWTService()
+		jwtService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			if token != "token" {
+				return nil, errors.New("invalid token")
+			}
+			return claims, nil
+		}
+		auth.verifier = &HeaderVerifier{jwt: jwtService}
+
+		return auth
+	}
+
+	t.Run("accepts headers vouched for by the JWT", func(t *testing.T) {
+		auth := prepare(t, models.JWTClaims{
+			"sub":    hdrName,
+			"email":  "markelog@example.org",
+			"groups": []interface{}{"editors", "admins"},
+		}, func(req *http.Request) {
+			req.Header.Set("X-WEBAUTH-EMAIL", "markelog@example.org")
+			req.Header.Set("X-WEBAUTH-GROUPS", "admins, editors")
+		})
+		require.NoError(t, auth.VerifyHeaders())
+	})
+
+	t.Run("rejects a request without JWT", func(t *testing.T) {
+		auth := prepare(t, models.JWTClaims{"sub": hdrName}, func(req *http.Request) {
+			req.Header.Del("X-WEBAUTH-JWT")
+		})
+		require.Error(t, auth.VerifyHeaders())
+	})
+
+	t.Run("rejects a JWT issued for another user", func(t *testing.T) {
+		auth := prepare(t, models.JWTClaims{"sub": "torkel"}, nil)
+		require.Error(t, auth.VerifyHeaders())
+	})
+
+	t.Run("rejects a header the JWT doesn't vouch for", func(t *testing.T) {
+		auth := prepare(t, models.JWTClaims{"sub": hdrName, "groups": "editors"}, func(req *http.Request) {
+			req.Header.Set("X-WEBAUTH-GROUPS", "editors,admins")
+		})
+		require.Error(t, auth.VerifyHeaders())
+	})
+
+	t.Run("rejects requests when the verification is not initialized", func(t *testing.T) {
+		auth := prepare(t, models.JWTClaims{"sub": hdrName}, nil)
+		auth.verifier = &HeaderVerifier{}
+		require.Error(t, auth.VerifyHeaders())
+	})
+}
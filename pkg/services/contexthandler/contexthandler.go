@@ -18,9 +18,11 @@ import (
 	"github.com/grafana/grafana/pkg/middleware/cookies"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/contexthandler/authproxy"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/totp"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
@@ -36,15 +38,17 @@ const ServiceName = "ContextHandler"
 
 func ProvideService(cfg *setting.Cfg, tokenService models.UserTokenService, jwtService models.JWTService,
 	remoteCache *remotecache.RemoteCache, renderService rendering.Service, sqlStore *sqlstore.SQLStore,
-	tracer tracing.Tracer) *ContextHandler {
+	tracer tracing.Tracer, totpService totp.Service, authProxyVerifier *authproxy.HeaderVerifier) *ContextHandler {
 	return &ContextHandler{
-		Cfg:              cfg,
-		AuthTokenService: tokenService,
-		JWTAuthService:   jwtService,
-		RemoteCache:      remoteCache,
-		RenderService:    renderService,
-		SQLStore:         sqlStore,
-		tracer:           tracer,
+		Cfg:               cfg,
+		AuthTokenService:  tokenService,
+		JWTAuthService:    jwtService,
+		RemoteCache:       remoteCache,
+		RenderService:     renderService,
+		SQLStore:          sqlStore,
+		TOTPService:       totpService,
+		AuthProxyVerifier: authProxyVerifier,
+		tracer:            tracer,
 	}
 }
 
@@ -56,7 +60,10 @@ type ContextHandler struct {
 	RemoteCache      *remotecache.RemoteCache
 	RenderService    rendering.Service
 	SQLStore         sqlstore.Store
-	tracer           tracing.Tracer
+	TOTPService      totp.Service
+	// AuthProxyVerifier verifies the JWTs issued by the auth proxy
+	AuthProxyVerifier *authproxy.HeaderVerifier
+	tracer            tracing.Tracer
 	// GetTime returns the current time.
 	// Stubbable by tests.
 	GetTime func() time.Time
@@ -127,6 +134,7 @@ func (h *ContextHandler) Middleware(mContext *web.Context) {
 	// then test if anonymous access is enabled
 	switch {
 	case h.initContextWithRenderAuth(reqContext):
+	case h.initContextWithJWTServiceAccount(reqContext):
 	case h.initContextWithAPIKey(reqContext):
 	case h.initContextWithBasicAuth(reqContext, orgID):
 	case h.initContextWithAuthProxy(reqContext, orgID):
@@ -235,6 +243,15 @@ func (h *ContextHandler) initContextWithAPIKey(reqContext *models.ReqContext) bo
 		return true
 	}
 
+	// Restricted tokens would be granted all the permissions of their
+	// service account when the permissions aren't evaluated
+	if apikey.IsRestricted() && (h.Cfg.IsFeatureToggleEnabled == nil || !h.Cfg.IsFeatureToggleEnabled(featuremgmt.FlagAccesscontrol)) {
+		reqContext.JsonApiErr(401, "Restricted API keys require access control to be enabled", nil)
+		return true
+	}
+
+	h.updateAPIKeyLastUsed(reqContext, apikey, getTime())
+
 	if apikey.ServiceAccountId == nil || *apikey.ServiceAccountId < 1 { //There is no service account attached to the apikey
 		//Use the old APIkey method.  This provides backwards compatibility.
 		reqContext.SignedInUser = &models.SignedInUser{}
@@ -262,9 +279,30 @@ func (h *ContextHandler) initContextWithAPIKey(reqContext *models.ReqContext) bo
 
 	reqContext.IsSignedIn = true
 	reqContext.SignedInUser = query.Result
+	if len(apikey.Permissions) > 0 {
+		// Only access control applies the token permissions
+		reqContext.SignedInUser.TokenPermissions = apikey.Permissions
+		reqContext.SignedInUser.TokenOrgRole = reqContext.SignedInUser.OrgRole
+		reqContext.SignedInUser.OrgRole = ""
+		reqContext.SignedInUser.IsGrafanaAdmin = false
+	}
 	return true
 }
 
+// updateAPIKeyLastUsed records the use of an API key, at most once a minute
+// unless it's used from another IP.
+func (h *ContextHandler) updateAPIKeyLastUsed(reqContext *models.ReqContext, apikey *models.ApiKey, now time.Time) {
+	ip := reqContext.RemoteAddr()
+	if apikey.LastUsedAt != nil && now.Sub(*apikey.LastUsedAt) < time.Minute && apikey.LastUsedIP == ip {
+		return
+	}
+
+	cmd := models.UpdateApiKeyLastUsedCommand{Id: apikey.Id, IP: ip, UsedAt: now}
+	if err := h.SQLStore.UpdateAPIKeyLastUsed(reqContext.Req.Context(), &cmd); err != nil {
+		reqContext.Logger.Warn("Failed to update API key last used", "id", apikey.Id, "error", err)
+	}
+}
+
 func (h *ContextHandler) initContextWithBasicAuth(reqContext *models.ReqContext, orgID int64) bool {
 	if !h.Cfg.BasicAuthEnabled {
 		return false
@@ -305,6 +343,30 @@ func (h *ContextHandler) initContextWithBasicAuth(reqContext *models.ReqContext,
 
 	user := authQuery.User
 
+	// Basic auth would bypass the second factor of the built-in login
+	if h.Cfg.TOTPEnabled {
+		enabled, err := h.TOTPService.IsEnabled(ctx, user.Id)
+		if err != nil {
+			reqContext.JsonApiErr(500, "Failed to authorize the user", err)
+			return true
+		}
+		if enabled {
+			reqContext.JsonApiErr(401, "Basic authentication is not allowed for users with two-factor authentication", nil)
+			return true
+		}
+
+		// Users who haven't enrolled yet, although the policy requires it, must enrol from the login page
+		required, err := h.TOTPService.IsRequired(ctx, user)
+		if err != nil {
+			reqContext.JsonApiErr(500, "Failed to authorize the user", err)
+			return true
+		}
+		if required {
+			reqContext.JsonApiErr(401, "Basic authentication is not allowed for users required to use two-factor authentication", nil)
+			return true
+		}
+	}
+
 	query := models.GetSignedInUserQuery{UserId: user.Id, OrgId: orgID}
 	if err := bus.Dispatch(ctx, &query); err != nil {
 		reqContext.Logger.Error(
@@ -452,9 +514,10 @@ func (h *ContextHandler) handleError(ctx *models.ReqContext, err error, statusCo
 func (h *ContextHandler) initContextWithAuthProxy(reqContext *models.ReqContext, orgID int64) bool {
 	username := reqContext.Req.Header.Get(h.Cfg.AuthProxyHeaderName)
 	auth := authproxy.New(h.Cfg, &authproxy.Options{
-		RemoteCache: h.RemoteCache,
-		Ctx:         reqContext,
-		OrgID:       orgID,
+		RemoteCache:    h.RemoteCache,
+		Ctx:            reqContext,
+		OrgID:          orgID,
+		HeaderVerifier: h.AuthProxyVerifier,
 	})
 
 	logger := log.New("auth.proxy")
@@ -480,6 +543,14 @@ func (h *ContextHandler) initContextWithAuthProxy(reqContext *models.ReqContext,
 		return true
 	}
 
+	// Check the identity headers were set by the proxy
+	if err := auth.VerifyHeaders(); err != nil {
+		h.handleError(reqContext, err, 407, func(details error) {
+			logger.Error("Failed to verify auth proxy headers", "message", err.Error(), "error", details)
+		})
+		return true
+	}
+
 	id, err := logUserIn(auth, username, logger, false)
 	if err != nil {
 		h.handleError(reqContext, err, 407, nil)
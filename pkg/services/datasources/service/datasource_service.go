@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/secrets"
@@ -22,6 +24,8 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb/azuremonitor/azcredentials"
 )
 
+var logger = log.New("datasources")
+
 type Service struct {
 	Bus            bus.Bus
 	SQLStore       *sqlstore.SQLStore
@@ -37,8 +41,9 @@ type proxyTransportCache struct {
 }
 
 type cachedRoundTripper struct {
-	updated      time.Time
-	roundTripper http.RoundTripper
+	updated        time.Time
+	secureJSONData map[string]string
+	roundTripper   http.RoundTripper
 }
 
 type secureJSONDecryptionCache struct {
@@ -48,9 +53,14 @@ type secureJSONDecryptionCache struct {
 
 type cachedDecryptedJSON struct {
 	updated time.Time
+	expires time.Time
 	json    map[string]string
 }
 
+// decryptionCacheTTL is how long decrypted secure JSON data is cached, so
+// that references to external secrets are resolved again regularly.
+const decryptionCacheTTL = time.Minute
+
 func ProvideService(bus bus.Bus, store *sqlstore.SQLStore, secretsService secrets.Service, ac accesscontrol.AccessControl) *Service {
 	s := &Service{
 		Bus:            bus,
@@ -166,7 +176,10 @@ func (s *Service) GetHTTPTransport(ds *models.DataSource, provider httpclient.Pr
 	s.ptc.Lock()
 	defer s.ptc.Unlock()
 
-	if t, present := s.ptc.cache[ds.Id]; present && ds.Updated.Equal(t.updated) {
+	// Round trippers are created again when secure JSON data resolves to
+	// other values, like after rotating a referenced external secret.
+	secureJSONData := s.DecryptedValues(ds)
+	if t, present := s.ptc.cache[ds.Id]; present && ds.Updated.Equal(t.updated) && reflect.DeepEqual(secureJSONData, t.secureJSONData) {
 		return t.roundTripper, nil
 	}
 
@@ -183,8 +196,9 @@ func (s *Service) GetHTTPTransport(ds *models.DataSource, provider httpclient.Pr
 	}
 
 	s.ptc.cache[ds.Id] = cachedRoundTripper{
-		roundTripper: rt,
-		updated:      ds.Updated,
+		roundTripper:   rt,
+		secureJSONData: secureJSONData,
+		updated:        ds.Updated,
 	}
 
 	return rt, nil
@@ -202,17 +216,19 @@ func (s *Service) DecryptedValues(ds *models.DataSource) map[string]string {
 	s.dsDecryptionCache.Lock()
 	defer s.dsDecryptionCache.Unlock()
 
-	if item, present := s.dsDecryptionCache.cache[ds.Id]; present && ds.Updated.Equal(item.updated) {
+	if item, present := s.dsDecryptionCache.cache[ds.Id]; present && ds.Updated.Equal(item.updated) && time.Now().Before(item.expires) {
 		return item.json
 	}
 
 	json, err := s.SecretsService.DecryptJsonData(context.Background(), ds.SecureJsonData)
 	if err != nil {
+		logger.Error("Failed to decrypt secure json data", "datasource", ds.Name, "error", err)
 		return map[string]string{}
 	}
 
 	s.dsDecryptionCache.cache[ds.Id] = cachedDecryptedJSON{
 		updated: ds.Updated,
+		expires: time.Now().Add(decryptionCacheTTL),
 		json:    json,
 	}
 
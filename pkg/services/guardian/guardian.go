@@ -104,6 +104,11 @@ func (g *dashboardGuardianImpl) logHasPermissionResult(permission models.Permiss
 }
 
 func (g *dashboardGuardianImpl) checkAcl(permission models.PermissionType, acl []*models.DashboardAclInfoDTO) (bool, error) {
+	// the permissions of restricted service account tokens are only applied by access control
+	if len(g.user.TokenPermissions) > 0 {
+		return false, nil
+	}
+
 	orgRole := g.user.OrgRole
 	teamAclItems := []*models.DashboardAclInfoDTO{}
 
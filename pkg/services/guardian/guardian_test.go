@@ -728,6 +728,34 @@ func TestGuardianGetHiddenACL(t *testing.T) {
 	})
 }
 
+func TestGuardianRestrictedToken(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	bus.AddHandler("test", func(ctx context.Context, query *models.GetDashboardAclInfoListQuery) error {
+		query.Result = []*models.DashboardAclInfoDTO{
+			{OrgId: orgID, DashboardId: dashboardID, UserId: userID, Permission: models.PERMISSION_ADMIN},
+			{OrgId: orgID, DashboardId: dashboardID, Role: &editorRole, Permission: models.PERMISSION_EDIT},
+		}
+		return nil
+	})
+
+	user := &models.SignedInUser{
+		OrgId:            orgID,
+		UserId:           userID,
+		TokenOrgRole:     models.ROLE_EDITOR,
+		TokenPermissions: []models.ApiKeyPermission{{Action: "datasources:query", Scope: "datasources:*"}},
+	}
+	g := New(context.Background(), dashboardID, orgID, user)
+
+	canSave, err := g.CanSave()
+	require.NoError(t, err)
+	require.False(t, canSave)
+
+	canView, err := g.CanView()
+	require.NoError(t, err)
+	require.False(t, canView)
+}
+
 func TestGuardianGetAclWithoutDuplicates(t *testing.T) {
 	t.Run("Get hidden ACL tests", func(t *testing.T) {
 		t.Cleanup(bus.ClearBusHandlers)
@@ -0,0 +1,78 @@
+package awskmsprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// awsKMSProvider encrypts data keys with a key of AWS KMS, or of any
+// service implementing the AWS KMS API when endpoint is set.
+type awsKMSProvider struct {
+	client kmsiface.KMSAPI
+	keyID  string
+}
+
+// New creates an AWS KMS provider from a [security.encryption.awskms.<keyName>]
+// section. Without access_key_id the default AWS credentials chain is used.
+func New(section setting.Section) (secrets.Provider, error) {
+	keyID := section.KeyValue("key_id").Value()
+	if keyID == "" {
+		return nil, errors.New("missing key_id for awskms encryption provider")
+	}
+
+	cfg := aws.NewConfig()
+	if region := section.KeyValue("region").Value(); region != "" {
+		cfg = cfg.WithRegion(region)
+	}
+	if endpoint := section.KeyValue("endpoint").Value(); endpoint != "" {
+		cfg = cfg.WithEndpoint(endpoint)
+	}
+	if accessKeyID := section.KeyValue("access_key_id").Value(); accessKeyID != "" {
+		cfg = cfg.WithCredentials(credentials.NewStaticCredentials(
+			accessKeyID,
+			section.KeyValue("secret_access_key").Value(),
+			section.KeyValue("session_token").Value(),
+		))
+	}
+	sess, err := session.NewSession(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session for awskms encryption provider: %w", err)
+	}
+
+	return &awsKMSProvider{
+		client: kms.New(sess),
+		keyID:  keyID,
+	}, nil
+}
+
+func (p *awsKMSProvider) Encrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	out, err := p.client.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:     aws.String(p.keyID),
+		Plaintext: blob,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.CiphertextBlob, nil
+}
+
+func (p *awsKMSProvider) Decrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	out, err := p.client.DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(p.keyID),
+		CiphertextBlob: blob,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
@@ -0,0 +1,97 @@
+package awskmsprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeKMS emulates the Encrypt and Decrypt actions of the AWS KMS API,
+// ciphertext is the plaintext with a prefix.
+func fakeKMS() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			KeyId          string
+			Plaintext      []byte
+			CiphertextBlob []byte
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.KeyId != "alias/grafana" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var resp map[string]interface{}
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.Encrypt":
+			resp = map[string]interface{}{
+				"KeyId":          body.KeyId,
+				"CiphertextBlob": append([]byte("encrypted:"), body.Plaintext...),
+			}
+		case "TrentService.Decrypt":
+			if !bytes.HasPrefix(body.CiphertextBlob, []byte("encrypted:")) {
+				w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"__type":"InvalidCiphertextException","message":"invalid ciphertext"}`))
+				return
+			}
+			resp = map[string]interface{}{
+				"KeyId":     body.KeyId,
+				"Plaintext": bytes.TrimPrefix(body.CiphertextBlob, []byte("encrypted:")),
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func TestAWSKMSProvider(t *testing.T) {
+	server := httptest.NewServer(fakeKMS())
+	t.Cleanup(server.Close)
+
+	raw, err := ini.Load([]byte(`
+	[security.encryption.awskms.v1]
+	key_id = alias/grafana
+	region = eu-north-1
+	access_key_id = test
+	secret_access_key = test
+	endpoint = ` + server.URL))
+	require.NoError(t, err)
+	settings := &setting.OSSImpl{Cfg: &setting.Cfg{Raw: raw}}
+
+	provider, err := New(settings.Section("security.encryption.awskms.v1"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	encrypted, err := provider.Encrypt(ctx, []byte("data key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("encrypted:data key"), encrypted)
+
+	decrypted, err := provider.Decrypt(ctx, encrypted)
+	require.NoError(t, err)
+	require.Equal(t, []byte("data key"), decrypted)
+
+	_, err = provider.Decrypt(ctx, []byte("data key"))
+	require.Error(t, err)
+}
+
+func TestNew_MissingKeyID(t *testing.T) {
+	raw, err := ini.Load([]byte(`
+	[security.encryption.awskms.v1]
+	region = eu-north-1`))
+	require.NoError(t, err)
+	settings := &setting.OSSImpl{Cfg: &setting.Cfg{Raw: raw}}
+
+	_, err = New(settings.Section("security.encryption.awskms.v1"))
+	require.EqualError(t, err, "missing key_id for awskms encryption provider")
+}
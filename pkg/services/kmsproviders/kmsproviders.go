@@ -12,6 +12,18 @@ const (
 	// which fallbacks to Grafana's secret key. See the
 	// defaultprovider package for further information.
 	Default = "secretKey.v1"
+
+	// HashicorpVault is the kind of the providers using
+	// the Transit secrets engine of HashiCorp Vault.
+	HashicorpVault = "hashicorpvault"
+
+	// AWSKMS is the kind of the providers using AWS KMS
+	// or a service compatible with its API.
+	AWSKMS = "awskms"
+
+	// PKCS11 is the kind of the providers using an AES key
+	// stored in a PKCS#11 token, like a HSM.
+	PKCS11 = "pkcs11"
 )
 
 type Service interface {
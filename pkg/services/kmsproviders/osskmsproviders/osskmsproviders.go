@@ -1,14 +1,23 @@
 package osskmsproviders
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/kmsproviders"
+	"github.com/grafana/grafana/pkg/services/kmsproviders/awskmsprovider"
 	grafana "github.com/grafana/grafana/pkg/services/kmsproviders/defaultprovider"
+	"github.com/grafana/grafana/pkg/services/kmsproviders/pkcs11provider"
+	"github.com/grafana/grafana/pkg/services/kmsproviders/vaultprovider"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
+var logger = log.New("kmsproviders")
+
 type Service struct {
 	enc      encryption.Internal
 	settings setting.Provider
@@ -23,12 +32,79 @@ func ProvideService(enc encryption.Internal, settings setting.Provider, features
 	}
 }
 
+// Provide returns the default provider, which is always available to decrypt
+// data keys encrypted before switching providers, and the providers listed in
+// available_encryption_providers and encryption_provider. Each of them is
+// configured in a [security.encryption.<provider>.<keyName>] section.
 func (s Service) Provide() (map[secrets.ProviderID]secrets.Provider, error) {
 	if !s.features.IsEnabled(featuremgmt.FlagEnvelopeEncryption) {
 		return nil, nil
 	}
 
-	return map[secrets.ProviderID]secrets.Provider{
+	providers := map[secrets.ProviderID]secrets.Provider{
 		kmsproviders.Default: grafana.New(s.settings, s.enc),
-	}, nil
+	}
+
+	for _, id := range s.configuredProviders() {
+		if _, exists := providers[id]; exists {
+			continue
+		}
+
+		provider, err := s.newProvider(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if provider == nil {
+			logger.Warn("Skipping unsupported encryption provider", "provider", id)
+			continue
+		}
+
+		providers[id] = provider
+	}
+
+	return providers, nil
+}
+
+func (s Service) configuredProviders() []secrets.ProviderID {
+	var ids []secrets.ProviderID
+	available := s.settings.KeyValue("security", "available_encryption_providers").Value()
+	for _, id := range strings.Fields(available) {
+		ids = append(ids, secrets.ProviderID(id))
+	}
+
+	current := s.settings.KeyValue("security", "encryption_provider").Value()
+	if current != "" && current != kmsproviders.Legacy {
+		ids = append(ids, secrets.ProviderID(current))
+	}
+
+	return ids
+}
+
+func (s Service) newProvider(id secrets.ProviderID) (secrets.Provider, error) {
+	kind, err := id.Kind()
+	if err != nil {
+		return nil, err
+	}
+
+	section := s.settings.Section(fmt.Sprintf("security.encryption.%s", id))
+
+	var provider secrets.Provider
+	switch kind {
+	case kmsproviders.HashicorpVault:
+		provider, err = vaultprovider.New(section)
+	case kmsproviders.AWSKMS:
+		provider, err = awskmsprovider.New(section)
+	case kmsproviders.PKCS11:
+		provider, err = pkcs11provider.New(section)
+	default:
+		// Kinds of providers not available in OSS, like azurekv.
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure encryption provider %s: %w", id, err)
+	}
+
+	return provider, nil
 }
@@ -0,0 +1,81 @@
+package osskmsproviders
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/services/encryption/ossencryption"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/kmsproviders"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func setupTestService(t *testing.T, rawCfg string, features featuremgmt.FeatureToggles) Service {
+	t.Helper()
+	raw, err := ini.Load([]byte(rawCfg))
+	require.NoError(t, err)
+	settings := &setting.OSSImpl{Cfg: &setting.Cfg{Raw: raw}}
+	return ProvideService(ossencryption.ProvideService(), settings, features)
+}
+
+func TestService_Provide(t *testing.T) {
+	features := featuremgmt.WithFeatures(featuremgmt.FlagEnvelopeEncryption)
+
+	t.Run("should return nothing when envelope encryption is disabled", func(t *testing.T) {
+		svc := setupTestService(t, `
+		[security]
+		secret_key = sdDkslslld
+		`, featuremgmt.WithFeatures())
+		providers, err := svc.Provide()
+		require.NoError(t, err)
+		require.Nil(t, providers)
+	})
+
+	t.Run("should return default provider", func(t *testing.T) {
+		svc := setupTestService(t, `
+		[security]
+		secret_key = sdDkslslld
+		`, features)
+		providers, err := svc.Provide()
+		require.NoError(t, err)
+		require.Len(t, providers, 1)
+		require.Contains(t, providers, secrets.ProviderID(kmsproviders.Default))
+	})
+
+	t.Run("should return configured providers", func(t *testing.T) {
+		svc := setupTestService(t, `
+		[security]
+		secret_key = sdDkslslld
+		encryption_provider = awskms.v1
+		available_encryption_providers = secretKey.v1 hashicorpvault.v1 azurekv.v1
+
+		[security.encryption.hashicorpvault.v1]
+		url = http://localhost:8200
+		token = test-token
+		key_ring = grafana
+
+		[security.encryption.awskms.v1]
+		key_id = alias/grafana
+		region = eu-north-1
+		`, features)
+		providers, err := svc.Provide()
+		require.NoError(t, err)
+		require.Len(t, providers, 3)
+		require.Contains(t, providers, secrets.ProviderID(kmsproviders.Default))
+		require.Contains(t, providers, secrets.ProviderID("hashicorpvault.v1"))
+		require.Contains(t, providers, secrets.ProviderID("awskms.v1"))
+	})
+
+	t.Run("should fail for invalid provider configuration", func(t *testing.T) {
+		svc := setupTestService(t, `
+		[security]
+		secret_key = sdDkslslld
+		encryption_provider = hashicorpvault.v1
+		`, features)
+		_, err := svc.Provide()
+		require.EqualError(t, err, "failed to configure encryption provider hashicorpvault.v1: missing url for hashicorpvault encryption provider")
+	})
+}
@@ -0,0 +1,195 @@
+package pkcs11provider
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	gcmIVSize  = 12
+	gcmTagBits = 128
+)
+
+// pkcs11Provider encrypts data keys with AES-GCM using an AES key stored
+// in a PKCS#11 token, like a HSM or SoftHSM. Encrypted data is prefixed
+// with the IV used.
+type pkcs11Provider struct {
+	mu      sync.Mutex
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+}
+
+// New creates a PKCS#11 provider from a [security.encryption.pkcs11.<keyName>]
+// section. The token is selected by token_label, or by slot_id if no label
+// is set, and the AES key by key_label.
+func New(section setting.Section) (secrets.Provider, error) {
+	module := section.KeyValue("module").Value()
+	if module == "" {
+		return nil, errors.New("missing module for pkcs11 encryption provider")
+	}
+	keyLabel := section.KeyValue("key_label").Value()
+	if keyLabel == "" {
+		return nil, errors.New("missing key_label for pkcs11 encryption provider")
+	}
+
+	ctx := pkcs11.New(module)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load pkcs11 module %s", module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize pkcs11 module: %w", err)
+	}
+
+	p := &pkcs11Provider{ctx: ctx}
+	if err := p.open(section, keyLabel); err != nil {
+		p.close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *pkcs11Provider) open(section setting.Section, keyLabel string) error {
+	slot, err := p.findSlot(section.KeyValue("token_label").Value(), section.KeyValue("slot_id").Value())
+	if err != nil {
+		return err
+	}
+	p.session, err = p.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("failed to open pkcs11 session: %w", err)
+	}
+	if pin := section.KeyValue("pin").Value(); pin != "" {
+		if err := p.ctx.Login(p.session, pkcs11.CKU_USER, pin); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+			return fmt.Errorf("failed to login to pkcs11 token: %w", err)
+		}
+	}
+	p.key, err = p.findKey(keyLabel)
+	return err
+}
+
+func (p *pkcs11Provider) findSlot(tokenLabel string, slotID string) (uint, error) {
+	if tokenLabel == "" {
+		if slotID == "" {
+			return 0, errors.New("missing token_label or slot_id for pkcs11 encryption provider")
+		}
+		id, err := strconv.ParseUint(slotID, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid slot_id for pkcs11 encryption provider: %w", err)
+		}
+		return uint(id), nil
+	}
+
+	slots, err := p.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pkcs11 slots: %w", err)
+	}
+	for _, slot := range slots {
+		info, err := p.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get pkcs11 token info: %w", err)
+		}
+		if info.Label == tokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("pkcs11 token %s not found", tokenLabel)
+}
+
+func (p *pkcs11Provider) findKey(label string) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_AES),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	if err := p.ctx.FindObjectsInit(p.session, template); err != nil {
+		return 0, fmt.Errorf("failed to find pkcs11 key: %w", err)
+	}
+	objects, _, err := p.ctx.FindObjects(p.session, 2)
+	if finalErr := p.ctx.FindObjectsFinal(p.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find pkcs11 key: %w", err)
+	}
+	switch len(objects) {
+	case 0:
+		return 0, fmt.Errorf("pkcs11 AES key %s not found", label)
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, fmt.Errorf("multiple pkcs11 AES keys with label %s found", label)
+	}
+}
+
+func (p *pkcs11Provider) Encrypt(_ context.Context, blob []byte) ([]byte, error) {
+	iv := make([]byte, gcmIVSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	params := pkcs11.NewGCMParams(iv, nil, gcmTagBits)
+	defer params.Free()
+	if err := p.ctx.EncryptInit(p.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_GCM, params)}, p.key); err != nil {
+		return nil, fmt.Errorf("failed to initialize pkcs11 encryption: %w", err)
+	}
+	encrypted, err := p.ctx.Encrypt(p.session, blob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt with pkcs11: %w", err)
+	}
+	// Some modules ignore the given IV and generate their own.
+	if usedIV := params.IV(); len(usedIV) == gcmIVSize {
+		iv = usedIV
+	}
+	return append(iv, encrypted...), nil
+}
+
+func (p *pkcs11Provider) Decrypt(_ context.Context, blob []byte) ([]byte, error) {
+	if len(blob) < gcmIVSize {
+		return nil, errors.New("pkcs11 encrypted data too short")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	params := pkcs11.NewGCMParams(blob[:gcmIVSize], nil, gcmTagBits)
+	defer params.Free()
+	if err := p.ctx.DecryptInit(p.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_GCM, params)}, p.key); err != nil {
+		return nil, fmt.Errorf("failed to initialize pkcs11 decryption: %w", err)
+	}
+	decrypted, err := p.ctx.Decrypt(p.session, blob[gcmIVSize:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with pkcs11: %w", err)
+	}
+	return decrypted, nil
+}
+
+// Run closes the session and finalizes the module on shutdown.
+func (p *pkcs11Provider) Run(ctx context.Context) error {
+	<-ctx.Done()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.close()
+	return ctx.Err()
+}
+
+func (p *pkcs11Provider) close() {
+	if p.session != 0 {
+		_ = p.ctx.CloseSession(p.session)
+		p.session = 0
+	}
+	_ = p.ctx.Finalize()
+	p.ctx.Destroy()
+}
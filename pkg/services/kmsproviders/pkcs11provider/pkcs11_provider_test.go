@@ -0,0 +1,68 @@
+package pkcs11provider
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// TestPKCS11Provider needs a token with an AES key, for example with SoftHSM:
+//
+//	softhsm2-util --init-token --free --label grafana --pin 1234 --so-pin 1234
+//	pkcs11-tool --module /usr/lib/softhsm/libsofthsm2.so --token-label grafana --login --pin 1234 \
+//	  --keygen --key-type AES:32 --label grafana-kek
+//	GRAFANA_TEST_PKCS11_MODULE=/usr/lib/softhsm/libsofthsm2.so go test ./pkg/services/kmsproviders/pkcs11provider/...
+func TestPKCS11Provider(t *testing.T) {
+	module := os.Getenv("GRAFANA_TEST_PKCS11_MODULE")
+	if module == "" {
+		t.Skip("GRAFANA_TEST_PKCS11_MODULE not set")
+	}
+
+	raw, err := ini.Load([]byte(`
+	[security.encryption.pkcs11.v1]
+	module = ` + module + `
+	token_label = grafana
+	pin = 1234
+	key_label = grafana-kek`))
+	require.NoError(t, err)
+	settings := &setting.OSSImpl{Cfg: &setting.Cfg{Raw: raw}}
+
+	provider, err := New(settings.Section("security.encryption.pkcs11.v1"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+	})
+	go func() {
+		_ = provider.(*pkcs11Provider).Run(ctx)
+	}()
+
+	encrypted, err := provider.Encrypt(ctx, []byte("data key"))
+	require.NoError(t, err)
+	require.NotContains(t, string(encrypted), "data key")
+
+	decrypted, err := provider.Decrypt(ctx, encrypted)
+	require.NoError(t, err)
+	require.Equal(t, []byte("data key"), decrypted)
+
+	encrypted[len(encrypted)-1] ^= 1
+	_, err = provider.Decrypt(ctx, encrypted)
+	require.Error(t, err)
+}
+
+func TestNew_MissingConfiguration(t *testing.T) {
+	raw, err := ini.Load([]byte(`
+	[security.encryption.pkcs11.v1]
+	key_label = grafana-kek`))
+	require.NoError(t, err)
+	settings := &setting.OSSImpl{Cfg: &setting.Cfg{Raw: raw}}
+
+	_, err = New(settings.Section("security.encryption.pkcs11.v1"))
+	require.EqualError(t, err, "missing module for pkcs11 encryption provider")
+}
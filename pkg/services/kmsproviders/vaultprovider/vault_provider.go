@@ -0,0 +1,161 @@
+package vaultprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var logger = log.New("kmsproviders.vault")
+
+// vaultProvider encrypts data keys with the Transit secrets engine of
+// HashiCorp Vault, so that the key encryption key never leaves Vault.
+type vaultProvider struct {
+	client               *http.Client
+	url                  string
+	token                string
+	namespace            string
+	transitEnginePath    string
+	keyRing              string
+	tokenRenewalInterval time.Duration
+}
+
+// New creates a Vault Transit provider from a
+// [security.encryption.hashicorpvault.<keyName>] section.
+func New(section setting.Section) (secrets.Provider, error) {
+	p := &vaultProvider{
+		client:               &http.Client{Timeout: 10 * time.Second},
+		url:                  strings.TrimSuffix(section.KeyValue("url").Value(), "/"),
+		token:                section.KeyValue("token").Value(),
+		namespace:            section.KeyValue("namespace").Value(),
+		transitEnginePath:    strings.Trim(section.KeyValue("transit_engine_path").MustString("transit"), "/"),
+		keyRing:              section.KeyValue("key_ring").Value(),
+		tokenRenewalInterval: section.KeyValue("token_renewal_interval").MustDuration(5 * time.Minute),
+	}
+	if p.url == "" {
+		return nil, errors.New("missing url for hashicorpvault encryption provider")
+	}
+	if _, err := url.Parse(p.url); err != nil {
+		return nil, fmt.Errorf("invalid url for hashicorpvault encryption provider: %w", err)
+	}
+	if p.token == "" {
+		return nil, errors.New("missing token for hashicorpvault encryption provider")
+	}
+	if p.keyRing == "" {
+		return nil, errors.New("missing key_ring for hashicorpvault encryption provider")
+	}
+	return p, nil
+}
+
+type transitResponse struct {
+	Data struct {
+		Ciphertext string `json:"ciphertext"`
+		Plaintext  string `json:"plaintext"`
+	} `json:"data"`
+}
+
+func (p *vaultProvider) Encrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	var resp transitResponse
+	err := p.do(ctx, path.Join(p.transitEnginePath, "encrypt", p.keyRing), map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(blob),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Data.Ciphertext == "" {
+		return nil, errors.New("vault returned empty ciphertext")
+	}
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+func (p *vaultProvider) Decrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	var resp transitResponse
+	err := p.do(ctx, path.Join(p.transitEnginePath, "decrypt", p.keyRing), map[string]string{
+		"ciphertext": string(blob),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+}
+
+// Run renews the token periodically, so that periodic service tokens
+// do not expire.
+func (p *vaultProvider) Run(ctx context.Context) error {
+	if p.tokenRenewalInterval <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(p.tokenRenewalInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.renewToken(ctx); err != nil {
+				logger.Error("Failed to renew vault token", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (p *vaultProvider) renewToken(ctx context.Context) error {
+	return p.do(ctx, "auth/token/renew-self", map[string]string{}, nil)
+}
+
+func (p *vaultProvider) do(ctx context.Context, apiPath string, body interface{}, result interface{}) error {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"/v1/"+apiPath, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", p.token)
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var errResp struct {
+			Errors []string `json:"errors"`
+		}
+		if err := json.Unmarshal(respBody, &errResp); err == nil && len(errResp.Errors) > 0 {
+			return fmt.Errorf("vault request failed with status %d: %s", resp.StatusCode, strings.Join(errResp.Errors, ", "))
+		}
+		return fmt.Errorf("vault request failed with status %d", resp.StatusCode)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, result)
+}
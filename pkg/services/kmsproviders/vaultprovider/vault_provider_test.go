@@ -0,0 +1,121 @@
+package vaultprovider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeTransit emulates the encrypt and decrypt endpoints of the Transit
+// secrets engine, ciphertext is the plaintext with a vault prefix.
+type fakeTransit struct {
+	renewCalls int
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != "test-token" {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+	var body map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var data map[string]string
+	switch r.URL.Path {
+	case "/v1/transit/encrypt/grafana":
+		data = map[string]string{"ciphertext": "vault:v1:" + body["plaintext"]}
+	case "/v1/transit/decrypt/grafana":
+		data = map[string]string{"plaintext": strings.TrimPrefix(body["ciphertext"], "vault:v1:")}
+	case "/v1/auth/token/renew-self":
+		f.renewCalls++
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":[]}`))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func testSection(t *testing.T, cfg string) setting.Section {
+	t.Helper()
+	raw, err := ini.Load([]byte(cfg))
+	require.NoError(t, err)
+	settings := &setting.OSSImpl{Cfg: &setting.Cfg{Raw: raw}}
+	return settings.Section("security.encryption.hashicorpvault.v1")
+}
+
+func TestVaultProvider(t *testing.T) {
+	transit := &fakeTransit{}
+	server := httptest.NewServer(transit)
+	t.Cleanup(server.Close)
+
+	section := testSection(t, `
+	[security.encryption.hashicorpvault.v1]
+	url = `+server.URL+`/
+	token = test-token
+	key_ring = grafana
+	`)
+
+	provider, err := New(section)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	encrypted, err := provider.Encrypt(ctx, []byte("data key"))
+	require.NoError(t, err)
+	require.Equal(t, "vault:v1:"+base64.StdEncoding.EncodeToString([]byte("data key")), string(encrypted))
+
+	decrypted, err := provider.Decrypt(ctx, encrypted)
+	require.NoError(t, err)
+	require.Equal(t, []byte("data key"), decrypted)
+
+	require.NoError(t, provider.(*vaultProvider).renewToken(ctx))
+	require.Equal(t, 1, transit.renewCalls)
+
+	t.Run("returns vault errors", func(t *testing.T) {
+		provider, err := New(testSection(t, `
+		[security.encryption.hashicorpvault.v1]
+		url = `+server.URL+`
+		token = wrong-token
+		key_ring = grafana
+		`))
+		require.NoError(t, err)
+
+		_, err = provider.Encrypt(ctx, []byte("data key"))
+		require.EqualError(t, err, "vault request failed with status 403: permission denied")
+	})
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(testSection(t, `
+	[security.encryption.hashicorpvault.v1]
+	token = test-token
+	key_ring = grafana
+	`))
+	require.EqualError(t, err, "missing url for hashicorpvault encryption provider")
+
+	_, err = New(testSection(t, `
+	[security.encryption.hashicorpvault.v1]
+	url = http://localhost:8200
+	key_ring = grafana
+	`))
+	require.EqualError(t, err, "missing token for hashicorpvault encryption provider")
+
+	_, err = New(testSection(t, `
+	[security.encryption.hashicorpvault.v1]
+	url = http://localhost:8200
+	token = test-token
+	`))
+	require.EqualError(t, err, "missing key_ring for hashicorpvault encryption provider")
+}
@@ -430,6 +430,16 @@ func (server *Server) buildGrafanaUser(user *ldap.Entry) (*models.ExternalUserIn
 		}
 	}
 
+	// Only sync teams if there are team mappings configured
+	if len(server.Config.Teams) > 0 {
+		extUser.Teams = []models.ExternalTeam{}
+		for _, team := range server.Config.Teams {
+			if isMemberOf(memberOf, team.GroupDN) {
+				extUser.Teams = append(extUser.Teams, models.ExternalTeam{OrgId: team.OrgId, TeamId: team.TeamId})
+			}
+		}
+	}
+
 	// If there are group org mappings configured, but no matching mappings,
 	// the user will not be able to login and will be disabled
 	if len(server.Config.Groups) > 0 && len(extUser.OrgRoles) == 0 {
@@ -491,6 +501,31 @@ func (server *Server) userBind(path, password string) error {
 // requestMemberOf use this function when POSIX LDAP
 // schema does not support memberOf, so it manually search the groups
 func (server *Server) requestMemberOf(entry *ldap.Entry) ([]string, error) {
+	var config = server.Config
+
+	var filterReplace string
+	if config.GroupSearchFilterUserAttribute == "" {
+		filterReplace = getAttribute(config.Attr.Username, entry)
+	} else {
+		filterReplace = getAttribute(
+			config.GroupSearchFilterUserAttribute,
+			entry,
+		)
+	}
+
+	filter := strings.ReplaceAll(
+		config.GroupSearchFilter, "%s",
+		ldap.EscapeFilter(filterReplace),
+	)
+
+	server.log.Info("Searching for user's groups", "filter", filter)
+
+	return server.searchGroups(filter)
+}
+
+// searchGroups returns the identifiers of the groups matching the filter
+// in the group search base DNs
+func (server *Server) searchGroups(filter string) ([]string, error) {
 	var memberOf []string
 	var config = server.Config
 	var searchBaseDNs []string
@@ -501,31 +536,14 @@ func (server *Server) requestMemberOf(entry *ldap.Entry) ([]string, error) {
 		searchBaseDNs = config.SearchBaseDNs
 	}
 
-	for _, groupSearchBase := range searchBaseDNs {
-		var filterReplace string
-		if config.GroupSearchFilterUserAttribute == "" {
-			filterReplace = getAttribute(config.Attr.Username, entry)
-		} else {
-			filterReplace = getAttribute(
-				config.GroupSearchFilterUserAttribute,
-				entry,
-			)
-		}
-
-		filter := strings.ReplaceAll(
-			config.GroupSearchFilter, "%s",
-			ldap.EscapeFilter(filterReplace),
-		)
-
-		server.log.Info("Searching for user's groups", "filter", filter)
-
-		// support old way of reading settings
-		groupIDAttribute := config.Attr.MemberOf
-		// but prefer dn attribute if default settings are used
-		if groupIDAttribute == "" || groupIDAttribute == "memberOf" {
-			groupIDAttribute = "dn"
-		}
+	// support old way of reading settings
+	groupIDAttribute := config.Attr.MemberOf
+	// but prefer dn attribute if default settings are used
+	if groupIDAttribute == "" || groupIDAttribute == "memberOf" {
+		groupIDAttribute = "dn"
+	}
 
+	for _, groupSearchBase := range searchBaseDNs {
 		groupSearchReq := ldap.SearchRequest{
 			BaseDN:       groupSearchBase,
 			Scope:        ldap.ScopeWholeSubtree,
@@ -584,15 +602,19 @@ func (server *Server) serializeUsers(
 func (server *Server) getMemberOf(result *ldap.Entry) (
 	[]string, error,
 ) {
+	var memberOf []string
 	if server.Config.GroupSearchFilter == "" {
-		memberOf := getArrayAttribute(server.Config.Attr.MemberOf, result)
-
-		return memberOf, nil
+		memberOf = getArrayAttribute(server.Config.Attr.MemberOf, result)
+	} else {
+		var err error
+		memberOf, err = server.requestMemberOf(result)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	memberOf, err := server.requestMemberOf(result)
-	if err != nil {
-		return nil, err
+	if server.Config.NestedGroups || server.Config.NestedGroupsInChain {
+		return server.resolveNestedGroups(result, memberOf)
 	}
 
 	return memberOf, nil
@@ -0,0 +1,137 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/ldap.v3"
+)
+
+// matchingRuleInChain is the LDAP_MATCHING_RULE_IN_CHAIN of Active Directory,
+// which matches the groups a DN is a member of, directly or through other groups
+const matchingRuleInChain = "1.2.840.113556.1.4.1941"
+
+// resolveNestedGroups adds the groups the groups of the user are members of,
+// either with a single search using the matching rule in chain of
+// Active Directory, or by walking the group hierarchy up to the max depth
+func (server *Server) resolveNestedGroups(user *ldap.Entry, groups []string) ([]string, error) {
+	if server.Config.NestedGroupsInChain {
+		filter := fmt.Sprintf("(member:%s:=%s)", matchingRuleInChain, ldap.EscapeFilter(user.DN))
+		server.log.Debug("Searching for user's nested groups", "filter", filter)
+
+		inChain, err := server.searchGroups(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		return mergeGroups(groups, inChain), nil
+	}
+
+	maxDepth := server.Config.NestedGroupsMaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultNestedGroupsMaxDepth
+	}
+
+	result := mergeGroups(nil, groups)
+	seen := make(map[string]struct{}, len(result))
+	for _, group := range result {
+		seen[strings.ToLower(group)] = struct{}{}
+	}
+
+	current := result
+	for depth := 0; depth < maxDepth && len(current) > 0; depth++ {
+		var parents []string
+		for _, group := range current {
+			groupParents, err := server.requestGroupParents(group)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, parent := range groupParents {
+				// skipping groups already seen also breaks membership cycles
+				if _, exists := seen[strings.ToLower(parent)]; exists {
+					continue
+				}
+				seen[strings.ToLower(parent)] = struct{}{}
+				parents = append(parents, parent)
+			}
+		}
+
+		result = append(result, parents...)
+		current = parents
+	}
+
+	if len(current) > 0 {
+		server.log.Warn(
+			"Stopped resolving nested groups at the max depth",
+			"user", user.DN,
+			"maxDepth", maxDepth,
+		)
+	}
+
+	return result, nil
+}
+
+// requestGroupParents returns the groups a group is a member of. They are
+// searched with the nested group search filter if one is configured, otherwise
+// they are read from the member of attribute of the group entry. The group
+// search filter can't be used, as it usually matches an attribute of users
+// other than their DN, like the memberUid of posix groups
+func (server *Server) requestGroupParents(groupDN string) ([]string, error) {
+	config := server.Config
+
+	if config.NestedGroupSearchFilter != "" {
+		filter := strings.ReplaceAll(
+			config.NestedGroupSearchFilter, "%s",
+			ldap.EscapeFilter(groupDN),
+		)
+
+		return server.searchGroups(filter)
+	}
+
+	memberOfAttribute := config.Attr.MemberOf
+	if memberOfAttribute == "" {
+		memberOfAttribute = "memberOf"
+	}
+
+	result, err := server.Connection.Search(&ldap.SearchRequest{
+		BaseDN:       groupDN,
+		Scope:        ldap.ScopeBaseObject,
+		DerefAliases: ldap.NeverDerefAliases,
+		Attributes:   []string{memberOfAttribute},
+		Filter:       "(objectClass=*)",
+	})
+	if err != nil {
+		// the group might be outside of the directory, or
+		// the member of attribute might not hold DNs
+		var ldapErr *ldap.Error
+		if errors.As(err, &ldapErr) && (ldapErr.ResultCode == ldap.LDAPResultNoSuchObject ||
+			ldapErr.ResultCode == ldap.LDAPResultInvalidDNSyntax) {
+			server.log.Debug("Cannot read the groups of group", "group", groupDN, "error", err)
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var parents []string
+	for _, entry := range result.Entries {
+		parents = append(parents, getArrayAttribute(memberOfAttribute, entry)...)
+	}
+
+	return parents, nil
+}
+
+// mergeGroups appends the groups which aren't already in the list,
+// ignoring the case as LDAP does for DNs
+func mergeGroups(groups, others []string) []string {
+	result := append([]string{}, groups...)
+	for _, group := range others {
+		if !isMemberOf(result, group) {
+			result = append(result, group)
+		}
+	}
+
+	return result
+}
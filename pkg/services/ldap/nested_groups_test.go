@@ -0,0 +1,252 @@
+package ldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ldap.v3"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestServer_resolveNestedGroups(t *testing.T) {
+	user := &ldap.Entry{
+		DN: "cn=torkel,ou=users,dc=grafana,dc=org",
+		Attributes: []*ldap.EntryAttribute{
+			{Name: "username", Values: []string{"torkel"}},
+			{Name: "memberOf", Values: []string{"cn=backend,ou=groups,dc=grafana,dc=org"}},
+		},
+	}
+
+	t.Run("walks the member of attribute of groups", func(t *testing.T) {
+		// backend -> engineering -> staff -> engineering
+		parents := map[string][]string{
+			"cn=backend,ou=groups,dc=grafana,dc=org":     {"cn=engineering,ou=groups,dc=grafana,dc=org"},
+			"cn=engineering,ou=groups,dc=grafana,dc=org": {"cn=staff,ou=groups,dc=grafana,dc=org"},
+			"cn=staff,ou=groups,dc=grafana,dc=org":       {"CN=Engineering,OU=Groups,DC=grafana,DC=org"},
+		}
+
+		connection := &MockConnection{}
+		connection.setSearchFunc(func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			assert.Equal(t, ldap.ScopeBaseObject, request.Scope)
+			assert.Equal(t, []string{"memberOf"}, request.Attributes)
+
+			return &ldap.SearchResult{Entries: []*ldap.Entry{{
+				DN:         request.BaseDN,
+				Attributes: []*ldap.EntryAttribute{{Name: "memberOf", Values: parents[request.BaseDN]}},
+			}}}, nil
+		})
+
+		server := &Server{
+			Config: &ServerConfig{
+				Attr:         AttributeMap{Username: "username", MemberOf: "memberOf"},
+				NestedGroups: true,
+				Groups: []*GroupToOrgRole{{
+					GroupDN: "cn=staff,ou=groups,dc=grafana,dc=org",
+					OrgId:   1,
+					OrgRole: models.ROLE_EDITOR,
+				}},
+			},
+			Connection: connection,
+			log:        log.New("test-logger"),
+		}
+
+		result, err := server.buildGrafanaUser(user)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"cn=backend,ou=groups,dc=grafana,dc=org",
+			"cn=engineering,ou=groups,dc=grafana,dc=org",
+			"cn=staff,ou=groups,dc=grafana,dc=org",
+		}, result.Groups)
+		assert.Equal(t, models.ROLE_EDITOR, result.OrgRoles[1])
+		assert.False(t, result.IsDisabled)
+	})
+
+	t.Run("stops at the max depth", func(t *testing.T) {
+		connection := &MockConnection{}
+		connection.setSearchFunc(func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			return &ldap.SearchResult{Entries: []*ldap.Entry{{
+				DN:         request.BaseDN,
+				Attributes: []*ldap.EntryAttribute{{Name: "memberOf", Values: []string{"cn=parent," + request.BaseDN}}},
+			}}}, nil
+		})
+
+		server := &Server{
+			Config: &ServerConfig{
+				Attr:                 AttributeMap{Username: "username", MemberOf: "memberOf"},
+				NestedGroups:         true,
+				NestedGroupsMaxDepth: 2,
+			},
+			Connection: connection,
+			log:        log.New("test-logger"),
+		}
+
+		groups, err := server.getMemberOf(user)
+		require.NoError(t, err)
+		assert.Len(t, groups, 3)
+	})
+
+	t.Run("skips groups which are not in the directory", func(t *testing.T) {
+		connection := &MockConnection{}
+		connection.setSearchError(ldap.NewError(ldap.LDAPResultNoSuchObject, nil))
+
+		server := &Server{
+			Config: &ServerConfig{
+				Attr:         AttributeMap{Username: "username", MemberOf: "memberOf"},
+				NestedGroups: true,
+			},
+			Connection: connection,
+			log:        log.New("test-logger"),
+		}
+
+		groups, err := server.getMemberOf(user)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cn=backend,ou=groups,dc=grafana,dc=org"}, groups)
+	})
+
+	t.Run("searches groups with the nested group search filter", func(t *testing.T) {
+		connection := &MockConnection{}
+		connection.setSearchFunc(func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			var entries []*ldap.Entry
+			switch request.Filter {
+			case "(member=cn=torkel,ou=users,dc=grafana,dc=org)":
+				entries = []*ldap.Entry{{DN: "cn=backend,ou=groups,dc=grafana,dc=org"}}
+			case "(member=cn=backend,ou=groups,dc=grafana,dc=org)":
+				entries = []*ldap.Entry{{DN: "cn=engineering,ou=groups,dc=grafana,dc=org"}}
+			}
+			return &ldap.SearchResult{Entries: entries}, nil
+		})
+
+		server := &Server{
+			Config: &ServerConfig{
+				Attr:                           AttributeMap{Username: "username"},
+				GroupSearchFilter:              "(member=%s)",
+				GroupSearchFilterUserAttribute: "dn",
+				GroupSearchBaseDNs:             []string{"ou=groups,dc=grafana,dc=org"},
+				NestedGroups:                   true,
+				NestedGroupSearchFilter:        "(member=%s)",
+			},
+			Connection: connection,
+			log:        log.New("test-logger"),
+		}
+
+		groups, err := server.getMemberOf(user)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"cn=backend,ou=groups,dc=grafana,dc=org",
+			"cn=engineering,ou=groups,dc=grafana,dc=org",
+		}, groups)
+	})
+
+	t.Run("doesn't search groups with the group search filter of posix groups", func(t *testing.T) {
+		var requests []*ldap.SearchRequest
+		connection := &MockConnection{}
+		connection.setSearchFunc(func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			requests = append(requests, request)
+
+			var entries []*ldap.Entry
+			switch request.Filter {
+			case "(&(objectClass=posixGroup)(memberUid=torkel))":
+				entries = []*ldap.Entry{{DN: "cn=backend,ou=groups,dc=grafana,dc=org"}}
+			case "(objectClass=*)":
+				if request.BaseDN == "cn=backend,ou=groups,dc=grafana,dc=org" {
+					entries = []*ldap.Entry{{
+						DN:         request.BaseDN,
+						Attributes: []*ldap.EntryAttribute{{Name: "memberOf", Values: []string{"cn=engineering,ou=groups,dc=grafana,dc=org"}}},
+					}}
+				}
+			}
+			return &ldap.SearchResult{Entries: entries}, nil
+		})
+
+		server := &Server{
+			Config: &ServerConfig{
+				Attr:                           AttributeMap{Username: "username"},
+				GroupSearchFilter:              "(&(objectClass=posixGroup)(memberUid=%s))",
+				GroupSearchFilterUserAttribute: "username",
+				GroupSearchBaseDNs:             []string{"ou=groups,dc=grafana,dc=org"},
+				NestedGroups:                   true,
+			},
+			Connection: connection,
+			log:        log.New("test-logger"),
+		}
+
+		groups, err := server.getMemberOf(user)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"cn=backend,ou=groups,dc=grafana,dc=org",
+			"cn=engineering,ou=groups,dc=grafana,dc=org",
+		}, groups)
+
+		require.NotEmpty(t, requests)
+		for _, request := range requests[1:] {
+			assert.Equal(t, ldap.ScopeBaseObject, request.Scope)
+			assert.Equal(t, "(objectClass=*)", request.Filter)
+		}
+	})
+
+	t.Run("uses the matching rule in chain of Active Directory", func(t *testing.T) {
+		connection := &MockConnection{}
+		connection.setSearchFunc(func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			assert.Equal(t, "(member:1.2.840.113556.1.4.1941:=cn=torkel,ou=users,dc=grafana,dc=org)", request.Filter)
+			assert.Equal(t, "ou=groups,dc=grafana,dc=org", request.BaseDN)
+
+			return &ldap.SearchResult{Entries: []*ldap.Entry{
+				{DN: "cn=backend,ou=groups,dc=grafana,dc=org"},
+				{DN: "cn=engineering,ou=groups,dc=grafana,dc=org"},
+			}}, nil
+		})
+
+		server := &Server{
+			Config: &ServerConfig{
+				Attr:                AttributeMap{Username: "username", MemberOf: "memberOf"},
+				GroupSearchBaseDNs:  []string{"ou=groups,dc=grafana,dc=org"},
+				NestedGroupsInChain: true,
+			},
+			Connection: connection,
+			log:        log.New("test-logger"),
+		}
+
+		groups, err := server.getMemberOf(user)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"cn=backend,ou=groups,dc=grafana,dc=org",
+			"cn=engineering,ou=groups,dc=grafana,dc=org",
+		}, groups)
+	})
+}
+
+func TestServer_buildGrafanaUser_teams(t *testing.T) {
+	server := &Server{
+		Config: &ServerConfig{
+			Attr: AttributeMap{Username: "username", MemberOf: "memberOf"},
+			Teams: []*GroupToTeam{
+				{GroupDN: "cn=backend,ou=groups,dc=grafana,dc=org", OrgId: 1, TeamId: 3},
+				{GroupDN: "cn=frontend,ou=groups,dc=grafana,dc=org", OrgId: 1, TeamId: 4},
+			},
+		},
+		Connection: &MockConnection{},
+		log:        log.New("test-logger"),
+	}
+
+	result, err := server.buildGrafanaUser(&ldap.Entry{
+		DN: "cn=torkel,ou=users,dc=grafana,dc=org",
+		Attributes: []*ldap.EntryAttribute{
+			{Name: "username", Values: []string{"torkel"}},
+			{Name: "memberOf", Values: []string{"CN=Backend,OU=Groups,DC=grafana,DC=org"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []models.ExternalTeam{{OrgId: 1, TeamId: 3}}, result.Teams)
+
+	t.Run("doesn't sync teams without team mappings", func(t *testing.T) {
+		server.Config.Teams = nil
+
+		result, err := server.buildGrafanaUser(&ldap.Entry{DN: "cn=torkel,ou=users,dc=grafana,dc=org"})
+		require.NoError(t, err)
+		assert.Nil(t, result.Teams)
+	})
+}
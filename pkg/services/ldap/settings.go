@@ -39,7 +39,19 @@ type ServerConfig struct {
 	GroupSearchFilterUserAttribute string   `toml:"group_search_filter_user_attribute"`
 	GroupSearchBaseDNs             []string `toml:"group_search_base_dns"`
 
+	// NestedGroups resolves the groups the groups of the user are members of
+	NestedGroups bool `toml:"nested_groups"`
+	// NestedGroupsInChain resolves them with a single search using the
+	// LDAP_MATCHING_RULE_IN_CHAIN of Active Directory
+	NestedGroupsInChain  bool `toml:"nested_groups_in_chain"`
+	NestedGroupsMaxDepth int  `toml:"nested_groups_max_depth"`
+	// NestedGroupSearchFilter searches for the groups a group is a member
+	// of, with %s replaced by the DN of the group. The member of attribute
+	// of the groups is read instead if not set.
+	NestedGroupSearchFilter string `toml:"nested_group_search_filter"`
+
 	Groups []*GroupToOrgRole `toml:"group_mappings"`
+	Teams  []*GroupToTeam    `toml:"team_mappings"`
 }
 
 // AttributeMap is a struct representation for LDAP "attributes" setting
@@ -63,6 +75,18 @@ type GroupToOrgRole struct {
 	OrgRole models.RoleType `toml:"org_role"`
 }
 
+// GroupToTeam is a struct representation of LDAP
+// config "team_mappings" setting
+type GroupToTeam struct {
+	GroupDN string `toml:"group_dn"`
+	OrgId   int64  `toml:"org_id"`
+	TeamId  int64  `toml:"team_id"`
+}
+
+// DefaultNestedGroupsMaxDepth is the number of levels of nested groups
+// resolved if nested_groups_max_depth isn't set
+const DefaultNestedGroupsMaxDepth = 10
+
 // logger for all LDAP stuff
 var logger = log.New("ldap")
 
@@ -157,6 +181,15 @@ func readConfig(configFile string) (*Config, error) {
 				groupMap.OrgId = 1
 			}
 		}
+
+		for _, teamMap := range server.Teams {
+			if teamMap.OrgId == 0 {
+				teamMap.OrgId = 1
+			}
+			if teamMap.TeamId == 0 {
+				return nil, fmt.Errorf("LDAP team mapping of group %q is missing option: \"team_id\"", teamMap.GroupDN)
+			}
+		}
 	}
 
 	return result, nil
@@ -0,0 +1,255 @@
+// Package ldapsync periodically syncs the users authenticated with LDAP, so
+// that changes of their groups apply without them having to log in again.
+package ldapsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/multildap"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	getLDAPConfig = multildap.GetConfig
+	newLDAP       = multildap.New
+
+	// now is used to test the schedule of the sync.
+	now = time.Now
+)
+
+// cronParser parses sync_cron, which has a leading seconds field.
+var cronParser = cron.NewParser(
+	cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// Service syncs the org roles, team memberships and disabled status of the
+// LDAP users on the sync_cron schedule of the [auth.ldap] section.
+type Service struct {
+	cfg              *setting.Cfg
+	sqlStore         *sqlstore.SQLStore
+	loginService     login.Service
+	authTokenService models.UserTokenService
+	serverLock       *serverlock.ServerLockService
+	schedule         cron.Schedule
+	log              log.Logger
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sqlStore *sqlstore.SQLStore,
+	loginService login.Service,
+	authTokenService models.UserTokenService,
+	serverLock *serverlock.ServerLockService,
+) (*Service, error) {
+	s := &Service{
+		cfg:              cfg,
+		sqlStore:         sqlStore,
+		loginService:     loginService,
+		authTokenService: authTokenService,
+		serverLock:       serverLock,
+		log:              log.New("ldap.sync"),
+	}
+
+	if !cfg.LDAPEnabled || !cfg.LDAPActiveSyncEnabled {
+		return s, nil
+	}
+
+	schedule, err := cronParser.Parse(cfg.LDAPSyncCron)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync_cron %q in the [auth.ldap] section: %w", cfg.LDAPSyncCron, err)
+	}
+	s.schedule = schedule
+
+	return s, nil
+}
+
+// IsDisabled returns true if LDAP or its background sync are not enabled.
+func (s *Service) IsDisabled() bool {
+	return s.schedule == nil
+}
+
+func (s *Service) Run(ctx context.Context) error {
+	for {
+		next := s.schedule.Next(now())
+		timer := time.NewTimer(next.Sub(now()))
+
+		select {
+		case <-timer.C:
+			// Every instance of a HA setup wakes up at the same time, the
+			// lock lets only one of them sync until the next scheduled run.
+			lockInterval := s.schedule.Next(next).Sub(next) / 2
+			err := s.serverLock.LockAndExecute(ctx, "ldap user sync", lockInterval, func(ctx context.Context) {
+				if _, err := s.Sync(ctx); err != nil {
+					s.log.Error("Failed to sync LDAP users", "error", err)
+				}
+			})
+			if err != nil {
+				s.log.Error("Failed to lock and execute the LDAP user sync", "error", err)
+			}
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Result is the outcome of a sync.
+type Result struct {
+	Synced   int
+	Disabled int
+	Failed   int
+}
+
+// ldapUser is a Grafana user authenticated with LDAP.
+type ldapUser struct {
+	Id         int64
+	Login      string
+	IsDisabled bool
+}
+
+// Sync looks up every LDAP user in the LDAP servers. The users found have
+// their info, org roles and team memberships updated, while the users not
+// found anymore, or not matching any group mapping, are disabled and their
+// sessions revoked.
+func (s *Service) Sync(ctx context.Context) (*Result, error) {
+	config, err := getLDAPConfig(s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, errors.New("LDAP is not enabled")
+	}
+	for _, server := range config.Servers {
+		// Without a bind password, servers using single bind can only be
+		// searched with the password of the user logging in
+		if server.BindPassword == "" && strings.Contains(server.BindDN, "%s") {
+			return nil, fmt.Errorf("LDAP server %s:%d uses single bind, which cannot sync users in the background", server.Host, server.Port)
+		}
+	}
+
+	users, err := s.getLDAPUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	multiLDAP := newLDAP(config.Servers)
+
+	// Users can only be told apart from users that were deleted from LDAP if
+	// all the servers can be searched.
+	statuses, err := multiLDAP.Ping()
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		if !status.Available {
+			return nil, fmt.Errorf("LDAP server %s:%d is unavailable: %w", status.Host, status.Port, status.Error)
+		}
+	}
+
+	s.log.Info("Syncing LDAP users", "count", len(users))
+
+	result := &Result{}
+	for start := 0; start < len(users); start += ldap.UsersMaxRequest {
+		end := start + ldap.UsersMaxRequest
+		if end > len(users) {
+			end = len(users)
+		}
+		batch := users[start:end]
+
+		logins := make([]string, 0, len(batch))
+		for _, user := range batch {
+			logins = append(logins, user.Login)
+		}
+
+		extUsers, err := multiLDAP.Users(logins)
+		if err != nil {
+			return result, err
+		}
+
+		// The first server a user is found on wins, as it does at login
+		found := make(map[string]*models.ExternalUserInfo, len(extUsers))
+		for _, extUser := range extUsers {
+			login := strings.ToLower(extUser.Login)
+			if _, exists := found[login]; !exists {
+				found[login] = extUser
+			}
+		}
+
+		for _, user := range batch {
+			extUser := found[strings.ToLower(user.Login)]
+
+			if extUser != nil && !extUser.IsDisabled {
+				if err := s.syncUser(ctx, user, extUser); err != nil {
+					s.log.Error("Failed to sync LDAP user", "userId", user.Id, "login", user.Login, "error", err)
+					result.Failed++
+					continue
+				}
+				result.Synced++
+				continue
+			}
+
+			if user.Login == s.cfg.AdminUser {
+				s.log.Warn("Refusing to disable the Grafana super admin, not found in LDAP", "login", user.Login)
+				continue
+			}
+
+			if err := s.disableUser(ctx, user); err != nil {
+				s.log.Error("Failed to disable LDAP user", "userId", user.Id, "login", user.Login, "error", err)
+				result.Failed++
+				continue
+			}
+			result.Disabled++
+		}
+	}
+
+	s.log.Info("Synced LDAP users", "synced", result.Synced, "disabled", result.Disabled, "failed", result.Failed)
+	return result, nil
+}
+
+func (s *Service) syncUser(ctx context.Context, user ldapUser, extUser *models.ExternalUserInfo) error {
+	extUser.UserId = user.Id
+
+	return s.loginService.UpsertUser(ctx, &models.UpsertUserCommand{
+		ReqContext:    &models.ReqContext{Logger: s.log},
+		ExternalUser:  extUser,
+		SignupAllowed: false,
+	})
+}
+
+func (s *Service) disableUser(ctx context.Context, user ldapUser) error {
+	if !user.IsDisabled {
+		s.log.Debug("Disabling LDAP user", "userId", user.Id, "login", user.Login)
+
+		err := s.sqlStore.DisableUser(ctx, &models.DisableUserCommand{UserId: user.Id, IsDisabled: true})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.authTokenService.RevokeAllUserTokens(ctx, user.Id)
+}
+
+// getLDAPUsers returns the users who have logged in with LDAP.
+func (s *Service) getLDAPUsers(ctx context.Context) ([]ldapUser, error) {
+	var users []ldapUser
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := `SELECT DISTINCT u.id, u.login, u.is_disabled FROM ` + s.sqlStore.Dialect.Quote("user") + ` AS u
+			INNER JOIN user_auth ON user_auth.user_id = u.id
+			WHERE user_auth.auth_module = ? AND u.is_service_account = ` + s.sqlStore.Dialect.BooleanStr(false) + `
+			ORDER BY u.id`
+		return sess.SQL(rawSQL, models.AuthModuleLDAP).Find(&users)
+	})
+	return users, err
+}
@@ -0,0 +1,156 @@
+package ldapsync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/multildap"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type multiLDAPMock struct {
+	multildap.IMultiLDAP
+	statuses []*multildap.ServerStatus
+	users    []*models.ExternalUserInfo
+}
+
+func (m *multiLDAPMock) Ping() ([]*multildap.ServerStatus, error) {
+	return m.statuses, nil
+}
+
+func (m *multiLDAPMock) Users(logins []string) ([]*models.ExternalUserInfo, error) {
+	return m.users, nil
+}
+
+type loginServiceMock struct {
+	login.Service
+	upserted []*models.ExternalUserInfo
+}
+
+func (s *loginServiceMock) UpsertUser(ctx context.Context, cmd *models.UpsertUserCommand) error {
+	s.upserted = append(s.upserted, cmd.ExternalUser)
+	return nil
+}
+
+func TestProvideService(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.LDAPSyncCron = "0 0 1 * * *"
+
+	s, err := ProvideService(cfg, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, s.IsDisabled())
+
+	cfg.LDAPEnabled = true
+	cfg.LDAPActiveSyncEnabled = true
+	s, err = ProvideService(cfg, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.False(t, s.IsDisabled())
+
+	next := s.schedule.Next(time.Date(2021, 11, 2, 13, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2021, 11, 3, 1, 0, 0, 0, time.UTC), next)
+
+	cfg.LDAPSyncCron = "every day"
+	_, err = ProvideService(cfg, nil, nil, nil, nil)
+	require.Error(t, err)
+}
+
+func TestService_Sync(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.LDAPEnabled = true
+	cfg.AdminUser = "admin"
+
+	createUser := func(login string, authModule string) *models.User {
+		user, err := sqlStore.CreateUser(context.Background(), models.CreateUserCommand{Login: login, Email: login + "@example.org"})
+		require.NoError(t, err)
+		if authModule != "" {
+			err = sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+				_, err := sess.Insert(&models.UserAuth{
+					UserId:     user.Id,
+					AuthModule: authModule,
+					AuthId:     "cn=" + login + ",ou=users,dc=grafana,dc=org",
+					Created:    time.Now(),
+				})
+				return err
+			})
+			require.NoError(t, err)
+		}
+		return user
+	}
+
+	admin := createUser("admin", models.AuthModuleLDAP)
+	torkel := createUser("torkel", models.AuthModuleLDAP)
+	leo := createUser("leo", models.AuthModuleLDAP)
+	carl := createUser("carl", models.AuthModuleLDAP)
+	createUser("local", "")
+	createUser("oauth", "oauth_github")
+
+	mock := &multiLDAPMock{
+		statuses: []*multildap.ServerStatus{{Host: "localhost", Port: 389, Available: true}},
+		users: []*models.ExternalUserInfo{
+			{Login: "Torkel", AuthModule: models.AuthModuleLDAP, OrgRoles: map[int64]models.RoleType{1: models.ROLE_EDITOR}},
+			{Login: "carl", AuthModule: models.AuthModuleLDAP, IsDisabled: true},
+		},
+	}
+	getLDAPConfig = func(*setting.Cfg) (*ldap.Config, error) {
+		return &ldap.Config{Servers: []*ldap.ServerConfig{{Host: "localhost"}}}, nil
+	}
+	newLDAP = func([]*ldap.ServerConfig) multildap.IMultiLDAP {
+		return mock
+	}
+	t.Cleanup(func() {
+		getLDAPConfig = multildap.GetConfig
+		newLDAP = multildap.New
+	})
+
+	var revoked []int64
+	authTokenService := auth.NewFakeUserAuthTokenService()
+	authTokenService.RevokeAllUserTokensProvider = func(ctx context.Context, userId int64) error {
+		revoked = append(revoked, userId)
+		return nil
+	}
+	loginService := &loginServiceMock{}
+
+	s, err := ProvideService(cfg, sqlStore, loginService, authTokenService, nil)
+	require.NoError(t, err)
+
+	result, err := s.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Synced: 1, Disabled: 2}, result)
+
+	require.Len(t, loginService.upserted, 1)
+	assert.Equal(t, torkel.Id, loginService.upserted[0].UserId)
+	assert.Equal(t, models.ROLE_EDITOR, loginService.upserted[0].OrgRoles[1])
+
+	// leo isn't in LDAP anymore, and carl doesn't match any group mapping
+	assert.ElementsMatch(t, []int64{leo.Id, carl.Id}, revoked)
+	for _, user := range []*models.User{leo, carl} {
+		query := &models.GetUserByIdQuery{Id: user.Id}
+		require.NoError(t, sqlStore.GetUserById(context.Background(), query))
+		assert.True(t, query.Result.IsDisabled, user.Login)
+	}
+
+	// the Grafana super admin is never disabled
+	query := &models.GetUserByIdQuery{Id: admin.Id}
+	require.NoError(t, sqlStore.GetUserById(context.Background(), query))
+	assert.False(t, query.Result.IsDisabled)
+
+	t.Run("doesn't disable users if a server is unavailable", func(t *testing.T) {
+		revoked = nil
+		mock.statuses = append(mock.statuses, &multildap.ServerStatus{Host: "ldap2", Port: 389, Error: errors.New("timeout")})
+
+		_, err := s.Sync(context.Background())
+		require.Error(t, err)
+		assert.Empty(t, revoked)
+	})
+}
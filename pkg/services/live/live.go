@@ -44,6 +44,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/pushws"
 	"github.com/grafana/grafana/pkg/services/live/runstream"
 	"github.com/grafana/grafana/pkg/services/live/survey"
+	"github.com/grafana/grafana/pkg/services/ngalert"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
@@ -68,7 +69,8 @@ type CoreGrafanaScope struct {
 func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, routeRegister routing.RouteRegister,
 	pluginStore plugins.Store, cacheService *localcache.CacheService,
 	dataSourceCache datasources.CacheService, sqlStore *sqlstore.SQLStore, secretsService secrets.Service,
-	usageStatsService usagestats.Service, queryDataService *query.Service, toggles featuremgmt.FeatureToggles) (*GrafanaLive, error) {
+	usageStatsService usagestats.Service, queryDataService *query.Service, toggles featuremgmt.FeatureToggles,
+	alertNG *ngalert.AlertNG) (*GrafanaLive, error) {
 	g := &GrafanaLive{
 		Cfg:                   cfg,
 		Features:              toggles,
@@ -153,6 +155,11 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 
 	channelLocalPublisher := liveplugin.NewChannelLocalPublisher(node, nil)
 
+	managedStreamHistoryLimits := managedstream.HistoryLimits{
+		MaxFrames: g.Cfg.LiveManagedStreamHistorySize,
+		MaxAge:    g.Cfg.LiveManagedStreamHistoryMaxAge,
+	}
+
 	var managedStreamRunner *managedstream.Runner
 	if g.IsHA() {
 		redisClient := redis.NewClient(&redis.Options{
@@ -162,16 +169,26 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		if _, err := cmd.Result(); err != nil {
 			return nil, fmt.Errorf("error pinging Redis: %v", err)
 		}
+		var frameHistory managedstream.FrameHistory
+		if managedStreamHistoryLimits.MaxFrames > 0 {
+			frameHistory = managedstream.NewRedisFrameHistory(redisClient, managedStreamHistoryLimits)
+		}
 		managedStreamRunner = managedstream.NewRunner(
 			g.Publish,
 			channelLocalPublisher,
 			managedstream.NewRedisFrameCache(redisClient),
+			frameHistory,
 		)
 	} else {
+		var frameHistory managedstream.FrameHistory
+		if managedStreamHistoryLimits.MaxFrames > 0 {
+			frameHistory = managedstream.NewMemoryFrameHistory(managedStreamHistoryLimits)
+		}
 		managedStreamRunner = managedstream.NewRunner(
 			g.Publish,
 			channelLocalPublisher,
 			managedstream.NewMemoryFrameCache(),
+			frameHistory,
 		)
 	}
 
@@ -186,21 +203,45 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 				ChannelHandlerGetter: g,
 			}
 		} else {
-			storage := &pipeline.FileStorage{
-				DataPath:       cfg.DataPath,
-				SecretsService: g.SecretsService,
+			var storage pipeline.Storage
+			if cfg.LivePipelineStorage == "database" {
+				// The channel rules and write configs are provisioned by the provisioning service.
+				sqlStorage := pipeline.NewSQLStorage(sqlStore, g.SecretsService, pipeline.DefaultSQLStoragePollInterval)
+				g.pipelineSQLStorage = sqlStorage
+				storage = sqlStorage
+			} else {
+				storage = &pipeline.FileStorage{
+					DataPath:       cfg.DataPath,
+					SecretsService: g.SecretsService,
+				}
 			}
 			g.pipelineStorage = storage
+			stateNotifier := pipeline.NewStateNotifier(
+				&pipelineAnnotationSaver{sqlStore: sqlStore},
+				&pipelineAlertSender{alertNG: alertNG},
+			)
 			builder = &pipeline.StorageRuleBuilder{
 				Node:                 node,
 				ManagedStream:        g.ManagedStreamRunner,
 				FrameStorage:         pipeline.NewFrameStorage(),
+				FrameWindowStorage:   pipeline.NewFrameWindowStorage(),
+				StateNotifier:        stateNotifier,
 				Storage:              storage,
 				ChannelHandlerGetter: g,
 				SecretsService:       g.SecretsService,
 			}
 		}
-		channelRuleGetter := pipeline.NewCacheSegmentedTree(builder)
+		g.pipelineInputRunner = pipeline.NewInputRunner(builder)
+		channelRuleGetter := pipeline.NewCacheSegmentedTree(g.pipelineInputRunner)
+		if g.pipelineSQLStorage != nil {
+			// Rebuild the rules of an organization as soon as they are changed, on
+			// this or any other Grafana server instance.
+			g.pipelineSQLStorage.OnChange(func(orgID int64) {
+				if err := channelRuleGetter.Refresh(orgID); err != nil {
+					logger.Error("Error refreshing channel rules", "error", err, "orgId", orgID)
+				}
+			})
+		}
 
 		// Pre-build/validate channel rules for all organizations on start.
 		// This can be unreasonable to have in production scenario with many
@@ -421,6 +462,8 @@ type GrafanaLive struct {
 	ManagedStreamRunner *managedstream.Runner
 	Pipeline            *pipeline.Pipeline
 	pipelineStorage     pipeline.Storage
+	pipelineSQLStorage  *pipeline.SQLStorage
+	pipelineInputRunner *pipeline.InputRunner
 
 	contextGetter    *liveplugin.ContextGetter
 	runStreamManager *runstream.Manager
@@ -465,6 +508,18 @@ func (g *GrafanaLive) Run(ctx context.Context) error {
 		})
 	}
 
+	if g.pipelineSQLStorage != nil {
+		eGroup.Go(func() error {
+			return g.pipelineSQLStorage.Run(eCtx)
+		})
+	}
+
+	if g.pipelineInputRunner != nil && g.Pipeline != nil {
+		eGroup.Go(func() error {
+			return g.pipelineInputRunner.Run(eCtx, g.Pipeline)
+		})
+	}
+
 	return eGroup.Wait()
 }
 
@@ -1131,6 +1186,7 @@ func (g *GrafanaLive) HandlePipelineConvertTestHTTP(c *models.ReqContext) respon
 		Node:                 g.node,
 		ManagedStream:        g.ManagedStreamRunner,
 		FrameStorage:         pipeline.NewFrameStorage(),
+		FrameWindowStorage:   pipeline.NewFrameWindowStorage(),
 		Storage:              storage,
 		ChannelHandlerGetter: g,
 	}
@@ -1194,7 +1250,7 @@ func (g *GrafanaLive) HandleChannelRulesPutHTTP(c *models.ReqContext) response.R
 	}
 	rule, err := g.pipelineStorage.UpdateChannelRule(c.Req.Context(), c.OrgId, cmd)
 	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Failed to update channel rule", err)
+		return pipelineErrorResponse(err, "Failed to update channel rule")
 	}
 	return response.JSON(http.StatusOK, util.DynMap{
 		"rule": rule,
@@ -1217,14 +1273,27 @@ func (g *GrafanaLive) HandleChannelRulesDeleteHTTP(c *models.ReqContext) respons
 	}
 	err = g.pipelineStorage.DeleteChannelRule(c.Req.Context(), c.OrgId, cmd)
 	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Failed to delete channel rule", err)
+		return pipelineErrorResponse(err, "Failed to delete channel rule")
 	}
 	return response.JSON(http.StatusOK, util.DynMap{})
 }
 
+// pipelineErrorResponse returns the response for an error of the pipeline storage.
+func pipelineErrorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, pipeline.ErrChannelRuleNotFound), errors.Is(err, pipeline.ErrWriteConfigNotFound):
+		return response.Error(http.StatusNotFound, message, err)
+	case errors.Is(err, pipeline.ErrVersionMismatch):
+		return response.Error(http.StatusPreconditionFailed, message, err)
+	default:
+		return response.Error(http.StatusInternalServerError, message, err)
+	}
+}
+
 // HandlePipelineEntitiesListHTTP ...
 func (g *GrafanaLive) HandlePipelineEntitiesListHTTP(_ *models.ReqContext) response.Response {
 	return response.JSON(http.StatusOK, util.DynMap{
+		"inputs":          pipeline.InputsRegistry,
 		"subscribers":     pipeline.SubscribersRegistry,
 		"dataOutputs":     pipeline.DataOutputsRegistry,
 		"converters":      pipeline.ConvertersRegistry,
@@ -1292,20 +1361,23 @@ func (g *GrafanaLive) HandleWriteConfigsPutHTTP(c *models.ReqContext) response.R
 		if cmd.SecureSettings == nil {
 			cmd.SecureSettings = map[string]string{}
 		}
-		secureJSONData, err := g.SecretsService.DecryptJsonData(c.Req.Context(), existingBackend.SecureSettings)
-		if err != nil {
-			logger.Error("Error decrypting secure settings", "error", err)
-			return response.Error(http.StatusInternalServerError, "Error decrypting secure settings", err)
-		}
-		for k, v := range secureJSONData {
-			if _, ok := cmd.SecureSettings[k]; !ok {
-				cmd.SecureSettings[k] = v
+		// Secure settings are decrypted one by one, so references to external
+		// secrets are kept as they are instead of being resolved.
+		for k, v := range existingBackend.SecureSettings {
+			if _, ok := cmd.SecureSettings[k]; ok {
+				continue
+			}
+			decrypted, err := g.SecretsService.Decrypt(c.Req.Context(), v)
+			if err != nil {
+				logger.Error("Error decrypting secure settings", "error", err)
+				return response.Error(http.StatusInternalServerError, "Error decrypting secure settings", err)
 			}
+			cmd.SecureSettings[k] = string(decrypted)
 		}
 	}
 	result, err := g.pipelineStorage.UpdateWriteConfig(c.Req.Context(), c.OrgId, cmd)
 	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Failed to update write config", err)
+		return pipelineErrorResponse(err, "Failed to update write config")
 	}
 	return response.JSON(http.StatusOK, util.DynMap{
 		"writeConfig": pipeline.WriteConfigToDto(result),
@@ -1328,7 +1400,7 @@ func (g *GrafanaLive) HandleWriteConfigsDeleteHTTP(c *models.ReqContext) respons
 	}
 	err = g.pipelineStorage.DeleteWriteConfig(c.Req.Context(), c.OrgId, cmd)
 	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Failed to delete write config", err)
+		return pipelineErrorResponse(err, "Failed to delete write config")
 	}
 	return response.JSON(http.StatusOK, util.DynMap{})
 }
@@ -0,0 +1,37 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// FrameHistory keeps the recent frames of managed stream channels, so that
+// subscribers can get them on join.
+type FrameHistory interface {
+	// Add appends a frame to the history of a channel in org.
+	Add(ctx context.Context, orgID int64, channel string, frameJson data.FrameJSONCache, ts time.Time) error
+	// GetFrames returns full JSON frames of a channel in org added after since,
+	// the oldest first.
+	GetFrames(ctx context.Context, orgID int64, channel string, since time.Time) ([]json.RawMessage, error)
+}
+
+// HistoryLimits bounds the history of each channel.
+type HistoryLimits struct {
+	// MaxFrames is the number of frames kept, older frames are dropped.
+	MaxFrames int
+	// MaxAge is how long frames are kept, 0 keeps them until they are dropped
+	// by MaxFrames.
+	MaxAge time.Duration
+}
+
+// since returns the time after which frames are returned for a request of the
+// history after since.
+func (l HistoryLimits) since(since time.Time, now time.Time) time.Time {
+	if l.MaxAge > 0 && since.Before(now.Add(-l.MaxAge)) {
+		return now.Add(-l.MaxAge)
+	}
+	return since
+}
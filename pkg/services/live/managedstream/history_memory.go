@@ -0,0 +1,86 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// MemoryFrameHistory keeps the history of each channel in a ring buffer in
+// memory. Not usable in HA setup.
+type MemoryFrameHistory struct {
+	limits HistoryLimits
+	mu     sync.RWMutex
+	rings  map[string]*frameRing
+}
+
+// NewMemoryFrameHistory ...
+func NewMemoryFrameHistory(limits HistoryLimits) *MemoryFrameHistory {
+	return &MemoryFrameHistory{
+		limits: limits,
+		rings:  map[string]*frameRing{},
+	}
+}
+
+type historyEntry struct {
+	time  time.Time
+	frame json.RawMessage
+}
+
+// frameRing is a ring buffer of frames which grows up to its size.
+type frameRing struct {
+	entries []historyEntry
+	start   int
+}
+
+func (r *frameRing) add(entry historyEntry, size int) {
+	if len(r.entries) < size {
+		r.entries = append(r.entries, entry)
+		return
+	}
+	r.entries[r.start] = entry
+	r.start = (r.start + 1) % len(r.entries)
+}
+
+func (r *frameRing) since(since time.Time) []json.RawMessage {
+	var frames []json.RawMessage
+	for i := 0; i < len(r.entries); i++ {
+		entry := r.entries[(r.start+i)%len(r.entries)]
+		if entry.time.After(since) {
+			frames = append(frames, entry.frame)
+		}
+	}
+	return frames
+}
+
+func (h *MemoryFrameHistory) Add(_ context.Context, orgID int64, channel string, frameJson data.FrameJSONCache, ts time.Time) error {
+	if h.limits.MaxFrames <= 0 {
+		return nil
+	}
+	key := orgchannel.PrependOrgID(orgID, channel)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.rings[key]
+	if !ok {
+		r = &frameRing{}
+		h.rings[key] = r
+	}
+	r.add(historyEntry{time: ts, frame: frameJson.Bytes(data.IncludeAll)}, h.limits.MaxFrames)
+	return nil
+}
+
+func (h *MemoryFrameHistory) GetFrames(_ context.Context, orgID int64, channel string, since time.Time) ([]json.RawMessage, error) {
+	key := orgchannel.PrependOrgID(orgID, channel)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	r, ok := h.rings[key]
+	if !ok {
+		return nil, nil
+	}
+	return r.since(h.limits.since(since, time.Now())), nil
+}
@@ -0,0 +1,64 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testFrameHistory(t *testing.T, h FrameHistory) {
+	ctx := context.Background()
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		frameJsonCache, err := data.FrameToJSONCache(data.NewFrame("test", data.NewField("value", nil, []int64{int64(i)})))
+		require.NoError(t, err)
+		err = h.Add(ctx, 1, "test", frameJsonCache, now.Add(time.Duration(i-4)*time.Second))
+		require.NoError(t, err)
+	}
+
+	values := func(frames []json.RawMessage) []int64 {
+		var result []int64
+		for _, frameJSON := range frames {
+			var f data.Frame
+			require.NoError(t, json.Unmarshal(frameJSON, &f))
+			result = append(result, f.Fields[0].At(0).(int64))
+		}
+		return result
+	}
+
+	// Only the last 3 frames are kept.
+	frames, err := h.GetFrames(ctx, 1, "test", now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 3, 4}, values(frames))
+
+	frames, err = h.GetFrames(ctx, 1, "test", now.Add(-1500*time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, []int64{3, 4}, values(frames))
+
+	// History is kept per org.
+	frames, err = h.GetFrames(ctx, 2, "test", now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, frames, 0)
+}
+
+func TestMemoryFrameHistory(t *testing.T) {
+	h := NewMemoryFrameHistory(HistoryLimits{MaxFrames: 3})
+	testFrameHistory(t, h)
+}
+
+func TestMemoryFrameHistory_MaxAge(t *testing.T) {
+	h := NewMemoryFrameHistory(HistoryLimits{MaxFrames: 10, MaxAge: time.Minute})
+	frameJsonCache, err := data.FrameToJSONCache(data.NewFrame("test"))
+	require.NoError(t, err)
+	require.NoError(t, h.Add(context.Background(), 1, "test", frameJsonCache, time.Now().Add(-2*time.Minute)))
+	require.NoError(t, h.Add(context.Background(), 1, "test", frameJsonCache, time.Now()))
+
+	frames, err := h.GetFrames(context.Background(), 1, "test", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+}
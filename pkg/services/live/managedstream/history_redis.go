@@ -0,0 +1,85 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// RedisFrameHistory keeps the history of each channel in a Redis sorted set
+// scored by the time of the frames, so that it's shared by all Grafana server
+// instances.
+type RedisFrameHistory struct {
+	limits      HistoryLimits
+	redisClient *redis.Client
+}
+
+// NewRedisFrameHistory ...
+func NewRedisFrameHistory(redisClient *redis.Client, limits HistoryLimits) *RedisFrameHistory {
+	return &RedisFrameHistory{
+		limits:      limits,
+		redisClient: redisClient,
+	}
+}
+
+func (h *RedisFrameHistory) Add(ctx context.Context, orgID int64, channel string, frameJson data.FrameJSONCache, ts time.Time) error {
+	if h.limits.MaxFrames <= 0 {
+		return nil
+	}
+	key := getHistoryKey(orgchannel.PrependOrgID(orgID, channel))
+
+	ttl := frameCacheTTL
+	if h.limits.MaxAge > 0 {
+		ttl = h.limits.MaxAge
+	}
+
+	pipe := h.redisClient.TxPipeline()
+	defer func() { _ = pipe.Close() }()
+
+	// Members of a sorted set are unique, the time prefix keeps equal frames.
+	pipe.ZAdd(ctx, key, &redis.Z{
+		Score:  float64(ts.UnixNano() / int64(time.Millisecond)),
+		Member: strconv.FormatInt(ts.UnixNano(), 10) + ":" + string(frameJson.Bytes(data.IncludeAll)),
+	})
+	if h.limits.MaxAge > 0 {
+		maxScore := ts.Add(-h.limits.MaxAge).UnixNano() / int64(time.Millisecond)
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(maxScore, 10))
+	}
+	pipe.ZRemRangeByRank(ctx, key, 0, int64(-h.limits.MaxFrames-1))
+	pipe.Expire(ctx, key, ttl)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (h *RedisFrameHistory) GetFrames(ctx context.Context, orgID int64, channel string, since time.Time) ([]json.RawMessage, error) {
+	key := getHistoryKey(orgchannel.PrependOrgID(orgID, channel))
+	since = h.limits.since(since, time.Now())
+	members, err := h.redisClient.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(since.UnixNano()/int64(time.Millisecond), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	frames := make([]json.RawMessage, 0, len(members))
+	for _, member := range members {
+		i := strings.IndexByte(member, ':')
+		if i < 0 {
+			continue
+		}
+		frames = append(frames, json.RawMessage(member[i+1:]))
+	}
+	return frames, nil
+}
+
+func getHistoryKey(channelID string) string {
+	return "gf_live.managed_stream_history." + channelID
+}
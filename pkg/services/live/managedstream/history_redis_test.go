@@ -0,0 +1,21 @@
+//go:build redis
+// +build redis
+
+package managedstream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisFrameHistory(t *testing.T) {
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	require.NoError(t, redisClient.Del(context.Background(), getHistoryKey("1/test")).Err())
+	h := NewRedisFrameHistory(redisClient, HistoryLimits{MaxFrames: 3})
+	testFrameHistory(t, h)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/orgchannel"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/live"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -41,19 +42,22 @@ type Runner struct {
 	publisher      models.ChannelPublisher
 	localPublisher LocalPublisher
 	frameCache     FrameCache
+	frameHistory   FrameHistory
 }
 
 type LocalPublisher interface {
 	PublishLocal(channel string, data []byte) error
 }
 
-// NewRunner creates new Runner.
-func NewRunner(publisher models.ChannelPublisher, localPublisher LocalPublisher, frameCache FrameCache) *Runner {
+// NewRunner creates new Runner. Frame history is optional, subscribers only get
+// the last frame of a channel without it.
+func NewRunner(publisher models.ChannelPublisher, localPublisher LocalPublisher, frameCache FrameCache, frameHistory FrameHistory) *Runner {
 	return &Runner{
 		publisher:      publisher,
 		localPublisher: localPublisher,
 		streams:        map[int64]map[string]*NamespaceStream{},
 		frameCache:     frameCache,
+		frameHistory:   frameHistory,
 	}
 }
 
@@ -124,7 +128,7 @@ func (r *Runner) GetOrCreateStream(orgID int64, scope string, namespace string)
 	prefix := scope + "/" + namespace
 	s, ok := r.streams[orgID][prefix]
 	if !ok {
-		s = NewNamespaceStream(orgID, scope, namespace, r.publisher, r.localPublisher, r.frameCache)
+		s = NewNamespaceStream(orgID, scope, namespace, r.publisher, r.localPublisher, r.frameCache, r.frameHistory)
 		r.streams[orgID][prefix] = s
 	}
 	return s, nil
@@ -138,6 +142,7 @@ type NamespaceStream struct {
 	publisher      models.ChannelPublisher
 	localPublisher LocalPublisher
 	frameCache     FrameCache
+	frameHistory   FrameHistory
 	rateMu         sync.RWMutex
 	rates          map[string][60]rateEntry
 }
@@ -155,7 +160,7 @@ type ManagedChannel struct {
 }
 
 // NewNamespaceStream creates new NamespaceStream.
-func NewNamespaceStream(orgID int64, scope string, namespace string, publisher models.ChannelPublisher, localPublisher LocalPublisher, schemaUpdater FrameCache) *NamespaceStream {
+func NewNamespaceStream(orgID int64, scope string, namespace string, publisher models.ChannelPublisher, localPublisher LocalPublisher, schemaUpdater FrameCache, frameHistory FrameHistory) *NamespaceStream {
 	return &NamespaceStream{
 		orgID:          orgID,
 		scope:          scope,
@@ -163,12 +168,14 @@ func NewNamespaceStream(orgID int64, scope string, namespace string, publisher m
 		publisher:      publisher,
 		localPublisher: localPublisher,
 		frameCache:     schemaUpdater,
+		frameHistory:   frameHistory,
 		rates:          map[string][60]rateEntry{},
 	}
 }
 
 // Push sends frame to the stream and saves it for later retrieval by subscribers.
 // * Saves the entire frame to cache.
+// * Adds the frame to history if enabled.
 // * If schema has been changed sends entire frame to channel, otherwise only data.
 func (s *NamespaceStream) Push(ctx context.Context, path string, frame *data.Frame) error {
 	jsonFrameCache, err := data.FrameToJSONCache(frame)
@@ -185,6 +192,13 @@ func (s *NamespaceStream) Push(ctx context.Context, path string, frame *data.Fra
 		return err
 	}
 
+	if s.frameHistory != nil {
+		// History is best effort, the frame is still published.
+		if err := s.frameHistory.Add(ctx, s.orgID, channel, jsonFrameCache, time.Now()); err != nil {
+			logger.Error("Error adding frame to managed stream history", "error", err, "channel", channel)
+		}
+	}
+
 	// When the schema has not changed, just send the data.
 	include := data.IncludeDataOnly
 	if isUpdated {
@@ -238,8 +252,38 @@ func (s *NamespaceStream) GetHandlerForPath(_ string) (models.ChannelHandler, er
 	return s, nil
 }
 
+// SubscribeRequest is the data subscribers can send on join.
+type SubscribeRequest struct {
+	// History is how much of the recent stream to get, e.g. 5m. The frames are
+	// merged into a single frame with the schema of the last frame.
+	History string `json:"history,omitempty"`
+}
+
 func (s *NamespaceStream) OnSubscribe(ctx context.Context, u *models.SignedInUser, e models.SubscribeEvent) (models.SubscribeReply, backend.SubscribeStreamStatus, error) {
 	reply := models.SubscribeReply{}
+	if s.frameHistory != nil && len(e.Data) > 0 {
+		var req SubscribeRequest
+		if err := json.Unmarshal(e.Data, &req); err != nil {
+			logger.Debug("Unknown managed stream subscribe request", "error", err, "channel", e.Channel)
+		} else if req.History != "" {
+			history, err := gtime.ParseDuration(req.History)
+			if err != nil {
+				return reply, backend.SubscribeStreamStatusNotFound, nil
+			}
+			frames, err := s.frameHistory.GetFrames(ctx, u.OrgId, e.Channel, time.Now().Add(-history))
+			if err != nil {
+				return reply, 0, err
+			}
+			frameJSON, ok, err := mergeFrames(frames)
+			if err != nil {
+				return reply, 0, err
+			}
+			if ok {
+				reply.Data = frameJSON
+				return reply, backend.SubscribeStreamStatusOK, nil
+			}
+		}
+	}
 	frameJSON, ok, err := s.frameCache.GetFrame(ctx, u.OrgId, e.Channel)
 	if err != nil {
 		return reply, 0, err
@@ -253,3 +297,48 @@ func (s *NamespaceStream) OnSubscribe(ctx context.Context, u *models.SignedInUse
 func (s *NamespaceStream) OnPublish(_ context.Context, _ *models.SignedInUser, _ models.PublishEvent) (models.PublishReply, backend.PublishStreamStatus, error) {
 	return models.PublishReply{}, backend.PublishStreamStatusPermissionDenied, nil
 }
+
+// mergeFrames merges JSON frames into a single frame. Frames with another schema
+// than the last one are skipped.
+func mergeFrames(frames []json.RawMessage) (json.RawMessage, bool, error) {
+	if len(frames) == 0 {
+		return nil, false, nil
+	}
+	decoded := make([]*data.Frame, 0, len(frames))
+	for _, frameJSON := range frames {
+		var frame data.Frame
+		if err := json.Unmarshal(frameJSON, &frame); err != nil {
+			return nil, false, err
+		}
+		decoded = append(decoded, &frame)
+	}
+	last := decoded[len(decoded)-1]
+	merged := last.EmptyCopy()
+	for _, frame := range decoded {
+		if !sameSchema(frame, merged) {
+			continue
+		}
+		for i, field := range frame.Fields {
+			for row := 0; row < field.Len(); row++ {
+				merged.Fields[i].Append(field.CopyAt(row))
+			}
+		}
+	}
+	frameJSON, err := data.FrameToJSON(merged, data.IncludeAll)
+	if err != nil {
+		return nil, false, err
+	}
+	return frameJSON, true, nil
+}
+
+func sameSchema(frame *data.Frame, other *data.Frame) bool {
+	if len(frame.Fields) != len(other.Fields) {
+		return false
+	}
+	for i, field := range frame.Fields {
+		if field.Name != other.Fields[i].Name || field.Type() != other.Fields[i].Type() {
+			return false
+		}
+	}
+	return true
+}
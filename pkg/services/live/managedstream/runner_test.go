@@ -2,10 +2,13 @@ package managedstream
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,13 +22,13 @@ func (p *testPublisher) publish(_ int64, _ string, _ []byte) error {
 
 func TestNewManagedStream(t *testing.T) {
 	publisher := &testPublisher{t: t}
-	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache())
+	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache(), nil)
 	require.NotNil(t, c)
 }
 
 func TestManagedStreamMinuteRate(t *testing.T) {
 	publisher := &testPublisher{t: t}
-	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache())
+	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache(), nil)
 	require.NotNil(t, c)
 
 	c.incRate("test1", time.Now().Unix())
@@ -48,7 +51,7 @@ func TestManagedStreamMinuteRate(t *testing.T) {
 func TestGetManagedStreams(t *testing.T) {
 	publisher := &testPublisher{t: t}
 	frameCache := NewMemoryFrameCache()
-	runner := NewRunner(publisher.publish, nil, frameCache)
+	runner := NewRunner(publisher.publish, nil, frameCache, nil)
 	s1, err := runner.GetOrCreateStream(1, "stream", "test1")
 	require.NoError(t, err)
 	s2, err := runner.GetOrCreateStream(1, "stream", "test2")
@@ -83,3 +86,64 @@ func TestGetManagedStreams(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, managedChannels, 7) // Not affected by other org.
 }
+
+func TestManagedStreamSubscribeHistory(t *testing.T) {
+	publisher := &testPublisher{t: t}
+	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache(), NewMemoryFrameHistory(HistoryLimits{MaxFrames: 10}))
+
+	for i := 0; i < 3; i++ {
+		frame := data.NewFrame("test", data.NewField("value", nil, []float64{float64(i)}))
+		require.NoError(t, c.Push(context.Background(), "cpu", frame))
+	}
+	user := &models.SignedInUser{OrgId: 1}
+
+	// Without a request subscribers only get the last frame.
+	reply, status, err := c.OnSubscribe(context.Background(), user, models.SubscribeEvent{Channel: "stream/a/cpu"})
+	require.NoError(t, err)
+	require.Equal(t, backend.SubscribeStreamStatusOK, status)
+	var frame data.Frame
+	require.NoError(t, json.Unmarshal(reply.Data, &frame))
+	require.Equal(t, 1, frame.Fields[0].Len())
+
+	reply, status, err = c.OnSubscribe(context.Background(), user, models.SubscribeEvent{
+		Channel: "stream/a/cpu",
+		Data:    json.RawMessage(`{"history":"5m"}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, backend.SubscribeStreamStatusOK, status)
+	frame = data.Frame{}
+	require.NoError(t, json.Unmarshal(reply.Data, &frame))
+	require.Equal(t, 3, frame.Fields[0].Len())
+	require.Equal(t, 0.0, frame.Fields[0].At(0))
+	require.Equal(t, 2.0, frame.Fields[0].At(2))
+
+	_, status, err = c.OnSubscribe(context.Background(), user, models.SubscribeEvent{
+		Channel: "stream/a/cpu",
+		Data:    json.RawMessage(`{"history":"five minutes"}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, backend.SubscribeStreamStatusNotFound, status)
+}
+
+func TestMergeFrames(t *testing.T) {
+	frameJSON := func(frame *data.Frame) json.RawMessage {
+		b, err := data.FrameToJSON(frame, data.IncludeAll)
+		require.NoError(t, err)
+		return b
+	}
+	merged, ok, err := mergeFrames([]json.RawMessage{
+		frameJSON(data.NewFrame("test", data.NewField("value", nil, []float64{1}))),
+		frameJSON(data.NewFrame("test", data.NewField("other", nil, []float64{2}))),
+		frameJSON(data.NewFrame("test", data.NewField("value", nil, []float64{3, 4}))),
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	var frame data.Frame
+	require.NoError(t, json.Unmarshal(merged, &frame))
+	require.Equal(t, "value", frame.Fields[0].Name)
+	require.Equal(t, 3, frame.Fields[0].Len())
+
+	_, ok, err = mergeFrames(nil)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
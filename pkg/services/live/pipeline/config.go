@@ -20,6 +20,7 @@ type ChannelAuthConfig struct {
 
 type ChannelRuleSettings struct {
 	Auth            *ChannelAuthConfig      `json:"auth,omitempty"`
+	Inputs          []*InputConfig          `json:"inputs,omitempty"`
 	Subscribers     []*SubscriberConfig     `json:"subscribers,omitempty"`
 	DataOutputters  []*DataOutputterConfig  `json:"dataOutputs,omitempty"`
 	Converter       *ConverterConfig        `json:"converter,omitempty"`
@@ -31,6 +32,9 @@ type ChannelRule struct {
 	OrgId    int64               `json:"-"`
 	Pattern  string              `json:"pattern"`
 	Settings ChannelRuleSettings `json:"settings"`
+	// Version is incremented on every update of the rule. Only set by storages
+	// that support optimistic locking.
+	Version int64 `json:"version,omitempty"`
 }
 
 type ConverterConfig struct {
@@ -49,10 +53,51 @@ type KeepFieldsFrameProcessorConfig struct {
 	FieldNames []string `json:"fieldNames"`
 }
 
+type SetFieldConfig struct {
+	Name   string            `json:"name"`
+	Rename string            `json:"rename,omitempty"`
+	Config *data.FieldConfig `json:"config,omitempty" ts_type:"FieldConfig"`
+}
+
+type SetFieldsFrameProcessorConfig struct {
+	Fields []SetFieldConfig `json:"fields"`
+}
+
+type ExpressionFrameProcessorConfig struct {
+	FieldName string `json:"fieldName"`
+	// Expression is a Goja expression evaluated for each row, the values of
+	// the row are available in x, e.g. x.voltage * x.current.
+	Expression string            `json:"expression"`
+	Type       data.FieldType    `json:"type,omitempty"`
+	Config     *data.FieldConfig `json:"config,omitempty" ts_type:"FieldConfig"`
+}
+
+type AggregateFieldConfig struct {
+	Name     string `json:"name"`
+	Function string `json:"function"`
+}
+
+type AggregateFrameProcessorConfig struct {
+	// Interval is the size of the tumbling window, e.g. 10s.
+	Interval        string                 `json:"interval"`
+	Fields          []AggregateFieldConfig `json:"fields,omitempty"`
+	DefaultFunction string                 `json:"defaultFunction,omitempty"`
+}
+
+type LabelsFrameProcessorConfig struct {
+	// Labels values can reference the parameters of the channel rule pattern
+	// and the channel path, e.g. ${device} or ${path}.
+	Labels []Label `json:"labels"`
+}
+
 type FrameProcessorConfig struct {
 	Type                      string                          `json:"type" ts_type:"Omit<keyof FrameProcessorConfig, 'type'>"`
 	DropFieldsProcessorConfig *DropFieldsFrameProcessorConfig `json:"dropFields,omitempty"`
 	KeepFieldsProcessorConfig *KeepFieldsFrameProcessorConfig `json:"keepFields,omitempty"`
+	SetFieldsProcessorConfig  *SetFieldsFrameProcessorConfig  `json:"setFields,omitempty"`
+	ExpressionProcessorConfig *ExpressionFrameProcessorConfig `json:"expression,omitempty"`
+	AggregateProcessorConfig  *AggregateFrameProcessorConfig  `json:"aggregate,omitempty"`
+	LabelsProcessorConfig     *LabelsFrameProcessorConfig     `json:"labels,omitempty"`
 	MultipleProcessorConfig   *MultipleFrameProcessorConfig   `json:"multiple,omitempty"`
 }
 
@@ -78,6 +123,38 @@ type LokiOutputConfig struct {
 	UID string `json:"uid"`
 }
 
+// MQTTInputConfig subscribes to an MQTT topic. The endpoint of the write config
+// is the broker URL, like tcp://localhost:1883 or ssl://localhost:8883.
+type MQTTInputConfig struct {
+	// UID of the write config with the broker URL and credentials.
+	UID string `json:"uid"`
+	// Topic can contain + and # wildcards.
+	Topic string `json:"topic"`
+	QoS   byte   `json:"qos,omitempty"`
+	// ClientID defaults to a random ID.
+	ClientID string `json:"clientId,omitempty"`
+}
+
+// KafkaInputConfig consumes a Kafka topic. The endpoint of the write config is a
+// comma-separated list of brokers, like localhost:9092. Brokers prefixed with
+// tls:// are connected to with TLS, credentials use SASL/PLAIN.
+type KafkaInputConfig struct {
+	// UID of the write config with the brokers and credentials.
+	UID   string `json:"uid"`
+	Topic string `json:"topic"`
+	// GroupID is the consumer group, so that each message is only consumed by
+	// one Grafana server instance. Defaults to grafana-live.
+	GroupID string `json:"groupId,omitempty"`
+	// StartOffset is where new consumer groups start, "latest" (default) or "earliest".
+	StartOffset string `json:"startOffset,omitempty"`
+}
+
+type InputConfig struct {
+	Type             string            `json:"type" ts_type:"Omit<keyof InputConfig, 'type'>"`
+	MQTTInputConfig  *MQTTInputConfig  `json:"mqtt,omitempty"`
+	KafkaInputConfig *KafkaInputConfig `json:"kafka,omitempty"`
+}
+
 type MultipleSubscriberConfig struct {
 	Subscribers []SubscriberConfig `json:"subscribers"`
 }
@@ -308,11 +308,11 @@ func (f *DevRuleBuilder) BuildRules(_ context.Context, _ int64) ([]*LiveChannelR
 					},
 					0,
 				),
-				NewChangeLogFrameOutput(f.FrameStorage, ChangeLogOutputConfig{
+				NewChangeLogFrameOutput(f.FrameStorage, nil, ChangeLogOutputConfig{
 					FieldName: "value3",
 					Channel:   "stream/json/exact/value3/changes",
 				}),
-				NewChangeLogFrameOutput(f.FrameStorage, ChangeLogOutputConfig{
+				NewChangeLogFrameOutput(f.FrameStorage, nil, ChangeLogOutputConfig{
 					FieldName: "annotation",
 					Channel:   "stream/json/exact/annotation/changes",
 				}),
@@ -326,7 +326,7 @@ func (f *DevRuleBuilder) BuildRules(_ context.Context, _ int64) ([]*LiveChannelR
 						Channel: "stream/json/exact/condition",
 					}),
 				),
-				NewThresholdOutput(f.FrameStorage, ThresholdOutputConfig{
+				NewThresholdOutput(f.FrameStorage, nil, ThresholdOutputConfig{
 					FieldName: "value4",
 					Channel:   "stream/json/exact/value4/state",
 				}),
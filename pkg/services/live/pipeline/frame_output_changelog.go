@@ -9,19 +9,24 @@ import (
 )
 
 type ChangeLogOutputConfig struct {
-	FieldName string `json:"fieldName"`
-	Channel   string `json:"channel"`
+	FieldName  string                 `json:"fieldName"`
+	Channel    string                 `json:"channel"`
+	Annotation *StateAnnotationConfig `json:"annotation,omitempty"`
+	Alert      *StateAlertConfig      `json:"alert,omitempty"`
 }
 
 // ChangeLogFrameOutput can monitor value changes of the specified field and output
-// special change frame to the configured channel.
+// special change frame to the configured channel. With StateNotifier set it can also
+// create annotations and post alerts for changes. Alerts for changes are not resolved
+// explicitly, Alertmanager resolves them after its resolve timeout.
 type ChangeLogFrameOutput struct {
 	frameStorage FrameGetSetter
+	notifier     *StateNotifier
 	config       ChangeLogOutputConfig
 }
 
-func NewChangeLogFrameOutput(frameStorage FrameGetSetter, config ChangeLogOutputConfig) *ChangeLogFrameOutput {
-	return &ChangeLogFrameOutput{frameStorage: frameStorage, config: config}
+func NewChangeLogFrameOutput(frameStorage FrameGetSetter, notifier *StateNotifier, config ChangeLogOutputConfig) *ChangeLogFrameOutput {
+	return &ChangeLogFrameOutput{frameStorage: frameStorage, notifier: notifier, config: config}
 }
 
 const FrameOutputTypeChangeLog = "changeLog"
@@ -30,7 +35,7 @@ func (out *ChangeLogFrameOutput) Type() string {
 	return FrameOutputTypeChangeLog
 }
 
-func (out *ChangeLogFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+func (out *ChangeLogFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
 	previousFrame, previousFrameOK, err := out.frameStorage.Get(vars.OrgID, out.config.Channel)
 	if err != nil {
 		return nil, err
@@ -55,7 +60,8 @@ func (out *ChangeLogFrameOutput) OutputFrame(_ context.Context, vars Vars, frame
 	}
 
 	var previousValue interface{}
-	if previousFrameFieldIndex >= 0 {
+	hasPreviousValue := previousFrameFieldIndex >= 0
+	if hasPreviousValue {
 		// Take last value for the field.
 		previousValue = previousFrame.Fields[previousFrameFieldIndex].At(previousFrame.Fields[previousFrameFieldIndex].Len() - 1)
 	}
@@ -74,10 +80,20 @@ func (out *ChangeLogFrameOutput) OutputFrame(_ context.Context, vars Vars, frame
 				previousValue,
 				currentValue,
 			) {
-				fTime.Append(time.Now())
+				now := time.Now()
+				fTime.Append(now)
 				f1.Append(previousValue)
 				f2.Append(currentValue)
+				if hasPreviousValue {
+					out.notify(ctx, vars, frame.Fields[currentFrameFieldIndex], stateTransition{
+						time:      now,
+						prevState: formatStateValue(previousValue),
+						newState:  formatStateValue(currentValue),
+						value:     formatStateValue(currentValue),
+					})
+				}
 				previousValue = currentValue
+				hasPreviousValue = true
 			}
 		}
 	}
@@ -96,3 +112,16 @@ func (out *ChangeLogFrameOutput) OutputFrame(_ context.Context, vars Vars, frame
 
 	return nil, out.frameStorage.Set(vars.OrgID, out.config.Channel, frame)
 }
+
+func (out *ChangeLogFrameOutput) notify(ctx context.Context, vars Vars, field *data.Field, t stateTransition) {
+	out.notifier.annotate(ctx, vars, field.Name, out.config.Annotation, t)
+	if out.config.Alert == nil {
+		return
+	}
+	labels := alertLabels(vars, field, out.config.Alert)
+	annotations := alertAnnotations(out.config.Alert, map[string]string{
+		"old": t.prevState,
+		"new": t.newState,
+	})
+	out.notifier.sendAlert(vars, labels, annotations, true, true)
+}
@@ -22,7 +22,7 @@ func TestChangeLogOutput_NoPreviousFrame_SingleRow(t *testing.T) {
 
 	mockStorage.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
 
-	outputter := NewChangeLogFrameOutput(mockStorage, ChangeLogOutputConfig{
+	outputter := NewChangeLogFrameOutput(mockStorage, nil, ChangeLogOutputConfig{
 		FieldName: "test",
 		Channel:   "stream/test/no_previous_frame",
 	})
@@ -59,7 +59,7 @@ func TestChangeLogOutput_NoPreviousFrame_MultipleRows(t *testing.T) {
 
 	mockStorage.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
 
-	outputter := NewChangeLogFrameOutput(mockStorage, ChangeLogOutputConfig{
+	outputter := NewChangeLogFrameOutput(mockStorage, nil, ChangeLogOutputConfig{
 		FieldName: "test",
 		Channel:   "stream/test/no_previous_frame",
 	})
@@ -9,8 +9,10 @@ import (
 )
 
 type ThresholdOutputConfig struct {
-	FieldName string `json:"fieldName"`
-	Channel   string `json:"channel"`
+	FieldName  string                 `json:"fieldName"`
+	Channel    string                 `json:"channel"`
+	Annotation *StateAnnotationConfig `json:"annotation,omitempty"`
+	Alert      *StateAlertConfig      `json:"alert,omitempty"`
 }
 
 //go:generate mockgen -destination=frame_output_threshold_mock.go -package=pipeline github.com/grafana/grafana/pkg/services/live/pipeline FrameGetSetter
@@ -21,14 +23,16 @@ type FrameGetSetter interface {
 }
 
 // ThresholdOutput can monitor threshold transitions of the specified field and output
-// special state frame to the configured channel.
+// special state frame to the configured channel. With StateNotifier set it can also
+// create annotations for transitions and post alerts while field is in firing state.
 type ThresholdOutput struct {
 	frameStorage FrameGetSetter
+	notifier     *StateNotifier
 	config       ThresholdOutputConfig
 }
 
-func NewThresholdOutput(frameStorage FrameGetSetter, config ThresholdOutputConfig) *ThresholdOutput {
-	return &ThresholdOutput{frameStorage: frameStorage, config: config}
+func NewThresholdOutput(frameStorage FrameGetSetter, notifier *StateNotifier, config ThresholdOutputConfig) *ThresholdOutput {
+	return &ThresholdOutput{frameStorage: frameStorage, notifier: notifier, config: config}
 }
 
 const FrameOutputTypeThreshold = "threshold"
@@ -37,7 +41,7 @@ func (out *ThresholdOutput) Type() string {
 	return FrameOutputTypeThreshold
 }
 
-func (out *ThresholdOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+func (out *ThresholdOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
 	if frame == nil {
 		return nil, nil
 	}
@@ -113,6 +117,10 @@ func (out *ThresholdOutput) OutputFrame(_ context.Context, vars Vars, frame *dat
 	f3 := data.NewFieldFromFieldType(data.FieldTypeString, 0)
 	f3.Name = "color"
 
+	initialState := previousState
+	var transitions []stateTransition
+	var lastValue float64
+
 	for i := 0; i < frame.Fields[currentFrameFieldIndex].Len(); i++ {
 		// TODO: support other numeric types.
 		value, ok := frame.Fields[currentFrameFieldIndex].At(i).(*float64)
@@ -132,12 +140,26 @@ func (out *ThresholdOutput) OutputFrame(_ context.Context, vars Vars, frame *dat
 			break
 		}
 		if previousState == nil || currentThreshold.State != *previousState {
-			fTime.Append(time.Now())
+			now := time.Now()
+			fTime.Append(now)
 			f1.Append(*value)
 			f2.Append(currentThreshold.State)
 			f3.Append(currentThreshold.Color)
+			if previousState != nil {
+				transitions = append(transitions, stateTransition{
+					time:      now,
+					prevState: *previousState,
+					newState:  currentThreshold.State,
+					value:     formatStateValue(*value),
+				})
+			}
 			previousState = &currentThreshold.State
 		}
+		lastValue = *value
+	}
+
+	if previousState != nil {
+		out.notify(ctx, vars, frame.Fields[currentFrameFieldIndex], initialState, transitions, *previousState, lastValue)
 	}
 
 	if fTime.Len() > 0 {
@@ -154,3 +176,41 @@ func (out *ThresholdOutput) OutputFrame(_ context.Context, vars Vars, frame *dat
 
 	return nil, out.frameStorage.Set(vars.OrgID, out.config.Channel, frame)
 }
+
+func (out *ThresholdOutput) notify(ctx context.Context, vars Vars, field *data.Field, initialState *string, transitions []stateTransition, currentState string, currentValue float64) {
+	for _, t := range transitions {
+		out.notifier.annotate(ctx, vars, field.Name, out.config.Annotation, t)
+	}
+	if out.config.Alert == nil {
+		return
+	}
+	baseState := field.Config.Thresholds.Steps[0].State
+	isFiring := func(state string) bool {
+		if len(out.config.Alert.States) == 0 {
+			return state != baseState
+		}
+		for _, s := range out.config.Alert.States {
+			if s == state {
+				return true
+			}
+		}
+		return false
+	}
+	wasFiring := initialState != nil && isFiring(*initialState)
+	for _, t := range transitions {
+		wasFiring = wasFiring || isFiring(t.newState)
+	}
+	firing := isFiring(currentState)
+	if !firing && !wasFiring {
+		return
+	}
+	labels := alertLabels(vars, field, out.config.Alert)
+	annotations := alertAnnotations(out.config.Alert, map[string]string{
+		"state": currentState,
+		"value": formatStateValue(currentValue),
+	})
+	// Send alert at once when state changed, otherwise firing alert is only re-sent
+	// from time to time.
+	changed := initialState == nil || len(transitions) > 0
+	out.notifier.sendAlert(vars, labels, annotations, firing, changed)
+}
@@ -63,7 +63,7 @@ func TestThresholdOutput_NoPreviousFrame_SingleRow(t *testing.T) {
 
 	mockStorage.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
 
-	outputter := NewThresholdOutput(mockStorage, ThresholdOutputConfig{
+	outputter := NewThresholdOutput(mockStorage, nil, ThresholdOutputConfig{
 		FieldName: "test",
 		Channel:   "stream/test/no_previous_frame",
 	})
@@ -111,7 +111,7 @@ func TestThresholdOutput_NoPreviousFrame_MultipleRows(t *testing.T) {
 
 	mockStorage.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
 
-	outputter := NewThresholdOutput(mockStorage, ThresholdOutputConfig{
+	outputter := NewThresholdOutput(mockStorage, nil, ThresholdOutputConfig{
 		FieldName: "test",
 		Channel:   "stream/test/no_previous_frame",
 	})
@@ -172,7 +172,7 @@ func TestThresholdOutput_WithPreviousFrame_SingleRow(t *testing.T) {
 
 	mockStorage.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
 
-	outputter := NewThresholdOutput(mockStorage, ThresholdOutputConfig{
+	outputter := NewThresholdOutput(mockStorage, nil, ThresholdOutputConfig{
 		FieldName: "test",
 		Channel:   "stream/test/with_previous_frame",
 	})
@@ -220,7 +220,7 @@ func TestThresholdOutput_WithPreviousFrame_MultipleRows(t *testing.T) {
 
 	mockStorage.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
 
-	outputter := NewThresholdOutput(mockStorage, ThresholdOutputConfig{
+	outputter := NewThresholdOutput(mockStorage, nil, ThresholdOutputConfig{
 		FieldName: "test",
 		Channel:   "stream/test/with_previous_frame",
 	})
@@ -0,0 +1,339 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	AggregateFunctionAvg   = "avg"
+	AggregateFunctionMin   = "min"
+	AggregateFunctionMax   = "max"
+	AggregateFunctionSum   = "sum"
+	AggregateFunctionCount = "count"
+	AggregateFunctionFirst = "first"
+	AggregateFunctionLast  = "last"
+)
+
+// Windows not updated for this long after their end are removed.
+const aggregateWindowTTL = 10 * time.Minute
+
+// FrameWindowStorage keeps the windows of aggregate processors in memory, so
+// that they survive rebuilding of channel rules. Not usable in HA setup.
+type FrameWindowStorage struct {
+	mu        sync.Mutex
+	windows   map[string]*aggregateWindow
+	lastPrune time.Time
+}
+
+func NewFrameWindowStorage() *FrameWindowStorage {
+	return &FrameWindowStorage{
+		windows: map[string]*aggregateWindow{},
+	}
+}
+
+// prune must be called with the mutex held.
+func (s *FrameWindowStorage) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < time.Minute {
+		return
+	}
+	s.lastPrune = now
+	for key, w := range s.windows {
+		if now.Sub(w.updated) > w.interval+aggregateWindowTTL {
+			delete(s.windows, key)
+		}
+	}
+}
+
+// AggregateFrameProcessor aggregates the rows of a channel over a tumbling
+// window, like the average temperature per 10 seconds. Rows are collected until
+// a row of the next window arrives, then the aggregated row of the complete window
+// is passed on and the frames in between are dropped. The time of a row is taken
+// from the first time field, or is the time it was processed if the frame has no
+// time field.
+type AggregateFrameProcessor struct {
+	storage         *FrameWindowStorage
+	key             string
+	interval        time.Duration
+	functions       map[string]string
+	defaultFunction string
+	nowFunc         func() time.Time
+}
+
+func NewAggregateFrameProcessor(storage *FrameWindowStorage, config AggregateFrameProcessorConfig) (*AggregateFrameProcessor, error) {
+	if storage == nil {
+		return nil, errors.New("window storage required")
+	}
+	interval, err := time.ParseDuration(config.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid interval: %w", err)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid interval: %s", config.Interval)
+	}
+	defaultFunction := config.DefaultFunction
+	if defaultFunction == "" {
+		defaultFunction = AggregateFunctionLast
+	}
+	if !validAggregateFunction(defaultFunction) {
+		return nil, fmt.Errorf("unknown aggregate function: %s", defaultFunction)
+	}
+	functions := make(map[string]string, len(config.Fields))
+	for _, f := range config.Fields {
+		if !validAggregateFunction(f.Function) {
+			return nil, fmt.Errorf("unknown aggregate function: %s", f.Function)
+		}
+		functions[f.Name] = f.Function
+	}
+	// Windows are kept per processor config, so that changing the config starts
+	// new windows.
+	key, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	return &AggregateFrameProcessor{
+		storage:         storage,
+		key:             string(key),
+		interval:        interval,
+		functions:       functions,
+		defaultFunction: defaultFunction,
+		nowFunc:         time.Now,
+	}, nil
+}
+
+func validAggregateFunction(function string) bool {
+	switch function {
+	case AggregateFunctionAvg, AggregateFunctionMin, AggregateFunctionMax, AggregateFunctionSum,
+		AggregateFunctionCount, AggregateFunctionFirst, AggregateFunctionLast:
+		return true
+	}
+	return false
+}
+
+const FrameProcessorTypeAggregate = "aggregate"
+
+func (p *AggregateFrameProcessor) Type() string {
+	return FrameProcessorTypeAggregate
+}
+
+func (p *AggregateFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+	timeIndex := -1
+	for i, field := range frame.Fields {
+		if field.Type().Time() {
+			timeIndex = i
+			break
+		}
+	}
+	now := p.nowFunc()
+	key := orgchannel.PrependOrgID(vars.OrgID, vars.Channel) + "#" + p.key
+
+	p.storage.mu.Lock()
+	defer p.storage.mu.Unlock()
+
+	w := p.storage.windows[key]
+	var result *data.Frame
+	for i := 0; i < numRows; i++ {
+		rowTime := now
+		if timeIndex >= 0 {
+			if v, ok := frame.Fields[timeIndex].ConcreteAt(i); ok {
+				rowTime = v.(time.Time)
+			}
+		}
+		start := rowTime.Truncate(p.interval)
+		if w != nil && !w.matches(frame) {
+			logger.Debug("Frame schema changed, dropping aggregate window", "channel", vars.Channel)
+			w = nil
+		}
+		if w != nil && start.After(w.start) {
+			if result == nil {
+				result = w.newFrame()
+			}
+			w.appendTo(result)
+			w = nil
+		}
+		if w == nil {
+			w = p.newWindow(frame, start)
+		}
+		w.add(frame, i)
+	}
+	if w != nil {
+		w.updated = now
+		p.storage.windows[key] = w
+	}
+	p.storage.prune(now)
+	return result, nil
+}
+
+func (p *AggregateFrameProcessor) newWindow(frame *data.Frame, start time.Time) *aggregateWindow {
+	w := &aggregateWindow{
+		name:     frame.Name,
+		start:    start,
+		interval: p.interval,
+		fields:   make([]*aggregateField, 0, len(frame.Fields)),
+	}
+	timeFound := false
+	for _, field := range frame.Fields {
+		function, ok := p.functions[field.Name]
+		if !ok {
+			function = p.defaultFunction
+		}
+		isTime := !timeFound && field.Type().Time()
+		if isTime {
+			timeFound = true
+		}
+		// Only numeric fields can be aggregated with numeric functions.
+		if !field.Type().Numeric() && function != AggregateFunctionFirst && function != AggregateFunctionCount {
+			function = AggregateFunctionLast
+		}
+		w.fields = append(w.fields, &aggregateField{
+			name:      field.Name,
+			fieldType: field.Type(),
+			labels:    field.Labels,
+			config:    field.Config,
+			isTime:    isTime,
+			function:  function,
+			min:       math.Inf(1),
+			max:       math.Inf(-1),
+		})
+	}
+	return w
+}
+
+type aggregateWindow struct {
+	name     string
+	start    time.Time
+	interval time.Duration
+	updated  time.Time
+	fields   []*aggregateField
+}
+
+type aggregateField struct {
+	name      string
+	fieldType data.FieldType
+	labels    data.Labels
+	config    *data.FieldConfig
+	isTime    bool
+	function  string
+
+	count    int
+	sum      float64
+	min      float64
+	max      float64
+	hasFirst bool
+	first    interface{}
+	last     interface{}
+}
+
+func (w *aggregateWindow) matches(frame *data.Frame) bool {
+	if len(frame.Fields) != len(w.fields) {
+		return false
+	}
+	for i, field := range frame.Fields {
+		if field.Name != w.fields[i].name || field.Type() != w.fields[i].fieldType {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *aggregateWindow) add(frame *data.Frame, row int) {
+	for i, field := range frame.Fields {
+		f := w.fields[i]
+		if f.isTime {
+			continue
+		}
+		value := field.CopyAt(row)
+		if !f.hasFirst {
+			f.first = value
+			f.hasFirst = true
+		}
+		f.last = value
+		if !field.Type().Numeric() {
+			if _, ok := field.ConcreteAt(row); ok {
+				f.count++
+			}
+			continue
+		}
+		v, err := field.NullableFloatAt(row)
+		if err != nil || v == nil || math.IsNaN(*v) {
+			continue
+		}
+		f.count++
+		f.sum += *v
+		f.min = math.Min(f.min, *v)
+		f.max = math.Max(f.max, *v)
+	}
+}
+
+func (w *aggregateWindow) newFrame() *data.Frame {
+	fields := make([]*data.Field, 0, len(w.fields))
+	for _, f := range w.fields {
+		fieldType := f.fieldType
+		switch f.function {
+		case AggregateFunctionAvg, AggregateFunctionMin, AggregateFunctionMax, AggregateFunctionSum, AggregateFunctionCount:
+			if !f.isTime {
+				fieldType = data.FieldTypeNullableFloat64
+			}
+		}
+		field := data.NewFieldFromFieldType(fieldType, 0)
+		field.Name = f.name
+		field.Labels = f.labels
+		field.Config = f.config
+		fields = append(fields, field)
+	}
+	return data.NewFrame(w.name, fields...)
+}
+
+func (w *aggregateWindow) appendTo(frame *data.Frame) {
+	for i, f := range w.fields {
+		field := frame.Fields[i]
+		if f.isTime {
+			if f.fieldType == data.FieldTypeNullableTime {
+				start := w.start
+				field.Append(&start)
+			} else {
+				field.Append(w.start)
+			}
+			continue
+		}
+		switch f.function {
+		case AggregateFunctionFirst:
+			field.Append(f.first)
+		case AggregateFunctionLast:
+			field.Append(f.last)
+		case AggregateFunctionCount:
+			count := float64(f.count)
+			field.Append(&count)
+		default:
+			if f.count == 0 {
+				field.Append((*float64)(nil))
+				continue
+			}
+			var v float64
+			switch f.function {
+			case AggregateFunctionAvg:
+				v = f.sum / float64(f.count)
+			case AggregateFunctionMin:
+				v = f.min
+			case AggregateFunctionMax:
+				v = f.max
+			case AggregateFunctionSum:
+				v = f.sum
+			}
+			field.Append(&v)
+		}
+	}
+}
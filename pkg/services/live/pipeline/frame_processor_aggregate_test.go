@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func aggregateTestFrame(ts []time.Time, values []float64, status []string) *data.Frame {
+	return data.NewFrame("test",
+		data.NewField("time", nil, ts),
+		data.NewField("value", nil, values),
+		data.NewField("status", nil, status),
+	)
+}
+
+func TestAggregateFrameProcessor(t *testing.T) {
+	storage := NewFrameWindowStorage()
+	processor, err := NewAggregateFrameProcessor(storage, AggregateFrameProcessorConfig{
+		Interval: "10s",
+		Fields:   []AggregateFieldConfig{{Name: "value", Function: AggregateFunctionAvg}},
+	})
+	require.NoError(t, err)
+
+	start := time.Unix(1000, 0)
+	vars := Vars{OrgID: 1, Channel: "stream/test/agg"}
+
+	// Rows of the first window are collected.
+	frame, err := processor.ProcessFrame(context.Background(), vars, aggregateTestFrame(
+		[]time.Time{start.Add(time.Second), start.Add(2 * time.Second)}, []float64{1, 2}, []string{"a", "b"},
+	))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+
+	// Processors are rebuilt with the channel rules, windows must survive it.
+	processor, err = NewAggregateFrameProcessor(storage, AggregateFrameProcessorConfig{
+		Interval: "10s",
+		Fields:   []AggregateFieldConfig{{Name: "value", Function: AggregateFunctionAvg}},
+	})
+	require.NoError(t, err)
+	frame, err = processor.ProcessFrame(context.Background(), vars, aggregateTestFrame(
+		[]time.Time{start.Add(3 * time.Second)}, []float64{6}, []string{"c"},
+	))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+
+	// A row of the next window completes the first one.
+	frame, err = processor.ProcessFrame(context.Background(), vars, aggregateTestFrame(
+		[]time.Time{start.Add(11 * time.Second), start.Add(21 * time.Second)}, []float64{10, 20}, []string{"d", "e"},
+	))
+	require.NoError(t, err)
+	require.NotNil(t, frame)
+	rows, err := frame.RowLen()
+	require.NoError(t, err)
+	require.Equal(t, 2, rows)
+	require.Equal(t, start.Truncate(10*time.Second), frame.Fields[0].At(0))
+	require.Equal(t, 3.0, *frame.Fields[1].At(0).(*float64))
+	require.Equal(t, "c", frame.Fields[2].At(0))
+	require.Equal(t, 10.0, *frame.Fields[1].At(1).(*float64))
+	require.Equal(t, "d", frame.Fields[2].At(1))
+
+	// Windows are kept per channel.
+	frame, err = processor.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/other"}, aggregateTestFrame(
+		[]time.Time{start.Add(31 * time.Second)}, []float64{1}, []string{"f"},
+	))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+}
+
+func TestNewAggregateFrameProcessor_InvalidConfig(t *testing.T) {
+	storage := NewFrameWindowStorage()
+	_, err := NewAggregateFrameProcessor(storage, AggregateFrameProcessorConfig{Interval: "0s"})
+	require.Error(t, err)
+	_, err = NewAggregateFrameProcessor(storage, AggregateFrameProcessorConfig{Interval: "10s", DefaultFunction: "median"})
+	require.Error(t, err)
+	_, err = NewAggregateFrameProcessor(storage, AggregateFrameProcessorConfig{
+		Interval: "10s",
+		Fields:   []AggregateFieldConfig{{Name: "value", Function: "p99"}},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// ExpressionFrameProcessor adds a field to a data.Frame computed from the other
+// fields of each row with a Goja expression. Time values are passed to the
+// expression as milliseconds since epoch.
+type ExpressionFrameProcessor struct {
+	config    ExpressionFrameProcessorConfig
+	fieldType data.FieldType
+	program   *goja.Program
+}
+
+func NewExpressionFrameProcessor(config ExpressionFrameProcessorConfig) (*ExpressionFrameProcessor, error) {
+	if config.FieldName == "" {
+		return nil, fmt.Errorf("field name required")
+	}
+	fieldType := config.Type
+	switch fieldType {
+	case data.FieldTypeUnknown:
+		fieldType = data.FieldTypeNullableFloat64
+	case data.FieldTypeNullableFloat64, data.FieldTypeNullableString, data.FieldTypeNullableBool:
+	default:
+		return nil, fmt.Errorf("unsupported field type: %s", fieldType)
+	}
+	// All rows are evaluated in a single run, so that the script timeout applies
+	// to the whole frame.
+	program, err := goja.Compile("", fmt.Sprintf("__rows.map(function(x) { return (%s); })", config.Expression), true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %w", err)
+	}
+	return &ExpressionFrameProcessor{config: config, fieldType: fieldType, program: program}, nil
+}
+
+const FrameProcessorTypeExpression = "expression"
+
+func (p *ExpressionFrameProcessor) Type() string {
+	return FrameProcessorTypeExpression
+}
+
+func (p *ExpressionFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]interface{}, numRows)
+	for i := 0; i < numRows; i++ {
+		row := make(map[string]interface{}, len(frame.Fields))
+		for _, field := range frame.Fields {
+			v, ok := field.ConcreteAt(i)
+			if !ok {
+				row[field.Name] = nil
+				continue
+			}
+			if t, ok := v.(time.Time); ok {
+				v = t.UnixNano() / int64(time.Millisecond)
+			}
+			row[field.Name] = v
+		}
+		rows[i] = row
+	}
+
+	r := newGojaRuntime()
+	if err := r.vm.Set("__rows", rows); err != nil {
+		return nil, err
+	}
+	v, err := r.runProgram(p.program)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := v.Export().([]interface{})
+	if !ok || len(values) != numRows {
+		return nil, fmt.Errorf("unexpected expression result: %T", v.Export())
+	}
+
+	field := data.NewFieldFromFieldType(p.fieldType, numRows)
+	field.Name = p.config.FieldName
+	field.Config = p.config.Config
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		switch p.fieldType {
+		case data.FieldTypeNullableFloat64:
+			switch v := value.(type) {
+			case float64:
+				field.SetConcrete(i, v)
+			case int64:
+				field.SetConcrete(i, float64(v))
+			default:
+				return nil, fmt.Errorf("unexpected expression result: %v (%T)", value, value)
+			}
+		case data.FieldTypeNullableString:
+			field.SetConcrete(i, fmt.Sprintf("%v", value))
+		case data.FieldTypeNullableBool:
+			b, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("unexpected expression result: %v (%T)", value, value)
+			}
+			field.SetConcrete(i, b)
+		}
+	}
+
+	for i, f := range frame.Fields {
+		if f.Name == field.Name {
+			frame.Fields[i] = field
+			return frame, nil
+		}
+	}
+	frame.Fields = append(frame.Fields, field)
+	return frame, nil
+}
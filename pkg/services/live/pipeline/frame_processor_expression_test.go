@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpressionFrameProcessor(t *testing.T) {
+	processor, err := NewExpressionFrameProcessor(ExpressionFrameProcessorConfig{
+		FieldName:  "power",
+		Expression: "x.voltage * x.current",
+		Config:     &data.FieldConfig{Unit: "watt"},
+	})
+	require.NoError(t, err)
+
+	current := 2.0
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Unix(1, 0), time.Unix(2, 0)}),
+		data.NewField("voltage", nil, []float64{230, 231}),
+		data.NewField("current", nil, []*float64{&current, nil}),
+	)
+	frame, err = processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 4)
+	power := frame.Fields[3]
+	require.Equal(t, "power", power.Name)
+	require.Equal(t, "watt", power.Config.Unit)
+	require.Equal(t, 460.0, *power.At(0).(*float64))
+	// null is converted to 0 by the multiplication.
+	require.Equal(t, 0.0, *power.At(1).(*float64))
+
+	t.Run("time values are milliseconds", func(t *testing.T) {
+		processor, err := NewExpressionFrameProcessor(ExpressionFrameProcessorConfig{
+			FieldName:  "late",
+			Expression: "x.time > 1500",
+			Type:       data.FieldTypeNullableBool,
+		})
+		require.NoError(t, err)
+		frame, err := processor.ProcessFrame(context.Background(), Vars{}, frame)
+		require.NoError(t, err)
+		late := frame.Fields[len(frame.Fields)-1]
+		require.False(t, *late.At(0).(*bool))
+		require.True(t, *late.At(1).(*bool))
+	})
+
+	t.Run("invalid expression", func(t *testing.T) {
+		_, err := NewExpressionFrameProcessor(ExpressionFrameProcessorConfig{FieldName: "x", Expression: "x.voltage *"})
+		require.Error(t, err)
+	})
+
+	t.Run("unexpected result type", func(t *testing.T) {
+		processor, err := NewExpressionFrameProcessor(ExpressionFrameProcessorConfig{FieldName: "x", Expression: "'text'"})
+		require.NoError(t, err)
+		_, err = processor.ProcessFrame(context.Background(), Vars{}, frame)
+		require.Error(t, err)
+	})
+}
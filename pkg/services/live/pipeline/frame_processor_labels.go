@@ -0,0 +1,70 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline/tree"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// LabelsFrameProcessor adds labels to the non-time fields of a data.Frame. Label
+// values can reference the parameters of the channel rule pattern, so that a rule
+// for stream/iot/:device can label the fields with the device of the channel.
+type LabelsFrameProcessor struct {
+	config LabelsFrameProcessorConfig
+	tree   *tree.Node
+}
+
+func NewLabelsFrameProcessor(pattern string, config LabelsFrameProcessorConfig) *LabelsFrameProcessor {
+	t := tree.New()
+	t.AddRoute("/"+pattern, config)
+	return &LabelsFrameProcessor{config: config, tree: t}
+}
+
+const FrameProcessorTypeLabels = "labels"
+
+func (p *LabelsFrameProcessor) Type() string {
+	return FrameProcessorTypeLabels
+}
+
+func (p *LabelsFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	var params tree.Params
+	if nodeValue := p.tree.GetValue("/"+vars.Channel, true); nodeValue.Params != nil {
+		params = *nodeValue.Params
+	}
+	mapping := func(name string) string {
+		if v, ok := params.Get(name); ok {
+			// Values of catch-all parameters start with a slash.
+			return strings.TrimPrefix(v, "/")
+		}
+		switch name {
+		case "scope":
+			return vars.Scope
+		case "namespace":
+			return vars.Namespace
+		case "path":
+			return vars.Path
+		}
+		return ""
+	}
+
+	labels := make(data.Labels, len(p.config.Labels))
+	for _, label := range p.config.Labels {
+		labels[label.Name] = os.Expand(label.Value, mapping)
+	}
+	for _, field := range frame.Fields {
+		if field.Type().Time() {
+			continue
+		}
+		if field.Labels == nil {
+			field.Labels = data.Labels{}
+		}
+		for k, v := range labels {
+			field.Labels[k] = v
+		}
+	}
+	return frame, nil
+}
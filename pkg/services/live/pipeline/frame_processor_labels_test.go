@@ -0,0 +1,29 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelsFrameProcessor(t *testing.T) {
+	processor := NewLabelsFrameProcessor("stream/iot/:device/*rest", LabelsFrameProcessorConfig{
+		Labels: []Label{
+			{Name: "device", Value: "${device}"},
+			{Name: "sensor", Value: "sensor-${rest}"},
+			{Name: "namespace", Value: "${namespace}"},
+		},
+	})
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Unix(1, 0)}),
+		data.NewField("value", data.Labels{"unit": "c"}, []float64{1}),
+	)
+	vars := Vars{Channel: "stream/iot/dev1/temp/1", Scope: "stream", Namespace: "iot", Path: "dev1/temp/1"}
+	frame, err := processor.ProcessFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+	require.Nil(t, frame.Fields[0].Labels)
+	require.Equal(t, data.Labels{"unit": "c", "device": "dev1", "sensor": "sensor-temp/1", "namespace": "iot"}, frame.Fields[1].Labels)
+}
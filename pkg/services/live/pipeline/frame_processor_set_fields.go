@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// SetFieldsFrameProcessor can rename fields of a data.Frame and set their
+// config, like units or display names.
+type SetFieldsFrameProcessor struct {
+	config SetFieldsFrameProcessorConfig
+}
+
+func NewSetFieldsFrameProcessor(config SetFieldsFrameProcessorConfig) *SetFieldsFrameProcessor {
+	return &SetFieldsFrameProcessor{config: config}
+}
+
+const FrameProcessorTypeSetFields = "setFields"
+
+func (p *SetFieldsFrameProcessor) Type() string {
+	return FrameProcessorTypeSetFields
+}
+
+func (p *SetFieldsFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	for _, c := range p.config.Fields {
+		for _, field := range frame.Fields {
+			if field.Name != c.Name {
+				continue
+			}
+			if c.Config != nil {
+				config, err := mergeFieldConfig(field.Config, c.Config)
+				if err != nil {
+					return nil, err
+				}
+				field.Config = config
+			}
+			if c.Rename != "" {
+				field.Name = c.Rename
+			}
+		}
+	}
+	return frame, nil
+}
+
+// mergeFieldConfig returns a copy of the field config with the non-empty
+// properties of the other config set.
+func mergeFieldConfig(config *data.FieldConfig, other *data.FieldConfig) (*data.FieldConfig, error) {
+	merged := &data.FieldConfig{}
+	if config != nil {
+		b, err := json.Marshal(config)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, merged); err != nil {
+			return nil, err
+		}
+	}
+	b, err := json.Marshal(other)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
@@ -0,0 +1,21 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFieldsFrameProcessor(t *testing.T) {
+	processor := NewSetFieldsFrameProcessor(SetFieldsFrameProcessorConfig{
+		Fields: []SetFieldConfig{{Name: "temp", Rename: "temperature", Config: &data.FieldConfig{Unit: "celsius"}}},
+	})
+	field := data.NewField("temp", nil, []float64{1})
+	field.Config = &data.FieldConfig{DisplayName: "Temperature"}
+	frame, err := processor.ProcessFrame(context.Background(), Vars{}, data.NewFrame("test", field))
+	require.NoError(t, err)
+	require.Equal(t, "temperature", frame.Fields[0].Name)
+	require.Equal(t, &data.FieldConfig{DisplayName: "Temperature", Unit: "celsius"}, frame.Fields[0].Config)
+}
@@ -9,11 +9,15 @@ import (
 	"github.com/dop251/goja/parser"
 )
 
-func getRuntime(payload []byte) (*gojaRuntime, error) {
+func newGojaRuntime() *gojaRuntime {
 	vm := goja.New()
 	vm.SetMaxCallStackSize(64)
 	vm.SetParserOptions(parser.WithDisableSourceMaps)
-	r := &gojaRuntime{vm}
+	return &gojaRuntime{vm}
+}
+
+func getRuntime(payload []byte) (*gojaRuntime, error) {
+	r := newGojaRuntime()
 	err := r.init(payload)
 	if err != nil {
 		return nil, err
@@ -36,6 +40,18 @@ func (r *gojaRuntime) init(payload []byte) error {
 }
 
 func (r *gojaRuntime) runString(script string) (goja.Value, error) {
+	defer r.interruptAfterTimeout()()
+	return r.vm.RunString(script)
+}
+
+func (r *gojaRuntime) runProgram(program *goja.Program) (goja.Value, error) {
+	defer r.interruptAfterTimeout()()
+	return r.vm.RunProgram(program)
+}
+
+// interruptAfterTimeout interrupts the running script after a timeout, the
+// returned function must be called once the script is done.
+func (r *gojaRuntime) interruptAfterTimeout() func() {
 	doneCh := make(chan struct{})
 	go func() {
 		select {
@@ -51,8 +67,7 @@ func (r *gojaRuntime) runString(script string) (goja.Value, error) {
 			r.vm.Interrupt(errors.New("timeout"))
 		}
 	}()
-	defer close(doneCh)
-	return r.vm.RunString(script)
+	return func() { close(doneCh) }
 }
 
 func (r *gojaRuntime) getBool(script string) (bool, error) {
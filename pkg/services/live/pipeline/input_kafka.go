@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+const InputTypeKafka = "kafka"
+
+const defaultKafkaGroupID = "grafana-live"
+
+// KafkaInput consumes a Kafka topic as a member of a consumer group.
+type KafkaInput struct {
+	brokers     []string
+	tls         bool
+	topic       string
+	groupID     string
+	startOffset int64
+	username    string
+	password    string
+}
+
+func NewKafkaInput(endpoint string, basicAuth *BasicAuth, config KafkaInputConfig) (*KafkaInput, error) {
+	i := &KafkaInput{
+		topic:   config.Topic,
+		groupID: config.GroupID,
+	}
+	for _, broker := range strings.Split(endpoint, ",") {
+		broker = strings.TrimSpace(broker)
+		if strings.HasPrefix(broker, "tls://") {
+			broker = strings.TrimPrefix(broker, "tls://")
+			i.tls = true
+		}
+		if broker != "" {
+			i.brokers = append(i.brokers, broker)
+		}
+	}
+	if len(i.brokers) == 0 {
+		return nil, errors.New("brokers required")
+	}
+	if i.topic == "" {
+		return nil, errors.New("topic required")
+	}
+	if i.groupID == "" {
+		i.groupID = defaultKafkaGroupID
+	}
+	switch config.StartOffset {
+	case "", "latest":
+		i.startOffset = kafka.LastOffset
+	case "earliest":
+		i.startOffset = kafka.FirstOffset
+	default:
+		return nil, fmt.Errorf("invalid start offset: %s", config.StartOffset)
+	}
+	if basicAuth != nil {
+		i.username = basicAuth.User
+		i.password = basicAuth.Password
+	}
+	return i, nil
+}
+
+func (i *KafkaInput) Type() string {
+	return InputTypeKafka
+}
+
+func (i *KafkaInput) Key() string {
+	return fmt.Sprintf("%s|%s|%t|%s|%s|%d|%s|%x", InputTypeKafka, strings.Join(i.brokers, ","), i.tls, i.topic, i.groupID, i.startOffset, i.username, sha256.Sum256([]byte(i.password)))
+}
+
+func (i *KafkaInput) Run(ctx context.Context, handler InputHandler) error {
+	dialer := &kafka.Dialer{
+		Timeout:   10 * time.Second,
+		DualStack: true,
+	}
+	if i.tls {
+		dialer.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if i.username != "" {
+		dialer.SASLMechanism = plain.Mechanism{Username: i.username, Password: i.password}
+	}
+
+	config := kafka.ReaderConfig{
+		Brokers:        i.brokers,
+		GroupID:        i.groupID,
+		Topic:          i.topic,
+		Dialer:         dialer,
+		StartOffset:    i.startOffset,
+		MaxWait:        time.Second,
+		CommitInterval: time.Second,
+		ErrorLogger: kafka.LoggerFunc(func(msg string, args ...interface{}) {
+			logger.Warn("Kafka reader error", "error", fmt.Sprintf(msg, args...), "topic", i.topic)
+		}),
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	reader := kafka.NewReader(config)
+	defer func() {
+		if err := reader.Close(); err != nil {
+			logger.Warn("Error closing Kafka reader", "error", err, "topic", i.topic)
+		}
+	}()
+
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return err
+		}
+		handler(ctx, msg.Topic, msg.Value)
+	}
+}
@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const InputTypeMQTT = "mqtt"
+
+const mqttConnectTimeout = 30 * time.Second
+
+// MQTTInput subscribes to an MQTT topic. Lost connections are re-established
+// automatically, and the topic is subscribed to again.
+type MQTTInput struct {
+	broker   string
+	topic    string
+	qos      byte
+	clientID string
+	username string
+	password string
+}
+
+func NewMQTTInput(broker string, basicAuth *BasicAuth, config MQTTInputConfig) (*MQTTInput, error) {
+	if broker == "" {
+		return nil, errors.New("broker URL required")
+	}
+	if config.Topic == "" {
+		return nil, errors.New("topic required")
+	}
+	if config.QoS > 2 {
+		return nil, fmt.Errorf("invalid QoS: %d", config.QoS)
+	}
+	i := &MQTTInput{
+		broker:   broker,
+		topic:    config.Topic,
+		qos:      config.QoS,
+		clientID: config.ClientID,
+	}
+	if basicAuth != nil {
+		i.username = basicAuth.User
+		i.password = basicAuth.Password
+	}
+	return i, nil
+}
+
+func (i *MQTTInput) Type() string {
+	return InputTypeMQTT
+}
+
+func (i *MQTTInput) Key() string {
+	return fmt.Sprintf("%s|%s|%s|%d|%s|%s|%x", InputTypeMQTT, i.broker, i.topic, i.qos, i.clientID, i.username, sha256.Sum256([]byte(i.password)))
+}
+
+func (i *MQTTInput) Run(ctx context.Context, handler InputHandler) error {
+	clientID := i.clientID
+	if clientID == "" {
+		clientID = "grafana-live-" + util.GenerateShortUID()
+	}
+
+	subscribeErr := make(chan error, 1)
+	opts := mqtt.NewClientOptions().
+		AddBroker(i.broker).
+		SetClientID(clientID).
+		SetUsername(i.username).
+		SetPassword(i.password).
+		SetConnectTimeout(mqttConnectTimeout).
+		SetAutoReconnect(true).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			logger.Warn("MQTT connection lost", "error", err, "broker", i.broker)
+		}).
+		SetOnConnectHandler(func(c mqtt.Client) {
+			// Subscribe on every connect, since subscriptions are lost on reconnect
+			// with a clean session.
+			token := c.Subscribe(i.topic, i.qos, func(_ mqtt.Client, msg mqtt.Message) {
+				handler(ctx, msg.Topic(), msg.Payload())
+			})
+			if token.WaitTimeout(mqttConnectTimeout) && token.Error() == nil {
+				return
+			}
+			err := token.Error()
+			if err == nil {
+				err = errors.New("timeout")
+			}
+			select {
+			case subscribeErr <- fmt.Errorf("can't subscribe to %s: %w", i.topic, err):
+			default:
+			}
+		})
+
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(mqttConnectTimeout) {
+		return fmt.Errorf("timeout connecting to %s", i.broker)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("can't connect to %s: %w", i.broker, err)
+	}
+	defer client.Disconnect(250)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-subscribeErr:
+		return err
+	}
+}
@@ -0,0 +1,175 @@
+package pipeline
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	inputMinBackoff = time.Second
+	inputMaxBackoff = time.Minute
+)
+
+// InputProcessor processes the messages of inputs, it's implemented by Pipeline.
+type InputProcessor interface {
+	ProcessInput(ctx context.Context, orgID int64, channelID string, body []byte) (bool, error)
+}
+
+// InputRunner runs the inputs of channel rules. It wraps a RuleBuilder, so that
+// inputs are started, restarted or stopped whenever the rules of an organization
+// are built.
+type InputRunner struct {
+	builder RuleBuilder
+
+	mu        sync.Mutex
+	ctx       context.Context
+	processor InputProcessor
+	inputs    map[int64]map[string]*runningInput
+}
+
+type runningInput struct {
+	orgID   int64
+	pattern string
+	input   Input
+	cancel  context.CancelFunc
+}
+
+func NewInputRunner(builder RuleBuilder) *InputRunner {
+	return &InputRunner{
+		builder: builder,
+		inputs:  map[int64]map[string]*runningInput{},
+	}
+}
+
+func (r *InputRunner) BuildRules(ctx context.Context, orgID int64) ([]*LiveChannelRule, error) {
+	rules, err := r.builder.BuildRules(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	r.update(orgID, rules)
+	return rules, nil
+}
+
+// update stops the inputs of the organization which are not in the rules anymore
+// and starts the new ones.
+func (r *InputRunner) update(orgID int64, rules []*LiveChannelRule) {
+	inputs := map[string]*runningInput{}
+	for _, rule := range rules {
+		for _, input := range rule.Inputs {
+			inputs[rule.Pattern+"#"+input.Key()] = &runningInput{
+				orgID:   orgID,
+				pattern: rule.Pattern,
+				input:   input,
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, ri := range r.inputs[orgID] {
+		if _, ok := inputs[key]; ok {
+			inputs[key] = ri
+		} else if ri.cancel != nil {
+			ri.cancel()
+		}
+	}
+	for _, ri := range inputs {
+		if ri.cancel == nil && r.ctx != nil {
+			r.start(ri)
+		}
+	}
+	if len(inputs) == 0 {
+		delete(r.inputs, orgID)
+	} else {
+		r.inputs[orgID] = inputs
+	}
+}
+
+// Run starts the inputs of the rules built so far, and the ones built later on,
+// until the context is canceled.
+func (r *InputRunner) Run(ctx context.Context, processor InputProcessor) error {
+	r.mu.Lock()
+	r.ctx = ctx
+	r.processor = processor
+	for _, inputs := range r.inputs {
+		for _, ri := range inputs {
+			r.start(ri)
+		}
+	}
+	r.mu.Unlock()
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// start must be called with the mutex held.
+func (r *InputRunner) start(ri *runningInput) {
+	ctx, cancel := context.WithCancel(r.ctx)
+	ri.cancel = cancel
+	go runInput(ctx, ri, r.processor)
+}
+
+// runInput runs the input until the context is canceled, restarting it with a
+// backoff if it fails.
+func runInput(ctx context.Context, ri *runningInput, processor InputProcessor) {
+	channel, catchAll, _ := parseInputPattern(ri.pattern)
+	handler := func(ctx context.Context, topic string, data []byte) {
+		channelID := channel
+		if catchAll {
+			channelID = channel + "/" + topicToChannelPath(topic)
+		}
+		if _, err := processor.ProcessInput(ctx, ri.orgID, channelID, data); err != nil {
+			logger.Error("Error processing input message", "error", err, "type", ri.input.Type(), "orgId", ri.orgID, "channel", channelID)
+		}
+	}
+
+	backoff := inputMinBackoff
+	for {
+		started := time.Now()
+		logger.Debug("Starting input", "type", ri.input.Type(), "orgId", ri.orgID, "pattern", ri.pattern)
+		err := ri.input.Run(ctx, handler)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > inputMaxBackoff {
+			backoff = inputMinBackoff
+		}
+		logger.Error("Input stopped, restarting", "error", err, "type", ri.input.Type(), "orgId", ri.orgID, "pattern", ri.pattern, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > inputMaxBackoff {
+			backoff = inputMaxBackoff
+		}
+	}
+}
+
+// parseInputPattern returns the channel for the messages of the inputs of a rule
+// with the pattern. Patterns with a catch-all parameter at the end, like
+// stream/mqtt/*topic, return the channel before the parameter and true, since the
+// parameter is replaced by the topic of each message. Patterns with other
+// parameters can't have inputs.
+func parseInputPattern(pattern string) (string, bool, bool) {
+	i := strings.IndexAny(pattern, ":*")
+	if i < 0 {
+		return pattern, false, true
+	}
+	if pattern[i] == '*' && i > 0 && pattern[i-1] == '/' && !strings.Contains(pattern[i:], "/") {
+		return pattern[:i-1], true, true
+	}
+	return "", false, false
+}
+
+var invalidChannelPathChars = regexp.MustCompile(`[^A-Za-z0-9_\-/=.]`)
+
+// topicToChannelPath converts a topic of an MQTT or Kafka message to a valid
+// channel path.
+func topicToChannelPath(topic string) string {
+	return strings.Trim(invalidChannelPathChars.ReplaceAllString(topic, "_"), "/")
+}
@@ -0,0 +1,190 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+type testInput struct {
+	key      string
+	messages map[string]string
+
+	mu      sync.Mutex
+	running int
+	starts  int
+}
+
+func (i *testInput) Type() string { return "test" }
+
+func (i *testInput) Key() string { return i.key }
+
+func (i *testInput) Run(ctx context.Context, handler InputHandler) error {
+	i.mu.Lock()
+	i.running++
+	i.starts++
+	i.mu.Unlock()
+	defer func() {
+		i.mu.Lock()
+		i.running--
+		i.mu.Unlock()
+	}()
+
+	for topic, msg := range i.messages {
+		handler(ctx, topic, []byte(msg))
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (i *testInput) state() (int, int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.running, i.starts
+}
+
+type testInputRuleBuilder struct {
+	mu    sync.Mutex
+	rules []*LiveChannelRule
+}
+
+func (b *testInputRuleBuilder) BuildRules(_ context.Context, _ int64) ([]*LiveChannelRule, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rules, nil
+}
+
+func (b *testInputRuleBuilder) setRules(rules ...*LiveChannelRule) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rules = rules
+}
+
+type testInputProcessor struct {
+	mu       sync.Mutex
+	messages map[string]string
+}
+
+func (p *testInputProcessor) ProcessInput(_ context.Context, _ int64, channelID string, body []byte) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages[channelID] = string(body)
+	return true, nil
+}
+
+func TestInputRunner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	input := &testInput{key: "a", messages: map[string]string{"sensors/room 1/temp": "1"}}
+	builder := &testInputRuleBuilder{}
+	builder.setRules(&LiveChannelRule{Pattern: "stream/mqtt/*topic", Inputs: []Input{input}})
+	runner := NewInputRunner(builder)
+
+	// Inputs are only started by Run.
+	_, err := runner.BuildRules(ctx, 1)
+	require.NoError(t, err)
+	running, _ := input.state()
+	require.Equal(t, 0, running)
+
+	processor := &testInputProcessor{messages: map[string]string{}}
+	go func() { _ = runner.Run(ctx, processor) }()
+	require.Eventually(t, func() bool {
+		processor.mu.Lock()
+		defer processor.mu.Unlock()
+		return processor.messages["stream/mqtt/sensors/room_1/temp"] == "1"
+	}, time.Second, 10*time.Millisecond)
+
+	// Rebuilding the rules with an input with the same key keeps the input running.
+	builder.setRules(&LiveChannelRule{Pattern: "stream/mqtt/*topic", Inputs: []Input{&testInput{key: "a"}}})
+	_, err = runner.BuildRules(ctx, 1)
+	require.NoError(t, err)
+	running, starts := input.state()
+	require.Equal(t, 1, running)
+	require.Equal(t, 1, starts)
+
+	// Inputs with a different key replace it.
+	changed := &testInput{key: "b"}
+	builder.setRules(&LiveChannelRule{Pattern: "stream/mqtt/*topic", Inputs: []Input{changed}})
+	_, err = runner.BuildRules(ctx, 1)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		running, _ := input.state()
+		changedRunning, _ := changed.state()
+		return running == 0 && changedRunning == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// Inputs of deleted rules are stopped.
+	builder.setRules()
+	_, err = runner.BuildRules(ctx, 1)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		running, _ := changed.state()
+		return running == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestParseInputPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		channel  string
+		catchAll bool
+		ok       bool
+	}{
+		{pattern: "stream/iot/temperature", channel: "stream/iot/temperature", ok: true},
+		{pattern: "stream/iot/*topic", channel: "stream/iot", catchAll: true, ok: true},
+		{pattern: "stream/iot/:device", ok: false},
+		{pattern: "stream/iot/*topic/x", ok: false},
+		{pattern: "stream/iot*topic", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			channel, catchAll, ok := parseInputPattern(tt.pattern)
+			require.Equal(t, tt.channel, channel)
+			require.Equal(t, tt.catchAll, catchAll)
+			require.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func TestNewKafkaInput(t *testing.T) {
+	input, err := NewKafkaInput("tls://kafka-1:9093, tls://kafka-2:9093", &BasicAuth{User: "grafana", Password: "secret"}, KafkaInputConfig{Topic: "telemetry"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"kafka-1:9093", "kafka-2:9093"}, input.brokers)
+	require.True(t, input.tls)
+	require.Equal(t, defaultKafkaGroupID, input.groupID)
+	require.Equal(t, kafka.LastOffset, input.startOffset)
+
+	// The key changes with the password, so that the input is restarted.
+	other, err := NewKafkaInput("tls://kafka-1:9093, tls://kafka-2:9093", &BasicAuth{User: "grafana", Password: "changed"}, KafkaInputConfig{Topic: "telemetry"})
+	require.NoError(t, err)
+	require.NotEqual(t, input.Key(), other.Key())
+
+	_, err = NewKafkaInput("", nil, KafkaInputConfig{Topic: "telemetry"})
+	require.Error(t, err)
+	_, err = NewKafkaInput("localhost:9092", nil, KafkaInputConfig{Topic: "telemetry", StartOffset: "oldest"})
+	require.Error(t, err)
+}
+
+func TestChannelRuleValidInputs(t *testing.T) {
+	rule := ChannelRule{
+		Pattern: "stream/iot/:device",
+		Settings: ChannelRuleSettings{
+			Inputs: []*InputConfig{{Type: InputTypeMQTT}},
+		},
+	}
+	ok, _ := rule.Valid()
+	require.False(t, ok)
+
+	rule.Pattern = "stream/iot/*topic"
+	ok, _ = rule.Valid()
+	require.True(t, ok)
+
+	rule.Settings.Inputs[0].Type = "amqp"
+	ok, _ = rule.Valid()
+	require.False(t, ok)
+}
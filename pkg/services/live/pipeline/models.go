@@ -17,6 +17,16 @@ func (r ChannelRule) Valid() (bool, string) {
 			return false, fmt.Sprintf("unknown converter type: %s", r.Settings.Converter.Type)
 		}
 	}
+	if len(r.Settings.Inputs) > 0 {
+		if _, _, ok := parseInputPattern(r.Pattern); !ok {
+			return false, "inputs require a pattern without parameters or with a catch-all parameter at the end"
+		}
+		for _, input := range r.Settings.Inputs {
+			if !typeRegistered(input.Type, InputsRegistry) {
+				return false, fmt.Sprintf("unknown input type: %s", input.Type)
+			}
+		}
+	}
 	if len(r.Settings.Subscribers) > 0 {
 		for _, sub := range r.Settings.Subscribers {
 			if !typeRegistered(sub.Type, SubscribersRegistry) {
@@ -59,6 +69,7 @@ func WriteConfigToDto(b WriteConfig) WriteConfigDto {
 		UID:          b.UID,
 		Settings:     b.Settings,
 		SecureFields: secureFields,
+		Version:      b.Version,
 	}
 }
 
@@ -66,6 +77,7 @@ type WriteConfigDto struct {
 	UID          string          `json:"uid"`
 	Settings     WriteSettings   `json:"settings"`
 	SecureFields map[string]bool `json:"secureFields"`
+	Version      int64           `json:"version,omitempty"`
 }
 
 type WriteConfigGetCmd struct {
@@ -78,11 +90,14 @@ type WriteConfigCreateCmd struct {
 	SecureSettings map[string]string `json:"secureSettings"`
 }
 
-// TODO: add version field later.
 type WriteConfigUpdateCmd struct {
 	UID            string            `json:"uid"`
 	Settings       WriteSettings     `json:"settings"`
 	SecureSettings map[string]string `json:"secureSettings"`
+	// Version is the version of the write config the update is based on. If set,
+	// the update fails with ErrVersionMismatch if the write config has been changed
+	// in the meantime.
+	Version int64 `json:"version,omitempty"`
 }
 
 type WriteConfigDeleteCmd struct {
@@ -94,6 +109,7 @@ type WriteConfig struct {
 	UID            string            `json:"uid"`
 	Settings       WriteSettings     `json:"settings"`
 	SecureSettings map[string][]byte `json:"secureSettings,omitempty"`
+	Version        int64             `json:"version,omitempty"`
 }
 
 func (r WriteConfig) Valid() (bool, string) {
@@ -154,6 +170,10 @@ type ChannelRuleCreateCmd struct {
 type ChannelRuleUpdateCmd struct {
 	Pattern  string              `json:"pattern"`
 	Settings ChannelRuleSettings `json:"settings"`
+	// Version is the version of the rule the update is based on. If set, the
+	// update fails with ErrVersionMismatch if the rule has been changed in the
+	// meantime.
+	Version int64 `json:"version,omitempty"`
 }
 
 type ChannelRuleDeleteCmd struct {
@@ -104,6 +104,21 @@ type FrameOutputter interface {
 	OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error)
 }
 
+// InputHandler handles a message of an Input. The topic is where the message
+// was published to in the external source.
+type InputHandler func(ctx context.Context, topic string, data []byte)
+
+// Input consumes messages from an external source, like a message broker, to
+// process them according to the channel rule.
+type Input interface {
+	Type() string
+	// Key identifies the source and the settings of the input. Running inputs
+	// are only restarted if their key changes when channel rules are rebuilt.
+	Key() string
+	// Run consumes messages until the context is canceled or an error occurs.
+	Run(ctx context.Context, handler InputHandler) error
+}
+
 // Subscriber can handle channel subscribe events.
 type Subscriber interface {
 	Type() string
@@ -131,6 +146,11 @@ type LiveChannelRule struct {
 	// (see tree package's README for more information).
 	Pattern string
 
+	// Inputs consume messages from external sources, which are then processed like data
+	// published into the channel. Inputs only support patterns without parameters, or
+	// with a catch-all parameter at the end which is replaced by the message topic.
+	Inputs []Input
+
 	// SubscribeAuth allows providing authorization logic for subscribing to a channel.
 	// If SubscribeAuth is not set then all authenticated users can subscribe to a channel.
 	SubscribeAuth SubscribeAuthChecker
@@ -234,6 +254,36 @@ func (p *Pipeline) ProcessInput(ctx context.Context, orgID int64, channelID stri
 	return ok, err
 }
 
+// ProcessInputFrame processes a frame which does not need conversion, like the
+// series received with Prometheus remote write, with the frame processors and
+// outputs of the channel rule. Returns false if there is no rule for the channel.
+func (p *Pipeline) ProcessInputFrame(ctx context.Context, orgID int64, channelID string, frame *data.Frame) (bool, error) {
+	var span trace.Span
+	if p.tracer != nil {
+		ctx, span = p.tracer.Start(ctx, "live.pipeline.process_input_frame")
+		span.SetAttributes(
+			attribute.Int64("orgId", orgID),
+			attribute.String("channel", channelID),
+		)
+		defer span.End()
+	}
+	_, ok, err := p.ruleGetter.Get(orgID, channelID)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, nil
+	}
+	err = p.processChannelFrames(ctx, orgID, channelID, []*ChannelFrame{{Frame: frame}}, nil)
+	if err != nil {
+		if p.tracer != nil && span != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return false, fmt.Errorf("error processing frame: %w", err)
+	}
+	return true, nil
+}
+
 func (p *Pipeline) processInput(ctx context.Context, orgID int64, channelID string, body []byte, visitedChannels map[string]struct{}) (bool, error) {
 	var span trace.Span
 	if p.tracer != nil {
@@ -101,6 +101,27 @@ func TestPipeline(t *testing.T) {
 	require.NotNil(t, outputter.frame)
 }
 
+func TestPipeline_ProcessInputFrame(t *testing.T) {
+	outputter := &testOutputter{}
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/test/xxx": {
+				FrameProcessors: []FrameProcessor{&testProcessor{}},
+				FrameOutputters: []FrameOutputter{outputter},
+			},
+		},
+	})
+	require.NoError(t, err)
+	ok, err := p.ProcessInputFrame(context.Background(), 1, "stream/test/xxx", data.NewFrame("test"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotNil(t, outputter.frame)
+
+	ok, err = p.ProcessInputFrame(context.Background(), 1, "stream/test/yyy", data.NewFrame("test"))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
 func TestPipeline_OutputError(t *testing.T) {
 	boomErr := errors.New("boom")
 	outputter := &testOutputter{err: boomErr}
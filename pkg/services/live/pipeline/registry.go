@@ -1,11 +1,26 @@
 package pipeline
 
+import "github.com/grafana/grafana-plugin-sdk-go/data"
+
 type EntityInfo struct {
 	Type        string      `json:"type"`
 	Description string      `json:"description"`
 	Example     interface{} `json:"example,omitempty"`
 }
 
+var InputsRegistry = []EntityInfo{
+	{
+		Type:        InputTypeMQTT,
+		Description: "subscribe to an MQTT topic",
+		Example:     MQTTInputConfig{},
+	},
+	{
+		Type:        InputTypeKafka,
+		Description: "consume a Kafka topic",
+		Example:     KafkaInputConfig{},
+	},
+}
+
 var SubscribersRegistry = []EntityInfo{
 	{
 		Type:        SubscriberTypeBuiltin,
@@ -83,6 +98,37 @@ var FrameProcessorsRegistry = []EntityInfo{
 		Description: "list the fields that should be removed",
 		Example:     DropFieldsFrameProcessorConfig{},
 	},
+	{
+		Type:        FrameProcessorTypeSetFields,
+		Description: "rename fields and set their config, like units",
+		Example: SetFieldsFrameProcessorConfig{
+			Fields: []SetFieldConfig{{Name: "temp", Rename: "temperature", Config: &data.FieldConfig{Unit: "celsius"}}},
+		},
+	},
+	{
+		Type:        FrameProcessorTypeExpression,
+		Description: "add a field computed from the other fields of each row",
+		Example: ExpressionFrameProcessorConfig{
+			FieldName:  "power",
+			Expression: "x.voltage * x.current",
+		},
+	},
+	{
+		Type:        FrameProcessorTypeAggregate,
+		Description: "downsample rows by aggregating them over a time window",
+		Example: AggregateFrameProcessorConfig{
+			Interval:        "10s",
+			Fields:          []AggregateFieldConfig{{Name: "temperature", Function: AggregateFunctionAvg}},
+			DefaultFunction: AggregateFunctionLast,
+		},
+	},
+	{
+		Type:        FrameProcessorTypeLabels,
+		Description: "add labels from the channel path to the fields",
+		Example: LabelsFrameProcessorConfig{
+			Labels: []Label{{Name: "device", Value: "${device}"}},
+		},
+	},
 }
 
 var DataOutputsRegistry = []EntityInfo{
@@ -13,6 +13,8 @@ type StorageRuleBuilder struct {
 	Node                 *centrifuge.Node
 	ManagedStream        *managedstream.Runner
 	FrameStorage         *FrameStorage
+	FrameWindowStorage   *FrameWindowStorage
+	StateNotifier        *StateNotifier
 	Storage              Storage
 	ChannelHandlerGetter ChannelHandlerGetter
 	SecretsService       secrets.Service
@@ -47,6 +49,43 @@ func (f *StorageRuleBuilder) extractSubscriber(config *SubscriberConfig) (Subscr
 	}
 }
 
+func (f *StorageRuleBuilder) extractInput(config *InputConfig, writeConfigs []WriteConfig) (Input, error) {
+	if config == nil {
+		return nil, nil
+	}
+	missingConfiguration := fmt.Errorf("missing configuration for %s", config.Type)
+	switch config.Type {
+	case InputTypeMQTT:
+		if config.MQTTInputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.MQTTInputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.MQTTInputConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		return NewMQTTInput(writeConfig.Settings.Endpoint, basicAuth, *config.MQTTInputConfig)
+	case InputTypeKafka:
+		if config.KafkaInputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.KafkaInputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.KafkaInputConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		return NewKafkaInput(writeConfig.Settings.Endpoint, basicAuth, *config.KafkaInputConfig)
+	default:
+		return nil, fmt.Errorf("unknown input type: %s", config.Type)
+	}
+}
+
 func (f *StorageRuleBuilder) extractConverter(config *ConverterConfig) (Converter, error) {
 	if config == nil {
 		return nil, nil
@@ -78,7 +117,7 @@ func (f *StorageRuleBuilder) extractConverter(config *ConverterConfig) (Converte
 	}
 }
 
-func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig) (FrameProcessor, error) {
+func (f *StorageRuleBuilder) extractFrameProcessor(pattern string, config *FrameProcessorConfig) (FrameProcessor, error) {
 	if config == nil {
 		return nil, nil
 	}
@@ -94,6 +133,26 @@ func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig)
 			return nil, missingConfiguration
 		}
 		return NewKeepFieldsFrameProcessor(*config.KeepFieldsProcessorConfig), nil
+	case FrameProcessorTypeSetFields:
+		if config.SetFieldsProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewSetFieldsFrameProcessor(*config.SetFieldsProcessorConfig), nil
+	case FrameProcessorTypeExpression:
+		if config.ExpressionProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewExpressionFrameProcessor(*config.ExpressionProcessorConfig)
+	case FrameProcessorTypeAggregate:
+		if config.AggregateProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewAggregateFrameProcessor(f.FrameWindowStorage, *config.AggregateProcessorConfig)
+	case FrameProcessorTypeLabels:
+		if config.LabelsProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewLabelsFrameProcessor(pattern, *config.LabelsProcessorConfig), nil
 	case FrameProcessorTypeMultiple:
 		if config.MultipleProcessorConfig == nil {
 			return nil, missingConfiguration
@@ -101,7 +160,7 @@ func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig)
 		var processors []FrameProcessor
 		for _, outConf := range config.MultipleProcessorConfig.Processors {
 			out := outConf
-			proc, err := f.extractFrameProcessor(&out)
+			proc, err := f.extractFrameProcessor(pattern, &out)
 			if err != nil {
 				return nil, err
 			}
@@ -214,7 +273,7 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 		if config.ThresholdOutputConfig == nil {
 			return nil, missingConfiguration
 		}
-		return NewThresholdOutput(f.FrameStorage, *config.ThresholdOutputConfig), nil
+		return NewThresholdOutput(f.FrameStorage, f.StateNotifier, *config.ThresholdOutputConfig), nil
 	case FrameOutputTypeRemoteWrite:
 		if config.RemoteWriteOutputConfig == nil {
 			return nil, missingConfiguration
@@ -252,7 +311,7 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 		if config.ChangeLogOutputConfig == nil {
 			return nil, missingConfiguration
 		}
-		return NewChangeLogFrameOutput(f.FrameStorage, *config.ChangeLogOutputConfig), nil
+		return NewChangeLogFrameOutput(f.FrameStorage, f.StateNotifier, *config.ChangeLogOutputConfig), nil
 	default:
 		return nil, fmt.Errorf("unknown output type: %s", config.Type)
 	}
@@ -339,7 +398,7 @@ func (f *StorageRuleBuilder) BuildRules(ctx context.Context, orgID int64) ([]*Li
 
 		var processors []FrameProcessor
 		for _, procConfig := range ruleConfig.Settings.FrameProcessors {
-			proc, err := f.extractFrameProcessor(procConfig)
+			proc, err := f.extractFrameProcessor(rule.Pattern, procConfig)
 			if err != nil {
 				return nil, fmt.Errorf("error building processor for %s: %w", rule.Pattern, err)
 			}
@@ -367,6 +426,21 @@ func (f *StorageRuleBuilder) BuildRules(ctx context.Context, orgID int64) ([]*Li
 		}
 		rule.FrameOutputters = outputters
 
+		if len(ruleConfig.Settings.Inputs) > 0 {
+			if _, _, ok := parseInputPattern(rule.Pattern); !ok {
+				return nil, fmt.Errorf("inputs are not supported for pattern %s", rule.Pattern)
+			}
+		}
+		var inputs []Input
+		for _, inputConfig := range ruleConfig.Settings.Inputs {
+			input, err := f.extractInput(inputConfig, writeConfigs)
+			if err != nil {
+				return nil, fmt.Errorf("error building input for %s: %w", rule.Pattern, err)
+			}
+			inputs = append(inputs, input)
+		}
+		rule.Inputs = inputs
+
 		var subscribers []Subscriber
 		for _, subConfig := range ruleConfig.Settings.Subscribers {
 			sub, err := f.extractSubscriber(subConfig)
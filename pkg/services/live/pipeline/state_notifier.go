@@ -0,0 +1,212 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/api/v2/models"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+// StateAnnotationConfig makes an output create an annotation for each state
+// transition. Annotations without dashboard are organization annotations.
+type StateAnnotationConfig struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	PanelID      int64    `json:"panelId,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+}
+
+// StateAlertConfig makes an output post alerts to the Grafana Alertmanager.
+type StateAlertConfig struct {
+	// Name is the alertname label of alerts, field name is used by default.
+	Name string `json:"name,omitempty"`
+	// States which fire an alert, only used by threshold output. By default all
+	// states except the state of the base threshold step fire.
+	States      []string          `json:"states,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AnnotationSaver saves annotations created by outputs.
+type AnnotationSaver interface {
+	SaveAnnotation(ctx context.Context, dashboardUID string, item *annotations.Item) error
+}
+
+// AlertSender posts alerts to the Alertmanager of an organization.
+type AlertSender interface {
+	SendAlerts(orgID int64, alerts apimodels.PostableAlerts) error
+}
+
+// Firing alerts are re-sent with this interval, so that Alertmanager does
+// not resolve them while state is unchanged.
+const alertResendInterval = time.Minute
+
+// StateNotifier creates annotations and alerts for state transitions found
+// by threshold and changelog outputs. It keeps the time firing alerts were
+// sent in memory, so that it survives rebuilding of channel rules.
+type StateNotifier struct {
+	annotationSaver AnnotationSaver
+	alertSender     AlertSender
+
+	mu        sync.Mutex
+	lastSent  map[string]time.Time
+	lastPrune time.Time
+	nowFunc   func() time.Time
+}
+
+// NewStateNotifier creates StateNotifier. Both annotationSaver and alertSender
+// are optional.
+func NewStateNotifier(annotationSaver AnnotationSaver, alertSender AlertSender) *StateNotifier {
+	return &StateNotifier{
+		annotationSaver: annotationSaver,
+		alertSender:     alertSender,
+		lastSent:        map[string]time.Time{},
+		nowFunc:         time.Now,
+	}
+}
+
+type stateTransition struct {
+	time      time.Time
+	prevState string
+	newState  string
+	value     string
+}
+
+func (n *StateNotifier) annotate(ctx context.Context, vars Vars, fieldName string, config *StateAnnotationConfig, t stateTransition) {
+	if n == nil || n.annotationSaver == nil || config == nil {
+		return
+	}
+	epoch := t.time.UnixNano() / int64(time.Millisecond)
+	item := &annotations.Item{
+		OrgId:     vars.OrgID,
+		PanelId:   config.PanelID,
+		Epoch:     epoch,
+		EpochEnd:  epoch,
+		Text:      fmt.Sprintf("%s changed from %s to %s", fieldName, t.prevState, t.newState),
+		PrevState: t.prevState,
+		NewState:  t.newState,
+		Tags:      config.Tags,
+		Data: simplejson.NewFromAny(map[string]interface{}{
+			"channel": vars.Channel,
+			"field":   fieldName,
+			"value":   t.value,
+		}),
+	}
+	if err := n.annotationSaver.SaveAnnotation(ctx, config.DashboardUID, item); err != nil {
+		logger.Error("Error saving state annotation", "channel", vars.Channel, "error", err)
+	}
+}
+
+// alertLabels returns labels of alerts for the field, config labels take
+// precedence over field labels.
+func alertLabels(vars Vars, field *data.Field, config *StateAlertConfig) data.Labels {
+	name := config.Name
+	if name == "" {
+		name = field.Name
+	}
+	labels := data.Labels{}
+	for k, v := range field.Labels {
+		labels[k] = v
+	}
+	labels["alertname"] = name
+	labels["channel"] = vars.Channel
+	for k, v := range config.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// sendAlert posts a firing or resolved alert. Firing alerts are sent when force
+// is set or resend interval passed since the alert was last sent.
+func (n *StateNotifier) sendAlert(vars Vars, labels data.Labels, annotations map[string]string, firing bool, force bool) {
+	if n == nil || n.alertSender == nil {
+		return
+	}
+	now := n.nowFunc()
+	key := strconv.FormatInt(vars.OrgID, 10) + labels.String()
+
+	n.mu.Lock()
+	n.prune(now)
+	lastSent, sent := n.lastSent[key]
+	if firing {
+		if !force && sent && now.Sub(lastSent) < alertResendInterval {
+			n.mu.Unlock()
+			return
+		}
+		n.lastSent[key] = now
+	} else {
+		delete(n.lastSent, key)
+	}
+	n.mu.Unlock()
+
+	alert := models.PostableAlert{
+		Annotations: models.LabelSet(annotations),
+		StartsAt:    strfmt.DateTime(now),
+		Alert: models.Alert{
+			Labels: models.LabelSet(labels),
+		},
+	}
+	if !firing {
+		alert.EndsAt = strfmt.DateTime(now)
+	}
+	err := n.alertSender.SendAlerts(vars.OrgID, apimodels.PostableAlerts{PostableAlerts: []models.PostableAlert{alert}})
+	if err != nil {
+		logger.Error("Error sending state alert", "channel", vars.Channel, "error", err)
+	}
+}
+
+// prune removes alerts not sent for a long time, like alerts of removed
+// outputs. Must be called with the mutex held.
+func (n *StateNotifier) prune(now time.Time) {
+	if now.Sub(n.lastPrune) < alertResendInterval {
+		return
+	}
+	n.lastPrune = now
+	for key, lastSent := range n.lastSent {
+		if now.Sub(lastSent) > 10*alertResendInterval {
+			delete(n.lastSent, key)
+		}
+	}
+}
+
+func alertAnnotations(config *StateAlertConfig, values map[string]string) map[string]string {
+	result := make(map[string]string, len(config.Annotations)+len(values))
+	for k, v := range values {
+		result[k] = v
+	}
+	for k, v := range config.Annotations {
+		result[k] = v
+	}
+	return result
+}
+
+// formatStateValue formats a field value for annotations and alerts.
+func formatStateValue(value interface{}) string {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "null"
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "null"
+	}
+	switch val := v.Interface().(type) {
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
@@ -0,0 +1,201 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/annotations"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+type testAnnotationSaver struct {
+	dashboardUIDs []string
+	items         []*annotations.Item
+}
+
+func (s *testAnnotationSaver) SaveAnnotation(_ context.Context, dashboardUID string, item *annotations.Item) error {
+	s.dashboardUIDs = append(s.dashboardUIDs, dashboardUID)
+	s.items = append(s.items, item)
+	return nil
+}
+
+type testAlertSender struct {
+	orgIDs []int64
+	alerts []apimodels.PostableAlerts
+}
+
+func (s *testAlertSender) SendAlerts(orgID int64, alerts apimodels.PostableAlerts) error {
+	s.orgIDs = append(s.orgIDs, orgID)
+	s.alerts = append(s.alerts, alerts)
+	return nil
+}
+
+func thresholdTestFrame(value float64) *data.Frame {
+	f := data.NewField("temperature", data.Labels{"room": "kitchen"}, []*float64{&value})
+	f.Config = &data.FieldConfig{
+		Thresholds: &data.ThresholdsConfig{
+			Mode: data.ThresholdsModeAbsolute,
+			Steps: []data.Threshold{
+				{Value: 0, State: "normal", Color: "green"},
+				{Value: 30, State: "hot", Color: "red"},
+			},
+		},
+	}
+	return data.NewFrame("test", data.NewField("time", nil, []time.Time{time.Now()}), f)
+}
+
+func TestThresholdOutput_StateNotifier(t *testing.T) {
+	annotationSaver := &testAnnotationSaver{}
+	alertSender := &testAlertSender{}
+	notifier := NewStateNotifier(annotationSaver, alertSender)
+	now := time.Now()
+	notifier.nowFunc = func() time.Time { return now }
+
+	outputter := NewThresholdOutput(NewFrameStorage(), notifier, ThresholdOutputConfig{
+		FieldName: "temperature",
+		Channel:   "stream/test/state",
+		Annotation: &StateAnnotationConfig{
+			DashboardUID: "dash",
+			PanelID:      2,
+			Tags:         []string{"sensor"},
+		},
+		Alert: &StateAlertConfig{
+			Labels:      map[string]string{"severity": "critical"},
+			Annotations: map[string]string{"summary": "Too hot"},
+		},
+	})
+	vars := Vars{OrgID: 1, Channel: "stream/test/sensor"}
+
+	output := func(value float64) {
+		_, err := outputter.OutputFrame(context.Background(), vars, thresholdTestFrame(value))
+		require.NoError(t, err)
+	}
+
+	// No previous state, nothing to annotate and not firing.
+	output(20)
+	require.Len(t, annotationSaver.items, 0)
+	require.Len(t, alertSender.alerts, 0)
+
+	output(40)
+	require.Len(t, annotationSaver.items, 1)
+	item := annotationSaver.items[0]
+	require.Equal(t, "dash", annotationSaver.dashboardUIDs[0])
+	require.Equal(t, int64(1), item.OrgId)
+	require.Equal(t, int64(2), item.PanelId)
+	require.Equal(t, "normal", item.PrevState)
+	require.Equal(t, "hot", item.NewState)
+	require.Equal(t, []string{"sensor"}, item.Tags)
+	require.Equal(t, "temperature changed from normal to hot", item.Text)
+
+	require.Len(t, alertSender.alerts, 1)
+	require.Equal(t, int64(1), alertSender.orgIDs[0])
+	alert := alertSender.alerts[0].PostableAlerts[0]
+	require.Equal(t, "temperature", alert.Labels["alertname"])
+	require.Equal(t, "stream/test/sensor", alert.Labels["channel"])
+	require.Equal(t, "kitchen", alert.Labels["room"])
+	require.Equal(t, "critical", alert.Labels["severity"])
+	require.Equal(t, "hot", alert.Annotations["state"])
+	require.Equal(t, "40", alert.Annotations["value"])
+	require.Equal(t, "Too hot", alert.Annotations["summary"])
+	require.True(t, time.Time(alert.EndsAt).IsZero())
+
+	// Still firing, alert is only re-sent after resend interval.
+	output(45)
+	require.Len(t, alertSender.alerts, 1)
+	now = now.Add(alertResendInterval)
+	output(45)
+	require.Len(t, alertSender.alerts, 2)
+	require.Len(t, annotationSaver.items, 1)
+
+	// Back to normal, alert is resolved.
+	output(20)
+	require.Len(t, annotationSaver.items, 2)
+	require.Equal(t, "hot", annotationSaver.items[1].PrevState)
+	require.Equal(t, "normal", annotationSaver.items[1].NewState)
+	require.Len(t, alertSender.alerts, 3)
+	alert = alertSender.alerts[2].PostableAlerts[0]
+	require.Equal(t, "normal", alert.Annotations["state"])
+	require.Equal(t, now, time.Time(alert.EndsAt))
+
+	output(25)
+	require.Len(t, annotationSaver.items, 2)
+	require.Len(t, alertSender.alerts, 3)
+}
+
+func TestThresholdOutput_StateNotifier_AlertStates(t *testing.T) {
+	alertSender := &testAlertSender{}
+	outputter := NewThresholdOutput(NewFrameStorage(), NewStateNotifier(nil, alertSender), ThresholdOutputConfig{
+		FieldName: "temperature",
+		Channel:   "stream/test/state",
+		Alert: &StateAlertConfig{
+			Name:   "Temperature",
+			States: []string{"normal"},
+		},
+	})
+	_, err := outputter.OutputFrame(context.Background(), Vars{OrgID: 1}, thresholdTestFrame(40))
+	require.NoError(t, err)
+	require.Len(t, alertSender.alerts, 0)
+
+	_, err = outputter.OutputFrame(context.Background(), Vars{OrgID: 1}, thresholdTestFrame(20))
+	require.NoError(t, err)
+	require.Len(t, alertSender.alerts, 1)
+	alert := alertSender.alerts[0].PostableAlerts[0]
+	require.Equal(t, "Temperature", alert.Labels["alertname"])
+	require.True(t, time.Time(alert.EndsAt).IsZero())
+}
+
+func TestChangeLogOutput_StateNotifier(t *testing.T) {
+	annotationSaver := &testAnnotationSaver{}
+	alertSender := &testAlertSender{}
+	outputter := NewChangeLogFrameOutput(NewFrameStorage(), NewStateNotifier(annotationSaver, alertSender), ChangeLogOutputConfig{
+		FieldName:  "status",
+		Channel:    "stream/test/changes",
+		Annotation: &StateAnnotationConfig{},
+		Alert:      &StateAlertConfig{Name: "StatusChanged"},
+	})
+	vars := Vars{OrgID: 2, Channel: "stream/test/device"}
+
+	// Change frame has no previous value for the first row, so the field must be nullable.
+	output := func(values ...string) {
+		field := data.NewFieldFromFieldType(data.FieldTypeNullableString, len(values))
+		field.Name = "status"
+		for i, v := range values {
+			field.SetConcrete(i, v)
+		}
+		frame := data.NewFrame("test", field)
+		_, err := outputter.OutputFrame(context.Background(), vars, frame)
+		require.NoError(t, err)
+	}
+
+	output("ok")
+	require.Len(t, annotationSaver.items, 0)
+	require.Len(t, alertSender.alerts, 0)
+
+	output("ok", "failed", "failed")
+	require.Len(t, annotationSaver.items, 1)
+	require.Equal(t, "", annotationSaver.dashboardUIDs[0])
+	require.Equal(t, "ok", annotationSaver.items[0].PrevState)
+	require.Equal(t, "failed", annotationSaver.items[0].NewState)
+	require.Equal(t, int64(2), annotationSaver.items[0].OrgId)
+
+	require.Len(t, alertSender.alerts, 1)
+	require.Equal(t, int64(2), alertSender.orgIDs[0])
+	alert := alertSender.alerts[0].PostableAlerts[0]
+	require.Equal(t, "StatusChanged", alert.Labels["alertname"])
+	require.Equal(t, "ok", alert.Annotations["old"])
+	require.Equal(t, "failed", alert.Annotations["new"])
+}
+
+func TestFormatStateValue(t *testing.T) {
+	v := 1.5
+	require.Equal(t, "1.5", formatStateValue(v))
+	require.Equal(t, "1.5", formatStateValue(&v))
+	require.Equal(t, "null", formatStateValue((*float64)(nil)))
+	require.Equal(t, "null", formatStateValue(nil))
+	require.Equal(t, "test", formatStateValue("test"))
+	require.Equal(t, "true", formatStateValue(true))
+}
@@ -1,6 +1,17 @@
 package pipeline
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrChannelRuleNotFound = errors.New("channel rule not found")
+	ErrWriteConfigNotFound = errors.New("write config not found")
+	// ErrVersionMismatch is returned when an update is based on an outdated
+	// version of a channel rule or write config.
+	ErrVersionMismatch = errors.New("the entity has been changed by someone else")
+)
 
 // Storage describes all methods to manage Live pipeline persistent data.
 type Storage interface {
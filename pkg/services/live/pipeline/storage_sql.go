@@ -0,0 +1,532 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// DefaultSQLStoragePollInterval is how often SQLStorage checks for changes made
+// by other Grafana server instances.
+const DefaultSQLStoragePollInterval = 5 * time.Second
+
+// SQLStorage stores channel rules and write configs in the Grafana database.
+//
+// Each channel rule and write config has a version which is used for optimistic
+// locking. Every change also increments the revision of the organization, which
+// is polled to notify about changes made by other Grafana server instances.
+type SQLStorage struct {
+	store          *sqlstore.SQLStore
+	secretsService secrets.Service
+	pollInterval   time.Duration
+
+	mu        sync.Mutex
+	revisions map[int64]int64
+	listeners []func(orgID int64)
+}
+
+func NewSQLStorage(store *sqlstore.SQLStore, secretsService secrets.Service, pollInterval time.Duration) *SQLStorage {
+	if pollInterval <= 0 {
+		pollInterval = DefaultSQLStoragePollInterval
+	}
+	return &SQLStorage{
+		store:          store,
+		secretsService: secretsService,
+		pollInterval:   pollInterval,
+		revisions:      map[int64]int64{},
+	}
+}
+
+type channelRuleRow struct {
+	Id       int64
+	OrgId    int64
+	Pattern  string
+	Settings string
+	Version  int64
+	Created  time.Time
+	Updated  time.Time
+}
+
+func (r channelRuleRow) TableName() string {
+	return "live_channel_rule"
+}
+
+func (r channelRuleRow) toChannelRule() (ChannelRule, error) {
+	rule := ChannelRule{
+		OrgId:   r.OrgId,
+		Pattern: r.Pattern,
+		Version: r.Version,
+	}
+	if err := json.Unmarshal([]byte(r.Settings), &rule.Settings); err != nil {
+		return ChannelRule{}, fmt.Errorf("can't unmarshal settings of channel rule %s: %w", r.Pattern, err)
+	}
+	return rule, nil
+}
+
+type writeConfigRow struct {
+	Id             int64
+	OrgId          int64
+	Uid            string
+	Settings       string
+	SecureSettings string
+	Version        int64
+	Created        time.Time
+	Updated        time.Time
+}
+
+func (r writeConfigRow) TableName() string {
+	return "live_write_config"
+}
+
+func (r writeConfigRow) toWriteConfig() (WriteConfig, error) {
+	writeConfig := WriteConfig{
+		OrgId:   r.OrgId,
+		UID:     r.Uid,
+		Version: r.Version,
+	}
+	if err := json.Unmarshal([]byte(r.Settings), &writeConfig.Settings); err != nil {
+		return WriteConfig{}, fmt.Errorf("can't unmarshal settings of write config %s: %w", r.Uid, err)
+	}
+	if r.SecureSettings != "" {
+		if err := json.Unmarshal([]byte(r.SecureSettings), &writeConfig.SecureSettings); err != nil {
+			return WriteConfig{}, fmt.Errorf("can't unmarshal secure settings of write config %s: %w", r.Uid, err)
+		}
+	}
+	return writeConfig, nil
+}
+
+type revisionRow struct {
+	Id       int64
+	OrgId    int64
+	Revision int64
+	Updated  time.Time
+}
+
+func (r revisionRow) TableName() string {
+	return "live_pipeline_revision"
+}
+
+// OnChange registers a function which is called with the ID of the organization
+// after its channel rules or write configs have been changed, by this or by another
+// Grafana server instance. Changes made by other instances are noticed by Run.
+func (s *SQLStorage) OnChange(fn func(orgID int64)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Run polls the revisions of all organizations until the context is canceled.
+func (s *SQLStorage) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.checkRevisions(ctx); err != nil {
+				logger.Error("Error checking pipeline revisions", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *SQLStorage) checkRevisions(ctx context.Context) error {
+	var rows []revisionRow
+	err := s.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Find(&rows)
+	})
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		s.notify(row.OrgId, row.Revision)
+	}
+	return nil
+}
+
+// notify calls the listeners if the revision of the organization is newer than
+// the last one seen.
+func (s *SQLStorage) notify(orgID int64, revision int64) {
+	s.mu.Lock()
+	if revision <= s.revisions[orgID] {
+		s.mu.Unlock()
+		return
+	}
+	s.revisions[orgID] = revision
+	listeners := make([]func(orgID int64), len(s.listeners))
+	copy(listeners, s.listeners)
+	s.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(orgID)
+	}
+}
+
+// incrementRevision increments the revision of the organization and returns it.
+func incrementRevision(sess *sqlstore.DBSession, orgID int64) (int64, error) {
+	now := time.Now()
+	res, err := sess.Exec("UPDATE live_pipeline_revision SET revision = revision + 1, updated = ? WHERE org_id = ?", now, orgID)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected == 0 {
+		if _, err := sess.Insert(&revisionRow{OrgId: orgID, Revision: 1, Updated: now}); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+	row := revisionRow{}
+	if _, err := sess.Where("org_id = ?", orgID).Get(&row); err != nil {
+		return 0, err
+	}
+	return row.Revision, nil
+}
+
+func (s *SQLStorage) ListWriteConfigs(ctx context.Context, orgID int64) ([]WriteConfig, error) {
+	var rows []writeConfigRow
+	err := s.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("uid").Find(&rows)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't read write configs: %w", err)
+	}
+	writeConfigs := make([]WriteConfig, 0, len(rows))
+	for _, row := range rows {
+		writeConfig, err := row.toWriteConfig()
+		if err != nil {
+			return nil, err
+		}
+		writeConfigs = append(writeConfigs, writeConfig)
+	}
+	return writeConfigs, nil
+}
+
+func (s *SQLStorage) GetWriteConfig(ctx context.Context, orgID int64, cmd WriteConfigGetCmd) (WriteConfig, bool, error) {
+	var row writeConfigRow
+	var exists bool
+	err := s.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		exists, err = sess.Where("org_id = ? AND uid = ?", orgID, cmd.UID).Get(&row)
+		return err
+	})
+	if err != nil {
+		return WriteConfig{}, false, fmt.Errorf("can't read write config: %w", err)
+	}
+	if !exists {
+		return WriteConfig{}, false, nil
+	}
+	writeConfig, err := row.toWriteConfig()
+	return writeConfig, err == nil, err
+}
+
+// newWriteConfigRow validates the write config and returns its row, with encrypted
+// secure settings.
+func (s *SQLStorage) newWriteConfigRow(ctx context.Context, orgID int64, uid string, settings WriteSettings, secureSettings map[string]string) (writeConfigRow, error) {
+	encrypted, err := s.secretsService.EncryptJsonData(ctx, secureSettings, secrets.WithoutScope())
+	if err != nil {
+		return writeConfigRow{}, fmt.Errorf("error encrypting data: %w", err)
+	}
+
+	writeConfig := WriteConfig{
+		OrgId:          orgID,
+		UID:            uid,
+		Settings:       settings,
+		SecureSettings: encrypted,
+	}
+	ok, reason := writeConfig.Valid()
+	if !ok {
+		return writeConfigRow{}, fmt.Errorf("invalid write config: %s", reason)
+	}
+
+	settingsJSON, err := json.Marshal(writeConfig.Settings)
+	if err != nil {
+		return writeConfigRow{}, err
+	}
+	secureSettingsJSON, err := json.Marshal(writeConfig.SecureSettings)
+	if err != nil {
+		return writeConfigRow{}, err
+	}
+	now := time.Now()
+	return writeConfigRow{
+		OrgId:          orgID,
+		Uid:            uid,
+		Settings:       string(settingsJSON),
+		SecureSettings: string(secureSettingsJSON),
+		Version:        1,
+		Created:        now,
+		Updated:        now,
+	}, nil
+}
+
+func (s *SQLStorage) CreateWriteConfig(ctx context.Context, orgID int64, cmd WriteConfigCreateCmd) (WriteConfig, error) {
+	if cmd.UID == "" {
+		cmd.UID = util.GenerateShortUID()
+	}
+	row, err := s.newWriteConfigRow(ctx, orgID, cmd.UID, cmd.Settings, cmd.SecureSettings)
+	if err != nil {
+		return WriteConfig{}, err
+	}
+
+	var revision int64
+	err = s.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id = ? AND uid = ?", orgID, row.Uid).Exist(&writeConfigRow{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("write config already exists in org: %s", row.Uid)
+		}
+		if _, err := sess.Insert(&row); err != nil {
+			return err
+		}
+		revision, err = incrementRevision(sess, orgID)
+		return err
+	})
+	if err != nil {
+		return WriteConfig{}, err
+	}
+	s.notify(orgID, revision)
+	return row.toWriteConfig()
+}
+
+func (s *SQLStorage) UpdateWriteConfig(ctx context.Context, orgID int64, cmd WriteConfigUpdateCmd) (WriteConfig, error) {
+	row, err := s.newWriteConfigRow(ctx, orgID, cmd.UID, cmd.Settings, cmd.SecureSettings)
+	if err != nil {
+		return WriteConfig{}, err
+	}
+
+	var revision int64
+	err = s.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var existing writeConfigRow
+		exists, err := sess.Where("org_id = ? AND uid = ?", orgID, row.Uid).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if cmd.Version != 0 {
+				return ErrWriteConfigNotFound
+			}
+			if _, err := sess.Insert(&row); err != nil {
+				return err
+			}
+		} else {
+			if cmd.Version != 0 && cmd.Version != existing.Version {
+				return ErrVersionMismatch
+			}
+			row.Id = existing.Id
+			row.Created = existing.Created
+			row.Version = existing.Version + 1
+			affected, err := sess.Where("id = ? AND version = ?", existing.Id, existing.Version).
+				Cols("settings", "secure_settings", "version", "updated").Update(&row)
+			if err != nil {
+				return err
+			}
+			if affected == 0 {
+				return ErrVersionMismatch
+			}
+		}
+		revision, err = incrementRevision(sess, orgID)
+		return err
+	})
+	if err != nil {
+		return WriteConfig{}, err
+	}
+	s.notify(orgID, revision)
+	return row.toWriteConfig()
+}
+
+func (s *SQLStorage) DeleteWriteConfig(ctx context.Context, orgID int64, cmd WriteConfigDeleteCmd) error {
+	var revision int64
+	err := s.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("org_id = ? AND uid = ?", orgID, cmd.UID).Delete(&writeConfigRow{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrWriteConfigNotFound
+		}
+		revision, err = incrementRevision(sess, orgID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.notify(orgID, revision)
+	return nil
+}
+
+func (s *SQLStorage) ListChannelRules(ctx context.Context, orgID int64) ([]ChannelRule, error) {
+	var rules []ChannelRule
+	err := s.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		rules, err = listChannelRules(sess, orgID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't read channel rules: %w", err)
+	}
+	return rules, nil
+}
+
+func listChannelRules(sess *sqlstore.DBSession, orgID int64) ([]ChannelRule, error) {
+	var rows []channelRuleRow
+	if err := sess.Where("org_id = ?", orgID).Asc("pattern").Find(&rows); err != nil {
+		return nil, err
+	}
+	rules := make([]ChannelRule, 0, len(rows))
+	for _, row := range rows {
+		rule, err := row.toChannelRule()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// newChannelRuleRow validates the channel rule and returns its row.
+func newChannelRuleRow(orgID int64, pattern string, settings ChannelRuleSettings) (channelRuleRow, error) {
+	rule := ChannelRule{
+		OrgId:    orgID,
+		Pattern:  pattern,
+		Settings: settings,
+	}
+	ok, reason := rule.Valid()
+	if !ok {
+		return channelRuleRow{}, fmt.Errorf("invalid channel rule: %s", reason)
+	}
+	settingsJSON, err := json.Marshal(rule.Settings)
+	if err != nil {
+		return channelRuleRow{}, err
+	}
+	now := time.Now()
+	return channelRuleRow{
+		OrgId:    orgID,
+		Pattern:  pattern,
+		Settings: string(settingsJSON),
+		Version:  1,
+		Created:  now,
+		Updated:  now,
+	}, nil
+}
+
+// insertChannelRule inserts the rule, if its pattern doesn't conflict with the
+// patterns of the existing rules of the organization.
+func insertChannelRule(sess *sqlstore.DBSession, row channelRuleRow) error {
+	rules, err := listChannelRules(sess, row.OrgId)
+	if err != nil {
+		return err
+	}
+	for _, existingRule := range rules {
+		if existingRule.Pattern == row.Pattern {
+			return fmt.Errorf("pattern already exists in org: %s", row.Pattern)
+		}
+	}
+	rules = append(rules, ChannelRule{OrgId: row.OrgId, Pattern: row.Pattern})
+	ok, reason := checkRulesValid(row.OrgId, rules)
+	if !ok {
+		return errors.New(reason)
+	}
+	_, err = sess.Insert(&row)
+	return err
+}
+
+func (s *SQLStorage) CreateChannelRule(ctx context.Context, orgID int64, cmd ChannelRuleCreateCmd) (ChannelRule, error) {
+	row, err := newChannelRuleRow(orgID, cmd.Pattern, cmd.Settings)
+	if err != nil {
+		return ChannelRule{}, err
+	}
+
+	var revision int64
+	err = s.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := insertChannelRule(sess, row); err != nil {
+			return err
+		}
+		var err error
+		revision, err = incrementRevision(sess, orgID)
+		return err
+	})
+	if err != nil {
+		return ChannelRule{}, err
+	}
+	s.notify(orgID, revision)
+	return row.toChannelRule()
+}
+
+func (s *SQLStorage) UpdateChannelRule(ctx context.Context, orgID int64, cmd ChannelRuleUpdateCmd) (ChannelRule, error) {
+	row, err := newChannelRuleRow(orgID, cmd.Pattern, cmd.Settings)
+	if err != nil {
+		return ChannelRule{}, err
+	}
+
+	var revision int64
+	err = s.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var existing channelRuleRow
+		exists, err := sess.Where("org_id = ? AND pattern = ?", orgID, row.Pattern).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if cmd.Version != 0 {
+				return ErrChannelRuleNotFound
+			}
+			if err := insertChannelRule(sess, row); err != nil {
+				return err
+			}
+		} else {
+			if cmd.Version != 0 && cmd.Version != existing.Version {
+				return ErrVersionMismatch
+			}
+			row.Id = existing.Id
+			row.Created = existing.Created
+			row.Version = existing.Version + 1
+			affected, err := sess.Where("id = ? AND version = ?", existing.Id, existing.Version).
+				Cols("settings", "version", "updated").Update(&row)
+			if err != nil {
+				return err
+			}
+			if affected == 0 {
+				return ErrVersionMismatch
+			}
+		}
+		revision, err = incrementRevision(sess, orgID)
+		return err
+	})
+	if err != nil {
+		return ChannelRule{}, err
+	}
+	s.notify(orgID, revision)
+	return row.toChannelRule()
+}
+
+func (s *SQLStorage) DeleteChannelRule(ctx context.Context, orgID int64, cmd ChannelRuleDeleteCmd) error {
+	var revision int64
+	err := s.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("org_id = ? AND pattern = ?", orgID, cmd.Pattern).Delete(&channelRuleRow{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrChannelRuleNotFound
+		}
+		revision, err = incrementRevision(sess, orgID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.notify(orgID, revision)
+	return nil
+}
@@ -0,0 +1,196 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/require"
+)
+
+// initTestDB initializes the test DB with the tables of the livePipeline feature.
+func initTestDB(t *testing.T) *sqlstore.SQLStore {
+	t.Helper()
+	return sqlstore.InitTestDB(t, sqlstore.InitTestDBOpt{FeatureFlags: []string{featuremgmt.FlagLivePipeline}})
+}
+
+func setupSQLStorage(t *testing.T) *SQLStorage {
+	t.Helper()
+	return NewSQLStorage(initTestDB(t), fakes.NewFakeSecretsService(), 0)
+}
+
+func TestSQLStorage_ChannelRules(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rules are scoped by organization", func(t *testing.T) {
+		s := setupSQLStorage(t)
+
+		rule, err := s.CreateChannelRule(ctx, 1, ChannelRuleCreateCmd{Pattern: "stream/telegraf/:metric"})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), rule.Version)
+
+		_, err = s.CreateChannelRule(ctx, 2, ChannelRuleCreateCmd{Pattern: "stream/telegraf/:metric"})
+		require.NoError(t, err)
+
+		_, err = s.CreateChannelRule(ctx, 1, ChannelRuleCreateCmd{Pattern: "stream/telegraf/:metric"})
+		require.Error(t, err)
+
+		rules, err := s.ListChannelRules(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		require.Equal(t, int64(1), rules[0].OrgId)
+
+		rules, err = s.ListChannelRules(ctx, 3)
+		require.NoError(t, err)
+		require.Len(t, rules, 0)
+	})
+
+	t.Run("conflicting patterns are rejected", func(t *testing.T) {
+		s := setupSQLStorage(t)
+
+		_, err := s.CreateChannelRule(ctx, 1, ChannelRuleCreateCmd{Pattern: "stream/telegraf/:metric"})
+		require.NoError(t, err)
+		_, err = s.CreateChannelRule(ctx, 1, ChannelRuleCreateCmd{Pattern: "stream/telegraf/:other"})
+		require.Error(t, err)
+	})
+
+	t.Run("updates check the version", func(t *testing.T) {
+		s := setupSQLStorage(t)
+
+		rule, err := s.CreateChannelRule(ctx, 1, ChannelRuleCreateCmd{Pattern: "stream/cpu"})
+		require.NoError(t, err)
+
+		settings := ChannelRuleSettings{Auth: &ChannelAuthConfig{Subscribe: &ChannelAuthCheckConfig{RequireRole: "Editor"}}}
+		updated, err := s.UpdateChannelRule(ctx, 1, ChannelRuleUpdateCmd{Pattern: "stream/cpu", Settings: settings, Version: rule.Version})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), updated.Version)
+
+		_, err = s.UpdateChannelRule(ctx, 1, ChannelRuleUpdateCmd{Pattern: "stream/cpu", Version: rule.Version})
+		require.ErrorIs(t, err, ErrVersionMismatch)
+
+		// Updates without a version overwrite the rule.
+		updated, err = s.UpdateChannelRule(ctx, 1, ChannelRuleUpdateCmd{Pattern: "stream/cpu", Settings: settings})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), updated.Version)
+
+		rules, err := s.ListChannelRules(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, settings, rules[0].Settings)
+	})
+
+	t.Run("update creates missing rules without a version", func(t *testing.T) {
+		s := setupSQLStorage(t)
+
+		_, err := s.UpdateChannelRule(ctx, 1, ChannelRuleUpdateCmd{Pattern: "stream/cpu", Version: 1})
+		require.ErrorIs(t, err, ErrChannelRuleNotFound)
+
+		rule, err := s.UpdateChannelRule(ctx, 1, ChannelRuleUpdateCmd{Pattern: "stream/cpu"})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), rule.Version)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s := setupSQLStorage(t)
+
+		_, err := s.CreateChannelRule(ctx, 1, ChannelRuleCreateCmd{Pattern: "stream/cpu"})
+		require.NoError(t, err)
+
+		require.ErrorIs(t, s.DeleteChannelRule(ctx, 2, ChannelRuleDeleteCmd{Pattern: "stream/cpu"}), ErrChannelRuleNotFound)
+		require.NoError(t, s.DeleteChannelRule(ctx, 1, ChannelRuleDeleteCmd{Pattern: "stream/cpu"}))
+		require.ErrorIs(t, s.DeleteChannelRule(ctx, 1, ChannelRuleDeleteCmd{Pattern: "stream/cpu"}), ErrChannelRuleNotFound)
+	})
+}
+
+func TestSQLStorage_WriteConfigs(t *testing.T) {
+	ctx := context.Background()
+	s := setupSQLStorage(t)
+
+	writeConfig, err := s.CreateWriteConfig(ctx, 1, WriteConfigCreateCmd{
+		Settings:       WriteSettings{Endpoint: "http://localhost:9090/api/v1/write"},
+		SecureSettings: map[string]string{"basicAuthPassword": "secret"},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, writeConfig.UID)
+
+	_, err = s.CreateWriteConfig(ctx, 1, WriteConfigCreateCmd{UID: "no-endpoint"})
+	require.Error(t, err)
+
+	existing, ok, err := s.GetWriteConfig(ctx, 1, WriteConfigGetCmd{UID: writeConfig.UID})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, writeConfig, existing)
+	password, err := s.secretsService.Decrypt(ctx, existing.SecureSettings["basicAuthPassword"])
+	require.NoError(t, err)
+	require.Equal(t, "secret", string(password))
+
+	_, ok, err = s.GetWriteConfig(ctx, 2, WriteConfigGetCmd{UID: writeConfig.UID})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	updated, err := s.UpdateWriteConfig(ctx, 1, WriteConfigUpdateCmd{
+		UID:      writeConfig.UID,
+		Settings: WriteSettings{Endpoint: "http://localhost:9091/api/v1/write"},
+		Version:  writeConfig.Version,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), updated.Version)
+	require.Empty(t, updated.SecureSettings)
+
+	_, err = s.UpdateWriteConfig(ctx, 1, WriteConfigUpdateCmd{
+		UID:      writeConfig.UID,
+		Settings: WriteSettings{Endpoint: "http://localhost:9092/api/v1/write"},
+		Version:  writeConfig.Version,
+	})
+	require.ErrorIs(t, err, ErrVersionMismatch)
+
+	writeConfigs, err := s.ListWriteConfigs(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []WriteConfig{updated}, writeConfigs)
+
+	require.NoError(t, s.DeleteWriteConfig(ctx, 1, WriteConfigDeleteCmd{UID: writeConfig.UID}))
+	require.ErrorIs(t, s.DeleteWriteConfig(ctx, 1, WriteConfigDeleteCmd{UID: writeConfig.UID}), ErrWriteConfigNotFound)
+}
+
+func TestSQLStorage_OnChange(t *testing.T) {
+	ctx := context.Background()
+	store := initTestDB(t)
+
+	// Two storages with the same database, like two Grafana server instances.
+	s1 := NewSQLStorage(store, fakes.NewFakeSecretsService(), 0)
+	s2 := NewSQLStorage(store, fakes.NewFakeSecretsService(), 0)
+
+	var mu sync.Mutex
+	var changes1, changes2 []int64
+	s1.OnChange(func(orgID int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes1 = append(changes1, orgID)
+	})
+	s2.OnChange(func(orgID int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes2 = append(changes2, orgID)
+	})
+
+	_, err := s1.CreateChannelRule(ctx, 2, ChannelRuleCreateCmd{Pattern: "stream/cpu"})
+	require.NoError(t, err)
+	require.Equal(t, []int64{2}, changes1)
+	require.Empty(t, changes2)
+
+	require.NoError(t, s2.checkRevisions(ctx))
+	require.Equal(t, []int64{2}, changes2)
+
+	// Changes are only reported once.
+	require.NoError(t, s1.checkRevisions(ctx))
+	require.NoError(t, s2.checkRevisions(ctx))
+	require.Equal(t, []int64{2}, changes1)
+	require.Equal(t, []int64{2}, changes2)
+
+	require.NoError(t, s2.DeleteChannelRule(ctx, 2, ChannelRuleDeleteCmd{Pattern: "stream/cpu"}))
+	require.NoError(t, s1.checkRevisions(ctx))
+	require.Equal(t, []int64{2, 2}, changes1)
+	require.Equal(t, []int64{2, 2}, changes2)
+}
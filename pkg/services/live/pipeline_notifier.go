@@ -0,0 +1,51 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/ngalert"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// pipelineAnnotationSaver saves annotations of pipeline outputs with
+// the annotations repository.
+type pipelineAnnotationSaver struct {
+	sqlStore *sqlstore.SQLStore
+}
+
+func (s *pipelineAnnotationSaver) SaveAnnotation(ctx context.Context, dashboardUID string, item *annotations.Item) error {
+	if dashboardUID != "" {
+		query := &models.GetDashboardQuery{Uid: dashboardUID, OrgId: item.OrgId}
+		if err := s.sqlStore.GetDashboard(ctx, query); err != nil {
+			return fmt.Errorf("error getting dashboard %s: %w", dashboardUID, err)
+		}
+		item.DashboardId = query.Result.Id
+	}
+	repo := annotations.GetRepository()
+	if repo == nil {
+		return errors.New("annotations repository not initialized")
+	}
+	return repo.Save(item)
+}
+
+// pipelineAlertSender posts alerts of pipeline outputs to the Grafana
+// Alertmanager of an organization.
+type pipelineAlertSender struct {
+	alertNG *ngalert.AlertNG
+}
+
+func (s *pipelineAlertSender) SendAlerts(orgID int64, alerts apimodels.PostableAlerts) error {
+	if s.alertNG == nil || s.alertNG.IsDisabled() || s.alertNG.MultiOrgAlertmanager == nil {
+		return errors.New("unified alerting is disabled")
+	}
+	am, err := s.alertNG.MultiOrgAlertmanager.AlertmanagerFor(orgID)
+	if err != nil {
+		return err
+	}
+	return am.PutAlerts(alerts)
+}
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/live/convert"
 	"github.com/grafana/grafana/pkg/services/live/pushurl"
+	"github.com/grafana/grafana/pkg/services/live/remotewrite"
 	"github.com/grafana/grafana/pkg/setting"
 
 	liveDto "github.com/grafana/grafana-plugin-sdk-go/live"
@@ -21,6 +23,13 @@ var (
 	logger = log.New("live.push_http")
 )
 
+const (
+	// maxRemoteWriteBodySize limits the size of compressed remote write requests.
+	maxRemoteWriteBodySize = 10 << 20
+	// maxRemoteWriteDecodedSize limits the size of remote write requests once decoded.
+	maxRemoteWriteDecodedSize = 64 << 20
+)
+
 func ProvideService(cfg *setting.Cfg, live *live.GrafanaLive) *Gateway {
 	logger.Info("Live Push Gateway initialization")
 	g := &Gateway{
@@ -127,3 +136,65 @@ func (g *Gateway) HandlePipelinePush(ctx *models.ReqContext) {
 		return
 	}
 }
+
+// HandlePipelineRemoteWrite receives Prometheus remote write requests. Each
+// series is processed according to the channel rule of the channel named after
+// the metric in the channel given in the URL, like stream/prometheus/up. Series
+// without a rule are dropped.
+func (g *Gateway) HandlePipelineRemoteWrite(ctx *models.ReqContext) {
+	channelPrefix := strings.TrimSuffix(web.Params(ctx.Req)["*"], "/")
+	if _, err := liveDto.ParseChannel(channelPrefix + "/metric"); err != nil {
+		logger.Error("Invalid remote write channel", "error", err, "channel", channelPrefix)
+		ctx.Resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(ctx.Req.Body, maxRemoteWriteBodySize+1))
+	if err != nil {
+		logger.Error("Error reading body", "error", err)
+		ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(body) > maxRemoteWriteBodySize {
+		ctx.Resp.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	series, err := remotewrite.TimeSeriesFromBytes(body, maxRemoteWriteDecodedSize)
+	if errors.Is(err, remotewrite.ErrTooLarge) {
+		ctx.Resp.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		logger.Error("Error decoding remote write request", "error", err)
+		ctx.Resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var dropped int
+	for _, ts := range series {
+		frame, ok := remotewrite.FrameFromTimeSeries(ts)
+		if !ok {
+			dropped++
+			continue
+		}
+		// Recording rule names can contain colons, which are not allowed in channels.
+		channelID := channelPrefix + "/" + strings.ReplaceAll(frame.Name, ":", "_")
+		ruleFound, err := g.GrafanaLive.Pipeline.ProcessInputFrame(ctx.Req.Context(), ctx.OrgId, channelID, frame)
+		if err != nil {
+			logger.Error("Pipeline input processing error", "error", err, "channel", channelID)
+			ctx.Resp.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !ruleFound {
+			dropped++
+		}
+	}
+	logger.Debug("Live remote write request",
+		"channel", channelPrefix,
+		"bodyLength", len(body),
+		"series", len(series),
+		"dropped", dropped,
+	)
+	ctx.Resp.WriteHeader(http.StatusNoContent)
+}
@@ -1,6 +1,7 @@
 package remotewrite
 
 import (
+	"errors"
 	"fmt"
 	"hash/fnv"
 	"strings"
@@ -14,6 +15,10 @@ import (
 
 type metricKey uint64
 
+// ErrTooLarge is returned when a remote write request decodes to more bytes
+// than allowed.
+var ErrTooLarge = errors.New("remote write request is too large")
+
 // Serialize frames to Prometheus remote write format.
 func Serialize(frames ...*data.Frame) ([]byte, error) {
 	return TimeSeriesToBytes(TimeSeriesFromFrames(frames...))
@@ -241,3 +246,55 @@ func createLabels(fieldLabels map[string]string) []prompb.Label {
 	}
 	return labels
 }
+
+// TimeSeriesFromBytes converts snappy compressed byte slice of a Prometheus
+// remote write request to Prometheus TimeSeries. The decoded request can't be
+// larger than maxDecodedSize.
+func TimeSeriesFromBytes(b []byte, maxDecodedSize int) ([]prompb.TimeSeries, error) {
+	// Decode allocates the length claimed by the header before decoding
+	decodedSize, err := snappy.DecodedLen(b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode snappy: %w", err)
+	}
+	if decodedSize > maxDecodedSize {
+		return nil, ErrTooLarge
+	}
+
+	writeRequestData, err := snappy.Decode(nil, b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode snappy: %w", err)
+	}
+	var writeRequest prompb.WriteRequest
+	if err := proto.Unmarshal(writeRequestData, &writeRequest); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal protobuf: %w", err)
+	}
+	return writeRequest.Timeseries, nil
+}
+
+// FrameFromTimeSeries converts Prometheus TimeSeries to a frame named by the
+// metric name with a time field and a value field with the labels of the series.
+// Returns false if the series has no metric name.
+func FrameFromTimeSeries(ts prompb.TimeSeries) (*data.Frame, bool) {
+	var metricName string
+	labels := make(data.Labels, len(ts.Labels))
+	for _, label := range ts.Labels {
+		if label.Name == "__name__" {
+			metricName = label.Value
+			continue
+		}
+		labels[label.Name] = label.Value
+	}
+	if metricName == "" {
+		return nil, false
+	}
+	times := make([]time.Time, 0, len(ts.Samples))
+	values := make([]float64, 0, len(ts.Samples))
+	for _, sample := range ts.Samples {
+		times = append(times, time.Unix(0, sample.Timestamp*int64(time.Millisecond)))
+		values = append(values, sample.Value)
+	}
+	return data.NewFrame(metricName,
+		data.NewField("time", nil, times),
+		data.NewField("value", labels, values),
+	), true
+}
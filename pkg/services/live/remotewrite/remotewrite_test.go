@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/require"
 )
 
@@ -85,3 +86,37 @@ func TestSerialize(t *testing.T) {
 	_, err := Serialize(frame)
 	require.NoError(t, err)
 }
+
+func TestTimeSeriesFromBytes(t *testing.T) {
+	now := time.Unix(1640000000, 0)
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{now, now.Add(time.Second)}),
+		data.NewField("value", data.Labels{"host": "a"}, []float64{1.0, 2.0}),
+	)
+	b, err := Serialize(frame)
+	require.NoError(t, err)
+
+	series, err := TimeSeriesFromBytes(b, 1<<20)
+	require.NoError(t, err)
+	require.Len(t, series, 1)
+
+	result, ok := FrameFromTimeSeries(series[0])
+	require.True(t, ok)
+	require.Equal(t, "test_value", result.Name)
+	require.Equal(t, now, result.Fields[0].At(0).(time.Time))
+	require.Equal(t, 2.0, result.Fields[1].At(1))
+	require.Equal(t, data.Labels{"host": "a"}, result.Fields[1].Labels)
+
+	_, err = TimeSeriesFromBytes([]byte("not snappy"), 1<<20)
+	require.Error(t, err)
+
+	_, err = TimeSeriesFromBytes(b, 1)
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	// a few bytes can claim a huge decoded length
+	_, err = TimeSeriesFromBytes([]byte{0xff, 0xff, 0xff, 0xff, 0x0f}, 1<<20)
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	_, ok = FrameFromTimeSeries(prompb.TimeSeries{})
+	require.False(t, ok)
+}
@@ -101,7 +101,7 @@ func (ls *Implementation) UpsertUser(ctx context.Context, cmd *models.UpsertUser
 			}
 		}
 
-		if extUser.AuthModule == models.AuthModuleLDAP && user.IsDisabled {
+		if extUser.AuthModule == models.AuthModuleLDAP && user.IsDisabled && !extUser.IsDisabled {
 			// Re-enable user when it found in LDAP
 			if err := ls.SQLStore.DisableUser(ctx, &models.DisableUserCommand{UserId: cmd.Result.Id, IsDisabled: false}); err != nil {
 				return err
@@ -120,6 +120,10 @@ func (ls *Implementation) UpsertUser(ctx context.Context, cmd *models.UpsertUser
 		}
 	}
 
+	if err := ls.syncTeams(ctx, cmd.Result, extUser); err != nil {
+		return err
+	}
+
 	if ls.TeamSync != nil {
 		err := ls.TeamSync(cmd.Result, extUser)
 		if err != nil {
@@ -268,3 +272,79 @@ func (ls *Implementation) syncOrgRoles(ctx context.Context, user *models.User, e
 
 	return nil
 }
+
+// syncTeams syncs the team memberships an external provider grants. Only
+// external memberships are removed, members added by hand keep their teams.
+func (ls *Implementation) syncTeams(ctx context.Context, user *models.User, extUser *models.ExternalUserInfo) error {
+	// don't sync teams if the provider doesn't map any
+	if extUser.Teams == nil {
+		return nil
+	}
+
+	logger.Debug("Syncing teams", "id", user.Id, "extTeams", extUser.Teams)
+
+	wanted := make(map[int64]models.ExternalTeam, len(extUser.Teams))
+	for _, team := range extUser.Teams {
+		wanted[team.TeamId] = team
+	}
+
+	memberships, err := ls.SQLStore.GetUserTeamMemberships(ctx, 0, user.Id, true)
+	if err != nil {
+		return err
+	}
+
+	handledTeamIds := map[int64]bool{}
+	for _, membership := range memberships {
+		handledTeamIds[membership.TeamId] = true
+		if _, ok := wanted[membership.TeamId]; ok {
+			continue
+		}
+
+		logger.Debug("Removing user's team membership as part of syncing with external login",
+			"userId", user.Id, "teamId", membership.TeamId)
+		cmd := &models.RemoveTeamMemberCommand{OrgId: membership.OrgId, UserId: user.Id, TeamId: membership.TeamId}
+		if err := ls.SQLStore.RemoveTeamMember(ctx, cmd); err != nil {
+			if errors.Is(err, models.ErrLastTeamAdmin) || errors.Is(err, models.ErrTeamMemberNotFound) {
+				logger.Warn("Failed to remove user from team", "userId", user.Id, "teamId", membership.TeamId, "error", err)
+				continue
+			}
+			return err
+		}
+	}
+
+	orgsQuery := &models.GetUserOrgListQuery{UserId: user.Id}
+	if err := ls.SQLStore.GetUserOrgList(ctx, orgsQuery); err != nil {
+		return err
+	}
+	isOrgMember := map[int64]bool{}
+	for _, org := range orgsQuery.Result {
+		isOrgMember[org.OrgId] = true
+	}
+
+	for teamId, team := range wanted {
+		if handledTeamIds[teamId] {
+			continue
+		}
+
+		if !isOrgMember[team.OrgId] {
+			logger.Debug("Not adding user to team of an organization they're not a member of",
+				"userId", user.Id, "teamId", teamId, "orgId", team.OrgId)
+			continue
+		}
+
+		if err := ls.SQLStore.GetTeamById(ctx, &models.GetTeamByIdQuery{OrgId: team.OrgId, Id: teamId}); err != nil {
+			if errors.Is(err, models.ErrTeamNotFound) {
+				logger.Warn("Team of team_mapping not found", "teamId", teamId, "orgId", team.OrgId)
+				continue
+			}
+			return err
+		}
+
+		err := ls.SQLStore.AddTeamMember(user.Id, team.OrgId, teamId, true, 0)
+		if err != nil && !errors.Is(err, models.ErrTeamMemberAlreadyAdded) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log/level"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/mockstore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -136,6 +137,95 @@ func Test_teamSync(t *testing.T) {
 	})
 }
 
+func Test_syncTeams(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	login := Implementation{SQLStore: sqlStore}
+
+	user, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: "test_user", Email: "test_user@example.org"})
+	require.NoError(t, err)
+	otherOrg, err := sqlStore.CreateOrgWithMember("other", 0)
+	require.NoError(t, err)
+
+	devs, err := sqlStore.CreateTeam("devs", "", user.OrgId)
+	require.NoError(t, err)
+	ops, err := sqlStore.CreateTeam("ops", "", user.OrgId)
+	require.NoError(t, err)
+	manual, err := sqlStore.CreateTeam("manual", "", user.OrgId)
+	require.NoError(t, err)
+	other, err := sqlStore.CreateTeam("other", "", otherOrg.Id)
+	require.NoError(t, err)
+	require.NoError(t, sqlStore.AddTeamMember(user.Id, user.OrgId, manual.Id, false, 0))
+
+	teamIds := func() []int64 {
+		memberships, err := sqlStore.GetUserTeamMemberships(ctx, 0, user.Id, false)
+		require.NoError(t, err)
+		ids := []int64{}
+		for _, m := range memberships {
+			ids = append(ids, m.TeamId)
+		}
+		return ids
+	}
+
+	t.Run("teams are not synced without a team mapping", func(t *testing.T) {
+		require.NoError(t, login.syncTeams(ctx, user, &models.ExternalUserInfo{}))
+		assert.ElementsMatch(t, []int64{manual.Id}, teamIds())
+	})
+
+	t.Run("user is added to mapped teams of their organizations", func(t *testing.T) {
+		extUser := &models.ExternalUserInfo{Teams: []models.ExternalTeam{
+			{OrgId: user.OrgId, TeamId: devs.Id},
+			{OrgId: user.OrgId, TeamId: ops.Id},
+			{OrgId: user.OrgId, TeamId: manual.Id},
+			{OrgId: otherOrg.Id, TeamId: other.Id},
+			{OrgId: user.OrgId, TeamId: 999},
+		}}
+		require.NoError(t, login.syncTeams(ctx, user, extUser))
+		assert.ElementsMatch(t, []int64{manual.Id, devs.Id, ops.Id}, teamIds())
+	})
+
+	t.Run("user is removed from teams they are no longer mapped to", func(t *testing.T) {
+		extUser := &models.ExternalUserInfo{Teams: []models.ExternalTeam{{OrgId: user.OrgId, TeamId: ops.Id}}}
+		require.NoError(t, login.syncTeams(ctx, user, extUser))
+		assert.ElementsMatch(t, []int64{manual.Id, ops.Id}, teamIds())
+
+		require.NoError(t, login.syncTeams(ctx, user, &models.ExternalUserInfo{Teams: []models.ExternalTeam{}}))
+		assert.ElementsMatch(t, []int64{manual.Id}, teamIds())
+	})
+}
+
+func Test_UpsertUser_reenablesLDAPUsers(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	authInfoMock := &authInfoServiceMock{}
+	login := Implementation{SQLStore: sqlStore, AuthInfoService: authInfoMock}
+
+	user, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: "test_user", Email: "test_user@example.org"})
+	require.NoError(t, err)
+	authInfoMock.user = user
+
+	isDisabled := func() bool {
+		query := &models.GetUserByIdQuery{Id: user.Id}
+		require.NoError(t, sqlStore.GetUserById(ctx, query))
+		return query.Result.IsDisabled
+	}
+
+	t.Run("user not matching any group mapping stays disabled", func(t *testing.T) {
+		require.NoError(t, sqlStore.DisableUser(ctx, &models.DisableUserCommand{UserId: user.Id, IsDisabled: true}))
+		user.IsDisabled = true
+
+		cmd := &models.UpsertUserCommand{ExternalUser: &models.ExternalUserInfo{AuthModule: models.AuthModuleLDAP, IsDisabled: true}}
+		require.NoError(t, login.UpsertUser(ctx, cmd))
+		assert.True(t, isDisabled())
+	})
+
+	t.Run("user found in LDAP is enabled again", func(t *testing.T) {
+		cmd := &models.UpsertUserCommand{ExternalUser: &models.ExternalUserInfo{AuthModule: models.AuthModuleLDAP}}
+		require.NoError(t, login.UpsertUser(ctx, cmd))
+		assert.False(t, isDisabled())
+	})
+}
+
 func createSimpleUser() models.User {
 	user := models.User{
 		Id: 1,
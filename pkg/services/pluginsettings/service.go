@@ -23,9 +23,14 @@ type Service struct {
 
 type cachedDecryptedJSON struct {
 	updated time.Time
+	expires time.Time
 	json    map[string]string
 }
 
+// decryptionCacheTTL is how long decrypted secure JSON data is cached, so
+// that references to external secrets are resolved again regularly.
+const decryptionCacheTTL = time.Minute
+
 type secureJSONDecryptionCache struct {
 	cache map[int64]cachedDecryptedJSON
 	sync.Mutex
@@ -71,7 +76,7 @@ func (s *Service) DecryptedValues(ps *models.PluginSetting) map[string]string {
 	s.pluginSettingDecryptionCache.Lock()
 	defer s.pluginSettingDecryptionCache.Unlock()
 
-	if item, present := s.pluginSettingDecryptionCache.cache[ps.Id]; present && ps.Updated.Equal(item.updated) {
+	if item, present := s.pluginSettingDecryptionCache.cache[ps.Id]; present && ps.Updated.Equal(item.updated) && time.Now().Before(item.expires) {
 		return item.json
 	}
 
@@ -83,6 +88,7 @@ func (s *Service) DecryptedValues(ps *models.PluginSetting) map[string]string {
 
 	s.pluginSettingDecryptionCache.cache[ps.Id] = cachedDecryptedJSON{
 		updated: ps.Updated,
+		expires: time.Now().Add(decryptionCacheTTL),
 		json:    json,
 	}
 
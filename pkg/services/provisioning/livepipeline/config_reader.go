@@ -0,0 +1,125 @@
+package livepipeline
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"gopkg.in/yaml.v2"
+)
+
+type configReader struct {
+	log log.Logger
+}
+
+func (cr *configReader) readConfig(path string) ([]*configs, error) {
+	var result []*configs
+	cr.log.Debug("Looking for Live pipeline provisioning files", "path", path)
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		cr.log.Error("Failed to read Live pipeline provisioning files from directory", "path", path, "error", err)
+		return result, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing Live pipeline provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parseConfig(path, file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file.Name(), err)
+			}
+			result = append(result, cfg)
+		}
+	}
+
+	if err := validateRequiredFields(result); err != nil {
+		return nil, err
+	}
+	setDefaultOrgID(result)
+	return result, nil
+}
+
+func (cr *configReader) parseConfig(path string, file os.FileInfo) (*configs, error) {
+	filename, err := filepath.Abs(filepath.Join(path, file.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *configsV1
+	if err := yaml.Unmarshal(yamlFile, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg != nil && cfg.APIVersion.Value() > 1 {
+		return nil, fmt.Errorf("unsupported apiVersion %d", cfg.APIVersion.Value())
+	}
+	return cfg.mapToConfigs()
+}
+
+func validateRequiredFields(cfgs []*configs) error {
+	var errStrings []string
+	for _, cfg := range cfgs {
+		for i, rule := range cfg.ChannelRules {
+			if rule.Pattern == "" {
+				errStrings = append(errStrings, fmt.Sprintf("channel rule item %d in configuration doesn't contain required field pattern", i+1))
+			}
+		}
+		for i, rule := range cfg.DeleteChannelRules {
+			if rule.Pattern == "" {
+				errStrings = append(errStrings, fmt.Sprintf("delete channel rule item %d in configuration doesn't contain required field pattern", i+1))
+			}
+		}
+		for i, writeConfig := range cfg.WriteConfigs {
+			if writeConfig.UID == "" {
+				errStrings = append(errStrings, fmt.Sprintf("write config item %d in configuration doesn't contain required field uid", i+1))
+			}
+		}
+		for i, writeConfig := range cfg.DeleteWriteConfigs {
+			if writeConfig.UID == "" {
+				errStrings = append(errStrings, fmt.Sprintf("delete write config item %d in configuration doesn't contain required field uid", i+1))
+			}
+		}
+	}
+	if len(errStrings) != 0 {
+		return fmt.Errorf(strings.Join(errStrings, "\n"))
+	}
+	return nil
+}
+
+func setDefaultOrgID(cfgs []*configs) {
+	for _, cfg := range cfgs {
+		for _, rule := range cfg.ChannelRules {
+			if rule.OrgID < 1 {
+				rule.OrgID = 1
+			}
+		}
+		for _, rule := range cfg.DeleteChannelRules {
+			if rule.OrgID < 1 {
+				rule.OrgID = 1
+			}
+		}
+		for _, writeConfig := range cfg.WriteConfigs {
+			if writeConfig.OrgID < 1 {
+				writeConfig.OrgID = 1
+			}
+		}
+		for _, writeConfig := range cfg.DeleteWriteConfigs {
+			if writeConfig.OrgID < 1 {
+				writeConfig.OrgID = 1
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package livepipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+)
+
+// Provision scans a directory for provisioning config files and applies the
+// Live pipeline channel rules and write configs in those files to the storage.
+func Provision(ctx context.Context, configDirectory string, storage pipeline.Storage) error {
+	p := &Provisioner{
+		log:       log.New("provisioning.live"),
+		storage:   storage,
+		cfgReader: &configReader{log: log.New("provisioning.live")},
+	}
+	return p.applyChanges(ctx, configDirectory)
+}
+
+// Provisioner is responsible for provisioning Live pipeline channel rules and
+// write configs based on configuration read by the `configReader`.
+type Provisioner struct {
+	log       log.Logger
+	storage   pipeline.Storage
+	cfgReader *configReader
+}
+
+func (p *Provisioner) apply(ctx context.Context, cfg *configs) error {
+	for _, rule := range cfg.DeleteChannelRules {
+		p.log.Info("Deleting channel rule from configuration", "pattern", rule.Pattern, "orgId", rule.OrgID)
+		err := p.storage.DeleteChannelRule(ctx, rule.OrgID, pipeline.ChannelRuleDeleteCmd{Pattern: rule.Pattern})
+		if err != nil && !errors.Is(err, pipeline.ErrChannelRuleNotFound) {
+			return fmt.Errorf("failed to delete channel rule %q: %w", rule.Pattern, err)
+		}
+	}
+
+	for _, writeConfig := range cfg.DeleteWriteConfigs {
+		p.log.Info("Deleting write config from configuration", "uid", writeConfig.UID, "orgId", writeConfig.OrgID)
+		err := p.storage.DeleteWriteConfig(ctx, writeConfig.OrgID, pipeline.WriteConfigDeleteCmd{UID: writeConfig.UID})
+		if err != nil && !errors.Is(err, pipeline.ErrWriteConfigNotFound) {
+			return fmt.Errorf("failed to delete write config %q: %w", writeConfig.UID, err)
+		}
+	}
+
+	// Write configs first, since channel rules can refer to them.
+	for _, writeConfig := range cfg.WriteConfigs {
+		p.log.Info("Updating write config from configuration", "uid", writeConfig.UID, "orgId", writeConfig.OrgID)
+		_, err := p.storage.UpdateWriteConfig(ctx, writeConfig.OrgID, pipeline.WriteConfigUpdateCmd{
+			UID:            writeConfig.UID,
+			Settings:       writeConfig.Settings,
+			SecureSettings: writeConfig.SecureSettings,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update write config %q: %w", writeConfig.UID, err)
+		}
+	}
+
+	for _, rule := range cfg.ChannelRules {
+		p.log.Info("Updating channel rule from configuration", "pattern", rule.Pattern, "orgId", rule.OrgID)
+		_, err := p.storage.UpdateChannelRule(ctx, rule.OrgID, pipeline.ChannelRuleUpdateCmd{
+			Pattern:  rule.Pattern,
+			Settings: rule.Settings,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update channel rule %q: %w", rule.Pattern, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Provisioner) applyChanges(ctx context.Context, configPath string) error {
+	cfgs, err := p.cfgReader.readConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range cfgs {
+		if err := p.apply(ctx, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
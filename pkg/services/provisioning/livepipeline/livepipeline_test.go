@@ -0,0 +1,102 @@
+package livepipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	brokenYaml        = "./testdata/broken-yaml"
+	incorrectSettings = "./testdata/incorrect-settings"
+	unknownSettings   = "./testdata/unknown-settings"
+	correctProperties = "./testdata/correct-properties"
+)
+
+func TestConfigReader(t *testing.T) {
+	reader := &configReader{log: log.New("test logger")}
+
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		_, err := reader.readConfig(brokenYaml)
+		require.Error(t, err)
+	})
+
+	t.Run("Skip missing directory", func(t *testing.T) {
+		cfgs, err := reader.readConfig("./testdata/missing")
+		require.NoError(t, err)
+		require.Len(t, cfgs, 0)
+	})
+
+	t.Run("Read incorrect properties", func(t *testing.T) {
+		_, err := reader.readConfig(incorrectSettings)
+		require.Error(t, err)
+		require.Equal(t, "channel rule item 1 in configuration doesn't contain required field pattern\n"+
+			"write config item 1 in configuration doesn't contain required field uid", err.Error())
+	})
+
+	t.Run("Unknown settings should return error", func(t *testing.T) {
+		_, err := reader.readConfig(unknownSettings)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `invalid settings of channel rule "stream/cpu"`)
+	})
+
+	t.Run("Can read correct properties", func(t *testing.T) {
+		t.Setenv("LIVE_PIPELINE_PASSWORD", "secret")
+
+		cfgs, err := reader.readConfig(correctProperties)
+		require.NoError(t, err)
+		require.Len(t, cfgs, 1)
+
+		cfg := cfgs[0]
+		require.Len(t, cfg.ChannelRules, 2)
+		rule := cfg.ChannelRules[0]
+		require.Equal(t, int64(1), rule.OrgID)
+		require.Equal(t, "stream/telegraf/:metric", rule.Pattern)
+		require.Equal(t, "influxAuto", rule.Settings.Converter.Type)
+		require.Equal(t, "labels_column", rule.Settings.Converter.AutoInfluxConverterConfig.FrameFormat)
+		require.Len(t, rule.Settings.FrameOutputters, 2)
+		require.Equal(t, "prometheus", rule.Settings.FrameOutputters[1].RemoteWriteOutputConfig.UID)
+		require.Equal(t, int64(2), cfg.ChannelRules[1].OrgID)
+
+		require.Len(t, cfg.DeleteChannelRules, 1)
+		require.Equal(t, int64(2), cfg.DeleteChannelRules[0].OrgID)
+
+		require.Len(t, cfg.WriteConfigs, 1)
+		writeConfig := cfg.WriteConfigs[0]
+		require.Equal(t, "prometheus", writeConfig.UID)
+		require.Equal(t, "http://localhost:9090/api/v1/write", writeConfig.Settings.Endpoint)
+		require.Equal(t, "grafana", writeConfig.Settings.BasicAuth.User)
+		require.Equal(t, map[string]string{"basicAuthPassword": "secret"}, writeConfig.SecureSettings)
+	})
+}
+
+func TestProvision(t *testing.T) {
+	ctx := context.Background()
+	storage := pipeline.NewSQLStorage(sqlstore.InitTestDB(t, sqlstore.InitTestDBOpt{FeatureFlags: []string{featuremgmt.FlagLivePipeline}}), fakes.NewFakeSecretsService(), 0)
+
+	// Provisioning is applied on every start, so it must be repeatable.
+	for i := 0; i < 2; i++ {
+		require.NoError(t, Provision(ctx, correctProperties, storage))
+	}
+
+	rules, err := storage.ListChannelRules(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, "stream/telegraf/:metric", rules[0].Pattern)
+
+	rules, err = storage.ListChannelRules(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	writeConfig, ok, err := storage.GetWriteConfig(ctx, 1, pipeline.WriteConfigGetCmd{UID: "prometheus"})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "http://localhost:9090/api/v1/write", writeConfig.Settings.Endpoint)
+	require.Contains(t, writeConfig.SecureSettings, "basicAuthPassword")
+}
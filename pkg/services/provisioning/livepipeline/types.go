@@ -0,0 +1,134 @@
+package livepipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// configs is a normalized data object for Live pipeline config data. Any config version should be mappable
+// to this type.
+type configs struct {
+	ChannelRules       []*channelRuleFromConfig
+	DeleteChannelRules []*deleteChannelRuleConfig
+	WriteConfigs       []*writeConfigFromConfig
+	DeleteWriteConfigs []*deleteWriteConfigConfig
+}
+
+type channelRuleFromConfig struct {
+	OrgID    int64
+	Pattern  string
+	Settings pipeline.ChannelRuleSettings
+}
+
+type deleteChannelRuleConfig struct {
+	OrgID   int64
+	Pattern string
+}
+
+type writeConfigFromConfig struct {
+	OrgID          int64
+	UID            string
+	Settings       pipeline.WriteSettings
+	SecureSettings map[string]string
+}
+
+type deleteWriteConfigConfig struct {
+	OrgID int64
+	UID   string
+}
+
+type configsV1 struct {
+	APIVersion         values.Int64Value            `json:"apiVersion" yaml:"apiVersion"`
+	ChannelRules       []*channelRuleFromConfigV1   `json:"channelRules" yaml:"channelRules"`
+	DeleteChannelRules []*deleteChannelRuleConfigV1 `json:"deleteChannelRules" yaml:"deleteChannelRules"`
+	WriteConfigs       []*writeConfigFromConfigV1   `json:"writeConfigs" yaml:"writeConfigs"`
+	DeleteWriteConfigs []*deleteWriteConfigConfigV1 `json:"deleteWriteConfigs" yaml:"deleteWriteConfigs"`
+}
+
+type channelRuleFromConfigV1 struct {
+	OrgID    values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Pattern  values.StringValue `json:"pattern" yaml:"pattern"`
+	Settings values.JSONValue   `json:"settings" yaml:"settings"`
+}
+
+type deleteChannelRuleConfigV1 struct {
+	OrgID   values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Pattern values.StringValue `json:"pattern" yaml:"pattern"`
+}
+
+type writeConfigFromConfigV1 struct {
+	OrgID          values.Int64Value     `json:"orgId" yaml:"orgId"`
+	UID            values.StringValue    `json:"uid" yaml:"uid"`
+	Settings       values.JSONValue      `json:"settings" yaml:"settings"`
+	SecureSettings values.StringMapValue `json:"secureSettings" yaml:"secureSettings"`
+}
+
+type deleteWriteConfigConfigV1 struct {
+	OrgID values.Int64Value  `json:"orgId" yaml:"orgId"`
+	UID   values.StringValue `json:"uid" yaml:"uid"`
+}
+
+// mapToConfigs maps config syntax to a normalized configs object. Settings are decoded like
+// the settings sent to the HTTP API, unknown fields are rejected to catch typos.
+func (cfg *configsV1) mapToConfigs() (*configs, error) {
+	r := &configs{}
+	if cfg == nil {
+		return r, nil
+	}
+
+	for _, rule := range cfg.ChannelRules {
+		c := &channelRuleFromConfig{
+			OrgID:   rule.OrgID.Value(),
+			Pattern: rule.Pattern.Value(),
+		}
+		if err := decodeSettings(rule.Settings.Value(), &c.Settings); err != nil {
+			return nil, fmt.Errorf("invalid settings of channel rule %q: %w", c.Pattern, err)
+		}
+		r.ChannelRules = append(r.ChannelRules, c)
+	}
+
+	for _, rule := range cfg.DeleteChannelRules {
+		r.DeleteChannelRules = append(r.DeleteChannelRules, &deleteChannelRuleConfig{
+			OrgID:   rule.OrgID.Value(),
+			Pattern: rule.Pattern.Value(),
+		})
+	}
+
+	for _, writeConfig := range cfg.WriteConfigs {
+		c := &writeConfigFromConfig{
+			OrgID:          writeConfig.OrgID.Value(),
+			UID:            writeConfig.UID.Value(),
+			SecureSettings: writeConfig.SecureSettings.Value(),
+		}
+		if err := decodeSettings(writeConfig.Settings.Value(), &c.Settings); err != nil {
+			return nil, fmt.Errorf("invalid settings of write config %q: %w", c.UID, err)
+		}
+		r.WriteConfigs = append(r.WriteConfigs, c)
+	}
+
+	for _, writeConfig := range cfg.DeleteWriteConfigs {
+		r.DeleteWriteConfigs = append(r.DeleteWriteConfigs, &deleteWriteConfigConfig{
+			OrgID: writeConfig.OrgID.Value(),
+			UID:   writeConfig.UID.Value(),
+		})
+	}
+
+	return r, nil
+}
+
+func decodeSettings(settings map[string]interface{}, v interface{}) error {
+	if settings == nil {
+		return nil
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
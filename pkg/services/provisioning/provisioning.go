@@ -9,29 +9,37 @@ import (
 	plugifaces "github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/encryption"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
+	"github.com/grafana/grafana/pkg/services/provisioning/livepipeline"
 	"github.com/grafana/grafana/pkg/services/provisioning/notifiers"
 	"github.com/grafana/grafana/pkg/services/provisioning/plugins"
+	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
 func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, pluginStore plugifaces.Store,
-	encryptionService encryption.Internal, notificatonService *notifications.NotificationService) (*ProvisioningServiceImpl, error) {
+	encryptionService encryption.Internal, notificatonService *notifications.NotificationService,
+	features featuremgmt.FeatureToggles, secretsService secrets.Service) (*ProvisioningServiceImpl, error) {
 	s := &ProvisioningServiceImpl{
 		Cfg:                     cfg,
 		SQLStore:                sqlStore,
 		pluginStore:             pluginStore,
 		EncryptionService:       encryptionService,
 		NotificationService:     notificatonService,
+		Features:                features,
+		SecretsService:          secretsService,
 		log:                     log.New("provisioning"),
 		newDashboardProvisioner: dashboards.New,
 		provisionNotifiers:      notifiers.Provision,
 		provisionDatasources:    datasources.Provision,
 		provisionPlugins:        plugins.Provision,
+		provisionLivePipeline:   livepipeline.Provision,
 	}
 	return s, nil
 }
@@ -43,6 +51,7 @@ type ProvisioningService interface {
 	ProvisionPlugins(ctx context.Context) error
 	ProvisionNotifications(ctx context.Context) error
 	ProvisionDashboards(ctx context.Context) error
+	ProvisionLivePipeline(ctx context.Context) error
 	GetDashboardProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
 }
@@ -55,6 +64,7 @@ func NewProvisioningServiceImpl() *ProvisioningServiceImpl {
 		provisionNotifiers:      notifiers.Provision,
 		provisionDatasources:    datasources.Provision,
 		provisionPlugins:        plugins.Provision,
+		provisionLivePipeline:   livepipeline.Provision,
 	}
 }
 
@@ -64,6 +74,7 @@ func newProvisioningServiceImpl(
 	provisionNotifiers func(context.Context, string, encryption.Internal, *notifications.NotificationService) error,
 	provisionDatasources func(context.Context, string) error,
 	provisionPlugins func(context.Context, string, plugifaces.Store) error,
+	provisionLivePipeline func(context.Context, string, pipeline.Storage) error,
 ) *ProvisioningServiceImpl {
 	return &ProvisioningServiceImpl{
 		log:                     log.New("provisioning"),
@@ -71,6 +82,7 @@ func newProvisioningServiceImpl(
 		provisionNotifiers:      provisionNotifiers,
 		provisionDatasources:    provisionDatasources,
 		provisionPlugins:        provisionPlugins,
+		provisionLivePipeline:   provisionLivePipeline,
 	}
 }
 
@@ -80,6 +92,8 @@ type ProvisioningServiceImpl struct {
 	pluginStore             plugifaces.Store
 	EncryptionService       encryption.Internal
 	NotificationService     *notifications.NotificationService
+	Features                featuremgmt.FeatureToggles
+	SecretsService          secrets.Service
 	log                     log.Logger
 	pollingCtxCancel        context.CancelFunc
 	newDashboardProvisioner dashboards.DashboardProvisionerFactory
@@ -87,6 +101,7 @@ type ProvisioningServiceImpl struct {
 	provisionNotifiers      func(context.Context, string, encryption.Internal, *notifications.NotificationService) error
 	provisionDatasources    func(context.Context, string) error
 	provisionPlugins        func(context.Context, string, plugifaces.Store) error
+	provisionLivePipeline   func(context.Context, string, pipeline.Storage) error
 	mutex                   sync.Mutex
 }
 
@@ -106,6 +121,11 @@ func (ps *ProvisioningServiceImpl) RunInitProvisioners(ctx context.Context) erro
 		return err
 	}
 
+	err = ps.ProvisionLivePipeline(ctx)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -168,6 +188,24 @@ func (ps *ProvisioningServiceImpl) ProvisionNotifications(ctx context.Context) e
 	return nil
 }
 
+// ProvisionLivePipeline provisions the Live pipeline channel rules and write configs, which are
+// only stored in the database with the database pipeline storage. Grafana servers pick up the
+// changes when they poll the pipeline storage.
+func (ps *ProvisioningServiceImpl) ProvisionLivePipeline(ctx context.Context) error {
+	if ps.Features == nil || !ps.Features.IsEnabled(featuremgmt.FlagLivePipeline) || ps.Cfg.LivePipelineStorage != "database" {
+		return nil
+	}
+
+	livePath := filepath.Join(ps.Cfg.ProvisioningPath, "live")
+	storage := pipeline.NewSQLStorage(ps.SQLStore, ps.SecretsService, pipeline.DefaultSQLStoragePollInterval)
+	if err := ps.provisionLivePipeline(ctx, livePath, storage); err != nil {
+		err = errutil.Wrap("Live pipeline provisioning error", err)
+		ps.log.Error("Failed to provision live pipeline", "error", err)
+		return err
+	}
+	return nil
+}
+
 func (ps *ProvisioningServiceImpl) ProvisionDashboards(ctx context.Context) error {
 	dashboardPath := filepath.Join(ps.Cfg.ProvisioningPath, "dashboards")
 	dashProvisioner, err := ps.newDashboardProvisioner(ctx, dashboardPath, ps.SQLStore)
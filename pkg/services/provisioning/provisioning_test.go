@@ -7,6 +7,8 @@ import (
 	"time"
 
 	dboards "github.com/grafana/grafana/pkg/dashboards"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
@@ -64,6 +66,30 @@ func TestProvisioningServiceImpl(t *testing.T) {
 	})
 }
 
+func TestProvisionLivePipeline(t *testing.T) {
+	var paths []string
+	service := newProvisioningServiceImpl(nil, nil, nil, nil, func(_ context.Context, path string, _ pipeline.Storage) error {
+		paths = append(paths, path)
+		return nil
+	})
+	service.Cfg = setting.NewCfg()
+	service.Cfg.ProvisioningPath = "/etc/grafana/provisioning"
+	service.Features = featuremgmt.WithFeatures(featuremgmt.FlagLivePipeline)
+
+	t.Run("Live pipeline is provisioned with the database storage", func(t *testing.T) {
+		service.Cfg.LivePipelineStorage = "database"
+		assert.NoError(t, service.ProvisionLivePipeline(context.Background()))
+		assert.Equal(t, []string{"/etc/grafana/provisioning/live"}, paths)
+	})
+
+	t.Run("Live pipeline is not provisioned with the file storage", func(t *testing.T) {
+		paths = nil
+		service.Cfg.LivePipelineStorage = "file"
+		assert.NoError(t, service.ProvisionLivePipeline(context.Background()))
+		assert.Empty(t, paths)
+	})
+}
+
 type serviceTestStruct struct {
 	waitForPollChanges func()
 	waitForStop        func()
@@ -98,6 +124,7 @@ func setup() *serviceTestStruct {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 	serviceTest.service.Cfg = setting.NewCfg()
 
@@ -0,0 +1,166 @@
+package roles
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"gopkg.in/yaml.v2"
+)
+
+type configReader struct {
+	log log.Logger
+}
+
+func (cr *configReader) readConfig(path string) ([]*configs, error) {
+	var result []*configs
+	cr.log.Debug("Looking for custom roles provisioning files", "path", path)
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		cr.log.Error("Failed to read custom roles provisioning files from directory", "path", path, "error", err)
+		return result, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing custom roles provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parseConfig(path, file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file.Name(), err)
+			}
+			result = append(result, cfg)
+		}
+	}
+
+	setDefaults(result)
+	if err := validateConfigs(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (cr *configReader) parseConfig(path string, file os.FileInfo) (*configs, error) {
+	filename, err := filepath.Abs(filepath.Join(path, file.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *configsV1
+	if err := yaml.Unmarshal(yamlFile, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg != nil && cfg.APIVersion.Value() > 1 {
+		return nil, fmt.Errorf("unsupported apiVersion %d", cfg.APIVersion.Value())
+	}
+	return cfg.mapToConfigs(), nil
+}
+
+func validateConfigs(cfgs []*configs) error {
+	var errStrings []string
+	for _, cfg := range cfgs {
+		for i, role := range cfg.Roles {
+			if role.Name == "" {
+				errStrings = append(errStrings, fmt.Sprintf("role item %d in configuration doesn't contain required field name", i+1))
+				continue
+			}
+			if err := accesscontrol.ValidateCustomRole(role.Name, role.Permissions); err != nil {
+				errStrings = append(errStrings, fmt.Sprintf("role %q: %s", role.Name, err))
+			}
+			for _, br := range role.BuiltInRoles {
+				if err := accesscontrol.ValidateBuiltInRoles([]string{br.Name}); err != nil {
+					errStrings = append(errStrings, fmt.Sprintf("role %q: %s", role.Name, err))
+				}
+			}
+			errStrings = append(errStrings, validateAssignments(role, "built-in role", role.BuiltInRoles)...)
+			errStrings = append(errStrings, validateAssignments(role, "team", role.Teams)...)
+			errStrings = append(errStrings, validateAssignments(role, "user", role.Users)...)
+			for _, team := range role.Teams {
+				if team.Name != "" && team.OrgID == accesscontrol.GlobalOrgID {
+					errStrings = append(errStrings, fmt.Sprintf("role %q: team %q must be assigned in an organization", role.Name, team.Name))
+				}
+			}
+		}
+		for i, role := range cfg.DeleteRoles {
+			if role.Name == "" && role.UID == "" {
+				errStrings = append(errStrings, fmt.Sprintf("delete role item %d in configuration doesn't contain required field name or uid", i+1))
+			}
+		}
+	}
+	if len(errStrings) != 0 {
+		return fmt.Errorf(strings.Join(errStrings, "\n"))
+	}
+	return nil
+}
+
+// validateAssignments checks that a role is only assigned where it is
+// visible: roles that belong to an organization can't be assigned in others.
+func validateAssignments(role *roleFromConfig, kind string, assignments []*assignmentConfig) []string {
+	var errStrings []string
+	for i, a := range assignments {
+		switch {
+		case a.Name == "":
+			errStrings = append(errStrings, fmt.Sprintf("role %q: %s item %d doesn't contain required field name", role.Name, kind, i+1))
+		case a.Global && !role.Global:
+			errStrings = append(errStrings, fmt.Sprintf("role %q: %s %q can only be assigned globally if the role is global", role.Name, kind, a.Name))
+		case !role.Global && a.OrgID != role.OrgID:
+			errStrings = append(errStrings, fmt.Sprintf("role %q: %s %q must be assigned in the organization of the role", role.Name, kind, a.Name))
+		}
+	}
+	return errStrings
+}
+
+func setDefaults(cfgs []*configs) {
+	for _, cfg := range cfgs {
+		for _, role := range cfg.Roles {
+			role.OrgID = orgID(role.OrgID, role.Global)
+			if role.Version < 1 {
+				role.Version = 1
+			}
+			for _, assignments := range [][]*assignmentConfig{role.BuiltInRoles, role.Teams, role.Users} {
+				for _, a := range assignments {
+					a.OrgID = assignmentOrgID(a, role)
+				}
+			}
+		}
+		for _, role := range cfg.DeleteRoles {
+			role.OrgID = orgID(role.OrgID, role.Global)
+		}
+	}
+}
+
+func orgID(orgID int64, global bool) int64 {
+	if global {
+		return accesscontrol.GlobalOrgID
+	}
+	if orgID < 1 {
+		return 1
+	}
+	return orgID
+}
+
+// assignmentOrgID defaults the organization of an assignment to the one of
+// its role.
+func assignmentOrgID(a *assignmentConfig, role *roleFromConfig) int64 {
+	if a.Global {
+		return accesscontrol.GlobalOrgID
+	}
+	if a.OrgID < 1 {
+		return role.OrgID
+	}
+	return a.OrgID
+}
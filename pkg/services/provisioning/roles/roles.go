@@ -0,0 +1,209 @@
+package roles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+type Store interface {
+	GetRoleByUID(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error)
+	GetRoleByName(ctx context.Context, orgID int64, name string) (*accesscontrol.RoleDTO, error)
+	CreateRole(ctx context.Context, cmd accesscontrol.CreateRoleCommand) (*accesscontrol.RoleDTO, error)
+	UpdateRole(ctx context.Context, cmd accesscontrol.UpdateRoleCommand) (*accesscontrol.RoleDTO, error)
+	DeleteRole(ctx context.Context, orgID int64, uid string) error
+	GetRoleAssignments(ctx context.Context, roleID int64) (*accesscontrol.RoleAssignments, error)
+	AddUserRole(ctx context.Context, orgID, userID, roleID int64) error
+	AddTeamRole(ctx context.Context, orgID, teamID, roleID int64) error
+	AddBuiltInRole(ctx context.Context, orgID int64, builtInRole string, roleID int64) error
+}
+
+// Provision scans a directory for provisioning config files and applies the
+// custom roles and their assignments in those files to the store.
+func Provision(ctx context.Context, configDirectory string, store Store, sqlStore *sqlstore.SQLStore) error {
+	p := &Provisioner{
+		log:       log.New("provisioning.roles"),
+		store:     store,
+		sqlStore:  sqlStore,
+		cfgReader: &configReader{log: log.New("provisioning.roles")},
+	}
+	return p.applyChanges(ctx, configDirectory)
+}
+
+// Provisioner is responsible for provisioning custom roles based on
+// configuration read by the `configReader`.
+type Provisioner struct {
+	log       log.Logger
+	store     Store
+	sqlStore  *sqlstore.SQLStore
+	cfgReader *configReader
+}
+
+func (p *Provisioner) apply(ctx context.Context, cfg *configs) error {
+	// Default assignments of fixed roles are declared in code and can't be
+	// changed, keep accepting the settings so that files stay portable.
+	for _, a := range cfg.RemoveDefaultAssignments {
+		p.log.Warn("Removing default assignments is not supported, skipping", "builtInRole", a.BuiltInRole, "fixedRole", a.FixedRole)
+	}
+	for _, a := range cfg.AddDefaultAssignments {
+		p.log.Warn("Adding default assignments is not supported, skipping", "builtInRole", a.BuiltInRole, "fixedRole", a.FixedRole)
+	}
+
+	for _, role := range cfg.DeleteRoles {
+		if err := p.deleteRole(ctx, role); err != nil {
+			return err
+		}
+	}
+
+	for _, role := range cfg.Roles {
+		stored, err := p.saveRole(ctx, role)
+		if err != nil {
+			return err
+		}
+		if err := p.assignRole(ctx, role, stored); err != nil {
+			return fmt.Errorf("failed to assign role %q: %w", role.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// saveRole creates the role, or updates it when the version in the
+// configuration is greater than the stored one, like fixed roles.
+func (p *Provisioner) saveRole(ctx context.Context, role *roleFromConfig) (*accesscontrol.RoleDTO, error) {
+	existing, err := p.store.GetRoleByName(ctx, role.OrgID, role.Name)
+	if errors.Is(err, accesscontrol.ErrRoleNotFound) {
+		p.log.Info("Creating role from configuration", "name", role.Name, "orgId", role.OrgID)
+		created, err := p.store.CreateRole(ctx, accesscontrol.CreateRoleCommand{
+			OrgID:       role.OrgID,
+			Global:      role.Global,
+			UID:         role.UID,
+			Name:        role.Name,
+			DisplayName: role.DisplayName,
+			Description: role.Description,
+			Group:       role.Group,
+			Version:     role.Version,
+			Permissions: role.Permissions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create role %q: %w", role.Name, err)
+		}
+		return created, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role %q: %w", role.Name, err)
+	}
+
+	if existing.Version >= role.Version {
+		p.log.Debug("Role has already been provisioned in a greater version, skipping update", "name", role.Name, "orgId", role.OrgID)
+		return existing, nil
+	}
+
+	p.log.Info("Updating role from configuration", "name", role.Name, "orgId", role.OrgID)
+	updated, err := p.store.UpdateRole(ctx, accesscontrol.UpdateRoleCommand{
+		OrgID:       role.OrgID,
+		UID:         existing.UID,
+		Name:        role.Name,
+		DisplayName: role.DisplayName,
+		Description: role.Description,
+		Group:       role.Group,
+		Version:     role.Version,
+		Permissions: role.Permissions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update role %q: %w", role.Name, err)
+	}
+	return updated, nil
+}
+
+func (p *Provisioner) deleteRole(ctx context.Context, role *deleteRoleConfig) error {
+	p.log.Info("Deleting role from configuration", "name", role.Name, "uid", role.UID, "orgId", role.OrgID)
+	// The uid identifies the role across organizations, prefer it to the name
+	ref := role.Name
+	var existing *accesscontrol.RoleDTO
+	var err error
+	if role.UID != "" {
+		ref = role.UID
+		existing, err = p.store.GetRoleByUID(ctx, role.OrgID, role.UID)
+	} else {
+		existing, err = p.store.GetRoleByName(ctx, role.OrgID, role.Name)
+	}
+	if errors.Is(err, accesscontrol.ErrRoleNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get role %q: %w", ref, err)
+	}
+
+	if !role.Force {
+		assignments, err := p.store.GetRoleAssignments(ctx, existing.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get assignments of role %q: %w", existing.Name, err)
+		}
+		if assignments.Len() > 0 {
+			return fmt.Errorf("failed to delete role %q: %w, set force to delete it with its assignments", existing.Name, accesscontrol.ErrRoleHasAssignments)
+		}
+	}
+
+	if err := p.store.DeleteRole(ctx, role.OrgID, existing.UID); err != nil && !errors.Is(err, accesscontrol.ErrRoleNotFound) {
+		return fmt.Errorf("failed to delete role %q: %w", existing.Name, err)
+	}
+	return nil
+}
+
+func (p *Provisioner) assignRole(ctx context.Context, role *roleFromConfig, stored *accesscontrol.RoleDTO) error {
+	for _, br := range role.BuiltInRoles {
+		if err := p.store.AddBuiltInRole(ctx, br.OrgID, br.Name, stored.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, user := range role.Users {
+		query := &models.GetUserByLoginQuery{LoginOrEmail: user.Name}
+		if err := p.sqlStore.GetUserByLogin(ctx, query); err != nil {
+			return fmt.Errorf("user %q: %w", user.Name, err)
+		}
+		if err := p.store.AddUserRole(ctx, user.OrgID, query.Result.Id, stored.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range role.Teams {
+		team := &models.Team{}
+		err := p.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			has, err := sess.Where("org_id = ? AND name = ?", t.OrgID, t.Name).Get(team)
+			if err == nil && !has {
+				return models.ErrTeamNotFound
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("team %q: %w", t.Name, err)
+		}
+		if err := p.store.AddTeamRole(ctx, t.OrgID, team.Id, stored.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Provisioner) applyChanges(ctx context.Context, configPath string) error {
+	cfgs, err := p.cfgReader.readConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range cfgs {
+		if err := p.apply(ctx, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
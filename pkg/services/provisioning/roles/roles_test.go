@@ -0,0 +1,141 @@
+package roles
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	brokenYaml        = "./testdata/broken-yaml"
+	incorrectSettings = "./testdata/incorrect-settings"
+	correctProperties = "./testdata/correct-properties"
+)
+
+func TestConfigReader(t *testing.T) {
+	reader := &configReader{log: log.New("test logger")}
+
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		_, err := reader.readConfig(brokenYaml)
+		require.Error(t, err)
+	})
+
+	t.Run("Skip missing directory", func(t *testing.T) {
+		cfgs, err := reader.readConfig("./testdata/missing")
+		require.NoError(t, err)
+		require.Len(t, cfgs, 0)
+	})
+
+	t.Run("Read incorrect properties", func(t *testing.T) {
+		_, err := reader.readConfig(incorrectSettings)
+		require.Error(t, err)
+		require.Equal(t, "role item 1 in configuration doesn't contain required field name\n"+
+			"role \"fixed:datasources:reader\": "+accesscontrol.ErrReservedRolePrefix.Error()+"\n"+
+			"role \"custom:global\": 'Owner' "+accesscontrol.ErrInvalidBuiltinRole.Error()+"\n"+
+			"role \"custom:global\": team \"SRE\" must be assigned in an organization\n"+
+			"role \"custom:local\": built-in role \"Viewer\" must be assigned in the organization of the role\n"+
+			"role \"custom:local\": user \"admin\" can only be assigned globally if the role is global\n"+
+			"delete role item 1 in configuration doesn't contain required field name or uid", err.Error())
+	})
+
+	t.Run("Can read correct properties", func(t *testing.T) {
+		t.Setenv("ROLES_PROVISIONING_USER", "alice")
+
+		cfgs, err := reader.readConfig(correctProperties)
+		require.NoError(t, err)
+		require.Len(t, cfgs, 1)
+
+		cfg := cfgs[0]
+		require.Len(t, cfg.Roles, 2)
+		role := cfg.Roles[0]
+		require.Equal(t, int64(1), role.OrgID)
+		require.Equal(t, "datasources_reader", role.UID)
+		require.Equal(t, "custom:datasources:reader", role.Name)
+		require.Equal(t, "Data source reader", role.DisplayName)
+		require.Equal(t, int64(2), role.Version)
+		require.Equal(t, []accesscontrol.Permission{
+			{Action: "datasources:read", Scope: "datasources:*"},
+			{Action: "datasources:query", Scope: "datasources:*"},
+		}, role.Permissions)
+		require.Equal(t, []*assignmentConfig{{OrgID: 1, Name: "Viewer"}}, role.BuiltInRoles)
+		require.Equal(t, []*assignmentConfig{{OrgID: 1, Name: "SRE"}}, role.Teams)
+		require.Equal(t, []*assignmentConfig{{OrgID: 1, Name: "alice"}}, role.Users)
+
+		global := cfg.Roles[1]
+		require.True(t, global.Global)
+		require.Equal(t, int64(accesscontrol.GlobalOrgID), global.OrgID)
+		require.Equal(t, int64(1), global.Version)
+		require.Equal(t, []*assignmentConfig{
+			{OrgID: 1, Name: "Viewer"},
+			{OrgID: accesscontrol.GlobalOrgID, Global: true, Name: "Editor"},
+		}, global.BuiltInRoles)
+
+		require.Len(t, cfg.DeleteRoles, 2)
+		require.Equal(t, "custom:old", cfg.DeleteRoles[0].Name)
+		require.Equal(t, "assigned_role", cfg.DeleteRoles[1].UID)
+		require.True(t, cfg.DeleteRoles[1].Force)
+
+		require.Len(t, cfg.RemoveDefaultAssignments, 1)
+	})
+}
+
+func TestProvision(t *testing.T) {
+	t.Setenv("ROLES_PROVISIONING_USER", "alice")
+	sqlStore := sqlstore.InitTestDB(t)
+	store := database.ProvideService(sqlStore)
+	ctx := context.Background()
+
+	user, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: "alice", OrgId: 1})
+	require.NoError(t, err)
+	team, err := sqlStore.CreateTeam("SRE", "", 1)
+	require.NoError(t, err)
+
+	old, err := store.CreateRole(ctx, accesscontrol.CreateRoleCommand{OrgID: 1, Name: "custom:old"})
+	require.NoError(t, err)
+
+	assigned, err := store.CreateRole(ctx, accesscontrol.CreateRoleCommand{OrgID: 1, UID: "assigned_role", Name: "custom:assigned"})
+	require.NoError(t, err)
+	require.NoError(t, store.AddUserRole(ctx, 1, user.Id, assigned.ID))
+
+	require.NoError(t, Provision(ctx, correctProperties, store, sqlStore))
+
+	_, err = store.GetRoleByUID(ctx, 1, old.UID)
+	require.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+	_, err = store.GetRoleByUID(ctx, 1, assigned.UID)
+	require.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+
+	role, err := store.GetRoleByUID(ctx, 1, "datasources_reader")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), role.Version)
+	require.Len(t, role.Permissions, 2)
+
+	assignments, err := store.GetRoleAssignments(ctx, role.ID)
+	require.NoError(t, err)
+	require.Equal(t, []accesscontrol.UserRoleAssignment{{OrgID: 1, UserID: user.Id, Login: "alice"}}, assignments.Users)
+	require.Equal(t, []accesscontrol.TeamRoleAssignment{{OrgID: 1, TeamID: team.Id, Name: "SRE"}}, assignments.Teams)
+	require.Equal(t, []accesscontrol.BuiltInRoleRoleAssignment{{OrgID: 1, BuiltInRole: "Viewer"}}, assignments.BuiltInRoles)
+
+	global, err := store.GetRoleByName(ctx, accesscontrol.GlobalOrgID, "custom:annotations:reader")
+	require.NoError(t, err)
+	assignments, err = store.GetRoleAssignments(ctx, global.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []accesscontrol.BuiltInRoleRoleAssignment{
+		{OrgID: 1, BuiltInRole: "Viewer"},
+		{OrgID: accesscontrol.GlobalOrgID, BuiltInRole: "Editor"},
+	}, assignments.BuiltInRoles)
+
+	// Roles are only updated when the provisioned version is greater
+	_, err = store.UpdateRole(ctx, accesscontrol.UpdateRoleCommand{OrgID: 1, UID: role.UID, Name: role.Name, Version: 5})
+	require.NoError(t, err)
+	require.NoError(t, Provision(ctx, correctProperties, store, sqlStore))
+	role, err = store.GetRoleByUID(ctx, 1, "datasources_reader")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), role.Version)
+	require.Len(t, role.Permissions, 0)
+}
@@ -0,0 +1,183 @@
+package roles
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// configs is a normalized data object for custom roles config data. Any config version should be mappable
+// to this type.
+type configs struct {
+	Roles                    []*roleFromConfig
+	DeleteRoles              []*deleteRoleConfig
+	RemoveDefaultAssignments []*defaultAssignmentConfig
+	AddDefaultAssignments    []*defaultAssignmentConfig
+}
+
+type roleFromConfig struct {
+	OrgID        int64
+	Global       bool
+	UID          string
+	Name         string
+	DisplayName  string
+	Description  string
+	Group        string
+	Version      int64
+	Permissions  []accesscontrol.Permission
+	BuiltInRoles []*assignmentConfig
+	Teams        []*assignmentConfig
+	Users        []*assignmentConfig
+}
+
+// assignmentConfig is a built-in role, team or user assignment of a role.
+// Name holds the built-in role name, the team name or the user login.
+type assignmentConfig struct {
+	OrgID  int64
+	Global bool
+	Name   string
+}
+
+type deleteRoleConfig struct {
+	OrgID  int64
+	Global bool
+	UID    string
+	Name   string
+	Force  bool
+}
+
+type defaultAssignmentConfig struct {
+	BuiltInRole string
+	FixedRole   string
+}
+
+type configsV1 struct {
+	APIVersion               values.Int64Value            `json:"apiVersion" yaml:"apiVersion"`
+	Roles                    []*roleFromConfigV1          `json:"roles" yaml:"roles"`
+	DeleteRoles              []*deleteRoleConfigV1        `json:"deleteRoles" yaml:"deleteRoles"`
+	RemoveDefaultAssignments []*defaultAssignmentConfigV1 `json:"removeDefaultAssignments" yaml:"removeDefaultAssignments"`
+	AddDefaultAssignments    []*defaultAssignmentConfigV1 `json:"addDefaultAssignments" yaml:"addDefaultAssignments"`
+}
+
+type roleFromConfigV1 struct {
+	OrgID        values.Int64Value      `json:"orgId" yaml:"orgId"`
+	Global       values.BoolValue       `json:"global" yaml:"global"`
+	UID          values.StringValue     `json:"uid" yaml:"uid"`
+	Name         values.StringValue     `json:"name" yaml:"name"`
+	DisplayName  values.StringValue     `json:"displayName" yaml:"displayName"`
+	Description  values.StringValue     `json:"description" yaml:"description"`
+	Group        values.StringValue     `json:"group" yaml:"group"`
+	Version      values.Int64Value      `json:"version" yaml:"version"`
+	Permissions  []*permissionConfigV1  `json:"permissions" yaml:"permissions"`
+	BuiltInRoles []*builtInRoleConfigV1 `json:"builtInRoles" yaml:"builtInRoles"`
+	Teams        []*teamConfigV1        `json:"teams" yaml:"teams"`
+	Users        []*userConfigV1        `json:"users" yaml:"users"`
+}
+
+type permissionConfigV1 struct {
+	Action values.StringValue `json:"action" yaml:"action"`
+	Scope  values.StringValue `json:"scope" yaml:"scope"`
+}
+
+type builtInRoleConfigV1 struct {
+	Name   values.StringValue `json:"name" yaml:"name"`
+	OrgID  values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Global values.BoolValue   `json:"global" yaml:"global"`
+}
+
+type teamConfigV1 struct {
+	Name  values.StringValue `json:"name" yaml:"name"`
+	OrgID values.Int64Value  `json:"orgId" yaml:"orgId"`
+}
+
+type userConfigV1 struct {
+	Login  values.StringValue `json:"login" yaml:"login"`
+	OrgID  values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Global values.BoolValue   `json:"global" yaml:"global"`
+}
+
+type deleteRoleConfigV1 struct {
+	OrgID  values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Global values.BoolValue   `json:"global" yaml:"global"`
+	UID    values.StringValue `json:"uid" yaml:"uid"`
+	Name   values.StringValue `json:"name" yaml:"name"`
+	Force  values.BoolValue   `json:"force" yaml:"force"`
+}
+
+type defaultAssignmentConfigV1 struct {
+	BuiltInRole values.StringValue `json:"builtInRole" yaml:"builtInRole"`
+	FixedRole   values.StringValue `json:"fixedRole" yaml:"fixedRole"`
+}
+
+// mapToConfigs maps config syntax to a normalized configs object.
+func (cfg *configsV1) mapToConfigs() *configs {
+	r := &configs{}
+	if cfg == nil {
+		return r
+	}
+
+	for _, role := range cfg.Roles {
+		c := &roleFromConfig{
+			OrgID:       role.OrgID.Value(),
+			Global:      role.Global.Value(),
+			UID:         role.UID.Value(),
+			Name:        role.Name.Value(),
+			DisplayName: role.DisplayName.Value(),
+			Description: role.Description.Value(),
+			Group:       role.Group.Value(),
+			Version:     role.Version.Value(),
+		}
+		for _, p := range role.Permissions {
+			c.Permissions = append(c.Permissions, accesscontrol.Permission{
+				Action: p.Action.Value(),
+				Scope:  p.Scope.Value(),
+			})
+		}
+		for _, br := range role.BuiltInRoles {
+			c.BuiltInRoles = append(c.BuiltInRoles, &assignmentConfig{
+				OrgID:  br.OrgID.Value(),
+				Global: br.Global.Value(),
+				Name:   br.Name.Value(),
+			})
+		}
+		for _, team := range role.Teams {
+			c.Teams = append(c.Teams, &assignmentConfig{
+				OrgID: team.OrgID.Value(),
+				Name:  team.Name.Value(),
+			})
+		}
+		for _, user := range role.Users {
+			c.Users = append(c.Users, &assignmentConfig{
+				OrgID:  user.OrgID.Value(),
+				Global: user.Global.Value(),
+				Name:   user.Login.Value(),
+			})
+		}
+		r.Roles = append(r.Roles, c)
+	}
+
+	for _, role := range cfg.DeleteRoles {
+		r.DeleteRoles = append(r.DeleteRoles, &deleteRoleConfig{
+			OrgID:  role.OrgID.Value(),
+			Global: role.Global.Value(),
+			UID:    role.UID.Value(),
+			Name:   role.Name.Value(),
+			Force:  role.Force.Value(),
+		})
+	}
+
+	r.RemoveDefaultAssignments = mapDefaultAssignments(cfg.RemoveDefaultAssignments)
+	r.AddDefaultAssignments = mapDefaultAssignments(cfg.AddDefaultAssignments)
+
+	return r
+}
+
+func mapDefaultAssignments(assignments []*defaultAssignmentConfigV1) []*defaultAssignmentConfig {
+	var result []*defaultAssignmentConfig
+	for _, a := range assignments {
+		result = append(result, &defaultAssignmentConfig{
+			BuiltInRole: a.BuiltInRole.Value(),
+			FixedRole:   a.FixedRole.Value(),
+		})
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	gocache "github.com/patrickmn/go-cache"
+)
+
+// localCounter counts requests in memory, for a single Grafana instance.
+type localCounter struct {
+	mu    sync.Mutex
+	cache *gocache.Cache
+}
+
+func newLocalCounter() *localCounter {
+	return &localCounter{cache: gocache.New(time.Minute, 5*time.Minute)}
+}
+
+func (l *localCounter) Incr(_ context.Context, key string, expire time.Duration) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.cache.Add(key, int64(1), expire); err == nil {
+		return 1, nil
+	}
+	count, err := l.cache.IncrementInt64(key, 1)
+	if err != nil {
+		// the counter expired between Add and IncrementInt64
+		l.cache.Set(key, int64(1), expire)
+		return 1, nil
+	}
+	return count, nil
+}
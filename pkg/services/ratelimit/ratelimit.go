@@ -0,0 +1,162 @@
+// Package ratelimit limits the number of HTTP requests of each user, API key,
+// service account and organization, by route group. Requests are counted in
+// fixed windows, either in memory or in the remote cache so that all Grafana
+// instances share the same counters.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// Route groups, see setting.RateLimitGroups.
+const (
+	GroupAPI    = "api"
+	GroupQuery  = "query"
+	GroupRender = "render"
+	GroupLogin  = "login"
+)
+
+var requestsRejectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name:      "rate_limiting_requests_rejected_total",
+	Help:      "Number of requests rejected because a rate limit was reached, by route group and limit",
+	Namespace: "grafana",
+}, []string{"group", "limit"})
+
+type counter interface {
+	Incr(ctx context.Context, key string, expire time.Duration) (int64, error)
+}
+
+type Service struct {
+	cfg     *setting.Cfg
+	counter counter
+	log     log.Logger
+	now     func() time.Time
+}
+
+func ProvideService(cfg *setting.Cfg, remoteCache *remotecache.RemoteCache) *Service {
+	s := &Service{
+		cfg: cfg,
+		log: log.New("ratelimit"),
+		now: time.Now,
+	}
+	if cfg.RateLimiting.UseRemoteCache {
+		s.counter = remoteCache
+	} else {
+		s.counter = newLocalCounter()
+	}
+	return s
+}
+
+// Middleware returns a handler rejecting the requests over the limits of the
+// route group with 429 Too Many Requests. The responses of limited requests
+// carry the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers
+// of the most restrictive limit.
+func (s *Service) Middleware(group string) web.Handler {
+	limits := s.cfg.RateLimiting.Limits[group]
+	if !s.cfg.RateLimiting.Enabled || (limits.User <= 0 && limits.Org <= 0) {
+		return func(c *models.ReqContext) {}
+	}
+
+	return func(c *models.ReqContext) {
+		s.limit(c, group, limits)
+	}
+}
+
+type subject struct {
+	// kind is either "user" or "org", like the settings
+	kind  string
+	key   string
+	limit int64
+}
+
+func (s *Service) limit(c *models.ReqContext, group string, limits setting.RateLimit) {
+	now := s.now()
+	window := s.cfg.RateLimiting.Window
+	start := now.Truncate(window)
+	reset := int64(math.Ceil(start.Add(window).Sub(now).Seconds()))
+
+	var (
+		exceeded  subject
+		limit     int64
+		remaining int64 = math.MaxInt64
+	)
+	for _, sub := range subjects(c, group, limits) {
+		key := fmt.Sprintf("ratelimit:%s:%s:%d", group, sub.key, start.Unix())
+		count, err := s.counter.Incr(c.Req.Context(), key, window)
+		if err != nil {
+			// Don't turn a cache outage into an outage of the API
+			s.log.Warn("Failed to count request, skipping rate limit", "group", group, "limit", sub.kind, "error", err)
+			continue
+		}
+
+		if left := sub.limit - count; left < remaining {
+			remaining, limit = left, sub.limit
+			if left < 0 {
+				exceeded = sub
+			}
+		}
+	}
+	if remaining == math.MaxInt64 {
+		return
+	}
+
+	header := c.Resp.Header()
+	header.Set("RateLimit-Limit", strconv.FormatInt(limit, 10))
+	header.Set("RateLimit-Remaining", strconv.FormatInt(maxInt64(remaining, 0), 10))
+	header.Set("RateLimit-Reset", strconv.FormatInt(reset, 10))
+
+	if remaining < 0 {
+		requestsRejectedTotal.WithLabelValues(group, exceeded.kind).Inc()
+		s.log.Debug("Rate limit reached", "group", group, "limit", exceeded.kind, "key", exceeded.key)
+		header.Set("Retry-After", strconv.FormatInt(reset, 10))
+		c.JsonApiErr(http.StatusTooManyRequests, "Rate limit reached", nil)
+	}
+}
+
+// subjects returns what the request is counted against: its user, API key or
+// service account, and its organization.
+func subjects(c *models.ReqContext, group string, limits setting.RateLimit) []subject {
+	var result []subject
+	if limits.User > 0 {
+		result = append(result, subject{kind: "user", key: identity(c, group), limit: limits.User})
+	}
+	if limits.Org > 0 && c.IsSignedIn && c.SignedInUser != nil && c.OrgId > 0 {
+		result = append(result, subject{kind: "org", key: fmt.Sprintf("org:%d", c.OrgId), limit: limits.Org})
+	}
+	return result
+}
+
+func identity(c *models.ReqContext, group string) string {
+	// Logins are attempted before being signed in
+	if group != GroupLogin && c.IsSignedIn && c.SignedInUser != nil && !c.IsAnonymous {
+		if c.ApiKeyId > 0 {
+			return fmt.Sprintf("apikey:%d", c.ApiKeyId)
+		}
+		// Service accounts are users, requests with their tokens count as theirs
+		if c.UserId > 0 {
+			return fmt.Sprintf("user:%d", c.UserId)
+		}
+	}
+	return "ip:" + c.RemoteAddr()
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
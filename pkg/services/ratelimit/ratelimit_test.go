@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func newTestCfg(limits setting.RateLimit) *setting.Cfg {
+	cfg := setting.NewCfg()
+	cfg.RateLimiting = setting.RateLimitingSettings{
+		Enabled: true,
+		Window:  time.Minute,
+		Limits:  map[string]setting.RateLimit{GroupQuery: limits},
+	}
+	return cfg
+}
+
+type testServer struct {
+	t      *testing.T
+	server *web.Mux
+}
+
+func newTestServer(t *testing.T, s *Service) *testServer {
+	t.Helper()
+
+	server := web.New()
+	server.UseMiddleware(web.Renderer(path.Join(setting.StaticRootPath, "views"), "[[", "]]"))
+	server.Use(func(c *web.Context) {
+		// The test requests tell who they are from with headers
+		userID, _ := strconv.ParseInt(c.Req.Header.Get("X-User-Id"), 10, 64)
+		apiKeyID, _ := strconv.ParseInt(c.Req.Header.Get("X-Api-Key-Id"), 10, 64)
+		user := &models.SignedInUser{OrgId: 1, UserId: userID, ApiKeyId: apiKeyID}
+		c.Map(&models.ReqContext{
+			Context:      c,
+			SignedInUser: user,
+			IsSignedIn:   user.UserId > 0 || user.ApiKeyId > 0,
+			Logger:       log.New("test"),
+		})
+	})
+	server.Post("/api/ds/query", s.Middleware(GroupQuery), func(c *models.ReqContext) {
+		c.JSON(http.StatusOK, map[string]string{"message": "OK"})
+	})
+	return &testServer{t: t, server: server}
+}
+
+func (s *testServer) query(header, value string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(http.MethodPost, "/api/ds/query", nil)
+	require.NoError(s.t, err)
+	req.Header.Set(header, value)
+	recorder := httptest.NewRecorder()
+	s.server.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("should limit the requests of each user and API key", func(t *testing.T) {
+		s := ProvideService(newTestCfg(setting.RateLimit{User: 2}), nil)
+		now := time.Date(2022, 1, 1, 10, 0, 15, 0, time.UTC)
+		s.now = func() time.Time { return now }
+		server := newTestServer(t, s)
+
+		resp := server.query("X-User-Id", "1")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "2", resp.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", resp.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "45", resp.Header().Get("RateLimit-Reset"))
+
+		resp = server.query("X-User-Id", "1")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "0", resp.Header().Get("RateLimit-Remaining"))
+
+		resp = server.query("X-User-Id", "1")
+		require.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Equal(t, "0", resp.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "45", resp.Header().Get("Retry-After"))
+
+		// Other users and API keys have their own counters
+		assert.Equal(t, http.StatusOK, server.query("X-User-Id", "2").Code)
+		assert.Equal(t, http.StatusOK, server.query("X-Api-Key-Id", "1").Code)
+
+		// Counting starts over in the next window
+		now = now.Add(time.Minute)
+		assert.Equal(t, http.StatusOK, server.query("X-User-Id", "1").Code)
+	})
+
+	t.Run("should limit the requests of an organization", func(t *testing.T) {
+		s := ProvideService(newTestCfg(setting.RateLimit{User: 10, Org: 2}), nil)
+		server := newTestServer(t, s)
+
+		assert.Equal(t, http.StatusOK, server.query("X-User-Id", "1").Code)
+		resp := server.query("X-Api-Key-Id", "1")
+		require.Equal(t, http.StatusOK, resp.Code)
+		// The organization limit is the most restrictive one
+		assert.Equal(t, "2", resp.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "0", resp.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, http.StatusTooManyRequests, server.query("X-User-Id", "2").Code)
+	})
+
+	t.Run("should limit unauthenticated requests by client IP", func(t *testing.T) {
+		s := ProvideService(newTestCfg(setting.RateLimit{User: 1, Org: 1}), nil)
+		server := newTestServer(t, s)
+
+		assert.Equal(t, http.StatusOK, server.query("X-Real-IP", "10.0.0.1").Code)
+		assert.Equal(t, http.StatusTooManyRequests, server.query("X-Real-IP", "10.0.0.1").Code)
+		assert.Equal(t, http.StatusOK, server.query("X-Real-IP", "10.0.0.2").Code)
+	})
+
+	t.Run("should share the counters through the remote cache", func(t *testing.T) {
+		cfg := newTestCfg(setting.RateLimit{User: 1})
+		cfg.RateLimiting.UseRemoteCache = true
+		cache := remotecache.NewFakeStore(t)
+
+		first := newTestServer(t, ProvideService(cfg, cache))
+		second := newTestServer(t, ProvideService(cfg, cache))
+
+		assert.Equal(t, http.StatusOK, first.query("X-User-Id", "1").Code)
+		assert.Equal(t, http.StatusTooManyRequests, second.query("X-User-Id", "1").Code)
+	})
+
+	t.Run("should not limit requests when the counters are unavailable", func(t *testing.T) {
+		s := ProvideService(newTestCfg(setting.RateLimit{User: 1}), nil)
+		s.counter = failingCounter{}
+		server := newTestServer(t, s)
+
+		resp := server.query("X-User-Id", "1")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, http.StatusOK, server.query("X-User-Id", "1").Code)
+	})
+
+	t.Run("should not limit requests when disabled", func(t *testing.T) {
+		cfg := newTestCfg(setting.RateLimit{User: 1})
+		cfg.RateLimiting.Enabled = false
+		server := newTestServer(t, ProvideService(cfg, nil))
+
+		assert.Equal(t, http.StatusOK, server.query("X-User-Id", "1").Code)
+		resp := server.query("X-User-Id", "1")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Header().Get("RateLimit-Limit"))
+	})
+}
+
+type failingCounter struct{}
+
+func (failingCounter) Incr(context.Context, string, time.Duration) (int64, error) {
+	return 0, errors.New("cache unavailable")
+}
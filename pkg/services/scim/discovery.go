@@ -0,0 +1,161 @@
+package scim
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+type supported struct {
+	Supported bool `json:"supported"`
+}
+
+type filterSupport struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type bulkSupport struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type authenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// GET /api/scim/v2/ServiceProviderConfig
+func (a *api) getServiceProviderConfig(c *models.ReqContext) response.Response {
+	return scimJSON(http.StatusOK, map[string]interface{}{
+		"schemas":        []string{schemaServiceProviderConfig},
+		"patch":          supported{Supported: true},
+		"bulk":           bulkSupport{},
+		"filter":         filterSupport{Supported: true, MaxResults: maxCount},
+		"changePassword": supported{},
+		"sort":           supported{},
+		"etag":           supported{},
+		"authenticationSchemes": []authenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "Service account token",
+			Description: "A Grafana service account token or API key sent as a bearer token",
+		}},
+		"meta": meta{ResourceType: "ServiceProviderConfig", Location: strings.TrimSuffix(a.cfg.AppURL, "/") + basePath + "/ServiceProviderConfig"},
+	})
+}
+
+// GET /api/scim/v2/ResourceTypes
+func (a *api) getResourceTypes(c *models.ReqContext) response.Response {
+	types := []interface{}{
+		resourceType(resourceTypeUser, "/Users", schemaUser),
+		resourceType(resourceTypeGroup, "/Groups", schemaGroup),
+	}
+	return scimJSON(http.StatusOK, listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: int64(len(types)),
+		StartIndex:   1,
+		ItemsPerPage: len(types),
+		Resources:    types,
+	})
+}
+
+func resourceType(name, endpoint, schema string) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":  []string{schemaResourceType},
+		"id":       name,
+		"name":     name,
+		"endpoint": endpoint,
+		"schema":   schema,
+		"meta":     meta{ResourceType: "ResourceType"},
+	}
+}
+
+// GET /api/scim/v2/Schemas
+//
+// Describes the attributes Grafana stores, other attributes sent by identity
+// providers are ignored.
+func (a *api) getSchemas(c *models.ReqContext) response.Response {
+	schemas := []interface{}{
+		schema(schemaUser, resourceTypeUser,
+			attribute("userName", "string", true, true),
+			complexAttribute("name",
+				attribute("formatted", "string", false, false),
+				attribute("givenName", "string", false, false),
+				attribute("familyName", "string", false, false),
+			),
+			attribute("displayName", "string", false, false),
+			multiValuedAttribute("emails",
+				attribute("value", "string", false, false),
+				attribute("type", "string", false, false),
+				attribute("primary", "boolean", false, false),
+			),
+			attribute("active", "boolean", false, false),
+			readOnly(multiValuedAttribute("groups",
+				attribute("value", "string", false, false),
+				attribute("$ref", "reference", false, false),
+				attribute("display", "string", false, false),
+			)),
+		),
+		schema(schemaGroup, resourceTypeGroup,
+			attribute("displayName", "string", true, true),
+			multiValuedAttribute("members",
+				attribute("value", "string", false, false),
+				attribute("$ref", "reference", false, false),
+				attribute("display", "string", false, false),
+			),
+		),
+	}
+	return scimJSON(http.StatusOK, listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: int64(len(schemas)),
+		StartIndex:   1,
+		ItemsPerPage: len(schemas),
+		Resources:    schemas,
+	})
+}
+
+func schema(id, name string, attributes ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         id,
+		"name":       name,
+		"attributes": attributes,
+		"meta":       meta{ResourceType: "Schema"},
+	}
+}
+
+func attribute(name, typ string, required, unique bool) map[string]interface{} {
+	uniqueness := "none"
+	if unique {
+		uniqueness = "server"
+	}
+	return map[string]interface{}{
+		"name":        name,
+		"type":        typ,
+		"multiValued": false,
+		"required":    required,
+		"mutability":  "readWrite",
+		"returned":    "default",
+		"uniqueness":  uniqueness,
+	}
+}
+
+func complexAttribute(name string, subAttributes ...map[string]interface{}) map[string]interface{} {
+	attr := attribute(name, "complex", false, false)
+	attr["subAttributes"] = subAttributes
+	return attr
+}
+
+func multiValuedAttribute(name string, subAttributes ...map[string]interface{}) map[string]interface{} {
+	attr := complexAttribute(name, subAttributes...)
+	attr["multiValued"] = true
+	return attr
+}
+
+func readOnly(attr map[string]interface{}) map[string]interface{} {
+	attr["mutability"] = "readOnly"
+	return attr
+}
@@ -0,0 +1,54 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errInvalidFilter = errors.New("invalid filter")
+
+// filter is an equality filter on a single attribute, the only kind of
+// filter identity providers use to look up existing resources, e.g.
+// userName eq "john@example.com".
+type filter struct {
+	// Attribute is the lowercase attribute name.
+	Attribute string
+	Value     string
+}
+
+// parseFilter parses a filter expression, an empty expression returns a nil
+// filter. Attributes not in the supported list are rejected.
+func parseFilter(expr string, supported ...string) (*filter, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(expr, " ", 3)
+	if len(parts) != 3 || !strings.EqualFold(parts[1], "eq") {
+		return nil, fmt.Errorf("%w: only the eq operator is supported", errInvalidFilter)
+	}
+
+	attribute := strings.ToLower(parts[0])
+	found := false
+	for _, s := range supported {
+		if strings.ToLower(s) == attribute {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: filtering on %q is not supported", errInvalidFilter, parts[0])
+	}
+
+	value := strings.TrimSpace(parts[2])
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal([]byte(value), &value); err != nil {
+			return nil, fmt.Errorf("%w: %s", errInvalidFilter, err)
+		}
+	}
+
+	return &filter{Attribute: attribute, Value: value}, nil
+}
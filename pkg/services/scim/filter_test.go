@@ -0,0 +1,38 @@
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		desc     string
+		expr     string
+		expected *filter
+		err      bool
+	}{
+		{desc: "empty", expr: "", expected: nil},
+		{desc: "quoted value", expr: `userName eq "john@example.com"`, expected: &filter{Attribute: "username", Value: "john@example.com"}},
+		{desc: "value with spaces", expr: `displayName EQ "Team A"`, expected: &filter{Attribute: "displayname", Value: "Team A"}},
+		{desc: "unquoted value", expr: `id eq 42`, expected: &filter{Attribute: "id", Value: "42"}},
+		{desc: "unsupported operator", expr: `userName co "john"`, err: true},
+		{desc: "unsupported attribute", expr: `title eq "Engineer"`, err: true},
+		{desc: "incomplete", expr: `userName eq`, err: true},
+		{desc: "invalid quoting", expr: `userName eq "john`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			f, err := parseFilter(tt.expr, "id", "userName", "displayName")
+			if tt.err {
+				require.ErrorIs(t, err, errInvalidFilter)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, f)
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/audit"
+)
+
+var groupFilterAttributes = []string{"id", "displayName"}
+
+// GET /api/scim/v2/Groups
+func (a *api) listGroups(c *models.ReqContext) response.Response {
+	f, err := parseFilter(c.Query("filter"), groupFilterAttributes...)
+	if err != nil {
+		return scimError(http.StatusBadRequest, "invalidFilter", err.Error())
+	}
+
+	startIndex, count := pagination(c)
+	teams, total, err := a.store.searchTeams(c.Req.Context(), c.OrgId, f, startIndex-1, count)
+	if err != nil {
+		return a.internalError("Failed to search groups", err)
+	}
+
+	resources := make([]interface{}, 0, len(teams))
+	for _, team := range teams {
+		res, err := a.groupResource(c, team)
+		if err != nil {
+			return a.internalError("Failed to get group members", err)
+		}
+		resources = append(resources, res)
+	}
+
+	return scimJSON(http.StatusOK, listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GET /api/scim/v2/Groups/:id
+func (a *api) getGroup(c *models.ReqContext) response.Response {
+	team, resp := a.loadTeam(c)
+	if resp != nil {
+		return resp
+	}
+	return a.groupResponse(c, http.StatusOK, team)
+}
+
+// POST /api/scim/v2/Groups
+func (a *api) createGroup(c *models.ReqContext) response.Response {
+	var res groupResource
+	if err := bind(c.Req, &res); err != nil {
+		return scimError(http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+	if res.DisplayName == "" {
+		return scimError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+
+	members, resp := a.memberIDs(c, res.Members)
+	if resp != nil {
+		return resp
+	}
+
+	team, err := a.sqlStore.CreateTeam(res.DisplayName, "", c.OrgId)
+	if errors.Is(err, models.ErrTeamNameTaken) {
+		return scimError(http.StatusConflict, "uniqueness", "Group already exists")
+	}
+	if err != nil {
+		return a.internalError("Failed to create group", err)
+	}
+
+	for _, userID := range members {
+		if err := a.setMember(c.Req.Context(), c.OrgId, team.Id, userID, true); err != nil {
+			return a.internalError("Failed to add group member", err)
+		}
+	}
+
+	a.audit.Record(c.Req.Context(), audit.NewEntry(c, audit.ActionCreate, audit.ResourceTeam, strconv.FormatInt(team.Id, 10)).
+		WithDetails("provisioning", "scim", "name", team.Name, "members", len(members)))
+
+	return a.groupResponse(c, http.StatusCreated, &team)
+}
+
+// PUT /api/scim/v2/Groups/:id
+func (a *api) replaceGroup(c *models.ReqContext) response.Response {
+	team, resp := a.loadTeam(c)
+	if resp != nil {
+		return resp
+	}
+
+	var res groupResource
+	if err := bind(c.Req, &res); err != nil {
+		return scimError(http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+	if res.DisplayName == "" {
+		return scimError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+
+	members, resp := a.memberIDs(c, res.Members)
+	if resp != nil {
+		return resp
+	}
+
+	return a.updateGroup(c, team, groupUpdate{name: res.DisplayName, replace: true, add: members})
+}
+
+// PATCH /api/scim/v2/Groups/:id
+func (a *api) patchGroup(c *models.ReqContext) response.Response {
+	team, resp := a.loadTeam(c)
+	if resp != nil {
+		return resp
+	}
+
+	var req patchRequest
+	if err := bind(c.Req, &req); err != nil {
+		return scimError(http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	update := groupUpdate{name: team.Name}
+	for _, op := range req.Operations {
+		path := strings.ToLower(op.Path)
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+			if path == "" {
+				var values groupResource
+				if err := json.Unmarshal(op.Value, &values); err != nil {
+					return scimError(http.StatusBadRequest, "invalidValue", "value must be an object without path")
+				}
+				if values.DisplayName != "" {
+					update.name = values.DisplayName
+				}
+				if values.Members == nil {
+					continue
+				}
+				op.Value, _ = json.Marshal(values.Members)
+				path = "members"
+			}
+
+			switch path {
+			case "displayname":
+				if err := json.Unmarshal(op.Value, &update.name); err != nil || update.name == "" {
+					return scimError(http.StatusBadRequest, "invalidValue", "invalid displayName")
+				}
+			case "members":
+				var refs []reference
+				if err := json.Unmarshal(op.Value, &refs); err != nil {
+					return scimError(http.StatusBadRequest, "invalidValue", "invalid members")
+				}
+				members, resp := a.memberIDs(c, refs)
+				if resp != nil {
+					return resp
+				}
+				if strings.EqualFold(op.Op, "replace") {
+					update.replace, update.add, update.remove = true, nil, nil
+				}
+				update.add = append(update.add, members...)
+			default:
+				return scimError(http.StatusBadRequest, "invalidPath", fmt.Sprintf("%q can't be changed", op.Path))
+			}
+		case "remove":
+			switch {
+			case path == "members" && len(op.Value) == 0:
+				update.replace, update.add, update.remove = true, nil, nil
+			case path == "members":
+				var refs []reference
+				if err := json.Unmarshal(op.Value, &refs); err != nil {
+					return scimError(http.StatusBadRequest, "invalidValue", "invalid members")
+				}
+				for _, ref := range refs {
+					if id, err := strconv.ParseInt(ref.Value, 10, 64); err == nil {
+						update.remove = append(update.remove, id)
+					}
+				}
+			case strings.HasPrefix(path, "members["):
+				// e.g. members[value eq "42"]
+				f, err := parseFilter(strings.TrimSuffix(op.Path[len("members["):], "]"), "value")
+				if err != nil {
+					return scimError(http.StatusBadRequest, "invalidPath", err.Error())
+				}
+				if id, err := strconv.ParseInt(f.Value, 10, 64); err == nil {
+					update.remove = append(update.remove, id)
+				}
+			default:
+				return scimError(http.StatusBadRequest, "mutability", fmt.Sprintf("%q can't be removed", op.Path))
+			}
+		default:
+			return scimError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("unsupported operation %q", op.Op))
+		}
+	}
+
+	return a.updateGroup(c, team, update)
+}
+
+// DELETE /api/scim/v2/Groups/:id
+func (a *api) deleteGroup(c *models.ReqContext) response.Response {
+	team, resp := a.loadTeam(c)
+	if resp != nil {
+		return resp
+	}
+
+	if err := a.sqlStore.DeleteTeam(c.Req.Context(), &models.DeleteTeamCommand{OrgId: c.OrgId, Id: team.Id}); err != nil {
+		return a.internalError("Failed to delete group", err)
+	}
+
+	a.audit.Record(c.Req.Context(), audit.NewEntry(c, audit.ActionDelete, audit.ResourceTeam, strconv.FormatInt(team.Id, 10)).
+		WithDetails("provisioning", "scim", "name", team.Name))
+
+	return response.Empty(http.StatusNoContent)
+}
+
+// groupUpdate holds the changes to a team. With replace, the members not
+// in add are removed.
+type groupUpdate struct {
+	name    string
+	replace bool
+	add     []int64
+	remove  []int64
+}
+
+func (a *api) updateGroup(c *models.ReqContext, team *models.Team, update groupUpdate) response.Response {
+	ctx := c.Req.Context()
+
+	if update.name != team.Name {
+		cmd := models.UpdateTeamCommand{Id: team.Id, OrgId: c.OrgId, Name: update.name, Email: team.Email}
+		if err := a.sqlStore.UpdateTeam(ctx, &cmd); err != nil {
+			if errors.Is(err, models.ErrTeamNameTaken) {
+				return scimError(http.StatusConflict, "uniqueness", "A group with the same name already exists")
+			}
+			return a.internalError("Failed to update group", err)
+		}
+		team.Name = update.name
+	}
+
+	current, err := a.store.teamMembers(ctx, c.OrgId, team.Id)
+	if err != nil {
+		return a.internalError("Failed to get group members", err)
+	}
+	isMember := make(map[int64]bool, len(current))
+	for _, user := range current {
+		isMember[user.Id] = true
+	}
+
+	wanted := make(map[int64]bool, len(update.add))
+	for _, userID := range update.add {
+		wanted[userID] = true
+	}
+	for _, userID := range update.remove {
+		delete(wanted, userID)
+	}
+
+	var added, removed int
+	for userID := range wanted {
+		if isMember[userID] {
+			continue
+		}
+		if err := a.setMember(ctx, c.OrgId, team.Id, userID, true); err != nil {
+			return a.internalError("Failed to add group member", err)
+		}
+		added++
+	}
+	for userID := range isMember {
+		if wanted[userID] || (!update.replace && !contains(update.remove, userID)) {
+			continue
+		}
+		if err := a.setMember(ctx, c.OrgId, team.Id, userID, false); err != nil {
+			return a.internalError("Failed to remove group member", err)
+		}
+		removed++
+	}
+
+	a.audit.Record(ctx, audit.NewEntry(c, audit.ActionUpdate, audit.ResourceTeam, strconv.FormatInt(team.Id, 10)).
+		WithDetails("provisioning", "scim", "name", team.Name, "membersAdded", added, "membersRemoved", removed))
+
+	return a.groupResponse(c, http.StatusOK, team)
+}
+
+// setMember adds or removes a team member through the team permissions, the
+// way the teams API does. Existing members keep their permission.
+func (a *api) setMember(ctx context.Context, orgID, teamID, userID int64, member bool) error {
+	permission := ""
+	if member {
+		permission = "Member"
+	}
+	_, err := a.teamPermissions.SetUserPermission(ctx, orgID, accesscontrol.User{ID: userID}, strconv.FormatInt(teamID, 10), permission)
+	return err
+}
+
+// memberIDs returns the user IDs of group members, which must be users of
+// the organization.
+func (a *api) memberIDs(c *models.ReqContext, refs []reference) ([]int64, response.Response) {
+	ids := make([]int64, 0, len(refs))
+	for _, ref := range refs {
+		id, err := strconv.ParseInt(ref.Value, 10, 64)
+		if err != nil {
+			return nil, scimError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("invalid member %q", ref.Value))
+		}
+		if _, err := a.store.getUser(c.Req.Context(), c.OrgId, id); err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return nil, scimError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("member %q not found", ref.Value))
+			}
+			return nil, a.internalError("Failed to get user", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (a *api) loadTeam(c *models.ReqContext) (*models.Team, response.Response) {
+	id, ok := parseID(c)
+	if !ok {
+		return nil, scimError(http.StatusNotFound, "", "Group not found")
+	}
+
+	team, err := a.store.getTeam(c.Req.Context(), c.OrgId, id)
+	if errors.Is(err, models.ErrTeamNotFound) {
+		return nil, scimError(http.StatusNotFound, "", "Group not found")
+	}
+	if err != nil {
+		return nil, a.internalError("Failed to get group", err)
+	}
+	return team, nil
+}
+
+func (a *api) groupResponse(c *models.ReqContext, status int, team *models.Team) response.Response {
+	res, err := a.groupResource(c, team)
+	if err != nil {
+		return a.internalError("Failed to get group members", err)
+	}
+	return scimJSON(status, res).SetHeader("Location", a.location("Groups", team.Id))
+}
+
+// groupResource returns the SCIM representation of a team. The members are
+// left out with excludedAttributes=members, which identity providers use to
+// avoid listing the members of large groups.
+func (a *api) groupResource(c *models.ReqContext, team *models.Team) (*groupResource, error) {
+	created, updated := team.Created, team.Updated
+	res := &groupResource{
+		Schemas:     []string{schemaGroup},
+		ID:          strconv.FormatInt(team.Id, 10),
+		DisplayName: team.Name,
+		Meta: &meta{
+			ResourceType: resourceTypeGroup,
+			Created:      &created,
+			LastModified: &updated,
+			Location:     a.location("Groups", team.Id),
+		},
+	}
+
+	if excludesMembers(c.Query("excludedAttributes")) {
+		return res, nil
+	}
+
+	members, err := a.store.teamMembers(c.Req.Context(), c.OrgId, team.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range members {
+		res.Members = append(res.Members, reference{
+			Value:   strconv.FormatInt(user.Id, 10),
+			Ref:     a.location("Users", user.Id),
+			Display: user.Login,
+		})
+	}
+	return res, nil
+}
+
+func excludesMembers(excludedAttributes string) bool {
+	for _, attr := range strings.Split(excludedAttributes, ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), "members") {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
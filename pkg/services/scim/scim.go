@@ -0,0 +1,200 @@
+// Package scim implements a SCIM 2.0 server (RFC 7643 and RFC 7644), letting
+// identity providers provision the users and teams of an organization. SCIM
+// users are the users of the organization of the caller, deactivating one
+// disables the Grafana user. SCIM groups are the teams of the organization.
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/resourcepermissions"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/resourceservices"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const (
+	contentType = "application/scim+json"
+	basePath    = "/api/scim/v2"
+
+	defaultCount = 100
+	maxCount     = 1000
+)
+
+// Service registers the SCIM API when enabled in the [auth.scim] section.
+type Service struct{}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	routeRegister routing.RouteRegister,
+	ac accesscontrol.AccessControl,
+	sqlStore *sqlstore.SQLStore,
+	resourceServices *resourceservices.ResourceServices,
+	authTokenService models.UserTokenService,
+	auditService audit.Service,
+) *Service {
+	s := &Service{}
+	if !cfg.SCIMEnabled {
+		return s
+	}
+
+	a := &api{
+		cfg:              cfg,
+		ac:               ac,
+		router:           routeRegister,
+		store:            &store{sqlStore: sqlStore},
+		sqlStore:         sqlStore,
+		teamPermissions:  resourceServices.GetTeamService(),
+		authTokenService: authTokenService,
+		audit:            auditService,
+		log:              log.New("scim"),
+	}
+	a.registerEndpoints()
+
+	return s
+}
+
+type api struct {
+	cfg              *setting.Cfg
+	ac               accesscontrol.AccessControl
+	router           routing.RouteRegister
+	store            *store
+	sqlStore         *sqlstore.SQLStore
+	teamPermissions  *resourcepermissions.Service
+	authTokenService models.UserTokenService
+	audit            audit.Service
+	log              log.Logger
+}
+
+var (
+	globalUserIDScope = accesscontrol.Scope("global", "users", "id", accesscontrol.Parameter(":id"))
+	userIDScope       = accesscontrol.Scope("users", "id", accesscontrol.Parameter(":id"))
+	teamIDScope       = accesscontrol.Scope("teams", "id", accesscontrol.Parameter(":id"))
+)
+
+func (a *api) registerEndpoints() {
+	// Users are global, provisioning them requires the permissions of a
+	// Grafana server admin. Without access control, only server admins can.
+	auth := acmiddleware.Middleware(a.ac)
+	reqGrafanaAdmin := middleware.ReqGrafanaAdmin
+
+	readUsers := accesscontrol.EvalAll(
+		accesscontrol.EvalPermission(accesscontrol.ActionUsersRead, accesscontrol.ScopeGlobalUsersAll),
+		accesscontrol.EvalPermission(accesscontrol.ActionOrgUsersRead, accesscontrol.ScopeUsersAll),
+	)
+	readTeams := accesscontrol.EvalPermission(accesscontrol.ActionTeamsRead, accesscontrol.ScopeTeamsAll)
+
+	a.router.Group(basePath, func(r routing.RouteRegister) {
+		r.Get("/ServiceProviderConfig", middleware.ReqSignedIn, routing.Wrap(a.getServiceProviderConfig))
+		r.Get("/ResourceTypes", middleware.ReqSignedIn, routing.Wrap(a.getResourceTypes))
+		r.Get("/Schemas", middleware.ReqSignedIn, routing.Wrap(a.getSchemas))
+
+		r.Get("/Users", auth(reqGrafanaAdmin, readUsers), routing.Wrap(a.listUsers))
+		r.Post("/Users", auth(reqGrafanaAdmin, accesscontrol.EvalAll(
+			accesscontrol.EvalPermission(accesscontrol.ActionUsersCreate),
+			accesscontrol.EvalPermission(accesscontrol.ActionOrgUsersAdd, accesscontrol.ScopeUsersAll),
+		)), routing.Wrap(a.createUser))
+		r.Get("/Users/:id", auth(reqGrafanaAdmin, readUsers), routing.Wrap(a.getUser))
+		r.Put("/Users/:id", auth(reqGrafanaAdmin, accesscontrol.EvalPermission(accesscontrol.ActionUsersWrite, globalUserIDScope)), routing.Wrap(a.replaceUser))
+		r.Patch("/Users/:id", auth(reqGrafanaAdmin, accesscontrol.EvalPermission(accesscontrol.ActionUsersWrite, globalUserIDScope)), routing.Wrap(a.patchUser))
+		r.Delete("/Users/:id", auth(reqGrafanaAdmin, accesscontrol.EvalAll(
+			accesscontrol.EvalPermission(accesscontrol.ActionOrgUsersRemove, userIDScope),
+			accesscontrol.EvalPermission(accesscontrol.ActionUsersDelete, globalUserIDScope),
+		)), routing.Wrap(a.deleteUser))
+
+		r.Get("/Groups", auth(reqGrafanaAdmin, readTeams), routing.Wrap(a.listGroups))
+		r.Post("/Groups", auth(reqGrafanaAdmin, accesscontrol.EvalPermission(accesscontrol.ActionTeamsCreate)), routing.Wrap(a.createGroup))
+		r.Get("/Groups/:id", auth(reqGrafanaAdmin, readTeams), routing.Wrap(a.getGroup))
+		r.Put("/Groups/:id", auth(reqGrafanaAdmin, accesscontrol.EvalAll(
+			accesscontrol.EvalPermission(accesscontrol.ActionTeamsWrite, teamIDScope),
+			accesscontrol.EvalPermission(accesscontrol.ActionTeamsPermissionsWrite, teamIDScope),
+		)), routing.Wrap(a.replaceGroup))
+		r.Patch("/Groups/:id", auth(reqGrafanaAdmin, accesscontrol.EvalAll(
+			accesscontrol.EvalPermission(accesscontrol.ActionTeamsWrite, teamIDScope),
+			accesscontrol.EvalPermission(accesscontrol.ActionTeamsPermissionsWrite, teamIDScope),
+		)), routing.Wrap(a.patchGroup))
+		r.Delete("/Groups/:id", auth(reqGrafanaAdmin, accesscontrol.EvalPermission(accesscontrol.ActionTeamsDelete, teamIDScope)), routing.Wrap(a.deleteGroup))
+	})
+}
+
+// scimJSON creates a response with the SCIM media type.
+func scimJSON(status int, body interface{}) *response.NormalResponse {
+	return response.JSON(status, body).SetHeader("Content-Type", contentType)
+}
+
+// scimError creates a SCIM error response, scimType is one of the error
+// types of RFC 7644 section 3.12, or empty.
+func scimError(status int, scimType, detail string) response.Response {
+	return scimJSON(status, errorResponse{
+		Schemas:  []string{schemaError},
+		ScimType: scimType,
+		Detail:   detail,
+		Status:   strconv.Itoa(status),
+	})
+}
+
+func (a *api) internalError(msg string, err error) response.Response {
+	a.log.Error(msg, "error", err)
+	return scimError(http.StatusInternalServerError, "", msg)
+}
+
+// bind decodes a request body sent as application/scim+json, or as
+// application/json which identity providers use as well.
+func bind(req *http.Request, v interface{}) error {
+	m, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	if m != contentType && m != "application/json" {
+		return fmt.Errorf("unsupported content type %q", m)
+	}
+	defer func() { _ = req.Body.Close() }()
+	if err := json.NewDecoder(req.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// pagination returns the 1-based start index and the count requested.
+func pagination(c *models.ReqContext) (int, int) {
+	startIndex := c.QueryInt("startIndex")
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count := defaultCount
+	if c.Query("count") != "" {
+		count = c.QueryInt("count")
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > maxCount {
+		count = maxCount
+	}
+	return startIndex, count
+}
+
+func (a *api) location(resource string, id int64) string {
+	return strings.TrimSuffix(a.cfg.AppURL, "/") + basePath + "/" + resource + "/" + strconv.FormatInt(id, 10)
+}
+
+func parseID(c *models.ReqContext) (int64, bool) {
+	id, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	return id, err == nil
+}
@@ -0,0 +1,337 @@
+package scim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/resourceservices"
+	"github.com/grafana/grafana/pkg/services/audit/audittest"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+var provisionerPermissions = []*accesscontrol.Permission{
+	{Action: accesscontrol.ActionUsersRead, Scope: accesscontrol.ScopeGlobalUsersAll},
+	{Action: accesscontrol.ActionUsersCreate},
+	{Action: accesscontrol.ActionUsersWrite, Scope: accesscontrol.ScopeGlobalUsersAll},
+	{Action: accesscontrol.ActionUsersDelete, Scope: accesscontrol.ScopeGlobalUsersAll},
+	{Action: accesscontrol.ActionUsersEnable, Scope: accesscontrol.ScopeGlobalUsersAll},
+	{Action: accesscontrol.ActionUsersDisable, Scope: accesscontrol.ScopeGlobalUsersAll},
+	{Action: accesscontrol.ActionOrgUsersRead, Scope: accesscontrol.ScopeUsersAll},
+	{Action: accesscontrol.ActionOrgUsersAdd, Scope: accesscontrol.ScopeUsersAll},
+	{Action: accesscontrol.ActionOrgUsersRemove, Scope: accesscontrol.ScopeUsersAll},
+	{Action: accesscontrol.ActionTeamsCreate},
+	{Action: accesscontrol.ActionTeamsRead, Scope: accesscontrol.ScopeTeamsAll},
+	{Action: accesscontrol.ActionTeamsWrite, Scope: accesscontrol.ScopeTeamsAll},
+	{Action: accesscontrol.ActionTeamsDelete, Scope: accesscontrol.ScopeTeamsAll},
+	{Action: accesscontrol.ActionTeamsPermissionsWrite, Scope: accesscontrol.ScopeTeamsAll},
+}
+
+type testEnv struct {
+	server   *web.Mux
+	sqlStore *sqlstore.SQLStore
+	audit    *audittest.FakeService
+	revoked  []int64
+}
+
+func setupTestEnv(t *testing.T, permissions []*accesscontrol.Permission) *testEnv {
+	t.Helper()
+
+	sqlStore := sqlstore.InitTestDB(t)
+	admin, err := sqlStore.CreateUser(context.Background(), models.CreateUserCommand{Login: "admin", Email: "admin@localhost", IsAdmin: true})
+	require.NoError(t, err)
+
+	cfg := setting.NewCfg()
+	cfg.SCIMEnabled = true
+	cfg.AppURL = "http://localhost:3000/"
+	cfg.AutoAssignOrgRole = string(models.ROLE_VIEWER)
+
+	env := &testEnv{sqlStore: sqlStore, audit: audittest.NewFakeService()}
+	tokenService := auth.NewFakeUserAuthTokenService()
+	tokenService.RevokeAllUserTokensProvider = func(ctx context.Context, userID int64) error {
+		env.revoked = append(env.revoked, userID)
+		return nil
+	}
+
+	router := routing.NewRouteRegister()
+	ac := accesscontrolmock.New().WithPermissions(permissions)
+	resourceServices, err := resourceservices.ProvideResourceServices(router, sqlStore, ac, database.ProvideService(sqlStore), env.audit)
+	require.NoError(t, err)
+	ProvideService(cfg, router, ac, sqlStore, resourceServices, tokenService, env.audit)
+
+	env.server = web.New()
+	env.server.UseMiddleware(web.Renderer(path.Join(setting.StaticRootPath, "views"), "[[", "]]"))
+	env.server.Use(func(c *web.Context) {
+		c.Map(&models.ReqContext{
+			Context:      c,
+			SignedInUser: &models.SignedInUser{OrgId: admin.OrgId, UserId: admin.Id, Login: admin.Login, IsGrafanaAdmin: true},
+			IsSignedIn:   true,
+			SkipCache:    true,
+			Logger:       log.New("test"),
+		})
+	})
+	router.Register(env.server)
+
+	return env
+}
+
+func (e *testEnv) request(t *testing.T, method, url string, body interface{}, v interface{}) int {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	recorder := httptest.NewRecorder()
+	e.server.ServeHTTP(recorder, req)
+	if v != nil && recorder.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), v), recorder.Body.String())
+	}
+	return recorder.Code
+}
+
+func patch(ops ...patchOperation) patchRequest {
+	return patchRequest{Schemas: []string{schemaPatchOp}, Operations: ops}
+}
+
+func TestUsers(t *testing.T) {
+	env := setupTestEnv(t, provisionerPermissions)
+	active := true
+
+	var created userResource
+	status := env.request(t, http.MethodPost, "/api/scim/v2/Users", userResource{
+		Schemas:    []string{schemaUser},
+		ExternalID: "00u1",
+		UserName:   "john",
+		Name:       &name{GivenName: "John", FamilyName: "Doe"},
+		Emails:     []email{{Value: "john@example.com", Primary: true}},
+		Active:     &active,
+	}, &created)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "john", created.UserName)
+	assert.Equal(t, "John Doe", created.DisplayName)
+	assert.Equal(t, "00u1", created.ExternalID)
+	assert.Equal(t, "http://localhost:3000/api/scim/v2/Users/"+created.ID, created.Meta.Location)
+	usersURL := "/api/scim/v2/Users/" + created.ID
+
+	t.Run("should reject a duplicate user", func(t *testing.T) {
+		var res errorResponse
+		status := env.request(t, http.MethodPost, "/api/scim/v2/Users", userResource{UserName: "john"}, &res)
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, "uniqueness", res.ScimType)
+	})
+
+	t.Run("should filter users", func(t *testing.T) {
+		for _, filter := range []string{`userName+eq+"JOHN"`, `externalId+eq+"00u1"`, `emails.value+eq+"john@example.com"`} {
+			var list struct {
+				TotalResults int64          `json:"totalResults"`
+				Resources    []userResource `json:"Resources"`
+			}
+			require.Equal(t, http.StatusOK, env.request(t, http.MethodGet, "/api/scim/v2/Users?filter="+filter, nil, &list))
+			require.Equal(t, int64(1), list.TotalResults, filter)
+			assert.Equal(t, created.ID, list.Resources[0].ID)
+		}
+
+		var res errorResponse
+		assert.Equal(t, http.StatusBadRequest, env.request(t, http.MethodGet, `/api/scim/v2/Users?filter=title+eq+"x"`, nil, &res))
+		assert.Equal(t, "invalidFilter", res.ScimType)
+	})
+
+	t.Run("should page users", func(t *testing.T) {
+		var list listResponse
+		require.Equal(t, http.StatusOK, env.request(t, http.MethodGet, "/api/scim/v2/Users?startIndex=2&count=1", nil, &list))
+		assert.Equal(t, int64(2), list.TotalResults)
+		assert.Equal(t, 2, list.StartIndex)
+		assert.Equal(t, 1, list.ItemsPerPage)
+	})
+
+	t.Run("should patch a user the way identity providers do", func(t *testing.T) {
+		var patched userResource
+		status := env.request(t, http.MethodPatch, usersURL, patch(
+			patchOperation{Op: "Replace", Path: `emails[type eq "work"].value`, Value: json.RawMessage(`"johnny@example.com"`)},
+			patchOperation{Op: "Replace", Value: json.RawMessage(`{"displayName": "Johnny Doe", "title": "Engineer"}`)},
+		), &patched)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "johnny@example.com", patched.Emails[0].Value)
+		assert.Equal(t, "Johnny Doe", patched.DisplayName)
+		assert.Equal(t, "00u1", patched.ExternalID)
+	})
+
+	t.Run("should disable a deactivated user and sign them out", func(t *testing.T) {
+		var patched userResource
+		status := env.request(t, http.MethodPatch, usersURL, patch(
+			patchOperation{Op: "replace", Path: "active", Value: json.RawMessage(`"False"`)},
+		), &patched)
+		require.Equal(t, http.StatusOK, status)
+		require.NotNil(t, patched.Active)
+		assert.False(t, *patched.Active)
+
+		query := models.GetUserByLoginQuery{LoginOrEmail: "john"}
+		require.NoError(t, env.sqlStore.GetUserByLogin(context.Background(), &query))
+		assert.True(t, query.Result.IsDisabled)
+		assert.Equal(t, []int64{query.Result.Id}, env.revoked)
+	})
+
+	t.Run("should replace a user", func(t *testing.T) {
+		var replaced userResource
+		status := env.request(t, http.MethodPut, usersURL, userResource{
+			UserName: "john.doe",
+			Emails:   []email{{Value: "john.doe@example.com"}},
+			Active:   &active,
+		}, &replaced)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "john.doe", replaced.UserName)
+		assert.Equal(t, "Johnny Doe", replaced.DisplayName)
+		assert.Empty(t, replaced.ExternalID)
+		assert.True(t, *replaced.Active)
+	})
+
+	t.Run("should not rename a user to the login of another user", func(t *testing.T) {
+		status := env.request(t, http.MethodPut, usersURL, userResource{UserName: "admin"}, nil)
+		assert.Equal(t, http.StatusConflict, status)
+	})
+
+	t.Run("should delete a user", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, env.request(t, http.MethodDelete, usersURL, nil, nil))
+		assert.Equal(t, http.StatusNotFound, env.request(t, http.MethodGet, usersURL, nil, nil))
+
+		query := models.GetUserByLoginQuery{LoginOrEmail: "john.doe"}
+		assert.ErrorIs(t, env.sqlStore.GetUserByLogin(context.Background(), &query), models.ErrUserNotFound)
+	})
+
+	t.Run("should add an existing user to the organization", func(t *testing.T) {
+		_, err := env.sqlStore.CreateUser(context.Background(), models.CreateUserCommand{Login: "jane", Email: "jane@example.com", OrgName: "Other"})
+		require.NoError(t, err)
+
+		var res userResource
+		status := env.request(t, http.MethodPost, "/api/scim/v2/Users", userResource{UserName: "jane@example.com"}, &res)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, "jane", res.UserName)
+	})
+}
+
+func TestUsers_Forbidden(t *testing.T) {
+	env := setupTestEnv(t, []*accesscontrol.Permission{
+		{Action: accesscontrol.ActionUsersRead, Scope: accesscontrol.ScopeGlobalUsersAll},
+		{Action: accesscontrol.ActionOrgUsersRead, Scope: accesscontrol.ScopeUsersAll},
+		{Action: accesscontrol.ActionUsersWrite, Scope: accesscontrol.ScopeGlobalUsersAll},
+	})
+
+	assert.Equal(t, http.StatusForbidden, env.request(t, http.MethodPost, "/api/scim/v2/Users", userResource{UserName: "john"}, nil))
+
+	var list struct {
+		Resources []userResource `json:"Resources"`
+	}
+	require.Equal(t, http.StatusOK, env.request(t, http.MethodGet, "/api/scim/v2/Users", nil, &list))
+	require.Len(t, list.Resources, 1)
+
+	status := env.request(t, http.MethodPatch, "/api/scim/v2/Users/"+list.Resources[0].ID, patch(
+		patchOperation{Op: "replace", Path: "active", Value: json.RawMessage(`false`)},
+	), nil)
+	assert.Equal(t, http.StatusForbidden, status)
+}
+
+func TestGroups(t *testing.T) {
+	env := setupTestEnv(t, provisionerPermissions)
+	ctx := context.Background()
+
+	var userIDs []string
+	for _, login := range []string{"alice", "bob"} {
+		var res userResource
+		require.Equal(t, http.StatusCreated, env.request(t, http.MethodPost, "/api/scim/v2/Users", userResource{UserName: login}, &res))
+		userIDs = append(userIDs, res.ID)
+	}
+
+	var created groupResource
+	status := env.request(t, http.MethodPost, "/api/scim/v2/Groups", groupResource{
+		DisplayName: "Engineering",
+		Members:     []reference{{Value: userIDs[0]}},
+	}, &created)
+	require.Equal(t, http.StatusCreated, status)
+	require.Len(t, created.Members, 1)
+	assert.Equal(t, "alice", created.Members[0].Display)
+	groupURL := "/api/scim/v2/Groups/" + created.ID
+
+	t.Run("should reject a duplicate group", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, env.request(t, http.MethodPost, "/api/scim/v2/Groups", groupResource{DisplayName: "Engineering"}, nil))
+	})
+
+	t.Run("should reject unknown members", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, env.request(t, http.MethodPost, "/api/scim/v2/Groups", groupResource{
+			DisplayName: "Other", Members: []reference{{Value: "999"}},
+		}, nil))
+	})
+
+	t.Run("should add and remove members", func(t *testing.T) {
+		var res groupResource
+		status := env.request(t, http.MethodPatch, groupURL, patch(
+			patchOperation{Op: "Add", Path: "members", Value: json.RawMessage(fmt.Sprintf(`[{"value": %q}]`, userIDs[1]))},
+			patchOperation{Op: "Remove", Path: fmt.Sprintf(`members[value eq "%s"]`, userIDs[0])},
+		), &res)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, res.Members, 1)
+		assert.Equal(t, "bob", res.Members[0].Display)
+	})
+
+	t.Run("should list user groups", func(t *testing.T) {
+		var res userResource
+		require.Equal(t, http.StatusOK, env.request(t, http.MethodGet, "/api/scim/v2/Users/"+userIDs[1], nil, &res))
+		require.Len(t, res.Groups, 1)
+		assert.Equal(t, "Engineering", res.Groups[0].Display)
+	})
+
+	t.Run("should replace a group", func(t *testing.T) {
+		var res groupResource
+		status := env.request(t, http.MethodPut, groupURL, groupResource{
+			DisplayName: "Platform",
+			Members:     []reference{{Value: userIDs[0]}},
+		}, &res)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "Platform", res.DisplayName)
+		require.Len(t, res.Members, 1)
+		assert.Equal(t, "alice", res.Members[0].Display)
+	})
+
+	t.Run("should exclude members", func(t *testing.T) {
+		var list struct {
+			TotalResults int64           `json:"totalResults"`
+			Resources    []groupResource `json:"Resources"`
+		}
+		status := env.request(t, http.MethodGet, `/api/scim/v2/Groups?filter=displayName+eq+"Platform"&excludedAttributes=members`, nil, &list)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, int64(1), list.TotalResults)
+		assert.Empty(t, list.Resources[0].Members)
+	})
+
+	t.Run("should delete a group", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, env.request(t, http.MethodDelete, groupURL, nil, nil))
+		assert.Equal(t, http.StatusNotFound, env.request(t, http.MethodGet, groupURL, nil, nil))
+
+		teams, _, err := (&store{sqlStore: env.sqlStore}).searchTeams(ctx, 1, nil, 0, 10)
+		require.NoError(t, err)
+		assert.Empty(t, teams)
+	})
+}
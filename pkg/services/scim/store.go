@@ -0,0 +1,201 @@
+package scim
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// store reads the users and teams of an organization the way they are
+// exposed through SCIM: users with their disabled state and external ID,
+// teams with their members. Writes go through the regular sqlstore methods.
+type store struct {
+	sqlStore *sqlstore.SQLStore
+}
+
+func (s *store) userTable() string {
+	return s.sqlStore.Dialect.Quote("user")
+}
+
+// searchUsers returns a page of the users of an organization matching the
+// filter, and the total number of matching users.
+func (s *store) searchUsers(ctx context.Context, orgID int64, f *filter, offset, limit int) ([]*models.User, int64, error) {
+	user := s.userTable()
+	where := []string{"org_user.org_id = ?", fmt.Sprintf("%s.is_service_account = %s", user, s.sqlStore.Dialect.BooleanStr(false))}
+	params := []interface{}{orgID}
+
+	if f != nil {
+		switch f.Attribute {
+		case "id":
+			id, err := strconv.ParseInt(f.Value, 10, 64)
+			if err != nil {
+				return []*models.User{}, 0, nil
+			}
+			where = append(where, user+".id = ?")
+			params = append(params, id)
+		case "username":
+			where = append(where, "LOWER("+user+".login) = LOWER(?)")
+			params = append(params, f.Value)
+		case "emails", "emails.value":
+			where = append(where, "LOWER("+user+".email) = LOWER(?)")
+			params = append(params, f.Value)
+		case "displayname":
+			where = append(where, user+".name = ?")
+			params = append(params, f.Value)
+		case "externalid":
+			where = append(where, user+".id IN (SELECT user_id FROM user_auth WHERE auth_module = ? AND auth_id = ?)")
+			params = append(params, models.AuthModuleSCIM, f.Value)
+		}
+	}
+
+	from := " FROM " + user + " INNER JOIN org_user ON org_user.user_id = " + user + ".id WHERE " + strings.Join(where, " AND ")
+
+	users := make([]*models.User, 0)
+	var total int64
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.SQL("SELECT COUNT(*)"+from, params...).Get(&total); err != nil {
+			return err
+		}
+		rawSQL := "SELECT " + user + ".*" + from + " ORDER BY " + user + ".id " + s.sqlStore.Dialect.LimitOffset(int64(limit), int64(offset))
+		return sess.SQL(rawSQL, params...).Find(&users)
+	})
+	return users, total, err
+}
+
+// getUser returns a user of an organization.
+func (s *store) getUser(ctx context.Context, orgID, userID int64) (*models.User, error) {
+	users, _, err := s.searchUsers(ctx, orgID, &filter{Attribute: "id", Value: strconv.FormatInt(userID, 10)}, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, models.ErrUserNotFound
+	}
+	return users[0], nil
+}
+
+// externalIDs returns the external IDs the identity provider assigned to the
+// given users.
+func (s *store) externalIDs(ctx context.Context, userIDs []int64) (map[int64]string, error) {
+	result := make(map[int64]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var links []*models.UserAuth
+		if err := sess.In("user_id", userIDs).Where("auth_module = ?", models.AuthModuleSCIM).Find(&links); err != nil {
+			return err
+		}
+		for _, link := range links {
+			result[link.UserId] = link.AuthId
+		}
+		return nil
+	})
+	return result, err
+}
+
+// setExternalID replaces the external ID of a user, an empty ID removes it.
+// An existing link is updated in place, so that it doesn't become the most
+// recent authentication of the user.
+func (s *store) setExternalID(ctx context.Context, userID int64, externalID string) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if externalID == "" {
+			_, err := sess.Exec("DELETE FROM user_auth WHERE user_id = ? AND auth_module = ?", userID, models.AuthModuleSCIM)
+			return err
+		}
+
+		link := &models.UserAuth{}
+		has, err := sess.Where("user_id = ? AND auth_module = ?", userID, models.AuthModuleSCIM).Get(link)
+		if err != nil {
+			return err
+		}
+		if has {
+			_, err := sess.ID(link.Id).Cols("auth_id").Update(&models.UserAuth{AuthId: externalID})
+			return err
+		}
+
+		_, err = sess.Insert(&models.UserAuth{
+			UserId:     userID,
+			AuthModule: models.AuthModuleSCIM,
+			AuthId:     externalID,
+			Created:    time.Now(),
+		})
+		return err
+	})
+}
+
+// userTeams returns the teams a user is a member of in an organization.
+func (s *store) userTeams(ctx context.Context, orgID, userID int64) ([]*models.Team, error) {
+	teams := make([]*models.Team, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := "SELECT team.* FROM team INNER JOIN team_member ON team_member.team_id = team.id " +
+			"WHERE team.org_id = ? AND team_member.user_id = ? ORDER BY team.name"
+		return sess.SQL(rawSQL, orgID, userID).Find(&teams)
+	})
+	return teams, err
+}
+
+// searchTeams returns a page of the teams of an organization matching the
+// filter, and the total number of matching teams.
+func (s *store) searchTeams(ctx context.Context, orgID int64, f *filter, offset, limit int) ([]*models.Team, int64, error) {
+	where := []string{"org_id = ?"}
+	params := []interface{}{orgID}
+
+	if f != nil {
+		switch f.Attribute {
+		case "id":
+			id, err := strconv.ParseInt(f.Value, 10, 64)
+			if err != nil {
+				return []*models.Team{}, 0, nil
+			}
+			where = append(where, "id = ?")
+			params = append(params, id)
+		case "displayname":
+			where = append(where, "LOWER(name) = LOWER(?)")
+			params = append(params, f.Value)
+		}
+	}
+
+	from := " FROM team WHERE " + strings.Join(where, " AND ")
+
+	teams := make([]*models.Team, 0)
+	var total int64
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.SQL("SELECT COUNT(*)"+from, params...).Get(&total); err != nil {
+			return err
+		}
+		rawSQL := "SELECT *" + from + " ORDER BY id " + s.sqlStore.Dialect.LimitOffset(int64(limit), int64(offset))
+		return sess.SQL(rawSQL, params...).Find(&teams)
+	})
+	return teams, total, err
+}
+
+// getTeam returns a team of an organization.
+func (s *store) getTeam(ctx context.Context, orgID, teamID int64) (*models.Team, error) {
+	teams, _, err := s.searchTeams(ctx, orgID, &filter{Attribute: "id", Value: strconv.FormatInt(teamID, 10)}, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(teams) == 0 {
+		return nil, models.ErrTeamNotFound
+	}
+	return teams[0], nil
+}
+
+// teamMembers returns the users who are members of a team.
+func (s *store) teamMembers(ctx context.Context, orgID, teamID int64) ([]*models.User, error) {
+	user := s.userTable()
+	users := make([]*models.User, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := "SELECT " + user + ".* FROM team_member INNER JOIN " + user + " ON " + user + ".id = team_member.user_id " +
+			"WHERE team_member.org_id = ? AND team_member.team_id = ? ORDER BY " + user + ".id"
+		return sess.SQL(rawSQL, orgID, teamID).Find(&users)
+	})
+	return users, err
+}
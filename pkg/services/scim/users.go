@@ -0,0 +1,501 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/audit"
+)
+
+var userFilterAttributes = []string{"id", "userName", "externalId", "emails", "emails.value", "displayName"}
+
+// GET /api/scim/v2/Users
+func (a *api) listUsers(c *models.ReqContext) response.Response {
+	f, err := parseFilter(c.Query("filter"), userFilterAttributes...)
+	if err != nil {
+		return scimError(http.StatusBadRequest, "invalidFilter", err.Error())
+	}
+
+	startIndex, count := pagination(c)
+	users, total, err := a.store.searchUsers(c.Req.Context(), c.OrgId, f, startIndex-1, count)
+	if err != nil {
+		return a.internalError("Failed to search users", err)
+	}
+
+	resources, err := a.userResources(c.Req.Context(), c.OrgId, users)
+	if err != nil {
+		return a.internalError("Failed to get users", err)
+	}
+
+	return scimJSON(http.StatusOK, listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GET /api/scim/v2/Users/:id
+func (a *api) getUser(c *models.ReqContext) response.Response {
+	user, resp := a.loadUser(c)
+	if resp != nil {
+		return resp
+	}
+	return a.userResponse(c, http.StatusOK, user)
+}
+
+// POST /api/scim/v2/Users
+func (a *api) createUser(c *models.ReqContext) response.Response {
+	var res userResource
+	if err := bind(c.Req, &res); err != nil {
+		return scimError(http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+	if res.UserName == "" {
+		return scimError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+
+	ctx := c.Req.Context()
+	var user *models.User
+	query := models.GetUserByLoginQuery{LoginOrEmail: res.UserName}
+	err := a.sqlStore.GetUserByLogin(ctx, &query)
+	switch {
+	case err == nil:
+		// Users are global, an existing user, e.g. one who signed in with
+		// LDAP or OAuth before, is added to the organization.
+		user = query.Result
+		if user.IsServiceAccount {
+			return scimError(http.StatusConflict, "uniqueness", "A service account has the same login")
+		}
+		if _, err := a.store.getUser(ctx, c.OrgId, user.Id); err == nil {
+			return scimError(http.StatusConflict, "uniqueness", "User already exists")
+		} else if !errors.Is(err, models.ErrUserNotFound) {
+			return a.internalError("Failed to get user", err)
+		}
+	case errors.Is(err, models.ErrUserNotFound):
+		user, err = a.sqlStore.CreateUser(ctx, models.CreateUserCommand{
+			Login:        res.UserName,
+			Email:        res.primaryEmail(),
+			Name:         res.fullName(),
+			SkipOrgSetup: true,
+		})
+		if errors.Is(err, models.ErrUserAlreadyExists) {
+			return scimError(http.StatusConflict, "uniqueness", "A user with the same email already exists")
+		}
+		if err != nil {
+			return a.internalError("Failed to create user", err)
+		}
+	default:
+		return a.internalError("Failed to get user", err)
+	}
+
+	cmd := models.AddOrgUserCommand{OrgId: c.OrgId, UserId: user.Id, Role: models.RoleType(a.cfg.AutoAssignOrgRole)}
+	if err := a.sqlStore.AddOrgUser(ctx, &cmd); err != nil {
+		return a.internalError("Failed to add user to organization", err)
+	}
+
+	if res.ExternalID != "" {
+		if err := a.store.setExternalID(ctx, user.Id, res.ExternalID); err != nil {
+			return a.internalError("Failed to set external ID", err)
+		}
+	}
+
+	if res.Active != nil {
+		if resp := a.setActive(c, user, *res.Active); resp != nil {
+			return resp
+		}
+	}
+
+	a.audit.Record(ctx, audit.NewEntry(c, audit.ActionCreate, audit.ResourceUser, strconv.FormatInt(user.Id, 10)).
+		WithDetails("provisioning", "scim", "userName", res.UserName, "externalId", res.ExternalID))
+
+	return a.userResponse(c, http.StatusCreated, user)
+}
+
+// PUT /api/scim/v2/Users/:id
+func (a *api) replaceUser(c *models.ReqContext) response.Response {
+	user, resp := a.loadUser(c)
+	if resp != nil {
+		return resp
+	}
+
+	var res userResource
+	if err := bind(c.Req, &res); err != nil {
+		return scimError(http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+	if res.UserName == "" {
+		return scimError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+
+	update := userUpdate{
+		login:      res.UserName,
+		email:      res.primaryEmail(),
+		name:       res.fullName(),
+		active:     res.Active,
+		externalID: &res.ExternalID,
+	}
+	return a.updateUser(c, user, update)
+}
+
+// PATCH /api/scim/v2/Users/:id
+func (a *api) patchUser(c *models.ReqContext) response.Response {
+	user, resp := a.loadUser(c)
+	if resp != nil {
+		return resp
+	}
+
+	var req patchRequest
+	if err := bind(c.Req, &req); err != nil {
+		return scimError(http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	p := &userPatch{}
+	for _, op := range req.Operations {
+		var err error
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+			err = p.apply(op.Path, op.Value)
+		case "remove":
+			if !strings.EqualFold(op.Path, "externalId") {
+				return scimError(http.StatusBadRequest, "mutability", fmt.Sprintf("%q can't be removed", op.Path))
+			}
+			empty := ""
+			p.externalID = &empty
+		default:
+			err = fmt.Errorf("unsupported operation %q", op.Op)
+		}
+		if err != nil {
+			return scimError(http.StatusBadRequest, "invalidValue", err.Error())
+		}
+	}
+
+	return a.updateUser(c, user, p.update(user))
+}
+
+// DELETE /api/scim/v2/Users/:id
+//
+// Removes the user from the organization, and deletes the user if they're not
+// a member of any other organization.
+func (a *api) deleteUser(c *models.ReqContext) response.Response {
+	user, resp := a.loadUser(c)
+	if resp != nil {
+		return resp
+	}
+
+	cmd := models.RemoveOrgUserCommand{UserId: user.Id, OrgId: c.OrgId, ShouldDeleteOrphanedUser: true}
+	if err := a.sqlStore.RemoveOrgUser(c.Req.Context(), &cmd); err != nil {
+		if errors.Is(err, models.ErrLastOrgAdmin) {
+			return scimError(http.StatusBadRequest, "mutability", "Cannot remove the last organization admin")
+		}
+		return a.internalError("Failed to remove user", err)
+	}
+
+	a.audit.Record(c.Req.Context(), audit.NewEntry(c, audit.ActionDelete, audit.ResourceUser, strconv.FormatInt(user.Id, 10)).
+		WithDetails("provisioning", "scim", "userWasDeleted", cmd.UserWasDeleted))
+
+	return response.Empty(http.StatusNoContent)
+}
+
+// userUpdate holds the new state of a user, nil fields are left unchanged.
+type userUpdate struct {
+	login      string
+	email      string
+	name       string
+	active     *bool
+	externalID *string
+}
+
+func (a *api) updateUser(c *models.ReqContext, user *models.User, update userUpdate) response.Response {
+	ctx := c.Req.Context()
+
+	if update.email == "" {
+		update.email = user.Email
+	}
+	if update.name == "" {
+		update.name = user.Name
+	}
+
+	if update.login != user.Login || update.email != user.Email || update.name != user.Name {
+		if resp := a.checkUnique(ctx, user.Id, update.login, update.email); resp != nil {
+			return resp
+		}
+		cmd := models.UpdateUserCommand{UserId: user.Id, Login: update.login, Email: update.email, Name: update.name}
+		if err := a.sqlStore.UpdateUser(ctx, &cmd); err != nil {
+			return a.internalError("Failed to update user", err)
+		}
+		user.Login, user.Email, user.Name = update.login, update.email, update.name
+	}
+
+	if update.externalID != nil {
+		if err := a.store.setExternalID(ctx, user.Id, *update.externalID); err != nil {
+			return a.internalError("Failed to set external ID", err)
+		}
+	}
+
+	if update.active != nil {
+		if resp := a.setActive(c, user, *update.active); resp != nil {
+			return resp
+		}
+	}
+
+	a.audit.Record(ctx, audit.NewEntry(c, audit.ActionUpdate, audit.ResourceUser, strconv.FormatInt(user.Id, 10)).
+		WithDetails("provisioning", "scim", "userName", user.Login, "active", !user.IsDisabled))
+
+	return a.userResponse(c, http.StatusOK, user)
+}
+
+// checkUnique verifies that no other user has the login or email.
+func (a *api) checkUnique(ctx context.Context, userID int64, login, email string) response.Response {
+	for _, loginOrEmail := range []string{login, email} {
+		query := models.GetUserByLoginQuery{LoginOrEmail: loginOrEmail}
+		err := a.sqlStore.GetUserByLogin(ctx, &query)
+		if err == nil && query.Result.Id != userID {
+			return scimError(http.StatusConflict, "uniqueness", "Another user has the same login or email")
+		}
+		if err != nil && !errors.Is(err, models.ErrUserNotFound) {
+			return a.internalError("Failed to get user", err)
+		}
+	}
+	return nil
+}
+
+// setActive enables or disables a user. A disabled user is signed out
+// immediately.
+func (a *api) setActive(c *models.ReqContext, user *models.User, active bool) response.Response {
+	if user.IsDisabled == !active {
+		return nil
+	}
+
+	ctx := c.Req.Context()
+	action := accesscontrol.ActionUsersDisable
+	if active {
+		action = accesscontrol.ActionUsersEnable
+	}
+	if !a.ac.IsDisabled() {
+		ok, err := a.ac.Evaluate(ctx, c.SignedInUser, accesscontrol.EvalPermission(action, accesscontrol.Scope("global", "users", "id", strconv.FormatInt(user.Id, 10))))
+		if err != nil {
+			return a.internalError("Failed to evaluate permissions", err)
+		}
+		if !ok {
+			return scimError(http.StatusForbidden, "", "Not allowed to change whether the user is active")
+		}
+	}
+
+	if err := a.sqlStore.DisableUser(ctx, &models.DisableUserCommand{UserId: user.Id, IsDisabled: !active}); err != nil {
+		return a.internalError("Failed to change whether the user is active", err)
+	}
+	user.IsDisabled = !active
+
+	if !active {
+		if err := a.authTokenService.RevokeAllUserTokens(ctx, user.Id); err != nil {
+			return a.internalError("Failed to sign out the user", err)
+		}
+	}
+
+	return nil
+}
+
+func (a *api) loadUser(c *models.ReqContext) (*models.User, response.Response) {
+	id, ok := parseID(c)
+	if !ok {
+		return nil, scimError(http.StatusNotFound, "", "User not found")
+	}
+
+	user, err := a.store.getUser(c.Req.Context(), c.OrgId, id)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return nil, scimError(http.StatusNotFound, "", "User not found")
+	}
+	if err != nil {
+		return nil, a.internalError("Failed to get user", err)
+	}
+	return user, nil
+}
+
+func (a *api) userResponse(c *models.ReqContext, status int, user *models.User) response.Response {
+	resources, err := a.userResources(c.Req.Context(), c.OrgId, []*models.User{user})
+	if err != nil {
+		return a.internalError("Failed to get user", err)
+	}
+	return scimJSON(status, resources[0]).SetHeader("Location", a.location("Users", user.Id))
+}
+
+func (a *api) userResources(ctx context.Context, orgID int64, users []*models.User) ([]interface{}, error) {
+	ids := make([]int64, len(users))
+	for i, user := range users {
+		ids[i] = user.Id
+	}
+	externalIDs, err := a.store.externalIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]interface{}, len(users))
+	for i, user := range users {
+		teams, err := a.store.userTeams(ctx, orgID, user.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		active := !user.IsDisabled
+		created, updated := user.Created, user.Updated
+		res := &userResource{
+			Schemas:     []string{schemaUser},
+			ID:          strconv.FormatInt(user.Id, 10),
+			ExternalID:  externalIDs[user.Id],
+			UserName:    user.Login,
+			DisplayName: user.Name,
+			Active:      &active,
+			Meta: &meta{
+				ResourceType: resourceTypeUser,
+				Created:      &created,
+				LastModified: &updated,
+				Location:     a.location("Users", user.Id),
+			},
+		}
+		if user.Name != "" {
+			res.Name = &name{Formatted: user.Name}
+		}
+		if user.Email != "" {
+			res.Emails = []email{{Value: user.Email, Type: "work", Primary: true}}
+		}
+		for _, team := range teams {
+			res.Groups = append(res.Groups, reference{
+				Value:   strconv.FormatInt(team.Id, 10),
+				Ref:     a.location("Groups", team.Id),
+				Display: team.Name,
+			})
+		}
+		resources[i] = res
+	}
+	return resources, nil
+}
+
+// userPatch collects the changes of the operations of a PATCH request.
+// Attributes Grafana doesn't store are ignored.
+type userPatch struct {
+	userName    *string
+	email       *string
+	displayName *string
+	formatted   *string
+	givenName   *string
+	familyName  *string
+	active      *bool
+	externalID  *string
+}
+
+// apply records an add or replace operation. Without a path, the value is
+// an object of the attributes to set.
+func (p *userPatch) apply(path string, value json.RawMessage) error {
+	if path == "" {
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(value, &values); err != nil {
+			return fmt.Errorf("value must be an object without path: %w", err)
+		}
+		for attr, v := range values {
+			if err := p.apply(attr, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	attr := strings.ToLower(path)
+	switch {
+	case attr == "active":
+		active, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		p.active = &active
+	case attr == "username":
+		return unmarshalString(value, &p.userName)
+	case attr == "displayname":
+		return unmarshalString(value, &p.displayName)
+	case attr == "externalid":
+		return unmarshalString(value, &p.externalID)
+	case attr == "name":
+		var n name
+		if err := json.Unmarshal(value, &n); err != nil {
+			return fmt.Errorf("invalid name: %w", err)
+		}
+		p.formatted, p.givenName, p.familyName = &n.Formatted, &n.GivenName, &n.FamilyName
+	case attr == "name.formatted":
+		return unmarshalString(value, &p.formatted)
+	case attr == "name.givenname":
+		return unmarshalString(value, &p.givenName)
+	case attr == "name.familyname":
+		return unmarshalString(value, &p.familyName)
+	case attr == "emails":
+		var emails []email
+		if err := json.Unmarshal(value, &emails); err != nil {
+			return fmt.Errorf("invalid emails: %w", err)
+		}
+		e := (&userResource{Emails: emails}).primaryEmail()
+		p.email = &e
+	case strings.HasPrefix(attr, "emails["):
+		// e.g. emails[type eq "work"].value
+		return unmarshalString(value, &p.email)
+	}
+	return nil
+}
+
+// update returns the new state of the user.
+func (p *userPatch) update(user *models.User) userUpdate {
+	update := userUpdate{login: user.Login, email: user.Email, name: user.Name, active: p.active, externalID: p.externalID}
+	if p.userName != nil && *p.userName != "" {
+		update.login = *p.userName
+	}
+	if p.email != nil {
+		update.email = *p.email
+	}
+	switch {
+	case p.displayName != nil:
+		update.name = *p.displayName
+	case p.formatted != nil && *p.formatted != "":
+		update.name = *p.formatted
+	case p.givenName != nil || p.familyName != nil:
+		var parts []string
+		for _, part := range []*string{p.givenName, p.familyName} {
+			if part != nil && *part != "" {
+				parts = append(parts, *part)
+			}
+		}
+		update.name = strings.Join(parts, " ")
+	}
+	return update
+}
+
+func unmarshalString(value json.RawMessage, dst **string) error {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return fmt.Errorf("invalid string value: %w", err)
+	}
+	*dst = &s
+	return nil
+}
+
+// parseBool parses a boolean, some identity providers send them as strings,
+// e.g. "False".
+func parseBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, fmt.Errorf("invalid boolean value")
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean value %q", s)
+	}
+	return b, nil
+}
@@ -31,8 +31,10 @@ func (ss *SecretsStoreImpl) GetDataKey(ctx context.Context, name string) (*secre
 
 	err := ss.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
 		var err error
+		// Retired data keys are still returned, as they are needed to
+		// decrypt secrets which have not been re-encrypted yet.
 		exists, err = sess.Table(dataKeysTable).
-			Where("name = ? AND active = ?", name, ss.sqlStore.Dialect.BooleanStr(true)).
+			Where("name = ?", name).
 			Get(dataKey)
 		return err
 	})
@@ -76,6 +78,21 @@ func (ss *SecretsStoreImpl) CreateDataKeyWithDBSession(_ context.Context, dataKe
 	return err
 }
 
+// RetireDataKeys deactivates the active data keys created before the given
+// time and returns the number of retired keys.
+func (ss *SecretsStoreImpl) RetireDataKeys(ctx context.Context, createdBefore time.Time) (int64, error) {
+	var affected int64
+	err := ss.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		affected, err = sess.Table(dataKeysTable).
+			Where("active = ? AND created < ?", ss.sqlStore.Dialect.BooleanStr(true), createdBefore).
+			Cols("active", "updated").
+			Update(&secrets.DataKey{Active: false, Updated: time.Now()})
+		return err
+	})
+	return affected, err
+}
+
 func (ss *SecretsStoreImpl) DeleteDataKey(ctx context.Context, name string) error {
 	if len(name) == 0 {
 		return fmt.Errorf("data key name is missing")
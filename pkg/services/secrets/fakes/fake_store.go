@@ -2,6 +2,7 @@ package fakes
 
 import (
 	"context"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"xorm.io/xorm"
@@ -41,6 +42,18 @@ func (f FakeSecretsStore) CreateDataKeyWithDBSession(_ context.Context, dataKey
 	return nil
 }
 
+func (f FakeSecretsStore) RetireDataKeys(_ context.Context, createdBefore time.Time) (int64, error) {
+	var retired int64
+	for _, key := range f.store {
+		if key.Active && key.Created.Before(createdBefore) {
+			key.Active = false
+			key.Updated = time.Now()
+			retired++
+		}
+	}
+	return retired, nil
+}
+
 func (f FakeSecretsStore) DeleteDataKey(_ context.Context, name string) error {
 	delete(f.store, name)
 	return nil
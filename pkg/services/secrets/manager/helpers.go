@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/kmsproviders/osskmsproviders"
 	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/references"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -42,6 +43,7 @@ func SetupTestService(tb testing.TB, store secrets.Store) *SecretsService {
 		settings,
 		features,
 		&usagestats.UsageStatsMock{T: tb},
+		references.ProvideResolver(settings),
 	)
 	require.NoError(tb, err)
 
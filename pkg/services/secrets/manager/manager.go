@@ -9,12 +9,14 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/kmsproviders"
 	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/references"
 	"github.com/grafana/grafana/pkg/setting"
 	"golang.org/x/sync/errgroup"
 	"xorm.io/xorm"
@@ -26,8 +28,10 @@ type SecretsService struct {
 	settings   setting.Provider
 	features   featuremgmt.FeatureToggles
 	usageStats usagestats.Service
+	references *references.Resolver
 
 	currentProviderID secrets.ProviderID
+	rotationInterval  time.Duration
 	providers         map[secrets.ProviderID]secrets.Provider
 	dataKeyCache      map[string]dataKeyCacheItem
 	log               log.Logger
@@ -40,6 +44,7 @@ func ProvideSecretsService(
 	settings setting.Provider,
 	features featuremgmt.FeatureToggles,
 	usageStats usagestats.Service,
+	resolver *references.Resolver,
 ) (*SecretsService, error) {
 	providers, err := kmsProvidersService.Provide()
 	if err != nil {
@@ -67,8 +72,10 @@ func ProvideSecretsService(
 		enc:               enc,
 		settings:          settings,
 		usageStats:        usageStats,
+		references:        resolver,
 		providers:         providers,
 		currentProviderID: currentProviderID,
+		rotationInterval:  RotationInterval(settings),
 		dataKeyCache:      make(map[string]dataKeyCacheItem),
 		features:          features,
 		log:               logger,
@@ -173,8 +180,29 @@ func (s *SecretsService) EncryptWithDBSession(ctx context.Context, payload []byt
 	return blob, nil
 }
 
+// keyName returns the name of the data key used to encrypt secrets of the
+// given scope. Names change daily, or on every rotation period when data
+// keys rotation is enabled, so a new data key is created for each of them.
 func (s *SecretsService) keyName(scope string) string {
-	return fmt.Sprintf("%s/%s@%s", now().Format("2006-01-02"), scope, s.currentProviderID)
+	period := now().UTC().Format("2006-01-02")
+	if s.rotationInterval > 0 {
+		period = now().UTC().Truncate(s.rotationInterval).Format("2006-01-02T15:04")
+	}
+	return fmt.Sprintf("%s/%s@%s", period, scope, s.currentProviderID)
+}
+
+// RotationInterval returns the data_keys_rotation_interval configured in the
+// [security.encryption] section, zero means data keys rotation is disabled.
+func RotationInterval(settings setting.Provider) time.Duration {
+	value := settings.KeyValue("security.encryption", "data_keys_rotation_interval").Value()
+	if value == "" || value == "0" {
+		return 0
+	}
+	interval, err := gtime.ParseDuration(value)
+	if err != nil || interval < 0 {
+		return 0
+	}
+	return interval
 }
 
 func (s *SecretsService) Decrypt(ctx context.Context, payload []byte) ([]byte, error) {
@@ -244,17 +272,24 @@ func (s *SecretsService) DecryptJsonData(ctx context.Context, sjd map[string][]b
 
 		decrypted[key] = string(decryptedData)
 	}
-	return decrypted, nil
+	return s.references.ResolveJsonData(ctx, decrypted)
 }
 
 func (s *SecretsService) GetDecryptedValue(ctx context.Context, sjd map[string][]byte, key, fallback string) string {
 	if value, ok := sjd[key]; ok {
 		decryptedData, err := s.Decrypt(ctx, value)
 		if err != nil {
+			s.log.Error("Failed to decrypt secure value, using the fallback", "key", key, "error", err)
+			return fallback
+		}
+
+		resolved, err := s.references.Resolve(ctx, string(decryptedData))
+		if err != nil {
+			s.log.Error("Failed to resolve secure value reference, using the fallback", "key", key, "error", err)
 			return fallback
 		}
 
-		return string(decryptedData)
+		return resolved
 	}
 
 	return fallback
@@ -356,12 +391,12 @@ func (s *SecretsService) GetProviders() map[secrets.ProviderID]secrets.Provider
 func (s *SecretsService) ReEncryptDataKeys(ctx context.Context) error {
 	err := s.store.ReEncryptDataKeys(ctx, s.providers, s.currentProviderID)
 	if err != nil {
-		return nil
+		return err
 	}
 
 	// Invalidate cache
 	s.dataKeyCache = make(map[string]dataKeyCacheItem)
-	return err
+	return nil
 }
 
 // These variables are used to test the code
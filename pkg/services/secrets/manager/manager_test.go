@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/kmsproviders/osskmsproviders"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/secrets/database"
+	"github.com/grafana/grafana/pkg/services/secrets/references"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
@@ -197,6 +198,7 @@ func TestSecretsService_UseCurrentProvider(t *testing.T) {
 			settings,
 			features,
 			&usagestats.UsageStatsMock{T: t},
+			references.ProvideResolver(settings),
 		)
 		require.NoError(t, err)
 
@@ -215,6 +217,7 @@ func TestSecretsService_UseCurrentProvider(t *testing.T) {
 			settings,
 			features,
 			&usagestats.UsageStatsMock{T: t},
+			references.ProvideResolver(settings),
 		)
 		require.NoError(t, err)
 
@@ -358,3 +361,54 @@ func TestSecretsService_ReEncryptDataKeys(t *testing.T) {
 		assert.Empty(t, svc.dataKeyCache)
 	})
 }
+
+func TestSecretsService_References(t *testing.T) {
+	t.Setenv("GRAFANA_SECRET_PASSWORD", "resolved")
+	raw, err := ini.Load([]byte(`
+		[security]
+		secret_key = SdlklWklckeLS
+
+		[security.secret_references]
+		enabled = true
+		allowed_env_prefixes = GRAFANA_SECRET_`))
+	require.NoError(t, err)
+
+	features := featuremgmt.WithFeatures(featuremgmt.FlagEnvelopeEncryption)
+	settings := &setting.OSSImpl{Cfg: &setting.Cfg{Raw: raw, IsFeatureToggleEnabled: features.IsEnabled}}
+	encr := ossencryption.ProvideService()
+	svc, err := ProvideSecretsService(
+		database.ProvideSecretsStore(sqlstore.InitTestDB(t)),
+		osskmsproviders.ProvideService(encr, settings, features),
+		encr,
+		settings,
+		features,
+		&usagestats.UsageStatsMock{T: t},
+		references.ProvideResolver(settings),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	sjd, err := svc.EncryptJsonData(ctx, map[string]string{
+		"password": "${env:GRAFANA_SECRET_PASSWORD}",
+		"token":    "${env:GF_SECURITY_ADMIN_PASSWORD}",
+	}, secrets.WithoutScope())
+	require.NoError(t, err)
+
+	t.Run("references are stored as they are", func(t *testing.T) {
+		decrypted, err := svc.Decrypt(ctx, sjd["password"])
+		require.NoError(t, err)
+		assert.Equal(t, "${env:GRAFANA_SECRET_PASSWORD}", string(decrypted))
+	})
+
+	t.Run("references are resolved when getting decrypted values", func(t *testing.T) {
+		assert.Equal(t, "resolved", svc.GetDecryptedValue(ctx, sjd, "password", "fallback"))
+		assert.Equal(t, "fallback", svc.GetDecryptedValue(ctx, sjd, "token", "fallback"))
+
+		_, err := svc.DecryptJsonData(ctx, sjd)
+		require.ErrorIs(t, err, references.ErrNotAllowed)
+
+		decrypted, err := svc.DecryptJsonData(ctx, map[string][]byte{"password": sjd["password"]})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"password": "resolved"}, decrypted)
+	})
+}
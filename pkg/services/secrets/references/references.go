@@ -0,0 +1,219 @@
+// Package references resolves secure fields referencing secrets stored
+// outside of Grafana, like ${file:/run/secrets/password}, ${env:PASSWORD} or
+// ${vault:secret/data/grafana#password}. References are stored encrypted like
+// any other secure field, and resolved each time the secure field is used.
+package references
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	KindFile  = "file"
+	KindEnv   = "env"
+	KindVault = "vault"
+)
+
+var (
+	ErrNotAllowed = errors.New("secret reference not allowed")
+	ErrNotFound   = errors.New("referenced secret not found")
+)
+
+var referenceRegex = regexp.MustCompile(`^\$\{(file|env|vault):([^}]+)\}$`)
+
+// IsReference returns true if the value of a secure field is a reference to
+// a secret stored outside of Grafana.
+func IsReference(value string) bool {
+	return referenceRegex.MatchString(strings.TrimSpace(value))
+}
+
+// Resolver resolves references to secrets stored in files, environment
+// variables and HashiCorp Vault KV secrets engines. Resolving references is
+// disabled by default, and only the references allowed in the
+// [security.secret_references] section can be resolved, so that users
+// editing data sources or notifiers cannot read other secrets of the server.
+type Resolver struct {
+	enabled            bool
+	allowedFilePaths   []string
+	allowedEnvPrefixes []string
+	allowedVaultPaths  []string
+	cacheTTL           time.Duration
+	vault              *vaultClient
+	log                log.Logger
+
+	mu    sync.Mutex
+	cache map[string]cacheItem
+}
+
+type cacheItem struct {
+	value   string
+	expires time.Time
+}
+
+// now is used to test the expiration of resolved references.
+var now = time.Now
+
+func ProvideResolver(settings setting.Provider) *Resolver {
+	section := settings.Section("security.secret_references")
+	r := &Resolver{
+		enabled:            section.KeyValue("enabled").MustBool(false),
+		allowedFilePaths:   strings.Fields(section.KeyValue("allowed_file_paths").Value()),
+		allowedEnvPrefixes: strings.Fields(section.KeyValue("allowed_env_prefixes").Value()),
+		allowedVaultPaths:  strings.Fields(section.KeyValue("allowed_vault_paths").Value()),
+		cacheTTL:           section.KeyValue("cache_ttl").MustDuration(5 * time.Minute),
+		log:                log.New("secrets.references"),
+		cache:              map[string]cacheItem{},
+	}
+
+	if url := section.KeyValue("vault_url").Value(); url != "" {
+		r.vault = newVaultClient(url, section.KeyValue("vault_token").Value(), section.KeyValue("vault_namespace").Value())
+	}
+
+	return r
+}
+
+// Resolve returns the referenced secret if the value is a reference, and the
+// value itself otherwise, or if resolving references is disabled.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	if !r.enabled {
+		return value, nil
+	}
+
+	match := referenceRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return value, nil
+	}
+	kind, ref := match[1], strings.TrimSpace(match[2])
+
+	key := kind + ":" + ref
+	r.mu.Lock()
+	item, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && item.expires.After(now()) {
+		return item.value, nil
+	}
+
+	resolved, err := r.resolve(ctx, kind, ref)
+	if err != nil {
+		r.log.Warn("Failed to resolve secret reference", "kind", kind, "reference", ref, "error", err)
+		return "", fmt.Errorf("failed to resolve %s secret reference %s: %w", kind, ref, err)
+	}
+
+	r.mu.Lock()
+	r.cache[key] = cacheItem{value: resolved, expires: now().Add(r.cacheTTL)}
+	r.mu.Unlock()
+
+	return resolved, nil
+}
+
+// ResolveJsonData resolves the references among the values of decrypted
+// secure JSON data, in place.
+func (r *Resolver) ResolveJsonData(ctx context.Context, kv map[string]string) (map[string]string, error) {
+	for k, v := range kv {
+		resolved, err := r.Resolve(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		kv[k] = resolved
+	}
+	return kv, nil
+}
+
+func (r *Resolver) resolve(ctx context.Context, kind string, ref string) (string, error) {
+	switch kind {
+	case KindFile:
+		return r.resolveFile(ref)
+	case KindEnv:
+		return r.resolveEnv(ref)
+	case KindVault:
+		return r.resolveVault(ctx, ref)
+	default:
+		return "", ErrNotAllowed
+	}
+}
+
+func (r *Resolver) resolveFile(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", ErrNotAllowed
+	}
+	path = filepath.Clean(path)
+	if !hasAllowedPrefix(path, r.allowedFilePaths, func(allowed string) string {
+		return strings.TrimSuffix(filepath.Clean(allowed), string(filepath.Separator)) + string(filepath.Separator)
+	}) {
+		return "", ErrNotAllowed
+	}
+
+	// nolint:gosec
+	// The path is restricted to the allowed_file_paths from the configuration.
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func (r *Resolver) resolveEnv(name string) (string, error) {
+	if !hasAllowedPrefix(name, r.allowedEnvPrefixes, nil) {
+		return "", ErrNotAllowed
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// resolveVault resolves references in the <path>#<field> format, where path
+// is the API path of a KV secret, for example secret/data/grafana with the
+// version 2 of the KV secrets engine.
+func (r *Resolver) resolveVault(ctx context.Context, ref string) (string, error) {
+	sep := strings.LastIndex(ref, "#")
+	if sep <= 0 || sep == len(ref)-1 {
+		return "", fmt.Errorf("expected <path>#<field>")
+	}
+	path, field := strings.Trim(ref[:sep], "/"), ref[sep+1:]
+
+	if r.vault == nil {
+		return "", errors.New("vault_url is not configured")
+	}
+	if strings.Contains(path, "..") || !hasAllowedPrefix(path, r.allowedVaultPaths, func(allowed string) string {
+		return strings.Trim(allowed, "/") + "/"
+	}) {
+		return "", ErrNotAllowed
+	}
+
+	return r.vault.readField(ctx, path, field)
+}
+
+// hasAllowedPrefix returns true if the value starts with one of the allowed
+// prefixes, or is one of them. Prefixes are normalized before the comparison
+// if a normalize function is given.
+func hasAllowedPrefix(value string, allowed []string, normalize func(string) string) bool {
+	for _, prefix := range allowed {
+		if normalize != nil {
+			prefix = normalize(prefix)
+			if value == strings.TrimSuffix(prefix, "/") {
+				return true
+			}
+		}
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
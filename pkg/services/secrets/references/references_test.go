@@ -0,0 +1,179 @@
+package references
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func setupResolver(t *testing.T, cfg string) *Resolver {
+	t.Helper()
+	raw, err := ini.Load([]byte(cfg))
+	require.NoError(t, err)
+	return ProvideResolver(&setting.OSSImpl{Cfg: &setting.Cfg{Raw: raw}})
+}
+
+func TestIsReference(t *testing.T) {
+	require.True(t, IsReference("${file:/run/secrets/password}"))
+	require.True(t, IsReference(" ${env:PASSWORD} "))
+	require.True(t, IsReference("${vault:secret/data/grafana#password}"))
+	require.False(t, IsReference("password"))
+	require.False(t, IsReference("prefix ${env:PASSWORD}"))
+	require.False(t, IsReference("${unknown:PASSWORD}"))
+}
+
+func TestResolver_Disabled(t *testing.T) {
+	t.Setenv("GRAFANA_SECRET_PASSWORD", "secret")
+	r := setupResolver(t, `
+	[security.secret_references]
+	allowed_env_prefixes = GRAFANA_SECRET_`)
+
+	value, err := r.Resolve(context.Background(), "${env:GRAFANA_SECRET_PASSWORD}")
+	require.NoError(t, err)
+	require.Equal(t, "${env:GRAFANA_SECRET_PASSWORD}", value)
+}
+
+func TestResolver_File(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "password"), []byte("secret\n"), 0600))
+	r := setupResolver(t, `
+	[security.secret_references]
+	enabled = true
+	allowed_file_paths = `+dir)
+	ctx := context.Background()
+
+	value, err := r.Resolve(ctx, "${file:"+filepath.Join(dir, "password")+"}")
+	require.NoError(t, err)
+	require.Equal(t, "secret", value)
+
+	_, err = r.Resolve(ctx, "${file:"+filepath.Join(dir, "missing")+"}")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = r.Resolve(ctx, "${file:"+filepath.Join(dir, "..", "password")+"}")
+	require.ErrorIs(t, err, ErrNotAllowed)
+
+	_, err = r.Resolve(ctx, "${file:"+dir+"-other/password}")
+	require.ErrorIs(t, err, ErrNotAllowed)
+
+	_, err = r.Resolve(ctx, "${file:password}")
+	require.ErrorIs(t, err, ErrNotAllowed)
+}
+
+func TestResolver_Env(t *testing.T) {
+	t.Setenv("GRAFANA_SECRET_PASSWORD", "secret")
+	t.Setenv("GF_SECURITY_ADMIN_PASSWORD", "admin")
+	r := setupResolver(t, `
+	[security.secret_references]
+	enabled = true
+	allowed_env_prefixes = GRAFANA_SECRET_`)
+	ctx := context.Background()
+
+	value, err := r.Resolve(ctx, "${env:GRAFANA_SECRET_PASSWORD}")
+	require.NoError(t, err)
+	require.Equal(t, "secret", value)
+
+	_, err = r.Resolve(ctx, "${env:GRAFANA_SECRET_MISSING}")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = r.Resolve(ctx, "${env:GF_SECURITY_ADMIN_PASSWORD}")
+	require.ErrorIs(t, err, ErrNotAllowed)
+
+	value, err = r.Resolve(ctx, "not a reference")
+	require.NoError(t, err)
+	require.Equal(t, "not a reference", value)
+}
+
+func TestResolver_Vault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		var data map[string]interface{}
+		switch r.URL.Path {
+		case "/v1/secret/data/grafana/db":
+			data = map[string]interface{}{
+				"data":     map[string]interface{}{"password": "kv2-secret"},
+				"metadata": map[string]interface{}{"version": 1},
+			}
+		case "/v1/kv/grafana/db":
+			data = map[string]interface{}{"password": "kv1-secret"}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	t.Cleanup(server.Close)
+
+	r := setupResolver(t, `
+	[security.secret_references]
+	enabled = true
+	vault_url = `+server.URL+`
+	vault_token = test-token
+	allowed_vault_paths = secret/data/grafana kv/grafana`)
+	ctx := context.Background()
+
+	value, err := r.Resolve(ctx, "${vault:secret/data/grafana/db#password}")
+	require.NoError(t, err)
+	require.Equal(t, "kv2-secret", value)
+
+	value, err = r.Resolve(ctx, "${vault:kv/grafana/db#password}")
+	require.NoError(t, err)
+	require.Equal(t, "kv1-secret", value)
+
+	_, err = r.Resolve(ctx, "${vault:secret/data/grafana/db#user}")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = r.Resolve(ctx, "${vault:secret/data/grafana/missing#password}")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = r.Resolve(ctx, "${vault:secret/data/other#password}")
+	require.ErrorIs(t, err, ErrNotAllowed)
+
+	_, err = r.Resolve(ctx, "${vault:secret/data/grafana/../other#password}")
+	require.ErrorIs(t, err, ErrNotAllowed)
+
+	_, err = r.Resolve(ctx, "${vault:secret/data/grafana/db}")
+	require.Error(t, err)
+}
+
+func TestResolver_Cache(t *testing.T) {
+	t.Cleanup(func() { now = time.Now })
+	current := time.Now()
+	now = func() time.Time { return current }
+
+	t.Setenv("GRAFANA_SECRET_PASSWORD", "old")
+	r := setupResolver(t, `
+	[security.secret_references]
+	enabled = true
+	allowed_env_prefixes = GRAFANA_SECRET_
+	cache_ttl = 1m`)
+	ctx := context.Background()
+
+	kv, err := r.ResolveJsonData(ctx, map[string]string{"password": "${env:GRAFANA_SECRET_PASSWORD}", "user": "admin"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"password": "old", "user": "admin"}, kv)
+
+	t.Setenv("GRAFANA_SECRET_PASSWORD", "new")
+	value, err := r.Resolve(ctx, "${env:GRAFANA_SECRET_PASSWORD}")
+	require.NoError(t, err)
+	require.Equal(t, "old", value)
+
+	current = current.Add(2 * time.Minute)
+	value, err = r.Resolve(ctx, "${env:GRAFANA_SECRET_PASSWORD}")
+	require.NoError(t, err)
+	require.Equal(t, "new", value)
+}
@@ -0,0 +1,81 @@
+package references
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// vaultClient reads secrets of the KV secrets engines of HashiCorp Vault,
+// both version 1 and version 2.
+type vaultClient struct {
+	url       string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+func newVaultClient(url, token, namespace string) *vaultClient {
+	return &vaultClient{
+		url:       strings.TrimSuffix(url, "/"),
+		token:     token,
+		namespace: namespace,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *vaultClient) readField(ctx context.Context, path string, field string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+
+	var body struct {
+		Errors []string               `json:"errors"`
+		Data   map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("failed to decode vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault request failed with status %d: %s", resp.StatusCode, strings.Join(body.Errors, ", "))
+	}
+
+	data := body.Data
+	// Secrets of the KV version 2 engine are nested in a data field, next
+	// to their metadata.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	value, ok := data[field]
+	if !ok {
+		return "", ErrNotFound
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("field %s of vault secret is not a string", field)
+	}
+	return s, nil
+}
@@ -0,0 +1,53 @@
+package rotation
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	secretsReEncryptedTotal *prometheus.CounterVec
+	secretsFailedTotal      *prometheus.CounterVec
+	secretsPending          prometheus.Gauge
+	dataKeys                *prometheus.GaugeVec
+	dataKeysDeletedTotal    prometheus.Counter
+	lastRunTimestamp        prometheus.Gauge
+)
+
+func init() {
+	secretsReEncryptedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "encryption_secrets_reencrypted_total",
+		Help:      "Number of secrets re-encrypted with a new data key after data keys rotation",
+		Namespace: "grafana",
+	}, []string{"kind"})
+
+	secretsFailedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "encryption_secrets_reencryption_failed_total",
+		Help:      "Number of secrets Grafana failed to re-encrypt after data keys rotation",
+		Namespace: "grafana",
+	}, []string{"kind"})
+
+	secretsPending = promauto.NewGauge(prometheus.GaugeOpts{
+		Name:      "encryption_secrets_reencryption_pending",
+		Help:      "Number of secrets still encrypted with a retired data key after the last re-encryption run",
+		Namespace: "grafana",
+	})
+
+	dataKeys = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "encryption_data_keys",
+		Help:      "Number of data keys by state, active or retired",
+		Namespace: "grafana",
+	}, []string{"state"})
+
+	dataKeysDeletedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name:      "encryption_data_keys_deleted_total",
+		Help:      "Number of retired data keys deleted once no secret referenced them",
+		Namespace: "grafana",
+	})
+
+	lastRunTimestamp = promauto.NewGauge(prometheus.GaugeOpts{
+		Name:      "encryption_data_keys_rotation_last_run_timestamp_seconds",
+		Help:      "Timestamp of the last completed data keys rotation run",
+		Namespace: "grafana",
+	})
+}
@@ -0,0 +1,345 @@
+package rotation
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	defaultBatchSize = 100
+	// maxCheckInterval is the maximum time between two runs, so secrets which
+	// could not be re-encrypted are retried even with long rotation intervals.
+	maxCheckInterval = time.Hour
+	// deletionGracePeriod is the minimum time between retiring a data key and
+	// deleting it, so secrets read before its retirement can still be
+	// decrypted, or written back, by other Grafana instances.
+	deletionGracePeriod = time.Hour
+)
+
+// now is used to test the rotation of data keys.
+var now = time.Now
+
+// Service periodically rotates the data keys used for envelope encryption.
+// Data keys created before the current rotation period are retired, secrets
+// encrypted with retired data keys are re-encrypted with the current ones
+// and retired data keys are deleted once no secret references them anymore.
+type Service struct {
+	features       featuremgmt.FeatureToggles
+	sqlStore       *sqlstore.SQLStore
+	secretsService secrets.Service
+	store          secrets.Store
+	serverLock     *serverlock.ServerLockService
+	interval       time.Duration
+	batchSize      int
+	log            log.Logger
+	mu             sync.RWMutex
+	status         Status
+	current        *run
+}
+
+// Status is the progress of the data keys rotation on this Grafana instance.
+type Status struct {
+	Enabled           bool      `json:"enabled"`
+	RotationInterval  string    `json:"rotationInterval"`
+	Running           bool      `json:"running"`
+	LastRunStarted    time.Time `json:"lastRunStarted"`
+	LastRunFinished   time.Time `json:"lastRunFinished"`
+	LastRunError      string    `json:"lastRunError,omitempty"`
+	ActiveDataKeys    int       `json:"activeDataKeys"`
+	RetiredDataKeys   int       `json:"retiredDataKeys"`
+	ReEncrypted       int       `json:"reEncrypted"`
+	Failed            int       `json:"failed"`
+	Pending           int       `json:"pending"`
+	DeletedDataKeys   int       `json:"deletedDataKeys"`
+	DeletedDataKeysAt time.Time `json:"deletedDataKeysAt"`
+}
+
+func ProvideService(
+	settings setting.Provider,
+	features featuremgmt.FeatureToggles,
+	sqlStore *sqlstore.SQLStore,
+	secretsService secrets.Service,
+	store secrets.Store,
+	serverLock *serverlock.ServerLockService,
+	routeRegister routing.RouteRegister,
+) *Service {
+	batchSize, err := strconv.Atoi(settings.KeyValue("security.encryption", "data_keys_reencryption_batch_size").MustString(strconv.Itoa(defaultBatchSize)))
+	if err != nil || batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	s := &Service{
+		features:       features,
+		sqlStore:       sqlStore,
+		secretsService: secretsService,
+		store:          store,
+		serverLock:     serverLock,
+		interval:       manager.RotationInterval(settings),
+		batchSize:      batchSize,
+		log:            log.New("secrets.rotation"),
+	}
+	s.status.Enabled = !s.IsDisabled()
+	if s.interval > 0 {
+		s.status.RotationInterval = s.interval.String()
+	}
+
+	routeRegister.Get("/api/admin/encryption/rotation", middleware.ReqGrafanaAdmin, routing.Wrap(s.getStatus))
+
+	return s
+}
+
+// IsDisabled returns true if data keys rotation is not configured or if
+// envelope encryption is not enabled.
+func (s *Service) IsDisabled() bool {
+	return s.interval <= 0 || !s.features.IsEnabled(featuremgmt.FlagEnvelopeEncryption)
+}
+
+func (s *Service) Run(ctx context.Context) error {
+	checkInterval := s.interval
+	if checkInterval > maxCheckInterval {
+		checkInterval = maxCheckInterval
+	}
+
+	s.runWithLock(ctx, checkInterval)
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.runWithLock(ctx, checkInterval)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// runWithLock rotates data keys if no other Grafana instance did it during
+// the last check interval.
+func (s *Service) runWithLock(ctx context.Context, checkInterval time.Duration) {
+	err := s.serverLock.LockAndExecute(ctx, "rotate data keys", checkInterval, func(ctx context.Context) {
+		if err := s.Rotate(ctx); err != nil {
+			s.log.Error("Failed to rotate data keys", "error", err)
+		}
+	})
+	if err != nil {
+		s.log.Error("Failed to lock and execute data keys rotation", "error", err)
+	}
+}
+
+// Status returns the progress of the current run, or the result of the last
+// one if no run is in progress.
+func (s *Service) Status() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := s.status
+	if s.current != nil {
+		s.current.mu.Lock()
+		status.ReEncrypted = s.current.reencryptedCount
+		status.Failed = s.current.failedCount
+		s.current.mu.Unlock()
+	}
+	return status
+}
+
+func (s *Service) getStatus(_ *models.ReqContext) response.Response {
+	return response.JSON(200, s.Status())
+}
+
+// Rotate retires the data keys created before the current rotation period,
+// re-encrypts the secrets encrypted with retired data keys and deletes the
+// retired data keys no secret references anymore.
+func (s *Service) Rotate(ctx context.Context) error {
+	started := now()
+	r := &run{
+		sqlStore:       s.sqlStore,
+		secretsService: s.secretsService,
+		batchSize:      s.batchSize,
+		log:            s.log,
+		retired:        map[string]*secrets.DataKey{},
+		references:     map[string]bool{},
+	}
+
+	s.mu.Lock()
+	s.status.Running = true
+	s.status.LastRunStarted = started
+	s.current = r
+	s.mu.Unlock()
+
+	err := s.rotate(ctx, r, started)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = nil
+	s.status.Running = false
+	s.status.LastRunFinished = now()
+	s.status.LastRunError = ""
+	if err != nil {
+		s.status.LastRunError = err.Error()
+	}
+	s.status.ReEncrypted = r.reencryptedCount
+	s.status.Failed = r.failedCount
+	s.status.Pending = r.pendingCount
+	secretsPending.Set(float64(r.pendingCount))
+	lastRunTimestamp.Set(float64(s.status.LastRunFinished.Unix()))
+
+	return err
+}
+
+func (s *Service) rotate(ctx context.Context, r *run, started time.Time) error {
+	retired, err := s.store.RetireDataKeys(ctx, started.Truncate(s.interval))
+	if err != nil {
+		return err
+	}
+	if retired > 0 {
+		s.log.Info("Retired data keys", "count", retired)
+	}
+
+	keys, err := s.store.GetAllDataKeys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if !key.Active {
+			r.retired[key.Name] = key
+		}
+	}
+	s.updateDataKeysStatus(len(keys)-len(r.retired), len(r.retired))
+
+	if len(r.retired) == 0 {
+		return nil
+	}
+
+	for _, c := range secretColumns {
+		if err := r.reencryptColumn(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	// Secrets read by other Grafana instances before the retirement of their
+	// data key may still be written back, so data keys are only deleted after
+	// a grace period.
+	deleted := 0
+	for name, key := range r.retired {
+		if r.references[name] || key.Updated.After(started.Add(-deletionGracePeriod)) {
+			continue
+		}
+		if err := s.store.DeleteDataKey(ctx, name); err != nil {
+			return err
+		}
+		deleted++
+		dataKeysDeletedTotal.Inc()
+	}
+
+	if deleted > 0 {
+		s.log.Info("Deleted retired data keys", "count", deleted)
+		s.updateDataKeysStatus(len(keys)-len(r.retired), len(r.retired)-deleted)
+		s.mu.Lock()
+		s.status.DeletedDataKeys += deleted
+		s.status.DeletedDataKeysAt = now()
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+func (s *Service) updateDataKeysStatus(active, retired int) {
+	dataKeys.WithLabelValues("active").Set(float64(active))
+	dataKeys.WithLabelValues("retired").Set(float64(retired))
+
+	s.mu.Lock()
+	s.status.ActiveDataKeys = active
+	s.status.RetiredDataKeys = retired
+	s.mu.Unlock()
+}
+
+// run holds the state of a data keys rotation run.
+type run struct {
+	sqlStore       *sqlstore.SQLStore
+	secretsService secrets.Service
+	batchSize      int
+	log            log.Logger
+
+	// retired are the retired data keys by name, and references the names of
+	// those still referenced by secrets which could not be re-encrypted.
+	retired    map[string]*secrets.DataKey
+	references map[string]bool
+
+	mu               sync.Mutex
+	reencryptedCount int
+	failedCount      int
+	pendingCount     int
+}
+
+// reencrypt encrypts a secret encrypted with a retired data key with the
+// current data key of the same scope. It returns the name of the retired data
+// key, or an empty name if the secret is not encrypted with a retired one.
+func (r *run) reencrypt(ctx context.Context, secret []byte) ([]byte, string, error) {
+	keyName, ok := dataKeyName(secret)
+	if !ok {
+		return secret, "", nil
+	}
+	key, ok := r.retired[keyName]
+	if !ok {
+		return secret, "", nil
+	}
+
+	decrypted, err := r.secretsService.Decrypt(ctx, secret)
+	if err != nil {
+		return secret, keyName, err
+	}
+	encrypted, err := r.secretsService.Encrypt(ctx, decrypted, secrets.WithScope(key.Scope))
+	if err != nil {
+		return secret, keyName, err
+	}
+	// The current data key may have been retired if the clocks of Grafana
+	// instances are not in sync.
+	if newKeyName, _ := dataKeyName(encrypted); r.retired[newKeyName] != nil {
+		return secret, keyName, fmt.Errorf("current data key %s is retired", newKeyName)
+	}
+	return encrypted, keyName, nil
+}
+
+// referenced marks retired data keys as still referenced by a secret.
+func (r *run) referenced(keyNames ...string) {
+	if len(keyNames) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range keyNames {
+		r.references[name] = true
+	}
+	r.pendingCount++
+}
+
+func (r *run) reencrypted(kind string) {
+	secretsReEncryptedTotal.WithLabelValues(kind).Inc()
+	r.mu.Lock()
+	r.reencryptedCount++
+	r.mu.Unlock()
+}
+
+func (r *run) failed(kind string, err error, ctx ...interface{}) {
+	secretsFailedTotal.WithLabelValues(kind).Inc()
+	r.log.Warn("Failed to re-encrypt secret", append(ctx, "error", err)...)
+	r.mu.Lock()
+	r.failedCount++
+	r.mu.Unlock()
+}
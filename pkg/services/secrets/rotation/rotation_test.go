@@ -0,0 +1,247 @@
+package rotation
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit/audittest"
+	"github.com/grafana/grafana/pkg/services/encryption/ossencryption"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/kmsproviders"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/database"
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/totp"
+	totpmanager "github.com/grafana/grafana/pkg/services/totp/manager"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const oldKeyName = "2020-01-01/root@" + kmsproviders.Default
+
+// setupOldDataKey creates an active data key created long ago, and returns a
+// function encrypting secrets with it.
+func setupOldDataKey(t *testing.T, sqlStore *sqlstore.SQLStore, secretsService *manager.SecretsService) func(string) []byte {
+	t.Helper()
+	ctx := context.Background()
+
+	dataKey := []byte("old data key old data key old da")
+	encryptedDataKey, err := secretsService.GetProviders()[kmsproviders.Default].Encrypt(ctx, dataKey)
+	require.NoError(t, err)
+
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	err = sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Table("data_keys").Insert(&secrets.DataKey{
+			Active:        true,
+			Name:          oldKeyName,
+			Scope:         "root",
+			Provider:      kmsproviders.Default,
+			EncryptedData: encryptedDataKey,
+			Created:       created,
+			Updated:       created,
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	enc := ossencryption.ProvideService()
+	return func(secret string) []byte {
+		encrypted, err := enc.Encrypt(ctx, []byte(secret), string(dataKey))
+		require.NoError(t, err)
+		prefix := "#" + base64.RawStdEncoding.EncodeToString([]byte(oldKeyName)) + "#"
+		return append([]byte(prefix), encrypted...)
+	}
+}
+
+func TestService_Rotate(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	store := database.ProvideSecretsStore(sqlStore)
+	secretsService := manager.SetupTestService(t, store)
+	encryptWithOldKey := setupOldDataKey(t, sqlStore, secretsService)
+
+	raw, err := ini.Load([]byte(`
+		[security.encryption]
+		data_keys_rotation_interval = 1d
+		data_keys_reencryption_batch_size = 1`))
+	require.NoError(t, err)
+	features := featuremgmt.WithFeatures(featuremgmt.FlagEnvelopeEncryption)
+	svc := ProvideService(&setting.OSSImpl{Cfg: &setting.Cfg{Raw: raw}}, features, sqlStore,
+		secretsService, store, nil, routing.NewRouteRegister())
+	require.False(t, svc.IsDisabled())
+	require.Equal(t, 24*time.Hour, svc.interval)
+
+	// The tables of disabled features, like live_write_config without the
+	// livePipeline feature toggle, don't exist.
+	columns := secretColumns
+	secretColumns = append(secretColumns[:len(secretColumns):len(secretColumns)],
+		secretColumn{kind: "missing", table: "missing_table", column: "secret", reencrypt: reencryptBase64})
+	t.Cleanup(func() { secretColumns = columns })
+
+	addDataSource := func(name string, secureJSONData map[string][]byte) *models.DataSource {
+		cmd := &models.AddDataSourceCommand{
+			OrgId:                   1,
+			Name:                    name,
+			Type:                    "prometheus",
+			Access:                  models.DS_ACCESS_PROXY,
+			EncryptedSecureJsonData: secureJSONData,
+		}
+		require.NoError(t, sqlStore.AddDataSource(ctx, cmd))
+		return cmd.Result
+	}
+	getDataSource := func(id int64) *models.DataSource {
+		query := &models.GetDataSourceQuery{Id: id, OrgId: 1}
+		require.NoError(t, sqlStore.GetDataSource(ctx, query))
+		return query.Result
+	}
+
+	ds := addDataSource("ds", map[string][]byte{"password": encryptWithOldKey("pwd")})
+	broken := addDataSource("broken", map[string][]byte{"password": []byte("#" + base64.RawStdEncoding.EncodeToString([]byte(oldKeyName)) + "#invalid")})
+	snapshot := &models.CreateDashboardSnapshotCommand{Key: "key", DeleteKey: "delete-key", OrgId: 1, DashboardEncrypted: encryptWithOldKey("dashboard")}
+	require.NoError(t, sqlStore.CreateDashboardSnapshot(ctx, snapshot))
+	userAuth := &models.UserAuth{UserId: 1, AuthModule: "oauth_generic_oauth", AuthId: "id", Created: time.Now(),
+		OAuthIdToken: base64.StdEncoding.EncodeToString(encryptWithOldKey("id token"))}
+	_, err = sqlStore.NewSession(ctx).Insert(userAuth)
+	require.NoError(t, err)
+
+	t.Run("re-encrypts secrets of retired data keys", func(t *testing.T) {
+		require.NoError(t, svc.Rotate(ctx))
+
+		oldKey, err := store.GetDataKey(ctx, oldKeyName)
+		require.NoError(t, err)
+		require.False(t, oldKey.Active)
+
+		password := getDataSource(ds.Id).SecureJsonData["password"]
+		keyName, ok := dataKeyName(password)
+		require.True(t, ok)
+		require.NotEqual(t, oldKeyName, keyName)
+		decrypted, err := secretsService.Decrypt(ctx, password)
+		require.NoError(t, err)
+		require.Equal(t, "pwd", string(decrypted))
+
+		query := &models.GetDashboardSnapshotQuery{Key: "key"}
+		require.NoError(t, sqlStore.GetDashboardSnapshot(query))
+		decrypted, err = secretsService.Decrypt(ctx, query.Result.DashboardEncrypted)
+		require.NoError(t, err)
+		require.Equal(t, "dashboard", string(decrypted))
+
+		var stored models.UserAuth
+		_, err = sqlStore.NewSession(ctx).ID(userAuth.Id).Get(&stored)
+		require.NoError(t, err)
+		idToken, err := base64.StdEncoding.DecodeString(stored.OAuthIdToken)
+		require.NoError(t, err)
+		decrypted, err = secretsService.Decrypt(ctx, idToken)
+		require.NoError(t, err)
+		require.Equal(t, "id token", string(decrypted))
+
+		status := svc.Status()
+		require.False(t, status.Running)
+		require.Empty(t, status.LastRunError)
+		require.Equal(t, 3, status.ReEncrypted)
+		require.Equal(t, 1, status.Failed)
+		require.Equal(t, 1, status.Pending)
+		require.Equal(t, 1, status.RetiredDataKeys)
+	})
+
+	// Skip the deletion grace period.
+	_, err = sqlStore.NewSession(ctx).Exec("UPDATE data_keys SET updated = ? WHERE name = ?", time.Now().Add(-2*deletionGracePeriod), oldKeyName)
+	require.NoError(t, err)
+
+	t.Run("keeps retired data keys still referenced", func(t *testing.T) {
+		require.NoError(t, svc.Rotate(ctx))
+
+		_, err := store.GetDataKey(ctx, oldKeyName)
+		require.NoError(t, err)
+		require.Equal(t, 0, svc.Status().ReEncrypted)
+		require.Equal(t, 1, svc.Status().Pending)
+	})
+
+	t.Run("deletes retired data keys no longer referenced", func(t *testing.T) {
+		require.NoError(t, sqlStore.DeleteDataSource(ctx, &models.DeleteDataSourceCommand{ID: broken.Id, OrgID: 1}))
+		require.NoError(t, svc.Rotate(ctx))
+
+		_, err := store.GetDataKey(ctx, oldKeyName)
+		require.ErrorIs(t, err, secrets.ErrDataKeyNotFound)
+
+		status := svc.Status()
+		require.Equal(t, 0, status.Pending)
+		require.Equal(t, 0, status.RetiredDataKeys)
+		require.Equal(t, 1, status.DeletedDataKeys)
+	})
+}
+
+// oldKeySecretsService encrypts secrets with the old data key.
+type oldKeySecretsService struct {
+	secrets.Service
+	encrypt func(string) []byte
+}
+
+func (s oldKeySecretsService) Encrypt(_ context.Context, payload []byte, _ secrets.EncryptionOptions) ([]byte, error) {
+	return s.encrypt(string(payload)), nil
+}
+
+func TestService_Rotate_totp(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	store := database.ProvideSecretsStore(sqlStore)
+	secretsService := manager.SetupTestService(t, store)
+	encryptWithOldKey := setupOldDataKey(t, sqlStore, secretsService)
+
+	raw, err := ini.Load([]byte(`
+		[security.encryption]
+		data_keys_rotation_interval = 1d`))
+	require.NoError(t, err)
+	svc := ProvideService(&setting.OSSImpl{Cfg: &setting.Cfg{Raw: raw}}, featuremgmt.WithFeatures(featuremgmt.FlagEnvelopeEncryption),
+		sqlStore, secretsService, store, nil, routing.NewRouteRegister())
+
+	cfg := setting.NewCfg()
+	cfg.TOTPEnabled = true
+	newTOTPService := func(secretsService secrets.Service) *totpmanager.TOTPService {
+		return totpmanager.ProvideService(cfg, sqlStore, secretsService, routing.NewRouteRegister(), audittest.NewFakeService())
+	}
+	user := &models.User{Id: 1, Login: "admin"}
+
+	enrolment, err := newTOTPService(oldKeySecretsService{Service: secretsService, encrypt: encryptWithOldKey}).Enrol(ctx, user)
+	require.NoError(t, err)
+	totpService := newTOTPService(secretsService)
+	code, err := totp.GenerateCode(enrolment.Secret, time.Now())
+	require.NoError(t, err)
+	_, err = totpService.Confirm(ctx, user.Id, code)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Rotate(ctx))
+	require.Equal(t, 1, svc.Status().ReEncrypted)
+	_, err = sqlStore.NewSession(ctx).Exec("UPDATE data_keys SET updated = ? WHERE name = ?", time.Now().Add(-2*deletionGracePeriod), oldKeyName)
+	require.NoError(t, err)
+	require.NoError(t, svc.Rotate(ctx))
+	_, err = store.GetDataKey(ctx, oldKeyName)
+	require.ErrorIs(t, err, secrets.ErrDataKeyNotFound)
+
+	code, err = totp.GenerateCode(enrolment.Secret, time.Now().Add(30*time.Second))
+	require.NoError(t, err)
+	require.NoError(t, totpService.Verify(ctx, user.Id, code))
+}
+
+func TestService_IsDisabled(t *testing.T) {
+	raw, err := ini.Load([]byte(``))
+	require.NoError(t, err)
+	svc := ProvideService(&setting.OSSImpl{Cfg: &setting.Cfg{Raw: raw}}, featuremgmt.WithFeatures(featuremgmt.FlagEnvelopeEncryption),
+		nil, nil, nil, nil, routing.NewRouteRegister())
+	require.True(t, svc.IsDisabled())
+}
+
+func TestDataKeyName(t *testing.T) {
+	name, ok := dataKeyName([]byte("#" + base64.RawStdEncoding.EncodeToString([]byte("2022-01-01/root@secretKey.v1")) + "#encrypted"))
+	require.True(t, ok)
+	require.Equal(t, "2022-01-01/root@secretKey.v1", name)
+
+	_, ok = dataKeyName([]byte("legacy encrypted"))
+	require.False(t, ok)
+}
@@ -0,0 +1,246 @@
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// secretColumn is a database column holding secrets encrypted by the secrets
+// service. Rows are read in batches ordered by id, and the value of a row is
+// only updated if it did not change in the meantime.
+type secretColumn struct {
+	kind   string
+	table  string
+	column string
+	// binary is set for blob columns, other columns are text.
+	binary bool
+	// reencrypt returns the given value with all its secrets encrypted with a
+	// retired data key encrypted again, and the names of those data keys.
+	// On error, the names of the retired data keys are returned as well, so
+	// they are not deleted while the value still references them.
+	reencrypt func(ctx context.Context, r *run, value []byte) ([]byte, []string, error)
+}
+
+var secretColumns = []secretColumn{
+	{kind: "dashboard_snapshot", table: "dashboard_snapshot", column: "dashboard_encrypted", binary: true, reencrypt: reencryptRaw},
+	{kind: "user_auth", table: "user_auth", column: "o_auth_access_token", reencrypt: reencryptBase64},
+	{kind: "user_auth", table: "user_auth", column: "o_auth_refresh_token", reencrypt: reencryptBase64},
+	{kind: "user_auth", table: "user_auth", column: "o_auth_token_type", reencrypt: reencryptBase64},
+	{kind: "user_auth", table: "user_auth", column: "o_auth_id_token", reencrypt: reencryptBase64},
+	{kind: "user_totp", table: "user_totp", column: "secret", reencrypt: reencryptBase64},
+	{kind: "data_source", table: "data_source", column: "secure_json_data", reencrypt: reencryptJSON},
+	{kind: "plugin_setting", table: "plugin_setting", column: "secure_json_data", reencrypt: reencryptJSON},
+	{kind: "alert_notification", table: "alert_notification", column: "secure_settings", reencrypt: reencryptJSON},
+	// Only created when the livePipeline feature toggle is enabled
+	{kind: "live_write_config", table: "live_write_config", column: "secure_settings", reencrypt: reencryptJSON},
+	{kind: "alert_configuration", table: "alert_configuration", column: "alertmanager_configuration", reencrypt: reencryptAlertmanagerConfig},
+}
+
+// dataKeyName returns the name of the data key a secret is encrypted with,
+// secrets encrypted with the legacy encryption have no data key.
+func dataKeyName(payload []byte) (string, bool) {
+	if len(payload) == 0 || payload[0] != '#' {
+		return "", false
+	}
+	end := bytes.IndexByte(payload[1:], '#')
+	if end < 0 {
+		return "", false
+	}
+	name, err := base64.RawStdEncoding.DecodeString(string(payload[1 : end+1]))
+	if err != nil {
+		return "", false
+	}
+	return string(name), true
+}
+
+// secretsReEncrypter collects the names of the retired data keys of the
+// secrets of a value, and the first error re-encrypting them.
+type secretsReEncrypter struct {
+	run      *run
+	keyNames []string
+	err      error
+}
+
+// reencrypt returns the secret encrypted with the current data key if it was
+// encrypted with a retired one, and the secret itself otherwise.
+func (e *secretsReEncrypter) reencrypt(ctx context.Context, secret []byte) ([]byte, bool) {
+	encrypted, keyName, err := e.run.reencrypt(ctx, secret)
+	if keyName != "" {
+		e.keyNames = append(e.keyNames, keyName)
+	}
+	if err != nil {
+		if e.err == nil {
+			e.err = err
+		}
+		return secret, false
+	}
+	return encrypted, keyName != ""
+}
+
+func reencryptRaw(ctx context.Context, r *run, value []byte) ([]byte, []string, error) {
+	e := &secretsReEncrypter{run: r}
+	encrypted, _ := e.reencrypt(ctx, value)
+	return encrypted, e.keyNames, e.err
+}
+
+func reencryptBase64(ctx context.Context, r *run, value []byte) ([]byte, []string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(value))
+	if err != nil {
+		return value, nil, err
+	}
+
+	e := &secretsReEncrypter{run: r}
+	encrypted, changed := e.reencrypt(ctx, decoded)
+	if !changed {
+		return value, e.keyNames, e.err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(encrypted)), e.keyNames, e.err
+}
+
+// reencryptJSON re-encrypts secure JSON data, a JSON object with an encrypted
+// secret for each field, used by data sources, plugin settings, legacy alert
+// notifiers and Live write configs.
+func reencryptJSON(ctx context.Context, r *run, value []byte) ([]byte, []string, error) {
+	var secureJSONData map[string][]byte
+	if err := json.Unmarshal(value, &secureJSONData); err != nil {
+		return value, nil, err
+	}
+
+	e := &secretsReEncrypter{run: r}
+	for k, v := range secureJSONData {
+		secureJSONData[k], _ = e.reencrypt(ctx, v)
+	}
+	if e.err != nil || len(e.keyNames) == 0 {
+		return value, e.keyNames, e.err
+	}
+
+	updated, err := json.Marshal(secureJSONData)
+	if err != nil {
+		return value, e.keyNames, err
+	}
+	return updated, e.keyNames, nil
+}
+
+// reencryptAlertmanagerConfig re-encrypts the secure settings of the Grafana
+// managed receivers of an Alertmanager configuration.
+func reencryptAlertmanagerConfig(ctx context.Context, r *run, value []byte) ([]byte, []string, error) {
+	cfg, err := notifier.Load(value)
+	if err != nil {
+		return value, nil, err
+	}
+
+	e := &secretsReEncrypter{run: r}
+	for _, receiver := range cfg.AlertmanagerConfig.Receivers {
+		for _, gmr := range receiver.GrafanaManagedReceivers {
+			for k, v := range gmr.SecureSettings {
+				decoded, err := base64.StdEncoding.DecodeString(v)
+				if err != nil {
+					e.err = err
+					continue
+				}
+				if encrypted, changed := e.reencrypt(ctx, decoded); changed {
+					gmr.SecureSettings[k] = base64.StdEncoding.EncodeToString(encrypted)
+				}
+			}
+		}
+	}
+	if e.err != nil || len(e.keyNames) == 0 {
+		return value, e.keyNames, e.err
+	}
+
+	updated, err := json.Marshal(cfg)
+	if err != nil {
+		return value, e.keyNames, err
+	}
+	return updated, e.keyNames, nil
+}
+
+// reencryptColumn re-encrypts the secrets of a column in batches, each
+// batch being read in its own database session. The tables of features which
+// are not enabled may not exist, they have no secrets to re-encrypt.
+func (r *run) reencryptColumn(ctx context.Context, c secretColumn) error {
+	var exists bool
+	err := r.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		exists, err = sess.IsTableExist(c.table)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !exists {
+		r.log.Debug("Skipping the secrets of a table which doesn't exist", "table", c.table, "column", c.column)
+		return nil
+	}
+
+	selectSQL := fmt.Sprintf("SELECT id, %s AS secret FROM %s WHERE id > ? AND %s IS NOT NULL ORDER BY id ASC",
+		c.column, c.table, c.column)
+	updateSQL := fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ? AND %s = ?", c.table, c.column, c.column)
+
+	var lastID int64
+	for {
+		var rows []struct {
+			Id     int64
+			Secret []byte
+		}
+		err := r.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			return sess.SQL(selectSQL+r.sqlStore.Dialect.LimitOffset(int64(r.batchSize), 0), lastID).Find(&rows)
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			lastID = row.Id
+			if len(row.Secret) == 0 {
+				continue
+			}
+
+			updated, keyNames, err := c.reencrypt(ctx, r, row.Secret)
+			if err != nil {
+				r.referenced(keyNames...)
+				r.failed(c.kind, err, "table", c.table, "column", c.column, "id", row.Id)
+				continue
+			}
+			if len(keyNames) == 0 {
+				continue
+			}
+
+			// The value is only updated if it was not changed since it was read,
+			// otherwise the secrets are re-encrypted in the next run.
+			var newValue, oldValue interface{} = updated, row.Secret
+			if !c.binary {
+				newValue, oldValue = string(updated), string(row.Secret)
+			}
+
+			var affected int64
+			err = r.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+				res, err := sess.Exec(updateSQL, newValue, row.Id, oldValue)
+				if err != nil {
+					return err
+				}
+				affected, err = res.RowsAffected()
+				return err
+			})
+			if err != nil || affected == 0 {
+				r.referenced(keyNames...)
+				if err != nil {
+					r.failed(c.kind, err, "table", c.table, "column", c.column, "id", row.Id)
+				}
+				continue
+			}
+
+			r.reencrypted(c.kind)
+		}
+
+		if len(rows) < r.batchSize {
+			return nil
+		}
+	}
+}
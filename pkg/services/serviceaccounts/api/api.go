@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/setting"
@@ -33,6 +34,7 @@ type ServiceAccountsAPI struct {
 	RouterRegister routing.RouteRegister
 	store          serviceaccounts.Store
 	apiKeyStore    APIKeyStore
+	auditService   audit.Service
 	log            log.Logger
 }
 
@@ -48,6 +50,7 @@ func NewServiceAccountsAPI(
 	routerRegister routing.RouteRegister,
 	store serviceaccounts.Store,
 	apiKeyStore APIKeyStore,
+	auditService audit.Service,
 ) *ServiceAccountsAPI {
 	return &ServiceAccountsAPI{
 		cfg:            cfg,
@@ -56,6 +59,7 @@ func NewServiceAccountsAPI(
 		RouterRegister: routerRegister,
 		store:          store,
 		apiKeyStore:    apiKeyStore,
+		auditService:   auditService,
 		log:            log.New("serviceaccounts.api"),
 	}
 }
@@ -81,6 +85,14 @@ func (api *ServiceAccountsAPI) RegisterAPIEndpoints(
 			accesscontrol.EvalPermission(serviceaccounts.ActionWrite, serviceaccounts.ScopeID)), routing.Wrap(api.CreateToken))
 		serviceAccountsRoute.Delete("/:serviceAccountId/tokens/:tokenId", auth(middleware.ReqOrgAdmin,
 			accesscontrol.EvalPermission(serviceaccounts.ActionWrite, serviceaccounts.ScopeID)), routing.Wrap(api.DeleteToken))
+		serviceAccountsRoute.Post("/:serviceAccountId/tokens/:tokenId/rotate", auth(middleware.ReqOrgAdmin,
+			accesscontrol.EvalPermission(serviceaccounts.ActionWrite, serviceaccounts.ScopeID)), routing.Wrap(api.RotateToken))
+		serviceAccountsRoute.Get("/:serviceAccountId/jwt-identities", auth(middleware.ReqOrgAdmin,
+			accesscontrol.EvalPermission(serviceaccounts.ActionRead, serviceaccounts.ScopeID)), routing.Wrap(api.ListJWTIdentities))
+		serviceAccountsRoute.Post("/:serviceAccountId/jwt-identities", auth(middleware.ReqOrgAdmin,
+			accesscontrol.EvalPermission(serviceaccounts.ActionWrite, serviceaccounts.ScopeID)), routing.Wrap(api.AddJWTIdentity))
+		serviceAccountsRoute.Delete("/:serviceAccountId/jwt-identities/:identityId", auth(middleware.ReqOrgAdmin,
+			accesscontrol.EvalPermission(serviceaccounts.ActionWrite, serviceaccounts.ScopeID)), routing.Wrap(api.DeleteJWTIdentity))
 	})
 }
 
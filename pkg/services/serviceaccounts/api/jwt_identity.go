@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GET /api/serviceaccounts/:serviceAccountId/jwt-identities
+func (api *ServiceAccountsAPI) ListJWTIdentities(c *models.ReqContext) response.Response {
+	saID, err := strconv.ParseInt(web.Params(c.Req)[":serviceAccountId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "serviceAccountId is invalid", err)
+	}
+
+	identities, err := api.store.ListJWTIdentities(c.Req.Context(), c.OrgId, saID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to list JWT identities", err)
+	}
+	return response.JSON(http.StatusOK, identities)
+}
+
+// AddJWTIdentity lets a machine client authenticate as the service account
+// with a JWT issued by the identity provider configured in [auth.jwt].
+//
+// POST /api/serviceaccounts/:serviceAccountId/jwt-identities
+func (api *ServiceAccountsAPI) AddJWTIdentity(c *models.ReqContext) response.Response {
+	saID, err := strconv.ParseInt(web.Params(c.Req)[":serviceAccountId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "serviceAccountId is invalid", err)
+	}
+
+	form := serviceaccounts.AddJWTIdentityForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	identity, err := api.store.AddJWTIdentity(c.Req.Context(), c.OrgId, saID, form.Subject)
+	switch {
+	case errors.Is(err, serviceaccounts.ErrServiceAccountNotFound):
+		return response.Error(http.StatusNotFound, "Failed to retrieve service account", err)
+	case errors.Is(err, serviceaccounts.ErrJWTIdentityAlreadyAssigned):
+		return response.Error(http.StatusConflict, "JWT identity is already assigned to a service account", err)
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "Failed to add JWT identity", err)
+	}
+
+	api.auditService.Record(c.Req.Context(), audit.NewEntry(c, audit.ActionCreate, audit.ResourceServiceAccountJWT, strconv.FormatInt(identity.Id, 10)).
+		WithDetails("subject", identity.Subject, "serviceAccountId", saID))
+
+	return response.JSON(http.StatusOK, identity)
+}
+
+// DELETE /api/serviceaccounts/:serviceAccountId/jwt-identities/:identityId
+func (api *ServiceAccountsAPI) DeleteJWTIdentity(c *models.ReqContext) response.Response {
+	saID, err := strconv.ParseInt(web.Params(c.Req)[":serviceAccountId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "serviceAccountId is invalid", err)
+	}
+
+	identityID, err := strconv.ParseInt(web.Params(c.Req)[":identityId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "identityId is invalid", err)
+	}
+
+	err = api.store.DeleteJWTIdentity(c.Req.Context(), c.OrgId, saID, identityID)
+	switch {
+	case errors.Is(err, serviceaccounts.ErrJWTIdentityNotFound):
+		return response.Error(http.StatusNotFound, "Failed to find JWT identity", err)
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "Failed to delete JWT identity", err)
+	}
+
+	api.auditService.Record(c.Req.Context(), audit.NewEntry(c, audit.ActionDelete, audit.ResourceServiceAccountJWT, strconv.FormatInt(identityID, 10)).
+		WithDetails("serviceAccountId", saID))
+
+	return response.Success("JWT identity deleted")
+}
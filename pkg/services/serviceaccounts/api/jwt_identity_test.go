@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/tests"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	serviceaccountIDJWTIdentitiesPath       = "/api/serviceaccounts/%v/jwt-identities"
+	serviceaccountIDJWTIdentitiesDetailPath = "/api/serviceaccounts/%v/jwt-identities/%v"
+)
+
+func TestServiceAccountsAPI_JWTIdentities(t *testing.T) {
+	store := sqlstore.InitTestDB(t)
+	svcmock := tests.ServiceAccountMock{}
+	sa := tests.SetupUserServiceAccount(t, store, tests.TestUser{Login: "sa", IsServiceAccount: true})
+
+	var requestResponse = func(server *web.Mux, httpMethod, requestpath string, requestBody io.Reader) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(httpMethod, requestpath, requestBody)
+		require.NoError(t, err)
+		req.Header.Add("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	withPermissions := func(permissions ...*accesscontrol.Permission) *web.Mux {
+		acmock := tests.SetupMockAccesscontrol(
+			t,
+			func(c context.Context, siu *models.SignedInUser) ([]*accesscontrol.Permission, error) {
+				return permissions, nil
+			},
+			false,
+		)
+		return setupTestServer(t, &svcmock, routing.NewRouteRegister(), acmock, store)
+	}
+
+	server := withPermissions(
+		&accesscontrol.Permission{Action: serviceaccounts.ActionRead, Scope: serviceaccounts.ScopeAll},
+		&accesscontrol.Permission{Action: serviceaccounts.ActionWrite, Scope: serviceaccounts.ScopeAll},
+	)
+
+	var identity serviceaccounts.JWTIdentityDTO
+	t.Run("should be ok to add a JWT identity", func(t *testing.T) {
+		actual := requestResponse(server, http.MethodPost, fmt.Sprintf(serviceaccountIDJWTIdentitiesPath, sa.Id), strings.NewReader(`{"subject": "ci-pipeline"}`))
+		require.Equal(t, http.StatusOK, actual.Code, actual.Body.String())
+		require.NoError(t, json.Unmarshal(actual.Body.Bytes(), &identity))
+		assert.Equal(t, "ci-pipeline", identity.Subject)
+	})
+
+	t.Run("should be conflict to add a JWT identity twice", func(t *testing.T) {
+		actual := requestResponse(server, http.MethodPost, fmt.Sprintf(serviceaccountIDJWTIdentitiesPath, sa.Id), strings.NewReader(`{"subject": "ci-pipeline"}`))
+		require.Equal(t, http.StatusConflict, actual.Code, actual.Body.String())
+	})
+
+	t.Run("should be bad request to add a JWT identity without subject", func(t *testing.T) {
+		actual := requestResponse(server, http.MethodPost, fmt.Sprintf(serviceaccountIDJWTIdentitiesPath, sa.Id), strings.NewReader(`{}`))
+		require.Equal(t, http.StatusBadRequest, actual.Code, actual.Body.String())
+	})
+
+	t.Run("should be forbidden to add a JWT identity if wrong scoped", func(t *testing.T) {
+		server := withPermissions(&accesscontrol.Permission{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:2"})
+		actual := requestResponse(server, http.MethodPost, fmt.Sprintf(serviceaccountIDJWTIdentitiesPath, sa.Id), strings.NewReader(`{"subject": "deploy"}`))
+		require.Equal(t, http.StatusForbidden, actual.Code, actual.Body.String())
+	})
+
+	t.Run("should list the JWT identities", func(t *testing.T) {
+		actual := requestResponse(server, http.MethodGet, fmt.Sprintf(serviceaccountIDJWTIdentitiesPath, sa.Id), nil)
+		require.Equal(t, http.StatusOK, actual.Code, actual.Body.String())
+
+		var identities []serviceaccounts.JWTIdentityDTO
+		require.NoError(t, json.Unmarshal(actual.Body.Bytes(), &identities))
+		require.Len(t, identities, 1)
+		assert.Equal(t, identity.Id, identities[0].Id)
+	})
+
+	t.Run("should be ok to delete a JWT identity", func(t *testing.T) {
+		path := fmt.Sprintf(serviceaccountIDJWTIdentitiesDetailPath, sa.Id, identity.Id)
+		actual := requestResponse(server, http.MethodDelete, path, nil)
+		require.Equal(t, http.StatusOK, actual.Code, actual.Body.String())
+
+		actual = requestResponse(server, http.MethodDelete, path, nil)
+		require.Equal(t, http.StatusNotFound, actual.Code, actual.Body.String())
+	})
+}
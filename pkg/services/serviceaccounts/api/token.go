@@ -2,7 +2,9 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -10,12 +12,33 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/components/apikeygen"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/web"
 )
 
 const failedToDeleteMsg = "Failed to delete API key"
 
+// defaultRotationOverlap is how long a rotated token remains valid when the
+// rotation request doesn't say.
+const defaultRotationOverlap int64 = 3600
+
+var timeNow = time.Now
+
+var rotatedSuffix = regexp.MustCompile(`-rotated-\d+$`)
+
+type rotateTokenForm struct {
+	// Name of the new token, defaults to the name of the rotated token with
+	// a -rotated-<timestamp> suffix.
+	Name string `json:"name"`
+	// OverlapSeconds is how long the rotated token remains valid.
+	OverlapSeconds *int64 `json:"overlapSeconds"`
+	// SecondsToLive of the new token, defaults to the lifetime of the
+	// rotated token.
+	SecondsToLive *int64 `json:"secondsToLive"`
+}
+
 func (api *ServiceAccountsAPI) ListTokens(ctx *models.ReqContext) response.Response {
 	saID, err := strconv.ParseInt(web.Params(ctx.Req)[":serviceAccountId"], 10, 64)
 	if err != nil {
@@ -31,10 +54,13 @@ func (api *ServiceAccountsAPI) ListTokens(ctx *models.ReqContext) response.Respo
 				expiration = &v
 			}
 			result[i] = &models.ApiKeyDTO{
-				Id:         t.Id,
-				Name:       t.Name,
-				Role:       t.Role,
-				Expiration: expiration,
+				Id:          t.Id,
+				Name:        t.Name,
+				Role:        t.Role,
+				Expiration:  expiration,
+				LastUsedAt:  t.LastUsedAt,
+				LastUsedIP:  t.LastUsedIP,
+				Permissions: t.Permissions,
 			}
 		}
 
@@ -74,39 +100,154 @@ func (api *ServiceAccountsAPI) CreateToken(c *models.ReqContext) response.Respon
 		return response.Error(400, "Invalid role specified", nil)
 	}
 
+	if resp := api.validateTokenPermissions(cmd.Permissions); resp != nil {
+		return resp
+	}
+
+	if resp := api.validateSecondsToLive(cmd.SecondsToLive); resp != nil {
+		return resp
+	}
+
+	result, resp := api.addToken(c, &cmd)
+	if resp != nil {
+		return resp
+	}
+	api.auditService.Record(c.Req.Context(), audit.NewEntry(c, audit.ActionCreate, audit.ResourceServiceAccountToken, strconv.FormatInt(cmd.Result.Id, 10)).
+		WithDetails("name", cmd.Result.Name, "serviceAccountId", saID, "secondsToLive", cmd.SecondsToLive, "permissions", cmd.Permissions))
+
+	return response.JSON(200, result)
+}
+
+// RotateToken replaces a service account token by a new one with the same
+// role and permissions. The rotated token remains valid for an overlap
+// period, to give the time to deploy the new one.
+func (api *ServiceAccountsAPI) RotateToken(c *models.ReqContext) response.Response {
+	saID, err := strconv.ParseInt(web.Params(c.Req)[":serviceAccountId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "serviceAccountId is invalid", err)
+	}
+
+	tokenID, err := strconv.ParseInt(web.Params(c.Req)[":tokenId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "tokenId is invalid", err)
+	}
+
+	form := rotateTokenForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	query := &models.GetApiKeyByIdQuery{ApiKeyId: tokenID}
+	if err := api.apiKeyStore.GetApiKeyById(c.Req.Context(), query); err != nil {
+		if errors.Is(err, models.ErrInvalidApiKey) {
+			return response.Error(http.StatusNotFound, "Service account token not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to retrieve service account token", err)
+	}
+	token := query.Result
+	if token.OrgId != c.OrgId || token.ServiceAccountId == nil || *token.ServiceAccountId != saID {
+		return response.Error(http.StatusNotFound, "Service account token not found", nil)
+	}
+
+	now := timeNow()
+	if token.Expires != nil && *token.Expires <= now.Unix() {
+		return response.Error(http.StatusBadRequest, "Expired service account tokens can't be rotated", nil)
+	}
+
+	overlap := defaultRotationOverlap
+	if form.OverlapSeconds != nil {
+		overlap = *form.OverlapSeconds
+	}
+	if overlap < 0 {
+		return response.Error(http.StatusBadRequest, "Negative value for overlapSeconds", nil)
+	}
+
+	cmd := models.AddApiKeyCommand{
+		Name:             form.Name,
+		Role:             token.Role,
+		OrgId:            token.OrgId,
+		ServiceAccountId: token.ServiceAccountId,
+		Permissions:      token.Permissions,
+	}
+	if cmd.Name == "" {
+		cmd.Name = fmt.Sprintf("%s-rotated-%d", rotatedSuffix.ReplaceAllString(token.Name, ""), now.Unix())
+	}
+	if form.SecondsToLive != nil {
+		cmd.SecondsToLive = *form.SecondsToLive
+	} else if token.Expires != nil {
+		cmd.SecondsToLive = *token.Expires - token.Created.Unix()
+	}
+	if resp := api.validateSecondsToLive(cmd.SecondsToLive); resp != nil {
+		return resp
+	}
+
+	result, resp := api.addToken(c, &cmd)
+	if resp != nil {
+		return resp
+	}
+
+	if err := api.store.ExpireRotatedToken(c.Req.Context(), token.OrgId, token.Id, now.Add(time.Duration(overlap)*time.Second)); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to expire the rotated service account token", err)
+	}
+	api.auditService.Record(c.Req.Context(), audit.NewEntry(c, audit.ActionUpdate, audit.ResourceServiceAccountToken, strconv.FormatInt(token.Id, 10)).
+		WithDetails("name", token.Name, "serviceAccountId", saID, "rotatedTo", cmd.Result.Id, "overlapSeconds", overlap))
+
+	return response.JSON(http.StatusOK, result)
+}
+
+func (api *ServiceAccountsAPI) validateTokenPermissions(permissions []models.ApiKeyPermission) response.Response {
+	if len(permissions) == 0 {
+		return nil
+	}
+	if api.accesscontrol.IsDisabled() {
+		return response.Error(http.StatusBadRequest, "Restricting tokens requires access control to be enabled", nil)
+	}
+	for _, p := range permissions {
+		if p.Action == "" {
+			return response.Error(http.StatusBadRequest, "Token permissions require an action", nil)
+		}
+		if p.Scope != "" && !accesscontrol.ValidateScope(p.Scope) {
+			return response.Error(http.StatusBadRequest, fmt.Sprintf("Invalid scope %q for action %q", p.Scope, p.Action), nil)
+		}
+	}
+	return nil
+}
+
+func (api *ServiceAccountsAPI) validateSecondsToLive(secondsToLive int64) response.Response {
 	if api.cfg.ApiKeyMaxSecondsToLive != -1 {
-		if cmd.SecondsToLive == 0 {
+		if secondsToLive == 0 {
 			return response.Error(400, "Number of seconds before expiration should be set", nil)
 		}
-		if cmd.SecondsToLive > api.cfg.ApiKeyMaxSecondsToLive {
+		if secondsToLive > api.cfg.ApiKeyMaxSecondsToLive {
 			return response.Error(400, "Number of seconds before expiration is greater than the global limit", nil)
 		}
 	}
+	return nil
+}
 
+func (api *ServiceAccountsAPI) addToken(c *models.ReqContext, cmd *models.AddApiKeyCommand) (*dtos.NewApiKeyResult, response.Response) {
 	newKeyInfo, err := apikeygen.New(cmd.OrgId, cmd.Name)
 	if err != nil {
-		return response.Error(500, "Generating API key failed", err)
+		return nil, response.Error(500, "Generating API key failed", err)
 	}
 
 	cmd.Key = newKeyInfo.HashedKey
 
-	if err := api.apiKeyStore.AddAPIKey(c.Req.Context(), &cmd); err != nil {
+	if err := api.apiKeyStore.AddAPIKey(c.Req.Context(), cmd); err != nil {
 		if errors.Is(err, models.ErrInvalidApiKeyExpiration) {
-			return response.Error(400, err.Error(), nil)
+			return nil, response.Error(400, err.Error(), nil)
 		}
 		if errors.Is(err, models.ErrDuplicateApiKey) {
-			return response.Error(409, err.Error(), nil)
+			return nil, response.Error(409, err.Error(), nil)
 		}
-		return response.Error(500, "Failed to add API Key", err)
+		return nil, response.Error(500, "Failed to add API Key", err)
 	}
 
-	result := &dtos.NewApiKeyResult{
+	return &dtos.NewApiKeyResult{
 		ID:   cmd.Result.Id,
 		Name: cmd.Result.Name,
 		Key:  newKeyInfo.ClientSecret,
-	}
-
-	return response.JSON(200, result)
+	}, nil
 }
 
 // DeleteToken deletes service account tokens
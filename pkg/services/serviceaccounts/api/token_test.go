@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/bus"
@@ -108,6 +109,39 @@ func TestServiceAccountsAPI_CreateToken(t *testing.T) {
 			body:         map[string]interface{}{"name": "Test4", "role": "Viewer"},
 			expectedCode: http.StatusForbidden,
 		},
+		{
+			desc: "should be ok to create serviceaccount token restricted to some permissions",
+			acmock: tests.SetupMockAccesscontrol(
+				t,
+				func(c context.Context, siu *models.SignedInUser) ([]*accesscontrol.Permission, error) {
+					return []*accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: serviceaccounts.ScopeAll}}, nil
+				},
+				false,
+			),
+			body: map[string]interface{}{"name": "Test5", "role": "Viewer", "permissions": []map[string]string{
+				{"action": "dashboards:read", "scope": "dashboards:uid:abc"},
+				{"action": "folders:read"},
+			}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc: "should be bad request to create serviceaccount token with an invalid scope",
+			acmock: tests.SetupMockAccesscontrol(
+				t,
+				func(c context.Context, siu *models.SignedInUser) ([]*accesscontrol.Permission, error) {
+					return []*accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: serviceaccounts.ScopeAll}}, nil
+				},
+				false,
+			),
+			body:         map[string]interface{}{"name": "Test6", "role": "Viewer", "permissions": []map[string]string{{"action": "dashboards:read", "scope": "dashboards:*:abc"}}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			desc:         "should be bad request to create a restricted serviceaccount token without access control",
+			acmock:       tests.SetupMockAccesscontrol(t, nil, true),
+			body:         map[string]interface{}{"name": "Test7", "role": "Viewer", "permissions": []map[string]string{{"action": "dashboards:read"}}},
+			expectedCode: http.StatusBadRequest,
+		},
 	}
 
 	var requestResponse = func(server *web.Mux, httpMethod, requestpath string, requestBody io.Reader) *httptest.ResponseRecorder {
@@ -148,6 +182,9 @@ func TestServiceAccountsAPI_CreateToken(t *testing.T) {
 
 				assert.Equal(t, sa.Id, *query.Result.ServiceAccountId)
 				assert.Equal(t, sa.OrgId, query.Result.OrgId)
+				if permissions, ok := tc.body["permissions"].([]map[string]string); ok {
+					assert.Len(t, query.Result.Permissions, len(permissions))
+				}
 			}
 		})
 	}
@@ -238,3 +275,75 @@ func TestServiceAccountsAPI_DeleteToken(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceAccountsAPI_RotateToken(t *testing.T) {
+	store := sqlstore.InitTestDB(t)
+	svcmock := tests.ServiceAccountMock{}
+	sa := tests.SetupUserServiceAccount(t, store, tests.TestUser{Login: "sa", IsServiceAccount: true})
+	acmock := tests.SetupMockAccesscontrol(
+		t,
+		func(c context.Context, siu *models.SignedInUser) ([]*accesscontrol.Permission, error) {
+			return []*accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: serviceaccounts.ScopeAll}}, nil
+		},
+		false,
+	)
+	server := setupTestServer(t, &svcmock, routing.NewRouteRegister(), acmock, store)
+
+	rotate := func(saID, tokenID int64, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		endpoint := fmt.Sprintf(serviceaccountIDTokensDetailPath+"/rotate", saID, tokenID)
+		req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Add("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+
+		actualBody := map[string]interface{}{}
+		_ = json.Unmarshal(recorder.Body.Bytes(), &actualBody)
+		return recorder, actualBody
+	}
+
+	t.Run("should replace the token and expire the rotated one after the overlap", func(t *testing.T) {
+		token := createTokenforSA(t, "Test1", sa.OrgId, sa.Id)
+
+		before := time.Now()
+		resp, body := rotate(sa.Id, token.Id, `{"overlapSeconds": 60}`)
+		require.Equal(t, http.StatusOK, resp.Code, body)
+		assert.NotEmpty(t, body["key"])
+		require.True(t, strings.HasPrefix(body["name"].(string), "Test1-rotated-"), body["name"])
+
+		query := models.GetApiKeyByIdQuery{ApiKeyId: token.Id}
+		require.NoError(t, store.GetApiKeyById(context.Background(), &query))
+		require.NotNil(t, query.Result.Expires)
+		assert.InDelta(t, before.Add(time.Minute).Unix(), *query.Result.Expires, 2)
+		assert.NotNil(t, query.Result.ExpiryNotifiedAt)
+
+		newQuery := models.GetApiKeyByNameQuery{KeyName: body["name"].(string), OrgId: sa.OrgId}
+		require.NoError(t, store.GetApiKeyByName(context.Background(), &newQuery))
+		assert.Equal(t, sa.Id, *newQuery.Result.ServiceAccountId)
+		assert.Nil(t, newQuery.Result.Expires)
+
+		t.Run("and name the next rotation after the original token", func(t *testing.T) {
+			timeNow = func() time.Time { return before.Add(time.Hour) }
+			t.Cleanup(func() { timeNow = time.Now })
+
+			resp, body := rotate(sa.Id, newQuery.Result.Id, `{}`)
+			require.Equal(t, http.StatusOK, resp.Code, body)
+			assert.Equal(t, fmt.Sprintf("Test1-rotated-%d", before.Add(time.Hour).Unix()), body["name"])
+		})
+	})
+
+	t.Run("should not rotate the token of another service account", func(t *testing.T) {
+		other := tests.SetupUserServiceAccount(t, store, tests.TestUser{Login: "other", IsServiceAccount: true})
+		token := createTokenforSA(t, "Test2", sa.OrgId, sa.Id)
+
+		resp, body := rotate(other.Id, token.Id, `{}`)
+		require.Equal(t, http.StatusNotFound, resp.Code, body)
+	})
+
+	t.Run("should reject a negative overlap", func(t *testing.T) {
+		token := createTokenforSA(t, "Test3", sa.OrgId, sa.Id)
+
+		resp, body := rotate(sa.Id, token.Id, `{"overlapSeconds": -1}`)
+		require.Equal(t, http.StatusBadRequest, resp.Code, body)
+	})
+}
@@ -138,6 +138,132 @@ func (s *ServiceAccountsStoreImpl) ListTokens(ctx context.Context, orgID int64,
 	return result, err
 }
 
+// ListExpiringTokens returns the service account tokens of all organizations
+// expiring before the given time, whose admins haven't been warned yet.
+func (s *ServiceAccountsStoreImpl) ListExpiringTokens(ctx context.Context, before time.Time) ([]*models.ApiKey, error) {
+	result := make([]*models.ApiKey, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("service_account_id > 0 AND expires IS NOT NULL AND expires >= ? AND expires < ? AND expiry_notified_at IS NULL",
+			time.Now().Unix(), before.Unix()).
+			Asc("expires").
+			Find(&result)
+	})
+	return result, err
+}
+
+// MarkTokenExpiryNotified records that the admins have been warned about the
+// expiry of a service account token.
+func (s *ServiceAccountsStoreImpl) MarkTokenExpiryNotified(ctx context.Context, tokenID int64) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		now := time.Now()
+		_, err := sess.ID(tokenID).Cols("expiry_notified_at").Update(&models.ApiKey{ExpiryNotifiedAt: &now})
+		return err
+	})
+}
+
+// ExpireRotatedToken makes a rotated service account token expire at the
+// given time, unless it expires earlier already. No expiry warning is sent
+// for a token that has been replaced.
+func (s *ServiceAccountsStoreImpl) ExpireRotatedToken(ctx context.Context, orgID, tokenID int64, expires time.Time) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		key := models.ApiKey{}
+		has, err := sess.Where("org_id = ? AND id = ?", orgID, tokenID).Get(&key)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrApiKeyNotFound
+		}
+
+		v := expires.Unix()
+		if key.Expires != nil && *key.Expires < v {
+			v = *key.Expires
+		}
+		now := time.Now()
+		_, err = sess.ID(tokenID).Cols("expires", "expiry_notified_at").Update(&models.ApiKey{Expires: &v, ExpiryNotifiedAt: &now})
+		return err
+	})
+}
+
+// ListJWTIdentities returns the JWT identities a service account can be
+// authenticated with.
+func (s *ServiceAccountsStoreImpl) ListJWTIdentities(ctx context.Context, orgID, serviceAccountID int64) ([]*serviceaccounts.JWTIdentityDTO, error) {
+	result := make([]*serviceaccounts.JWTIdentityDTO, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var links []*models.UserAuth
+		err := sess.Table("user_auth").
+			Join("inner", "user", "user.id = user_auth.user_id").
+			Where("user.org_id = ? AND user.id = ? AND user.is_service_account = ? AND user_auth.auth_module = ?",
+				orgID, serviceAccountID, s.sqlStore.Dialect.BooleanStr(true), models.AuthModuleJWTServiceAccount).
+			Cols("user_auth.id", "user_auth.auth_id", "user_auth.created").
+			Asc("user_auth.auth_id").
+			Find(&links)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			result = append(result, &serviceaccounts.JWTIdentityDTO{Id: link.Id, Subject: link.AuthId, Created: link.Created})
+		}
+		return nil
+	})
+	return result, err
+}
+
+// AddJWTIdentity links a JWT identity to a service account. An identity can
+// only authenticate a single service account.
+func (s *ServiceAccountsStoreImpl) AddJWTIdentity(ctx context.Context, orgID, serviceAccountID int64, subject string) (*serviceaccounts.JWTIdentityDTO, error) {
+	var result *serviceaccounts.JWTIdentityDTO
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("org_id = ? AND id = ? AND is_service_account = ?", orgID, serviceAccountID, s.sqlStore.Dialect.BooleanStr(true)).
+			Exist(&models.User{})
+		if err != nil {
+			return err
+		}
+		if !has {
+			return serviceaccounts.ErrServiceAccountNotFound
+		}
+
+		has, err = sess.Exist(&models.UserAuth{AuthModule: models.AuthModuleJWTServiceAccount, AuthId: subject})
+		if err != nil {
+			return err
+		}
+		if has {
+			return serviceaccounts.ErrJWTIdentityAlreadyAssigned
+		}
+
+		link := &models.UserAuth{
+			UserId:     serviceAccountID,
+			AuthModule: models.AuthModuleJWTServiceAccount,
+			AuthId:     subject,
+			Created:    time.Now(),
+		}
+		if _, err := sess.Insert(link); err != nil {
+			return err
+		}
+		result = &serviceaccounts.JWTIdentityDTO{Id: link.Id, Subject: link.AuthId, Created: link.Created}
+		return nil
+	})
+	return result, err
+}
+
+// DeleteJWTIdentity unlinks a JWT identity from a service account.
+func (s *ServiceAccountsStoreImpl) DeleteJWTIdentity(ctx context.Context, orgID, serviceAccountID, identityID int64) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := "DELETE FROM user_auth WHERE id = ? AND user_id = ? AND auth_module = ? AND user_id IN (SELECT id FROM " +
+			s.sqlStore.Dialect.Quote("user") + " WHERE org_id = ? AND is_service_account = ?)"
+		result, err := sess.Exec(rawSQL, identityID, serviceAccountID, models.AuthModuleJWTServiceAccount, orgID, s.sqlStore.Dialect.BooleanStr(true))
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return serviceaccounts.ErrJWTIdentityNotFound
+		}
+		return nil
+	})
+}
+
 func (s *ServiceAccountsStoreImpl) ListServiceAccounts(ctx context.Context, orgID, serviceAccountID int64) ([]*serviceaccounts.ServiceAccountDTO, error) {
 	query := models.GetOrgUsersQuery{OrgId: orgID, IsServiceAccount: true}
 	if serviceAccountID > 0 {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/tests"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
@@ -80,3 +81,62 @@ func TestStore_RetrieveServiceAccount(t *testing.T) {
 		})
 	}
 }
+
+func TestStore_JWTIdentities(t *testing.T) {
+	db, store := setupTestDatabase(t)
+	sa := tests.SetupUserServiceAccount(t, db, tests.TestUser{Login: "servicetest1@admin", IsServiceAccount: true})
+	other := tests.SetupUserServiceAccount(t, db, tests.TestUser{Login: "servicetest2@admin", IsServiceAccount: true})
+	user := tests.SetupUserServiceAccount(t, db, tests.TestUser{Login: "test1@admin", IsServiceAccount: false})
+	ctx := context.Background()
+
+	identity, err := store.AddJWTIdentity(ctx, sa.OrgId, sa.Id, "ci-pipeline")
+	require.NoError(t, err)
+	require.Equal(t, "ci-pipeline", identity.Subject)
+
+	t.Run("an identity can only be assigned once", func(t *testing.T) {
+		_, err := store.AddJWTIdentity(ctx, other.OrgId, other.Id, "ci-pipeline")
+		require.ErrorIs(t, err, serviceaccounts.ErrJWTIdentityAlreadyAssigned)
+	})
+
+	t.Run("identities can't be assigned to users", func(t *testing.T) {
+		_, err := store.AddJWTIdentity(ctx, user.OrgId, user.Id, "deploy")
+		require.ErrorIs(t, err, serviceaccounts.ErrServiceAccountNotFound)
+	})
+
+	t.Run("identities can't be assigned across organizations", func(t *testing.T) {
+		_, err := store.AddJWTIdentity(ctx, other.OrgId, sa.Id, "deploy")
+		require.ErrorIs(t, err, serviceaccounts.ErrServiceAccountNotFound)
+	})
+
+	t.Run("lists the identities of a service account", func(t *testing.T) {
+		identities, err := store.ListJWTIdentities(ctx, sa.OrgId, sa.Id)
+		require.NoError(t, err)
+		require.Len(t, identities, 1)
+		require.Equal(t, identity.Id, identities[0].Id)
+		require.Equal(t, "ci-pipeline", identities[0].Subject)
+
+		identities, err = store.ListJWTIdentities(ctx, other.OrgId, other.Id)
+		require.NoError(t, err)
+		require.Empty(t, identities)
+	})
+
+	t.Run("identities are resolved to their service account", func(t *testing.T) {
+		link := &models.UserAuth{AuthModule: models.AuthModuleJWTServiceAccount, AuthId: "ci-pipeline"}
+		has, err := db.NewSession(ctx).Get(link)
+		require.NoError(t, err)
+		require.True(t, has)
+		require.Equal(t, sa.Id, link.UserId)
+	})
+
+	t.Run("deletes an identity", func(t *testing.T) {
+		err := store.DeleteJWTIdentity(ctx, other.OrgId, other.Id, identity.Id)
+		require.ErrorIs(t, err, serviceaccounts.ErrJWTIdentityNotFound)
+
+		err = store.DeleteJWTIdentity(ctx, sa.OrgId, sa.Id, identity.Id)
+		require.NoError(t, err)
+
+		identities, err := store.ListJWTIdentities(ctx, sa.OrgId, sa.Id)
+		require.NoError(t, err)
+		require.Empty(t, identities)
+	})
+}
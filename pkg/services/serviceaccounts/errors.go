@@ -3,5 +3,7 @@ package serviceaccounts
 import "errors"
 
 var (
-	ErrServiceAccountNotFound = errors.New("Service account not found")
+	ErrServiceAccountNotFound     = errors.New("Service account not found")
+	ErrJWTIdentityNotFound        = errors.New("JWT identity not found")
+	ErrJWTIdentityAlreadyAssigned = errors.New("JWT identity is already assigned")
 )
@@ -5,8 +5,11 @@ import (
 
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/api"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/database"
@@ -19,9 +22,13 @@ var (
 )
 
 type ServiceAccountsService struct {
-	store    serviceaccounts.Store
-	features featuremgmt.FeatureToggles
-	log      log.Logger
+	cfg           *setting.Cfg
+	store         serviceaccounts.Store
+	sqlStore      sqlstore.Store
+	features      featuremgmt.FeatureToggles
+	serverLock    *serverlock.ServerLockService
+	notifications notifications.EmailSender
+	log           log.Logger
 }
 
 func ProvideServiceAccountsService(
@@ -30,11 +37,18 @@ func ProvideServiceAccountsService(
 	store *sqlstore.SQLStore,
 	ac accesscontrol.AccessControl,
 	routeRegister routing.RouteRegister,
+	auditService audit.Service,
+	serverLock *serverlock.ServerLockService,
+	notificationService notifications.EmailSender,
 ) (*ServiceAccountsService, error) {
 	s := &ServiceAccountsService{
-		features: features,
-		store:    database.NewServiceAccountsStore(store),
-		log:      log.New("serviceaccounts"),
+		cfg:           cfg,
+		features:      features,
+		store:         database.NewServiceAccountsStore(store),
+		sqlStore:      store,
+		serverLock:    serverLock,
+		notifications: notificationService,
+		log:           log.New("serviceaccounts"),
 	}
 
 	if features.IsEnabled(featuremgmt.FlagServiceAccounts) {
@@ -43,7 +57,7 @@ func ProvideServiceAccountsService(
 		}
 	}
 
-	serviceaccountsAPI := api.NewServiceAccountsAPI(cfg, s, ac, routeRegister, s.store, store)
+	serviceaccountsAPI := api.NewServiceAccountsAPI(cfg, s, ac, routeRegister, s.store, store, auditService)
 	serviceaccountsAPI.RegisterAPIEndpoints(features)
 
 	return s, nil
@@ -0,0 +1,122 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const tokenExpiryCheckInterval = time.Hour
+
+var tokenExpiryWarningsTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name:      "service_account_token_expiry_warnings_total",
+	Help:      "Number of warnings about service account tokens close to expiry",
+	Namespace: "grafana",
+})
+
+// IsDisabled returns true if service accounts are disabled, or if the expiry
+// warnings are.
+func (sa *ServiceAccountsService) IsDisabled() bool {
+	return !sa.features.IsEnabled(featuremgmt.FlagServiceAccounts) || sa.cfg.ServiceAccountTokenExpiryWarning <= 0
+}
+
+// Run warns the organization admins about the service account tokens close to
+// expiry.
+func (sa *ServiceAccountsService) Run(ctx context.Context) error {
+	sa.warnExpiringTokensWithLock(ctx)
+
+	ticker := time.NewTicker(tokenExpiryCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			sa.warnExpiringTokensWithLock(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (sa *ServiceAccountsService) warnExpiringTokensWithLock(ctx context.Context) {
+	err := sa.serverLock.LockAndExecute(ctx, "warn about expiring service account tokens", tokenExpiryCheckInterval, func(ctx context.Context) {
+		if err := sa.warnExpiringTokens(ctx); err != nil {
+			sa.log.Error("Failed to warn about expiring service account tokens", "error", err)
+		}
+	})
+	if err != nil {
+		sa.log.Error("Failed to lock and execute the warnings about expiring service account tokens", "error", err)
+	}
+}
+
+func (sa *ServiceAccountsService) warnExpiringTokens(ctx context.Context) error {
+	tokens, err := sa.store.ListExpiringTokens(ctx, time.Now().Add(sa.cfg.ServiceAccountTokenExpiryWarning))
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if err := sa.warnExpiringToken(ctx, token); err != nil {
+			// Retried on the next run
+			sa.log.Error("Failed to warn about expiring service account token", "tokenId", token.Id, "error", err)
+			continue
+		}
+		if err := sa.store.MarkTokenExpiryNotified(ctx, token.Id); err != nil {
+			sa.log.Error("Failed to mark service account token expiry as notified", "tokenId", token.Id, "error", err)
+		}
+	}
+	return nil
+}
+
+func (sa *ServiceAccountsService) warnExpiringToken(ctx context.Context, token *models.ApiKey) error {
+	expiresAt := time.Unix(*token.Expires, 0)
+	sa.log.Warn("Service account token expires soon", "tokenId", token.Id, "name", token.Name,
+		"serviceAccountId", *token.ServiceAccountId, "orgId", token.OrgId, "expiresAt", expiresAt)
+	tokenExpiryWarningsTotal.Inc()
+
+	if !sa.cfg.Smtp.Enabled {
+		return nil
+	}
+
+	usersQuery := models.GetOrgUsersQuery{OrgId: token.OrgId}
+	if err := sa.sqlStore.GetOrgUsers(ctx, &usersQuery); err != nil {
+		return err
+	}
+	var emails []string
+	for _, user := range usersQuery.Result {
+		if user.Role == string(models.ROLE_ADMIN) && user.Email != "" {
+			emails = append(emails, user.Email)
+		}
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+
+	serviceAccount, err := sa.store.RetrieveServiceAccount(ctx, token.OrgId, *token.ServiceAccountId)
+	if err != nil {
+		return err
+	}
+	orgQuery := models.GetOrgByIdQuery{Id: token.OrgId}
+	if err := bus.Dispatch(ctx, &orgQuery); err != nil {
+		return err
+	}
+
+	return sa.notifications.SendEmailCommandHandler(ctx, &models.SendEmailCommand{
+		To:       emails,
+		Template: "service_account_token_expiring",
+		Data: map[string]interface{}{
+			"TokenName":          token.Name,
+			"ServiceAccountName": serviceAccount.Name,
+			"OrgName":            orgQuery.Result.Name,
+			"ExpiresAt":          expiresAt.UTC().Format(time.RFC1123),
+			"LinkUrl":            setting.ToAbsUrl(fmt.Sprintf("org/serviceaccounts/%d", serviceAccount.Id)),
+		},
+	})
+}
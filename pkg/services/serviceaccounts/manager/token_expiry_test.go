@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/database"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestServiceAccountsService_WarnExpiringTokens(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	sa, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: "sa", Name: "Service account", IsServiceAccount: true})
+	require.NoError(t, err)
+	for login, role := range map[string]models.RoleType{"admin": models.ROLE_ADMIN, "viewer": models.ROLE_VIEWER} {
+		user, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: login, Email: login + "@localhost"})
+		require.NoError(t, err)
+		err = sqlStore.AddOrgUser(ctx, &models.AddOrgUserCommand{OrgId: sa.OrgId, UserId: user.Id, Role: role})
+		require.NoError(t, err)
+	}
+
+	addToken := func(name string, secondsToLive int64) *models.ApiKey {
+		cmd := models.AddApiKeyCommand{Name: name, Role: models.ROLE_VIEWER, OrgId: sa.OrgId, Key: name, SecondsToLive: secondsToLive, ServiceAccountId: &sa.Id}
+		require.NoError(t, sqlStore.AddAPIKey(ctx, &cmd))
+		return cmd.Result
+	}
+	expiring := addToken("expiring", int64((24 * time.Hour).Seconds()))
+	addToken("not-expiring-soon", int64((30 * 24 * time.Hour).Seconds()))
+	addToken("never-expiring", 0)
+
+	cfg := setting.NewCfg()
+	cfg.ServiceAccountTokenExpiryWarning = 7 * 24 * time.Hour
+	cfg.Smtp.Enabled = true
+	notificationService := notifications.MockNotificationService()
+	svc := &ServiceAccountsService{
+		cfg:           cfg,
+		store:         database.NewServiceAccountsStore(sqlStore),
+		sqlStore:      sqlStore,
+		features:      featuremgmt.WithFeatures(featuremgmt.FlagServiceAccounts),
+		notifications: notificationService,
+		log:           log.New("serviceaccounts-test"),
+	}
+
+	var sent []models.SendEmailCommand
+	notificationService.EmailHandler = func(ctx context.Context, cmd *models.SendEmailCommand) error {
+		sent = append(sent, *cmd)
+		return nil
+	}
+
+	require.NoError(t, svc.warnExpiringTokens(ctx))
+
+	require.Len(t, sent, 1)
+	assert.Equal(t, []string{"admin@localhost"}, sent[0].To)
+	assert.Equal(t, "service_account_token_expiring", sent[0].Template)
+	assert.Equal(t, "expiring", sent[0].Data["TokenName"])
+
+	query := models.GetApiKeyByIdQuery{ApiKeyId: expiring.Id}
+	require.NoError(t, sqlStore.GetApiKeyById(ctx, &query))
+	assert.NotNil(t, query.Result.ExpiryNotifiedAt)
+
+	t.Run("should warn only once", func(t *testing.T) {
+		sent = nil
+		require.NoError(t, svc.warnExpiringTokens(ctx))
+		assert.Empty(t, sent)
+	})
+}
@@ -38,6 +38,19 @@ type ServiceAccountDTO struct {
 	AccessControl map[string]bool `json:"accessControl,omitempty"`
 }
 
+// AddJWTIdentityForm links a JWT identity to a service account. The subject
+// is the value of the claim configured with service_account_claim in the
+// [auth.jwt] section, usually the client ID of a machine client.
+type AddJWTIdentityForm struct {
+	Subject string `json:"subject" binding:"Required"`
+}
+
+type JWTIdentityDTO struct {
+	Id      int64     `json:"id"`
+	Subject string    `json:"subject"`
+	Created time.Time `json:"created"`
+}
+
 type ServiceAccountProfileDTO struct {
 	Id            int64           `json:"id"`
 	Name          string          `json:"name"`
@@ -2,6 +2,7 @@ package serviceaccounts
 
 import (
 	"context"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 )
@@ -20,4 +21,10 @@ type Store interface {
 	UpgradeServiceAccounts(ctx context.Context) error
 	ConvertToServiceAccounts(ctx context.Context, keys []int64) error
 	ListTokens(ctx context.Context, orgID int64, serviceAccount int64) ([]*models.ApiKey, error)
+	ListExpiringTokens(ctx context.Context, before time.Time) ([]*models.ApiKey, error)
+	MarkTokenExpiryNotified(ctx context.Context, tokenID int64) error
+	ExpireRotatedToken(ctx context.Context, orgID, tokenID int64, expires time.Time) error
+	ListJWTIdentities(ctx context.Context, orgID, serviceAccountID int64) ([]*JWTIdentityDTO, error)
+	AddJWTIdentity(ctx context.Context, orgID, serviceAccountID int64, subject string) (*JWTIdentityDTO, error)
+	DeleteJWTIdentity(ctx context.Context, orgID, serviceAccountID, identityID int64) error
 }
@@ -3,6 +3,7 @@ package tests
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
@@ -56,17 +57,25 @@ func SetupMockAccesscontrol(t *testing.T, userpermissionsfunc func(c context.Con
 var _ serviceaccounts.Store = new(ServiceAccountsStoreMock)
 
 type Calls struct {
-	CreateServiceAccount   []interface{}
-	ListServiceAccounts    []interface{}
-	RetrieveServiceAccount []interface{}
-	DeleteServiceAccount   []interface{}
-	UpgradeServiceAccounts []interface{}
-	ConvertServiceAccounts []interface{}
-	ListTokens             []interface{}
+	CreateServiceAccount    []interface{}
+	ListServiceAccounts     []interface{}
+	RetrieveServiceAccount  []interface{}
+	DeleteServiceAccount    []interface{}
+	UpgradeServiceAccounts  []interface{}
+	ConvertServiceAccounts  []interface{}
+	ListTokens              []interface{}
+	ListExpiringTokens      []interface{}
+	MarkTokenExpiryNotified []interface{}
+	ExpireRotatedToken      []interface{}
+	ListJWTIdentities       []interface{}
+	AddJWTIdentity          []interface{}
+	DeleteJWTIdentity       []interface{}
 }
 
 type ServiceAccountsStoreMock struct {
 	Calls Calls
+
+	ExpectedExpiringTokens []*models.ApiKey
 }
 
 func (s *ServiceAccountsStoreMock) CreateServiceAccount(ctx context.Context, cmd *serviceaccounts.CreateServiceaccountForm) (*serviceaccounts.ServiceAccountDTO, error) {
@@ -95,6 +104,37 @@ func (s *ServiceAccountsStoreMock) ListTokens(ctx context.Context, orgID int64,
 	s.Calls.ListTokens = append(s.Calls.ListTokens, []interface{}{ctx, orgID, serviceAccount})
 	return nil, nil
 }
+
+func (s *ServiceAccountsStoreMock) ListExpiringTokens(ctx context.Context, before time.Time) ([]*models.ApiKey, error) {
+	s.Calls.ListExpiringTokens = append(s.Calls.ListExpiringTokens, []interface{}{ctx, before})
+	return s.ExpectedExpiringTokens, nil
+}
+
+func (s *ServiceAccountsStoreMock) MarkTokenExpiryNotified(ctx context.Context, tokenID int64) error {
+	s.Calls.MarkTokenExpiryNotified = append(s.Calls.MarkTokenExpiryNotified, []interface{}{ctx, tokenID})
+	return nil
+}
+
+func (s *ServiceAccountsStoreMock) ExpireRotatedToken(ctx context.Context, orgID, tokenID int64, expires time.Time) error {
+	s.Calls.ExpireRotatedToken = append(s.Calls.ExpireRotatedToken, []interface{}{ctx, orgID, tokenID, expires})
+	return nil
+}
+
+func (s *ServiceAccountsStoreMock) ListJWTIdentities(ctx context.Context, orgID, serviceAccountID int64) ([]*serviceaccounts.JWTIdentityDTO, error) {
+	s.Calls.ListJWTIdentities = append(s.Calls.ListJWTIdentities, []interface{}{ctx, orgID, serviceAccountID})
+	return nil, nil
+}
+
+func (s *ServiceAccountsStoreMock) AddJWTIdentity(ctx context.Context, orgID, serviceAccountID int64, subject string) (*serviceaccounts.JWTIdentityDTO, error) {
+	s.Calls.AddJWTIdentity = append(s.Calls.AddJWTIdentity, []interface{}{ctx, orgID, serviceAccountID, subject})
+	return nil, nil
+}
+
+func (s *ServiceAccountsStoreMock) DeleteJWTIdentity(ctx context.Context, orgID, serviceAccountID, identityID int64) error {
+	s.Calls.DeleteJWTIdentity = append(s.Calls.DeleteJWTIdentity, []interface{}{ctx, orgID, serviceAccountID, identityID})
+	return nil
+}
+
 func (s *ServiceAccountsStoreMock) ListServiceAccounts(ctx context.Context, orgID int64, serviceAccountID int64) ([]*serviceaccounts.ServiceAccountDTO, error) {
 	s.Calls.ListServiceAccounts = append(s.Calls.ListServiceAccounts, []interface{}{ctx, orgID})
 	return nil, nil
@@ -102,6 +102,7 @@ func (ss *SQLStore) AddAPIKey(ctx context.Context, cmd *models.AddApiKeyCommand)
 			Updated:          updated,
 			Expires:          expires,
 			ServiceAccountId: cmd.ServiceAccountId,
+			Permissions:      cmd.Permissions,
 		}
 
 		if _, err := sess.Insert(&t); err != nil {
@@ -112,6 +113,15 @@ func (ss *SQLStore) AddAPIKey(ctx context.Context, cmd *models.AddApiKeyCommand)
 	})
 }
 
+// UpdateAPIKeyLastUsed records when and from which IP an API key was last used.
+func (ss *SQLStore) UpdateAPIKeyLastUsed(ctx context.Context, cmd *models.UpdateApiKeyLastUsedCommand) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		key := models.ApiKey{LastUsedAt: &cmd.UsedAt, LastUsedIP: cmd.IP}
+		_, err := sess.ID(cmd.Id).Cols("last_used_at", "last_used_ip").Update(&key)
+		return err
+	})
+}
+
 // UpdateApikeyServiceAccount sets a service account for an existing API key
 func (ss *SQLStore) UpdateApikeyServiceAccount(ctx context.Context, apikeyId int64, saccountId int64) error {
 	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
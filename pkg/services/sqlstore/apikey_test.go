@@ -58,6 +58,27 @@ func TestApiKeyDataAccess(t *testing.T) {
 			assert.Nil(t, query.Result.Expires)
 		})
 
+		t.Run("Add a restricted key for service account and record its use", func(t *testing.T) {
+			var one int64 = 1
+			permissions := []models.ApiKeyPermission{{Action: "dashboards:read", Scope: "dashboards:uid:abc"}}
+			cmd := models.AddApiKeyCommand{OrgId: 1, Name: "restricted-SA", Key: "sa1-restricted", ServiceAccountId: &one, Permissions: permissions}
+			err := ss.AddAPIKey(context.Background(), &cmd)
+			assert.Nil(t, err)
+
+			usedAt := time.Date(2022, 2, 1, 10, 0, 0, 0, time.UTC)
+			err = ss.UpdateAPIKeyLastUsed(context.Background(), &models.UpdateApiKeyLastUsedCommand{Id: cmd.Result.Id, IP: "10.0.0.1", UsedAt: usedAt})
+			assert.Nil(t, err)
+
+			query := models.GetApiKeyByNameQuery{KeyName: "restricted-SA", OrgId: 1}
+			err = ss.GetApiKeyByName(context.Background(), &query)
+			assert.Nil(t, err)
+
+			assert.True(t, query.Result.IsRestricted())
+			assert.Equal(t, permissions, query.Result.Permissions)
+			assert.Equal(t, "10.0.0.1", query.Result.LastUsedIP)
+			assert.Equal(t, usedAt.Unix(), query.Result.LastUsedAt.Unix())
+		})
+
 		t.Run("Add an expiring key", func(t *testing.T) {
 			// expires in one hour
 			cmd := models.AddApiKeyCommand{OrgId: 1, Name: "expiring-in-an-hour", Key: "asd2", SecondsToLive: 3600}
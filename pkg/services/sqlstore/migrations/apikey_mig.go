@@ -86,4 +86,20 @@ func addApiKeyMigrations(mg *Migrator) {
 	mg.AddMigration("Add service account foreign key", NewAddColumnMigration(apiKeyV2, &Column{
 		Name: "service_account_id", Type: DB_BigInt, Nullable: true,
 	}))
+
+	mg.AddMigration("Add last_used_at to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "last_used_at", Type: DB_DateTime, Nullable: true,
+	}))
+
+	mg.AddMigration("Add last_used_ip to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "last_used_ip", Type: DB_NVarchar, Length: 255, Nullable: true,
+	}))
+
+	mg.AddMigration("Add permissions to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "permissions", Type: DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("Add expiry_notified_at to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "expiry_notified_at", Type: DB_DateTime, Nullable: true,
+	}))
 }
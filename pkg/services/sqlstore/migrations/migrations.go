@@ -72,11 +72,16 @@ func (*OSSMigrations) AddMigration(mg *Migrator) {
 	ualert.AddDashboardUIDPanelIDMigration(mg)
 	accesscontrol.AddMigration(mg)
 	addQueryHistoryMigrations(mg)
+	addAuditMigrations(mg)
+	addUserTOTPMigrations(mg)
 
 	if mg.Cfg != nil && mg.Cfg.IsFeatureToggleEnabled != nil {
 		if mg.Cfg.IsFeatureToggleEnabled(featuremgmt.FlagAccesscontrol) {
 			accesscontrol.AddTeamMembershipMigrations(mg)
 		}
+		if mg.Cfg.IsFeatureToggleEnabled(featuremgmt.FlagLivePipeline) {
+			addLivePipelineMigrations(mg)
+		}
 	}
 }
 
@@ -634,6 +634,10 @@ func (m *SQLStoreMock) UpdateApikeyServiceAccount(ctx context.Context, apikeyId
 	return m.ExpectedError
 }
 
+func (m *SQLStoreMock) UpdateAPIKeyLastUsed(ctx context.Context, cmd *models.UpdateApiKeyLastUsedCommand) error {
+	return m.ExpectedError
+}
+
 func (m *SQLStoreMock) GetApiKeyById(ctx context.Context, query *models.GetApiKeyByIdQuery) error {
 	return m.ExpectedError
 }
@@ -455,12 +455,16 @@ type ITestDB interface {
 }
 
 var testSQLStore *SQLStore
+var testSQLStoreFeatures []string
 var testSQLStoreMutex sync.Mutex
 
 // InitTestDBOpt contains options for InitTestDB.
 type InitTestDBOpt struct {
 	// EnsureDefaultOrgAndUser flags whether to ensure that default org and user exist.
 	EnsureDefaultOrgAndUser bool
+	// FeatureFlags are feature toggles enabled in addition to the ones always enabled during
+	// tests, for example to create the tables of a feature.
+	FeatureFlags []string
 }
 
 var featuresEnabledDuringTests = []string{
@@ -490,7 +494,14 @@ func InitTestDB(t ITestDB, opts ...InitTestDBOpt) *SQLStore {
 func initTestDB(migration registry.DatabaseMigrator, opts ...InitTestDBOpt) (*SQLStore, error) {
 	testSQLStoreMutex.Lock()
 	defer testSQLStoreMutex.Unlock()
-	if testSQLStore == nil {
+
+	features := append([]string{}, featuresEnabledDuringTests...)
+	for _, opt := range opts {
+		features = append(features, opt.FeatureFlags...)
+	}
+
+	// The test DB is initialized again when other features are enabled, so that their migrations run.
+	if testSQLStore == nil || !sameFeatures(testSQLStoreFeatures, features) {
 		dbType := migrator.SQLite
 
 		if len(opts) == 0 {
@@ -505,7 +516,7 @@ func initTestDB(migration registry.DatabaseMigrator, opts ...InitTestDBOpt) (*SQ
 		// set test db config
 		cfg := setting.NewCfg()
 		cfg.IsFeatureToggleEnabled = func(requestedFeature string) bool {
-			for _, enabledFeature := range featuresEnabledDuringTests {
+			for _, enabledFeature := range features {
 				if enabledFeature == requestedFeature {
 					return true
 				}
@@ -582,6 +593,8 @@ func initTestDB(migration registry.DatabaseMigrator, opts ...InitTestDBOpt) (*SQ
 			}
 		}
 
+		testSQLStoreFeatures = features
+
 		// temp global var until we get rid of global vars
 		dialect = testSQLStore.Dialect
 		return testSQLStore, nil
@@ -597,6 +610,18 @@ func initTestDB(migration registry.DatabaseMigrator, opts ...InitTestDBOpt) (*SQ
 	return testSQLStore, nil
 }
 
+func sameFeatures(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func IsTestDbMySQL() bool {
 	if db, present := os.LookupEnv("GRAFANA_TEST_DB"); present {
 		return db == migrator.MySQL
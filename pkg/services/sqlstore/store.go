@@ -147,6 +147,7 @@ type Store interface {
 	DeleteApiKey(ctx context.Context, cmd *models.DeleteApiKeyCommand) error
 	AddAPIKey(ctx context.Context, cmd *models.AddApiKeyCommand) error
 	UpdateApikeyServiceAccount(ctx context.Context, apikeyId int64, saccountId int64) error
+	UpdateAPIKeyLastUsed(ctx context.Context, cmd *models.UpdateApiKeyLastUsedCommand) error
 	GetApiKeyById(ctx context.Context, query *models.GetApiKeyByIdQuery) error
 	GetApiKeyByName(ctx context.Context, query *models.GetApiKeyByNameQuery) error
 	UpdateTempUserStatus(ctx context.Context, cmd *models.UpdateTempUserStatusCommand) error
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type TOTPAPI struct {
+	service        totp.Service
+	cfg            *setting.Cfg
+	RouterRegister routing.RouteRegister
+	audit          audit.Service
+}
+
+func NewTOTPAPI(service totp.Service, cfg *setting.Cfg, routerRegister routing.RouteRegister, auditService audit.Service) *TOTPAPI {
+	return &TOTPAPI{
+		service:        service,
+		cfg:            cfg,
+		RouterRegister: routerRegister,
+		audit:          auditService,
+	}
+}
+
+func (api *TOTPAPI) RegisterAPIEndpoints() {
+	api.RouterRegister.Group("/api/user/totp", func(totpRoute routing.RouteRegister) {
+		totpRoute.Get("/", routing.Wrap(api.GetStatus))
+		totpRoute.Post("/enrol", routing.Wrap(api.Enrol))
+		totpRoute.Post("/confirm", routing.Wrap(api.Confirm))
+		totpRoute.Post("/recovery-codes", routing.Wrap(api.RegenerateRecoveryCodes))
+		totpRoute.Post("/disable", routing.Wrap(api.Disable))
+	}, middleware.ReqSignedInNoAnonymous)
+
+	api.RouterRegister.Delete("/api/admin/users/:id/totp", middleware.ReqGrafanaAdmin, routing.Wrap(api.Reset))
+}
+
+// CodeCommand is a code of the authenticator app, or a recovery code.
+type CodeCommand struct {
+	Code string `json:"code" binding:"Required"`
+}
+
+// GET /api/user/totp
+func (api *TOTPAPI) GetStatus(c *models.ReqContext) response.Response {
+	enabled, err := api.service.IsEnabled(c.Req.Context(), c.UserId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor authentication status", err)
+	}
+	required, err := api.service.IsRequired(c.Req.Context(), signedInUser(c))
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor authentication status", err)
+	}
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"enabled":  enabled,
+		"required": required,
+	})
+}
+
+// POST /api/user/totp/enrol
+func (api *TOTPAPI) Enrol(c *models.ReqContext) response.Response {
+	enrolment, err := api.service.Enrol(c.Req.Context(), signedInUser(c))
+	if err != nil {
+		if errors.Is(err, totp.ErrAlreadyEnrolled) {
+			return response.Error(http.StatusConflict, "Two-factor authentication is already enabled", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to enrol two-factor authentication", err)
+	}
+
+	return response.JSON(http.StatusOK, enrolment)
+}
+
+// POST /api/user/totp/confirm
+func (api *TOTPAPI) Confirm(c *models.ReqContext) response.Response {
+	cmd := CodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if resp := api.validateLoginAttempts(c); resp != nil {
+		return resp
+	}
+
+	codes, err := api.service.Confirm(c.Req.Context(), c.UserId, cmd.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, totp.ErrInvalidCode):
+			return api.invalidCode(c, err)
+		case errors.Is(err, totp.ErrNotEnrolled):
+			return response.Error(http.StatusBadRequest, "Two-factor authentication enrolment not started", err)
+		case errors.Is(err, totp.ErrAlreadyEnrolled):
+			return response.Error(http.StatusConflict, "Two-factor authentication is already enabled", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to enable two-factor authentication", err)
+	}
+
+	api.audit.Record(c.Req.Context(), audit.NewEntry(c, audit.ActionTOTPEnable, audit.ResourceUser, strconv.FormatInt(c.UserId, 10)))
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"message":       "Two-factor authentication enabled",
+		"recoveryCodes": codes,
+	})
+}
+
+// POST /api/user/totp/recovery-codes
+func (api *TOTPAPI) RegenerateRecoveryCodes(c *models.ReqContext) response.Response {
+	cmd := CodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if resp := api.verify(c, cmd.Code); resp != nil {
+		return resp
+	}
+
+	codes, err := api.service.RegenerateRecoveryCodes(c.Req.Context(), c.UserId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to generate recovery codes", err)
+	}
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"recoveryCodes": codes,
+	})
+}
+
+// POST /api/user/totp/disable
+func (api *TOTPAPI) Disable(c *models.ReqContext) response.Response {
+	cmd := CodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	required, err := api.service.IsRequired(c.Req.Context(), signedInUser(c))
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to disable two-factor authentication", err)
+	}
+	if required {
+		return response.Error(http.StatusForbidden, "Two-factor authentication is required for your account", nil)
+	}
+
+	if resp := api.verify(c, cmd.Code); resp != nil {
+		return resp
+	}
+
+	if err := api.service.Disable(c.Req.Context(), c.UserId); err != nil && !errors.Is(err, totp.ErrNotEnrolled) {
+		return response.Error(http.StatusInternalServerError, "Failed to disable two-factor authentication", err)
+	}
+
+	api.audit.Record(c.Req.Context(), audit.NewEntry(c, audit.ActionTOTPDisable, audit.ResourceUser, strconv.FormatInt(c.UserId, 10)))
+
+	return response.Success("Two-factor authentication disabled")
+}
+
+// DELETE /api/admin/users/:id/totp
+func (api *TOTPAPI) Reset(c *models.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+
+	if err := api.service.Disable(c.Req.Context(), userID); err != nil {
+		if errors.Is(err, totp.ErrNotEnrolled) {
+			return response.Error(http.StatusNotFound, "User has not enrolled two-factor authentication", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to reset two-factor authentication", err)
+	}
+
+	api.audit.Record(c.Req.Context(), audit.NewEntry(c, audit.ActionTOTPDisable, audit.ResourceUser, strconv.FormatInt(userID, 10)).
+		WithDetails("reset", true))
+
+	return response.Success("Two-factor authentication reset")
+}
+
+// verify checks a code of the signed in user, counting invalid codes as
+// invalid login attempts.
+func (api *TOTPAPI) verify(c *models.ReqContext, code string) response.Response {
+	if resp := api.validateLoginAttempts(c); resp != nil {
+		return resp
+	}
+
+	if err := api.service.Verify(c.Req.Context(), c.UserId, code); err != nil {
+		switch {
+		case errors.Is(err, totp.ErrInvalidCode):
+			return api.invalidCode(c, err)
+		case errors.Is(err, totp.ErrNotEnrolled):
+			return response.Error(http.StatusBadRequest, "Two-factor authentication is not enabled", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to verify two-factor authentication code", err)
+	}
+	return nil
+}
+
+func (api *TOTPAPI) validateLoginAttempts(c *models.ReqContext) response.Response {
+	if err := login.ValidateLoginAttempts(c.Req.Context(), api.loginQuery(c)); err != nil {
+		if errors.Is(err, login.ErrTooManyLoginAttempts) {
+			return response.Error(http.StatusTooManyRequests, "Too many invalid codes, try again later", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to verify two-factor authentication code", err)
+	}
+	return nil
+}
+
+func (api *TOTPAPI) invalidCode(c *models.ReqContext, err error) response.Response {
+	if err := login.SaveInvalidLoginAttempt(c.Req.Context(), api.loginQuery(c)); err != nil {
+		c.Logger.Error("Failed to save invalid login attempt", "err", err)
+	}
+	return response.Error(http.StatusBadRequest, "Invalid two-factor authentication code", err)
+}
+
+func (api *TOTPAPI) loginQuery(c *models.ReqContext) *models.LoginUserQuery {
+	return &models.LoginUserQuery{
+		ReqContext: c,
+		Username:   c.Login,
+		IpAddress:  c.Req.RemoteAddr,
+		Cfg:        api.cfg,
+	}
+}
+
+func signedInUser(c *models.ReqContext) *models.User {
+	return &models.User{Id: c.UserId, Login: c.Login, Email: c.Email, IsAdmin: c.IsGrafanaAdmin}
+}
@@ -0,0 +1,88 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 secret of the RFC 6238 test vectors, 12345678901234567890.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCode(t *testing.T) {
+	// The last six digits of the eight digit codes of RFC 6238, appendix B.
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, expected := range vectors {
+		code, err := GenerateCode(rfcSecret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code, "time %d", unix)
+	}
+}
+
+func TestValidateCode(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	step, err := ValidateCode(rfcSecret, "050471", now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1111111111/period), step)
+
+	t.Run("accepts codes of the previous and next time step", func(t *testing.T) {
+		previous, err := GenerateCode(rfcSecret, now.Add(-period*time.Second))
+		require.NoError(t, err)
+		_, err = ValidateCode(rfcSecret, previous, now)
+		require.NoError(t, err)
+
+		next, err := GenerateCode(rfcSecret, now.Add(period*time.Second))
+		require.NoError(t, err)
+		_, err = ValidateCode(rfcSecret, next, now)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects older codes", func(t *testing.T) {
+		old, err := GenerateCode(rfcSecret, now.Add(-2*period*time.Second))
+		require.NoError(t, err)
+		_, err = ValidateCode(rfcSecret, old, now)
+		require.ErrorIs(t, err, ErrInvalidCode)
+	})
+
+	t.Run("rejects malformed codes", func(t *testing.T) {
+		for _, code := range []string{"", "05047", "0504711", "abcdef"} {
+			_, err := ValidateCode(rfcSecret, code, now)
+			require.ErrorIs(t, err, ErrInvalidCode, code)
+		}
+	})
+}
+
+func TestProvisioningURL(t *testing.T) {
+	assert.Equal(t,
+		"otpauth://totp/Grafana:admin@example.com?algorithm=SHA1&digits=6&issuer=Grafana&period=30&secret="+rfcSecret,
+		ProvisioningURL("Grafana", "admin@example.com", rfcSecret))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, strings.Split(hashes, ","), recoveryCodeCount)
+
+	remaining, ok := UseRecoveryCode(hashes, strings.ToUpper(codes[3]))
+	require.True(t, ok)
+	assert.Len(t, strings.Split(remaining, ","), recoveryCodeCount-1)
+
+	_, ok = UseRecoveryCode(remaining, codes[3])
+	assert.False(t, ok)
+
+	_, ok = UseRecoveryCode(remaining, "aaaaa-aaaaa")
+	assert.False(t, ok)
+}
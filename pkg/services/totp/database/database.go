@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/totp"
+)
+
+type TOTPStoreImpl struct {
+	sqlStore *sqlstore.SQLStore
+}
+
+func NewTOTPStore(store *sqlstore.SQLStore) *TOTPStoreImpl {
+	return &TOTPStoreImpl{
+		sqlStore: store,
+	}
+}
+
+func (s *TOTPStoreImpl) Get(ctx context.Context, userID int64) (*totp.UserTOTP, error) {
+	var result totp.UserTOTP
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("user_id = ?", userID).Get(&result)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return totp.ErrNotEnrolled
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *TOTPStoreImpl) Save(ctx context.Context, userTOTP *totp.UserTOTP) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.Exec("DELETE FROM user_totp WHERE user_id = ?", userTOTP.UserId); err != nil {
+			return err
+		}
+		userTOTP.Id = 0
+		_, err := sess.Insert(userTOTP)
+		return err
+	})
+}
+
+func (s *TOTPStoreImpl) Delete(ctx context.Context, userID int64) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM user_totp WHERE user_id = ?", userID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return totp.ErrNotEnrolled
+		}
+		return nil
+	})
+}
+
+func (s *TOTPStoreImpl) UseStep(ctx context.Context, userID int64, step int64) error {
+	return s.update(ctx, "UPDATE user_totp SET last_used_step = ?, updated = ? WHERE user_id = ? AND last_used_step < ?",
+		step, time.Now(), userID, step)
+}
+
+func (s *TOTPStoreImpl) UseRecoveryCodes(ctx context.Context, userID int64, expected, remaining string) error {
+	return s.update(ctx, "UPDATE user_totp SET recovery_codes = ?, updated = ? WHERE user_id = ? AND recovery_codes = ?",
+		remaining, time.Now(), userID, expected)
+}
+
+// update runs a conditional update, failing with ErrInvalidCode when the
+// condition doesn't hold anymore because the code was used concurrently.
+func (s *TOTPStoreImpl) update(ctx context.Context, sql string, args ...interface{}) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec(append([]interface{}{sql}, args...)...)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return totp.ErrInvalidCode
+		}
+		return nil
+	})
+}
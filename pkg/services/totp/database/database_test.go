@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/totp"
+)
+
+func TestStore(t *testing.T) {
+	store := NewTOTPStore(sqlstore.InitTestDB(t))
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	_, err := store.Get(ctx, 1)
+	require.ErrorIs(t, err, totp.ErrNotEnrolled)
+
+	require.NoError(t, store.Save(ctx, &totp.UserTOTP{UserId: 1, Secret: "pending", Created: now, Updated: now}))
+	require.NoError(t, store.Save(ctx, &totp.UserTOTP{UserId: 1, Secret: "secret", RecoveryCodes: "a,b", Enabled: true, Created: now, Updated: now}))
+
+	userTOTP, err := store.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "secret", userTOTP.Secret)
+	require.True(t, userTOTP.Enabled)
+
+	t.Run("a time step can only be used once", func(t *testing.T) {
+		require.NoError(t, store.UseStep(ctx, 1, 100))
+		require.ErrorIs(t, store.UseStep(ctx, 1, 100), totp.ErrInvalidCode)
+		require.ErrorIs(t, store.UseStep(ctx, 1, 99), totp.ErrInvalidCode)
+		require.NoError(t, store.UseStep(ctx, 1, 101))
+	})
+
+	t.Run("recovery codes are only replaced if they are the expected ones", func(t *testing.T) {
+		require.NoError(t, store.UseRecoveryCodes(ctx, 1, "a,b", "b"))
+		require.ErrorIs(t, store.UseRecoveryCodes(ctx, 1, "a,b", "b"), totp.ErrInvalidCode)
+
+		userTOTP, err := store.Get(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, "b", userTOTP.RecoveryCodes)
+	})
+
+	require.NoError(t, store.Delete(ctx, 1))
+	require.ErrorIs(t, store.Delete(ctx, 1), totp.ErrNotEnrolled)
+}
@@ -0,0 +1,221 @@
+package manager
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/services/totp/api"
+	"github.com/grafana/grafana/pkg/services/totp/database"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// now is used to test the validation of codes.
+var now = time.Now
+
+type TOTPService struct {
+	cfg      *setting.Cfg
+	store    totp.Store
+	sqlStore *sqlstore.SQLStore
+	secrets  secrets.Service
+	log      log.Logger
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sqlStore *sqlstore.SQLStore,
+	secretsService secrets.Service,
+	routeRegister routing.RouteRegister,
+	auditService audit.Service,
+) *TOTPService {
+	s := &TOTPService{
+		cfg:      cfg,
+		store:    database.NewTOTPStore(sqlStore),
+		sqlStore: sqlStore,
+		secrets:  secretsService,
+		log:      log.New("totp"),
+	}
+
+	if cfg.TOTPEnabled {
+		totpAPI := api.NewTOTPAPI(s, cfg, routeRegister, auditService)
+		totpAPI.RegisterAPIEndpoints()
+	}
+
+	return s
+}
+
+func (s *TOTPService) IsEnabled(ctx context.Context, userID int64) (bool, error) {
+	userTOTP, err := s.store.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, totp.ErrNotEnrolled) {
+			return false, nil
+		}
+		return false, err
+	}
+	return userTOTP.Enabled, nil
+}
+
+// IsRequired returns true for Grafana server admins and users with the Admin
+// role in any organization when require_for_admins is set.
+func (s *TOTPService) IsRequired(ctx context.Context, user *models.User) (bool, error) {
+	if !s.cfg.TOTPEnabled || !s.cfg.TOTPRequireForAdmins {
+		return false, nil
+	}
+	if user.IsAdmin {
+		return true, nil
+	}
+
+	query := models.GetUserOrgListQuery{UserId: user.Id}
+	if err := s.sqlStore.GetUserOrgList(ctx, &query); err != nil {
+		return false, err
+	}
+	for _, org := range query.Result {
+		if org.Role == models.ROLE_ADMIN {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *TOTPService) Enrol(ctx context.Context, user *models.User) (*totp.Enrolment, error) {
+	enabled, err := s.IsEnabled(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, totp.ErrAlreadyEnrolled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.secrets.Encrypt(ctx, []byte(secret), secrets.WithoutScope())
+	if err != nil {
+		return nil, err
+	}
+
+	created := now()
+	err = s.store.Save(ctx, &totp.UserTOTP{
+		UserId:  user.Id,
+		Secret:  base64.StdEncoding.EncodeToString(encrypted),
+		Created: created,
+		Updated: created,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &totp.Enrolment{
+		Secret: secret,
+		URL:    totp.ProvisioningURL(s.cfg.TOTPIssuer, user.Login, secret),
+	}, nil
+}
+
+func (s *TOTPService) Confirm(ctx context.Context, userID int64, code string) ([]string, error) {
+	userTOTP, err := s.store.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if userTOTP.Enabled {
+		return nil, totp.ErrAlreadyEnrolled
+	}
+
+	step, err := s.validateCode(ctx, userTOTP, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := totp.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	userTOTP.Enabled = true
+	userTOTP.RecoveryCodes = hashes
+	userTOTP.LastUsedStep = step
+	userTOTP.Updated = now()
+	if err := s.store.Save(ctx, userTOTP); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Second factor enrolled", "userId", userID)
+	return codes, nil
+}
+
+func (s *TOTPService) Verify(ctx context.Context, userID int64, code string) error {
+	userTOTP, err := s.store.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !userTOTP.Enabled {
+		return totp.ErrNotEnrolled
+	}
+
+	step, err := s.validateCode(ctx, userTOTP, code)
+	if err == nil {
+		return s.store.UseStep(ctx, userID, step)
+	}
+	if !errors.Is(err, totp.ErrInvalidCode) {
+		return err
+	}
+
+	remaining, ok := totp.UseRecoveryCode(userTOTP.RecoveryCodes, code)
+	if !ok {
+		return totp.ErrInvalidCode
+	}
+	if err := s.store.UseRecoveryCodes(ctx, userID, userTOTP.RecoveryCodes, remaining); err != nil {
+		return err
+	}
+
+	s.log.Info("Recovery code used", "userId", userID)
+	return nil
+}
+
+func (s *TOTPService) RegenerateRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	userTOTP, err := s.store.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !userTOTP.Enabled {
+		return nil, totp.ErrNotEnrolled
+	}
+
+	codes, hashes, err := totp.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.UseRecoveryCodes(ctx, userID, userTOTP.RecoveryCodes, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *TOTPService) Disable(ctx context.Context, userID int64) error {
+	if err := s.store.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	s.log.Info("Second factor removed", "userId", userID)
+	return nil
+}
+
+func (s *TOTPService) validateCode(ctx context.Context, userTOTP *totp.UserTOTP, code string) (int64, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(userTOTP.Secret)
+	if err != nil {
+		return 0, err
+	}
+	secret, err := s.secrets.Decrypt(ctx, encrypted)
+	if err != nil {
+		return 0, err
+	}
+	return totp.ValidateCode(string(secret), code, now())
+}
@@ -0,0 +1,125 @@
+package manager
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit/audittest"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func setupTestService(t *testing.T, cfg *setting.Cfg) (*TOTPService, *sqlstore.SQLStore) {
+	t.Helper()
+	cfg.TOTPEnabled = true
+	cfg.TOTPIssuer = "Grafana"
+	sqlStore := sqlstore.InitTestDB(t)
+	return ProvideService(cfg, sqlStore, fakes.NewFakeSecretsService(), routing.NewRouteRegister(), audittest.NewFakeService()), sqlStore
+}
+
+func setNow(t *testing.T, at time.Time) {
+	t.Helper()
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestTOTPService(t *testing.T) {
+	s, _ := setupTestService(t, setting.NewCfg())
+	ctx := context.Background()
+	user := &models.User{Id: 1, Login: "admin"}
+	at := time.Date(2022, 2, 1, 10, 0, 0, 0, time.UTC)
+	setNow(t, at)
+
+	enrolment, err := s.Enrol(ctx, user)
+	require.NoError(t, err)
+	enrolmentURL, err := url.Parse(enrolment.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", enrolmentURL.Scheme)
+	assert.Equal(t, enrolment.Secret, enrolmentURL.Query().Get("secret"))
+
+	stored, err := s.store.Get(ctx, user.Id)
+	require.NoError(t, err)
+	assert.NotEqual(t, enrolment.Secret, stored.Secret)
+
+	enabled, err := s.IsEnabled(ctx, user.Id)
+	require.NoError(t, err)
+	require.False(t, enabled, "the second factor is enabled once confirmed")
+
+	_, err = s.Confirm(ctx, user.Id, "000000")
+	require.ErrorIs(t, err, totp.ErrInvalidCode)
+
+	code, err := totp.GenerateCode(enrolment.Secret, at)
+	require.NoError(t, err)
+	recoveryCodes, err := s.Confirm(ctx, user.Id, code)
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, 10)
+
+	enabled, err = s.IsEnabled(ctx, user.Id)
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	_, err = s.Enrol(ctx, user)
+	require.ErrorIs(t, err, totp.ErrAlreadyEnrolled)
+
+	t.Run("a code can't be used twice", func(t *testing.T) {
+		require.ErrorIs(t, s.Verify(ctx, user.Id, code), totp.ErrInvalidCode)
+
+		next := at.Add(30 * time.Second)
+		setNow(t, next)
+		code, err := totp.GenerateCode(enrolment.Secret, next)
+		require.NoError(t, err)
+		require.NoError(t, s.Verify(ctx, user.Id, code))
+		require.ErrorIs(t, s.Verify(ctx, user.Id, code), totp.ErrInvalidCode)
+	})
+
+	t.Run("a recovery code can be used once", func(t *testing.T) {
+		require.NoError(t, s.Verify(ctx, user.Id, recoveryCodes[0]))
+		require.ErrorIs(t, s.Verify(ctx, user.Id, recoveryCodes[0]), totp.ErrInvalidCode)
+		require.NoError(t, s.Verify(ctx, user.Id, recoveryCodes[1]))
+	})
+
+	t.Run("regenerated recovery codes replace the previous ones", func(t *testing.T) {
+		codes, err := s.RegenerateRecoveryCodes(ctx, user.Id)
+		require.NoError(t, err)
+		require.ErrorIs(t, s.Verify(ctx, user.Id, recoveryCodes[2]), totp.ErrInvalidCode)
+		require.NoError(t, s.Verify(ctx, user.Id, codes[0]))
+	})
+
+	require.NoError(t, s.Disable(ctx, user.Id))
+	require.ErrorIs(t, s.Verify(ctx, user.Id, recoveryCodes[3]), totp.ErrNotEnrolled)
+}
+
+func TestTOTPService_IsRequired(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.TOTPRequireForAdmins = true
+	s, sqlStore := setupTestService(t, cfg)
+	ctx := context.Background()
+
+	serverAdmin, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: "server-admin", IsAdmin: true})
+	require.NoError(t, err)
+	orgAdmin, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: "org-admin", DefaultOrgRole: string(models.ROLE_ADMIN)})
+	require.NoError(t, err)
+	viewer, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: "viewer", SkipOrgSetup: true})
+	require.NoError(t, err)
+	require.NoError(t, sqlStore.AddOrgUser(ctx, &models.AddOrgUserCommand{OrgId: orgAdmin.OrgId, UserId: viewer.Id, Role: models.ROLE_VIEWER}))
+
+	for user, expected := range map[*models.User]bool{serverAdmin: true, orgAdmin: true, viewer: false} {
+		required, err := s.IsRequired(ctx, user)
+		require.NoError(t, err)
+		assert.Equal(t, expected, required, user.Login)
+	}
+
+	cfg.TOTPRequireForAdmins = false
+	required, err := s.IsRequired(ctx, serverAdmin)
+	require.NoError(t, err)
+	assert.False(t, required)
+}
@@ -0,0 +1,74 @@
+// Package totp implements the time-based one-time password (TOTP) second
+// factor of the built-in login, as specified by RFC 6238.
+package totp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+var (
+	ErrNotEnrolled     = errors.New("user has not enrolled a second factor")
+	ErrAlreadyEnrolled = errors.New("user has already enrolled a second factor")
+	ErrInvalidCode     = errors.New("invalid two-factor authentication code")
+)
+
+type Service interface {
+	// IsEnabled returns true if the user enrolled a second factor.
+	IsEnabled(ctx context.Context, userID int64) (bool, error)
+	// IsRequired returns true if the policy requires the user to sign in
+	// with a second factor.
+	IsRequired(ctx context.Context, user *models.User) (bool, error)
+	// Enrol generates a new secret for a user who hasn't enrolled yet. The
+	// second factor is enabled once the user confirms a code of the secret.
+	Enrol(ctx context.Context, user *models.User) (*Enrolment, error)
+	// Confirm enables the second factor of the user if the code is valid for
+	// the secret of the enrolment, and returns the recovery codes.
+	Confirm(ctx context.Context, userID int64, code string) ([]string, error)
+	// Verify checks a code, or a recovery code which can only be used once.
+	Verify(ctx context.Context, userID int64, code string) error
+	// RegenerateRecoveryCodes replaces the recovery codes of the user.
+	RegenerateRecoveryCodes(ctx context.Context, userID int64) ([]string, error)
+	// Disable removes the second factor of the user.
+	Disable(ctx context.Context, userID int64) error
+}
+
+type Store interface {
+	Get(ctx context.Context, userID int64) (*UserTOTP, error)
+	// Save inserts or replaces the second factor of the user.
+	Save(ctx context.Context, totp *UserTOTP) error
+	Delete(ctx context.Context, userID int64) error
+	// UseStep records the time step of a code, failing with ErrInvalidCode if
+	// a code of the same or a later step was used already.
+	UseStep(ctx context.Context, userID int64, step int64) error
+	// UseRecoveryCodes replaces the recovery codes of the user, failing with
+	// ErrInvalidCode if they are no longer the expected ones.
+	UseRecoveryCodes(ctx context.Context, userID int64, expected, remaining string) error
+}
+
+// UserTOTP is the second factor of a user. The secret is encrypted with the
+// secrets service, the recovery codes are hashed.
+type UserTOTP struct {
+	Id            int64
+	UserId        int64
+	Secret        string
+	RecoveryCodes string
+	Enabled       bool
+	LastUsedStep  int64
+	Created       time.Time
+	Updated       time.Time
+}
+
+func (UserTOTP) TableName() string {
+	return "user_totp"
+}
+
+// Enrolment is the secret of a new second factor, and the otpauth:// URL
+// authenticator apps scan as a QR code.
+type Enrolment struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"`
+}
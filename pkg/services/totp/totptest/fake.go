@@ -0,0 +1,72 @@
+package totptest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/totp"
+)
+
+var _ totp.Service = new(FakeService)
+
+// FakeService accepts ValidCode as the code of users with a second factor.
+type FakeService struct {
+	Enabled       bool
+	Required      bool
+	ValidCode     string
+	Enrolment     *totp.Enrolment
+	RecoveryCodes []string
+	ExpectedError error
+}
+
+func NewFakeService() *FakeService {
+	return &FakeService{}
+}
+
+func (s *FakeService) IsEnabled(_ context.Context, _ int64) (bool, error) {
+	return s.Enabled, s.ExpectedError
+}
+
+func (s *FakeService) IsRequired(_ context.Context, _ *models.User) (bool, error) {
+	return s.Required, s.ExpectedError
+}
+
+func (s *FakeService) Enrol(_ context.Context, _ *models.User) (*totp.Enrolment, error) {
+	if s.Enabled {
+		return nil, totp.ErrAlreadyEnrolled
+	}
+	return s.Enrolment, s.ExpectedError
+}
+
+func (s *FakeService) Confirm(_ context.Context, _ int64, code string) ([]string, error) {
+	if s.Enabled {
+		return nil, totp.ErrAlreadyEnrolled
+	}
+	if code != s.ValidCode {
+		return nil, totp.ErrInvalidCode
+	}
+	s.Enabled = true
+	return s.RecoveryCodes, s.ExpectedError
+}
+
+func (s *FakeService) Verify(_ context.Context, _ int64, code string) error {
+	if !s.Enabled {
+		return totp.ErrNotEnrolled
+	}
+	if code != s.ValidCode {
+		return totp.ErrInvalidCode
+	}
+	return s.ExpectedError
+}
+
+func (s *FakeService) RegenerateRecoveryCodes(_ context.Context, _ int64) ([]string, error) {
+	return s.RecoveryCodes, s.ExpectedError
+}
+
+func (s *FakeService) Disable(_ context.Context, _ int64) error {
+	if !s.Enabled {
+		return totp.ErrNotEnrolled
+	}
+	s.Enabled = false
+	return s.ExpectedError
+}
@@ -231,6 +231,12 @@ type Cfg struct {
 	// SMTP email settings
 	Smtp SmtpSettings
 
+	// Audit log settings
+	Audit AuditSettings
+
+	// Rate limiting of HTTP requests
+	RateLimiting RateLimitingSettings
+
 	// Rendering
 	ImagesDir                      string
 	CSVsDir                        string
@@ -283,6 +289,7 @@ type Cfg struct {
 	LoginMaxInactiveLifetime     time.Duration
 	LoginMaxLifetime             time.Duration
 	TokenRotationIntervalMinutes int
+	LoginMaxConcurrentSessions   int
 	SigV4AuthEnabled             bool
 	SigV4VerboseLogging          bool
 	BasicAuthEnabled             bool
@@ -307,6 +314,19 @@ type Cfg struct {
 	AuthProxyHeaders          map[string]string
 	AuthProxySyncTTL          int
 
+	// Auth proxy header verification
+	AuthProxyHeaderVerification string
+	AuthProxySignatureHeader    string
+	AuthProxySignatureSecrets   []string
+	AuthProxySignatureMaxAge    time.Duration
+	AuthProxyJWTHeader          string
+	AuthProxyJWTIdentityClaim   string
+	AuthProxyJWTExpectClaims    string
+	AuthProxyJWTJWKSetURL       string
+	AuthProxyJWTJWKSetFile      string
+	AuthProxyJWTKeyFile         string
+	AuthProxyJWTCacheTTL        time.Duration
+
 	// OAuth
 	OAuthCookieMaxAge int
 
@@ -322,6 +342,20 @@ type Cfg struct {
 	JWTAuthJWKSetFile    string
 	JWTAuthAutoSignUp    bool
 
+	// JWT service account authentication
+	JWTAuthServiceAccountsEnabled bool
+	JWTAuthServiceAccountClaim    string
+	JWTAuthServiceAccountAudience []string
+	JWTAuthServiceAccountScopes   []string
+
+	// SCIM provisioning
+	SCIMEnabled bool
+
+	// TOTP second factor
+	TOTPEnabled          bool
+	TOTPRequireForAdmins bool
+	TOTPIssuer           string
+
 	// Dataproxy
 	SendUserHeader                 bool
 	DataProxyLogging               bool
@@ -335,6 +369,7 @@ type Cfg struct {
 	DataProxyIdleConnTimeout       int
 	ResponseLimit                  int64
 	DataProxyRowLimit              int64
+	DataProxyBytesLimit            int64
 
 	// DistributedCache
 	RemoteCacheOptions *RemoteCacheOptions
@@ -342,6 +377,10 @@ type Cfg struct {
 	EditorsCanAdmin bool
 
 	ApiKeyMaxSecondsToLive int64
+	// ServiceAccountTokenExpiryWarning is how long before a service account
+	// token expires its organization admins are warned, zero disables the
+	// warnings.
+	ServiceAccountTokenExpiryWarning time.Duration
 
 	// Check if a feature toggle is enabled
 	// @deprecated
@@ -385,8 +424,10 @@ type Cfg struct {
 	ApplicationInsightsEndpointUrl      string
 
 	// LDAP
-	LDAPEnabled     bool
-	LDAPAllowSignup bool
+	LDAPEnabled           bool
+	LDAPAllowSignup       bool
+	LDAPSyncCron          string
+	LDAPActiveSyncEnabled bool
 
 	Quota QuotaSettings
 
@@ -414,6 +455,15 @@ type Cfg struct {
 	// LiveAllowedOrigins is a set of origins accepted by Live. If not provided
 	// then Live uses AppURL as the only allowed origin.
 	LiveAllowedOrigins []string
+	// LivePipelineStorage is where Live pipeline channel rules and write configs
+	// are stored, "file" or "database".
+	LivePipelineStorage string
+	// LiveManagedStreamHistorySize is the number of frames kept per managed
+	// stream channel for subscribers to request on join. 0 disables history.
+	LiveManagedStreamHistorySize int
+	// LiveManagedStreamHistoryMaxAge is how long frames are kept in managed
+	// stream history, 0 means until they are dropped by the size.
+	LiveManagedStreamHistoryMaxAge time.Duration
 
 	// Grafana.com URL
 	GrafanaComURL string
@@ -974,6 +1024,12 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	cfg.readAzureSettings()
 	cfg.readSessionConfig()
 	cfg.readSmtpSettings()
+	if err := cfg.readAuditSettings(); err != nil {
+		return err
+	}
+	if err := cfg.readRateLimitingSettings(); err != nil {
+		return err
+	}
 	cfg.readQuotaSettings()
 	cfg.readAnnotationSettings()
 	cfg.readExpressionsSettings()
@@ -1048,9 +1104,13 @@ func (cfg *Cfg) readLDAPConfig() {
 	ldapSec := cfg.Raw.Section("auth.ldap")
 	LDAPConfigFile = ldapSec.Key("config_file").String()
 	LDAPSyncCron = ldapSec.Key("sync_cron").String()
+	cfg.LDAPSyncCron = LDAPSyncCron
 	LDAPEnabled = ldapSec.Key("enabled").MustBool(false)
 	cfg.LDAPEnabled = LDAPEnabled
-	LDAPActiveSyncEnabled = ldapSec.Key("active_sync_enabled").MustBool(false)
+	// The background sync disables users, it's only on by default in Grafana Enterprise where it
+	// always was.
+	LDAPActiveSyncEnabled = ldapSec.Key("active_sync_enabled").MustBool(cfg.IsEnterprise)
+	cfg.LDAPActiveSyncEnabled = LDAPActiveSyncEnabled
 	LDAPAllowSignup = ldapSec.Key("allow_sign_up").MustBool(true)
 	cfg.LDAPAllowSignup = LDAPAllowSignup
 }
@@ -1245,11 +1305,16 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	}
 
 	cfg.ApiKeyMaxSecondsToLive = auth.Key("api_key_max_seconds_to_live").MustInt64(-1)
+	cfg.ServiceAccountTokenExpiryWarning, err = gtime.ParseDuration(valueAsString(auth, "service_account_token_expiry_warning", "7d"))
+	if err != nil {
+		return err
+	}
 
 	cfg.TokenRotationIntervalMinutes = auth.Key("token_rotation_interval_minutes").MustInt(10)
 	if cfg.TokenRotationIntervalMinutes < 2 {
 		cfg.TokenRotationIntervalMinutes = 2
 	}
+	cfg.LoginMaxConcurrentSessions = auth.Key("login_maximum_concurrent_sessions").MustInt(0)
 
 	DisableLoginForm = auth.Key("disable_login_form").MustBool(false)
 	DisableSignoutMenu = auth.Key("disable_signout_menu").MustBool(false)
@@ -1286,6 +1351,19 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	cfg.JWTAuthKeyFile = valueAsString(authJWT, "key_file", "")
 	cfg.JWTAuthJWKSetFile = valueAsString(authJWT, "jwk_set_file", "")
 	cfg.JWTAuthAutoSignUp = authJWT.Key("auto_sign_up").MustBool(false)
+	cfg.JWTAuthServiceAccountsEnabled = authJWT.Key("service_accounts_enabled").MustBool(false)
+	cfg.JWTAuthServiceAccountClaim = valueAsString(authJWT, "service_account_claim", "sub")
+	cfg.JWTAuthServiceAccountAudience = util.SplitString(valueAsString(authJWT, "service_account_audience", ""))
+	cfg.JWTAuthServiceAccountScopes = util.SplitString(valueAsString(authJWT, "service_account_scopes", ""))
+
+	// SCIM provisioning
+	cfg.SCIMEnabled = iniFile.Section("auth.scim").Key("enabled").MustBool(false)
+
+	// TOTP second factor
+	authTOTP := iniFile.Section("auth.totp")
+	cfg.TOTPEnabled = authTOTP.Key("enabled").MustBool(false)
+	cfg.TOTPRequireForAdmins = authTOTP.Key("require_for_admins").MustBool(false)
+	cfg.TOTPIssuer = valueAsString(authTOTP, "issuer", "Grafana")
 
 	authProxy := iniFile.Section("auth.proxy")
 	AuthProxyEnabled = authProxy.Key("enabled").MustBool(false)
@@ -1319,6 +1397,28 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 		}
 	}
 
+	cfg.AuthProxyHeaderVerification = valueAsString(authProxy, "header_verification", "")
+	cfg.AuthProxySignatureHeader = valueAsString(authProxy, "signature_header", "X-WEBAUTH-SIGNATURE")
+	cfg.AuthProxySignatureSecrets = util.SplitString(valueAsString(authProxy, "signature_secrets", ""))
+	cfg.AuthProxySignatureMaxAge = authProxy.Key("signature_max_age").MustDuration(5 * time.Minute)
+	cfg.AuthProxyJWTHeader = valueAsString(authProxy, "jwt_header", "X-WEBAUTH-JWT")
+	cfg.AuthProxyJWTIdentityClaim = valueAsString(authProxy, "jwt_identity_claim", "sub")
+	cfg.AuthProxyJWTExpectClaims = valueAsString(authProxy, "jwt_expect_claims", "{}")
+	cfg.AuthProxyJWTJWKSetURL = valueAsString(authProxy, "jwt_jwk_set_url", "")
+	cfg.AuthProxyJWTJWKSetFile = valueAsString(authProxy, "jwt_jwk_set_file", "")
+	cfg.AuthProxyJWTKeyFile = valueAsString(authProxy, "jwt_key_file", "")
+	cfg.AuthProxyJWTCacheTTL = authProxy.Key("jwt_cache_ttl").MustDuration(time.Minute * 60)
+
+	switch cfg.AuthProxyHeaderVerification {
+	case "", "jwt":
+	case "signature":
+		if len(cfg.AuthProxySignatureSecrets) == 0 {
+			return fmt.Errorf("[auth.proxy] header_verification is signature, but signature_secrets is empty")
+		}
+	default:
+		return fmt.Errorf("[auth.proxy] header_verification %q is invalid, valid values are signature and jwt", cfg.AuthProxyHeaderVerification)
+	}
+
 	return nil
 }
 
@@ -1533,6 +1633,21 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 		return fmt.Errorf("unsupported live HA engine type: %s", cfg.LiveHAEngine)
 	}
 	cfg.LiveHAEngineAddress = section.Key("ha_engine_address").MustString("127.0.0.1:6379")
+	cfg.LivePipelineStorage = section.Key("pipeline_storage").MustString("file")
+	switch cfg.LivePipelineStorage {
+	case "file", "database":
+	default:
+		return fmt.Errorf("unsupported live pipeline storage type: %s", cfg.LivePipelineStorage)
+	}
+	cfg.LiveManagedStreamHistorySize = section.Key("managed_stream_history_size").MustInt(0)
+	if cfg.LiveManagedStreamHistorySize < 0 {
+		return fmt.Errorf("unexpected value %d for [live] managed_stream_history_size", cfg.LiveManagedStreamHistorySize)
+	}
+	historyMaxAge, err := gtime.ParseDuration(section.Key("managed_stream_history_max_age").MustString("1h"))
+	if err != nil {
+		return fmt.Errorf("invalid value for [live] managed_stream_history_max_age: %w", err)
+	}
+	cfg.LiveManagedStreamHistoryMaxAge = historyMaxAge
 
 	var originPatterns []string
 	allowedOrigins := section.Key("allowed_origins").MustString("")
@@ -1543,7 +1658,7 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 		}
 		originPatterns = append(originPatterns, originPattern)
 	}
-	_, err := GetAllowedOriginGlobs(originPatterns)
+	_, err = GetAllowedOriginGlobs(originPatterns)
 	if err != nil {
 		return err
 	}
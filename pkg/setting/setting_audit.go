@@ -0,0 +1,37 @@
+package setting
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+type AuditSettings struct {
+	Enabled bool
+	// LogPath is the file audit entries are written to as JSON lines, in
+	// addition to the database. Empty disables the file.
+	LogPath    string
+	LogMaxDays int64
+	// Retention is how long audit entries are kept in the database. Zero
+	// keeps them forever.
+	Retention time.Duration
+}
+
+func (cfg *Cfg) readAuditSettings() error {
+	sec := cfg.Raw.Section("audit")
+	cfg.Audit.Enabled = sec.Key("enabled").MustBool(false)
+	cfg.Audit.LogPath = sec.Key("log_path").String()
+	if cfg.Audit.LogPath != "" && !filepath.IsAbs(cfg.Audit.LogPath) {
+		cfg.Audit.LogPath = filepath.Join(cfg.LogsPath, cfg.Audit.LogPath)
+	}
+	cfg.Audit.LogMaxDays = sec.Key("log_max_days").MustInt64(7)
+
+	retention, err := gtime.ParseDuration(valueAsString(sec, "retention", "365d"))
+	if err != nil {
+		return err
+	}
+	cfg.Audit.Retention = retention
+
+	return nil
+}
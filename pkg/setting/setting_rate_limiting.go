@@ -0,0 +1,63 @@
+package setting
+
+import (
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+// RateLimitGroups are the route groups that can be rate limited, each one
+// configured in a [rate_limiting.<group>] section.
+var RateLimitGroups = []string{"api", "query", "render", "login"}
+
+type RateLimitingSettings struct {
+	Enabled bool
+	// Window is the period requests are counted over.
+	Window time.Duration
+	// UseRemoteCache shares the counters between Grafana instances through
+	// the remote cache instead of keeping them in memory.
+	UseRemoteCache bool
+	// Limits are the request limits per window by route group.
+	Limits map[string]RateLimit
+}
+
+// RateLimit is the maximum number of requests in a window. Zero means
+// unlimited.
+type RateLimit struct {
+	// User applies to each user, API key or service account, or to each
+	// client IP for unauthenticated requests.
+	User int64
+	// Org applies to all the requests of an organization.
+	Org int64
+}
+
+var defaultRateLimits = map[string]RateLimit{
+	"api":    {},
+	"query":  {User: 600},
+	"render": {User: 60},
+	"login":  {User: 20},
+}
+
+func (cfg *Cfg) readRateLimitingSettings() error {
+	sec := cfg.Raw.Section("rate_limiting")
+	cfg.RateLimiting.Enabled = sec.Key("enabled").MustBool(false)
+	cfg.RateLimiting.UseRemoteCache = sec.Key("use_remote_cache").MustBool(false)
+
+	window, err := gtime.ParseDuration(valueAsString(sec, "window", "1m"))
+	if err != nil {
+		return err
+	}
+	cfg.RateLimiting.Window = window
+
+	cfg.RateLimiting.Limits = make(map[string]RateLimit, len(RateLimitGroups))
+	for _, group := range RateLimitGroups {
+		groupSec := cfg.Raw.Section("rate_limiting." + group)
+		defaults := defaultRateLimits[group]
+		cfg.RateLimiting.Limits[group] = RateLimit{
+			User: groupSec.Key("user_limit").MustInt64(defaults.User),
+			Org:  groupSec.Key("org_limit").MustInt64(defaults.Org),
+		}
+	}
+
+	return nil
+}
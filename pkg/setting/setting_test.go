@@ -391,6 +391,30 @@ func TestAuthDurationSettings(t *testing.T) {
 	require.Equal(t, maxLifetimeDurationTest, cfg.LoginMaxLifetime)
 }
 
+func TestLDAPActiveSyncSetting(t *testing.T) {
+	f := ini.Empty()
+	sec, err := f.NewSection("auth.ldap")
+	require.NoError(t, err)
+	_, err = sec.NewKey("active_sync_enabled", "")
+	require.NoError(t, err)
+
+	cfg := NewCfg()
+	cfg.Raw = f
+	cfg.readLDAPConfig()
+	require.False(t, cfg.LDAPActiveSyncEnabled)
+
+	cfg = NewCfg()
+	cfg.Raw = f
+	cfg.IsEnterprise = true
+	sec.Key("active_sync_enabled").SetValue("")
+	cfg.readLDAPConfig()
+	require.True(t, cfg.LDAPActiveSyncEnabled)
+
+	sec.Key("active_sync_enabled").SetValue("false")
+	cfg.readLDAPConfig()
+	require.False(t, cfg.LDAPActiveSyncEnabled)
+}
+
 func TestGetCDNPath(t *testing.T) {
 	var err error
 	cfg := NewCfg()
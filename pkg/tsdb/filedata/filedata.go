@@ -0,0 +1,283 @@
+package filedata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const pluginID = "filedata"
+
+// maxContentSize limits the size of the files and HTTP responses that are read.
+const maxContentSize = 64 << 20
+
+const (
+	sourceLocal = "local"
+	sourceHTTP  = "http"
+)
+
+var (
+	_      backend.QueryDataHandler   = (*Service)(nil)
+	_      backend.CheckHealthHandler = (*Service)(nil)
+	logger                            = log.New("tsdb.filedata")
+)
+
+type Service struct {
+	im  instancemgmt.InstanceManager
+	cfg *setting.Cfg
+}
+
+func ProvideService(cfg *setting.Cfg, httpClientProvider httpclient.Provider) *Service {
+	return &Service{
+		im:  datasource.NewInstanceManager(newInstanceSettings(cfg, httpClientProvider)),
+		cfg: cfg,
+	}
+}
+
+type dataSourceJSONData struct {
+	// Source is where the data is read from, local files or an HTTP endpoint.
+	Source string `json:"source"`
+	// Path is the directory of the local files.
+	Path string `json:"path"`
+}
+
+type dataSourceInfo struct {
+	source     string
+	dir        string
+	url        *url.URL
+	httpClient *http.Client
+}
+
+func newInstanceSettings(cfg *setting.Cfg, httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
+	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		jsonData := dataSourceJSONData{}
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &jsonData); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
+		switch jsonData.Source {
+		case sourceLocal:
+			dir, err := checkDir(cfg, jsonData.Path)
+			if err != nil {
+				return nil, err
+			}
+			return &dataSourceInfo{source: sourceLocal, dir: dir}, nil
+		case sourceHTTP, "":
+			u, err := url.Parse(settings.URL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid URL %q: %w", settings.URL, err)
+			}
+			if u.Scheme != "http" && u.Scheme != "https" {
+				return nil, fmt.Errorf("invalid URL %q: the scheme must be http or https", settings.URL)
+			}
+
+			opts, err := settings.HTTPClientOptions()
+			if err != nil {
+				return nil, err
+			}
+			client, err := httpClientProvider.New(opts)
+			if err != nil {
+				return nil, err
+			}
+			return &dataSourceInfo{source: sourceHTTP, url: u, httpClient: client}, nil
+		default:
+			return nil, fmt.Errorf("unknown source %q", jsonData.Source)
+		}
+	}
+}
+
+// checkDir returns the cleaned directory of the local files, which has to be in one of the
+// directories in the allowed_paths setting of the [plugin.filedata] section.
+func checkDir(cfg *setting.Cfg, dir string) (string, error) {
+	allowed := allowedPaths(cfg)
+	if len(allowed) == 0 {
+		return "", errors.New("local files are disabled, set allowed_paths in the [plugin.filedata] section of the Grafana configuration to enable them")
+	}
+	if dir == "" {
+		return "", errors.New("missing directory")
+	}
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("directory %q is not absolute", dir)
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("directory %q can't be opened: %w", dir, err)
+	}
+	for _, allowedDir := range allowed {
+		if isInDir(resolved, allowedDir) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("directory %q is not in one of the allowed paths", dir)
+}
+
+func allowedPaths(cfg *setting.Cfg) []string {
+	var paths []string
+	for _, p := range strings.Split(cfg.PluginSettings[pluginID]["allowed_paths"], ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// isInDir reports whether path is dir or a file below dir.
+func isInDir(path string, dir string) bool {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	rel, err := filepath.Rel(filepath.Clean(dir), path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+func (s *Service) getDSInfo(pluginCtx backend.PluginContext) (*dataSourceInfo, error) {
+	i, err := s.im.Get(pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+	instance := i.(*dataSourceInfo)
+	return instance, nil
+}
+
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	response := backend.NewQueryDataResponse()
+	for _, q := range req.Queries {
+		response.Responses[q.RefID] = s.query(ctx, dsInfo, q)
+	}
+	return response, nil
+}
+
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: err.Error(),
+		}, nil
+	}
+
+	if dsInfo.source == sourceLocal {
+		if _, err := os.ReadDir(dsInfo.dir); err != nil {
+			return &backend.CheckHealthResult{
+				Status:  backend.HealthStatusError,
+				Message: fmt.Sprintf("Directory can't be read: %v", err),
+			}, nil
+		}
+		return &backend.CheckHealthResult{Status: backend.HealthStatusOk, Message: "Directory can be read"}, nil
+	}
+
+	body, err := dsInfo.readURL(ctx, "")
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: err.Error(),
+		}, nil
+	}
+	if err := body.Close(); err != nil {
+		logger.Warn("Failed to close response body", "err", err)
+	}
+	return &backend.CheckHealthResult{Status: backend.HealthStatusOk, Message: "URL can be read"}, nil
+}
+
+// open opens the file or URL path, which is relative to the directory or URL of the data source.
+func (ds *dataSourceInfo) open(ctx context.Context, path string) (io.ReadCloser, error) {
+	if ds.source == sourceLocal {
+		return ds.openFile(path)
+	}
+	return ds.readURL(ctx, path)
+}
+
+func (ds *dataSourceInfo) openFile(name string) (io.ReadCloser, error) {
+	if name == "" {
+		return nil, errors.New("missing file name")
+	}
+	path := filepath.Join(ds.dir, filepath.Clean(filepath.Join("/", name)))
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, fmt.Errorf("file %q can't be opened", name)
+	}
+	if !isInDir(resolved, ds.dir) {
+		return nil, fmt.Errorf("file %q is not in the data source directory", name)
+	}
+
+	// The path is checked to be in the data source directory above.
+	// nolint:gosec
+	f, err := os.Open(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("file %q can't be opened", name)
+	}
+	return f, nil
+}
+
+func (ds *dataSourceInfo) readURL(ctx context.Context, name string) (io.ReadCloser, error) {
+	ref, err := url.Parse(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %w", name, err)
+	}
+	if ref.Scheme != "" || ref.Host != "" {
+		return nil, fmt.Errorf("invalid path %q: the path must be relative to the data source URL", name)
+	}
+
+	u := *ds.url
+	if ref.Path != "" {
+		// The requests carry the credentials of the data source, so they must stay below its URL.
+		base := strings.TrimSuffix(path.Clean("/"+u.Path), "/")
+		joined := path.Clean(base + "/" + ref.Path)
+		if joined != base && !strings.HasPrefix(joined, base+"/") {
+			return nil, fmt.Errorf("invalid path %q: the path must be below the data source URL", name)
+		}
+		if strings.HasSuffix(ref.Path, "/") && joined != "/" {
+			joined += "/"
+		}
+		u.Path = joined
+		u.RawPath = ""
+	}
+	if ref.RawQuery != "" {
+		query := u.Query()
+		for k, v := range ref.Query() {
+			query[k] = v
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := ds.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode/100 != 2 {
+		if err := res.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "err", err)
+		}
+		return nil, fmt.Errorf("request failed with status %s", res.Status)
+	}
+	return res.Body, nil
+}
@@ -0,0 +1,253 @@
+package filedata
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+var testFiles = map[string]string{
+	"metrics.csv": "time,host,cpu,up\n" +
+		"2021-06-01T00:10:00Z,a,2.5,true\n" +
+		"2021-06-01T00:00:00Z,a,0.5,false\n" +
+		"2021-06-01T01:00:00Z,b,1,true\n",
+	"metrics.json": `{"data": {"items": [
+		{"ts": 1622506200000, "value": {"cpu": 2.5}, "tags": ["a"]},
+		{"ts": 1622505600, "value": {"cpu": 0.5}, "tags": ["b"]}
+	]}}`,
+	"metrics.ndjson": `{"time": "2021-06-01 00:00:00", "count": 1}` + "\n\n" +
+		`{"time": "2021-06-01 00:05:00", "count": "2"}` + "\n",
+}
+
+func createTestDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range testFiles {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	return dir
+}
+
+func newTestService(t *testing.T, allowedPaths string) *Service {
+	cfg := setting.NewCfg()
+	cfg.DataProxyRowLimit = 1000
+	cfg.PluginSettings = setting.PluginSettings{pluginID: {"allowed_paths": allowedPaths}}
+	return ProvideService(cfg, httpclient.NewProvider())
+}
+
+func queryData(t *testing.T, s *Service, settings backend.DataSourceInstanceSettings, query string) backend.DataResponse {
+	t.Helper()
+
+	from := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	resp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &settings},
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				JSON:      []byte(query),
+				TimeRange: backend.TimeRange{From: from, To: from.Add(30 * time.Minute)},
+			},
+		},
+	})
+	require.NoError(t, err)
+	return resp.Responses["A"]
+}
+
+func localSettings(dir string) backend.DataSourceInstanceSettings {
+	return backend.DataSourceInstanceSettings{
+		ID:       1,
+		JSONData: []byte(fmt.Sprintf(`{"source": "local", "path": %q}`, dir)),
+	}
+}
+
+func TestLocalFiles(t *testing.T) {
+	dir := createTestDir(t)
+	s := newTestService(t, dir)
+	settings := localSettings(dir)
+
+	t.Run("CSV columns are inferred and sorted by time", func(t *testing.T) {
+		res := queryData(t, s, settings, `{"path": "metrics.csv", "timeField": "time"}`)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+
+		frame := res.Frames[0]
+		require.Equal(t, 3, frame.Rows())
+		require.Equal(t, "A", frame.RefID)
+		require.Equal(t, []string{"time", "host", "cpu", "up"}, []string{frame.Fields[0].Name, frame.Fields[1].Name, frame.Fields[2].Name, frame.Fields[3].Name})
+		require.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), *frame.Fields[0].At(0).(*time.Time))
+		require.Equal(t, "a", *frame.Fields[1].At(0).(*string))
+		require.Equal(t, 0.5, *frame.Fields[2].At(0).(*float64))
+		require.Equal(t, false, *frame.Fields[3].At(0).(*bool))
+	})
+
+	t.Run("Rows are filtered by the time range", func(t *testing.T) {
+		res := queryData(t, s, settings, `{"path": "metrics.csv", "timeField": "time", "filterByTime": true}`)
+		require.NoError(t, res.Error)
+		require.Equal(t, 2, res.Frames[0].Rows())
+	})
+
+	t.Run("JSON fields are extracted by JSONPath", func(t *testing.T) {
+		res := queryData(t, s, settings, `{
+			"path": "metrics.json",
+			"rootPath": "$.data.items",
+			"fields": [
+				{"name": "time", "jsonPath": "$.ts"},
+				{"name": "cpu", "jsonPath": "$.value.cpu"},
+				{"name": "tags", "jsonPath": "$.tags", "type": "string"}
+			],
+			"timeField": "time"
+		}`)
+		require.NoError(t, res.Error)
+
+		frame := res.Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		// Timestamps in seconds and milliseconds are both supported.
+		require.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), *frame.Fields[0].At(0).(*time.Time))
+		require.Equal(t, time.Date(2021, 6, 1, 0, 10, 0, 0, time.UTC), *frame.Fields[0].At(1).(*time.Time))
+		require.Equal(t, 0.5, *frame.Fields[1].At(0).(*float64))
+		require.Equal(t, `["b"]`, *frame.Fields[2].At(0).(*string))
+	})
+
+	t.Run("NDJSON strings are not parsed as numbers", func(t *testing.T) {
+		res := queryData(t, s, settings, `{"path": "metrics.ndjson"}`)
+		require.NoError(t, res.Error)
+
+		frame := res.Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, "count", frame.Fields[0].Name)
+		require.Equal(t, "2", *frame.Fields[0].At(1).(*string))
+
+		res = queryData(t, s, settings, `{"path": "metrics.ndjson", "fields": [{"name": "count", "type": "number"}]}`)
+		require.NoError(t, res.Error)
+		require.Equal(t, 2.0, *res.Frames[0].Fields[0].At(1).(*float64))
+	})
+
+	t.Run("Rows are limited", func(t *testing.T) {
+		s := newTestService(t, dir)
+		s.cfg.DataProxyRowLimit = 1
+		res := queryData(t, s, settings, `{"path": "metrics.csv"}`)
+		require.NoError(t, res.Error)
+		require.Equal(t, 1, res.Frames[0].Rows())
+		require.Len(t, res.Frames[0].Meta.Notices, 1)
+	})
+
+	t.Run("Files outside the directory are rejected", func(t *testing.T) {
+		outside := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.csv"), []byte("a\n1\n"), 0600))
+		require.NoError(t, os.Symlink(filepath.Join(outside, "secret.csv"), filepath.Join(dir, "link.csv")))
+
+		for _, path := range []string{"../secret.csv", "link.csv", filepath.Join(outside, "secret.csv")} {
+			res := queryData(t, s, settings, fmt.Sprintf(`{"path": %q}`, path))
+			require.Error(t, res.Error, path)
+		}
+	})
+
+	t.Run("Local files are disabled without allowed paths", func(t *testing.T) {
+		s := newTestService(t, "")
+		_, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &settings},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("Directories outside the allowed paths are rejected", func(t *testing.T) {
+		s := newTestService(t, t.TempDir())
+		_, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &settings},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/metrics.json":
+			require.Equal(t, "bar", r.URL.Query().Get("foo"))
+			_, _ = w.Write([]byte(testFiles["metrics.json"]))
+		case "/api":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	s := newTestService(t, "")
+	settings := backend.DataSourceInstanceSettings{
+		ID:       1,
+		URL:      server.URL + "/api",
+		JSONData: []byte(`{"source": "http"}`),
+	}
+
+	t.Run("Content is read relative to the URL", func(t *testing.T) {
+		res := queryData(t, s, settings, `{"path": "metrics.json?foo=bar", "rootPath": "$.data.items[*].value"}`)
+		require.NoError(t, res.Error)
+		require.Equal(t, 2, res.Frames[0].Rows())
+		require.Equal(t, "cpu", res.Frames[0].Fields[0].Name)
+		require.Equal(t, 2.5, *res.Frames[0].Fields[0].At(0).(*float64))
+	})
+
+	t.Run("Errors are returned", func(t *testing.T) {
+		res := queryData(t, s, settings, `{"path": "missing.json"}`)
+		require.Error(t, res.Error)
+	})
+
+	t.Run("Absolute URLs are rejected", func(t *testing.T) {
+		res := queryData(t, s, settings, `{"path": "http://169.254.169.254/latest/meta-data"}`)
+		require.Error(t, res.Error)
+		res = queryData(t, s, settings, `{"path": "//169.254.169.254/latest/meta-data"}`)
+		require.Error(t, res.Error)
+	})
+
+	t.Run("Paths outside of the URL are rejected", func(t *testing.T) {
+		for _, path := range []string{"../metrics.json", "/../metrics.json", "sub/../../metrics.json", "%2e%2e/metrics.json", "../api-other/metrics.json"} {
+			res := queryData(t, s, settings, fmt.Sprintf(`{"path": %q}`, path))
+			require.Error(t, res.Error, path)
+			require.Contains(t, res.Error.Error(), "below the data source URL", path)
+		}
+
+		res := queryData(t, s, settings, `{"path": "sub/../metrics.json?foo=bar", "rootPath": "$.data.items[*].value"}`)
+		require.NoError(t, res.Error)
+	})
+
+	t.Run("Health check requests the URL", func(t *testing.T) {
+		res, err := s.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &settings},
+		})
+		require.NoError(t, err)
+		require.Equal(t, backend.HealthStatusOk, res.Status)
+	})
+}
+
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC)
+	for _, v := range []interface{}{
+		"2021-06-01T12:30:00Z",
+		"2021-06-01T14:30:00+02:00",
+		"2021-06-01 12:30:00",
+		"2021-06-01 12:30",
+		"1622550600",
+		int64(1622550600),
+		int64(1622550600000),
+		1622550600.0,
+	} {
+		parsed, err := parseTime(v)
+		require.NoError(t, err, v)
+		require.True(t, expected.Equal(*parsed), v)
+	}
+
+	_, err := parseTime("yesterday")
+	require.Error(t, err)
+}
@@ -0,0 +1,585 @@
+package filedata
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/ohler55/ojg/jp"
+	"github.com/ohler55/ojg/oj"
+)
+
+const (
+	formatCSV    = "csv"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+)
+
+const (
+	typeAuto    = "auto"
+	typeString  = "string"
+	typeNumber  = "number"
+	typeBoolean = "boolean"
+	typeTime    = "time"
+)
+
+type queryModel struct {
+	// Path is the file name relative to the data source directory, or the path and query
+	// string relative to the data source URL.
+	Path string `json:"path"`
+	// Format is csv, json or ndjson. It defaults to the extension of the path.
+	Format string `json:"format"`
+	// RootPath is a JSONPath selecting the records in JSON content.
+	RootPath string `json:"rootPath"`
+	// Fields are the fields of the frame. All fields of the records are used if empty.
+	Fields []fieldModel `json:"fields"`
+	// TimeField is the name of the time field. The rows are sorted by it.
+	TimeField string `json:"timeField"`
+	// FilterByTime drops the rows that aren't in the time range of the query.
+	FilterByTime bool `json:"filterByTime"`
+}
+
+type fieldModel struct {
+	Name string `json:"name"`
+	// JSONPath selects the value of the field in a record, by default the value named Name.
+	JSONPath string `json:"jsonPath"`
+	// Type is auto, string, number, boolean or time.
+	Type string `json:"type"`
+}
+
+// field is a field of the query with its parsed JSONPath.
+type field struct {
+	name      string
+	fieldType string
+	path      jp.Expr
+}
+
+func (s *Service) query(ctx context.Context, dsInfo *dataSourceInfo, q backend.DataQuery) backend.DataResponse {
+	model := queryModel{}
+	if err := json.Unmarshal(q.JSON, &model); err != nil {
+		return backend.DataResponse{Error: fmt.Errorf("failed to parse query: %w", err)}
+	}
+	if model.Path == "" {
+		return backend.DataResponse{}
+	}
+
+	body, err := dsInfo.open(ctx, model.Path)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+	defer func() {
+		if err := body.Close(); err != nil {
+			logger.Warn("Failed to close content", "path", model.Path, "err", err)
+		}
+	}()
+
+	content, err := io.ReadAll(io.LimitReader(body, maxContentSize+1))
+	if err != nil {
+		return backend.DataResponse{Error: fmt.Errorf("failed to read %q: %w", model.Path, err)}
+	}
+	if len(content) > maxContentSize {
+		return backend.DataResponse{Error: fmt.Errorf("%q is larger than %d bytes", model.Path, maxContentSize)}
+	}
+
+	frame, err := s.toFrame(model, content, q.TimeRange)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+	frame.RefID = q.RefID
+	return backend.DataResponse{Frames: data.Frames{frame}}
+}
+
+// toFrame parses the content and returns the frame with the fields of the query.
+func (s *Service) toFrame(model queryModel, content []byte, timeRange backend.TimeRange) (*data.Frame, error) {
+	format := model.Format
+	if format == "" {
+		format = formatFromPath(model.Path)
+	}
+
+	var records []interface{}
+	var columns []string
+	var err error
+	switch format {
+	case formatCSV:
+		records, columns, err = parseCSV(content)
+	case formatJSON:
+		records, err = parseJSON(content, model.RootPath)
+	case formatNDJSON:
+		records, err = parseNDJSON(content, model.RootPath)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := queryFields(model, records, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]interface{}, 0, len(records))
+	for _, record := range records {
+		row := make([]interface{}, len(fields))
+		for i, f := range fields {
+			row[i] = f.value(record)
+		}
+		rows = append(rows, row)
+	}
+
+	if model.TimeField != "" {
+		rows, err = sortByTime(rows, fields, model.TimeField, model.FilterByTime, timeRange)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	frame := data.NewFrame("")
+	if limit := s.cfg.DataProxyRowLimit; limit > 0 && int64(len(rows)) > limit {
+		rows = rows[:limit]
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Results have been limited to %v because the row limit was reached", limit),
+		})
+	}
+
+	// CSV values are always strings, JSON values already have a type.
+	parseStrings := format == formatCSV
+	for i, f := range fields {
+		values := make([]interface{}, len(rows))
+		for j, row := range rows {
+			values[j] = row[i]
+		}
+		dataField, err := toField(f, values, parseStrings)
+		if err != nil {
+			return nil, err
+		}
+		frame.Fields = append(frame.Fields, dataField)
+	}
+	return frame, nil
+}
+
+func formatFromPath(p string) string {
+	if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p = p[:i]
+	}
+	switch strings.ToLower(path.Ext(p)) {
+	case ".csv":
+		return formatCSV
+	case ".ndjson", ".jsonl":
+		return formatNDJSON
+	default:
+		return formatJSON
+	}
+}
+
+// parseCSV returns the rows of CSV content with a header as records, and the column names.
+func parseCSV(content []byte) ([]interface{}, []string, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	// Remove the byte order mark written by some spreadsheet applications.
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	var records []interface{}
+	for {
+		line, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		record := make(map[string]interface{}, len(header))
+		for i, name := range header {
+			if i < len(line) && line[i] != "" {
+				record[name] = line[i]
+			}
+		}
+		records = append(records, record)
+	}
+	return records, header, nil
+}
+
+func parseJSON(content []byte, rootPath string) ([]interface{}, error) {
+	value, err := oj.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return selectRecords(value, rootPath)
+}
+
+// parseNDJSON parses newline delimited JSON, the root path is applied to every line.
+func parseNDJSON(content []byte, rootPath string) ([]interface{}, error) {
+	var records []interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, maxContentSize)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		value, err := oj.Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON in line %d: %w", n, err)
+		}
+		lineRecords, err := selectRecords(value, rootPath)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, lineRecords...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read NDJSON: %w", err)
+	}
+	return records, nil
+}
+
+// selectRecords returns the values selected by the root path as records. Arrays are
+// expanded, so that each element is a record.
+func selectRecords(value interface{}, rootPath string) ([]interface{}, error) {
+	values := []interface{}{value}
+	if rootPath != "" {
+		x, err := jp.ParseString(rootPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root path %q: %w", rootPath, err)
+		}
+		values = x.Get(value)
+	}
+
+	var records []interface{}
+	for _, v := range values {
+		if elements, ok := v.([]interface{}); ok {
+			records = append(records, elements...)
+		} else {
+			records = append(records, v)
+		}
+	}
+	return records, nil
+}
+
+// queryFields returns the fields of the query. Without fields in the query, these are the
+// CSV columns, or the keys of the JSON objects in alphabetical order.
+func queryFields(model queryModel, records []interface{}, columns []string) ([]field, error) {
+	if len(model.Fields) > 0 {
+		fields := make([]field, 0, len(model.Fields))
+		for _, f := range model.Fields {
+			name := f.Name
+			if name == "" {
+				name = f.JSONPath
+			}
+			if name == "" {
+				return nil, errors.New("a field must have a name or a JSONPath")
+			}
+			qf := field{name: name, fieldType: f.Type}
+			if f.JSONPath != "" {
+				x, err := jp.ParseString(f.JSONPath)
+				if err != nil {
+					return nil, fmt.Errorf("invalid JSONPath %q of field %q: %w", f.JSONPath, name, err)
+				}
+				qf.path = x
+			}
+			fields = append(fields, qf)
+		}
+		return fields, nil
+	}
+
+	if columns == nil {
+		keys := map[string]bool{}
+		for _, record := range records {
+			if m, ok := record.(map[string]interface{}); ok {
+				for k := range m {
+					keys[k] = true
+				}
+			} else {
+				keys[""] = true
+			}
+		}
+		for k := range keys {
+			columns = append(columns, k)
+		}
+		sort.Strings(columns)
+	}
+
+	fields := make([]field, 0, len(columns))
+	for _, name := range columns {
+		f := field{name: name}
+		if name == "" {
+			// Records that are not objects, like the numbers in [1, 2, 3].
+			f.name = "value"
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// value returns the value of the field in the record.
+func (f field) value(record interface{}) interface{} {
+	if f.path != nil {
+		values := f.path.Get(record)
+		if len(values) == 0 {
+			return nil
+		}
+		return values[0]
+	}
+	if m, ok := record.(map[string]interface{}); ok {
+		return m[f.name]
+	}
+	if f.name == "value" {
+		return record
+	}
+	return nil
+}
+
+// sortByTime sorts the rows by the time field, and drops the rows outside the time range
+// if filter is set.
+func sortByTime(rows [][]interface{}, fields []field, timeField string, filter bool, timeRange backend.TimeRange) ([][]interface{}, error) {
+	index := -1
+	for i, f := range fields {
+		if f.name == timeField {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("time field %q not found", timeField)
+	}
+	fields[index].fieldType = typeTime
+
+	type timedRow struct {
+		t   *time.Time
+		row []interface{}
+	}
+	timed := make([]timedRow, 0, len(rows))
+	for _, row := range rows {
+		t, err := parseTime(row[index])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of time field %q: %w", timeField, err)
+		}
+		if filter && (t == nil || t.Before(timeRange.From) || t.After(timeRange.To)) {
+			continue
+		}
+		row[index] = t
+		timed = append(timed, timedRow{t: t, row: row})
+	}
+
+	sort.SliceStable(timed, func(i, j int) bool {
+		if timed[i].t == nil || timed[j].t == nil {
+			return timed[i].t != nil
+		}
+		return timed[i].t.Before(*timed[j].t)
+	})
+
+	sorted := make([][]interface{}, len(timed))
+	for i, r := range timed {
+		sorted[i] = r.row
+	}
+	return sorted, nil
+}
+
+// toField returns a data field with the values converted to the type of the field. The type of
+// auto fields is inferred from the values. Strings are only parsed if parseStrings is set.
+func toField(f field, values []interface{}, parseStrings bool) (*data.Field, error) {
+	fieldType := f.fieldType
+	if fieldType == "" || fieldType == typeAuto {
+		fieldType = inferType(values, parseStrings)
+	}
+
+	var fieldValues interface{}
+	switch fieldType {
+	case typeTime:
+		times := make([]*time.Time, len(values))
+		for i, v := range values {
+			t, err := parseTime(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of time field %q: %w", f.name, err)
+			}
+			times[i] = t
+		}
+		fieldValues = times
+	case typeNumber:
+		numbers := make([]*float64, len(values))
+		for i, v := range values {
+			n, ok := toNumber(v, true)
+			if !ok && v != nil {
+				return nil, fmt.Errorf("invalid value of number field %q: %v", f.name, v)
+			}
+			numbers[i] = n
+		}
+		fieldValues = numbers
+	case typeBoolean:
+		bools := make([]*bool, len(values))
+		for i, v := range values {
+			b, ok := toBool(v, true)
+			if !ok && v != nil {
+				return nil, fmt.Errorf("invalid value of boolean field %q: %v", f.name, v)
+			}
+			bools[i] = b
+		}
+		fieldValues = bools
+	case typeString:
+		strs := make([]*string, len(values))
+		for i, v := range values {
+			strs[i] = toString(v)
+		}
+		fieldValues = strs
+	default:
+		return nil, fmt.Errorf("unknown type %q of field %q", fieldType, f.name)
+	}
+	return data.NewField(f.name, nil, fieldValues), nil
+}
+
+// inferType returns boolean or number if all values that aren't null have that type,
+// otherwise string.
+func inferType(values []interface{}, parseStrings bool) string {
+	isBool, isNumber := true, true
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		if _, ok := toBool(v, parseStrings); !ok {
+			isBool = false
+		}
+		if _, ok := toNumber(v, parseStrings); !ok {
+			isNumber = false
+		}
+		if !isBool && !isNumber {
+			return typeString
+		}
+	}
+	switch {
+	case isBool:
+		return typeBoolean
+	case isNumber:
+		return typeNumber
+	default:
+		return typeString
+	}
+}
+
+func toNumber(v interface{}, parseStrings bool) (*float64, bool) {
+	var n float64
+	switch v := v.(type) {
+	case int64:
+		n = float64(v)
+	case float64:
+		n = v
+	case string:
+		if !parseStrings {
+			return nil, false
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, false
+		}
+		n = parsed
+	default:
+		return nil, false
+	}
+	return &n, true
+}
+
+func toBool(v interface{}, parseStrings bool) (*bool, bool) {
+	var b bool
+	switch v := v.(type) {
+	case bool:
+		b = v
+	case string:
+		if !parseStrings {
+			return nil, false
+		}
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true":
+			b = true
+		case "false":
+			b = false
+		default:
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	return &b, true
+}
+
+// toString returns strings as they are, and the JSON encoding of other values.
+func toString(v interface{}) *string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return &v
+	default:
+		s := oj.JSON(v)
+		return &s
+	}
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// epochSecondsLimit is used to tell Unix timestamps in seconds from timestamps in milliseconds.
+// Timestamps in seconds are below it until the year 5138, in milliseconds after March 1973.
+const epochSecondsLimit = 1e11
+
+// parseTime parses numbers as Unix timestamps in seconds or milliseconds, and strings as
+// RFC 3339 or a similar layout. Strings without a time zone are in UTC.
+func parseTime(v interface{}) (*time.Time, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case *time.Time:
+		return v, nil
+	case string:
+		s := strings.TrimSpace(v)
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return &t, nil
+			}
+		}
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return epochTime(n), nil
+		}
+		return nil, fmt.Errorf("unknown time format %q", s)
+	}
+	if n, ok := toNumber(v, false); ok {
+		return epochTime(*n), nil
+	}
+	return nil, fmt.Errorf("unsupported time value %v", v)
+}
+
+func epochTime(n float64) *time.Time {
+	var t time.Time
+	if n < epochSecondsLimit && n > -epochSecondsLimit {
+		t = time.Unix(0, int64(n*float64(time.Second))).UTC()
+	} else {
+		t = time.Unix(0, int64(n*float64(time.Millisecond))).UTC()
+	}
+	return &t
+}
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
-	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana/pkg/tsdb/sqleng"
 )
 
@@ -65,6 +64,10 @@ func (m *msSQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 			return "", fmt.Errorf("missing time column argument for macro %v", name)
 		}
 
+		if len(args) > 1 {
+			return m.typedTimeFilter(timeRange, query, args[0], args[1])
+		}
+
 		return fmt.Sprintf("%s BETWEEN '%s' AND '%s'", args[0], timeRange.From.UTC().Format(time.RFC3339), timeRange.To.UTC().Format(time.RFC3339)), nil
 	case "__timeFrom":
 		return fmt.Sprintf("'%s'", timeRange.From.UTC().Format(time.RFC3339)), nil
@@ -74,9 +77,9 @@ func (m *msSQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 		if len(args) < 2 {
 			return "", fmt.Errorf("macro %v needs time column and interval", name)
 		}
-		interval, err := gtime.ParseInterval(strings.Trim(args[1], `'"`))
+		interval, err := sqleng.ParseIntervalArg(args[1])
 		if err != nil {
-			return "", fmt.Errorf("error parsing interval %v", args[1])
+			return "", err
 		}
 		if len(args) == 3 {
 			err := sqleng.SetupFillmode(query, interval, args[2])
@@ -84,6 +87,13 @@ func (m *msSQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 				return "", err
 			}
 		}
+		loc, err := sqleng.QueryLocation(query)
+		if err != nil {
+			return "", err
+		}
+		if loc != nil {
+			return sqleng.LocalTimeGroup(fmt.Sprintf("DATEDIFF(second, '1970-01-01', %s)", args[0]), interval, loc, timeRange, mssqlFloor), nil
+		}
 		return fmt.Sprintf("FLOOR(DATEDIFF(second, '1970-01-01', %s)/%.0f)*%.0f", args[0], interval.Seconds(), interval.Seconds()), nil
 	case "__timeGroupAlias":
 		tg, err := m.evaluateMacro(timeRange, query, "__timeGroup", args)
@@ -109,9 +119,9 @@ func (m *msSQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 		if len(args) < 2 {
 			return "", fmt.Errorf("macro %v needs time column and interval and optional fill value", name)
 		}
-		interval, err := gtime.ParseInterval(strings.Trim(args[1], `'`))
+		interval, err := sqleng.ParseIntervalArg(args[1])
 		if err != nil {
-			return "", fmt.Errorf("error parsing interval %v", args[1])
+			return "", err
 		}
 		if len(args) == 3 {
 			err := sqleng.SetupFillmode(query, interval, args[2])
@@ -119,6 +129,13 @@ func (m *msSQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 				return "", err
 			}
 		}
+		loc, err := sqleng.QueryLocation(query)
+		if err != nil {
+			return "", err
+		}
+		if loc != nil {
+			return sqleng.LocalTimeGroup(args[0], interval, loc, timeRange, mssqlFloor), nil
+		}
 		return fmt.Sprintf("FLOOR(%s/%v)*%v", args[0], interval.Seconds(), interval.Seconds()), nil
 	case "__unixEpochGroupAlias":
 		tg, err := m.evaluateMacro(timeRange, query, "__unixEpochGroup", args)
@@ -130,3 +147,32 @@ func (m *msSQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 		return "", fmt.Errorf("unknown macro %q", name)
 	}
 }
+
+// typedTimeFilter filters on a column of the given type. Date and datetime columns are compared
+// with the time range in the time zone of the query, since they don't store a time zone.
+func (m *msSQLMacroEngine) typedTimeFilter(timeRange backend.TimeRange, query *backend.DataQuery, column string, columnType string) (string, error) {
+	t, err := sqleng.ParseTimeFilterColumnType(columnType)
+	if err != nil {
+		return "", err
+	}
+	loc, err := sqleng.QueryLocation(query)
+	if err != nil {
+		return "", err
+	}
+	from, to := sqleng.LocalTimeRange(timeRange, loc)
+
+	switch t {
+	case sqleng.TimeFilterColumnTypeDate:
+		return fmt.Sprintf("%s BETWEEN CAST('%s' AS date) AND CAST('%s' AS date)", column, from.Format("2006-01-02"), to.Format("2006-01-02")), nil
+	case sqleng.TimeFilterColumnTypeDateTime:
+		const layout = "2006-01-02T15:04:05"
+		return fmt.Sprintf("%s BETWEEN '%s' AND '%s'", column, from.Format(layout), to.Format(layout)), nil
+	default:
+		return fmt.Sprintf("%s BETWEEN CAST('%s' AS datetimeoffset) AND CAST('%s' AS datetimeoffset)", column,
+			timeRange.From.UTC().Format(time.RFC3339), timeRange.To.UTC().Format(time.RFC3339)), nil
+	}
+}
+
+func mssqlFloor(expr string) string {
+	return fmt.Sprintf("FLOOR(%s)", expr)
+}
@@ -242,3 +242,23 @@ func TestMacroEngineConcurrency(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestMacroEngineTimezone(t *testing.T) {
+	engine := &msSQLMacroEngine{}
+	query := &backend.DataQuery{JSON: []byte(`{"timezone": "Europe/Berlin"}`)}
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	timeRange := backend.TimeRange{From: from, To: from.Add(24 * time.Hour)}
+
+	t.Run("interpolate __timeGroup function aligned to the time zone", func(t *testing.T) {
+		sql, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroup(time_column,'1d')")
+		require.NoError(t, err)
+
+		require.Equal(t, "GROUP BY FLOOR((DATEDIFF(second, '1970-01-01', time_column)+7200)/86400)*86400-7200", sql)
+	})
+
+	t.Run("interpolate __timeFilter function with column type", func(t *testing.T) {
+		sql, err := engine.Interpolate(query, timeRange, "WHERE $__timeFilter(time_column, datetimeoffset)")
+		require.NoError(t, err)
+		require.Equal(t, "WHERE time_column BETWEEN CAST('2018-04-12T18:00:00Z' AS datetimeoffset) AND CAST('2018-04-13T18:00:00Z' AS datetimeoffset)", sql)
+	})
+}
@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
-	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/tsdb/sqleng"
 )
@@ -68,6 +67,9 @@ func (m *mySQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 		if len(args) == 0 {
 			return "", fmt.Errorf("missing time column argument for macro %v", name)
 		}
+		if len(args) > 1 {
+			return m.typedTimeFilter(timeRange, query, args[0], args[1])
+		}
 
 		return fmt.Sprintf("%s BETWEEN FROM_UNIXTIME(%d) AND FROM_UNIXTIME(%d)", args[0], timeRange.From.UTC().Unix(), timeRange.To.UTC().Unix()), nil
 	case "__timeFrom":
@@ -78,9 +80,9 @@ func (m *mySQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 		if len(args) < 2 {
 			return "", fmt.Errorf("macro %v needs time column and interval", name)
 		}
-		interval, err := gtime.ParseInterval(strings.Trim(args[1], `'"`))
+		interval, err := sqleng.ParseIntervalArg(args[1])
 		if err != nil {
-			return "", fmt.Errorf("error parsing interval %v", args[1])
+			return "", err
 		}
 		if len(args) == 3 {
 			err := sqleng.SetupFillmode(query, interval, args[2])
@@ -88,6 +90,13 @@ func (m *mySQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 				return "", err
 			}
 		}
+		loc, err := sqleng.QueryLocation(query)
+		if err != nil {
+			return "", err
+		}
+		if loc != nil {
+			return sqleng.LocalTimeGroup(fmt.Sprintf("UNIX_TIMESTAMP(%s)", args[0]), interval, loc, timeRange, mysqlFloor), nil
+		}
 		return fmt.Sprintf("UNIX_TIMESTAMP(%s) DIV %.0f * %.0f", args[0], interval.Seconds(), interval.Seconds()), nil
 	case "__timeGroupAlias":
 		tg, err := m.evaluateMacro(timeRange, query, "__timeGroup", args)
@@ -113,9 +122,9 @@ func (m *mySQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 		if len(args) < 2 {
 			return "", fmt.Errorf("macro %v needs time column and interval and optional fill value", name)
 		}
-		interval, err := gtime.ParseInterval(strings.Trim(args[1], `'`))
+		interval, err := sqleng.ParseIntervalArg(args[1])
 		if err != nil {
-			return "", fmt.Errorf("error parsing interval %v", args[1])
+			return "", err
 		}
 		if len(args) == 3 {
 			err := sqleng.SetupFillmode(query, interval, args[2])
@@ -123,6 +132,13 @@ func (m *mySQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 				return "", err
 			}
 		}
+		loc, err := sqleng.QueryLocation(query)
+		if err != nil {
+			return "", err
+		}
+		if loc != nil {
+			return sqleng.LocalTimeGroup(args[0], interval, loc, timeRange, mysqlFloor), nil
+		}
 		return fmt.Sprintf("%s DIV %v * %v", args[0], interval.Seconds(), interval.Seconds()), nil
 	case "__unixEpochGroupAlias":
 		tg, err := m.evaluateMacro(timeRange, query, "__unixEpochGroup", args)
@@ -134,3 +150,30 @@ func (m *mySQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 		return "", fmt.Errorf("unknown macro %v", name)
 	}
 }
+
+// typedTimeFilter filters on a column of the given type. Date and datetime columns are compared
+// with the time range in the time zone of the query, since they don't store a time zone.
+func (m *mySQLMacroEngine) typedTimeFilter(timeRange backend.TimeRange, query *backend.DataQuery, column string, columnType string) (string, error) {
+	t, err := sqleng.ParseTimeFilterColumnType(columnType)
+	if err != nil {
+		return "", err
+	}
+	loc, err := sqleng.QueryLocation(query)
+	if err != nil {
+		return "", err
+	}
+	from, to := sqleng.LocalTimeRange(timeRange, loc)
+
+	switch t {
+	case sqleng.TimeFilterColumnTypeDate:
+		return fmt.Sprintf("%s BETWEEN '%s' AND '%s'", column, from.Format(dateFormat), to.Format(dateFormat)), nil
+	case sqleng.TimeFilterColumnTypeDateTime:
+		return fmt.Sprintf("%s BETWEEN '%s' AND '%s'", column, from.Format(dateTimeFormat1), to.Format(dateTimeFormat1)), nil
+	default:
+		return fmt.Sprintf("%s BETWEEN FROM_UNIXTIME(%d) AND FROM_UNIXTIME(%d)", column, timeRange.From.UTC().Unix(), timeRange.To.UTC().Unix()), nil
+	}
+}
+
+func mysqlFloor(expr string) string {
+	return fmt.Sprintf("FLOOR(%s)", expr)
+}
@@ -221,3 +221,37 @@ func TestMacroEngineConcurrency(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestMacroEngineTimezone(t *testing.T) {
+	engine := newMysqlMacroEngine(log.New("test"))
+	query := &backend.DataQuery{JSON: []byte(`{"timezone": "Asia/Kolkata"}`)}
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	timeRange := backend.TimeRange{From: from, To: from.Add(48 * time.Hour)}
+
+	t.Run("interpolate __timeGroup function aligned to the time zone", func(t *testing.T) {
+		sql, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroup(time_column,'1d')")
+		require.NoError(t, err)
+
+		require.Equal(t, "GROUP BY FLOOR((UNIX_TIMESTAMP(time_column)+19800)/86400)*86400-19800", sql)
+	})
+
+	t.Run("interpolate __timeGroup function with interval arithmetic", func(t *testing.T) {
+		sql, err := engine.Interpolate(&backend.DataQuery{}, timeRange, "GROUP BY $__timeGroup(time_column,1m*5)")
+		require.NoError(t, err)
+
+		require.Equal(t, "GROUP BY UNIX_TIMESTAMP(time_column) DIV 300 * 300", sql)
+	})
+
+	t.Run("interpolate __timeFilter function with column type", func(t *testing.T) {
+		sql, err := engine.Interpolate(query, timeRange, "WHERE $__timeFilter(day_column, date)")
+		require.NoError(t, err)
+		require.Equal(t, "WHERE day_column BETWEEN '2018-04-12' AND '2018-04-14'", sql)
+
+		sql, err = engine.Interpolate(query, timeRange, "WHERE $__timeFilter(time_column, datetime)")
+		require.NoError(t, err)
+		require.Equal(t, "WHERE time_column BETWEEN '2018-04-12 23:30:00' AND '2018-04-14 23:30:00'", sql)
+
+		_, err = engine.Interpolate(query, timeRange, "WHERE $__timeFilter(time_column, int)")
+		require.Error(t, err)
+	})
+}
@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -116,6 +117,8 @@ func newInstanceSettings(cfg *setting.Cfg, httpClientProvider httpclient.Provide
 			TimeColumnNames:   []string{"time", "time_sec"},
 			MetricColumnTypes: []string{"CHAR", "VARCHAR", "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT"},
 			RowLimit:          cfg.DataProxyRowLimit,
+			BytesLimit:        cfg.DataProxyBytesLimit,
+			QueryCanceler:     &mysqlQueryCanceler{},
 		}
 
 		rowTransformer := mysqlQueryResultTransformer{
@@ -143,6 +146,21 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 	return dsHandler.QueryData(ctx, req)
 }
 
+// mysqlQueryCanceler kills queries on the server, since the MySQL driver only closes
+// the connection when the query context is cancelled, and MySQL keeps running the query.
+type mysqlQueryCanceler struct{}
+
+func (c *mysqlQueryCanceler) ConnectionID(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var id int64
+	err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id)
+	return id, err
+}
+
+func (c *mysqlQueryCanceler) CancelQuery(ctx context.Context, db *sql.DB, connectionID int64) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", connectionID))
+	return err
+}
+
 type mysqlQueryResultTransformer struct {
 	log log.Logger
 }
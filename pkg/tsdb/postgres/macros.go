@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
-	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana/pkg/tsdb/sqleng"
 )
 
@@ -83,6 +82,10 @@ func (m *postgresMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *
 			return "", fmt.Errorf("missing time column argument for macro %v", name)
 		}
 
+		if len(args) > 1 {
+			return m.typedTimeFilter(timeRange, query, args[0], args[1])
+		}
+
 		return fmt.Sprintf("%s BETWEEN '%s' AND '%s'", args[0], timeRange.From.UTC().Format(time.RFC3339Nano), timeRange.To.UTC().Format(time.RFC3339Nano)), nil
 	case "__timeFrom":
 		return fmt.Sprintf("'%s'", timeRange.From.UTC().Format(time.RFC3339Nano)), nil
@@ -92,9 +95,9 @@ func (m *postgresMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *
 		if len(args) < 2 {
 			return "", fmt.Errorf("macro %v needs time column and interval and optional fill value", name)
 		}
-		interval, err := gtime.ParseInterval(strings.Trim(args[1], `'`))
+		interval, err := sqleng.ParseIntervalArg(args[1])
 		if err != nil {
-			return "", fmt.Errorf("error parsing interval %v", args[1])
+			return "", err
 		}
 		if len(args) == 3 {
 			err := sqleng.SetupFillmode(query, interval, args[2])
@@ -103,6 +106,16 @@ func (m *postgresMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *
 			}
 		}
 
+		loc, err := sqleng.QueryLocation(query)
+		if err != nil {
+			return "", err
+		}
+		if loc != nil {
+			// time_bucket only supports time zones in recent TimescaleDB versions, so
+			// the time zone aware grouping is used for TimescaleDB as well.
+			return sqleng.LocalTimeGroup(fmt.Sprintf("extract(epoch from %s)", args[0]), interval, loc, timeRange, postgresFloor), nil
+		}
+
 		if m.timescaledb {
 			return fmt.Sprintf("time_bucket('%.3fs',%s)", interval.Seconds(), args[0]), nil
 		}
@@ -136,9 +149,9 @@ func (m *postgresMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *
 		if len(args) < 2 {
 			return "", fmt.Errorf("macro %v needs time column and interval and optional fill value", name)
 		}
-		interval, err := gtime.ParseInterval(strings.Trim(args[1], `'`))
+		interval, err := sqleng.ParseIntervalArg(args[1])
 		if err != nil {
-			return "", fmt.Errorf("error parsing interval %v", args[1])
+			return "", err
 		}
 		if len(args) == 3 {
 			err := sqleng.SetupFillmode(query, interval, args[2])
@@ -146,6 +159,13 @@ func (m *postgresMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *
 				return "", err
 			}
 		}
+		loc, err := sqleng.QueryLocation(query)
+		if err != nil {
+			return "", err
+		}
+		if loc != nil {
+			return sqleng.LocalTimeGroup(args[0], interval, loc, timeRange, postgresFloor), nil
+		}
 		return fmt.Sprintf("floor(%s/%v)*%v", args[0], interval.Seconds(), interval.Seconds()), nil
 	case "__unixEpochGroupAlias":
 		tg, err := m.evaluateMacro(timeRange, query, "__unixEpochGroup", args)
@@ -157,3 +177,32 @@ func (m *postgresMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *
 		return "", fmt.Errorf("unknown macro %q", name)
 	}
 }
+
+// typedTimeFilter filters on a column of the given type. Date and timestamp without time zone
+// columns are compared with the time range in the time zone of the query.
+func (m *postgresMacroEngine) typedTimeFilter(timeRange backend.TimeRange, query *backend.DataQuery, column string, columnType string) (string, error) {
+	t, err := sqleng.ParseTimeFilterColumnType(columnType)
+	if err != nil {
+		return "", err
+	}
+	loc, err := sqleng.QueryLocation(query)
+	if err != nil {
+		return "", err
+	}
+	from, to := sqleng.LocalTimeRange(timeRange, loc)
+
+	switch t {
+	case sqleng.TimeFilterColumnTypeDate:
+		return fmt.Sprintf("%s BETWEEN '%s'::date AND '%s'::date", column, from.Format("2006-01-02"), to.Format("2006-01-02")), nil
+	case sqleng.TimeFilterColumnTypeDateTime:
+		const layout = "2006-01-02 15:04:05.999999"
+		return fmt.Sprintf("%s BETWEEN '%s'::timestamp AND '%s'::timestamp", column, from.Format(layout), to.Format(layout)), nil
+	default:
+		return fmt.Sprintf("%s BETWEEN '%s'::timestamptz AND '%s'::timestamptz", column,
+			timeRange.From.UTC().Format(time.RFC3339Nano), timeRange.To.UTC().Format(time.RFC3339Nano)), nil
+	}
+}
+
+func postgresFloor(expr string) string {
+	return fmt.Sprintf("floor(%s)", expr)
+}
@@ -247,3 +247,27 @@ func TestMacroEngineConcurrency(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestMacroEngineTimezone(t *testing.T) {
+	engine := newPostgresMacroEngine(false)
+	query := &backend.DataQuery{JSON: []byte(`{"timezone": "America/New_York"}`)}
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	timeRange := backend.TimeRange{From: from, To: from.Add(7 * 24 * time.Hour)}
+
+	t.Run("interpolate __timeGroup function aligned to the time zone", func(t *testing.T) {
+		sql, err := engine.Interpolate(query, timeRange, "SELECT $__timeGroupAlias(time_column,'1w')")
+		require.NoError(t, err)
+
+		require.Equal(t, "SELECT floor((extract(epoch from time_column)-360000)/604800)*604800+360000 AS \"time\"", sql)
+	})
+
+	t.Run("interpolate __timeFilter function with column type", func(t *testing.T) {
+		sql, err := engine.Interpolate(query, timeRange, "WHERE $__timeFilter(day_column, date)")
+		require.NoError(t, err)
+		require.Equal(t, "WHERE day_column BETWEEN '2018-04-12'::date AND '2018-04-19'::date", sql)
+
+		sql, err = engine.Interpolate(query, timeRange, "WHERE $__timeFilter(time_column, timestamptz)")
+		require.NoError(t, err)
+		require.Equal(t, "WHERE time_column BETWEEN '2018-04-12T18:00:00Z'::timestamptz AND '2018-04-19T18:00:00Z'::timestamptz", sql)
+	})
+}
@@ -45,7 +45,7 @@ func (p *Provider) GetClient(headers map[string]string) (apiv1.API, error) {
 	}
 
 	opts.Middlewares = p.middlewares()
-	opts.Headers = reqHeaders(headers)
+	opts.Headers = reqHeaders(opts.Headers, headers)
 
 	// Set SigV4 service namespace
 	if opts.SigV4 != nil {
@@ -88,9 +88,14 @@ func (p *Provider) middlewares() []sdkhttpclient.Middleware {
 	return middlewares
 }
 
-func reqHeaders(headers map[string]string) map[string]string {
-	// copy to avoid changing the original map
-	h := make(map[string]string, len(headers))
+// reqHeaders returns the custom headers of the data source settings and the request headers,
+// which are only set by the query service for queries, but not for resource calls.
+func reqHeaders(settingsHeaders map[string]string, headers map[string]string) map[string]string {
+	// copy to avoid changing the original maps
+	h := make(map[string]string, len(settingsHeaders)+len(headers))
+	for k, v := range settingsHeaders {
+		h[k] = v
+	}
 	for k, v := range headers {
 		h[k] = v
 	}
@@ -12,7 +12,9 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
@@ -30,15 +32,18 @@ type Service struct {
 	intervalCalculator intervalv2.Calculator
 	im                 instancemgmt.InstanceManager
 	tracer             tracing.Tracer
+	resourceHandler    backend.CallResourceHandler
 }
 
 func ProvideService(httpClientProvider httpclient.Provider, tracer tracing.Tracer) *Service {
 	plog.Debug("initializing")
-	return &Service{
+	s := &Service{
 		intervalCalculator: intervalv2.NewCalculator(),
 		im:                 datasource.NewInstanceManager(newInstanceSettings(httpClientProvider)),
 		tracer:             tracer,
 	}
+	s.resourceHandler = httpadapter.New(s.newResourceMux())
+	return s
 }
 
 func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
@@ -60,11 +65,33 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
+		oauthPassThru, err := maputil.GetBoolOptional(jsonData, "oauthPassThru")
+		if err != nil {
+			return nil, err
+		}
+
+		cacheDuration := defaultResourceCacheDuration
+		cacheDurationSetting, err := maputil.GetStringOptional(jsonData, "metadataCacheDuration")
+		if err != nil {
+			return nil, err
+		}
+		if cacheDurationSetting != "" {
+			cacheDuration, err = intervalv2.ParseIntervalStringToTimeDuration(cacheDurationSetting)
+			if err != nil {
+				return nil, fmt.Errorf("invalid metadata cache duration: %w", err)
+			}
+		}
+
 		mdl := DatasourceInfo{
-			ID:           settings.ID,
-			URL:          settings.URL,
-			TimeInterval: timeInterval,
-			getClient:    pc.GetClient,
+			ID:                    settings.ID,
+			URL:                   settings.URL,
+			TimeInterval:          timeInterval,
+			OAuthPassThru:         oauthPassThru,
+			getClient:             pc.GetClient,
+			resourceCacheDuration: cacheDuration,
+		}
+		if cacheDuration > 0 {
+			mdl.resourceCache = localcache.New(cacheDuration, 2*cacheDuration)
 		}
 
 		return mdl, nil
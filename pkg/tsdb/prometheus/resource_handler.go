@@ -0,0 +1,308 @@
+package prometheus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	apiv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultResourceCacheDuration is how long metadata responses are cached, if the
+// metadataCacheDuration setting of the data source isn't set.
+const defaultResourceCacheDuration = time.Minute
+
+// authHeaders are the headers forwarded to Prometheus if OAuth pass-through is enabled.
+var authHeaders = []string{"Authorization", "X-ID-Token"}
+
+// resourceRequest is a metadata request to Prometheus.
+type resourceRequest struct {
+	path    string
+	label   string
+	matches []string
+	start   time.Time
+	end     time.Time
+	form    url.Values
+}
+
+// resourceFunc requests metadata from Prometheus and returns the data of the response.
+type resourceFunc func(ctx context.Context, client apiv1.API, req *resourceRequest) (interface{}, apiv1.Warnings, error)
+
+// resourceResponse has the format of the Prometheus HTTP API responses, so that clients
+// can handle them like responses from Prometheus.
+type resourceResponse struct {
+	Status    string          `json:"status"`
+	Data      interface{}     `json:"data,omitempty"`
+	ErrorType apiv1.ErrorType `json:"errorType,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Warnings  []string        `json:"warnings,omitempty"`
+}
+
+func (s *Service) newResourceMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/labels", s.handleResourceReq(labelNames))
+	mux.HandleFunc("/api/v1/label/", s.handleResourceReq(labelValues))
+	mux.HandleFunc("/api/v1/series", s.handleResourceReq(series))
+	mux.HandleFunc("/api/v1/metadata", s.handleResourceReq(metadata))
+	mux.HandleFunc("/api/v1/rules", s.handleResourceReq(rules))
+	return mux
+}
+
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return s.resourceHandler.CallResource(ctx, req, sender)
+}
+
+func (s *Service) handleResourceReq(fn resourceFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodPost {
+			writeResourceError(rw, http.StatusMethodNotAllowed, apiv1.ErrBadData, "method not allowed")
+			return
+		}
+
+		dsInfo, err := s.getDSInfo(httpadapter.PluginConfigFromContext(req.Context()))
+		if err != nil {
+			writeResourceError(rw, http.StatusInternalServerError, apiv1.ErrServer, err.Error())
+			return
+		}
+
+		resourceReq, err := parseResourceRequest(req, dsInfo.resourceCacheDuration)
+		if err != nil {
+			writeResourceError(rw, http.StatusBadRequest, apiv1.ErrBadData, err.Error())
+			return
+		}
+
+		headers := map[string]string{}
+		if dsInfo.OAuthPassThru {
+			for _, h := range authHeaders {
+				if v := req.Header.Get(h); v != "" {
+					headers[h] = v
+				}
+			}
+		}
+
+		cacheKey := resourceCacheKey(resourceReq, headers)
+		if dsInfo.resourceCache != nil {
+			if body, ok := dsInfo.resourceCache.Get(cacheKey); ok {
+				writeResourceResponse(rw, http.StatusOK, body.([]byte))
+				return
+			}
+		}
+
+		client, err := dsInfo.getClient(headers)
+		if err != nil {
+			writeResourceError(rw, http.StatusInternalServerError, apiv1.ErrServer, err.Error())
+			return
+		}
+
+		ctx, span := s.tracer.Start(req.Context(), "datasource.prometheus.resource")
+		span.SetAttributes("path", resourceReq.path, attribute.Key("path").String(resourceReq.path))
+		span.SetAttributes("match", resourceReq.matches, attribute.Key("match").StringSlice(resourceReq.matches))
+		defer span.End()
+
+		data, warnings, err := fn(ctx, client, resourceReq)
+		if err != nil {
+			span.RecordError(err)
+			plog.Debug("Prometheus resource request failed", "path", resourceReq.path, "error", err)
+			status, errType := resourceErrorStatus(err)
+			writeResourceError(rw, status, errType, ConvertAPIError(err).Error())
+			return
+		}
+
+		body, err := json.Marshal(resourceResponse{Status: "success", Data: data, Warnings: warnings})
+		if err != nil {
+			writeResourceError(rw, http.StatusInternalServerError, apiv1.ErrServer, err.Error())
+			return
+		}
+		if dsInfo.resourceCache != nil {
+			dsInfo.resourceCache.SetDefault(cacheKey, body)
+		}
+		writeResourceResponse(rw, http.StatusOK, body)
+	}
+}
+
+// parseResourceRequest parses the path and the query or form parameters of the request. If the
+// responses are cached, the time range is extended to multiples of the cache duration, so that
+// requests for time ranges that differ by a few seconds, like from dashboard refreshes, use the
+// same cached response.
+func parseResourceRequest(req *http.Request, cacheDuration time.Duration) (*resourceRequest, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	r := &resourceRequest{
+		path:    req.URL.Path,
+		matches: req.Form["match[]"],
+		form:    req.Form,
+	}
+
+	if strings.HasPrefix(r.path, "/api/v1/label/") {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.path, "/api/v1/label/"), "/values")
+		if name == r.path || strings.Contains(name, "/") || !model.LabelName(name).IsValid() {
+			return nil, fmt.Errorf("invalid label name %q", name)
+		}
+		r.label = name
+	}
+
+	var err error
+	if r.start, err = parseResourceTime(req.Form.Get("start"), time.Time{}); err != nil {
+		return nil, fmt.Errorf("invalid start: %w", err)
+	}
+	if r.end, err = parseResourceTime(req.Form.Get("end"), time.Now()); err != nil {
+		return nil, fmt.Errorf("invalid end: %w", err)
+	}
+
+	if cacheDuration > 0 {
+		if !r.start.IsZero() {
+			r.start = r.start.Truncate(cacheDuration)
+		}
+		if end := r.end.Truncate(cacheDuration); !end.Equal(r.end) {
+			r.end = end.Add(cacheDuration)
+		}
+	}
+	return r, nil
+}
+
+// parseResourceTime parses a Unix timestamp in seconds or an RFC 3339 time, like Prometheus.
+func parseResourceTime(s string, defaultTime time.Time) (time.Time, error) {
+	if s == "" {
+		return defaultTime, nil
+	}
+	if t, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, frac := math.Modf(t)
+		return time.Unix(int64(sec), int64(math.Round(frac*1000))*int64(time.Millisecond)).UTC(), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", s)
+}
+
+// resourceCacheKey returns the cache key of the request. Responses can differ between users
+// with OAuth pass-through, so the forwarded headers are part of the key.
+func resourceCacheKey(r *resourceRequest, headers map[string]string) string {
+	h := sha256.New()
+	for _, name := range authHeaders {
+		_, _ = h.Write([]byte(name + "=" + headers[name] + "\n"))
+	}
+	params := url.Values{
+		"match[]": r.matches,
+		"start":   {strconv.FormatInt(r.start.Unix(), 10)},
+		"end":     {strconv.FormatInt(r.end.Unix(), 10)},
+		"metric":  {r.form.Get("metric")},
+		"limit":   {r.form.Get("limit")},
+	}
+	return r.path + "?" + params.Encode() + "#" + hex.EncodeToString(h.Sum(nil))
+}
+
+func labelNames(ctx context.Context, client apiv1.API, req *resourceRequest) (interface{}, apiv1.Warnings, error) {
+	return client.LabelNames(ctx, req.matches, req.start, req.end)
+}
+
+func labelValues(ctx context.Context, client apiv1.API, req *resourceRequest) (interface{}, apiv1.Warnings, error) {
+	return client.LabelValues(ctx, req.label, req.matches, req.start, req.end)
+}
+
+func series(ctx context.Context, client apiv1.API, req *resourceRequest) (interface{}, apiv1.Warnings, error) {
+	if len(req.matches) == 0 {
+		return nil, nil, &apiv1.Error{Type: apiv1.ErrBadData, Msg: "no match[] parameter provided"}
+	}
+	return client.Series(ctx, req.matches, req.start, req.end)
+}
+
+func metadata(ctx context.Context, client apiv1.API, req *resourceRequest) (interface{}, apiv1.Warnings, error) {
+	data, err := client.Metadata(ctx, req.form.Get("metric"), req.form.Get("limit"))
+	return data, nil, err
+}
+
+// rules returns the rule groups with the type of each rule, which the client library only uses
+// for decoding the rules.
+func rules(ctx context.Context, client apiv1.API, _ *resourceRequest) (interface{}, apiv1.Warnings, error) {
+	result, err := client.Rules(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type ruleGroup struct {
+		Name     string        `json:"name"`
+		File     string        `json:"file"`
+		Interval float64       `json:"interval"`
+		Rules    []interface{} `json:"rules"`
+	}
+	type alertingRule struct {
+		apiv1.AlertingRule
+		Type string `json:"type"`
+	}
+	type recordingRule struct {
+		apiv1.RecordingRule
+		Type string `json:"type"`
+	}
+
+	groups := make([]ruleGroup, 0, len(result.Groups))
+	for _, g := range result.Groups {
+		group := ruleGroup{Name: g.Name, File: g.File, Interval: g.Interval, Rules: make([]interface{}, 0, len(g.Rules))}
+		for _, rule := range g.Rules {
+			switch r := rule.(type) {
+			case apiv1.AlertingRule:
+				group.Rules = append(group.Rules, alertingRule{AlertingRule: r, Type: string(apiv1.RuleTypeAlerting)})
+			case apiv1.RecordingRule:
+				group.Rules = append(group.Rules, recordingRule{RecordingRule: r, Type: string(apiv1.RuleTypeRecording)})
+			}
+		}
+		groups = append(groups, group)
+	}
+	return map[string]interface{}{"groups": groups}, nil, nil
+}
+
+// resourceErrorStatus returns the HTTP status code and the Prometheus error type of err.
+func resourceErrorStatus(err error) (int, apiv1.ErrorType) {
+	var apiErr *apiv1.Error
+	if !errors.As(err, &apiErr) {
+		if errors.Is(err, context.Canceled) {
+			return http.StatusServiceUnavailable, apiv1.ErrCanceled
+		}
+		return http.StatusBadGateway, apiv1.ErrServer
+	}
+
+	switch apiErr.Type {
+	case apiv1.ErrBadData:
+		return http.StatusBadRequest, apiErr.Type
+	case apiv1.ErrTimeout, apiv1.ErrCanceled:
+		return http.StatusServiceUnavailable, apiErr.Type
+	case apiv1.ErrExec:
+		return http.StatusUnprocessableEntity, apiErr.Type
+	case apiv1.ErrBadResponse:
+		return http.StatusBadGateway, apiErr.Type
+	default:
+		return http.StatusBadGateway, apiv1.ErrServer
+	}
+}
+
+func writeResourceError(rw http.ResponseWriter, status int, errType apiv1.ErrorType, msg string) {
+	body, err := json.Marshal(resourceResponse{Status: "error", ErrorType: errType, Error: msg})
+	if err != nil {
+		plog.Error("Failed to marshal resource error", "error", err)
+		return
+	}
+	writeResourceResponse(rw, status, body)
+}
+
+func writeResourceResponse(rw http.ResponseWriter, status int, body []byte) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	if _, err := rw.Write(body); err != nil {
+		plog.Error("Failed to write resource response", "error", err)
+	}
+}
@@ -0,0 +1,238 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePrometheus struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (p *fakePrometheus) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	p.mu.Unlock()
+
+	if err := req.ParseForm(); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var data string
+	switch req.URL.Path {
+	case "/api/v1/labels":
+		data = `["__name__", "job"]`
+	case "/api/v1/label/job/values":
+		data = `["prometheus", "node"]`
+	case "/api/v1/series":
+		data = `[{"__name__": "up", "job": "node"}]`
+	case "/api/v1/metadata":
+		data = `{"up": [{"type": "gauge", "help": "Up", "unit": ""}]}`
+	case "/api/v1/rules":
+		data = `{"groups": [{"name": "g", "file": "f", "interval": 60, "rules": [
+			{"type": "recording", "name": "job:up:sum", "query": "sum by (job) (up)", "health": "ok"}
+		]}]}`
+	default:
+		rw.WriteHeader(http.StatusBadRequest)
+		_, _ = rw.Write([]byte(`{"status": "error", "errorType": "bad_data", "error": "unknown path"}`))
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	_, _ = rw.Write([]byte(`{"status": "success", "data": ` + data + `}`))
+}
+
+func (p *fakePrometheus) lastRequest(t *testing.T) *http.Request {
+	t.Helper()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	require.NotEmpty(t, p.requests)
+	return p.requests[len(p.requests)-1]
+}
+
+func (p *fakePrometheus) numRequests() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.requests)
+}
+
+type resourceResponseSender struct {
+	response *backend.CallResourceResponse
+}
+
+func (s *resourceResponseSender) Send(resp *backend.CallResourceResponse) error {
+	s.response = resp
+	return nil
+}
+
+func callResource(t *testing.T, s *Service, settings *backend.DataSourceInstanceSettings, path string, params url.Values, headers map[string][]string) (int, map[string]interface{}) {
+	t.Helper()
+
+	sender := &resourceResponseSender{}
+	err := s.CallResource(context.Background(), &backend.CallResourceRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: settings},
+		Path:          path,
+		Method:        http.MethodGet,
+		URL:           path + "?" + params.Encode(),
+		Headers:       headers,
+	}, sender)
+	require.NoError(t, err)
+	require.NotNil(t, sender.response)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(sender.response.Body, &body))
+	return sender.response.Status, body
+}
+
+func setupResourceTest(t *testing.T, jsonData string) (*Service, *fakePrometheus, *backend.DataSourceInstanceSettings) {
+	t.Helper()
+
+	prom := &fakePrometheus{}
+	server := httptest.NewServer(prom)
+	t.Cleanup(server.Close)
+
+	tracer, err := tracing.InitializeTracerForTest()
+	require.NoError(t, err)
+
+	settings := &backend.DataSourceInstanceSettings{
+		ID:       1,
+		URL:      server.URL,
+		JSONData: []byte(jsonData),
+	}
+	return ProvideService(httpclient.NewProvider(), tracer), prom, settings
+}
+
+func TestResourceHandler(t *testing.T) {
+	start := time.Date(2021, 6, 1, 0, 0, 10, 0, time.UTC)
+	end := start.Add(time.Hour)
+	params := url.Values{
+		"match[]": {`{job="node"}`},
+		"start":   {"1622505610"},
+		"end":     {end.Format(time.RFC3339)},
+	}
+
+	t.Run("label names are scoped by match[] and time range", func(t *testing.T) {
+		s, prom, settings := setupResourceTest(t, `{"metadataCacheDuration": "0s"}`)
+
+		status, body := callResource(t, s, settings, "api/v1/labels", params, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "success", body["status"])
+		require.Equal(t, []interface{}{"__name__", "job"}, body["data"])
+
+		req := prom.lastRequest(t)
+		require.Equal(t, []string{`{job="node"}`}, req.Form["match[]"])
+		require.Equal(t, "1622505610", req.Form.Get("start"))
+		require.Equal(t, "1622509210", req.Form.Get("end"))
+	})
+
+	t.Run("label values", func(t *testing.T) {
+		s, _, settings := setupResourceTest(t, `{}`)
+
+		status, body := callResource(t, s, settings, "api/v1/label/job/values", params, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []interface{}{"prometheus", "node"}, body["data"])
+
+		status, body = callResource(t, s, settings, "api/v1/label/not-a-label/values", nil, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, "error", body["status"])
+		require.Equal(t, "bad_data", body["errorType"])
+	})
+
+	t.Run("series require match[]", func(t *testing.T) {
+		s, prom, settings := setupResourceTest(t, `{}`)
+
+		status, body := callResource(t, s, settings, "api/v1/series", params, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []interface{}{map[string]interface{}{"__name__": "up", "job": "node"}}, body["data"])
+
+		requests := prom.numRequests()
+		status, _ = callResource(t, s, settings, "api/v1/series", url.Values{}, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, requests, prom.numRequests())
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		s, prom, settings := setupResourceTest(t, `{}`)
+
+		status, body := callResource(t, s, settings, "api/v1/metadata", url.Values{"metric": {"up"}}, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Contains(t, body["data"], "up")
+		require.Equal(t, "up", prom.lastRequest(t).Form.Get("metric"))
+	})
+
+	t.Run("rules keep their type", func(t *testing.T) {
+		s, _, settings := setupResourceTest(t, `{}`)
+
+		status, body := callResource(t, s, settings, "api/v1/rules", nil, nil)
+		require.Equal(t, http.StatusOK, status)
+		groups := body["data"].(map[string]interface{})["groups"].([]interface{})
+		rule := groups[0].(map[string]interface{})["rules"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, "recording", rule["type"])
+		require.Equal(t, "job:up:sum", rule["name"])
+		require.Equal(t, "sum by (job) (up)", rule["query"])
+	})
+
+	t.Run("responses are cached", func(t *testing.T) {
+		s, prom, settings := setupResourceTest(t, `{"metadataCacheDuration": "5m"}`)
+
+		_, _ = callResource(t, s, settings, "api/v1/labels", params, nil)
+		require.Equal(t, 1, prom.numRequests())
+		// The time range is extended to multiples of the cache duration.
+		require.Equal(t, "1622505600", prom.lastRequest(t).Form.Get("start"))
+		require.Equal(t, "1622509500", prom.lastRequest(t).Form.Get("end"))
+
+		shifted := url.Values{"match[]": params["match[]"], "start": {"1622505620"}, "end": {"1622509220"}}
+		status, body := callResource(t, s, settings, "api/v1/labels", shifted, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []interface{}{"__name__", "job"}, body["data"])
+		require.Equal(t, 1, prom.numRequests())
+
+		_, _ = callResource(t, s, settings, "api/v1/labels", url.Values{"match[]": {"up"}}, nil)
+		require.Equal(t, 2, prom.numRequests())
+	})
+
+	t.Run("auth headers are only forwarded with OAuth pass-through", func(t *testing.T) {
+		headers := map[string][]string{"Authorization": {"Bearer token"}}
+
+		s, prom, settings := setupResourceTest(t, `{}`)
+		_, _ = callResource(t, s, settings, "api/v1/labels", nil, headers)
+		require.Empty(t, prom.lastRequest(t).Header.Get("Authorization"))
+
+		s, prom, settings = setupResourceTest(t, `{"oauthPassThru": true}`)
+		_, _ = callResource(t, s, settings, "api/v1/labels", nil, headers)
+		require.Equal(t, "Bearer token", prom.lastRequest(t).Header.Get("Authorization"))
+
+		// Users with different tokens don't share cached responses.
+		_, _ = callResource(t, s, settings, "api/v1/labels", nil, map[string][]string{"Authorization": {"Bearer other"}})
+		require.Equal(t, 2, prom.numRequests())
+	})
+
+	t.Run("custom headers of the data source are sent", func(t *testing.T) {
+		prom := &fakePrometheus{}
+		server := httptest.NewServer(prom)
+		t.Cleanup(server.Close)
+
+		s, _, _ := setupResourceTest(t, `{}`)
+		settings := &backend.DataSourceInstanceSettings{
+			ID:                      2,
+			URL:                     server.URL,
+			JSONData:                []byte(`{"httpHeaderName1": "X-Tenant"}`),
+			DecryptedSecureJSONData: map[string]string{"httpHeaderValue1": "team-a"},
+		}
+		_, _ = callResource(t, s, settings, "api/v1/labels", nil, nil)
+		require.Equal(t, "team-a", prom.lastRequest(t).Header.Get("X-Tenant"))
+	})
+}
@@ -3,15 +3,21 @@ package prometheus
 import (
 	"time"
 
+	"github.com/grafana/grafana/pkg/infra/localcache"
 	apiv1 "github.com/prometheus/client_golang/api/prometheus/v1"
 )
 
 type DatasourceInfo struct {
-	ID           int64
-	URL          string
-	TimeInterval string
+	ID            int64
+	URL           string
+	TimeInterval  string
+	OAuthPassThru bool
 
 	getClient clientGetter
+
+	// resourceCache caches the responses of metadata requests, it's nil if caching is disabled.
+	resourceCache         *localcache.CacheService
+	resourceCacheDuration time.Duration
 }
 
 type clientGetter func(map[string]string) (apiv1.API, error)
@@ -0,0 +1,173 @@
+package sqleng
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
+)
+
+// queryCancelTimeout is how long we wait for the database server to acknowledge
+// that a cancelled query has been killed.
+const queryCancelTimeout = 5 * time.Second
+
+// SQLQueryCanceler kills a running query on the database server. It's only needed
+// for drivers that merely drop the client connection when the query context is
+// cancelled, leaving the server to finish the query. lib/pq and go-mssqldb cancel
+// the server-side query themselves and don't need one.
+type SQLQueryCanceler interface {
+	// ConnectionID returns the server-side identifier of conn.
+	ConnectionID(ctx context.Context, conn *sql.Conn) (int64, error)
+	// CancelQuery kills the query currently running on the connection identified by
+	// connectionID, using another connection from db.
+	CancelQuery(ctx context.Context, db *sql.DB, connectionID int64) error
+}
+
+// effectiveLimit returns the stricter of the server wide and the data source
+// specific limit. A limit less than or equal to zero means no limit.
+func effectiveLimit(serverLimit int64, dsLimit int64) int64 {
+	if dsLimit > 0 && (serverLimit <= 0 || dsLimit < serverLimit) {
+		return dsLimit
+	}
+	return serverLimit
+}
+
+// watchCancellation kills the query running on conn on the database server if ctx
+// is cancelled before the returned stop function is called.
+func (e *DataSourceHandler) watchCancellation(ctx context.Context, conn *sql.Conn) (func(), error) {
+	if e.queryCanceler == nil || ctx.Done() == nil {
+		return func() {}, nil
+	}
+
+	connectionID, err := e.queryCanceler.ConnectionID(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			cancelCtx, cancel := context.WithTimeout(context.Background(), queryCancelTimeout)
+			defer cancel()
+			e.log.Debug("Cancelling query", "connectionId", connectionID, "reason", ctx.Err())
+			if err := e.queryCanceler.CancelQuery(cancelCtx, e.engine.DB().DB, connectionID); err != nil {
+				e.log.Warn("Failed to cancel query", "connectionId", connectionID, "err", err)
+			}
+		case <-done:
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}, nil
+}
+
+// frameFromRows works like sqlutil.FrameFromRows, but stops reading once either the
+// row or the byte limit has been reached. In that case the rows read so far are
+// returned together with a warning notice. A limit less than or equal to zero means
+// no limit.
+func frameFromRows(rows *sql.Rows, rowLimit int64, byteLimit int64, converters ...sqlutil.Converter) (*data.Frame, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	scanner, converters, err := sqlutil.MakeScanRow(types, names, converters...)
+	if err != nil {
+		return nil, err
+	}
+
+	frame := sqlutil.NewFrame(names, converters...)
+
+	var rowCount, byteCount int64
+	for rows.Next() {
+		if rowLimit > 0 && rowCount == rowLimit {
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("Results have been limited to %v because the SQL row limit was reached", rowLimit),
+			})
+			break
+		}
+
+		r := scanner.NewScannableRow()
+		if err := rows.Scan(r...); err != nil {
+			return nil, err
+		}
+
+		rowSize := approxRowSize(r)
+		if byteLimit > 0 && byteCount+rowSize > byteLimit {
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text: fmt.Sprintf("Results have been limited to %v rows because the SQL byte limit of %v bytes was reached",
+					rowCount, byteLimit),
+			})
+			break
+		}
+
+		if err := sqlutil.Append(frame, r, converters...); err != nil {
+			return nil, err
+		}
+
+		rowCount++
+		byteCount += rowSize
+	}
+
+	if err := rows.Err(); err != nil {
+		return frame, err
+	}
+
+	return frame, nil
+}
+
+// approxRowSize estimates how much memory the scanned values of a row occupy.
+func approxRowSize(row []interface{}) int64 {
+	var size int64
+	for _, v := range row {
+		size += approxValueSize(reflect.ValueOf(v))
+	}
+	return size
+}
+
+func approxValueSize(v reflect.Value) int64 {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return 0
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Slice:
+		return int64(v.Len()) * int64(v.Type().Elem().Size())
+	case reflect.Struct:
+		// Structs such as sql.NullString hold their variable sized data in string
+		// and slice fields. Pointers are not followed, since those usually point to
+		// shared data, like the location of a time.Time.
+		size := int64(v.Type().Size())
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.Kind() == reflect.String || f.Kind() == reflect.Slice {
+				size += approxValueSize(f)
+			}
+		}
+		return size
+	case reflect.Invalid:
+		return 0
+	default:
+		return int64(v.Type().Size())
+	}
+}
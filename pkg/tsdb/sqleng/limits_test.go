@@ -0,0 +1,149 @@
+package sqleng
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
+	"github.com/grafana/grafana/pkg/infra/log"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveLimit(t *testing.T) {
+	require.Equal(t, int64(100), effectiveLimit(100, 0))
+	require.Equal(t, int64(10), effectiveLimit(100, 10))
+	require.Equal(t, int64(100), effectiveLimit(100, 1000))
+	require.Equal(t, int64(10), effectiveLimit(0, 10))
+	require.Equal(t, int64(0), effectiveLimit(0, 0))
+}
+
+func TestFrameFromRows(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	_, err = db.Exec("CREATE TABLE metric (id INTEGER, value TEXT)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO metric VALUES (1, 'aaaaaaaaaa'), (2, 'bbbbbbbbbb'), (3, 'cccccccccc')")
+	require.NoError(t, err)
+
+	// SQLite only knows the scan type of a column after the first row has been read.
+	asString := &sqlutil.StringFieldReplacer{
+		OutputFieldType: data.FieldTypeNullableString,
+		ReplaceFunc:     func(in *string) (interface{}, error) { return in, nil },
+	}
+	converters := sqlutil.ToConverters(
+		sqlutil.StringConverter{Name: "handle INTEGER", InputTypeName: "INTEGER", Replacer: asString},
+		sqlutil.StringConverter{Name: "handle TEXT", InputTypeName: "TEXT", Replacer: asString},
+	)
+
+	query := func(t *testing.T) *sql.Rows {
+		rows, err := db.Query("SELECT id, value FROM metric ORDER BY id")
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, rows.Close()) })
+		return rows
+	}
+
+	t.Run("Without limits all rows are read", func(t *testing.T) {
+		frame, err := frameFromRows(query(t), 0, 0, converters...)
+		require.NoError(t, err)
+		require.Equal(t, 3, frame.Rows())
+		require.Nil(t, frame.Meta)
+	})
+
+	t.Run("Row limit truncates the result", func(t *testing.T) {
+		frame, err := frameFromRows(query(t), 2, 0, converters...)
+		require.NoError(t, err)
+		require.Equal(t, 2, frame.Rows())
+		require.Len(t, frame.Meta.Notices, 1)
+		require.Contains(t, frame.Meta.Notices[0].Text, "row limit")
+	})
+
+	t.Run("Byte limit truncates the result", func(t *testing.T) {
+		frame, err := frameFromRows(query(t), 0, 100, converters...)
+		require.NoError(t, err)
+		require.Equal(t, 1, frame.Rows())
+		require.Len(t, frame.Meta.Notices, 1)
+		require.Contains(t, frame.Meta.Notices[0].Text, "byte limit of 100 bytes")
+	})
+}
+
+type fakeQueryCanceler struct {
+	mu            sync.Mutex
+	cancelledConn int64
+}
+
+func (c *fakeQueryCanceler) cancelled() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cancelledConn
+}
+
+func (c *fakeQueryCanceler) ConnectionID(ctx context.Context, conn *sql.Conn) (int64, error) {
+	return 42, nil
+}
+
+func (c *fakeQueryCanceler) CancelQuery(ctx context.Context, db *sql.DB, connectionID int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancelledConn = connectionID
+	return nil
+}
+
+func TestWatchCancellation(t *testing.T) {
+	newHandler := func(t *testing.T, canceler SQLQueryCanceler) *DataSourceHandler {
+		handler, err := NewQueryDataHandler(DataPluginConfiguration{
+			DriverName:       "sqlite3",
+			ConnectionString: ":memory:",
+			QueryCanceler:    canceler,
+		}, &testQueryResultTransformer{}, &testMacroEngine{}, log.New("test"))
+		require.NoError(t, err)
+		t.Cleanup(handler.Dispose)
+		return handler
+	}
+
+	t.Run("Cancels the query when the context is cancelled", func(t *testing.T) {
+		canceler := &fakeQueryCanceler{}
+		handler := newHandler(t, canceler)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		conn, err := handler.engine.DB().Conn(ctx)
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		stop, err := handler.watchCancellation(ctx, conn)
+		require.NoError(t, err)
+		cancel()
+		require.Eventually(t, func() bool { return canceler.cancelled() == 42 }, time.Second, 10*time.Millisecond)
+		stop()
+	})
+
+	t.Run("Doesn't cancel the query when it has completed", func(t *testing.T) {
+		canceler := &fakeQueryCanceler{}
+		handler := newHandler(t, canceler)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		conn, err := handler.engine.DB().Conn(ctx)
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		stop, err := handler.watchCancellation(ctx, conn)
+		require.NoError(t, err)
+		stop()
+		cancel()
+		require.Equal(t, int64(0), canceler.cancelled())
+	})
+}
+
+type testMacroEngine struct{}
+
+func (m *testMacroEngine) Interpolate(query *backend.DataQuery, timeRange backend.TimeRange, sql string) (string, error) {
+	return sql, nil
+}
@@ -90,10 +90,9 @@ func resample(f *data.Frame, qm dataQueryModel) (*data.Frame, error) {
 	lastSeenRowIdx := -1
 	timeField := f.Fields[tsSchema.TimeIndex]
 
-	startUnixTime := qm.TimeRange.From.Unix() / int64(qm.Interval.Seconds()) * int64(qm.Interval.Seconds())
-	startTime := time.Unix(startUnixTime, 0)
+	startTime := bucketStart(qm.TimeRange.From, qm.Interval, qm.Location)
 
-	for currentTime := startTime; !currentTime.After(qm.TimeRange.To); currentTime = currentTime.Add(qm.Interval) {
+	for currentTime := startTime; !currentTime.After(qm.TimeRange.To); currentTime = nextBucket(currentTime, qm.Interval, qm.Location) {
 		initialRowIdx := 0
 		if lastSeenRowIdx > 0 {
 			initialRowIdx = lastSeenRowIdx + 1
@@ -114,7 +113,7 @@ func resample(f *data.Frame, qm dataQueryModel) (*data.Frame, error) {
 			}
 
 			// take the last element of the period current - interval <-> current, use it as value for current data point value
-			previousTime := currentTime.Add(-qm.Interval)
+			previousTime := previousBucket(currentTime, qm.Interval, qm.Location)
 			if t.(time.Time).After(previousTime) {
 				if !t.(time.Time).After(currentTime) {
 					intermediateRows = append(intermediateRows, initialRowIdx)
@@ -19,7 +19,6 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
 	"github.com/grafana/grafana/pkg/util/errutil"
-	"xorm.io/core"
 	"xorm.io/xorm"
 )
 
@@ -41,6 +40,18 @@ type SqlQueryResultTransformer interface {
 	GetConverterList() []sqlutil.StringConverter
 }
 
+// SqlQueryResultConverter can be implemented by a SqlQueryResultTransformer whose columns can't be
+// matched by exact type names, the converters are tried before the string converters.
+type SqlQueryResultConverter interface {
+	GetConverters() []sqlutil.Converter
+}
+
+// SqlQueryFrameTransformer can be implemented by a SqlQueryResultTransformer to adjust the data frame
+// before the time and value columns are processed.
+type SqlQueryFrameTransformer interface {
+	TransformFrame(frame *data.Frame, columnTypes []*sql.ColumnType) (*data.Frame, error)
+}
+
 var sqlIntervalCalculator = intervalv2.NewCalculator()
 
 // NewXormEngine is an xorm.Engine factory, that can be stubbed by tests.
@@ -64,6 +75,8 @@ type JsonData struct {
 	Encrypt             string `json:"encrypt"`
 	Servername          string `json:"servername"`
 	TimeInterval        string `json:"timeInterval"`
+	RowLimit            int64  `json:"rowLimit"`
+	BytesLimit          int64  `json:"bytesLimit"`
 }
 
 type DataSourceInfo struct {
@@ -84,6 +97,8 @@ type DataPluginConfiguration struct {
 	TimeColumnNames   []string
 	MetricColumnTypes []string
 	RowLimit          int64
+	BytesLimit        int64
+	QueryCanceler     SQLQueryCanceler
 }
 type DataSourceHandler struct {
 	macroEngine            SQLMacroEngine
@@ -94,6 +109,8 @@ type DataSourceHandler struct {
 	log                    log.Logger
 	dsInfo                 DataSourceInfo
 	rowLimit               int64
+	bytesLimit             int64
+	queryCanceler          SQLQueryCanceler
 }
 type QueryJson struct {
 	RawSql       string  `json:"rawSql"`
@@ -102,6 +119,7 @@ type QueryJson struct {
 	FillMode     string  `json:"fillMode"`
 	FillValue    float64 `json:"fillValue"`
 	Format       string  `json:"format"`
+	Timezone     string  `json:"timezone"`
 }
 
 func (e *DataSourceHandler) transformQueryError(err error) error {
@@ -131,7 +149,9 @@ func NewQueryDataHandler(config DataPluginConfiguration, queryResultTransformer
 		timeColumnNames:        []string{"time"},
 		log:                    log,
 		dsInfo:                 config.DSInfo,
-		rowLimit:               config.RowLimit,
+		rowLimit:               effectiveLimit(config.RowLimit, config.DSInfo.JsonData.RowLimit),
+		bytesLimit:             effectiveLimit(config.BytesLimit, config.DSInfo.JsonData.BytesLimit),
+		queryCanceler:          config.QueryCanceler,
 	}
 
 	if len(config.TimeColumnNames) > 0 {
@@ -230,8 +250,6 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 		panic("Query model property rawSql should not be empty at this point")
 	}
 
-	timeRange := query.TimeRange
-
 	errAppendDebug := func(frameErr string, err error, query string) {
 		var emptyFrame data.Frame
 		emptyFrame.SetMeta(&data.FrameMeta{
@@ -242,8 +260,20 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 		ch <- queryResult
 	}
 
+	// $__timeShift moves the time range of the query back in time, and the result forward again,
+	// so that it can be compared with the unshifted time range.
+	rawSQL, timeShift, err := extractTimeShift(queryJson.RawSql)
+	if err != nil {
+		errAppendDebug("interpolation failed", err, queryJson.RawSql)
+		return
+	}
+	query.TimeRange.From = query.TimeRange.From.Add(-timeShift)
+	query.TimeRange.To = query.TimeRange.To.Add(-timeShift)
+
+	timeRange := query.TimeRange
+
 	// global substitutions
-	interpolatedQuery, err := Interpolate(query, timeRange, e.dsInfo.JsonData.TimeInterval, queryJson.RawSql)
+	interpolatedQuery, err := Interpolate(query, timeRange, e.dsInfo.JsonData.TimeInterval, rawSQL)
 	if err != nil {
 		errAppendDebug("interpolation failed", e.transformQueryError(err), interpolatedQuery)
 		return
@@ -256,11 +286,27 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 		return
 	}
 
-	session := e.engine.NewSession()
-	defer session.Close()
-	db := session.DB()
+	// Use a dedicated connection, so that the query running on it can be killed on the
+	// database server if the request is cancelled.
+	conn, err := e.engine.DB().Conn(queryContext)
+	if err != nil {
+		errAppendDebug("db query error", e.transformQueryError(err), interpolatedQuery)
+		return
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			e.log.Warn("Failed to close connection", "err", err)
+		}
+	}()
+
+	stopWatching, err := e.watchCancellation(queryContext, conn)
+	if err != nil {
+		errAppendDebug("db query error", e.transformQueryError(err), interpolatedQuery)
+		return
+	}
+	defer stopWatching()
 
-	rows, err := db.QueryContext(queryContext, interpolatedQuery)
+	rows, err := conn.QueryContext(queryContext, interpolatedQuery)
 	if err != nil {
 		errAppendDebug("db query error", e.transformQueryError(err), interpolatedQuery)
 		return
@@ -278,18 +324,31 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 	}
 
 	// Convert row.Rows to dataframe
+	var converters []sqlutil.Converter
+	if c, ok := e.queryResultTransformer.(SqlQueryResultConverter); ok {
+		converters = append(converters, c.GetConverters()...)
+	}
 	stringConverters := e.queryResultTransformer.GetConverterList()
-	frame, err := sqlutil.FrameFromRows(rows.Rows, e.rowLimit, sqlutil.ToConverters(stringConverters...)...)
+	converters = append(converters, sqlutil.ToConverters(stringConverters...)...)
+	frame, err := frameFromRows(rows, e.rowLimit, e.bytesLimit, converters...)
 	if err != nil {
 		errAppendDebug("convert frame from rows error", err, interpolatedQuery)
 		return
 	}
 
+	if t, ok := e.queryResultTransformer.(SqlQueryFrameTransformer); ok {
+		if frame, err = t.TransformFrame(frame, qm.columnTypes); err != nil {
+			errAppendDebug("transform frame error", err, interpolatedQuery)
+			return
+		}
+	}
+
 	if frame.Meta == nil {
 		frame.Meta = &data.FrameMeta{}
 	}
 
 	frame.Meta.ExecutedQueryString = interpolatedQuery
+	qm.TimeShift = timeShift
 
 	// If no rows were returned, no point checking anything else.
 	if frame.Rows() == 0 {
@@ -364,6 +423,10 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 		}
 	}
 
+	if qm.TimeShift != 0 {
+		shiftTimeFields(frame, qm.TimeShift)
+	}
+
 	queryResult.dataResponse.Frames = data.Frames{frame}
 	ch <- queryResult
 }
@@ -385,7 +448,7 @@ var Interpolate = func(query backend.DataQuery, timeRange backend.TimeRange, tim
 }
 
 func (e *DataSourceHandler) newProcessCfg(query backend.DataQuery, queryContext context.Context,
-	rows *core.Rows, interpolatedQuery string) (*dataQueryModel, error) {
+	rows *sql.Rows, interpolatedQuery string) (*dataQueryModel, error) {
 	columnNames, err := rows.Columns()
 	if err != nil {
 		return nil, err
@@ -427,6 +490,10 @@ func (e *DataSourceHandler) newProcessCfg(query backend.DataQuery, queryContext
 		}
 	}
 
+	if qm.Location, err = parseLocation(queryJson.Timezone); err != nil {
+		return nil, err
+	}
+
 	qm.TimeRange.From = query.TimeRange.From.UTC()
 	qm.TimeRange.To = query.TimeRange.To.UTC()
 
@@ -459,7 +526,7 @@ func (e *DataSourceHandler) newProcessCfg(query backend.DataQuery, queryContext
 			if qm.metricIndex == -1 {
 				columnType := qm.columnTypes[i].DatabaseTypeName()
 				for _, mct := range e.metricColumnTypes {
-					if columnType == mct {
+					if strings.EqualFold(columnType, mct) {
 						qm.metricIndex = i
 						continue
 					}
@@ -487,12 +554,14 @@ type dataQueryModel struct {
 	TimeRange         backend.TimeRange
 	FillMissing       *data.FillMissing // property not set until after Interpolate()
 	Interval          time.Duration
+	Location          *time.Location // time zone that time buckets are aligned to, nil for the original UTC behavior
+	TimeShift         time.Duration  // set by the $__timeShift macro
 	columnNames       []string
 	columnTypes       []*sql.ColumnType
 	timeIndex         int
 	timeEndIndex      int
 	metricIndex       int
-	rows              *core.Rows
+	rows              *sql.Rows
 	metricPrefix      bool
 	queryContext      context.Context
 }
@@ -0,0 +1,351 @@
+package sqleng
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// week is the length of a week. Buckets that are a multiple of a week start on a Monday.
+	week = 7 * 24 * time.Hour
+	// mondayEpochOffset is the offset of the first Monday after the unix epoch, a Thursday.
+	mondayEpochOffset = int64(4 * 24 * 60 * 60)
+)
+
+var (
+	timeShiftRegExp    = regexp.MustCompile(`\$__timeShift\(([^\)]*)\)`)
+	intervalArgRegExp  = regexp.MustCompile(`^\s*([^*/\s]+)\s*(?:([*/])\s*([0-9]+)\s*)?$`)
+	numericIntervalExp = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// TimeFilterColumnType is the optional type of the column passed to $__timeFilter.
+type TimeFilterColumnType string
+
+const (
+	// TimeFilterColumnTypeDate is a column holding a calendar date.
+	TimeFilterColumnTypeDate TimeFilterColumnType = "date"
+	// TimeFilterColumnTypeDateTime is a column holding a date and time without time zone.
+	TimeFilterColumnTypeDateTime TimeFilterColumnType = "datetime"
+	// TimeFilterColumnTypeTimestampTZ is a column holding a point in time, with time zone.
+	TimeFilterColumnTypeTimestampTZ TimeFilterColumnType = "timestamptz"
+)
+
+// ParseTimeFilterColumnType parses the optional column type argument of $__timeFilter.
+// An empty type means the data source default.
+func ParseTimeFilterColumnType(arg string) (TimeFilterColumnType, error) {
+	switch t := strings.ToLower(strings.Trim(arg, `'"`)); t {
+	case "":
+		return "", nil
+	case "date":
+		return TimeFilterColumnTypeDate, nil
+	case "datetime", "datetime2", "timestamp":
+		return TimeFilterColumnTypeDateTime, nil
+	case "timestamptz", "datetimeoffset":
+		return TimeFilterColumnTypeTimestampTZ, nil
+	default:
+		return "", fmt.Errorf("unsupported column type %q, expected date, datetime or timestamptz", t)
+	}
+}
+
+// ParseIntervalArg parses the interval argument of the grouping macros. Besides a plain
+// interval, such as 5m, an interval can be multiplied or divided by an integer, for
+// example $__interval*2 or 1h/4. A number without unit, such as the value of
+// $__interval_ms, is interpreted as milliseconds.
+func ParseIntervalArg(arg string) (time.Duration, error) {
+	matches := intervalArgRegExp.FindStringSubmatch(strings.Trim(arg, `'"`))
+	if matches == nil {
+		return 0, fmt.Errorf("error parsing interval %v", arg)
+	}
+
+	var interval time.Duration
+	if numericIntervalExp.MatchString(matches[1]) {
+		ms, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error parsing interval %v", arg)
+		}
+		interval = time.Duration(ms) * time.Millisecond
+	} else {
+		var err error
+		if interval, err = gtime.ParseInterval(matches[1]); err != nil {
+			return 0, fmt.Errorf("error parsing interval %v", arg)
+		}
+	}
+
+	if matches[2] != "" {
+		factor, err := strconv.ParseInt(matches[3], 10, 64)
+		if err != nil || factor == 0 {
+			return 0, fmt.Errorf("error parsing interval %v", arg)
+		}
+		if matches[2] == "*" {
+			interval *= time.Duration(factor)
+		} else {
+			interval /= time.Duration(factor)
+		}
+	}
+
+	if interval <= 0 {
+		return 0, fmt.Errorf("error parsing interval %v", arg)
+	}
+
+	return interval, nil
+}
+
+// QueryLocation returns the time zone that time grouping and filtering should be aligned to,
+// as set in the timezone property of the query. The frontend sets it to the dashboard time zone.
+// Nil is returned if no time zone is set, in which case the macros keep their original,
+// time zone agnostic behavior.
+func QueryLocation(query *backend.DataQuery) (*time.Location, error) {
+	if query == nil || len(query.JSON) == 0 {
+		return nil, nil
+	}
+
+	model := struct {
+		Timezone string `json:"timezone"`
+	}{}
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return nil, err
+	}
+
+	return parseLocation(model.Timezone)
+}
+
+func parseLocation(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "", "browser":
+		return nil, nil
+	case "utc":
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// utcOffset is the UTC offset of a time zone, in seconds, from a point in time onwards.
+type utcOffset struct {
+	from   int64
+	offset int64
+}
+
+// utcOffsets returns the UTC offsets of loc between from and to. Daylight saving time and other
+// time zone changes are found by probing once a day and searching for the exact second in between.
+func utcOffsets(loc *time.Location, from time.Time, to time.Time) []utcOffset {
+	offsetAt := func(unix int64) int64 {
+		_, offset := time.Unix(unix, 0).In(loc).Zone()
+		return int64(offset)
+	}
+
+	const step = int64(24 * 60 * 60)
+	start, end := from.Unix(), to.Unix()
+	offsets := []utcOffset{{from: start, offset: offsetAt(start)}}
+	for probe := start; probe < end; probe += step {
+		next := probe + step
+		if next > end {
+			next = end
+		}
+		current := offsets[len(offsets)-1].offset
+		if offsetAt(next) == current {
+			continue
+		}
+		// Binary search the first second with the new offset.
+		lo, hi := probe, next
+		for hi-lo > 1 {
+			mid := lo + (hi-lo)/2
+			if offsetAt(mid) == current {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		offsets = append(offsets, utcOffset{from: hi, offset: offsetAt(hi)})
+	}
+
+	return offsets
+}
+
+func bucketOrigin(interval time.Duration) int64 {
+	if interval%week == 0 {
+		return mondayEpochOffset
+	}
+	return 0
+}
+
+// bucketSeconds returns the interval in seconds, at least 1, as buckets aligned to the wall
+// clock of a location can't be shorter than a second.
+func bucketSeconds(interval time.Duration) int64 {
+	seconds := int64(interval / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// LocalTimeGroup returns an SQL expression that groups epoch, an expression evaluating to unix
+// seconds, into buckets of interval that are aligned to the wall clock of loc. That makes daily
+// buckets start at local midnight, also across daylight saving time changes, and weekly buckets
+// start on Monday. The expression evaluates to the unix seconds at the start of the bucket.
+// The floor function formats an SQL expression rounding its argument down to an integer.
+func LocalTimeGroup(epoch string, interval time.Duration, loc *time.Location, timeRange backend.TimeRange,
+	floor func(string) string) string {
+	seconds := bucketSeconds(interval)
+	origin := bucketOrigin(interval)
+
+	// Buckets overlapping the start of the range begin before it.
+	offsets := utcOffsets(loc, timeRange.From.Add(-interval), timeRange.To.Add(interval))
+
+	if len(offsets) == 1 {
+		shift := offsets[0].offset - origin
+		if shift%seconds == 0 {
+			return fmt.Sprintf("%s*%d", floor(fmt.Sprintf("%s/%d", epoch, seconds)), seconds)
+		}
+		return fmt.Sprintf("%s*%d%s", floor(fmt.Sprintf("(%s%s)/%d", epoch, signed(shift), seconds)), seconds, signed(-shift))
+	}
+
+	// The wall clock time of the row, using the UTC offset at the time of the row.
+	var local strings.Builder
+	local.WriteString("(" + epoch + "+CASE")
+	for i := 1; i < len(offsets); i++ {
+		local.WriteString(fmt.Sprintf(" WHEN %s<%d THEN %d", epoch, offsets[i].from, offsets[i-1].offset))
+	}
+	local.WriteString(fmt.Sprintf(" ELSE %d END)", offsets[len(offsets)-1].offset))
+
+	// The wall clock time the bucket starts at.
+	bucket := fmt.Sprintf("(%s*%d%s)", floor(fmt.Sprintf("(%s%s)/%d", local.String(), signed(-origin), seconds)), seconds, signed(origin))
+
+	// Back to unix seconds, using the UTC offset at the start of the bucket. Wall clock times that
+	// are skipped or repeated by a time zone change use the larger of both offsets.
+	var result strings.Builder
+	result.WriteString(bucket + "-CASE")
+	for i := 1; i < len(offsets); i++ {
+		threshold := offsets[i].from + maxInt64(offsets[i-1].offset, offsets[i].offset)
+		result.WriteString(fmt.Sprintf(" WHEN %s<%d THEN %d", bucket, threshold, offsets[i-1].offset))
+	}
+	result.WriteString(fmt.Sprintf(" ELSE %d END", offsets[len(offsets)-1].offset))
+
+	return result.String()
+}
+
+func signed(v int64) string {
+	if v == 0 {
+		return ""
+	}
+	if v > 0 {
+		return "+" + strconv.FormatInt(v, 10)
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// bucketStart returns the start of the bucket of interval that t falls into, aligned to the
+// wall clock of loc the same way as LocalTimeGroup does. If loc is nil, buckets are aligned to UTC.
+func bucketStart(t time.Time, interval time.Duration, loc *time.Location) time.Time {
+	if loc == nil {
+		// intervals can be shorter than a second, like $__timeGroup(time, 500)
+		nanos := interval.Nanoseconds()
+		return time.Unix(0, floorDiv(t.UnixNano(), nanos)*nanos)
+	}
+
+	seconds := bucketSeconds(interval)
+	_, offset := t.In(loc).Zone()
+	origin := bucketOrigin(interval)
+	wall := floorDiv(t.Unix()+int64(offset)-origin, seconds)*seconds + origin
+	return wallClockTime(wall, loc)
+}
+
+// nextBucket returns the start of the bucket following the one starting at start.
+func nextBucket(start time.Time, interval time.Duration, loc *time.Location) time.Time {
+	if loc == nil {
+		return start.Add(interval)
+	}
+
+	_, offset := start.In(loc).Zone()
+	return wallClockTime(start.Unix()+int64(offset)+bucketSeconds(interval), loc)
+}
+
+// previousBucket returns the start of the bucket preceding the one starting at start.
+func previousBucket(start time.Time, interval time.Duration, loc *time.Location) time.Time {
+	if loc == nil {
+		return start.Add(-interval)
+	}
+
+	_, offset := start.In(loc).Zone()
+	return wallClockTime(start.Unix()+int64(offset)-bucketSeconds(interval), loc)
+}
+
+// wallClockTime returns the time at the wall clock time of loc, given as seconds since
+// 1970-01-01 00:00:00.
+func wallClockTime(wall int64, loc *time.Location) time.Time {
+	days := floorDiv(wall, 24*60*60)
+	secs := wall - days*24*60*60
+	return time.Date(1970, 1, 1+int(days), 0, 0, int(secs), 0, loc)
+}
+
+// extractTimeShift removes the $__timeShift(<interval>) macro from sql and returns the interval.
+func extractTimeShift(sql string) (string, time.Duration, error) {
+	matches := timeShiftRegExp.FindAllStringSubmatch(sql, -1)
+	if len(matches) == 0 {
+		return sql, 0, nil
+	}
+	if len(matches) > 1 {
+		return sql, 0, fmt.Errorf("macro $__timeShift can only be used once per query")
+	}
+
+	shift, err := gtime.ParseInterval(strings.Trim(strings.TrimSpace(matches[0][1]), `'"`))
+	if err != nil {
+		return sql, 0, fmt.Errorf("error parsing time shift %v", matches[0][1])
+	}
+
+	return timeShiftRegExp.ReplaceAllString(sql, ""), shift, nil
+}
+
+// shiftTimeFields moves the values of all time fields of frame forward by shift.
+func shiftTimeFields(frame *data.Frame, shift time.Duration) {
+	for _, field := range frame.Fields {
+		switch field.Type() {
+		case data.FieldTypeTime:
+			for i := 0; i < field.Len(); i++ {
+				field.Set(i, field.At(i).(time.Time).Add(shift))
+			}
+		case data.FieldTypeNullableTime:
+			for i := 0; i < field.Len(); i++ {
+				if t := field.At(i).(*time.Time); t != nil {
+					shifted := t.Add(shift)
+					field.Set(i, &shifted)
+				}
+			}
+		}
+	}
+}
+
+// LocalTimeRange returns the start and end of timeRange in loc, or in UTC if loc is nil.
+func LocalTimeRange(timeRange backend.TimeRange, loc *time.Location) (time.Time, time.Time) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return timeRange.From.In(loc), timeRange.To.In(loc)
+}
@@ -0,0 +1,167 @@
+package sqleng
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIntervalArg(t *testing.T) {
+	tests := []struct {
+		arg      string
+		expected time.Duration
+		err      bool
+	}{
+		{arg: "5m", expected: 5 * time.Minute},
+		{arg: "'5m'", expected: 5 * time.Minute},
+		{arg: `"1h"`, expected: time.Hour},
+		{arg: "1m*2", expected: 2 * time.Minute},
+		{arg: "1h / 4", expected: 15 * time.Minute},
+		{arg: "60000", expected: time.Minute},
+		{arg: "60000*5", expected: 5 * time.Minute},
+		{arg: "1m*0", err: true},
+		{arg: "1m+2", err: true},
+		{arg: "foo", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			interval, err := ParseIntervalArg(tt.arg)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, interval)
+		})
+	}
+}
+
+func TestQueryLocation(t *testing.T) {
+	loc, err := QueryLocation(&backend.DataQuery{JSON: []byte(`{}`)})
+	require.NoError(t, err)
+	require.Nil(t, loc)
+
+	loc, err = QueryLocation(&backend.DataQuery{JSON: []byte(`{"timezone": "browser"}`)})
+	require.NoError(t, err)
+	require.Nil(t, loc)
+
+	loc, err = QueryLocation(&backend.DataQuery{JSON: []byte(`{"timezone": "utc"}`)})
+	require.NoError(t, err)
+	require.Equal(t, time.UTC, loc)
+
+	loc, err = QueryLocation(&backend.DataQuery{JSON: []byte(`{"timezone": "Europe/Berlin"}`)})
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Berlin", loc.String())
+
+	_, err = QueryLocation(&backend.DataQuery{JSON: []byte(`{"timezone": "Mars/Olympus_Mons"}`)})
+	require.Error(t, err)
+}
+
+func TestLocalTimeGroup(t *testing.T) {
+	floor := func(expr string) string { return "floor(" + expr + ")" }
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	t.Run("Without time zone changes in the time range", func(t *testing.T) {
+		timeRange := backend.TimeRange{
+			From: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC),
+		}
+
+		require.Equal(t, "floor(t/300)*300", LocalTimeGroup("t", 5*time.Minute, berlin, timeRange, floor))
+		require.Equal(t, "floor((t+7200)/86400)*86400-7200", LocalTimeGroup("t", 24*time.Hour, berlin, timeRange, floor))
+		require.Equal(t, "floor((t-345600)/604800)*604800+345600", LocalTimeGroup("t", 7*24*time.Hour, time.UTC, timeRange, floor))
+	})
+
+	t.Run("With a daylight saving time change in the time range", func(t *testing.T) {
+		timeRange := backend.TimeRange{
+			From: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2021, 3, 31, 0, 0, 0, 0, time.UTC),
+		}
+		// Daylight saving time starts at 2021-03-28 01:00:00 UTC, unix time 1616893200.
+		expr := LocalTimeGroup("t", 24*time.Hour, berlin, timeRange, floor)
+		require.Contains(t, expr, "(t+CASE WHEN t<1616893200 THEN 3600 ELSE 7200 END)")
+	})
+}
+
+func TestBuckets(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	t.Run("Without location buckets are aligned to UTC", func(t *testing.T) {
+		start := bucketStart(time.Date(2021, 3, 27, 15, 0, 0, 0, time.UTC), 24*time.Hour, nil)
+		require.Equal(t, time.Date(2021, 3, 27, 0, 0, 0, 0, time.UTC).Unix(), start.Unix())
+		require.Equal(t, time.Date(2021, 3, 28, 0, 0, 0, 0, time.UTC).Unix(), nextBucket(start, 24*time.Hour, nil).Unix())
+	})
+
+	t.Run("Daily buckets start at local midnight across daylight saving time changes", func(t *testing.T) {
+		start := bucketStart(time.Date(2021, 3, 27, 15, 0, 0, 0, time.UTC), 24*time.Hour, berlin)
+		require.Equal(t, time.Date(2021, 3, 27, 0, 0, 0, 0, berlin).Unix(), start.Unix())
+
+		next := nextBucket(start, 24*time.Hour, berlin)
+		require.Equal(t, time.Date(2021, 3, 28, 0, 0, 0, 0, berlin).Unix(), next.Unix())
+
+		next = nextBucket(next, 24*time.Hour, berlin)
+		require.Equal(t, time.Date(2021, 3, 29, 0, 0, 0, 0, berlin).Unix(), next.Unix())
+		require.Equal(t, 23*time.Hour, next.Sub(nextBucket(start, 24*time.Hour, berlin)))
+
+		require.Equal(t, start.Unix(), previousBucket(nextBucket(start, 24*time.Hour, berlin), 24*time.Hour, berlin).Unix())
+	})
+
+	t.Run("Weekly buckets start on Monday", func(t *testing.T) {
+		start := bucketStart(time.Date(2021, 6, 10, 15, 0, 0, 0, time.UTC), 7*24*time.Hour, berlin)
+		require.Equal(t, time.Date(2021, 6, 7, 0, 0, 0, 0, berlin).Unix(), start.Unix())
+		require.Equal(t, time.Monday, start.In(berlin).Weekday())
+	})
+
+	t.Run("Buckets can be shorter than a second", func(t *testing.T) {
+		interval, err := ParseIntervalArg("1h/7200")
+		require.NoError(t, err)
+		require.Equal(t, 500*time.Millisecond, interval)
+
+		start := bucketStart(time.Date(2021, 6, 10, 15, 0, 0, 700_000_000, time.UTC), interval, nil)
+		require.Equal(t, time.Date(2021, 6, 10, 15, 0, 0, 500_000_000, time.UTC), start.UTC())
+		require.Equal(t, time.Date(2021, 6, 10, 15, 0, 1, 0, time.UTC), nextBucket(start, interval, nil).UTC())
+
+		// like LocalTimeGroup, buckets aligned to a location last at least a second
+		start = bucketStart(time.Date(2021, 6, 10, 15, 0, 0, 700_000_000, time.UTC), interval, berlin)
+		require.Equal(t, time.Date(2021, 6, 10, 15, 0, 0, 0, time.UTC), start.UTC())
+		require.Equal(t, time.Date(2021, 6, 10, 15, 0, 1, 0, time.UTC), nextBucket(start, interval, berlin).UTC())
+		require.Equal(t, start, previousBucket(nextBucket(start, interval, berlin), interval, berlin))
+	})
+}
+
+func TestExtractTimeShift(t *testing.T) {
+	sql, shift, err := extractTimeShift("SELECT 1 FROM t WHERE $__timeFilter(time) $__timeShift(1w)")
+	require.NoError(t, err)
+	require.Equal(t, "SELECT 1 FROM t WHERE $__timeFilter(time) ", sql)
+	require.Equal(t, 7*24*time.Hour, shift)
+
+	sql, shift, err = extractTimeShift("SELECT 1")
+	require.NoError(t, err)
+	require.Equal(t, "SELECT 1", sql)
+	require.Equal(t, time.Duration(0), shift)
+
+	_, _, err = extractTimeShift("SELECT $__timeShift(1d) $__timeShift(1w)")
+	require.Error(t, err)
+
+	_, _, err = extractTimeShift("SELECT $__timeShift(foo)")
+	require.Error(t, err)
+}
+
+func TestShiftTimeFields(t *testing.T) {
+	t1 := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	frame := data.NewFrame("",
+		data.NewField("time", nil, []time.Time{t1, t1}),
+		data.NewField("timeend", nil, []*time.Time{&t1, nil}),
+	)
+
+	shiftTimeFields(frame, time.Hour)
+	require.Equal(t, t1.Add(time.Hour), frame.Fields[0].At(0))
+	require.Equal(t, t1.Add(time.Hour), *frame.Fields[1].At(0).(*time.Time))
+	require.Nil(t, frame.Fields[1].At(1))
+}
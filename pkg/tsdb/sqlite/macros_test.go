@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacroEngine(t *testing.T) {
+	engine := newSqliteMacroEngine()
+	query := &backend.DataQuery{JSON: []byte("{}")}
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	to := from.Add(5 * time.Minute)
+	timeRange := backend.TimeRange{From: from, To: to}
+
+	t.Run("interpolate __time function", func(t *testing.T) {
+		sql, err := engine.Interpolate(query, timeRange, "select $__time(time_column)")
+		require.NoError(t, err)
+		require.Equal(t, "select CAST(strftime('%s', time_column) AS INTEGER) AS time", sql)
+	})
+
+	t.Run("interpolate __timeFilter function", func(t *testing.T) {
+		sql, err := engine.Interpolate(query, timeRange, "WHERE $__timeFilter(time_column)")
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("WHERE CAST(strftime('%%s', time_column) AS INTEGER) BETWEEN %d AND %d", from.Unix(), to.Unix()), sql)
+	})
+
+	t.Run("interpolate __timeFrom function", func(t *testing.T) {
+		sql, err := engine.Interpolate(query, timeRange, "select $__timeFrom()")
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("select datetime(%d, 'unixepoch')", from.Unix()), sql)
+	})
+
+	t.Run("interpolate __timeGroup function", func(t *testing.T) {
+		sql, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroup(time_column,'5m')")
+		require.NoError(t, err)
+		sql2, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroupAlias(time_column,'5m')")
+		require.NoError(t, err)
+
+		require.Equal(t, "GROUP BY CAST(CAST(strftime('%s', time_column) AS INTEGER)/300 AS INTEGER)*300", sql)
+		require.Equal(t, sql+" AS time", sql2)
+	})
+
+	t.Run("interpolate __timeGroup function with fill mode", func(t *testing.T) {
+		query := &backend.DataQuery{JSON: []byte("{}")}
+		_, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroup(time_column,'5m', previous)")
+		require.NoError(t, err)
+		require.JSONEq(t, `{"fill": true, "fillInterval": 300, "fillMode": "previous"}`, string(query.JSON))
+	})
+
+	t.Run("interpolate __unixEpochGroup function", func(t *testing.T) {
+		sql, err := engine.Interpolate(query, timeRange, "GROUP BY $__unixEpochGroup(time_column,'1h')")
+		require.NoError(t, err)
+		require.Equal(t, "GROUP BY CAST(time_column/3600 AS INTEGER)*3600", sql)
+	})
+
+	t.Run("interpolate __unixEpochFilter function", func(t *testing.T) {
+		sql, err := engine.Interpolate(query, timeRange, "select $__unixEpochFilter(time)")
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("select time >= %d AND time <= %d", from.Unix(), to.Unix()), sql)
+	})
+
+	t.Run("interpolate __timeGroup function with time zone", func(t *testing.T) {
+		query := &backend.DataQuery{JSON: []byte(`{"timezone": "Europe/Berlin"}`)}
+		sql, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroup(time_column,'1d')")
+		require.NoError(t, err)
+		require.Equal(t, "GROUP BY CAST((CAST(strftime('%s', time_column) AS INTEGER)+7200)/86400 AS INTEGER)*86400-7200", sql)
+	})
+
+	t.Run("unknown macro returns an error", func(t *testing.T) {
+		_, err := engine.Interpolate(query, timeRange, "select $__unknown(time)")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,332 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+	"github.com/mattn/go-sqlite3"
+	"xorm.io/core"
+)
+
+const pluginID = "sqlite"
+
+// driverName is the sqlite3 driver the data source connects with, which
+// prevents queries from attaching other database files.
+const driverName = "sqlite3_datasource"
+
+var logger = log.New("tsdb.sqlite")
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		// ATTACH DATABASE works on read-only connections, and the databases
+		// stay attached to pooled connections, so it would let queries read
+		// any file, bypassing the checks of checkPath.
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			conn.SetLimit(sqlite3.SQLITE_LIMIT_ATTACHED, 0)
+			return nil
+		},
+	})
+	core.RegisterDriver(driverName, &xormDriver{})
+}
+
+// xormDriver satisfies the xorm.io/core.Driver interface for driverName.
+type xormDriver struct{}
+
+func (d *xormDriver) Parse(driverName, dataSourceName string) (*core.Uri, error) {
+	driver := core.QueryDriver("sqlite3")
+	if driver == nil {
+		return nil, errors.New("could not find driver with name sqlite3")
+	}
+	return driver.Parse(driverName, dataSourceName)
+}
+
+type Service struct {
+	im instancemgmt.InstanceManager
+}
+
+func ProvideService(cfg *setting.Cfg) *Service {
+	return &Service{
+		im: datasource.NewInstanceManager(newInstanceSettings(cfg)),
+	}
+}
+
+type sqliteJSONData struct {
+	Path string `json:"path"`
+}
+
+func newInstanceSettings(cfg *setting.Cfg) datasource.InstanceFactoryFunc {
+	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		jsonData := sqleng.JsonData{
+			MaxOpenConns:    0,
+			MaxIdleConns:    2,
+			ConnMaxLifetime: 14400,
+		}
+		if err := json.Unmarshal(settings.JSONData, &jsonData); err != nil {
+			return nil, fmt.Errorf("error reading settings: %w", err)
+		}
+		var sqliteData sqliteJSONData
+		if err := json.Unmarshal(settings.JSONData, &sqliteData); err != nil {
+			return nil, fmt.Errorf("error reading settings: %w", err)
+		}
+
+		path, err := checkPath(cfg, sqliteData.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		dsInfo := sqleng.DataSourceInfo{
+			JsonData: jsonData,
+			URL:      path,
+			ID:       settings.ID,
+			Updated:  settings.Updated,
+			UID:      settings.UID,
+		}
+
+		config := sqleng.DataPluginConfiguration{
+			DriverName:        driverName,
+			ConnectionString:  connectionString(path),
+			DSInfo:            dsInfo,
+			MetricColumnTypes: []string{"TEXT", "CHAR", "VARCHAR", "NCHAR", "NVARCHAR", "CLOB"},
+			RowLimit:          cfg.DataProxyRowLimit,
+			BytesLimit:        cfg.DataProxyBytesLimit,
+		}
+
+		return sqleng.NewQueryDataHandler(config, &sqliteQueryResultTransformer{}, newSqliteMacroEngine(), logger)
+	}
+}
+
+// connectionString opens the database file read-only, so that neither queries nor the
+// driver can modify it.
+func connectionString(path string) string {
+	escaped := strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+	return fmt.Sprintf("file:%s?mode=ro&_query_only=true", escaped)
+}
+
+// checkPath returns the cleaned path of the database file. The file has to be in one of the
+// directories in the allowed_paths setting of the [plugin.sqlite] section, and must never be
+// a file in the Grafana data directory, which contains the Grafana database.
+func checkPath(cfg *setting.Cfg, path string) (string, error) {
+	allowed := allowedPaths(cfg)
+	if len(allowed) == 0 {
+		return "", errors.New("database files are disabled, set allowed_paths in the [plugin.sqlite] section of the Grafana configuration to enable them")
+	}
+	if path == "" {
+		return "", errors.New("missing database file path")
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("database file path %q is not absolute", path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("database file %q can't be opened: %w", path, err)
+	}
+
+	if cfg.DataPath != "" && isInDir(resolved, cfg.DataPath) {
+		return "", fmt.Errorf("database file %q is in the Grafana data directory", path)
+	}
+	if cfg.Raw != nil {
+		grafanaDB := cfg.Raw.Section("database").Key("path").MustString("data/grafana.db")
+		if !filepath.IsAbs(grafanaDB) {
+			grafanaDB = filepath.Join(cfg.DataPath, grafanaDB)
+		}
+		if isInDir(resolved, grafanaDB) {
+			return "", fmt.Errorf("database file %q is the Grafana database", path)
+		}
+	}
+
+	for _, dir := range allowed {
+		if isInDir(resolved, dir) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("database file %q is not in one of the allowed paths", path)
+}
+
+func allowedPaths(cfg *setting.Cfg) []string {
+	var paths []string
+	for _, p := range strings.Split(cfg.PluginSettings[pluginID]["allowed_paths"], ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// isInDir reports whether path is dir or a file below dir.
+func isInDir(path string, dir string) bool {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	rel, err := filepath.Rel(filepath.Clean(dir), path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+func (s *Service) getDataSourceHandler(pluginCtx backend.PluginContext) (*sqleng.DataSourceHandler, error) {
+	i, err := s.im.Get(pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+	instance := i.(*sqleng.DataSourceHandler)
+	return instance, nil
+}
+
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	dsHandler, err := s.getDataSourceHandler(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+	return dsHandler.QueryData(ctx, req)
+}
+
+type sqliteQueryResultTransformer struct{}
+
+func (t *sqliteQueryResultTransformer) TransformQueryError(err error) error {
+	return err
+}
+
+func (t *sqliteQueryResultTransformer) GetConverterList() []sqlutil.StringConverter {
+	return nil
+}
+
+// GetConverters returns converters for the declared column types. SQLite columns can store values of
+// any type, so all values are scanned as strings and converted following the type affinity rules in
+// https://www.sqlite.org/datatype3.html. The driver only knows the type of a column after the first row
+// has been read, which is too late for creating the frame.
+func (t *sqliteQueryResultTransformer) GetConverters() []sqlutil.Converter {
+	return []sqlutil.Converter{
+		stringConverter("handle date and time", `(?i)^(DATE|DATETIME|TIMESTAMP)$`, data.FieldTypeNullableTime, parseTime),
+		stringConverter("handle INTEGER affinity", `(?i)INT`, data.FieldTypeNullableInt64, parseInt),
+		stringConverter("handle TEXT affinity", `(?i)CHAR|CLOB|TEXT`, data.FieldTypeNullableString, nil),
+		stringConverter("handle REAL affinity", `(?i)REAL|FLOA|DOUB`, data.FieldTypeNullableFloat64, parseFloat),
+		stringConverter("handle BLOB affinity", `(?i)BLOB`, data.FieldTypeNullableString, nil),
+		// Expressions don't have a declared type, they are converted by TransformFrame.
+		stringConverter("handle expressions", "", data.FieldTypeNullableString, nil),
+		stringConverter("handle NUMERIC affinity", `.`, data.FieldTypeNullableFloat64, parseFloat),
+	}
+}
+
+// TransformFrame converts expression columns, that don't have a declared type, to numbers
+// if all their values are numbers.
+func (t *sqliteQueryResultTransformer) TransformFrame(frame *data.Frame, columnTypes []*sql.ColumnType) (*data.Frame, error) {
+	for i, field := range frame.Fields {
+		if i >= len(columnTypes) || columnTypes[i].DatabaseTypeName() != "" || field.Type() != data.FieldTypeNullableString {
+			continue
+		}
+		if converted := convertNumbers(field, parseInt, data.FieldTypeNullableInt64); converted != nil {
+			frame.Fields[i] = converted
+		} else if converted := convertNumbers(field, parseFloat, data.FieldTypeNullableFloat64); converted != nil {
+			frame.Fields[i] = converted
+		}
+	}
+	return frame, nil
+}
+
+// convertNumbers returns a field of fieldType with the values of field parsed by parse,
+// or nil if a value can't be parsed.
+func convertNumbers(field *data.Field, parse func(string) (interface{}, error), fieldType data.FieldType) *data.Field {
+	converted := data.NewFieldFromFieldType(fieldType, field.Len())
+	converted.Name = field.Name
+	converted.Labels = field.Labels
+	for i := 0; i < field.Len(); i++ {
+		v, ok := field.ConcreteAt(i)
+		if !ok {
+			continue
+		}
+		n, err := parse(v.(string))
+		if err != nil {
+			return nil
+		}
+		converted.Set(i, n)
+	}
+	return converted
+}
+
+// stringConverter returns a converter for the column types matching typeRegex, or for columns
+// without a declared type if typeRegex is empty.
+func stringConverter(name string, typeRegex string, fieldType data.FieldType, parse func(string) (interface{}, error)) sqlutil.Converter {
+	converter := sqlutil.Converter{
+		Name:          name,
+		InputScanType: reflect.TypeOf(sql.NullString{}),
+		FrameConverter: sqlutil.FrameConverter{
+			FieldType: fieldType,
+			ConverterFunc: func(in interface{}) (interface{}, error) {
+				ns := in.(*sql.NullString)
+				if !ns.Valid {
+					return nil, nil
+				}
+				if parse == nil {
+					v := ns.String
+					return &v, nil
+				}
+				return parse(ns.String)
+			},
+		},
+	}
+	if typeRegex != "" {
+		// The type name is compared even if the regular expression doesn't match, so it
+		// mustn't be empty like the type name of expressions.
+		converter.InputTypeName = name
+		converter.InputTypeRegex = regexp.MustCompile(typeRegex)
+	}
+	return converter
+}
+
+func parseInt(s string) (interface{}, error) {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func parseFloat(s string) (interface{}, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// The formats of the SQLite date and time functions, the driver returns RFC 3339 for
+// values it already parsed itself.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+func parseTime(s string) (interface{}, error) {
+	for _, layout := range timeLayouts {
+		if v, err := time.Parse(layout, s); err == nil {
+			return &v, nil
+		}
+	}
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		v := time.Unix(0, int64(secs*float64(time.Second))).UTC()
+		return &v, nil
+	}
+	return nil, fmt.Errorf("unknown date and time format %q", s)
+}
@@ -0,0 +1,181 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestDB(t *testing.T, dir string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "test.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	stmts := []string{
+		`CREATE TABLE metric (time DATETIME, host TEXT, cpu REAL, requests INTEGER, ratio NUMERIC)`,
+		`INSERT INTO metric VALUES ('2021-06-01 00:00:00', 'a', 0.5, 10, 1)`,
+		`INSERT INTO metric VALUES ('2021-06-01 00:00:30', 'a', 1.5, 20, 0.5)`,
+		`INSERT INTO metric VALUES ('2021-06-01 00:10:00', 'a', 2.5, 30, NULL)`,
+	}
+	for _, stmt := range stmts {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+	return path
+}
+
+func newTestService(t *testing.T, allowedPath string) *Service {
+	cfg := setting.NewCfg()
+	cfg.DataPath = t.TempDir()
+	cfg.DataProxyRowLimit = 1000
+	cfg.PluginSettings = setting.PluginSettings{"sqlite": {"allowed_paths": allowedPath}}
+	return ProvideService(cfg)
+}
+
+func queryData(t *testing.T, s *Service, path string, query string) backend.DataResponse {
+	t.Helper()
+
+	from := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	resp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+				ID:       1,
+				JSONData: []byte(fmt.Sprintf(`{"path": %q}`, path)),
+			},
+		},
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				JSON:      []byte(query),
+				TimeRange: backend.TimeRange{From: from, To: from.Add(15 * time.Minute)},
+			},
+		},
+	})
+	require.NoError(t, err)
+	return resp.Responses["A"]
+}
+
+func TestSQLite(t *testing.T) {
+	dir := t.TempDir()
+	path := createTestDB(t, dir)
+	s := newTestService(t, dir)
+
+	t.Run("Columns are converted by their declared type", func(t *testing.T) {
+		res := queryData(t, s, path, `{"rawSql": "SELECT time, host, cpu, requests, ratio, requests * 2 AS doubled FROM metric ORDER BY time", "format": "table"}`)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+
+		frame := res.Frames[0]
+		require.Equal(t, 3, frame.Rows())
+		require.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+		require.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[2].Type())
+		require.Equal(t, data.FieldTypeNullableInt64, frame.Fields[3].Type())
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[4].Type())
+		require.Equal(t, data.FieldTypeNullableInt64, frame.Fields[5].Type())
+
+		require.Equal(t, time.Date(2021, 6, 1, 0, 0, 30, 0, time.UTC), *frame.Fields[0].At(1).(*time.Time))
+		require.Equal(t, int64(40), *frame.Fields[5].At(1).(*int64))
+		require.Nil(t, frame.Fields[4].At(2))
+	})
+
+	t.Run("Time series are grouped and filled", func(t *testing.T) {
+		res := queryData(t, s, path, `{
+			"rawSql": "SELECT $__timeGroupAlias(time, '5m', 0), avg(cpu) AS cpu FROM metric WHERE $__timeFilter(time) GROUP BY 1 ORDER BY 1",
+			"format": "time_series"
+		}`)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+
+		frame := res.Frames[0]
+		require.Equal(t, 4, frame.Rows())
+		require.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC).Unix(), frame.Fields[0].At(0).(*time.Time).Unix())
+		require.Equal(t, 1.0, *frame.Fields[1].At(0).(*float64))
+		require.Equal(t, 0.0, *frame.Fields[1].At(1).(*float64))
+		require.Equal(t, 2.5, *frame.Fields[1].At(2).(*float64))
+	})
+
+	t.Run("The database file is read-only", func(t *testing.T) {
+		res := queryData(t, s, path, `{"rawSql": "INSERT INTO metric VALUES ('2021-06-01 00:20:00', 'b', 1, 1, 1)", "format": "table"}`)
+		require.Error(t, res.Error)
+	})
+
+	t.Run("Other database files can't be attached", func(t *testing.T) {
+		other := createTestDB(t, t.TempDir())
+		res := queryData(t, s, path, fmt.Sprintf(`{"rawSql": "ATTACH DATABASE '%s' AS other", "format": "table"}`, other))
+		require.Error(t, res.Error)
+
+		res = queryData(t, s, path, `{"rawSql": "SELECT * FROM other.metric", "format": "table"}`)
+		require.Error(t, res.Error)
+	})
+}
+
+func TestCheckPath(t *testing.T) {
+	dir := t.TempDir()
+	path := createTestDB(t, dir)
+
+	cfg := setting.NewCfg()
+	cfg.DataPath = t.TempDir()
+	cfg.PluginSettings = setting.PluginSettings{"sqlite": {"allowed_paths": dir + "," + cfg.DataPath}}
+
+	t.Run("Accepts an absolute path", func(t *testing.T) {
+		checked, err := checkPath(cfg, path)
+		require.NoError(t, err)
+		resolved, err := filepath.EvalSymlinks(path)
+		require.NoError(t, err)
+		require.Equal(t, resolved, checked)
+	})
+
+	t.Run("Rejects a relative path", func(t *testing.T) {
+		_, err := checkPath(cfg, "test.db")
+		require.Error(t, err)
+	})
+
+	t.Run("Rejects a file that doesn't exist", func(t *testing.T) {
+		_, err := checkPath(cfg, filepath.Join(dir, "missing.db"))
+		require.Error(t, err)
+	})
+
+	t.Run("Rejects files in the data directory", func(t *testing.T) {
+		grafanaDB := filepath.Join(cfg.DataPath, "grafana.db")
+		require.NoError(t, os.WriteFile(grafanaDB, nil, 0600))
+		_, err := checkPath(cfg, grafanaDB)
+		require.Error(t, err)
+
+		link := filepath.Join(dir, "link.db")
+		require.NoError(t, os.Symlink(grafanaDB, link))
+		_, err = checkPath(cfg, link)
+		require.Error(t, err)
+	})
+
+	t.Run("Only accepts files in the allowed paths", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.DataPath = t.TempDir()
+		cfg.PluginSettings = setting.PluginSettings{"sqlite": {"allowed_paths": "/nonexistent, " + dir}}
+		_, err := checkPath(cfg, path)
+		require.NoError(t, err)
+
+		cfg.PluginSettings = setting.PluginSettings{"sqlite": {"allowed_paths": "/nonexistent"}}
+		_, err = checkPath(cfg, path)
+		require.Error(t, err)
+	})
+
+	t.Run("Rejects any file if the allowed paths are not set", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.DataPath = t.TempDir()
+		_, err := checkPath(cfg, path)
+		require.Error(t, err)
+	})
+}
@@ -12,6 +12,12 @@ export interface FormModel {
   user: string;
   password: string;
   email: string;
+  otp?: string;
+}
+
+export interface SecondFactor {
+  // enrolment is set when the user must enrol a second factor before signing in
+  enrolment?: { secret: string; url: string };
 }
 
 interface Props {
@@ -30,12 +36,16 @@ interface Props {
     isOauthEnabled: boolean;
     loginHint: string;
     passwordHint: string;
+    secondFactor?: SecondFactor;
+    recoveryCodes?: string[];
   }) => JSX.Element;
 }
 
 interface State {
   isLoggingIn: boolean;
   isChangingPassword: boolean;
+  secondFactor?: SecondFactor;
+  recoveryCodes?: string[];
 }
 
 export class LoginCtrl extends PureComponent<Props, State> {
@@ -91,6 +101,10 @@ export class LoginCtrl extends PureComponent<Props, State> {
       .post('/login', formModel)
       .then((result: any) => {
         this.result = result;
+        if (result.recoveryCodes) {
+          this.setState({ recoveryCodes: result.recoveryCodes });
+          return;
+        }
         if (formModel.password !== 'admin' || config.ldapEnabled || config.authProxyEnabled) {
           this.toGrafana();
           return;
@@ -98,9 +112,17 @@ export class LoginCtrl extends PureComponent<Props, State> {
           this.changeView();
         }
       })
-      .catch(() => {
+      .catch((err: any) => {
+        const data = err?.data ?? {};
+        let secondFactor = this.state.secondFactor;
+        if (data.totpEnrolment) {
+          secondFactor = { enrolment: data.totpEnrolment };
+        } else if (data.totpRequired) {
+          secondFactor = {};
+        }
         this.setState({
           isLoggingIn: false,
+          secondFactor,
         });
       });
   };
@@ -126,7 +148,7 @@ export class LoginCtrl extends PureComponent<Props, State> {
 
   render() {
     const { children } = this.props;
-    const { isLoggingIn, isChangingPassword } = this.state;
+    const { isLoggingIn, isChangingPassword, secondFactor, recoveryCodes } = this.state;
     const { login, toGrafana, changePassword } = this;
     const { loginHint, passwordHint, disableLoginForm, ldapEnabled, authProxyEnabled, disableUserSignUp } = config;
 
@@ -145,6 +167,8 @@ export class LoginCtrl extends PureComponent<Props, State> {
           changePassword,
           skipPasswordChange: toGrafana,
           isChangingPassword,
+          secondFactor,
+          recoveryCodes,
         })}
       </>
     );
@@ -1,7 +1,7 @@
 import React, { FC, ReactElement } from 'react';
 import { selectors } from '@grafana/e2e-selectors';
 
-import { FormModel } from './LoginCtrl';
+import { FormModel, SecondFactor } from './LoginCtrl';
 import { Button, Form, Input, Field } from '@grafana/ui';
 import { css } from '@emotion/css';
 import { PasswordField } from '../PasswordField/PasswordField';
@@ -12,6 +12,7 @@ interface Props {
   isLoggingIn: boolean;
   passwordHint: string;
   loginHint: string;
+  secondFactor?: SecondFactor;
 }
 
 const wrapperStyles = css`
@@ -24,7 +25,12 @@ export const submitButton = css`
   width: 100%;
 `;
 
-export const LoginForm: FC<Props> = ({ children, onSubmit, isLoggingIn, passwordHint, loginHint }) => {
+const secretStyles = css`
+  font-family: monospace;
+  word-break: break-all;
+`;
+
+export const LoginForm: FC<Props> = ({ children, onSubmit, isLoggingIn, passwordHint, loginHint, secondFactor }) => {
   return (
     <div className={wrapperStyles}>
       <Form onSubmit={onSubmit} validateOn="onChange">
@@ -47,6 +53,27 @@ export const LoginForm: FC<Props> = ({ children, onSubmit, isLoggingIn, password
                 {...register('password', { required: 'Password is required' })}
               />
             </Field>
+            {secondFactor?.enrolment && (
+              <p>
+                Two-factor authentication is required for your account. Scan the{' '}
+                <a href={secondFactor.enrolment.url}>setup link</a> as a QR code with your authenticator app, or enter
+                the key <span className={secretStyles}>{secondFactor.enrolment.secret}</span>.
+              </p>
+            )}
+            {secondFactor && (
+              <Field
+                label="Authentication code"
+                description={secondFactor.enrolment ? undefined : 'Code of your authenticator app, or a recovery code'}
+                invalid={!!errors.otp}
+                error={errors.otp?.message}
+              >
+                <Input
+                  {...register('otp', { required: 'Authentication code is required' })}
+                  autoFocus
+                  autoComplete="one-time-code"
+                />
+              </Field>
+            )}
             <Button aria-label={selectors.pages.Login.submit} className={submitButton} disabled={isLoggingIn}>
               {isLoggingIn ? 'Logging in...' : 'Log in'}
             </Button>
@@ -7,6 +7,7 @@ import { UserSignup } from './UserSignup';
 import { LoginServiceButtons } from './LoginServiceButtons';
 import LoginCtrl from './LoginCtrl';
 import { LoginForm } from './LoginForm';
+import { RecoveryCodes } from './RecoveryCodes';
 import { ChangePassword } from '../ForgottenPassword/ChangePassword';
 import { Branding } from 'app/core/components/Branding/Branding';
 import { HorizontalGroup, LinkButton } from '@grafana/ui';
@@ -35,9 +36,16 @@ export const LoginPage: FC = () => {
           changePassword,
           skipPasswordChange,
           isChangingPassword,
+          secondFactor,
+          recoveryCodes,
         }) => (
           <>
-            {!isChangingPassword && (
+            {recoveryCodes && (
+              <InnerBox>
+                <RecoveryCodes codes={recoveryCodes} onContinue={() => skipPasswordChange()} />
+              </InnerBox>
+            )}
+            {!isChangingPassword && !recoveryCodes && (
               <InnerBox>
                 {!disableLoginForm && (
                   <LoginForm
@@ -45,6 +53,7 @@ export const LoginPage: FC = () => {
                     loginHint={loginHint}
                     passwordHint={passwordHint}
                     isLoggingIn={isLoggingIn}
+                    secondFactor={secondFactor}
                   >
                     {!(ldapEnabled || authProxyEnabled) ? (
                       <HorizontalGroup justify="flex-end">
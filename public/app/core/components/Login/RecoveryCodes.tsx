@@ -0,0 +1,35 @@
+import React, { FC } from 'react';
+import { css } from '@emotion/css';
+import { Button } from '@grafana/ui';
+
+import { submitButton } from './LoginForm';
+
+interface Props {
+  codes: string[];
+  onContinue: () => void;
+}
+
+const codesStyles = css`
+  font-family: monospace;
+  columns: 2;
+  padding: 16px 0;
+`;
+
+export const RecoveryCodes: FC<Props> = ({ codes, onContinue }) => {
+  return (
+    <div>
+      <p>
+        Two-factor authentication is enabled. Keep these recovery codes somewhere safe, each of them lets you sign in
+        once if you lose your authenticator app. They won&apos;t be shown again.
+      </p>
+      <div className={codesStyles}>
+        {codes.map((code) => (
+          <div key={code}>{code}</div>
+        ))}
+      </div>
+      <Button className={submitButton} onClick={onContinue}>
+        Continue
+      </Button>
+    </div>
+  );
+};
@@ -23,6 +23,7 @@ interface TabInfo {
   icon?: string;
 }
 const tabs: TabInfo[] = [
+  { label: 'Inputs', type: 'inputs' },
   { label: 'Converter', type: 'converter', isConverter: true },
   { label: 'Processors', type: 'frameProcessors' },
   { label: 'Outputs', type: 'frameOutputs' },
@@ -11,6 +11,10 @@ export interface Output extends RuleSetting {
   [t: string]: any;
 }
 
+export interface Input extends RuleSetting {
+  [t: string]: any;
+}
+
 export interface RuleSetting<T = any> {
   type: string;
   [key: string]: any;
@@ -19,11 +23,13 @@ export interface RuleSettings {
   converter?: Converter;
   frameProcessors?: Processor[];
   frameOutputs?: Output[];
+  inputs?: Input[];
 }
 
 export interface Rule {
   pattern: string;
   settings: RuleSettings;
+  version?: number;
 }
 
 export interface Pipeline {
@@ -33,9 +39,10 @@ export interface Pipeline {
 export interface GrafanaCloudBackend {
   uid: string;
   settings: any;
+  version?: number;
 }
 
-export type RuleType = 'converter' | 'frameProcessors' | 'frameOutputs';
+export type RuleType = 'converter' | 'frameProcessors' | 'frameOutputs' | 'inputs';
 
 export interface PipelineListOption {
   type: string;
@@ -46,12 +53,14 @@ export interface EntitiesTypes {
   converters: PipelineListOption[];
   frameProcessors: PipelineListOption[];
   frameOutputs: PipelineListOption[];
+  inputs: PipelineListOption[];
 }
 
 export interface PipeLineEntitiesInfo {
   converter: SelectableValue[];
   frameProcessors: SelectableValue[];
   frameOutputs: SelectableValue[];
+  inputs: SelectableValue[];
   getExample: (rule: RuleType, type: string) => object;
 }
 
@@ -9,8 +9,10 @@ export async function getPipeLineEntities(): Promise<PipeLineEntitiesInfo> {
         converter: transformLabel(data, 'converters'),
         frameProcessors: transformLabel(data, 'frameProcessors'),
         frameOutputs: transformLabel(data, 'frameOutputs'),
+        inputs: transformLabel(data, 'inputs'),
         getExample: (ruleType, type) => {
-          return data[`${ruleType}s`]?.filter((option: PipelineListOption) => option.type === type)?.[0]?.['example'];
+          const key = ruleType === 'converter' ? 'converters' : ruleType;
+          return data[key]?.filter((option: PipelineListOption) => option.type === type)?.[0]?.['example'];
         },
       };
     });
@@ -9,9 +9,22 @@ export interface ChannelAuthConfig {
   subscribe?: ChannelAuthCheckConfig;
   publish?: ChannelAuthCheckConfig;
 }
+export interface StateAnnotationConfig {
+  dashboardUID?: string;
+  panelId?: number;
+  tags?: string[];
+}
+export interface StateAlertConfig {
+  name?: string;
+  states?: string[];
+  labels?: Record<string, string>;
+  annotations?: Record<string, string>;
+}
 export interface ChangeLogOutputConfig {
   fieldName: string;
   channel: string;
+  annotation?: StateAnnotationConfig;
+  alert?: StateAlertConfig;
 }
 export interface RemoteWriteOutputConfig {
   uid: string;
@@ -20,6 +33,8 @@ export interface RemoteWriteOutputConfig {
 export interface ThresholdOutputConfig {
   fieldName: string;
   channel: string;
+  annotation?: StateAnnotationConfig;
+  alert?: StateAlertConfig;
 }
 export interface NumberCompareFrameConditionConfig {
   fieldName: string;
@@ -60,6 +75,36 @@ export interface FrameOutputterConfig {
 export interface MultipleFrameProcessorConfig {
   processors: FrameProcessorConfig[];
 }
+export interface Label {
+  name: string;
+  value: string;
+}
+export interface LabelsFrameProcessorConfig {
+  labels: Label[];
+}
+export interface AggregateFieldConfig {
+  name: string;
+  function: string;
+}
+export interface AggregateFrameProcessorConfig {
+  interval: string;
+  fields?: AggregateFieldConfig[];
+  defaultFunction?: string;
+}
+export interface ExpressionFrameProcessorConfig {
+  fieldName: string;
+  expression: string;
+  type?: number;
+  config?: FieldConfig;
+}
+export interface SetFieldConfig {
+  name: string;
+  rename?: string;
+  config?: FieldConfig;
+}
+export interface SetFieldsFrameProcessorConfig {
+  fields: SetFieldConfig[];
+}
 export interface KeepFieldsFrameProcessorConfig {
   fieldNames: string[];
 }
@@ -70,6 +115,10 @@ export interface FrameProcessorConfig {
   type: Omit<keyof FrameProcessorConfig, 'type'>;
   dropFields?: DropFieldsFrameProcessorConfig;
   keepFields?: KeepFieldsFrameProcessorConfig;
+  setFields?: SetFieldsFrameProcessorConfig;
+  expression?: ExpressionFrameProcessorConfig;
+  aggregate?: AggregateFrameProcessorConfig;
+  labels?: LabelsFrameProcessorConfig;
   multiple?: MultipleFrameProcessorConfig;
 }
 export interface JsonFrameConverterConfig {}
@@ -79,10 +128,6 @@ export interface AutoInfluxConverterConfig {
 export interface ExactJsonConverterConfig {
   fields: Field[];
 }
-export interface Label {
-  name: string;
-  value: string;
-}
 export interface Field {
   name: string;
   type: number;
@@ -0,0 +1,57 @@
+import React from 'react';
+import {
+  DataSourcePluginOptionsEditorProps,
+  onUpdateDatasourceJsonDataOption,
+  updateDatasourcePluginJsonDataOption,
+} from '@grafana/data';
+import { DataSourceHttpSettings, InlineField, Input, RadioButtonGroup } from '@grafana/ui';
+import { FileDataOptions, FileDataSource } from '../types';
+
+const sources: Array<{ label: string; value: FileDataSource }> = [
+  { label: 'HTTP', value: 'http' },
+  { label: 'Local files', value: 'local' },
+];
+
+export type Props = DataSourcePluginOptionsEditorProps<FileDataOptions>;
+
+export const ConfigEditor = (props: Props) => {
+  const { options, onOptionsChange } = props;
+  const source = options.jsonData.source ?? 'http';
+
+  return (
+    <>
+      <div className="gf-form-group">
+        <InlineField label="Source" labelWidth={14}>
+          <RadioButtonGroup
+            options={sources}
+            value={source}
+            onChange={(value) => updateDatasourcePluginJsonDataOption(props, 'source', value)}
+          />
+        </InlineField>
+        {source === 'local' && (
+          <InlineField
+            label="Directory"
+            labelWidth={14}
+            tooltip="Absolute path of the directory on the Grafana server. It has to be in one of the allowed_paths of the [plugin.filedata] section of the Grafana configuration."
+          >
+            <Input
+              className="width-30"
+              value={options.jsonData.path ?? ''}
+              placeholder="/var/lib/grafana-files"
+              onChange={onUpdateDatasourceJsonDataOption(props, 'path')}
+            />
+          </InlineField>
+        )}
+      </div>
+
+      {source === 'http' && (
+        <DataSourceHttpSettings
+          defaultUrl="http://localhost:8080"
+          dataSourceConfig={options}
+          showAccessOptions={false}
+          onChange={onOptionsChange}
+        />
+      )}
+    </>
+  );
+};
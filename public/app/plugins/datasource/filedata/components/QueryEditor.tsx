@@ -0,0 +1,128 @@
+import React from 'react';
+import { QueryEditorProps, SelectableValue } from '@grafana/data';
+import { Button, IconButton, InlineField, InlineFieldRow, InlineSwitch, Input, Select } from '@grafana/ui';
+import { FileDataDatasource } from '../datasource';
+import { FileDataField, FileDataFieldType, FileDataFormat, FileDataOptions, FileDataQuery } from '../types';
+
+const formats: Array<SelectableValue<FileDataFormat | undefined>> = [
+  { label: 'From extension', value: undefined },
+  { label: 'CSV', value: 'csv' },
+  { label: 'JSON', value: 'json' },
+  { label: 'NDJSON', value: 'ndjson' },
+];
+
+const fieldTypes: Array<SelectableValue<FileDataFieldType>> = [
+  { label: 'Auto', value: 'auto' },
+  { label: 'String', value: 'string' },
+  { label: 'Number', value: 'number' },
+  { label: 'Boolean', value: 'boolean' },
+  { label: 'Time', value: 'time' },
+];
+
+type Props = QueryEditorProps<FileDataDatasource, FileDataQuery, FileDataOptions>;
+
+export const QueryEditor = ({ query, onChange, onRunQuery }: Props) => {
+  const fields = query.fields ?? [];
+
+  const onUpdate = (update: Partial<FileDataQuery>, run = true) => {
+    onChange({ ...query, ...update });
+    if (run) {
+      onRunQuery();
+    }
+  };
+
+  const onFieldChange = (index: number, update: Partial<FileDataField>, run = true) => {
+    onUpdate({ fields: fields.map((f, i) => (i === index ? { ...f, ...update } : f)) }, run);
+  };
+
+  return (
+    <>
+      <InlineFieldRow>
+        <InlineField label="Path" labelWidth={14} grow tooltip="File name or URL path relative to the data source">
+          <Input
+            value={query.path ?? ''}
+            placeholder="metrics.csv"
+            onChange={(e) => onUpdate({ path: e.currentTarget.value }, false)}
+            onBlur={onRunQuery}
+          />
+        </InlineField>
+        <InlineField label="Format">
+          <Select
+            width={20}
+            menuShouldPortal
+            options={formats}
+            value={query.format}
+            onChange={(v) => onUpdate({ format: v.value })}
+          />
+        </InlineField>
+      </InlineFieldRow>
+      <InlineFieldRow>
+        <InlineField label="Root path" labelWidth={14} grow tooltip="JSONPath of the records, for example $.data.items">
+          <Input
+            value={query.rootPath ?? ''}
+            placeholder="$"
+            onChange={(e) => onUpdate({ rootPath: e.currentTarget.value }, false)}
+            onBlur={onRunQuery}
+          />
+        </InlineField>
+        <InlineField label="Time field">
+          <Input
+            width={20}
+            value={query.timeField ?? ''}
+            onChange={(e) => onUpdate({ timeField: e.currentTarget.value }, false)}
+            onBlur={onRunQuery}
+          />
+        </InlineField>
+        <InlineField label="Filter by time" tooltip="Only return the rows in the time range of the dashboard">
+          <InlineSwitch
+            value={!!query.filterByTime}
+            onChange={(e) => onUpdate({ filterByTime: e.currentTarget.checked })}
+          />
+        </InlineField>
+      </InlineFieldRow>
+      {fields.map((field, index) => (
+        <InlineFieldRow key={index}>
+          <InlineField label="Field" labelWidth={14}>
+            <Input
+              width={20}
+              value={field.name}
+              placeholder="Name"
+              onChange={(e) => onFieldChange(index, { name: e.currentTarget.value }, false)}
+              onBlur={onRunQuery}
+            />
+          </InlineField>
+          <InlineField label="JSONPath" grow>
+            <Input
+              value={field.jsonPath ?? ''}
+              placeholder="$.name"
+              onChange={(e) => onFieldChange(index, { jsonPath: e.currentTarget.value }, false)}
+              onBlur={onRunQuery}
+            />
+          </InlineField>
+          <InlineField label="Type">
+            <Select
+              width={14}
+              menuShouldPortal
+              options={fieldTypes}
+              value={field.type ?? 'auto'}
+              onChange={(v) => onFieldChange(index, { type: v.value })}
+            />
+          </InlineField>
+          <IconButton
+            name="trash-alt"
+            aria-label="Remove field"
+            onClick={() => onUpdate({ fields: fields.filter((_, i) => i !== index) })}
+          />
+        </InlineFieldRow>
+      ))}
+      <Button
+        variant="secondary"
+        size="sm"
+        icon="plus"
+        onClick={() => onUpdate({ fields: [...fields, { name: '' }] }, false)}
+      >
+        Add field
+      </Button>
+    </>
+  );
+};
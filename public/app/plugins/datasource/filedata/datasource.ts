@@ -0,0 +1,22 @@
+import { DataSourceInstanceSettings, ScopedVars } from '@grafana/data';
+import { DataSourceWithBackend, getTemplateSrv } from '@grafana/runtime';
+import { FileDataOptions, FileDataQuery } from './types';
+
+export class FileDataDatasource extends DataSourceWithBackend<FileDataQuery, FileDataOptions> {
+  constructor(instanceSettings: DataSourceInstanceSettings<FileDataOptions>) {
+    super(instanceSettings);
+  }
+
+  filterQuery(query: FileDataQuery): boolean {
+    return !query.hide && !!query.path;
+  }
+
+  applyTemplateVariables(query: FileDataQuery, scopedVars: ScopedVars): FileDataQuery {
+    const templateSrv = getTemplateSrv();
+    return {
+      ...query,
+      path: templateSrv.replace(query.path, scopedVars),
+      rootPath: templateSrv.replace(query.rootPath, scopedVars),
+    };
+  }
+}
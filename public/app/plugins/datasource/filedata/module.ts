@@ -0,0 +1,9 @@
+import { DataSourcePlugin } from '@grafana/data';
+import { FileDataDatasource } from './datasource';
+import { ConfigEditor } from './components/ConfigEditor';
+import { QueryEditor } from './components/QueryEditor';
+import { FileDataOptions, FileDataQuery } from './types';
+
+export const plugin = new DataSourcePlugin<FileDataDatasource, FileDataQuery, FileDataOptions>(FileDataDatasource)
+  .setConfigEditor(ConfigEditor)
+  .setQueryEditor(QueryEditor);
@@ -0,0 +1,27 @@
+import { DataQuery, DataSourceJsonData } from '@grafana/data';
+
+export type FileDataSource = 'local' | 'http';
+
+export type FileDataFormat = 'csv' | 'json' | 'ndjson';
+
+export type FileDataFieldType = 'auto' | 'string' | 'number' | 'boolean' | 'time';
+
+export interface FileDataOptions extends DataSourceJsonData {
+  source?: FileDataSource;
+  path?: string;
+}
+
+export interface FileDataField {
+  name: string;
+  jsonPath?: string;
+  type?: FileDataFieldType;
+}
+
+export interface FileDataQuery extends DataQuery {
+  path?: string;
+  format?: FileDataFormat;
+  rootPath?: string;
+  fields?: FileDataField[];
+  timeField?: string;
+  filterByTime?: boolean;
+}
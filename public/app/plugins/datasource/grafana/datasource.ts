@@ -15,6 +15,7 @@ import {
   DataSourceInstanceSettings,
   DataSourceRef,
   isValidLiveChannelAddress,
+  LiveChannelScope,
   MutableDataFrame,
   parseLiveChannelAddress,
   toDataFrame,
@@ -90,7 +91,7 @@ export class GrafanaDatasource extends DataSourceWithBackend<GrafanaQuery> {
           target.channel = channel; // mutate the current query object so it is saved with `stream/` prefix
         }
 
-        const addr = parseLiveChannelAddress(channel);
+        let addr = parseLiveChannelAddress(channel);
         if (!isValidLiveChannelAddress(addr)) {
           continue;
         }
@@ -104,6 +105,11 @@ export class GrafanaDatasource extends DataSourceWithBackend<GrafanaQuery> {
           buffer.maxDelta = request.range.to.valueOf() - request.range.from.valueOf();
         }
 
+        // Managed streams can return the recent stream on join when history is enabled.
+        if (addr!.scope === LiveChannelScope.Stream && buffer.maxDelta && !addr!.data) {
+          addr = { ...addr!, data: { history: `${Math.ceil(buffer.maxDelta / 1000)}s` } };
+        }
+
         results.push(
           getGrafanaLiveSrv().getDataStream({
             key: `${request.requestId}.${counter++}`,
@@ -1,8 +1,17 @@
 import { map as _map } from 'lodash';
-import { lastValueFrom, of } from 'rxjs';
+import { lastValueFrom, Observable, of } from 'rxjs';
 import { catchError, map, mapTo } from 'rxjs/operators';
 import { BackendDataSourceResponse, DataSourceWithBackend, FetchResponse, getBackendSrv } from '@grafana/runtime';
-import { AnnotationEvent, DataSourceInstanceSettings, MetricFindValue, ScopedVars, TimeRange } from '@grafana/data';
+import {
+  AnnotationEvent,
+  DataQueryRequest,
+  DataQueryResponse,
+  DataSourceInstanceSettings,
+  getTimeZoneInfo,
+  MetricFindValue,
+  ScopedVars,
+  TimeRange,
+} from '@grafana/data';
 
 import ResponseParser from './response_parser';
 import { getTemplateSrv, TemplateSrv } from 'app/features/templating/template_srv';
@@ -75,9 +84,16 @@ export class MssqlDatasource extends DataSourceWithBackend<MssqlQuery, MssqlOpti
       datasource: this.getRef(),
       rawSql: this.templateSrv.replace(target.rawSql, scopedVars, this.interpolateVariable),
       format: target.format,
+      timezone: target.timezone,
     };
   }
 
+  query(request: DataQueryRequest<MssqlQuery>): Observable<DataQueryResponse> {
+    // Time grouping macros align buckets, such as days and weeks, to the dashboard time zone.
+    const timezone = getTimeZoneInfo(request.timezone, Date.now())?.ianaName;
+    return super.query({ ...request, targets: request.targets.map((target) => ({ ...target, timezone })) });
+  }
+
   async annotationQuery(options: any): Promise<AnnotationEvent[]> {
     if (!options.annotation.rawQuery) {
       return Promise.reject({ message: 'Query missing in annotation definition' });
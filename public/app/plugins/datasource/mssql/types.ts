@@ -14,6 +14,7 @@ export interface MssqlQuery extends DataQuery {
   alias?: string;
   format?: ResultFormat;
   rawSql?: any;
+  timezone?: string;
 }
 
 export interface MssqlOptions extends DataSourceJsonData {
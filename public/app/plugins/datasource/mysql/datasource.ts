@@ -1,8 +1,16 @@
 import { map as _map } from 'lodash';
-import { lastValueFrom, of } from 'rxjs';
+import { lastValueFrom, Observable, of } from 'rxjs';
 import { catchError, map, mapTo } from 'rxjs/operators';
 import { BackendDataSourceResponse, DataSourceWithBackend, FetchResponse, getBackendSrv } from '@grafana/runtime';
-import { AnnotationEvent, DataSourceInstanceSettings, MetricFindValue, ScopedVars } from '@grafana/data';
+import {
+  AnnotationEvent,
+  DataQueryRequest,
+  DataQueryResponse,
+  DataSourceInstanceSettings,
+  getTimeZoneInfo,
+  MetricFindValue,
+  ScopedVars,
+} from '@grafana/data';
 
 import MySQLQueryModel from 'app/plugins/datasource/mysql/mysql_query_model';
 import ResponseParser from './response_parser';
@@ -81,9 +89,16 @@ export class MysqlDatasource extends DataSourceWithBackend<MySQLQuery, MySQLOpti
       datasource: this.getRef(),
       rawSql: queryModel.render(this.interpolateVariable as any),
       format: target.format,
+      timezone: target.timezone,
     };
   }
 
+  query(request: DataQueryRequest<MySQLQuery>): Observable<DataQueryResponse> {
+    // Time grouping macros align buckets, such as days and weeks, to the dashboard time zone.
+    const timezone = getTimeZoneInfo(request.timezone, Date.now())?.ianaName;
+    return super.query({ ...request, targets: request.targets.map((target) => ({ ...target, timezone })) });
+  }
+
   async annotationQuery(options: any): Promise<AnnotationEvent[]> {
     if (!options.annotation.rawQuery) {
       return Promise.reject({
@@ -17,4 +17,5 @@ export interface MySQLQuery extends DataQuery {
   alias?: string;
   format?: ResultFormat;
   rawSql?: any;
+  timezone?: string;
 }
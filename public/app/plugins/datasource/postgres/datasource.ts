@@ -1,8 +1,17 @@
 import { map as _map } from 'lodash';
-import { lastValueFrom, of } from 'rxjs';
+import { lastValueFrom, Observable, of } from 'rxjs';
 import { map, catchError } from 'rxjs/operators';
 import { BackendDataSourceResponse, DataSourceWithBackend, FetchResponse, getBackendSrv } from '@grafana/runtime';
-import { AnnotationEvent, DataSourceInstanceSettings, MetricFindValue, ScopedVars, TimeRange } from '@grafana/data';
+import {
+  AnnotationEvent,
+  DataQueryRequest,
+  DataQueryResponse,
+  DataSourceInstanceSettings,
+  getTimeZoneInfo,
+  MetricFindValue,
+  ScopedVars,
+  TimeRange,
+} from '@grafana/data';
 
 import ResponseParser from './response_parser';
 import PostgresQueryModel from 'app/plugins/datasource/postgres/postgres_query_model';
@@ -83,9 +92,16 @@ export class PostgresDatasource extends DataSourceWithBackend<PostgresQuery, Pos
       datasource: this.getRef(),
       rawSql: queryModel.render(this.interpolateVariable as any),
       format: target.format,
+      timezone: target.timezone,
     };
   }
 
+  query(request: DataQueryRequest<PostgresQuery>): Observable<DataQueryResponse> {
+    // Time grouping macros align buckets, such as days and weeks, to the dashboard time zone.
+    const timezone = getTimeZoneInfo(request.timezone, Date.now())?.ianaName;
+    return super.query({ ...request, targets: request.targets.map((target) => ({ ...target, timezone })) });
+  }
+
   async annotationQuery(options: any): Promise<AnnotationEvent[]> {
     if (!options.annotation.rawQuery) {
       return Promise.reject({
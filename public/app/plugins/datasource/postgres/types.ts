@@ -18,4 +18,5 @@ export interface PostgresQuery extends DataQuery {
   alias?: string;
   format?: ResultFormat;
   rawSql?: any;
+  timezone?: string;
 }
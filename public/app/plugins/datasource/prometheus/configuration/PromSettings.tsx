@@ -95,6 +95,25 @@ export const PromSettings = (props: Props) => {
             tooltip="Checking this option will disable the metrics chooser and metric/label support in the query field's autocomplete. This helps if you have performance issues with bigger Prometheus instances."
           />
         </div>
+        <div className="gf-form-inline">
+          <div className="gf-form">
+            <FormField
+              label="Metadata cache duration"
+              labelWidth={14}
+              inputEl={
+                <Input
+                  className="width-6"
+                  value={options.jsonData.metadataCacheDuration}
+                  onChange={onChangeHandler('metadataCacheDuration', options, onOptionsChange)}
+                  spellCheck={false}
+                  placeholder="1m"
+                  validationEvents={promSettingsValidationEvents}
+                />
+              }
+              tooltip="How long label names, label values, series and metric metadata are cached by the Grafana server. Set to 0s to disable caching. Defaults to 1m."
+            />
+          </div>
+        </div>
         <div className="gf-form-inline">
           <div className="gf-form max-width-30">
             <FormField
@@ -124,6 +124,21 @@ describe('PrometheusDatasource', () => {
       expect(fetchMock.mock.calls[0][0].url).not.toContain('bar=baz%20baz&foo=foo');
       expect(fetchMock.mock.calls[0][0].data).toEqual({ bar: 'baz baz', foo: 'foo' });
     });
+    it('should use the backend resource API with the server access mode', () => {
+      const proxySettings = { ...cloneDeep(instanceSettings), id: 7, access: 'proxy' } as typeof instanceSettings;
+      const promDs = new PrometheusDatasource(proxySettings, templateSrvStub as any, timeSrvStub as any);
+      promDs.metadataRequest('/api/v1/label/job/values', { 'match[]': 'up' });
+      expect(fetchMock.mock.calls.length).toBe(1);
+      expect(fetchMock.mock.calls[0][0].method).toBe('GET');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/7/resources/api/v1/label/job/values');
+      expect(fetchMock.mock.calls[0][0].params).toEqual({ 'match[]': 'up' });
+    });
+    it('should not use the backend resource API for other endpoints', () => {
+      const proxySettings = { ...cloneDeep(instanceSettings), id: 7, access: 'proxy' } as typeof instanceSettings;
+      const promDs = new PrometheusDatasource(proxySettings, templateSrvStub as any, timeSrvStub as any);
+      promDs.metadataRequest('/api/v1/query_exemplars', { query: 'test' });
+      expect(fetchMock.mock.calls[0][0].url).toContain('proxied/api/v1/query_exemplars');
+    });
   });
 
   describe('customQueryParams', () => {
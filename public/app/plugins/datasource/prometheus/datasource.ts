@@ -58,6 +58,14 @@ import { renderLegendFormat } from './legend';
 
 export const ANNOTATION_QUERY_STEP_DEFAULT = '60s';
 const GET_AND_POST_METADATA_ENDPOINTS = ['api/v1/query', 'api/v1/query_range', 'api/v1/series', 'api/v1/labels'];
+// Metadata endpoints that the backend serves with caching when the data source uses the server access mode.
+const RESOURCE_METADATA_ENDPOINTS = [
+  /^\/api\/v1\/labels$/,
+  /^\/api\/v1\/label\/[^/]+\/values$/,
+  /^\/api\/v1\/series$/,
+  /^\/api\/v1\/metadata$/,
+  /^\/api\/v1\/rules$/,
+];
 
 export class PrometheusDatasource
   extends DataSourceWithBackend<PromQuery, PromOptions>
@@ -188,6 +196,17 @@ export class PrometheusDatasource
 
   // Use this for tab completion features, wont publish response to other components
   async metadataRequest<T = any>(url: string, params = {}) {
+    if (this.access === 'proxy' && RESOURCE_METADATA_ENDPOINTS.some((endpoint) => endpoint.test(url))) {
+      return await lastValueFrom(
+        getBackendSrv().fetch<T>({
+          url: `/api/datasources/${this.id}/resources${url}`,
+          params,
+          method: 'GET',
+          hideFromInspector: true,
+        })
+      );
+    }
+
     // If URL includes endpoint that supports POST and GET method, try to use configured method. This might fail as POST is supported only in v2.10+.
     if (GET_AND_POST_METADATA_ENDPOINTS.some((endpoint) => url.includes(endpoint))) {
       try {
@@ -30,6 +30,7 @@ export interface PromOptions extends DataSourceJsonData {
   directUrl?: string;
   customQueryParameters?: string;
   disableMetricsLookup?: boolean;
+  metadataCacheDuration?: string;
   exemplarTraceIdDestinations?: ExemplarTraceIdDestination[];
 }
 
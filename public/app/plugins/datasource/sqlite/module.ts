@@ -0,0 +1,33 @@
+import { SqliteDatasource } from './datasource';
+import { SqliteQueryCtrl } from './query_ctrl';
+import { SqliteConfigCtrl } from './config_ctrl';
+import { SqliteQuery } from './types';
+import { DataSourcePlugin } from '@grafana/data';
+
+const defaultQuery = `SELECT
+    $__time(<time_column>),
+    <text_column> as text,
+    <tags_column> as tags
+  FROM
+    <table name>
+  WHERE
+    $__timeFilter(time_column)
+  ORDER BY
+    <time_column> ASC`;
+
+class SqliteAnnotationsQueryCtrl {
+  static templateUrl = 'partials/annotations.editor.html';
+
+  declare annotation: any;
+
+  /** @ngInject */
+  constructor($scope: any) {
+    this.annotation = $scope.ctrl.annotation;
+    this.annotation.rawQuery = this.annotation.rawQuery || defaultQuery;
+  }
+}
+
+export const plugin = new DataSourcePlugin<SqliteDatasource, SqliteQuery>(SqliteDatasource)
+  .setQueryCtrl(SqliteQueryCtrl)
+  .setConfigCtrl(SqliteConfigCtrl)
+  .setAnnotationQueryCtrl(SqliteAnnotationsQueryCtrl);
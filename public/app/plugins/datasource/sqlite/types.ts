@@ -0,0 +1,23 @@
+import { DataQuery, DataSourceJsonData } from '@grafana/data';
+
+export interface SqliteQueryForInterpolation {
+  alias?: any;
+  format?: any;
+  rawSql?: any;
+  refId: any;
+  hide?: any;
+}
+
+export type ResultFormat = 'time_series' | 'table';
+
+export interface SqliteQuery extends DataQuery {
+  alias?: string;
+  format?: ResultFormat;
+  rawSql?: any;
+  timezone?: string;
+}
+
+export interface SqliteOptions extends DataSourceJsonData {
+  path: string;
+  timeInterval: string;
+}